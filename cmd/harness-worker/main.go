@@ -0,0 +1,54 @@
+// Command harness-worker executes tool calls for a remote harness server.
+// Run it on the machine that holds the code; point the server at it with
+// HARNESS_REMOTE_WORKER_URL.
+package main
+
+import (
+	"fmt"
+	stdlog "log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/remote"
+	"github.com/user/harness/pkg/tool"
+)
+
+func main() {
+	logConfig, _ := log.LoadFromEnv()
+	logger := log.NewLogger(logConfig)
+
+	// Without a token anyone who can reach the worker can run its tools, so
+	// it listens only on loopback
+	token := os.Getenv("HARNESS_WORKER_TOKEN")
+	addr, err := remote.ListenAddr(os.Getenv("HARNESS_WORKER_ADDR"), token)
+	if err != nil {
+		stdlog.Fatalf("Invalid HARNESS_WORKER_ADDR: %v", err)
+	}
+	if token == "" {
+		logger.Warn("harness", "HARNESS_WORKER_TOKEN is not set: accepting unauthenticated jobs on loopback only", log.F("addr", addr))
+	}
+
+	// Serve the built-in tools, or the subset named in HARNESS_TOOLS
+	registry := tool.NewBuiltinRegistry()
+	var enabled []string
+	for _, name := range strings.Split(os.Getenv("HARNESS_TOOLS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			enabled = append(enabled, name)
+		}
+	}
+	if err := registry.SetEnabled(enabled); err != nil {
+		stdlog.Fatalf("Invalid HARNESS_TOOLS: %v", err)
+	}
+	tools := registry.Tools()
+	worker := remote.NewWorker(tools, token, logger)
+
+	logger.Info("harness", "Worker starting", log.F("addr", addr), log.F("tools", len(tools)))
+	fmt.Printf("Harness worker starting on %s\n", addr)
+
+	if err := http.ListenAndServe(addr, worker.Handler()); err != nil {
+		logger.Error("harness", "Worker error", log.F("error", err.Error()))
+		stdlog.Fatalf("Worker error: %v", err)
+	}
+}
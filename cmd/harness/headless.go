@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/server"
+)
+
+// Exit codes of harness run.
+const (
+	exitCompleted = 0 // the run completed
+	exitFailed    = 1 // the run failed, was cancelled, or hit a limit
+	exitUsage     = 2 // invalid arguments
+)
+
+// runHeadless runs a single prompt without the HTTP server and returns the
+// process exit code:
+//
+//	harness run [-format text|json] [-profile NAME] [-model MODEL] [-max-turns N] [-workdir DIR] PROMPT
+//
+// The prompt is read from stdin when PROMPT is "-" or missing. The text
+// format writes the assistant's text to stdout and tool activity to stderr;
+// the json format writes every event to stdout as NDJSON, in the format of
+// POST /prompt/stream, ending with a done event. It is configured from the
+// same environment variables as the server.
+func runHeadless(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	format := flags.String("format", "text", "output format: text or json")
+	profile := flags.String("profile", "", "agent profile to use instead of HARNESS_PROFILE")
+	model := flags.String("model", "", "model to use instead of HARNESS_MODEL")
+	maxTurns := flags.Int("max-turns", 0, "turn limit of the run")
+	workdir := flags.String("workdir", os.Getenv("HARNESS_WORKDIR"), "directory to jail tool paths and commands to")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: harness run [-format text|json] [-profile NAME] [-model MODEL] [-max-turns N] [-workdir DIR] PROMPT")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "harness: unknown format %q\n", *format)
+		return exitUsage
+	}
+	prompt, err := headlessPrompt(flags.Args(), os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "harness: %v\n", err)
+		return exitUsage
+	}
+
+	logConfig, agentLogConfig := log.LoadFromEnv()
+	logger := log.NewLogger(logConfig)
+	agentLogger := log.NewAgentLogger(agentLogConfig)
+	if agentLogger != nil {
+		defer agentLogger.Close()
+	}
+	shutdownTracing := setupTracing(logger)
+	defer shutdownTracing()
+
+	h, _, _ := newHarness(logger, *workdir)
+	output := &headlessHandler{out: os.Stdout, progress: os.Stderr, json: *format == "json"}
+	eventHandler := bindEventHandler(h, output, agentLogger, logger)
+
+	// Cancel the run on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := harness.PromptOptions{Profile: *profile, Model: *model, MaxTurns: *maxTurns}
+	if err := h.ValidateOptions(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "harness: %v\n", err)
+		return exitUsage
+	}
+
+	// Name the run up front so the agent log ties the prompt to it
+	runID := newRunID()
+	ctx = log.WithRunID(ctx, runID)
+	eventHandler.LogUserPrompt(runID, prompt)
+	result, err := h.PromptWithOptions(ctx, prompt, opts)
+	output.OnDone(result, err)
+	if err != nil {
+		return exitFailed
+	}
+	return exitCompleted
+}
+
+// newRunID returns a random run identifier in the harness's format.
+func newRunID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "run_" + hex.EncodeToString(buf)
+}
+
+// headlessPrompt returns the prompt of harness run: its argument, or stdin
+// when the argument is "-" or missing.
+func headlessPrompt(args []string, stdin io.Reader) (string, error) {
+	if len(args) > 1 {
+		return "", errors.New("expected a single prompt argument; quote it")
+	}
+	prompt := "-"
+	if len(args) == 1 {
+		prompt = args[0]
+	}
+	if prompt == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the prompt: %w", err)
+		}
+		prompt = string(data)
+	}
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return "", errors.New("prompt is empty")
+	}
+	return prompt, nil
+}
+
+// headlessHandler writes the events of harness run to stdout and stderr.
+type headlessHandler struct {
+	mu       sync.Mutex
+	out      io.Writer // assistant text, or every event with json
+	progress io.Writer // tool activity without json
+	json     bool
+}
+
+// emit writes event as an NDJSON line.
+func (h *headlessHandler) emit(event server.Event) {
+	event.Timestamp = time.Now().Unix()
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.out.Write(append(data, '\n'))
+}
+
+// printf writes a line of text output to w.
+func (h *headlessHandler) printf(w io.Writer, format string, args ...any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, format, args...)
+}
+
+// OnText writes the assistant's text.
+func (h *headlessHandler) OnText(text string) {
+	if h.json {
+		h.emit(server.Event{Type: "text", Content: text})
+		return
+	}
+	h.printf(h.out, "%s\n", text)
+}
+
+// OnToolCall reports a tool call.
+func (h *headlessHandler) OnToolCall(id string, name string, input json.RawMessage) {
+	if h.json {
+		h.emit(server.Event{Type: "tool_call", ID: id, Name: name, Input: input})
+		return
+	}
+	h.printf(h.progress, "→ %s %s\n", name, truncateLine(string(input), 200))
+}
+
+// OnToolResult reports a tool result; the text format shows only errors.
+func (h *headlessHandler) OnToolResult(id string, result string, isError bool) {
+	if h.json {
+		h.emit(server.Event{Type: "tool_result", ID: id, Result: result, IsError: isError})
+		return
+	}
+	if isError {
+		h.printf(h.progress, "✗ %s\n", truncateLine(result, 200))
+	}
+}
+
+// OnReasoning reports the model's reasoning; the text format omits it.
+func (h *headlessHandler) OnReasoning(content string) {
+	if h.json {
+		h.emit(server.Event{Type: "reasoning", Content: content})
+	}
+}
+
+// OnStreamReset reports that the turn is streamed again after a failure; the
+// text format cannot take back what it printed, so it says so on stderr.
+func (h *headlessHandler) OnStreamReset(err error) {
+	if h.json {
+		h.emit(server.Event{Type: "stream_reset", Message: err.Error()})
+		return
+	}
+	h.printf(h.progress, "↺ turn failed partway, repeating it: %s\n", truncateLine(err.Error(), 200))
+}
+
+// OnDone reports the end of the run: a done event with json, or the state
+// and error of an unsuccessful run on stderr.
+func (h *headlessHandler) OnDone(result harness.RunResult, err error) {
+	state := headlessState(err)
+	if h.json {
+		event := server.Event{
+			Type:        "done",
+			RunID:       result.RunID,
+			State:       state,
+			Content:     result.FinalText,
+			StopReason:  result.StopReason,
+			Turns:       result.Turns,
+			Usage:       &result.Usage,
+			DurationMs:  result.DurationMs,
+			NeedsReview: result.NeedsReview,
+		}
+		if err != nil {
+			event.Message = err.Error()
+		}
+		h.emit(event)
+		return
+	}
+	if err != nil {
+		h.printf(h.progress, "harness: run %s: %v\n", state, err)
+	}
+}
+
+// headlessState returns the run state of a run that ended with err, as in
+// the server's done events.
+func headlessState(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	case errors.Is(err, harness.ErrMaxTurnsExceeded):
+		return "max_turns_exceeded"
+	case errors.Is(err, harness.ErrBudgetExceeded):
+		return "budget_exceeded"
+	case err != nil:
+		return "failed"
+	}
+	return "completed"
+}
+
+// truncateLine returns s on one line, cut to at most n bytes.
+func truncateLine(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > n {
+		return s[:n] + "…"
+	}
+	return s
+}
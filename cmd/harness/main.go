@@ -1,18 +1,57 @@
-// Command harness runs the AI agent server with file tools.
+// Command harness runs the AI agent server with file tools:
+//
+//	harness [-addr ADDR] [-workdir DIR]
+//
+// listens on ADDR (default HARNESS_ADDR, then :8080) and jails tool paths
+// and commands to DIR (default HARNESS_WORKDIR, then unrestricted).
+//
+//	harness run [-format text|json] PROMPT
+//
+// runs a single prompt without the server instead (see runHeadless), and
+//
+//	harness mcp [-transport stdio|sse]
+//
+// serves the tools to other agents over MCP (see runMCPServer).
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	stdlog "log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/user/harness/pkg/harness"
 	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/mcp"
+	"github.com/user/harness/pkg/remote"
 	"github.com/user/harness/pkg/server"
 	"github.com/user/harness/pkg/tool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 func main() {
+	// Run a single prompt without the server: harness run PROMPT
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(runHeadless(os.Args[2:]))
+	}
+	// Serve the tools over MCP without the model loop: harness mcp
+	if len(os.Args) > 1 && os.Args[1] == "mcp" {
+		os.Exit(runMCPServer(os.Args[2:]))
+	}
+
 	// Initialize logging from environment
 	logConfig, agentLogConfig := log.LoadFromEnv()
 	logger := log.NewLogger(logConfig)
@@ -23,8 +62,148 @@ func main() {
 		defer agentLogger.Close()
 	}
 
+	addr := flag.String("addr", getEnvOrDefault("HARNESS_ADDR", ":8080"), "address to listen on; 0.0.0.0:PORT or :PORT in a container")
+	workdir := flag.String("workdir", os.Getenv("HARNESS_WORKDIR"), "directory to jail tool paths and commands to")
+	flag.Parse()
+
 	logger.Info("harness", "Starting harness server")
 
+	// Export traces over OTLP, if an endpoint is configured
+	shutdownTracing := setupTracing(logger)
+	defer shutdownTracing()
+
+	h, registry, config := newHarness(logger, *workdir)
+	tools := registry.Tools()
+
+	// Create the server for the harness
+	srv := server.NewServer(h, *addr, logger)
+	srv.SetToolRegistry(registry)
+	srv.SetEventHistorySize(getEnvIntOrDefault("HARNESS_EVENT_HISTORY", server.DefaultEventHistorySize))
+	srv.SetClientBufferSize(getEnvIntOrDefault("HARNESS_SSE_BUFFER", server.DefaultClientBufferSize))
+	srv.SetClientStallTimeout(getEnvDurationOrDefault("HARNESS_SSE_STALL_TIMEOUT", server.DefaultClientStallTimeout))
+	srv.SetPromptQueueSize(getEnvIntOrDefault("HARNESS_PROMPT_QUEUE", 10))
+	srv.SetRateLimit(rateLimitConfig())
+	if err := srv.SetTrustedProxies(getEnvList("HARNESS_TRUSTED_PROXIES")); err != nil {
+		logger.Error("harness", "Invalid HARNESS_TRUSTED_PROXIES", log.F("error", err.Error()))
+		stdlog.Fatalf("Invalid HARNESS_TRUSTED_PROXIES: %v", err)
+	}
+	if err := srv.SetWebhookAllowlist(getEnvList("HARNESS_WEBHOOK_ALLOWED_NETWORKS")); err != nil {
+		logger.Error("harness", "Invalid HARNESS_WEBHOOK_ALLOWED_NETWORKS", log.F("error", err.Error()))
+		stdlog.Fatalf("Invalid HARNESS_WEBHOOK_ALLOWED_NETWORKS: %v", err)
+	}
+	if err := srv.SetCloneAllowlist(getEnvList("HARNESS_CLONE_ALLOWED_NETWORKS")); err != nil {
+		logger.Error("harness", "Invalid HARNESS_CLONE_ALLOWED_NETWORKS", log.F("error", err.Error()))
+		stdlog.Fatalf("Invalid HARNESS_CLONE_ALLOWED_NETWORKS: %v", err)
+	}
+	srv.SetMaxUploadSize(int64(getEnvIntOrDefault("HARNESS_MAX_UPLOAD_BYTES", server.DefaultMaxUploadBytes)))
+	srv.SetPromptLimits(server.PromptLimits{
+		MaxBytes: int64(getEnvIntOrDefault("HARNESS_MAX_PROMPT_BYTES", server.DefaultMaxPromptBytes)),
+		MaxChars: getEnvIntOrDefault("HARNESS_MAX_PROMPT_CHARS", server.DefaultMaxPromptChars),
+	})
+	srv.SetAuth(server.AuthConfig{
+		BearerToken: os.Getenv("HARNESS_AUTH_TOKEN"),
+		APIKey:      os.Getenv("HARNESS_API_KEY"),
+	})
+	srv.SetWebUI(os.Getenv("HARNESS_WEB_UI") != "false")
+	if os.Getenv("HARNESS_ADMIN") == "true" {
+		srv.EnableAdmin()
+	}
+	if err := srv.SetCORS(corsConfig()); err != nil {
+		logger.Error("harness", "Invalid CORS configuration", log.F("error", err.Error()))
+		stdlog.Fatalf("Invalid CORS configuration: %v", err)
+	}
+
+	// Broadcast the harness's events to the server's clients
+	eventHandler := bindEventHandler(h, srv.EventHandler(), agentLogger, logger)
+
+	// Set up user prompt logging for agent interaction log
+	srv.SetUserPromptLogger(eventHandler.LogUserPrompt)
+
+	logger.Info("harness", "Server configured",
+		log.F("addr", *addr),
+		log.F("workdir", config.Workspace),
+		log.F("model", config.Model),
+		log.F("tools", toolNames(tools)),
+		log.F("auth", os.Getenv("HARNESS_AUTH_TOKEN") != "" || os.Getenv("HARNESS_API_KEY") != ""),
+	)
+
+	// Persist the conversation and resume it after a restart, if configured
+	if dir := os.Getenv("HARNESS_CONVERSATION_DIR"); dir != "" {
+		store, err := harness.NewFileStore(dir)
+		if err != nil {
+			logger.Error("harness", "Failed to open conversation store", log.F("error", err.Error()))
+			stdlog.Fatalf("Failed to open conversation store: %v", err)
+		}
+		id := getEnvOrDefault("HARNESS_CONVERSATION_ID", "default")
+		if err := h.SetStore(store, id); err != nil {
+			stdlog.Fatalf("Invalid conversation id: %v", err)
+		}
+		if err := h.LoadConversation(id); err != nil && !errors.Is(err, harness.ErrConversationNotFound) {
+			logger.Error("harness", "Failed to load conversation", log.F("error", err.Error()))
+			stdlog.Fatalf("Failed to load conversation: %v", err)
+		}
+	}
+
+	// Persist run transcripts and annotations, if configured
+	if dir := os.Getenv("HARNESS_RUNS_DIR"); dir != "" {
+		if err := srv.EnableRunStore(dir); err != nil {
+			logger.Error("harness", "Failed to enable run store", log.F("error", err.Error()))
+			stdlog.Fatalf("Failed to enable run store: %v", err)
+		}
+	}
+
+	// Ask clients to approve destructive tool calls, if configured
+	if os.Getenv("HARNESS_APPROVAL") == "true" {
+		srv.EnableApproval(getEnvList("HARNESS_APPROVAL_TOOLS"))
+	}
+
+	// Manage project checkouts under a workspace root, if configured
+	if root := os.Getenv("HARNESS_WORKSPACE_ROOT"); root != "" {
+		if err := srv.EnableWorkspaces(root); err != nil {
+			logger.Error("harness", "Failed to enable workspaces", log.F("error", err.Error()))
+			stdlog.Fatalf("Failed to enable workspaces: %v", err)
+		}
+	}
+
+	// Summarize the session after a period with no clients and no prompts
+	srv.StartIdleMonitor(context.Background(), server.IdleConfig{
+		Timeout:    getEnvDurationOrDefault("HARNESS_IDLE_TIMEOUT", 0),
+		SummaryDir: getEnvOrDefault("HARNESS_SUMMARY_DIR", ".harness/summaries"),
+	})
+
+	fmt.Printf("Harness server starting on %s\n", *addr)
+	fmt.Printf("Model: %s\n", config.Model)
+	fmt.Printf("Tools: %s\n", strings.ReplaceAll(toolNames(tools), ",", ", "))
+
+	// Shut down gracefully on SIGINT or SIGTERM, letting the running prompt
+	// finish for up to HARNESS_SHUTDOWN_TIMEOUT before cancelling it
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		sig := <-signals
+		signal.Stop(signals)
+		logger.Info("harness", "Shutting down", log.F("signal", sig.String()))
+		ctx, cancel := context.WithTimeout(context.Background(), getEnvDurationOrDefault("HARNESS_SHUTDOWN_TIMEOUT", 30*time.Second))
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Warn("harness", "Shutdown incomplete", log.F("error", err.Error()))
+		}
+	}()
+
+	if err := srv.ListenAndServe(); err != nil {
+		logger.Error("harness", "Server error", log.F("error", err.Error()))
+		stdlog.Fatalf("Server error: %v", err)
+	}
+	// Wait for Shutdown to finish before the deferred log file close
+	<-stopped
+	logger.Info("harness", "Server stopped")
+}
+
+// newHarness configures a harness and its tools from the environment. Its
+// event handler is set by the caller.
+func newHarness(logger log.Logger, workdir string) (*harness.Harness, *tool.Registry, harness.Config) {
 	// Get API key from environment
 	apiKey := os.Getenv("ANTHROPIC_API_KEY")
 	if apiKey == "" {
@@ -41,57 +220,173 @@ func main() {
 		MaxTokens:    harness.DefaultMaxTokens,
 		MaxTurns:     harness.DefaultMaxTurns,
 		SystemPrompt: systemPrompt,
+
+		Temperature:   getEnvFloat("HARNESS_TEMPERATURE"),
+		TopP:          getEnvFloat("HARNESS_TOP_P"),
+		StopSequences: getEnvList("HARNESS_STOP_SEQUENCES"),
+
+		EnableWebSearch:     os.Getenv("HARNESS_WEB_SEARCH") == "true",
+		MaxMutatingFailures: getEnvIntOrDefault("HARNESS_MAX_MUTATING_FAILURES", 0),
+		ToolEventTypes:      getEnvList("HARNESS_TOOL_EVENT_TYPES"),
+		APILatencySLO: harness.SLO{
+			P50: getEnvDurationOrDefault("HARNESS_SLO_API_P50", 0),
+			P95: getEnvDurationOrDefault("HARNESS_SLO_API_P95", 0),
+		},
+		ToolLatencySLO: harness.SLO{
+			P50: getEnvDurationOrDefault("HARNESS_SLO_TOOL_P50", 0),
+			P95: getEnvDurationOrDefault("HARNESS_SLO_TOOL_P95", 0),
+		},
+		SLOWindow: getEnvDurationOrDefault("HARNESS_SLO_WINDOW", harness.DefaultSLOWindow),
+
+		MaxRetries:     getEnvIntOrDefault("HARNESS_MAX_RETRIES", 3),
+		RetryBaseDelay: getEnvDurationOrDefault("HARNESS_RETRY_BASE_DELAY", harness.DefaultRetryBaseDelay),
+		FallbackModels: getEnvList("HARNESS_FALLBACK_MODELS"),
+
+		CompactionThreshold:    getEnvIntOrDefault("HARNESS_COMPACTION_THRESHOLD", 0),
+		CompactionKeepMessages: getEnvIntOrDefault("HARNESS_COMPACTION_KEEP_MESSAGES", harness.DefaultCompactionKeepMessages),
+		ContextWindow:          getEnvIntOrDefault("HARNESS_CONTEXT_WINDOW", harness.DefaultContextWindow),
+		CountTokens:            os.Getenv("HARNESS_COUNT_TOKENS") == "true",
+
+		OutputSummaries: outputSummaries(getEnvList("HARNESS_SUMMARIZE_OUTPUT"), os.Getenv("HARNESS_OUTPUT_SUMMARY_MODEL")),
+		ArtifactDir:     os.Getenv("HARNESS_ARTIFACT_DIR"),
+
+		Prices:          loadPrices(os.Getenv("HARNESS_PRICES_FILE"), logger),
+		Profiles:        loadProfiles(os.Getenv("HARNESS_PROFILES_FILE"), logger),
+		Profile:         os.Getenv("HARNESS_PROFILE"),
+		IgnorePatterns:  ignorePatterns(os.Getenv("HARNESS_IGNORE")),
+		ToolImages:      os.Getenv("HARNESS_TOOL_IMAGES") == "true",
+		Workspace:       resolveWorkdir(workdir),
+		GitReadOnly:     os.Getenv("HARNESS_GIT_READ_ONLY") == "true",
+		MaxLineLength:   getEnvIntOrDefault("HARNESS_MAX_LINE_LENGTH", 0),
+		ReadCacheBytes:  int64(getEnvIntOrDefault("HARNESS_READ_CACHE_BYTES", 0)),
+		PromptCaching:   os.Getenv("HARNESS_PROMPT_CACHING") != "false",
+		Checkpoints:     getEnvIntOrDefault("HARNESS_CHECKPOINTS", 20),
+		CheckpointFiles: os.Getenv("HARNESS_CHECKPOINT_FILES") != "false",
+		ToolTimeout:     getEnvDurationOrDefault("HARNESS_TOOL_TIMEOUT", 10*time.Minute),
+		TaskMaxTurns:    getEnvIntOrDefault("HARNESS_TASK_MAX_TURNS", 0),
+
+		MaxToolResultBytes: getEnvIntOrDefault("HARNESS_MAX_RESULT_BYTES", 100000),
+		ToolResultLimits:   toolResultLimits(getEnvList("HARNESS_TOOL_RESULT_LIMITS")),
+
+		MaxCostUSD:         getEnvFloatOrDefault("HARNESS_MAX_COST_USD", 0),
+		MaxTokensPerPrompt: getEnvIntOrDefault("HARNESS_MAX_TOKENS_PER_PROMPT", 0),
+	}
+
+	registry := newToolRegistry(logger)
+
+	// Let the model delegate work to sub-agents using the tools above
+	if err := registry.Register(harness.NewTaskTool()); err != nil {
+		stdlog.Fatalf("Failed to register task tool: %v", err)
+	}
+
+	// Let the model ask the user for clarification through the server
+	if err := registry.Register(harness.NewAskUserTool()); err != nil {
+		stdlog.Fatalf("Failed to register ask_user tool: %v", err)
+	}
+
+	// Restrict the model to a subset of the tools, if configured
+	if err := registry.SetEnabled(getEnvList("HARNESS_TOOLS")); err != nil {
+		logger.Error("harness", "Invalid HARNESS_TOOLS", log.F("error", err.Error()))
+		stdlog.Fatalf("Invalid HARNESS_TOOLS: %v", err)
 	}
 
-	// Create tools
-	tools := []tool.Tool{
-		tool.NewReadTool(),
-		tool.NewListDirTool(),
-		tool.NewGrepTool(),
-		tool.NewBashTool(),
-		tool.NewWriteTool(),
-		tool.NewEditTool(),
-		tool.NewMoveTool(),
+	// Keep the model from changing a read-only working directory, such as
+	// a read-only container mount; a remote worker's files are elsewhere
+	if os.Getenv("HARNESS_REMOTE_WORKER_URL") == "" && readOnlyDir(config.Workspace) {
+		config.GitReadOnly = true
+		disableFileTools(registry, logger)
 	}
+	warnIfRoot(registry, logger)
 
-	// Create harness with nil handler initially
-	h, err := harness.NewHarness(config, tools, nil)
+	// The handler is bound later with bindEventHandler, since the server's
+	// handler needs the harness first
+	h, err := harness.NewHarness(config, registry.Tools(), nil)
 	if err != nil {
 		logger.Error("harness", "Failed to create harness", log.F("error", err.Error()))
 		stdlog.Fatalf("Failed to create harness: %v", err)
 	}
+	return h, registry, config
+}
 
-	// Create server (only once)
-	addr := getEnvOrDefault("HARNESS_ADDR", ":8080")
-	srv := server.NewServer(h, addr, logger)
+// newToolRegistry returns a registry of the tools that run without a
+// harness: the built-in tools, or a remote worker's, and the tools of the
+// configured MCP servers.
+func newToolRegistry(logger log.Logger) *tool.Registry {
+	registry := tool.NewBuiltinRegistry()
 
-	// Create logging event handler that wraps SSE handler
-	// This logs agent interactions to file while still broadcasting to SSE clients
-	eventHandler := log.NewLoggingEventHandler(srv.EventHandler(), agentLogger)
+	// Execute tools on a remote worker instead, if configured
+	if workerURL := os.Getenv("HARNESS_REMOTE_WORKER_URL"); workerURL != "" {
+		client := remote.NewClient(workerURL, os.Getenv("HARNESS_WORKER_TOKEN"))
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		remoteTools, err := client.Tools(ctx)
+		cancel()
+		if err != nil {
+			logger.Error("harness", "Failed to reach remote worker", log.F("error", err.Error()))
+			stdlog.Fatalf("Failed to reach remote worker: %v", err)
+		}
+		registry = tool.NewRegistry()
+		for _, t := range remoteTools {
+			if err := registry.Register(t); err != nil {
+				stdlog.Fatalf("Invalid remote tool: %v", err)
+			}
+		}
+		logger.Info("harness", "Using remote worker", log.F("url", workerURL), log.F("tools", len(remoteTools)))
+	}
 
-	// Set the event handler on the existing harness
-	// This ensures events are broadcast to the same server instance handling HTTP requests
-	h.SetEventHandler(eventHandler)
+	// Add tools from MCP servers, if configured
+	if path := os.Getenv("HARNESS_MCP_CONFIG"); path != "" {
+		registerMCPTools(path, registry, logger)
+	}
+	return registry
+}
 
-	// Set logger on harness for API and tool logging
+// bindEventHandler sets handler as h's event handler, wrapped to also
+// record agent interactions in agentLogger, and logger as its logger for
+// API and tool logging. It returns the wrapping handler.
+func bindEventHandler(h *harness.Harness, handler harness.EventHandler, agentLogger log.AgentLogger, logger log.Logger) *log.LoggingEventHandler {
+	eventHandler := log.NewLoggingEventHandler(handler, agentLogger)
+	h.SetEventHandler(eventHandler)
 	h.SetLogger(logger)
+	return eventHandler
+}
 
-	// Set up user prompt logging for agent interaction log
-	srv.SetUserPromptLogger(eventHandler.LogUserPrompt)
-
-	logger.Info("harness", "Server configured",
-		log.F("addr", addr),
-		log.F("model", config.Model),
-		log.F("tools", "read,list_dir,grep,bash,write,edit,move"),
+// setupTracing installs a TracerProvider exporting spans over OTLP/HTTP
+// when OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is
+// set. The exporter reads the other standard OTEL_EXPORTER_OTLP_* variables,
+// and the resource OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES. The
+// returned function flushes pending spans.
+func setupTracing(logger log.Logger) func() {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func() {}
+	}
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		logger.Error("harness", "Failed to create trace exporter", log.F("error", err.Error()))
+		stdlog.Fatalf("Failed to create trace exporter: %v", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "harness")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
 	)
+	if err != nil {
+		logger.Warn("harness", "Invalid trace resource", log.F("error", err.Error()))
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	logger.Info("harness", "Tracing enabled")
 
-	fmt.Printf("Harness server starting on %s\n", addr)
-	fmt.Printf("Model: %s\n", config.Model)
-	fmt.Printf("Tools: read, list_dir, grep, bash, write, edit, move\n")
-
-	if err := srv.ListenAndServe(); err != nil {
-		logger.Error("harness", "Server error", log.F("error", err.Error()))
-		stdlog.Fatalf("Server error: %v", err)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			logger.Warn("harness", "Failed to flush traces", log.F("error", err.Error()))
+		}
 	}
 }
 
@@ -102,6 +397,117 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// rateLimitConfig reads the prompt rate limits from the environment: prompts
+// per minute per client IP and across clients, each with a burst that
+// defaults to its rate.
+func rateLimitConfig() server.RateLimitConfig {
+	perIP := getEnvIntOrDefault("HARNESS_RATE_LIMIT_PER_IP", 0)
+	global := getEnvIntOrDefault("HARNESS_RATE_LIMIT_GLOBAL", 0)
+	return server.RateLimitConfig{
+		PerIP: server.RateLimit{
+			PerMinute: float64(perIP),
+			Burst:     getEnvIntOrDefault("HARNESS_RATE_LIMIT_PER_IP_BURST", perIP),
+		},
+		Global: server.RateLimit{
+			PerMinute: float64(global),
+			Burst:     getEnvIntOrDefault("HARNESS_RATE_LIMIT_GLOBAL_BURST", global),
+		},
+	}
+}
+
+// getEnvIntOrDefault returns the integer value of an environment variable,
+// or defaultValue if it is unset or not a valid integer.
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDurationOrDefault returns the duration value of an environment variable
+// (e.g., "30m"), or defaultValue if it is unset or invalid.
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloat returns the number value of an environment variable, or nil
+// if it is unset or not a valid number.
+func getEnvFloat(key string) *float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return &f
+		}
+	}
+	return nil
+}
+
+// getEnvFloatOrDefault returns the number value of an environment variable,
+// or defaultValue if it is unset or not a valid number.
+func getEnvFloatOrDefault(key string, defaultValue float64) float64 {
+	if f := getEnvFloat(key); f != nil {
+		return *f
+	}
+	return defaultValue
+}
+
+// getEnvList returns a comma-separated environment variable as a list,
+// skipping empty entries. Returns nil if the variable is unset.
+func getEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ignorePatterns parses HARNESS_IGNORE: a comma-separated list replacing the
+// default global ignore list, or "none" to disable it. Unset keeps the
+// default.
+func ignorePatterns(value string) []string {
+	if strings.TrimSpace(value) == "none" {
+		return []string{}
+	}
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// corsConfig reads the CORS configuration from HARNESS_CORS_*. Unset
+// HARNESS_CORS_ORIGINS allows any origin; "none" disallows cross-origin
+// requests.
+func corsConfig() server.CORSConfig {
+	config := server.DefaultCORSConfig()
+	switch origins := strings.TrimSpace(os.Getenv("HARNESS_CORS_ORIGINS")); origins {
+	case "":
+	case "none":
+		config.AllowedOrigins = nil
+	default:
+		config.AllowedOrigins = getEnvList("HARNESS_CORS_ORIGINS")
+	}
+	if methods := getEnvList("HARNESS_CORS_METHODS"); methods != nil {
+		config.AllowedMethods = methods
+	}
+	if headers := getEnvList("HARNESS_CORS_HEADERS"); headers != nil {
+		config.AllowedHeaders = headers
+	}
+	config.AllowCredentials = os.Getenv("HARNESS_CORS_CREDENTIALS") == "true"
+	config.MaxAge = getEnvDurationOrDefault("HARNESS_CORS_MAX_AGE", 0)
+	return config
+}
+
 // loadSystemPrompt reads the system prompt from a file.
 // Returns empty string if file doesn't exist or can't be read.
 func loadSystemPrompt(filePath string, logger log.Logger) string {
@@ -122,3 +528,117 @@ func loadSystemPrompt(filePath string, logger log.Logger) string {
 	)
 	return prompt
 }
+
+// loadPrices reads a JSON object of model prices keyed by model ID or prefix
+// (e.g. {"my-model": {"input": 3, "output": 15}}). Returns nil if path is empty.
+func loadPrices(path string, logger log.Logger) map[string]harness.ModelPrice {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Error("harness", "Failed to read price table", log.F("error", err.Error()))
+		stdlog.Fatalf("Failed to read price table: %v", err)
+	}
+	var prices map[string]harness.ModelPrice
+	if err := json.Unmarshal(data, &prices); err != nil {
+		logger.Error("harness", "Invalid price table", log.F("error", err.Error()))
+		stdlog.Fatalf("Invalid price table %s: %v", path, err)
+	}
+	logger.Info("harness", "Loaded price table", log.F("path", path), log.F("models", len(prices)))
+	return prices
+}
+
+// loadProfiles reads a JSON object of agent profiles keyed by name (e.g.
+// {"reviewer": {"system_prompt": "...", "tools": ["read", "grep"]}}).
+// Returns nil if path is empty.
+func loadProfiles(path string, logger log.Logger) map[string]harness.Profile {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Error("harness", "Failed to read profiles", log.F("error", err.Error()))
+		stdlog.Fatalf("Failed to read profiles: %v", err)
+	}
+	var profiles map[string]harness.Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		logger.Error("harness", "Invalid profiles", log.F("error", err.Error()))
+		stdlog.Fatalf("Invalid profiles %s: %v", path, err)
+	}
+	logger.Info("harness", "Loaded profiles", log.F("path", path), log.F("profiles", len(profiles)))
+	return profiles
+}
+
+// outputSummaries enables output distillation with default settings for the
+// named tools, optionally summarized by model.
+func outputSummaries(tools []string, model string) map[string]harness.OutputSummary {
+	if len(tools) == 0 {
+		return nil
+	}
+	summaries := make(map[string]harness.OutputSummary, len(tools))
+	for _, name := range tools {
+		summaries[name] = harness.OutputSummary{Model: model}
+	}
+	return summaries
+}
+
+// toolResultLimits parses HARNESS_TOOL_RESULT_LIMITS entries of the form
+// tool=bytes, where 0 disables the limit for the tool.
+func toolResultLimits(entries []string) map[string]int {
+	if len(entries) == 0 {
+		return nil
+	}
+	limits := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil {
+			stdlog.Fatalf("Invalid HARNESS_TOOL_RESULT_LIMITS entry %q: want tool=bytes", entry)
+		}
+		limits[strings.TrimSpace(name)] = limit
+	}
+	return limits
+}
+
+// registerMCPTools connects to the MCP servers configured in path and
+// registers their tools. A server that cannot be reached is logged and
+// skipped so the harness still starts.
+func registerMCPTools(path string, registry *tool.Registry, logger log.Logger) {
+	configs, err := mcp.LoadConfig(path)
+	if err != nil {
+		logger.Error("harness", "Failed to load MCP config", log.F("error", err.Error()))
+		stdlog.Fatalf("Failed to load MCP config: %v", err)
+	}
+	for _, config := range configs {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		client, err := mcp.Connect(ctx, config, logger)
+		var tools []tool.Tool
+		if err == nil {
+			tools, err = client.Tools(ctx)
+		}
+		cancel()
+		if err != nil {
+			logger.Error("harness", "Skipping MCP server", log.F("server", config.Name), log.F("error", err.Error()))
+			if client != nil {
+				client.Close()
+			}
+			continue
+		}
+		for _, t := range tools {
+			if err := registry.Register(t); err != nil {
+				logger.Warn("harness", "Skipping MCP tool", log.F("server", config.Name), log.F("error", err.Error()))
+			}
+		}
+		logger.Info("harness", "Using MCP server", log.F("server", config.Name), log.F("tools", len(tools)))
+	}
+}
+
+// toolNames returns the comma-separated names of tools.
+func toolNames(tools []tool.Tool) string {
+	names := make([]string, len(tools))
+	for i, t := range tools {
+		names[i] = t.Name()
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/mcp"
+	"github.com/user/harness/pkg/tool"
+)
+
+// runMCPServer serves the harness's tools to other agents over MCP, without
+// the model loop, and returns the process exit code:
+//
+//	harness mcp [-transport stdio|sse] [-addr ADDR] [-root DIR]
+//
+// The stdio transport serves one client on stdin and stdout, as when started
+// by a desktop app or IDE plugin; the sse transport listens on ADDR and
+// requires HARNESS_AUTH_TOKEN as a bearer token, if set. The tools are the
+// server's, restricted by HARNESS_TOOLS, except task and ask_user, which
+// need the model loop. -root, or HARNESS_WORKDIR, jails tool paths to DIR.
+func runMCPServer(args []string) int {
+	flags := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	transport := flags.String("transport", "stdio", "MCP transport: stdio or sse")
+	addr := flags.String("addr", getEnvOrDefault("HARNESS_ADDR", ":8080"), "listen address of the sse transport")
+	root := flags.String("root", os.Getenv("HARNESS_WORKDIR"), "directory to jail tool paths to")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: harness mcp [-transport stdio|sse] [-addr ADDR] [-root DIR]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() > 0 || (*transport != "stdio" && *transport != "sse") {
+		flags.Usage()
+		return exitUsage
+	}
+
+	// Logs go to stderr, so they stay out of the stdio transport
+	logConfig, _ := log.LoadFromEnv()
+	logger := log.NewLogger(logConfig)
+
+	registry := newToolRegistry(logger)
+	var enabled []string
+	for _, name := range getEnvList("HARNESS_TOOLS") {
+		if name != "task" && name != "ask_user" {
+			enabled = append(enabled, name)
+		}
+	}
+	if err := registry.SetEnabled(enabled); err != nil {
+		fmt.Fprintf(os.Stderr, "harness: invalid HARNESS_TOOLS: %v\n", err)
+		return exitUsage
+	}
+	tools := registry.Tools()
+	srv := mcp.NewServer(tools, os.Getenv("HARNESS_AUTH_TOKEN"), logger)
+
+	// Stop on SIGINT or SIGTERM, cancelling the tool calls in flight
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx = toolContext(ctx, *root)
+
+	logger.Info("harness", "Serving tools over MCP",
+		log.F("transport", *transport),
+		log.F("tools", toolNames(tools)),
+	)
+
+	if *transport == "stdio" {
+		if err := srv.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "harness: %v\n", err)
+			return exitFailed
+		}
+		return exitCompleted
+	}
+
+	httpServer := &http.Server{
+		Addr:        *addr,
+		Handler:     srv.Handler(),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+	fmt.Fprintf(os.Stderr, "Harness MCP server starting on %s\n", *addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "harness: %v\n", err)
+		return exitFailed
+	}
+	return exitCompleted
+}
+
+// toolContext returns ctx with the tool restrictions the harness applies to
+// its own calls: HARNESS_IGNORE, HARNESS_GIT_READ_ONLY,
+// HARNESS_MAX_LINE_LENGTH, and the workspace root, if any.
+func toolContext(ctx context.Context, root string) context.Context {
+	if root != "" {
+		ctx = tool.WithWorkspace(ctx, root)
+	}
+	if patterns := ignorePatterns(os.Getenv("HARNESS_IGNORE")); patterns != nil {
+		ctx = tool.WithIgnorePatterns(ctx, patterns)
+	}
+	if os.Getenv("HARNESS_GIT_READ_ONLY") == "true" {
+		ctx = tool.WithGitReadOnly(ctx, true)
+	}
+	if n := getEnvIntOrDefault("HARNESS_MAX_LINE_LENGTH", 0); n > 0 {
+		ctx = tool.WithMaxLineLength(ctx, n)
+	}
+	return ctx
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// fileTools are the tools that change files in the working directory,
+// disabled when it is read-only. The git tools are made read-only instead,
+// and bash and archive are kept for commands that write elsewhere.
+var fileTools = []string{"write", "edit", "multi_edit", "patch", "move", "mkdir", "touch", "delete", "memory"}
+
+// resolveWorkdir returns the absolute path of the -workdir directory, or ""
+// if none is set, and exits if it is not a directory.
+func resolveWorkdir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(dir)
+	if err == nil {
+		var info os.FileInfo
+		if info, err = os.Stat(abs); err == nil && !info.IsDir() {
+			err = os.ErrInvalid
+		}
+	}
+	if err != nil {
+		stdlog.Fatalf("Invalid working directory %s: %v", dir, err)
+	}
+	return abs
+}
+
+// readOnlyDir reports whether files cannot be created in dir, as in a
+// container whose workspace is mounted read-only.
+func readOnlyDir(dir string) bool {
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, ".harness-write-check-*")
+	if err != nil {
+		return true
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return false
+}
+
+// disableFileTools disables the enabled tools among fileTools.
+func disableFileTools(registry *tool.Registry, logger log.Logger) {
+	var names []string
+	for _, name := range fileTools {
+		if registry.IsEnabled(name) {
+			names = append(names, name)
+		}
+	}
+	registry.Disable(names...)
+	logger.Warn("harness", "Working directory is read-only; disabled the tools that change files",
+		log.F("tools", strings.Join(names, ",")),
+	)
+}
+
+// warnIfRoot warns that bash commands run as root, which a container should
+// avoid by running as an unprivileged user.
+func warnIfRoot(registry *tool.Registry, logger log.Logger) {
+	if os.Geteuid() == 0 && registry.IsEnabled("bash") {
+		logger.Warn("harness", "Running as root: bash commands have root privileges; run as an unprivileged user, as the Docker image does")
+	}
+}
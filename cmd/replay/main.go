@@ -0,0 +1,89 @@
+// Command replay reads an agent log written by the harness with
+// HARNESS_AGENT_LOG in the json format, the default.
+//
+//	replay conversation [-run ID] LOG
+//
+// prints the conversation the log records as a JSON array of messages, the
+// format of a FileStore conversation file.
+//
+//	replay run [-run ID] LOG
+//
+// re-drives a harness through the logged runs with the logged API responses
+// and tool results, and reports where its requests differ from the logged
+// ones. It exits with status 1 if any do.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/replay"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: replay conversation|run [-run ID] LOG")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	runID := flags.String("run", "", "only use the entries of this run")
+	flags.Parse(os.Args[2:])
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	entries, err := readLog(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		os.Exit(1)
+	}
+	if *runID != "" {
+		entries = replay.FilterRun(entries, *runID)
+	}
+
+	switch command {
+	case "conversation":
+		messages, err := replay.Conversation(entries)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+			os.Exit(1)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(messages)
+	case "run":
+		report, err := replay.Run(context.Background(), entries)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Replayed %d runs, %d requests\n", report.Runs, report.Requests)
+		for _, d := range report.Divergences {
+			fmt.Printf("DIVERGED %s\n", d)
+		}
+		if len(report.Divergences) > 0 {
+			os.Exit(1)
+		}
+	default:
+		usage()
+	}
+}
+
+// readLog reads the entries of the log at path.
+func readLog(path string) ([]log.AgentEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return replay.Read(f)
+}
@@ -0,0 +1,255 @@
+// Package client is a Go client for the harness HTTP server.
+//
+// Subscribe streams events with content deduplication enabled: the server
+// sends each large body once and references it by hash afterwards. The
+// client caches bodies and resolves references transparently, fetching
+// bodies it has not seen from GET /content/{hash}. A body the server no
+// longer holds is left empty, and its field stays in the event's Refs.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/user/harness/pkg/server"
+)
+
+const (
+	// maxCachedBodies bounds the number of deduplicated bodies kept.
+	maxCachedBodies = 1000
+	// maxEventSize is the largest SSE line the client accepts.
+	maxEventSize = 16 << 20
+)
+
+// errContentGone reports a referenced body the server has evicted.
+var errContentGone = errors.New("content no longer available")
+
+// Client talks to a harness server.
+type Client struct {
+	baseURL string
+	http    *http.Client
+	token   string
+
+	mu    sync.Mutex
+	cache map[string]string
+	order []string
+}
+
+// New creates a Client for the server at baseURL (e.g. "http://localhost:8080").
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{},
+		cache:   make(map[string]string),
+	}
+}
+
+// SetToken sets the bearer token sent with every request, for servers
+// that require authentication.
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// do sends req with the client's credentials.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.http.Do(req)
+}
+
+// Prompt submits a user prompt. The response arrives as events.
+func (c *Client) Prompt(ctx context.Context, content string) error {
+	body, err := json.Marshal(map[string]string{"content": content})
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, "/prompt", body)
+}
+
+// Cancel cancels the running prompt.
+func (c *Client) Cancel(ctx context.Context) error {
+	return c.post(ctx, "/cancel", nil)
+}
+
+// CancelRun cancels the run with the given ID, whether running or queued.
+func (c *Client) CancelRun(ctx context.Context, runID string) error {
+	body, err := json.Marshal(map[string]string{"run_id": runID})
+	if err != nil {
+		return err
+	}
+	return c.post(ctx, "/cancel", body)
+}
+
+// post sends a POST request and checks for a 2xx response.
+func (c *Client) post(ctx context.Context, path string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError(path, resp)
+	}
+	return nil
+}
+
+// responseError returns the error of a failed response. It wraps the
+// server's *server.APIError, with Status set, when the body is a JSON error.
+func responseError(path string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var envelope struct {
+		Error *server.APIError `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error != nil {
+		envelope.Error.Status = resp.StatusCode
+		return fmt.Errorf("%s returned %d: %w", path, resp.StatusCode, envelope.Error)
+	}
+	return fmt.Errorf("%s returned %d: %s", path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// Subscribe connects to the event stream and calls fn for each event, with
+// deduplicated bodies restored. Refs is nil on delivered events, unless a
+// body was evicted from the server before it could be fetched: that field
+// is empty and Refs keeps its entry. Subscribe blocks until ctx is done or
+// the stream ends, and returns nil when ctx is cancelled.
+func (c *Client) Subscribe(ctx context.Context, fn func(server.Event)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/events?dedup=1", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError("events", resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxEventSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue // blank separators and comments (heartbeats)
+		}
+		var event server.Event
+		if err := json.Unmarshal([]byte(line[len("data: "):]), &event); err != nil {
+			continue
+		}
+		if err := c.resolve(ctx, &event); err != nil {
+			return err
+		}
+		fn(event)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
+// resolve fills in referenced bodies and caches bodies sent inline. Refs
+// is left holding only the fields whose bodies the server has evicted.
+func (c *Client) resolve(ctx context.Context, event *server.Event) error {
+	var missing map[string]string
+	for field, hash := range event.Refs {
+		var err error
+		switch field {
+		case "content":
+			event.Content, err = c.restore(ctx, hash, event.Content)
+		case "result":
+			event.Result, err = c.restore(ctx, hash, event.Result)
+		case "input":
+			var input string
+			input, err = c.restore(ctx, hash, string(event.Input))
+			event.Input = json.RawMessage(input)
+		}
+		if errors.Is(err, errContentGone) {
+			if missing == nil {
+				missing = make(map[string]string)
+			}
+			missing[field] = hash
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	event.Refs = missing
+	return nil
+}
+
+// restore caches an inline body, or returns the referenced body if the
+// field was omitted.
+func (c *Client) restore(ctx context.Context, hash string, inline string) (string, error) {
+	if inline != "" {
+		c.store(hash, inline)
+		return inline, nil
+	}
+	return c.lookup(ctx, hash)
+}
+
+// store caches a body, evicting the oldest entries beyond the limit.
+func (c *Client) store(hash, body string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.cache[hash]; ok {
+		return
+	}
+	c.cache[hash] = body
+	c.order = append(c.order, hash)
+	if len(c.order) > maxCachedBodies {
+		delete(c.cache, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// lookup returns a body from the cache or fetches it from the server.
+func (c *Client) lookup(ctx context.Context, hash string) (string, error) {
+	c.mu.Lock()
+	body, ok := c.cache[hash]
+	c.mu.Unlock()
+	if ok {
+		return body, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/content/"+hash, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("content %s: %w", hash, errContentGone)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError("content "+hash, resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	c.store(hash, string(data))
+	return string(data), nil
+}
@@ -0,0 +1,188 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/client"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func TestSubscribe_ReassemblesDeduplicatedBodies(t *testing.T) {
+	big := strings.Repeat("package main // repeated file content\n", 100)
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText(big).Build())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText(big).Build())
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Watch the raw deduplicated stream alongside the client
+	raw := make(chan string, 100)
+	go func() {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events?dedup=1", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		buf := make([]byte, 1<<20)
+		for {
+			n, err := resp.Body.Read(buf)
+			if n > 0 {
+				raw <- string(buf[:n])
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	c := client.New(ts.URL)
+	texts := make(chan string, 10)
+	go c.Subscribe(ctx, func(e server.Event) {
+		if e.Type == "text" {
+			texts <- e.Content
+		}
+	})
+	time.Sleep(100 * time.Millisecond) // let both subscribers connect
+
+	for i := 0; i < 2; i++ {
+		if err := c.Prompt(context.Background(), "show the file"); err != nil {
+			t.Fatalf("Prompt failed: %v", err)
+		}
+		select {
+		case text := <-texts:
+			if text != big {
+				t.Errorf("prompt %d: expected full body to be restored, got %d bytes", i, len(text))
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("prompt %d: timeout waiting for text event", i)
+		}
+		for h.IsRunning() {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// The body crossed the raw stream only once
+	time.Sleep(50 * time.Millisecond)
+	var stream strings.Builder
+	for len(raw) > 0 {
+		stream.WriteString(<-raw)
+	}
+	if n := strings.Count(stream.String(), "repeated file content"); n != 100 {
+		t.Errorf("expected body to be sent once (100 lines), saw %d lines", n)
+	}
+	if !strings.Contains(stream.String(), `"refs":{"content":`) {
+		t.Error("expected events to carry content refs")
+	}
+}
+
+func TestSubscribe_FetchesUnknownRefs(t *testing.T) {
+	big := strings.Repeat("x", 2048)
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText(big).Build())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText(big).Build())
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// First broadcast happens before the client connects
+	if err := h.Prompt(context.Background(), "first"); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	c := client.New(ts.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	texts := make(chan string, 10)
+	go c.Subscribe(ctx, func(e server.Event) {
+		if e.Type == "text" {
+			texts <- e.Content
+		}
+	})
+	time.Sleep(100 * time.Millisecond)
+
+	if err := h.Prompt(context.Background(), "second"); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+	select {
+	case text := <-texts:
+		if text != big {
+			t.Errorf("expected body fetched from /content, got %d bytes", len(text))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for text event")
+	}
+}
+
+func TestClient_SendsToken(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", nil)
+	s.SetAuth(server.AuthConfig{BearerToken: "secret"})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	c := client.New(ts.URL)
+	err := c.Cancel(context.Background())
+	var apiErr *server.APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized || apiErr.Code != server.CodeUnauthorized {
+		t.Errorf("expected a 401 API error without token, got %v", err)
+	}
+	c.SetToken("secret")
+	if err := c.Cancel(context.Background()); err != nil {
+		t.Errorf("expected success with token, got %v", err)
+	}
+}
+
+func TestSubscribe_EvictedContentKeepsStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/content/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"type\":\"text\",\"refs\":{\"content\":\"abc123\"}}\n\n")
+		fmt.Fprint(w, "data: {\"type\":\"text\",\"content\":\"after\"}\n\n")
+	}))
+	defer ts.Close()
+
+	var events []server.Event
+	if err := client.New(ts.URL).Subscribe(context.Background(), func(e server.Event) {
+		events = append(events, e)
+	}); err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Content != "" || events[0].Refs["content"] != "abc123" {
+		t.Errorf("expected evicted body to stay empty and referenced, got %+v", events[0])
+	}
+	if events[1].Content != "after" || events[1].Refs != nil {
+		t.Errorf("expected the stream to continue, got %+v", events[1])
+	}
+}
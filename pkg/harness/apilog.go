@@ -0,0 +1,66 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+)
+
+// apiResponse is the form of a response passed to APIHandler: the message
+// with its content as request parameters, which encode without the unused
+// fields of the SDK's response union, and decode as an anthropic.Message.
+type apiResponse struct {
+	ID         string                             `json:"id"`
+	Type       string                             `json:"type"`
+	Role       string                             `json:"role"`
+	Model      string                             `json:"model"`
+	Content    []anthropic.ContentBlockParamUnion `json:"content"`
+	StopReason string                             `json:"stop_reason"`
+	Usage      apiResponseUsage                   `json:"usage"`
+}
+
+// apiResponseUsage is the token usage of an apiResponse.
+type apiResponseUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+// emitAPIRequest reports an API request to handlers that accept it.
+func (h *Harness) emitAPIRequest(ctx context.Context, params anthropic.MessageNewParams) {
+	ah, ok := h.handler.(APIHandler)
+	if !ok {
+		return
+	}
+	if data, err := json.Marshal(params); err == nil {
+		ah.OnAPIRequest(log.RunID(ctx), data)
+	}
+}
+
+// emitAPIResponse reports an API response to handlers that accept it.
+func (h *Harness) emitAPIResponse(ctx context.Context, message *anthropic.Message) {
+	ah, ok := h.handler.(APIHandler)
+	if !ok {
+		return
+	}
+	response := apiResponse{
+		ID:         message.ID,
+		Type:       "message",
+		Role:       "assistant",
+		Model:      string(message.Model),
+		Content:    message.ToParam().Content,
+		StopReason: string(message.StopReason),
+		Usage: apiResponseUsage{
+			InputTokens:              message.Usage.InputTokens,
+			OutputTokens:             message.Usage.OutputTokens,
+			CacheCreationInputTokens: message.Usage.CacheCreationInputTokens,
+			CacheReadInputTokens:     message.Usage.CacheReadInputTokens,
+		},
+	}
+	if data, err := json.Marshal(response); err == nil {
+		ah.OnAPIResponse(log.RunID(ctx), data)
+	}
+}
@@ -0,0 +1,150 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AskUserToolName is the name of the tool returned by NewAskUserTool.
+const AskUserToolName = "ask_user"
+
+// callIDKey is the context key for the ID of the tool call being executed.
+type callIDKey struct{}
+
+// Question is a question the model puts to the user with the ask_user tool.
+type Question struct {
+	ID       string   `json:"id"` // ID of the tool call asking it
+	Question string   `json:"question"`
+	Choices  []string `json:"choices,omitempty"` // suggested answers; others are accepted
+}
+
+// QuestionHandler delivers the ask_user tool's questions to the user. Ask
+// blocks until the user answers; an error (including ctx cancellation)
+// fails the tool call.
+type QuestionHandler interface {
+	Ask(ctx context.Context, q Question) (string, error)
+}
+
+// QuestionFunc adapts a function to a QuestionHandler.
+type QuestionFunc func(ctx context.Context, q Question) (string, error)
+
+// Ask calls f.
+func (f QuestionFunc) Ask(ctx context.Context, q Question) (string, error) {
+	return f(ctx, q)
+}
+
+// SetQuestionHandler sends the ask_user tool's questions to q. Sessions
+// created afterwards inherit it. With a nil handler the tool tells the model
+// that no user is available.
+func (h *Harness) SetQuestionHandler(q QuestionHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.questions = q
+}
+
+// AskUserTool pauses the run to ask the user a question, such as which of
+// several approaches to take, and returns the answer, so the model can
+// clarify a task instead of guessing.
+type AskUserTool struct{}
+
+// askUserInput defines the expected input parameters for the ask_user tool.
+type askUserInput struct {
+	Question string   `json:"question"`
+	Choices  []string `json:"choices"`
+}
+
+// askUserOutput defines the success response format.
+type askUserOutput struct {
+	Answer string `json:"answer"`
+}
+
+// askUserError defines the error response format.
+type askUserError struct {
+	Error string `json:"error"`
+}
+
+// NewAskUserTool creates a new AskUserTool instance. It works only when
+// executed by a Harness with a QuestionHandler.
+func NewAskUserTool() *AskUserTool {
+	return &AskUserTool{}
+}
+
+// Name returns the tool identifier.
+func (t *AskUserTool) Name() string {
+	return AskUserToolName
+}
+
+// Description returns a human-readable description of the tool.
+func (t *AskUserTool) Description() string {
+	return "Ask the user a question and wait for the answer. Use it when the task is ambiguous or a decision needs the user's input, such as choosing between approaches, rather than guessing. Ask one specific question, and offer choices when the likely answers are known"
+}
+
+// ReadOnly reports that asking a question changes nothing.
+func (t *AskUserTool) ReadOnly() bool {
+	return true
+}
+
+// Timeout disables the harness's time limit for the tool: the user may take
+// any time to answer.
+func (t *AskUserTool) Timeout() time.Duration {
+	return -1
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *AskUserTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"question": {"type": "string", "description": "The question to ask, with any context the user needs to answer it"},
+			"choices": {"type": "array", "items": {"type": "string"}, "description": "Suggested answers to pick from; the user may still answer freely"}
+		},
+		"required": ["question"]
+	}`)
+}
+
+// Execute asks the user the question and returns the answer.
+func (t *AskUserTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params askUserInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatAskUserError("invalid input: " + err.Error()), nil
+	}
+	question := Question{Question: strings.TrimSpace(params.Question)}
+	if question.Question == "" {
+		return formatAskUserError("question is required"), nil
+	}
+	for _, choice := range params.Choices {
+		if choice = strings.TrimSpace(choice); choice != "" {
+			question.Choices = append(question.Choices, choice)
+		}
+	}
+	question.ID, _ = ctx.Value(callIDKey{}).(string)
+
+	session, ok := ctx.Value(sessionKey{}).(*Harness)
+	if !ok {
+		return formatAskUserError("the ask_user tool must be run by a harness"), nil
+	}
+	session.mu.Lock()
+	handler := session.questions
+	session.mu.Unlock()
+	if handler == nil {
+		return formatAskUserError("no user is available to answer; proceed with your best judgement and state your assumptions"), nil
+	}
+
+	answer, err := handler.Ask(ctx, question)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return formatAskUserError("no answer: " + err.Error()), nil
+	}
+	data, _ := json.Marshal(askUserOutput{Answer: answer})
+	return string(data), nil
+}
+
+// formatAskUserError creates a JSON error response.
+func formatAskUserError(msg string) string {
+	data, _ := json.Marshal(askUserError{Error: msg})
+	return string(data)
+}
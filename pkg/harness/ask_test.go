@@ -0,0 +1,61 @@
+package harness_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestAskUserTool_ReturnsAnswer(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "ask_user", map[string]any{
+		"question": "Which database?",
+		"choices":  []string{"postgres", " sqlite ", ""},
+	}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Using sqlite."))
+
+	handler := &toolEventRecorder{}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"},
+		[]tool.Tool{harness.NewAskUserTool()}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	var asked harness.Question
+	h.SetQuestionHandler(harness.QuestionFunc(func(ctx context.Context, q harness.Question) (string, error) {
+		asked = q
+		return "sqlite", nil
+	}))
+	if err := h.Prompt(context.Background(), "set up storage"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if asked.ID != "call_1" || asked.Question != "Which database?" || strings.Join(asked.Choices, ",") != "postgres,sqlite" {
+		t.Errorf("unexpected question %+v", asked)
+	}
+	if len(handler.ToolResults) != 1 || handler.ToolResults[0].Result != `{"answer":"sqlite"}` {
+		t.Errorf("unexpected tool results %+v", handler.ToolResults)
+	}
+}
+
+func TestAskUserTool_NoHandler(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "ask_user", map[string]string{"question": "Which database?"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Assuming sqlite."))
+
+	handler := &toolEventRecorder{}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"},
+		[]tool.Tool{harness.NewAskUserTool()}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "set up storage"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if len(handler.ToolResults) != 1 || !strings.Contains(handler.ToolResults[0].Result, "no user is available") {
+		t.Errorf("expected an error result without a handler, got %+v", handler.ToolResults)
+	}
+}
@@ -0,0 +1,73 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBudgetExceeded is matched by the BudgetError a run returns when it used
+// up Config.MaxCostUSD or Config.MaxTokensPerPrompt.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budgets checked by the agent loop, as reported in BudgetError.Budget.
+const (
+	// BudgetCost is Config.MaxCostUSD, the session's estimated cost.
+	BudgetCost = "cost_usd"
+	// BudgetPromptTokens is Config.MaxTokensPerPrompt, the run's tokens.
+	BudgetPromptTokens = "tokens_per_prompt"
+)
+
+// BudgetError reports the budget that stopped a run and how much of it was
+// used. It matches ErrBudgetExceeded with errors.Is.
+type BudgetError struct {
+	Budget string  // BudgetCost or BudgetPromptTokens
+	Limit  float64 // the configured limit
+	Used   float64 // usage when the run stopped, at least Limit
+}
+
+func (e *BudgetError) Error() string {
+	if e.Budget == BudgetCost {
+		return fmt.Sprintf("%v: session cost $%.4f reached the limit of $%.4f", ErrBudgetExceeded, e.Used, e.Limit)
+	}
+	return fmt.Sprintf("%v: prompt used %.0f tokens, limit %.0f", ErrBudgetExceeded, e.Used, e.Limit)
+}
+
+// Unwrap returns ErrBudgetExceeded.
+func (e *BudgetError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// promptTokens returns all tokens of u: input, output, and cache.
+func promptTokens(u Usage) int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// checkBudget returns a *BudgetError if the session's cost or the running
+// prompt's tokens reached their limit, so no further API request is made.
+func (h *Harness) checkBudget() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if limit := h.config.MaxCostUSD; limit > 0 && h.usage.CostUSD >= limit {
+		return &BudgetError{Budget: BudgetCost, Limit: limit, Used: h.usage.CostUSD}
+	}
+	if limit := h.config.MaxTokensPerPrompt; limit > 0 {
+		if used := promptTokens(h.result.Usage); used >= int64(limit) {
+			return &BudgetError{Budget: BudgetPromptTokens, Limit: float64(limit), Used: float64(used)}
+		}
+	}
+	return nil
+}
+
+// taskBudget limits a sub-agent's session to what is left of the running
+// prompt's budgets, so it cannot spend past them. Call it after checkBudget
+// succeeded.
+func (h *Harness) taskBudget(session *Harness) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if limit := h.config.MaxCostUSD; limit > 0 {
+		session.config.MaxCostUSD = limit - h.usage.CostUSD
+	}
+	if limit := h.config.MaxTokensPerPrompt; limit > 0 {
+		session.config.MaxTokensPerPrompt = limit - int(promptTokens(h.result.Usage))
+	}
+}
@@ -0,0 +1,90 @@
+package harness_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestBudget_TokensPerPrompt(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	for _, id := range []string{"call_1", "call_2"} {
+		mockStreamer.AddResponse(testutil.NewMessageBuilder().
+			AddToolUse(id, "mock_tool", map[string]string{}).
+			WithUsage(100, 20).
+			BuildWithToolUse())
+	}
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("Done.").WithUsage(100, 20).Build())
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model", MaxTokensPerPrompt: 150},
+		[]tool.Tool{&MockTool{name: "mock_tool"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	// 120 tokens allow a second turn; 240 stop the run before a third
+	result, err := h.PromptWithOptions(context.Background(), "Go", harness.PromptOptions{})
+	var budgetErr *harness.BudgetError
+	if !errors.As(err, &budgetErr) || !errors.Is(err, harness.ErrBudgetExceeded) {
+		t.Fatalf("expected a BudgetError, got %v", err)
+	}
+	if budgetErr.Budget != harness.BudgetPromptTokens || budgetErr.Limit != 150 || budgetErr.Used != 240 {
+		t.Errorf("unexpected budget error %+v", budgetErr)
+	}
+	if result.StopReason != harness.StopReasonBudgetExceeded || result.Turns != 2 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	// The last tool calls were answered, so the run can be resumed
+	if msgs := h.Messages(); len(msgs) != 5 {
+		t.Errorf("expected the prompt and two tool rounds, got %d messages", len(msgs))
+	}
+
+	result, err = h.Continue(context.Background())
+	if err != nil || result.FinalText != "Done." {
+		t.Errorf("expected Continue to run with a fresh budget, got %+v, %v", result, err)
+	}
+}
+
+func TestBudget_SessionCost(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddToolUse("call_1", "mock_tool", map[string]string{}).
+		WithUsage(1_000_000, 0).
+		BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Should not be requested"))
+
+	config := harness.Config{
+		Model:      "test-model",
+		MaxCostUSD: 2,
+		Prices:     map[string]harness.ModelPrice{"test-model": {Input: 3}},
+	}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{&MockTool{name: "mock_tool"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	err = h.Prompt(context.Background(), "Go")
+	var budgetErr *harness.BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Budget != harness.BudgetCost || budgetErr.Used != 3 {
+		t.Fatalf("expected the cost budget to stop the run, got %v", err)
+	}
+	if len(mockStreamer.RecordedParams) != 1 {
+		t.Errorf("expected 1 API request, got %d", len(mockStreamer.RecordedParams))
+	}
+
+	// The spent session refuses further prompts without adding them
+	messages := len(h.Messages())
+	if err := h.Prompt(context.Background(), "Again"); !errors.Is(err, harness.ErrBudgetExceeded) {
+		t.Errorf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if len(h.Messages()) != messages || len(mockStreamer.RecordedParams) != 1 {
+		t.Error("expected the refused prompt to change nothing")
+	}
+	if reason := h.LastRun().StopReason; reason != harness.StopReasonBudgetExceeded {
+		t.Errorf("expected stop reason %q, got %q", harness.StopReasonBudgetExceeded, reason)
+	}
+}
@@ -0,0 +1,105 @@
+package harness
+
+import (
+	"slices"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// addCacheBreakpoints marks the request's tool definitions, system prompt,
+// and conversation with cache_control breakpoints so the prefix they end is
+// cached and read back by the following turns. It uses four breakpoints, the
+// API maximum: after the tools, after the system prompt, at the end of the
+// conversation, and at the end of the previous user message, whose prefix
+// the previous turn cached.
+//
+// Breakpoints are set on copies; the blocks of the stored conversation are
+// shared with params and must not change.
+func addCacheBreakpoints(params *anthropic.MessageNewParams) {
+	cc := anthropic.NewCacheControlEphemeralParam()
+
+	if n := len(params.Tools); n > 0 {
+		tools := slices.Clone(params.Tools)
+		if t := tools[n-1].OfTool; t != nil {
+			cached := *t
+			cached.CacheControl = cc
+			tools[n-1] = anthropic.ToolUnionParam{OfTool: &cached}
+		} else if t := tools[n-1].OfWebSearchTool20250305; t != nil {
+			cached := *t
+			cached.CacheControl = cc
+			tools[n-1] = anthropic.ToolUnionParam{OfWebSearchTool20250305: &cached}
+		}
+		params.Tools = tools
+	}
+
+	if n := len(params.System); n > 0 {
+		system := slices.Clone(params.System)
+		system[n-1].CacheControl = cc
+		params.System = system
+	}
+
+	last := len(params.Messages) - 1
+	if last < 0 {
+		return
+	}
+	messages := slices.Clone(params.Messages)
+	markMessage(messages, last)
+	for i := last - 1; i >= 0; i-- {
+		if messages[i].Role == anthropic.MessageParamRoleUser {
+			markMessage(messages, i)
+			break
+		}
+	}
+	params.Messages = messages
+}
+
+// markMessage sets a breakpoint on the last block of messages[i] that
+// accepts one, replacing the message with a copy.
+func markMessage(messages []anthropic.MessageParam, i int) {
+	msg := messages[i]
+	for j := len(msg.Content) - 1; j >= 0; j-- {
+		if block, ok := withCacheControl(msg.Content[j]); ok {
+			msg.Content = slices.Clone(msg.Content)
+			msg.Content[j] = block
+			messages[i] = msg
+			return
+		}
+	}
+}
+
+// withCacheControl returns a copy of block with an ephemeral cache_control
+// breakpoint, or false for block types that cannot carry one (thinking).
+func withCacheControl(block anthropic.ContentBlockParamUnion) (anthropic.ContentBlockParamUnion, bool) {
+	cc := anthropic.NewCacheControlEphemeralParam()
+	switch {
+	case block.OfText != nil:
+		b := *block.OfText
+		b.CacheControl = cc
+		return anthropic.ContentBlockParamUnion{OfText: &b}, true
+	case block.OfImage != nil:
+		b := *block.OfImage
+		b.CacheControl = cc
+		return anthropic.ContentBlockParamUnion{OfImage: &b}, true
+	case block.OfDocument != nil:
+		b := *block.OfDocument
+		b.CacheControl = cc
+		return anthropic.ContentBlockParamUnion{OfDocument: &b}, true
+	case block.OfToolUse != nil:
+		b := *block.OfToolUse
+		b.CacheControl = cc
+		return anthropic.ContentBlockParamUnion{OfToolUse: &b}, true
+	case block.OfToolResult != nil:
+		b := *block.OfToolResult
+		b.CacheControl = cc
+		return anthropic.ContentBlockParamUnion{OfToolResult: &b}, true
+	case block.OfServerToolUse != nil:
+		b := *block.OfServerToolUse
+		b.CacheControl = cc
+		return anthropic.ContentBlockParamUnion{OfServerToolUse: &b}, true
+	case block.OfWebSearchToolResult != nil:
+		b := *block.OfWebSearchToolResult
+		b.CacheControl = cc
+		return anthropic.ContentBlockParamUnion{OfWebSearchToolResult: &b}, true
+	}
+	return block, false
+}
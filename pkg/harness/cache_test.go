@@ -0,0 +1,43 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestPromptCaching(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		mockStreamer := testutil.NewMockMessageStreamer()
+		mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "echo", map[string]string{}))
+		mockStreamer.AddResponse(testutil.SingleToolResponse("call_2", "echo", map[string]string{}))
+		mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+		config := harness.Config{Model: "test-model", SystemPrompt: "be brief", PromptCaching: enabled}
+		h, _ := harness.NewHarnessWithStreamer(config, []tool.Tool{&MockTool{name: "echo"}}, &MockEventHandler{}, mockStreamer)
+		if err := h.Prompt(context.Background(), "go"); err != nil {
+			t.Fatalf("prompt failed: %v", err)
+		}
+
+		// The first request has no earlier user message to mark. Later
+		// ones use all four breakpoints; a breakpoint leaking into the
+		// stored conversation would add a fifth.
+		want := []int{3, 4, 4}
+		if !enabled {
+			want = []int{0, 0, 0}
+		}
+		if len(mockStreamer.RecordedParams) != len(want) {
+			t.Fatalf("expected %d requests, got %d", len(want), len(mockStreamer.RecordedParams))
+		}
+		for i, params := range mockStreamer.RecordedParams {
+			data, _ := json.Marshal(params)
+			if got := strings.Count(string(data), `"cache_control"`); got != want[i] {
+				t.Errorf("caching=%v: request %d has %d breakpoints, want %d: %s", enabled, i+1, got, want[i], data)
+			}
+		}
+	}
+}
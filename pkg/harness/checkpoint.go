@@ -0,0 +1,241 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// ErrNoCheckpoint is returned by Rollback when there are fewer checkpoints
+// than turns to undo.
+var ErrNoCheckpoint = errors.New("no checkpoint to roll back to")
+
+// maxSnapshotBytes caps the size of a file snapshotted for rollback; larger
+// files are not restored.
+const maxSnapshotBytes = 10 << 20
+
+// Checkpoint describes the state before a turn, which Rollback returns to.
+type Checkpoint struct {
+	// ID numbers checkpoints from 1, in the order they were taken.
+	ID int `json:"id"`
+	// Messages is the length of the conversation at the checkpoint.
+	Messages  int       `json:"messages"`
+	CreatedAt time.Time `json:"created_at"`
+	// Files are the paths changed during the turn whose earlier content
+	// was snapshotted.
+	Files []string `json:"files,omitempty"`
+}
+
+// checkpoint is the conversation before a turn and the first content of
+// each file the turn changed.
+type checkpoint struct {
+	id        int
+	createdAt time.Time
+	messages  []anthropic.MessageParam
+	times     []time.Time
+	files     map[string]fileSnapshot
+}
+
+// fileSnapshot is a file's content before it was changed.
+type fileSnapshot struct {
+	exists bool
+	data   []byte
+	mode   fs.FileMode
+}
+
+// info describes c for callers of Checkpoints.
+func (c *checkpoint) info() Checkpoint {
+	info := Checkpoint{ID: c.id, Messages: len(c.messages), CreatedAt: c.createdAt}
+	for path := range c.files {
+		info.Files = append(info.Files, path)
+	}
+	slices.Sort(info.Files)
+	return info
+}
+
+// beginCheckpoint records the conversation before a turn, dropping the
+// oldest checkpoints beyond Config.Checkpoints.
+func (h *Harness) beginCheckpoint() {
+	if h.config.Checkpoints <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkpointSeq++
+	h.checkpoints = append(h.checkpoints, &checkpoint{
+		id:        h.checkpointSeq,
+		createdAt: time.Now(),
+		// Clone: applySteering replaces the last message in place
+		messages: slices.Clone(h.messages),
+		times:    slices.Clone(h.messageTimes),
+		files:    make(map[string]fileSnapshot),
+	})
+	if excess := len(h.checkpoints) - h.config.Checkpoints; excess > 0 {
+		h.checkpoints = slices.Delete(h.checkpoints, 0, excess)
+	}
+}
+
+// clearCheckpoints drops all checkpoints. Callers hold h.mu.
+func (h *Harness) clearCheckpoints() {
+	h.checkpoints = nil
+}
+
+// snapshotFiles records the current content of the files a call of t is
+// about to change in the latest checkpoint, unless the turn has already
+// changed them.
+func (h *Harness) snapshotFiles(ctx context.Context, t tool.Tool, input []byte) {
+	if !h.config.CheckpointFiles || h.config.Checkpoints <= 0 {
+		return
+	}
+	pr, ok := t.(tool.PathReporter)
+	if !ok {
+		return
+	}
+	paths := pr.ChangedPaths(ctx, input)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.checkpoints) == 0 {
+		return
+	}
+	cp := h.checkpoints[len(h.checkpoints)-1]
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		if _, ok := cp.files[abs]; ok {
+			continue
+		}
+		snapshot, err := readSnapshot(abs)
+		if err != nil {
+			log.ForContext(h.logger, ctx).Warn("harness", "File not snapshotted",
+				log.F("tool", t.Name()),
+				log.F("path", abs),
+				log.F("error", err.Error()),
+			)
+			continue
+		}
+		cp.files[abs] = snapshot
+	}
+}
+
+// readSnapshot reads the regular file at path, which may not exist.
+func readSnapshot(path string) (fileSnapshot, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileSnapshot{}, nil
+	}
+	if err != nil {
+		return fileSnapshot{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return fileSnapshot{}, errors.New("not a regular file")
+	}
+	if info.Size() > maxSnapshotBytes {
+		return fileSnapshot{}, fmt.Errorf("file exceeds %d bytes", maxSnapshotBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileSnapshot{}, err
+	}
+	return fileSnapshot{exists: true, data: data, mode: info.Mode().Perm()}, nil
+}
+
+// restore puts the file at path back to s: rewriting it, or removing a
+// regular file that did not exist.
+func (s fileSnapshot) restore(path string) error {
+	if !s.exists {
+		info, err := os.Lstat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return errors.New("not a regular file; left in place")
+		}
+		return os.Remove(path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, s.data, s.mode); err != nil {
+		return err
+	}
+	return os.Chmod(path, s.mode)
+}
+
+// Checkpoints returns the checkpoints Rollback can return to, oldest first.
+func (h *Harness) Checkpoints() []Checkpoint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	infos := make([]Checkpoint, len(h.checkpoints))
+	for i, cp := range h.checkpoints {
+		infos[i] = cp.info()
+	}
+	return infos
+}
+
+// Rollback undoes the last n turns: the conversation returns to its state
+// before them and, with Config.CheckpointFiles, the files they changed are
+// restored. Rolling back the first turn of a prompt also removes the
+// prompt. It returns the restored paths; files that could not be restored
+// are reported in the error, after the conversation has been rolled back.
+// The task plan and usage totals are kept. Returns ErrPromptInProgress
+// while a prompt is running and ErrNoCheckpoint if fewer than n turns are
+// checkpointed.
+func (h *Harness) Rollback(n int) ([]string, error) {
+	h.mu.Lock()
+	if h.running {
+		h.mu.Unlock()
+		return nil, ErrPromptInProgress
+	}
+	if n < 1 || n > len(h.checkpoints) {
+		available := len(h.checkpoints)
+		h.mu.Unlock()
+		return nil, fmt.Errorf("%w: %d turns requested, %d available", ErrNoCheckpoint, n, available)
+	}
+	first := len(h.checkpoints) - n
+	undone := h.checkpoints[first:]
+	h.checkpoints = slices.Clone(h.checkpoints[:first])
+	target := undone[0]
+	h.messages = slices.Clone(target.messages)
+	h.messageTimes = slices.Clone(target.times)
+	h.contextTokens = 0
+	h.mu.Unlock()
+
+	// Restore newest first, so each path ends at its oldest snapshot
+	var restored []string
+	var errs []error
+	for i := len(undone) - 1; i >= 0; i-- {
+		for path, snapshot := range undone[i].files {
+			if err := snapshot.restore(path); err != nil {
+				errs = append(errs, fmt.Errorf("restore %s: %w", path, err))
+				continue
+			}
+			if !slices.Contains(restored, path) {
+				restored = append(restored, path)
+			}
+		}
+	}
+	slices.Sort(restored)
+	h.saveConversation()
+
+	h.logger.Info("harness", "Conversation rolled back",
+		log.F("turns", n),
+		log.F("messages", len(target.messages)),
+		log.F("files_restored", len(restored)),
+	)
+	return restored, errors.Join(errs...)
+}
@@ -0,0 +1,106 @@
+package harness_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestRollback(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(existing, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	// First prompt: one turn, no changes
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Looks fine"))
+	// Second prompt: overwrite main.go, then create notes.txt, then finish
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "write", map[string]string{"path": "main.go", "content": "package other\n"}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_2", "write", map[string]string{"path": "notes.txt", "content": "todo\n"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done"))
+
+	config := harness.Config{Model: "test-model", Checkpoints: 10, CheckpointFiles: true}
+	h, _ := harness.NewHarnessWithStreamer(config, []tool.Tool{tool.NewWriteTool()}, nil, mockStreamer)
+	h.SetWorkspace(dir)
+
+	if err := h.Prompt(context.Background(), "check it"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "rename the package"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	checkpoints := h.Checkpoints()
+	if len(checkpoints) != 4 {
+		t.Fatalf("expected a checkpoint per turn, got %d", len(checkpoints))
+	}
+	if cp := checkpoints[1]; cp.Messages != 2 || len(cp.Files) != 1 || cp.Files[0] != existing {
+		t.Errorf("unexpected checkpoint of the first write: %+v", cp)
+	}
+
+	// Undo the last two turns: notes.txt is removed, main.go kept changed
+	restored, err := h.Rollback(2)
+	if err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	if len(restored) != 1 || restored[0] != filepath.Join(dir, "notes.txt") {
+		t.Errorf("unexpected restored files: %v", restored)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected notes.txt to be removed, got %v", err)
+	}
+	if got := len(h.Messages()); got != 5 {
+		t.Errorf("expected 5 messages after the first write, got %d", got)
+	}
+
+	// Undo the rest of the prompt, which also removes it
+	if _, err := h.Rollback(1); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	data, _ := os.ReadFile(existing)
+	if string(data) != "package main\n" {
+		t.Errorf("expected main.go restored, got %q", data)
+	}
+	if got := len(h.Messages()); got != 2 {
+		t.Errorf("expected the first prompt's 2 messages, got %d", got)
+	}
+
+	if _, err := h.Rollback(2); !errors.Is(err, harness.ErrNoCheckpoint) {
+		t.Errorf("expected ErrNoCheckpoint, got %v", err)
+	}
+	if len(h.Checkpoints()) != 1 {
+		t.Errorf("expected the first prompt's checkpoint to remain, got %d", len(h.Checkpoints()))
+	}
+}
+
+func TestCheckpoints_Limit(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	for i := 0; i < 3; i++ {
+		mockStreamer.AddResponse(testutil.TextOnlyResponse("ok"))
+	}
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model", Checkpoints: 2}, nil, nil, mockStreamer)
+	for i := 0; i < 3; i++ {
+		if err := h.Prompt(context.Background(), "hi"); err != nil {
+			t.Fatalf("prompt failed: %v", err)
+		}
+	}
+
+	checkpoints := h.Checkpoints()
+	if len(checkpoints) != 2 || checkpoints[0].ID != 2 || checkpoints[1].ID != 3 {
+		t.Fatalf("expected the last 2 checkpoints, got %+v", checkpoints)
+	}
+	if err := h.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Checkpoints()) != 0 {
+		t.Error("expected Reset to clear checkpoints")
+	}
+}
@@ -0,0 +1,127 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+)
+
+// DefaultCompactionKeepMessages is how many recent messages compaction keeps
+// verbatim when Config.CompactionKeepMessages is unset.
+const DefaultCompactionKeepMessages = 6
+
+// compactionInstruction asks the model to condense the turns being compacted.
+const compactionInstruction = "The conversation is getting long. Summarize everything above so the task can continue " +
+	"without it: the user's requests, decisions made, files read, created or modified, commands run and their outcomes, " +
+	"and what remains to be done. Be concise and factual. Reply with the summary only."
+
+// compactionNote opens a compacted history, before the synthetic summary turn.
+const compactionNote = "Earlier turns of this conversation were compacted. Summarize them."
+
+// compactionResume follows the synthetic summary turn.
+const compactionResume = "Continue from the summary."
+
+// contextTokens returns the prompt size reported for the latest API response:
+// input (including cached) plus output tokens.
+func contextTokens(u anthropic.Usage) int64 {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens + u.OutputTokens
+}
+
+// compactionSplit returns the index of the first message compaction keeps.
+// The kept tail starts at an assistant message so tool_use and tool_result
+// pairs are never separated. Returns 0 when there is nothing to compact.
+func compactionSplit(messages []anthropic.MessageParam, keep int) int {
+	for i := len(messages) - keep; i > 1; i-- {
+		if messages[i].Role == anthropic.MessageParamRoleAssistant {
+			return i
+		}
+	}
+	return 0
+}
+
+// maybeCompact compacts the history when the context size of the latest API
+// response has crossed Config.CompactionThreshold. Failures other than
+// cancellation are logged and the turn proceeds with the full history.
+func (h *Harness) maybeCompact(ctx context.Context) error {
+	h.mu.Lock()
+	tokens := h.contextTokens
+	h.mu.Unlock()
+	if h.config.CompactionThreshold <= 0 || tokens < int64(h.config.CompactionThreshold) {
+		return nil
+	}
+
+	if err := h.compact(ctx, tokens); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.ForContext(h.logger, ctx).Warn("harness", "Compaction failed",
+			log.F("context_tokens", tokens),
+			log.F("error", err.Error()),
+		)
+	}
+	return nil
+}
+
+// compact summarizes the messages before the kept tail and replaces them with
+// a synthetic exchange whose assistant turn carries the summary.
+func (h *Harness) compact(ctx context.Context, tokens int64) error {
+	keep := h.config.CompactionKeepMessages
+	if keep <= 0 {
+		keep = DefaultCompactionKeepMessages
+	}
+
+	h.mu.Lock()
+	split := compactionSplit(h.messages, keep)
+	older := slices.Clone(h.messages[:split])
+	h.mu.Unlock()
+	if split == 0 {
+		return nil
+	}
+
+	// The older part ends with a user message; add the instruction to it
+	// rather than sending two user turns in a row.
+	last := older[len(older)-1]
+	last.Content = append(slices.Clone(last.Content), anthropic.NewTextBlock(compactionInstruction))
+	older[len(older)-1] = last
+
+	message, err := h.complete(ctx, h.config.Model, h.config.SystemPrompt, h.config.MaxTokens, older)
+	if err != nil {
+		return err
+	}
+	summary := strings.TrimSpace(messageText(message))
+	if summary == "" {
+		return errors.New("model returned an empty summary")
+	}
+
+	h.mu.Lock()
+	tail := h.messages[split:]
+	tailTimes := h.messageTimes[split:]
+	messages := make([]anthropic.MessageParam, 0, len(tail)+3)
+	messages = append(messages,
+		anthropic.NewUserMessage(anthropic.NewTextBlock(compactionNote)),
+		anthropic.NewAssistantMessage(anthropic.NewTextBlock(summary)),
+		anthropic.NewUserMessage(anthropic.NewTextBlock(compactionResume)),
+	)
+	h.setMessages(messages, time.Now())
+	h.messages = append(h.messages, tail...)
+	h.messageTimes = append(h.messageTimes, tailTimes...)
+	h.contextTokens = 0
+	h.mu.Unlock()
+	h.saveConversation()
+
+	log.ForContext(h.logger, ctx).Info("harness", "Conversation compacted",
+		log.F("context_tokens", tokens),
+		log.F("compacted_messages", split),
+		log.F("kept_messages", len(tail)),
+		log.F("summary_length", len(summary)),
+	)
+	if ch, ok := h.handler.(CompactionHandler); ok {
+		ch.OnCompaction(split, summary)
+	}
+	return nil
+}
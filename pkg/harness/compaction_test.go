@@ -0,0 +1,166 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// compactionRecorder extends MockEventHandler with CompactionHandler support.
+type compactionRecorder struct {
+	MockEventHandler
+	Compacted []int
+	Summaries []string
+}
+
+func (h *compactionRecorder) OnCompaction(compacted int, summary string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Compacted = append(h.Compacted, compacted)
+	h.Summaries = append(h.Summaries, summary)
+}
+
+// paramText returns the text blocks of a message param.
+func paramText(msg anthropic.MessageParam) string {
+	var parts []string
+	for _, block := range msg.Content {
+		if block.OfText != nil {
+			parts = append(parts, block.OfText.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// assertTextOnly fails unless every block of a request that defines no tools
+// is one the API accepts without them.
+func assertTextOnly(t *testing.T, params anthropic.MessageNewParams) {
+	t.Helper()
+	if len(params.Tools) != 0 {
+		return
+	}
+	for i, msg := range params.Messages {
+		for _, block := range msg.Content {
+			if block.OfToolUse != nil || block.OfToolResult != nil || block.OfServerToolUse != nil || block.OfWebSearchToolResult != nil {
+				t.Errorf("message %d has a tool block in a request without tools: %+v", i, block)
+			}
+		}
+	}
+}
+
+func TestCompaction_SummarizesOlderTurnsPastThreshold(t *testing.T) {
+	echo := &MockTool{name: "echo"}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("call_1", "echo", map[string]string{}).WithUsage(100, 10).BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("call_2", "echo", map[string]string{}).WithUsage(1000, 100).BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Read the config and ran echo once."))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done"))
+
+	handler := &compactionRecorder{}
+	config := harness.Config{Model: "test-model", CompactionThreshold: 1000, CompactionKeepMessages: 2}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{echo}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "go"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(mockStreamer.RecordedParams) != 4 {
+		t.Fatalf("expected 4 API requests, got %d", len(mockStreamer.RecordedParams))
+	}
+
+	// The summary request holds the older turns with the instruction appended
+	summaryReq := mockStreamer.RecordedParams[2]
+	if len(summaryReq.Messages) != 3 || len(summaryReq.Tools) != 0 {
+		t.Fatalf("expected 3 older messages and no tools, got %d messages and %d tools", len(summaryReq.Messages), len(summaryReq.Tools))
+	}
+	assertTextOnly(t, summaryReq)
+	if text := paramText(summaryReq.Messages[1]); !strings.Contains(text, "[Called tool echo with input {}]") {
+		t.Errorf("expected the tool call as text, got %q", text)
+	}
+	if text := paramText(summaryReq.Messages[2]); !strings.HasPrefix(text, "[Result of tool echo]") || !strings.Contains(text, "Summarize") {
+		t.Errorf("expected the tool result as text followed by the instruction, got %q", text)
+	}
+
+	// The next turn starts from the synthetic summary and keeps call_2 verbatim
+	next := mockStreamer.RecordedParams[3].Messages
+	if len(next) != 5 {
+		t.Fatalf("expected 5 messages after compaction, got %d", len(next))
+	}
+	if next[1].Role != anthropic.MessageParamRoleAssistant || paramText(next[1]) != "Read the config and ran echo once." {
+		t.Errorf("expected the summary as an assistant message, got %+v", next[1])
+	}
+	if next[3].Content[0].OfToolUse == nil || next[3].Content[0].OfToolUse.ID != "call_2" {
+		t.Errorf("expected the call_2 tool use to be kept, got %+v", next[3].Content)
+	}
+	if next[4].Content[0].OfToolResult == nil || next[4].Content[0].OfToolResult.ToolUseID != "call_2" {
+		t.Errorf("expected the call_2 tool result to be kept, got %+v", next[4].Content)
+	}
+
+	if len(handler.Compacted) != 1 || handler.Compacted[0] != 3 {
+		t.Errorf("expected one compaction of 3 messages, got %v", handler.Compacted)
+	}
+	if len(h.Messages()) != 6 {
+		t.Errorf("expected 6 messages in history, got %d", len(h.Messages()))
+	}
+}
+
+func TestCompaction_DisabledByDefault(t *testing.T) {
+	echo := &MockTool{name: "echo"}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("call_1", "echo", map[string]string{}).WithUsage(100000, 10).BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("call_2", "echo", map[string]string{}).WithUsage(200000, 10).BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done"))
+
+	handler := &compactionRecorder{}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{echo}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "go"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if len(handler.Compacted) != 0 || len(mockStreamer.RecordedParams) != 3 {
+		t.Errorf("expected no compaction, got %v with %d requests", handler.Compacted, len(mockStreamer.RecordedParams))
+	}
+}
+
+func TestCompaction_FailureKeepsFullHistory(t *testing.T) {
+	echo := &MockTool{
+		name: "echo",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			return `{"ok":true}`, nil
+		},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("call_1", "echo", map[string]string{}).WithUsage(10, 10).BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("call_2", "echo", map[string]string{}).WithUsage(5000, 10).BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("   ")) // empty summary
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done"))
+
+	handler := &compactionRecorder{}
+	config := harness.Config{Model: "test-model", CompactionThreshold: 1000, CompactionKeepMessages: 2}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{echo}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "go"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(handler.Compacted) != 0 {
+		t.Errorf("expected no compaction, got %v", handler.Compacted)
+	}
+	if n := len(mockStreamer.RecordedParams[3].Messages); n != 5 {
+		t.Errorf("expected the full 5-message history, got %d", n)
+	}
+}
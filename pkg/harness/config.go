@@ -2,7 +2,10 @@
 // the Anthropic API with tools and event handling.
 package harness
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // Default configuration values
 const (
@@ -27,6 +30,170 @@ type Config struct {
 
 	// MaxTurns is the maximum number of agent loop iterations. Default: 10
 	MaxTurns int
+
+	// Temperature sets the sampling temperature, from 0 (most deterministic)
+	// to 1. Default: nil (the API default)
+	Temperature *float64
+
+	// TopP sets nucleus sampling: only the most likely tokens whose
+	// probabilities add up to TopP are sampled, from 0 to 1. Most tasks need
+	// only one of Temperature and TopP. Default: nil (the API default)
+	TopP *float64
+
+	// StopSequences are strings that end the model's response when
+	// generated. A response ended this way has the stop reason
+	// "stop_sequence". Default: none
+	StopSequences []string
+
+	// EnableWebSearch offers the provider-executed web search tool to the model.
+	// Server tools run on Anthropic's side; their calls and results are surfaced
+	// through ServerToolHandler rather than executed locally.
+	EnableWebSearch bool
+
+	// WebSearchMaxUses limits web searches per API request. Zero means no limit.
+	WebSearchMaxUses int
+
+	// MaxMutatingFailures enables fail-safe mode: after more than this many
+	// consecutive failed calls to mutating tools, the rest of the run is
+	// restricted to read-only tools. Zero disables fail-safe mode.
+	MaxMutatingFailures int
+
+	// ToolEventTypes restricts which custom event types tools may emit.
+	// Events of other types are dropped. Empty allows all types.
+	ToolEventTypes []string
+
+	// APILatencySLO and ToolLatencySLO set p50/p95 thresholds for API turns
+	// and tool executions. When a rolling percentile exceeds its threshold,
+	// an SLO violation is reported through SLOHandler.
+	APILatencySLO  SLO
+	ToolLatencySLO SLO
+
+	// SLOWindow is the rolling window for latency statistics. Default: 5m
+	SLOWindow time.Duration
+
+	// MaxRetries is how many times a turn is retried after a 429, 529, or
+	// other 5xx API error. Default: 0 (no retries)
+	MaxRetries int
+
+	// RetryBaseDelay is the backoff before the first retry; it doubles with
+	// each attempt, with jitter. Default: 1s
+	RetryBaseDelay time.Duration
+
+	// FallbackModels are tried in order when a turn still fails with a 429,
+	// 529, or 5xx error after MaxRetries retries. A run that switched keeps
+	// the fallback for its remaining turns. Default: none
+	FallbackModels []string
+
+	// Profiles are named agent presets, such as a read-only "reviewer", that
+	// prompts and sessions select (see Profile). Default: none
+	Profiles map[string]Profile
+
+	// Profile is the profile of prompts that name none. Default: none
+	Profile string
+
+	// CompactionThreshold is the context size, in tokens, at which older
+	// turns are summarized before the next API request. The size is the
+	// input plus output tokens of the latest response. Default: 0 (disabled)
+	CompactionThreshold int
+
+	// CompactionKeepMessages is how many recent messages compaction keeps
+	// verbatim. Default: 6
+	CompactionKeepMessages int
+
+	// ContextWindow is the model's context window in tokens. Before each
+	// turn, a request that would not fit with MaxTokens of output is
+	// compacted, when compaction is enabled, or refused with
+	// ErrContextWindowExceeded. Default: 200000
+	ContextWindow int
+
+	// CountTokens counts each request's input tokens with the count_tokens
+	// API before sending it, rather than estimating them locally.
+	CountTokens bool
+
+	// OutputSummaries enables output distillation per tool name: large results
+	// are saved to ArtifactDir and replaced by their error and warning lines.
+	OutputSummaries map[string]OutputSummary
+
+	// ArtifactDir is where raw tool output is saved when distilled, in a
+	// subdirectory per run that is removed when the run ends.
+	// Default: the run's temporary directory.
+	ArtifactDir string
+
+	// Prices sets model prices for cost estimates, keyed by model ID or ID
+	// prefix. Entries take precedence over DefaultPrices.
+	Prices map[string]ModelPrice
+
+	// IgnorePatterns replaces the global ignore list of recursive tools such
+	// as grep, glob, and tree, in .gitignore syntax. An empty non-nil list
+	// disables it. Default: tool.DefaultIgnorePatterns
+	IgnorePatterns []string
+
+	// ReadCacheBytes enables a cache of the files the read, grep, and tree
+	// tools read during a run, holding at most this many bytes of content.
+	// An entry is used only while the file's size and modification time
+	// are unchanged. Default: 0 (disabled)
+	ReadCacheBytes int64
+
+	// ToolImages lets tools attach images to their results, such as PNG and
+	// JPEG files opened with the read tool. Enable it only for models that
+	// accept image input. Default: false
+	ToolImages bool
+
+	// Workspace jails tool paths and commands to a directory, as SetWorkspace
+	// does, and is restored by SetWorkspace(""). Default: "" (unrestricted)
+	Workspace string
+
+	// GitReadOnly makes the git tools refuse operations that change the
+	// repository: committing and creating, switching, or deleting branches.
+	// Default: false
+	GitReadOnly bool
+
+	// MaxLineLength is the longest line, in bytes, the edit tools read; a
+	// file with a longer line is not edited. Default: 0 (1MB)
+	MaxLineLength int
+
+	// PromptCaching marks the tool definitions, system prompt, and recent
+	// conversation turns with cache_control breakpoints so repeated turns
+	// read the shared prefix from Anthropic's prompt cache. Cache reads and
+	// writes are reported in Usage. Default: false
+	PromptCaching bool
+
+	// Checkpoints is how many turn checkpoints Rollback can return to;
+	// older ones are dropped. Default: 0 (disabled)
+	Checkpoints int
+
+	// CheckpointFiles also snapshots, with each checkpoint, the files its
+	// turn changes through tools implementing tool.PathReporter, so
+	// Rollback restores them. Default: false
+	CheckpointFiles bool
+
+	// ToolTimeout limits how long a tool call may run before it fails with
+	// ErrToolTimeout. Tools implementing tool.TimeoutProvider override it.
+	// Default: 0 (no limit)
+	ToolTimeout time.Duration
+
+	// MaxToolResultBytes caps the size of a tool result added to the
+	// conversation. Larger results keep their beginning and end around a
+	// truncation marker. Default: 0 (no limit)
+	MaxToolResultBytes int
+
+	// ToolResultLimits overrides MaxToolResultBytes per tool name. Zero or a
+	// negative value disables the limit for that tool.
+	ToolResultLimits map[string]int
+
+	// TaskMaxTurns caps the turns of a sub-agent started by the task tool.
+	// Default: MaxTurns
+	TaskMaxTurns int
+
+	// MaxCostUSD stops a run with a BudgetError before its next API request
+	// once the session's estimated cost (Usage().CostUSD) reaches it, and
+	// refuses new prompts from then on. Default: 0 (no limit)
+	MaxCostUSD float64
+
+	// MaxTokensPerPrompt stops a run with a BudgetError before its next API
+	// request once its input, output, and cache tokens reach it.
+	// Default: 0 (no limit)
+	MaxTokensPerPrompt int
 }
 
 // Validate checks the configuration and returns an error if invalid.
@@ -46,6 +213,45 @@ func (c *Config) Validate() error {
 	if c.MaxTurns == 0 {
 		c.MaxTurns = DefaultMaxTurns
 	}
+	if c.SLOWindow == 0 {
+		c.SLOWindow = DefaultSLOWindow
+	}
+	if err := validateSampling(c.Temperature, c.TopP); err != nil {
+		return err
+	}
+	if c.MaxRetries < 0 {
+		return errors.New("MaxRetries must not be negative")
+	}
+	if c.MaxCostUSD < 0 {
+		return errors.New("MaxCostUSD must not be negative")
+	}
+	if c.MaxTokensPerPrompt < 0 {
+		return errors.New("MaxTokensPerPrompt must not be negative")
+	}
+	if c.ReadCacheBytes < 0 {
+		return errors.New("ReadCacheBytes must not be negative")
+	}
+	if c.MaxLineLength < 0 {
+		return errors.New("MaxLineLength must not be negative")
+	}
+	if c.RetryBaseDelay == 0 {
+		c.RetryBaseDelay = DefaultRetryBaseDelay
+	}
+	if c.CompactionKeepMessages == 0 {
+		c.CompactionKeepMessages = DefaultCompactionKeepMessages
+	}
+	if c.ContextWindow < 0 {
+		return errors.New("ContextWindow must not be negative")
+	}
+	if c.ContextWindow == 0 {
+		c.ContextWindow = DefaultContextWindow
+	}
+	if err := validateOutputSummaries(c.OutputSummaries); err != nil {
+		return err
+	}
+	if err := validateProfiles(c.Profiles, c.Profile); err != nil {
+		return err
+	}
 
 	return nil
 }
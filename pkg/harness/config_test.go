@@ -89,3 +89,21 @@ func TestConfig_Validate_PreservesCustomValues(t *testing.T) {
 		t.Errorf("custom MaxTurns should be preserved, got %d", c.MaxTurns)
 	}
 }
+
+func TestConfig_Validate_RetryDefaults(t *testing.T) {
+	c := Config{APIKey: "test-key"}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.MaxRetries != 0 {
+		t.Errorf("expected retries to be off by default, got %d", c.MaxRetries)
+	}
+	if c.RetryBaseDelay != DefaultRetryBaseDelay {
+		t.Errorf("expected RetryBaseDelay to default to %v, got %v", DefaultRetryBaseDelay, c.RetryBaseDelay)
+	}
+
+	c = Config{APIKey: "test-key", MaxRetries: -1}
+	if err := c.Validate(); err == nil {
+		t.Error("expected error for negative MaxRetries")
+	}
+}
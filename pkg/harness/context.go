@@ -0,0 +1,189 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+)
+
+// DefaultContextWindow is the context window, in tokens, assumed when
+// Config.ContextWindow is unset.
+const DefaultContextWindow = 200000
+
+// estimatedImageTokens is what the local estimate counts per image, about
+// the cost of a large image.
+const estimatedImageTokens = 1600
+
+// ErrContextWindowExceeded is returned when the next request would not fit
+// in the model's context window, even after compaction.
+var ErrContextWindowExceeded = errors.New("context window exceeded")
+
+// TokenCounter is an optional interface a MessageStreamer can implement to
+// count the input tokens of a request, such as with Anthropic's count_tokens
+// API. The harness uses it when Config.CountTokens is set.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, params anthropic.MessageNewParams) (int64, error)
+}
+
+// ContextBudget reports how much of the context window the conversation
+// uses, as of the latest API response.
+type ContextBudget struct {
+	Tokens    int64 `json:"tokens"`    // input, cache, and output tokens of the latest response
+	Window    int64 `json:"window"`    // Config.ContextWindow
+	Remaining int64 `json:"remaining"` // Window minus Tokens
+}
+
+// CountTokens implements TokenCounter with the count_tokens API.
+func (r *realMessageStreamer) CountTokens(ctx context.Context, params anthropic.MessageNewParams) (int64, error) {
+	count := anthropic.MessageCountTokensParams{
+		Messages: params.Messages,
+		Model:    params.Model,
+		Thinking: params.Thinking,
+	}
+	if len(params.System) > 0 {
+		count.System.OfTextBlockArray = params.System
+	}
+	for _, t := range params.Tools {
+		count.Tools = append(count.Tools, anthropic.MessageCountTokensToolUnionParam{
+			OfTool:                  t.OfTool,
+			OfBashTool20250124:      t.OfBashTool20250124,
+			OfTextEditor20250124:    t.OfTextEditor20250124,
+			OfTextEditor20250429:    t.OfTextEditor20250429,
+			OfTextEditor20250728:    t.OfTextEditor20250728,
+			OfWebSearchTool20250305: t.OfWebSearchTool20250305,
+		})
+	}
+	result, err := r.client.Messages.CountTokens(ctx, count)
+	if err != nil {
+		return 0, err
+	}
+	return result.InputTokens, nil
+}
+
+// ContextBudget returns the context window use as of the latest API response.
+func (h *Harness) ContextBudget() ContextBudget {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.contextBudget()
+}
+
+// contextBudget returns the context window use. Callers hold h.mu.
+func (h *Harness) contextBudget() ContextBudget {
+	window := h.contextWindow()
+	return ContextBudget{Tokens: h.contextTokens, Window: window, Remaining: window - h.contextTokens}
+}
+
+// contextWindow returns Config.ContextWindow, or DefaultContextWindow when
+// it is unset.
+func (h *Harness) contextWindow() int64 {
+	if h.config.ContextWindow <= 0 {
+		return DefaultContextWindow
+	}
+	return int64(h.config.ContextWindow)
+}
+
+// countTokens returns the input tokens of params: counted by the streamer
+// with Config.CountTokens, or estimated locally. A failed count falls back
+// to the estimate.
+func (h *Harness) countTokens(ctx context.Context, params anthropic.MessageNewParams) int64 {
+	counter, ok := h.streamer.(TokenCounter)
+	if !h.config.CountTokens || !ok {
+		return estimateTokens(params)
+	}
+	tokens, err := counter.CountTokens(ctx, params)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.ForContext(h.logger, ctx).Warn("api", "Token count failed, estimating",
+				log.F("error", err.Error()),
+			)
+		}
+		return estimateTokens(params)
+	}
+	return tokens
+}
+
+// estimateTokens estimates the input tokens of params at four bytes of JSON
+// per token, counting each image as estimatedImageTokens.
+func estimateTokens(params anthropic.MessageNewParams) int64 {
+	var bytes, images int64
+	add := func(v any) {
+		data, _ := json.Marshal(v)
+		bytes += int64(len(data))
+	}
+	add(params.System)
+	add(params.Tools)
+	for _, msg := range params.Messages {
+		for _, block := range msg.Content {
+			switch {
+			case block.OfImage != nil:
+				images++
+			case block.OfToolResult != nil:
+				for _, c := range block.OfToolResult.Content {
+					if c.OfImage != nil {
+						images++
+					} else {
+						add(c)
+					}
+				}
+			default:
+				add(block)
+			}
+		}
+	}
+	return bytes/4 + images*estimatedImageTokens
+}
+
+// fitContextWindow checks before a turn that its request, with room for
+// MaxTokens of output, fits in the context window. If it does not, the
+// history is compacted when compaction is enabled, and the request is
+// refused with ErrContextWindowExceeded if it still does not fit.
+func (h *Harness) fitContextWindow(ctx context.Context) error {
+	window := h.contextWindow()
+	output := int64(h.config.MaxTokens)
+	tokens := h.countTokens(ctx, h.preflightParams())
+	if tokens+output <= window {
+		return nil
+	}
+
+	logger := log.ForContext(h.logger, ctx)
+	if h.config.CompactionThreshold > 0 {
+		logger.Info("harness", "Compacting to fit the context window",
+			log.F("input_tokens", tokens),
+			log.F("context_window", window),
+		)
+		if err := h.compact(ctx, tokens); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Warn("harness", "Compaction failed", log.F("error", err.Error()))
+		}
+		tokens = h.countTokens(ctx, h.preflightParams())
+		if tokens+output <= window {
+			return nil
+		}
+	}
+	logger.Warn("harness", "Request exceeds the context window",
+		log.F("input_tokens", tokens),
+		log.F("max_tokens", output),
+		log.F("context_window", window),
+	)
+	return fmt.Errorf("%w: the request needs about %d input tokens plus %d for output, but the window is %d",
+		ErrContextWindowExceeded, tokens, output, window)
+}
+
+// preflightParams returns the parts of the next request that count toward
+// its input tokens.
+func (h *Harness) preflightParams() anthropic.MessageNewParams {
+	params := anthropic.MessageNewParams{
+		Model:    anthropic.Model(h.model()),
+		System:   h.systemBlocks(),
+		Messages: h.Messages(),
+		Tools:    h.activeToolParams(),
+	}
+	h.applySampling(&params)
+	return params
+}
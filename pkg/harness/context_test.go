@@ -0,0 +1,110 @@
+package harness_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// countingStreamer adds harness.TokenCounter to a mock streamer, answering
+// counts from a queue and repeating the last one.
+type countingStreamer struct {
+	*testutil.MockMessageStreamer
+	counts []int64
+	calls  int
+}
+
+func (s *countingStreamer) CountTokens(ctx context.Context, params anthropic.MessageNewParams) (int64, error) {
+	count := s.counts[min(s.calls, len(s.counts)-1)]
+	s.calls++
+	return count, nil
+}
+
+func TestContextWindow_RefusesOversizedRequest(t *testing.T) {
+	streamer := &countingStreamer{MockMessageStreamer: testutil.NewMockMessageStreamer(), counts: []int64{199000}}
+	streamer.AddResponse(testutil.TextOnlyResponse("unreachable"))
+
+	config := harness.Config{Model: "test-model", CountTokens: true}
+	h, err := harness.NewHarnessWithStreamer(config, nil, nil, streamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	err = h.Prompt(context.Background(), "go")
+	if !errors.Is(err, harness.ErrContextWindowExceeded) {
+		t.Fatalf("expected ErrContextWindowExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "199000 input tokens plus 4096") {
+		t.Errorf("expected the sizes in the error, got %q", err)
+	}
+	if len(streamer.RecordedParams) != 0 {
+		t.Errorf("expected no API request, got %d", len(streamer.RecordedParams))
+	}
+}
+
+func TestContextWindow_CompactsToFit(t *testing.T) {
+	streamer := &countingStreamer{MockMessageStreamer: testutil.NewMockMessageStreamer(), counts: []int64{100, 100, 20000, 500}}
+	streamer.AddResponse(testutil.SingleToolResponse("call_1", "echo", map[string]string{}))
+	streamer.AddResponse(testutil.SingleToolResponse("call_2", "echo", map[string]string{}))
+	streamer.AddResponse(testutil.TextOnlyResponse("Asked to go; ran echo twice."))
+	streamer.AddResponse(testutil.TextOnlyResponse("Done"))
+
+	handler := &compactionRecorder{}
+	config := harness.Config{
+		Model:                  "test-model",
+		CountTokens:            true,
+		ContextWindow:          10000,
+		CompactionThreshold:    1000000,
+		CompactionKeepMessages: 2,
+	}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{&MockTool{name: "echo"}}, handler, streamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "go"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(handler.Compacted) != 1 {
+		t.Fatalf("expected one compaction, got %v", handler.Compacted)
+	}
+	if len(streamer.RecordedParams) != 4 || streamer.calls != 4 {
+		t.Fatalf("expected 4 API requests and 4 counts, got %d and %d", len(streamer.RecordedParams), streamer.calls)
+	}
+	if next := streamer.RecordedParams[3].Messages; paramText(next[1]) != "Asked to go; ran echo twice." {
+		t.Errorf("expected the compacted history in the next request, got %+v", next)
+	}
+}
+
+func TestContextWindow_EstimatesAndReportsBudget(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("Hi").WithUsage(100, 10).Build())
+
+	recorder := &usageRecorder{}
+	config := harness.Config{Model: "test-model", MaxTokens: 500, ContextWindow: 1000}
+	h, err := harness.NewHarnessWithStreamer(config, nil, recorder, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "hello"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	want := harness.ContextBudget{Tokens: 110, Window: 1000, Remaining: 890}
+	if len(recorder.reports) != 1 || recorder.reports[0].Context != want {
+		t.Errorf("expected context %+v in the usage report, got %+v", want, recorder.reports)
+	}
+	if got := h.ContextBudget(); got != want {
+		t.Errorf("expected ContextBudget %+v, got %+v", want, got)
+	}
+
+	// About 750 estimated tokens leave no room for 500 of output
+	err = h.Prompt(context.Background(), strings.Repeat("word ", 600))
+	if !errors.Is(err, harness.ErrContextWindowExceeded) {
+		t.Errorf("expected ErrContextWindowExceeded from the estimate, got %v", err)
+	}
+}
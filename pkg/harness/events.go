@@ -1,6 +1,9 @@
 package harness
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // EventHandler defines the interface for receiving events from the Harness agent loop.
 // Implementations can use this to stream events to clients (e.g., via SSE).
@@ -26,3 +29,147 @@ type EventHandler interface {
 	// content contains the complete reasoning text.
 	OnReasoning(content string)
 }
+
+// ServerToolHandler is an optional interface an EventHandler can implement to
+// receive events for provider-executed tools (e.g., web search). These tools run
+// on the API side, so there is no local execution and no OnToolResult call.
+type ServerToolHandler interface {
+	// OnServerToolUse is called when the model invokes a server tool.
+	// id is the server tool use identifier, name is the server tool name.
+	OnServerToolUse(id string, name string, input json.RawMessage)
+
+	// OnServerToolResult is called when the provider returns a server tool result.
+	// toolUseID matches the id from OnServerToolUse.
+	// blockType is the API content block type (e.g., "web_search_tool_result").
+	// content is the raw JSON content of the result block.
+	OnServerToolResult(toolUseID string, blockType string, content json.RawMessage)
+}
+
+// FailSafeHandler is an optional interface an EventHandler can implement to be
+// notified when a run switches to read-only tools after repeated failures.
+type FailSafeHandler interface {
+	// OnFailSafe is called once per run when fail-safe mode activates.
+	// failures is the number of consecutive failed mutating tool calls.
+	OnFailSafe(failures int)
+}
+
+// ToolEventHandler is an optional interface an EventHandler can implement to
+// receive custom events emitted by tools during execution via tool.Emit.
+type ToolEventHandler interface {
+	// OnToolEvent is called for each custom event a tool emits.
+	// id matches the id from the corresponding OnToolCall.
+	// eventType is the tool-defined event type (e.g., "test_result").
+	// payload is the JSON-encoded event payload.
+	OnToolEvent(id string, eventType string, payload json.RawMessage)
+}
+
+// ToolOutputHandler is an optional interface an EventHandler can implement to
+// receive process output from tools (e.g., bash) while they are still running.
+type ToolOutputHandler interface {
+	// OnToolOutput is called for each chunk of output a tool reports via tool.EmitOutput.
+	// id matches the id from the corresponding OnToolCall.
+	// stream is "stdout" or "stderr".
+	// text is the output produced since the previous chunk on the stream.
+	OnToolOutput(id string, stream string, text string)
+}
+
+// ToolDisplayHandler is an optional interface an EventHandler can implement to
+// receive client-facing display hints attached to tool results.
+type ToolDisplayHandler interface {
+	// OnToolDisplay is called before OnToolResult when a tool returns a display hint.
+	// id matches the id from the corresponding OnToolCall.
+	// kind is the display kind (e.g., "diff", "table", "filetree").
+	// payload is the JSON-encoded display payload.
+	OnToolDisplay(id string, kind string, payload json.RawMessage)
+}
+
+// SLOHandler is an optional interface an EventHandler can implement to be
+// notified when rolling latencies exceed the configured SLO thresholds.
+type SLOHandler interface {
+	// OnSLOViolation is called when a rolling percentile starts exceeding its
+	// threshold. It is not called again until the percentile recovers.
+	// kind is "api" or "tool"; percentile is "p50" or "p95".
+	OnSLOViolation(kind string, percentile string, observed time.Duration, threshold time.Duration)
+}
+
+// TextDeltaHandler is an optional interface an EventHandler can implement to
+// receive assistant text as it streams, before the complete block arrives
+// via OnText.
+type TextDeltaHandler interface {
+	// OnTextDelta is called for each streamed text fragment.
+	// blockIndex is the content block index within the assistant message;
+	// concatenating the deltas of a block yields the text passed to OnText.
+	OnTextDelta(blockIndex int, delta string)
+}
+
+// RetryHandler is an optional interface an EventHandler can implement to be
+// told when a failed API request will be retried.
+type RetryHandler interface {
+	// OnRetry is called before waiting delay for retry attempt (1-based)
+	// after err.
+	OnRetry(attempt int, delay time.Duration, err error)
+}
+
+// StreamResetHandler is an optional interface an EventHandler can implement
+// to be told when a turn is streamed again. Without it, a handler sees the
+// deltas and blocks of a failed attempt followed by those of the retry.
+type StreamResetHandler interface {
+	// OnStreamReset is called when an API request that already streamed
+	// text deltas or blocks failed with err and the turn will be requested
+	// again, by a retry or with a fallback model. The events of the turn so
+	// far, since the last tool result, should be discarded.
+	OnStreamReset(err error)
+}
+
+// ModelChangeHandler is an optional interface an EventHandler can implement
+// to be told when a run switches to a fallback model.
+type ModelChangeHandler interface {
+	// OnModelChanged is called when requests move from model from to model
+	// to because err persisted through the retries.
+	OnModelChanged(from, to string, err error)
+}
+
+// CompactionHandler is an optional interface an EventHandler can implement to
+// be told when older turns were summarized to stay within the context window.
+type CompactionHandler interface {
+	// OnCompaction is called after the first compacted messages of the
+	// history were replaced by summary.
+	OnCompaction(compacted int, summary string)
+}
+
+// UsageHandler is an optional interface an EventHandler can implement to
+// receive token usage and estimated cost after each turn.
+type UsageHandler interface {
+	// OnUsage is called after each API response of the agent loop.
+	// usage is the JSON encoding of a UsageReport.
+	OnUsage(usage json.RawMessage)
+}
+
+// PlanHandler is an optional interface an EventHandler can implement to
+// receive the agent's task plan whenever the todo tool changes it.
+type PlanHandler interface {
+	// OnPlan is called with the JSON encoding of the complete task list,
+	// a []tool.TodoItem. The list is empty after Reset.
+	OnPlan(plan json.RawMessage)
+}
+
+// SteeringHandler is an optional interface an EventHandler can implement to
+// learn when a message injected with Inject enters the conversation.
+type SteeringHandler interface {
+	// OnSteering is called with each injected message as it is added to
+	// the conversation, before the API request that carries it.
+	OnSteering(content string)
+}
+
+// APIHandler is an optional interface an EventHandler can implement to
+// receive each API request and response of the agent loop in full, for
+// logging and replay.
+type APIHandler interface {
+	// OnAPIRequest is called before each request of a turn, retries not
+	// repeated, with the JSON encoding of the anthropic.MessageNewParams.
+	OnAPIRequest(runID string, params json.RawMessage)
+
+	// OnAPIResponse is called with the JSON encoding of each complete
+	// anthropic.Message, after the events of its blocks.
+	OnAPIResponse(runID string, message json.RawMessage)
+}
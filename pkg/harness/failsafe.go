@@ -0,0 +1,98 @@
+package harness
+
+import (
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// failSafeNotice is sent to the model when fail-safe mode activates.
+const failSafeNotice = "Fail-safe mode activated: %d consecutive mutating tool calls failed. " +
+	"Tools that modify files or run commands are disabled for the rest of this run. " +
+	"Use the remaining read-only tools to investigate, then explain the problem to the user."
+
+// failSafeState tracks consecutive mutating tool failures within a single run.
+type failSafeState struct {
+	consecutiveFailures int
+	active              bool
+}
+
+// isMutating reports whether the named tool is subject to fail-safe restrictions.
+// Unknown tools are treated as mutating.
+func (h *Harness) isMutating(name string) bool {
+	t, ok := h.tools[name]
+	if !ok {
+		return true
+	}
+	return !tool.IsReadOnly(t)
+}
+
+// recordToolOutcome updates fail-safe state after a tool call.
+// Returns true if this outcome activated fail-safe mode.
+func (h *Harness) recordToolOutcome(name string, failed bool) bool {
+	if h.config.MaxMutatingFailures <= 0 || !h.isMutating(name) {
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failSafe.active {
+		return false
+	}
+	if !failed {
+		h.failSafe.consecutiveFailures = 0
+		return false
+	}
+	h.failSafe.consecutiveFailures++
+	if h.failSafe.consecutiveFailures <= h.config.MaxMutatingFailures {
+		return false
+	}
+	h.failSafe.active = true
+	return true
+}
+
+// failSafeActive reports whether mutating tools are currently disabled.
+func (h *Harness) failSafeActive() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.failSafe.active
+}
+
+// activateFailSafe logs, emits, and returns the notice block for the model.
+func (h *Harness) activateFailSafe() anthropic.ContentBlockParamUnion {
+	failures := h.config.MaxMutatingFailures + 1
+	h.logger.Warn("harness", "Fail-safe mode activated",
+		log.F("consecutive_failures", failures),
+	)
+	if fh, ok := h.handler.(FailSafeHandler); ok {
+		fh.OnFailSafe(failures)
+	}
+	return anthropic.NewTextBlock(fmt.Sprintf(failSafeNotice, failures))
+}
+
+// activeToolParams returns the tool definitions to offer the model for the next turn.
+// Local tools excluded by the prompt's options are withheld, as are mutating
+// local tools in fail-safe mode.
+func (h *Harness) activeToolParams() []anthropic.ToolUnionParam {
+	failSafe := h.failSafeActive()
+	if !failSafe && h.options.Tools == nil {
+		return h.toolParams
+	}
+	params := make([]anthropic.ToolUnionParam, 0, len(h.toolParams))
+	for _, p := range h.toolParams {
+		if p.OfTool != nil && (!h.toolAllowed(p.OfTool.Name) || failSafe && h.isMutating(p.OfTool.Name)) {
+			continue
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// NeedsReview reports whether the running or most recent run entered
+// fail-safe mode and should be reviewed by a human. A finished run's
+// RunResult carries the same flag.
+func (h *Harness) NeedsReview() bool {
+	return h.failSafeActive()
+}
@@ -0,0 +1,176 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// readOnlyMockTool is a MockTool that declares itself read-only.
+type readOnlyMockTool struct {
+	MockTool
+}
+
+func (t *readOnlyMockTool) ReadOnly() bool { return true }
+
+// failSafeRecorder extends MockEventHandler with FailSafeHandler support.
+type failSafeRecorder struct {
+	MockEventHandler
+	FailSafeEvents []int
+}
+
+func (h *failSafeRecorder) OnFailSafe(failures int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.FailSafeEvents = append(h.FailSafeEvents, failures)
+}
+
+func toolNames(params []anthropic.ToolUnionParam) []string {
+	var names []string
+	for _, p := range params {
+		if p.OfTool != nil {
+			names = append(names, p.OfTool.Name)
+		}
+	}
+	return names
+}
+
+func TestFailSafe_DisablesMutatingToolsAfterConsecutiveFailures(t *testing.T) {
+	writeCalls := 0
+	tools := []tool.Tool{
+		&MockTool{
+			name: "write",
+			executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+				writeCalls++
+				return `{"error":"permission denied"}`, nil
+			},
+		},
+		&readOnlyMockTool{MockTool{name: "read"}},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("t1", "write", map[string]string{"value": "a"}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("t2", "write", map[string]string{"value": "b"}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("t3", "write", map[string]string{"value": "c"}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("t4", "write", map[string]string{"value": "d"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("I could not write the file."))
+
+	handler := &failSafeRecorder{}
+	h, err := harness.NewHarnessWithStreamer(
+		harness.Config{Model: "test-model", MaxMutatingFailures: 2},
+		tools,
+		handler,
+		mockStreamer,
+	)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	if err := h.Prompt(context.Background(), "write stuff"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	// More than 2 failures activate it, so the fourth write must be
+	// refused without executing
+	if writeCalls != 3 {
+		t.Errorf("expected 3 write executions, got %d", writeCalls)
+	}
+	if len(handler.FailSafeEvents) != 1 || handler.FailSafeEvents[0] != 3 {
+		t.Errorf("expected one fail-safe event with 3 failures, got %v", handler.FailSafeEvents)
+	}
+	if !h.NeedsReview() || !h.LastRun().NeedsReview {
+		t.Error("run should be flagged for review")
+	}
+
+	// After activation only read-only tools are offered
+	if names := toolNames(mockStreamer.RecordedParams[2].Tools); len(names) != 2 {
+		t.Errorf("expected both tools before activation, got %v", names)
+	}
+	if names := toolNames(mockStreamer.RecordedParams[3].Tools); len(names) != 1 || names[0] != "read" {
+		t.Errorf("expected only read tool after activation, got %v", names)
+	}
+
+	// The notice must follow the tool result in the same user message
+	msgs := h.Messages()
+	notice := msgs[6].Content[len(msgs[6].Content)-1]
+	if notice.OfText == nil || !strings.Contains(notice.OfText.Text, "Fail-safe mode activated") {
+		t.Errorf("expected fail-safe notice after tool result, got %+v", notice)
+	}
+}
+
+func TestFailSafe_SuccessResetsCounter(t *testing.T) {
+	results := []string{`{"error":"boom"}`, `{"error":"boom"}`, `{"ok":true}`, `{"error":"boom"}`, `{"error":"boom"}`}
+	call := 0
+	tools := []tool.Tool{
+		&MockTool{
+			name: "bash",
+			executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+				r := results[call]
+				call++
+				return r, nil
+			},
+		},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	for i := range results {
+		mockStreamer.AddResponse(testutil.SingleToolResponse(fmt.Sprintf("t%d", i+1), "bash", map[string]string{"value": "x"}))
+	}
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+
+	handler := &failSafeRecorder{}
+	h, err := harness.NewHarnessWithStreamer(
+		harness.Config{Model: "test-model", MaxMutatingFailures: 2},
+		tools,
+		handler,
+		mockStreamer,
+	)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	if err := h.Prompt(context.Background(), "run"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if len(handler.FailSafeEvents) != 0 {
+		t.Errorf("fail-safe should not activate when failures are not consecutive")
+	}
+	if h.NeedsReview() || h.LastRun().NeedsReview {
+		t.Error("run should not be flagged for review")
+	}
+}
+
+func TestFailSafe_DisabledByDefault(t *testing.T) {
+	tools := []tool.Tool{
+		&MockTool{
+			name: "write",
+			executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+				return `{"error":"nope"}`, nil
+			},
+		},
+	}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	for i := 0; i < 3; i++ {
+		mockStreamer.AddResponse(testutil.SingleToolResponse("t", "write", map[string]string{"value": "x"}))
+	}
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+
+	handler := &failSafeRecorder{}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, tools, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "go"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if len(handler.FailSafeEvents) != 0 {
+		t.Error("fail-safe should be disabled when MaxMutatingFailures is 0")
+	}
+}
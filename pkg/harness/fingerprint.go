@@ -0,0 +1,101 @@
+package harness
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// fingerprintTools are the external binaries whose versions are recorded.
+var fingerprintTools = []string{"git", "grep", "bash"}
+
+// fingerprintTimeout bounds each version probe.
+const fingerprintTimeout = 2 * time.Second
+
+// Fingerprint describes the environment a run executed in, so behavior
+// differences across machines can be traced to the recorded environment.
+type Fingerprint struct {
+	OS        string            `json:"os"`
+	Arch      string            `json:"arch"`
+	GoVersion string            `json:"go_version"`
+	Tools     map[string]string `json:"tools"` // first line of `<tool> --version`; missing tools are omitted
+	Workspace string            `json:"workspace"`
+	GitSHA    string            `json:"git_sha,omitempty"` // HEAD of the workspace, if it is a git checkout
+}
+
+var (
+	toolVersionsOnce sync.Once
+	toolVersions     map[string]string
+)
+
+// CaptureFingerprint records the environment for a run in dir.
+// Tool versions are probed once per process; the git SHA is read each time.
+// Probes that fail or time out are left out.
+func CaptureFingerprint(ctx context.Context, dir string) Fingerprint {
+	toolVersionsOnce.Do(func() {
+		toolVersions = make(map[string]string)
+		for _, name := range fingerprintTools {
+			if v := commandLine(context.Background(), "", name, "--version"); v != "" {
+				toolVersions[name] = v
+			}
+		}
+	})
+
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	tools := make(map[string]string, len(toolVersions))
+	for name, v := range toolVersions {
+		tools[name] = v
+	}
+	return Fingerprint{
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		GoVersion: runtime.Version(),
+		Tools:     tools,
+		Workspace: dir,
+		GitSHA:    commandLine(ctx, dir, "git", "rev-parse", "HEAD"),
+	}
+}
+
+// commandLine runs a command and returns the first line of its output, or ""
+// if it fails.
+func commandLine(ctx context.Context, dir, name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(ctx, fingerprintTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimSpace(line)
+}
+
+// recordFingerprint captures the environment of the starting run into its result.
+func (h *Harness) recordFingerprint(ctx context.Context) {
+	fp := CaptureFingerprint(ctx, h.Workspace())
+	log.ForContext(h.logger, ctx).Info("harness", "Run environment",
+		log.F("os", fp.OS),
+		log.F("arch", fp.Arch),
+		log.F("go_version", fp.GoVersion),
+		log.F("workspace", fp.Workspace),
+		log.F("git_sha", fp.GitSHA),
+	)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.result.Environment = &fp
+}
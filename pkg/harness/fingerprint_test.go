@@ -0,0 +1,87 @@
+package harness_test
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func TestCaptureFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	fp := harness.CaptureFingerprint(context.Background(), dir)
+
+	if fp.OS != runtime.GOOS || fp.Arch != runtime.GOARCH || fp.GoVersion != runtime.Version() {
+		t.Errorf("unexpected platform: %+v", fp)
+	}
+	if fp.Workspace != dir || fp.GitSHA != "" {
+		t.Errorf("expected non-git workspace %s, got %+v", dir, fp)
+	}
+	if _, err := exec.LookPath("bash"); err == nil && !strings.Contains(fp.Tools["bash"], "bash") {
+		t.Errorf("expected bash version, got %q", fp.Tools["bash"])
+	}
+}
+
+func TestCaptureFingerprint_GitSHA(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	head, _ := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+
+	fp := harness.CaptureFingerprint(context.Background(), dir)
+	if fp.GitSHA != strings.TrimSpace(string(head)) {
+		t.Errorf("expected git SHA %s, got %q", head, fp.GitSHA)
+	}
+}
+
+func TestLastRun_Environment(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Hi"))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	dir := t.TempDir()
+	h.SetWorkspace(dir)
+	h.Prompt(context.Background(), "hello")
+
+	env := h.LastRun().Environment
+	if env == nil || env.Workspace != dir || env.OS != runtime.GOOS {
+		t.Errorf("expected environment for workspace %s, got %+v", dir, env)
+	}
+}
+
+func TestSetWorkspace_RestoresConfigured(t *testing.T) {
+	dir := t.TempDir()
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Workspace: dir}, nil, nil, testutil.NewMockMessageStreamer())
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if got := h.Workspace(); got != dir {
+		t.Fatalf("expected the configured workspace %s, got %q", dir, got)
+	}
+	h.SetWorkspace(t.TempDir())
+	h.SetWorkspace("")
+	if got := h.Workspace(); got != dir {
+		t.Errorf("expected the configured workspace restored, got %q", got)
+	}
+	if got := h.NewSession(nil).Workspace(); got != dir {
+		t.Errorf("expected sessions to inherit the workspace, got %q", got)
+	}
+}
@@ -2,8 +2,12 @@ package harness
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,11 +15,20 @@ import (
 	"github.com/anthropics/anthropic-sdk-go/option"
 	"github.com/user/harness/pkg/log"
 	"github.com/user/harness/pkg/tool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ErrPromptInProgress is returned when Prompt is called while another prompt is running.
 var ErrPromptInProgress = errors.New("another prompt is already in progress")
 
+// ErrMaxTurnsExceeded is returned when a run reaches its turn limit with the
+// model's tool calls answered but no final response. Continue resumes it.
+var ErrMaxTurnsExceeded = errors.New("max turns exceeded")
+
+// ErrNothingToContinue is returned by Continue when the conversation does
+// not end with tool results awaiting a response.
+var ErrNothingToContinue = errors.New("nothing to continue: the conversation does not end with tool results")
+
 // Harness orchestrates the AI agent loop, connecting the Anthropic API
 // with tools and event handling.
 type Harness struct {
@@ -27,6 +40,80 @@ type Harness struct {
 	logger     log.Logger
 	messages   []anthropic.MessageParam
 
+	// When each message was added; zero for messages loaded from a store
+	// (guarded by mu, parallel to messages)
+	messageTimes []time.Time
+
+	// Per-run fail-safe tracking (guarded by mu)
+	failSafe failSafeState
+
+	// Set by Preempt to stop the run before its next API request (guarded by mu)
+	preempted bool
+
+	// How many of Config.FallbackModels the running prompt has moved
+	// through; 0 means it uses its own model
+	fallback int
+
+	// Whether the current API request has streamed events to the handler,
+	// which a retry must reset
+	streamed bool
+
+	// Accumulated token usage (guarded by mu)
+	usage Usage
+
+	// Context size reported by the latest API response, for compaction (guarded by mu)
+	contextTokens int64
+
+	// Result of the running or most recent prompt (guarded by mu)
+	result RunResult
+
+	// Overrides of the running or most recent prompt (guarded by mu)
+	options PromptOptions
+
+	// Profile of prompts that name none (guarded by mu)
+	profile string
+
+	// User messages injected into the running prompt, awaiting the next
+	// turn (guarded by mu)
+	steering []string
+
+	// Conversation and file state before recent turns, oldest first, for
+	// Rollback (guarded by mu)
+	checkpoints   []*checkpoint
+	checkpointSeq int
+
+	// Conversation persistence; nil store disables it (guarded by mu)
+	store          Store
+	conversationID string
+
+	// Rolling latency statistics, shared with sessions
+	latency *latencyTracker
+
+	// Directory tool paths are jailed to; empty means unrestricted (guarded by mu)
+	workspace string
+
+	// Consulted before each tool call; nil runs all calls (guarded by mu)
+	permission PermissionHandler
+
+	// Answers the ask_user tool's questions; nil means no user is
+	// available (guarded by mu)
+	questions QuestionHandler
+
+	// Host callbacks at points of the agent loop (guarded by mu)
+	hooks Hooks
+
+	// Temp dir of the most recent run, if kept for its artifacts (guarded by mu)
+	keptTempDir string
+
+	// Compiled OutputSummaries patterns by tool name
+	outputPatterns map[string][]*regexp.Regexp
+
+	// Read cache statistics, shared with sessions
+	readCache *readCacheTotals
+
+	// Task plan kept by the todo tool; cleared with the conversation
+	plan tool.TodoList
+
 	// Concurrency control
 	mu           sync.Mutex
 	running      bool
@@ -42,7 +129,12 @@ func NewHarness(config Config, tools []tool.Tool, handler EventHandler) (*Harnes
 	}
 
 	// Create Anthropic client
-	client := anthropic.NewClient(option.WithAPIKey(config.APIKey))
+	opts := []option.RequestOption{option.WithAPIKey(config.APIKey)}
+	if config.MaxRetries > 0 {
+		// The harness retries with its own backoff and reports each attempt
+		opts = append(opts, option.WithMaxRetries(0))
+	}
+	client := anthropic.NewClient(opts...)
 
 	// Convert tools to API format and build lookup map
 	toolParams := make([]anthropic.ToolUnionParam, len(tools))
@@ -51,8 +143,14 @@ func NewHarness(config Config, tools []tool.Tool, handler EventHandler) (*Harnes
 		toolParams[i] = toolToParam(t)
 		toolMap[t.Name()] = t
 	}
+	toolParams = append(toolParams, serverToolParams(config)...)
 
-	return &Harness{
+	outputPatterns, err := compileOutputSummaries(config.OutputSummaries)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Harness{
 		streamer:   &realMessageStreamer{client: client},
 		config:     config,
 		tools:      toolMap,
@@ -60,7 +158,17 @@ func NewHarness(config Config, tools []tool.Tool, handler EventHandler) (*Harnes
 		handler:    handler,
 		logger:     log.NopLogger{},
 		messages:   []anthropic.MessageParam{},
-	}, nil
+		latency:    newLatencyTracker(config.SLOWindow),
+		readCache:  &readCacheTotals{active: make(map[*tool.ReadCache]bool)},
+		profile:    config.Profile,
+		workspace:  config.Workspace,
+
+		outputPatterns: outputPatterns,
+	}
+	if err := h.validateProfileTools(); err != nil {
+		return nil, err
+	}
+	return h, nil
 }
 
 // NewHarnessWithStreamer creates a new Harness with a custom MessageStreamer.
@@ -89,8 +197,14 @@ func NewHarnessWithStreamer(config Config, tools []tool.Tool, handler EventHandl
 		toolParams[i] = toolToParam(t)
 		toolMap[t.Name()] = t
 	}
+	toolParams = append(toolParams, serverToolParams(config)...)
 
-	return &Harness{
+	outputPatterns, err := compileOutputSummaries(config.OutputSummaries)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Harness{
 		streamer:   streamer,
 		config:     config,
 		tools:      toolMap,
@@ -98,7 +212,46 @@ func NewHarnessWithStreamer(config Config, tools []tool.Tool, handler EventHandl
 		handler:    handler,
 		logger:     log.NopLogger{},
 		messages:   []anthropic.MessageParam{},
-	}, nil
+		latency:    newLatencyTracker(config.SLOWindow),
+		readCache:  &readCacheTotals{active: make(map[*tool.ReadCache]bool)},
+		profile:    config.Profile,
+		workspace:  config.Workspace,
+
+		outputPatterns: outputPatterns,
+	}
+	if err := validateProfiles(config.Profiles, config.Profile); err != nil {
+		return nil, err
+	}
+	if err := h.validateProfileTools(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// NewSession returns a new Harness that shares this harness's configuration,
+// tools, workspace, API streamer, and logger but starts with an empty conversation.
+// Events from the new session go to handler, which may be nil.
+func (h *Harness) NewSession(handler EventHandler) *Harness {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return &Harness{
+		streamer:   h.streamer,
+		config:     h.config,
+		tools:      h.tools,
+		toolParams: h.toolParams,
+		handler:    handler,
+		logger:     h.logger,
+		messages:   []anthropic.MessageParam{},
+		latency:    h.latency,
+		readCache:  h.readCache,
+		workspace:  h.workspace,
+		permission: h.permission,
+		questions:  h.questions,
+		hooks:      h.hooks,
+		profile:    h.config.Profile,
+
+		outputPatterns: h.outputPatterns,
+	}
 }
 
 // toolToParam converts a Tool interface to Anthropic ToolUnionParam.
@@ -131,27 +284,121 @@ func toolToParam(t tool.Tool) anthropic.ToolUnionParam {
 	}
 }
 
+// serverToolParams returns the provider-executed tools enabled by the config.
+func serverToolParams(config Config) []anthropic.ToolUnionParam {
+	var params []anthropic.ToolUnionParam
+	if config.EnableWebSearch {
+		webSearch := &anthropic.WebSearchTool20250305Param{}
+		if config.WebSearchMaxUses > 0 {
+			webSearch.MaxUses = anthropic.Int(int64(config.WebSearchMaxUses))
+		}
+		params = append(params, anthropic.ToolUnionParam{OfWebSearchTool20250305: webSearch})
+	}
+	return params
+}
+
 // Prompt sends a user message to the agent and runs the agent loop until completion.
-// Returns an error if another prompt is already in progress, the API fails, or context is cancelled.
+// Returns an error if another prompt is already in progress, the API fails, or context is cancelled,
+// ErrMaxTurnsExceeded if the turn limit stopped the loop, and a *BudgetError if a budget did.
+// The run's result is available from LastRun.
 func (h *Harness) Prompt(ctx context.Context, content string) error {
+	_, err := h.PromptWithOptions(ctx, content, PromptOptions{})
+	return err
+}
+
+// PromptWithOptions is like Prompt, with configuration overrides for this
+// prompt only, and returns the run's result, also when the run fails after
+// it started. Invalid options are rejected before the prompt starts.
+func (h *Harness) PromptWithOptions(ctx context.Context, content string, opts PromptOptions) (RunResult, error) {
+	if err := h.ValidateOptions(opts); err != nil {
+		return RunResult{}, err
+	}
+	return h.run(ctx, &content, opts)
+}
+
+// Continue resumes a run stopped by ErrMaxTurnsExceeded or by
+// Config.MaxTokensPerPrompt: it runs the agent loop again, with fresh turn
+// and token budgets and the previous prompt's options, so the model can
+// respond to the last tool results. It returns the result
+// of the resumed run, ErrNothingToContinue unless the conversation ends
+// with tool results, and ErrPromptInProgress while a prompt is running.
+func (h *Harness) Continue(ctx context.Context) (RunResult, error) {
+	return h.run(ctx, nil, PromptOptions{})
+}
+
+// CanContinue reports whether Continue has a run to resume: no prompt is
+// running and the conversation ends with tool results.
+func (h *Harness) CanContinue() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return !h.running && h.endsWithToolResults()
+}
+
+// endsWithToolResults reports whether the last message holds tool results.
+// Callers hold h.mu.
+func (h *Harness) endsWithToolResults() bool {
+	last := len(h.messages) - 1
+	if last < 0 || h.messages[last].Role != anthropic.MessageParamRoleUser {
+		return false
+	}
+	for _, block := range h.messages[last].Content {
+		if block.OfToolResult != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs the agent loop for a new prompt, or resumes the conversation
+// with the previous prompt's options if content is nil.
+func (h *Harness) run(ctx context.Context, content *string, opts PromptOptions) (RunResult, error) {
 	h.mu.Lock()
 	if h.running {
 		h.mu.Unlock()
-		return ErrPromptInProgress
+		return RunResult{}, ErrPromptInProgress
+	}
+	if content == nil {
+		if !h.endsWithToolResults() {
+			h.mu.Unlock()
+			return RunResult{}, ErrNothingToContinue
+		}
+		opts = h.options
+	} else {
+		opts = h.withProfile(opts)
 	}
 	h.running = true
+	h.options = opts
+	// Tag the run's logs and tool calls with its ID, unless the caller did
+	runID := log.RunID(ctx)
+	if runID == "" {
+		runID = newRunID()
+		ctx = log.WithRunID(ctx, runID)
+	}
 	// Create a cancellable context for this prompt
 	promptCtx, cancel := context.WithCancel(ctx)
 	h.cancelFunc = cancel
 	h.runningCtx = promptCtx
+	h.failSafe = failSafeState{}
+	h.preempted = false
+	h.fallback = 0
+	h.result = RunResult{RunID: runID}
 	h.mu.Unlock()
 
+	promptCtx, span := h.startRunSpan(promptCtx)
+	logger := log.ForContext(h.logger, promptCtx)
 	loopStart := time.Now()
-	h.logger.Info("harness", "Agent loop started",
-		log.F("prompt_length", len(content)),
-	)
+	if content != nil {
+		logger.Info("harness", "Agent loop started",
+			log.F("prompt_length", len(*content)),
+		)
+	} else {
+		logger.Info("harness", "Agent loop resumed",
+			log.F("messages", len(h.Messages())),
+		)
+	}
 
 	defer func() {
+		h.dropSteering()
 		h.mu.Lock()
 		h.running = false
 		h.cancelFunc = nil
@@ -159,25 +406,73 @@ func (h *Harness) Prompt(ctx context.Context, content string) error {
 		h.mu.Unlock()
 	}()
 
-	// Append user message to conversation history
-	h.messages = append(h.messages, anthropic.NewUserMessage(anthropic.NewTextBlock(content)))
+	h.recordFingerprint(promptCtx)
+
+	// Give tools a scratch directory that is removed when the run ends, and
+	// remove the one the previous run kept
+	h.removeKeptTempDir(promptCtx)
+	promptCtx, tempDir := h.startTempDir(promptCtx)
+	defer h.finishTempDir(promptCtx, tempDir)
+
+	// Cache the files tools read during the run
+	promptCtx, readCache := h.startReadCache(promptCtx)
+	defer h.finishReadCache(promptCtx, readCache)
+
+	// A spent session budget refuses the prompt before it is added to the
+	// conversation
+	err := h.checkBudget()
+	if err == nil {
+		// Append user message to conversation history, after the
+		// checkpoint of the first turn
+		h.beginCheckpoint()
+		if content != nil {
+			h.appendMessage(anthropic.NewUserMessage(anthropic.NewTextBlock(*content)))
+		}
 
-	// Run the agent loop
-	err := h.runAgentLoop(promptCtx)
+		// Run the agent loop
+		err = h.runAgentLoop(promptCtx)
+	}
+	if err != nil && errors.Is(promptCtx.Err(), context.Canceled) {
+		// Report a cancelled run as cancelled, whether the API request,
+		// a tool, or the turn limit noticed first
+		err = context.Canceled
+	}
+	h.saveConversation()
 
 	duration := time.Since(loopStart)
-	if err != nil {
-		h.logger.Error("harness", "Agent loop failed",
+	result := h.finishResult(err, duration)
+	h.runComplete(promptCtx, result, err)
+	span.SetAttributes(
+		attribute.Int(attrTurns, result.Turns),
+		attribute.String(attrStopReason, result.StopReason),
+	)
+	endSpan(span, err)
+	var budgetErr *BudgetError
+	if errors.Is(err, ErrMaxTurnsExceeded) {
+		logger.Warn("harness", "Agent loop stopped at the turn limit",
+			log.F("turns", result.Turns),
+			log.F("total_duration_ms", duration.Milliseconds()),
+		)
+	} else if errors.As(err, &budgetErr) {
+		logger.Warn("harness", "Agent loop stopped at the budget",
+			log.F("budget", budgetErr.Budget),
+			log.F("limit", budgetErr.Limit),
+			log.F("used", budgetErr.Used),
+			log.F("turns", result.Turns),
+			log.F("total_duration_ms", duration.Milliseconds()),
+		)
+	} else if err != nil {
+		logger.Error("harness", "Agent loop failed",
 			log.F("error", err.Error()),
 			log.F("total_duration_ms", duration.Milliseconds()),
 		)
 	} else {
-		h.logger.Info("harness", "Agent loop completed",
+		logger.Info("harness", "Agent loop completed",
 			log.F("total_duration_ms", duration.Milliseconds()),
 		)
 	}
 
-	return err
+	return result, err
 }
 
 // Cancel cancels the currently running prompt, if any.
@@ -200,98 +495,169 @@ type ToolCall struct {
 // runAgentLoop runs the main agent loop until termination.
 // Termination conditions:
 // 1. No tool calls in response → end loop
-// 2. MaxTurns exceeded → end loop
+// 2. MaxTurns exceeded → return ErrMaxTurnsExceeded
 // 3. API error → return error
 // 4. Context cancelled → return error
+// 5. Preempt called → return ErrPreempted before the next API call
+// 6. Budget used up → return a *BudgetError before the next API call
+// 7. BeforeTurn hook fails → return its error
+//
+// Messages injected with Inject are added before each API call, and keep
+// the loop going when the model finishes with messages pending.
 func (h *Harness) runAgentLoop(ctx context.Context) error {
-	for turn := 0; turn < h.config.MaxTurns; turn++ {
+	logger := log.ForContext(h.logger, ctx)
+	for turn := 0; turn < h.maxTurns(); turn++ {
 		// Check context before making API call
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
 		}
-
-		// Build system blocks if we have a system prompt
-		var systemBlocks []anthropic.TextBlockParam
-		if h.config.SystemPrompt != "" {
-			systemBlocks = []anthropic.TextBlockParam{{Text: h.config.SystemPrompt}}
+		if h.preemptRequested() {
+			logger.Info("harness", "Run preempted",
+				log.F("turn", turn+1),
+			)
+			return ErrPreempted
+		}
+		if err := h.checkBudget(); err != nil {
+			return err
+		}
+		if err := h.beforeTurn(ctx, turn); err != nil {
+			return err
+		}
+		done, err := h.runTurn(ctx, turn)
+		if err != nil || done {
+			return err
 		}
+	}
 
-		// Log API request
-		h.logger.Info("api", "Request sent",
-			log.F("model", h.config.Model),
-			log.F("messages", len(h.messages)),
-			log.F("tools", len(h.toolParams)),
-		)
-		apiStart := time.Now()
-
-		// Create streaming request
-		stream := h.streamer.NewStreaming(ctx, anthropic.MessageNewParams{
-			Model:     anthropic.Model(h.config.Model),
-			MaxTokens: int64(h.config.MaxTokens),
-			System:    systemBlocks,
-			Messages:  h.messages,
-			Tools:     h.toolParams,
-		})
-
-		// Accumulate streaming response
-		message := anthropic.Message{}
-		for stream.Next() {
-			event := stream.Current()
-			if err := message.Accumulate(event); err != nil {
-				return err
-			}
+	// MaxTurns reached
+	h.mu.Lock()
+	h.result.StopReason = StopReasonMaxTurns
+	h.mu.Unlock()
+	return fmt.Errorf("%w (limit %d)", ErrMaxTurnsExceeded, h.maxTurns())
+}
 
-			// Emit events on ContentBlockStopEvent
-			switch e := event.AsAny().(type) {
-			case anthropic.ContentBlockStopEvent:
-				h.emitBlockComplete(&message, e.Index)
-			}
-		}
-		if stream.Err() != nil {
-			apiDuration := time.Since(apiStart)
-			h.logger.Error("api", "Request failed",
-				log.F("model", h.config.Model),
-				log.F("error", stream.Err().Error()),
-				log.F("duration_ms", apiDuration.Milliseconds()),
-			)
-			return stream.Err()
+// runTurn makes the API request of turn, from 0, and executes the tool
+// calls of its response. done reports that the model has finished.
+func (h *Harness) runTurn(ctx context.Context, turn int) (done bool, err error) {
+	ctx, span := startTurnSpan(ctx, turn+1)
+	defer func() { endSpan(span, err) }()
+	logger := log.ForContext(h.logger, ctx)
+	var response *anthropic.Message
+	defer func() {
+		if err == nil && response != nil {
+			h.afterTurn(ctx, turn, response)
 		}
+	}()
 
-		// Log API response
+	if turn > 0 {
+		h.beginCheckpoint()
+	}
+	h.applySteering()
+	if err := h.maybeCompact(ctx); err != nil {
+		return false, err
+	}
+	if err := h.fitContextWindow(ctx); err != nil {
+		return false, err
+	}
+
+	// Log API request
+	logger.Info("api", "Request sent",
+		log.F("model", h.model()),
+		log.F("messages", len(h.messages)),
+		log.F("tools", len(h.toolParams)),
+	)
+	apiStart := time.Now()
+	toolParams := h.activeToolParams()
+
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.Model(h.model()),
+		MaxTokens: int64(h.config.MaxTokens),
+		System:    h.systemBlocks(),
+		Messages:  h.messages,
+		Tools:     toolParams,
+	}
+	h.applySampling(&params)
+	if h.config.PromptCaching {
+		addCacheBreakpoints(&params)
+	}
+
+	// Stream the response, retrying transient API errors
+	h.emitAPIRequest(ctx, params)
+	message, err := h.streamWithRetry(ctx, params)
+	if err != nil {
 		apiDuration := time.Since(apiStart)
-		h.logger.Info("api", "Response received",
-			log.F("input_tokens", message.Usage.InputTokens),
-			log.F("output_tokens", message.Usage.OutputTokens),
+		logger.Error("api", "Request failed",
+			log.F("model", h.model()),
+			log.F("error", err.Error()),
 			log.F("duration_ms", apiDuration.Milliseconds()),
 		)
+		return false, err
+	}
+	response = &message
+
+	// Log API response
+	apiDuration := time.Since(apiStart)
+	usage := h.usageFromAPI(h.model(), message.Usage)
+	logger.Info("api", "Response received",
+		log.F("input_tokens", usage.InputTokens),
+		log.F("output_tokens", usage.OutputTokens),
+		log.F("cache_creation_input_tokens", usage.CacheCreationInputTokens),
+		log.F("cache_read_input_tokens", usage.CacheReadInputTokens),
+		log.F("web_search_requests", usage.WebSearchRequests),
+		log.F("cost_usd", usage.CostUSD),
+		log.F("duration_ms", apiDuration.Milliseconds()),
+	)
 
-		// Append assistant message to history
-		h.messages = append(h.messages, message.ToParam())
-
-		// Process tool calls
-		toolCalls := h.extractToolCalls(&message)
-		if len(toolCalls) == 0 {
-			return nil // No tool calls = done
+	h.emitAPIResponse(ctx, &message)
+	h.recordUsage(usage)
+	h.mu.Lock()
+	h.contextTokens = contextTokens(message.Usage)
+	h.mu.Unlock()
+	h.recordTurn(&message, usage)
+	h.emitUsage(usage)
+	h.recordLatency(LatencyAPI, apiDuration)
+
+	// Append assistant message to history
+	h.appendMessage(message.ToParam())
+
+	// Process tool calls
+	toolCalls := h.extractToolCalls(&message)
+	if len(toolCalls) == 0 {
+		if h.steeringPending() {
+			return false, nil // Respond to messages injected during the turn
 		}
+		return true, nil // No tool calls = done
+	}
 
-		// Log turn completion at debug level
-		h.logger.Debug("harness", "Turn completed",
-			log.F("turn", turn+1),
-			log.F("tool_calls", len(toolCalls)),
-		)
+	// Log turn completion at debug level
+	logger.Debug("harness", "Turn completed",
+		log.F("turn", turn+1),
+		log.F("tool_calls", len(toolCalls)),
+	)
 
-		// Execute tools sequentially with fail-fast
-		toolResults, err := h.executeTools(ctx, toolCalls)
-		if err != nil {
-			return err // Context cancellation
-		}
+	// Execute tools sequentially with fail-fast
+	toolResults, err := h.executeTools(ctx, toolCalls)
+	if err != nil {
+		return false, err // Context cancellation
+	}
 
-		// Append tool results as user message
-		h.messages = append(h.messages, anthropic.NewUserMessage(toolResults...))
+	// Append tool results as user message
+	h.appendMessage(anthropic.NewUserMessage(toolResults...))
+	h.saveConversation()
+	return false, nil
+}
+
+// emitTextDelta forwards a streamed text fragment to handlers that accept deltas.
+func (h *Harness) emitTextDelta(e anthropic.ContentBlockDeltaEvent) {
+	if e.Delta.Type != "text_delta" || e.Delta.Text == "" {
+		return
+	}
+	if th, ok := h.handler.(TextDeltaHandler); ok {
+		h.streamed = true
+		th.OnTextDelta(int(e.Index), e.Delta.Text)
 	}
-	return nil // MaxTurns reached
 }
 
 // emitBlockComplete emits events for a completed content block.
@@ -303,6 +669,7 @@ func (h *Harness) emitBlockComplete(msg *anthropic.Message, index int64) {
 	if int(index) >= len(msg.Content) {
 		return
 	}
+	h.streamed = true
 
 	block := msg.Content[index]
 	switch b := block.AsAny().(type) {
@@ -313,6 +680,15 @@ func (h *Harness) emitBlockComplete(msg *anthropic.Message, index int64) {
 		h.handler.OnToolCall(b.ID, b.Name, inputJSON)
 	case anthropic.ThinkingBlock:
 		h.handler.OnReasoning(b.Thinking)
+	case anthropic.ServerToolUseBlock:
+		if sh, ok := h.handler.(ServerToolHandler); ok {
+			inputJSON, _ := json.Marshal(b.Input)
+			sh.OnServerToolUse(b.ID, string(b.Name), inputJSON)
+		}
+	case anthropic.WebSearchToolResultBlock:
+		if sh, ok := h.handler.(ServerToolHandler); ok {
+			sh.OnServerToolResult(b.ToolUseID, string(b.Type), json.RawMessage(b.Content.RawJSON()))
+		}
 	}
 }
 
@@ -337,8 +713,10 @@ func (h *Harness) extractToolCalls(msg *anthropic.Message) []ToolCall {
 // Returns tool result blocks and an error if context was cancelled.
 func (h *Harness) executeTools(ctx context.Context, calls []ToolCall) ([]anthropic.ContentBlockParamUnion, error) {
 	const slowToolThreshold = 5 * time.Second
+	logger := log.ForContext(h.logger, ctx)
 
 	var results []anthropic.ContentBlockParamUnion
+	failSafeTripped := false
 	for _, call := range calls {
 		// Check context before each tool execution
 		select {
@@ -347,38 +725,61 @@ func (h *Harness) executeTools(ctx context.Context, calls []ToolCall) ([]anthrop
 		default:
 		}
 
-		h.logger.Info("tool", "Execution started",
+		logger.Info("tool", "Execution started",
 			log.F("tool", call.Name),
 			log.F("id", call.ID),
 		)
-		if h.logger.IsDebugEnabled() {
-			h.logger.Debug("tool", "Tool input",
+		if logger.IsDebugEnabled() {
+			logger.Debug("tool", "Tool input",
 				log.F("tool", call.Name),
 				log.F("id", call.ID),
 				log.F("input", string(call.Input)),
 			)
 		}
 
+		// Collect images the tool attaches to its result
+		callCtx := ctx
+		var images []tool.Image
+		if h.config.ToolImages {
+			callCtx = tool.WithImageSink(ctx, func(img tool.Image) {
+				images = append(images, img)
+			})
+		}
+
 		toolStart := time.Now()
-		result, err := h.executeTool(ctx, call)
+		callCtx, span := startToolSpan(callCtx, call)
+		result, display, err := h.executeToolWithDisplay(callCtx, call)
 		toolDuration := time.Since(toolStart)
+		endSpan(span, err)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			h.recordInterrupted(call)
+		}
+		h.recordLatency(LatencyTool, toolDuration)
 
 		isError := err != nil
 		resultStr := result
 		if isError {
 			resultStr = err.Error()
 		}
+		if !errors.Is(err, ErrToolDenied) && h.recordToolOutcome(call.Name, isError || tool.IsErrorResult(resultStr)) {
+			failSafeTripped = true
+		}
+		if !isError {
+			resultStr = h.distillOutput(ctx, call, resultStr)
+		}
+		resultStr = h.limitResult(ctx, call, resultStr)
+		h.afterToolCall(ctx, call, resultStr, isError)
 
 		// Log tool completion
 		if isError {
-			h.logger.Error("tool", "Execution failed",
+			logger.Error("tool", "Execution failed",
 				log.F("tool", call.Name),
 				log.F("id", call.ID),
 				log.F("error", resultStr),
 				log.F("duration_ms", toolDuration.Milliseconds()),
 			)
 		} else {
-			h.logger.Info("tool", "Execution completed",
+			logger.Info("tool", "Execution completed",
 				log.F("tool", call.Name),
 				log.F("id", call.ID),
 				log.F("duration_ms", toolDuration.Milliseconds()),
@@ -388,36 +789,107 @@ func (h *Harness) executeTools(ctx context.Context, calls []ToolCall) ([]anthrop
 
 		// Warn on slow execution
 		if toolDuration > slowToolThreshold {
-			h.logger.Warn("tool", "Slow execution",
+			logger.Warn("tool", "Slow execution",
 				log.F("tool", call.Name),
 				log.F("id", call.ID),
 				log.F("duration_ms", toolDuration.Milliseconds()),
 			)
 		}
 
-		// Emit tool result event
+		// Emit display hint (clients only) and tool result events
+		if display != nil && !isError {
+			h.emitToolDisplay(ctx, call, display)
+		}
 		if h.handler != nil {
 			h.handler.OnToolResult(call.ID, resultStr, isError)
 		}
 
-		// Create tool result block
-		results = append(results, anthropic.NewToolResultBlock(call.ID, resultStr, isError))
+		// Create tool result block, with any attached images
+		block := anthropic.NewToolResultBlock(call.ID, resultStr, isError)
+		if !isError {
+			for _, img := range images {
+				block.OfToolResult.Content = append(block.OfToolResult.Content, anthropic.ToolResultBlockParamContentUnion{
+					OfImage: &anthropic.ImageBlockParam{
+						Source: anthropic.ImageBlockParamSourceUnion{
+							OfBase64: &anthropic.Base64ImageSourceParam{
+								Data:      base64.StdEncoding.EncodeToString(img.Data),
+								MediaType: anthropic.Base64ImageSourceMediaType(img.MediaType),
+							},
+						},
+					},
+				})
+			}
+		}
+		results = append(results, block)
 
 		// Fail-fast: stop on first error
 		if isError {
 			break
 		}
 	}
+
+	// Tell the model that mutating tools are now disabled.
+	// Text must follow all tool_result blocks in the message.
+	if failSafeTripped {
+		results = append(results, h.activateFailSafe())
+	}
 	return results, nil
 }
 
 // executeTool executes a single tool and returns its result.
 func (h *Harness) executeTool(ctx context.Context, call ToolCall) (string, error) {
+	result, _, err := h.executeToolWithDisplay(ctx, call)
+	return result, err
+}
+
+// executeToolWithDisplay executes a single tool and returns its result and optional display hint.
+func (h *Harness) executeToolWithDisplay(ctx context.Context, call ToolCall) (string, *tool.Display, error) {
 	t, ok := h.tools[call.Name]
 	if !ok {
-		return "", errors.New("unknown tool: " + call.Name)
+		return "", nil, errors.New("unknown tool: " + call.Name)
+	}
+	if !h.toolAllowed(call.Name) {
+		return "", nil, errors.New("tool not allowed for this prompt: " + call.Name)
+	}
+	if h.failSafeActive() && h.isMutating(call.Name) {
+		return "", nil, errors.New("tool disabled by fail-safe mode: " + call.Name)
+	}
+	if err := h.checkPermission(ctx, call); err != nil {
+		return "", nil, err
+	}
+	call, err := h.beforeToolCall(ctx, call)
+	if err != nil {
+		return "", nil, err
 	}
-	return t.Execute(ctx, call.Input)
+	ctx = tool.WithEmitter(ctx, h.toolEmitter(ctx, call))
+	if root := h.Workspace(); root != "" {
+		ctx = tool.WithWorkspace(ctx, root)
+	}
+	if h.config.IgnorePatterns != nil {
+		ctx = tool.WithIgnorePatterns(ctx, h.config.IgnorePatterns)
+	}
+	if h.config.GitReadOnly {
+		ctx = tool.WithGitReadOnly(ctx, true)
+	}
+	if h.config.MaxLineLength > 0 {
+		ctx = tool.WithMaxLineLength(ctx, h.config.MaxLineLength)
+	}
+	ctx = tool.WithTodoList(ctx, &h.plan)
+	// A sub-agent's changes belong to the turn of its parent's task call
+	owner := h
+	if parent, ok := ctx.Value(sessionKey{}).(*Harness); ok {
+		owner = parent
+	}
+	owner.snapshotFiles(ctx, t, call.Input)
+	ctx = context.WithValue(ctx, sessionKey{}, h)
+	ctx = context.WithValue(ctx, callIDKey{}, call.ID)
+	return h.runWithTimeout(ctx, call, h.toolTimeout(t), func(ctx context.Context) (string, *tool.Display, error) {
+		if dt, ok := t.(tool.DisplayTool); ok {
+			return dt.ExecuteWithDisplay(ctx, call.Input)
+		}
+		result, err := t.Execute(ctx, call.Input)
+		return result, nil, err
+	})
 }
 
 // Messages returns a copy of the current conversation history.
@@ -431,6 +903,78 @@ func (h *Harness) Messages() []anthropic.MessageParam {
 	return msgs
 }
 
+// Reset clears the conversation history so the next prompt starts a fresh
+// conversation. With a Store, the empty history is saved under the current
+// conversation ID. The task plan and checkpoints are cleared; token usage
+// totals are kept.
+// Returns ErrPromptInProgress while a prompt is running.
+func (h *Harness) Reset() error {
+	h.mu.Lock()
+	if h.running {
+		h.mu.Unlock()
+		return ErrPromptInProgress
+	}
+	cleared := len(h.messages)
+	h.setMessages([]anthropic.MessageParam{}, time.Time{})
+	h.clearCheckpoints()
+	h.contextTokens = 0
+	h.mu.Unlock()
+	h.saveConversation()
+	h.clearPlan()
+	h.removeKeptTempDir(context.Background())
+
+	h.logger.Info("harness", "Conversation reset", log.F("cleared_messages", cleared))
+	return nil
+}
+
+// TimedMessage is a conversation message with the time it was added.
+type TimedMessage struct {
+	Message anthropic.MessageParam
+	// Time is zero for messages whose time is unknown, such as those
+	// loaded from a Store.
+	Time time.Time
+}
+
+// TimedMessages returns a copy of the conversation history with the time
+// each message was added.
+func (h *Harness) TimedMessages() []TimedMessage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msgs := make([]TimedMessage, len(h.messages))
+	for i, msg := range h.messages {
+		msgs[i] = TimedMessage{Message: msg, Time: h.messageTimes[i]}
+	}
+	return msgs
+}
+
+// appendMessage adds a message to the conversation history.
+func (h *Harness) appendMessage(msg anthropic.MessageParam) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, msg)
+	h.messageTimes = append(h.messageTimes, time.Now())
+}
+
+// setMessages replaces the conversation history; added is when the new
+// messages were added, or zero if unknown. Callers hold h.mu.
+func (h *Harness) setMessages(messages []anthropic.MessageParam, added time.Time) {
+	h.messages = messages
+	h.messageTimes = make([]time.Time, len(messages))
+	for i := range h.messageTimes {
+		h.messageTimes[i] = added
+	}
+}
+
+// Tools returns the local tools available to the model, sorted by name.
+func (h *Harness) Tools() []tool.Tool {
+	tools := make([]tool.Tool, 0, len(h.tools))
+	for _, t := range h.tools {
+		tools = append(tools, t)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name() < tools[j].Name() })
+	return tools
+}
+
 // SetEventHandler sets or replaces the event handler.
 // This is useful for integration testing where the handler needs to be set after construction.
 func (h *Harness) SetEventHandler(handler EventHandler) {
@@ -449,3 +993,21 @@ func (h *Harness) SetLogger(logger log.Logger) {
 	}
 	h.logger = logger
 }
+
+// SetWorkspace jails tool paths and commands to root.
+// An empty root restores Config.Workspace, which is unrestricted by default.
+func (h *Harness) SetWorkspace(root string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if root == "" {
+		root = h.config.Workspace
+	}
+	h.workspace = root
+}
+
+// Workspace returns the directory tool paths are jailed to, or "" if unrestricted.
+func (h *Harness) Workspace() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.workspace
+}
@@ -3,10 +3,12 @@ package harness
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/user/harness/pkg/tool"
 )
 
@@ -147,6 +149,28 @@ func TestHarness_ConcurrencyControl(t *testing.T) {
 	cancel()
 }
 
+func TestHarness_ResetWhileRunning(t *testing.T) {
+	h, _ := NewHarness(Config{APIKey: "test-key"}, nil, nil)
+	h.appendMessage(anthropic.NewUserMessage(anthropic.NewTextBlock("hi")))
+
+	h.mu.Lock()
+	h.running = true
+	h.mu.Unlock()
+	if err := h.Reset(); err != ErrPromptInProgress {
+		t.Errorf("expected ErrPromptInProgress, got %v", err)
+	}
+	if len(h.Messages()) != 1 {
+		t.Error("history must not be cleared while a prompt is running")
+	}
+
+	h.mu.Lock()
+	h.running = false
+	h.mu.Unlock()
+	if err := h.Reset(); err != nil || len(h.Messages()) != 0 {
+		t.Errorf("expected history cleared, got %d messages, %v", len(h.Messages()), err)
+	}
+}
+
 func TestHarness_Messages(t *testing.T) {
 	h, _ := NewHarness(Config{APIKey: "test-key"}, nil, nil)
 
@@ -314,6 +338,40 @@ func TestHarness_ExecuteTools_ContextCancellation(t *testing.T) {
 	}
 }
 
+func TestHarness_ExecuteTools_Images(t *testing.T) {
+	tools := []tool.Tool{
+		&MockTool{
+			name: "screenshot",
+			executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+				attached := tool.AttachImage(ctx, tool.Image{MediaType: "image/png", Data: []byte("png")})
+				return fmt.Sprintf(`{"attached":%v}`, attached), nil
+			},
+		},
+	}
+	calls := []ToolCall{{ID: "id1", Name: "screenshot", Input: json.RawMessage(`{}`)}}
+
+	for _, enabled := range []bool{false, true} {
+		h, _ := NewHarness(Config{APIKey: "test-key", ToolImages: enabled}, tools, nil)
+		results, err := h.executeTools(context.Background(), calls)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		content := results[0].OfToolResult.Content
+		if !enabled {
+			if len(content) != 1 || content[0].OfText.Text != `{"attached":false}` {
+				t.Errorf("expected a text-only result with images disabled, got %d blocks", len(content))
+			}
+			continue
+		}
+		if len(content) != 2 || content[0].OfText.Text != `{"attached":true}` || content[1].OfImage == nil {
+			t.Fatalf("expected text and image blocks, got %d blocks", len(content))
+		}
+		if src := content[1].OfImage.Source.OfBase64; src.MediaType != "image/png" || src.Data != "cG5n" {
+			t.Errorf("unexpected image source: %s %s", src.MediaType, src.Data)
+		}
+	}
+}
+
 // mockError is a simple error type for testing.
 type mockError struct {
 	msg string
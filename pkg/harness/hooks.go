@@ -0,0 +1,102 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Hooks are functions the harness calls at points of the agent loop, so a
+// host application can enforce its own policies or record analytics. Any of
+// them may be nil. Hooks run on the goroutine of the prompt, so slow hooks
+// slow the run.
+type Hooks struct {
+	// BeforeToolCall runs before each tool call, after the permission check,
+	// and returns the call to execute: only its Input may be changed. An
+	// error blocks the call like a denied permission: the model receives an
+	// error result wrapping ErrToolDenied and the remaining calls of the
+	// response are skipped.
+	BeforeToolCall func(ctx context.Context, call ToolCall) (ToolCall, error)
+
+	// AfterToolCall runs after each tool call with the call as the model
+	// made it and the result the model receives.
+	AfterToolCall func(ctx context.Context, call ToolCall, result string, isError bool)
+
+	// BeforeTurn runs before each turn's API request, with the turn number
+	// from 1. An error stops the run and is returned by Prompt.
+	BeforeTurn func(ctx context.Context, turn int) error
+
+	// AfterTurn runs after each turn's response and its tool calls, unless
+	// the turn failed.
+	AfterTurn func(ctx context.Context, turn int, message *anthropic.Message)
+
+	// OnComplete runs when a run ends, however it ended, with its result and
+	// the error Prompt returns.
+	OnComplete func(ctx context.Context, result RunResult, err error)
+}
+
+// SetHooks replaces the harness's hooks. Sessions created afterwards,
+// including sub-agents, inherit them.
+func (h *Harness) SetHooks(hooks Hooks) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = hooks
+}
+
+// currentHooks returns the harness's hooks.
+func (h *Harness) currentHooks() Hooks {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.hooks
+}
+
+// beforeToolCall runs the BeforeToolCall hook, returning the call to execute.
+func (h *Harness) beforeToolCall(ctx context.Context, call ToolCall) (ToolCall, error) {
+	hook := h.currentHooks().BeforeToolCall
+	if hook == nil {
+		return call, nil
+	}
+	changed, err := hook(ctx, call)
+	if err != nil {
+		if ctx.Err() != nil {
+			return call, ctx.Err()
+		}
+		return call, fmt.Errorf("%w: %s: %v", ErrToolDenied, call.Name, err)
+	}
+	call.Input = changed.Input
+	return call, nil
+}
+
+// afterToolCall runs the AfterToolCall hook.
+func (h *Harness) afterToolCall(ctx context.Context, call ToolCall, result string, isError bool) {
+	if hook := h.currentHooks().AfterToolCall; hook != nil {
+		hook(ctx, call, result, isError)
+	}
+}
+
+// beforeTurn runs the BeforeTurn hook for turn, from 0.
+func (h *Harness) beforeTurn(ctx context.Context, turn int) error {
+	hook := h.currentHooks().BeforeTurn
+	if hook == nil {
+		return nil
+	}
+	if err := hook(ctx, turn+1); err != nil {
+		return fmt.Errorf("before turn %d hook: %w", turn+1, err)
+	}
+	return nil
+}
+
+// afterTurn runs the AfterTurn hook for turn, from 0.
+func (h *Harness) afterTurn(ctx context.Context, turn int, message *anthropic.Message) {
+	if hook := h.currentHooks().AfterTurn; hook != nil {
+		hook(ctx, turn+1, message)
+	}
+}
+
+// runComplete runs the OnComplete hook.
+func (h *Harness) runComplete(ctx context.Context, result RunResult, err error) {
+	if hook := h.currentHooks().OnComplete; hook != nil {
+		hook(ctx, result, err)
+	}
+}
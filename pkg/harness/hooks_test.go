@@ -0,0 +1,106 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestHooks_ToolCalls(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "write", map[string]string{"value": "draft.txt"}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_2", "write", map[string]string{"value": "/etc/passwd"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done."))
+
+	var inputs []string
+	write := &MockTool{name: "write", executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+		inputs = append(inputs, string(input))
+		return `{"ok":true}`, nil
+	}}
+	handler := &toolEventRecorder{}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{write}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	var after []string
+	h.SetHooks(harness.Hooks{
+		// Block absolute paths, and keep drafts in a directory
+		BeforeToolCall: func(ctx context.Context, call harness.ToolCall) (harness.ToolCall, error) {
+			if strings.Contains(string(call.Input), `"/`) {
+				return call, errors.New("absolute paths are not allowed")
+			}
+			call.Input = json.RawMessage(strings.Replace(string(call.Input), "draft", "drafts/draft", 1))
+			return call, nil
+		},
+		AfterToolCall: func(ctx context.Context, call harness.ToolCall, result string, isError bool) {
+			after = append(after, fmt.Sprintf("%s:%t", call.ID, isError))
+		},
+	})
+	if err := h.Prompt(context.Background(), "write files"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(inputs) != 1 || !strings.Contains(inputs[0], "drafts/draft.txt") {
+		t.Errorf("expected only the changed call to run, got %v", inputs)
+	}
+	if strings.Join(after, ",") != "call_1:false,call_2:true" {
+		t.Errorf("unexpected AfterToolCall calls %v", after)
+	}
+	if len(handler.ToolResults) != 2 || !strings.Contains(handler.ToolResults[1].Result, "absolute paths are not allowed") {
+		t.Errorf("expected the blocked call's error as its result, got %+v", handler.ToolResults)
+	}
+}
+
+func TestHooks_Turns(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "mock_tool", map[string]string{}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_2", "mock_tool", map[string]string{}))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{&MockTool{name: "mock_tool"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	var calls []string
+	var completed harness.RunResult
+	var completedErr error
+	errStop := errors.New("analytics quota reached")
+	h.SetHooks(harness.Hooks{
+		BeforeTurn: func(ctx context.Context, turn int) error {
+			calls = append(calls, fmt.Sprintf("before %d", turn))
+			if turn == 2 {
+				return errStop
+			}
+			return nil
+		},
+		AfterTurn: func(ctx context.Context, turn int, message *anthropic.Message) {
+			calls = append(calls, fmt.Sprintf("after %d %s", turn, message.StopReason))
+		},
+		OnComplete: func(ctx context.Context, result harness.RunResult, err error) {
+			completed, completedErr = result, err
+		},
+	})
+
+	err = h.Prompt(context.Background(), "go")
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected the BeforeTurn error, got %v", err)
+	}
+	if strings.Join(calls, ",") != "before 1,after 1 tool_use,before 2" {
+		t.Errorf("unexpected hook calls %v", calls)
+	}
+	if completed.Turns != 1 || !errors.Is(completedErr, errStop) {
+		t.Errorf("unexpected OnComplete call %+v, %v", completed, completedErr)
+	}
+	if len(mockStreamer.RecordedParams) != 1 {
+		t.Errorf("expected 1 API request, got %d", len(mockStreamer.RecordedParams))
+	}
+}
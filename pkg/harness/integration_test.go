@@ -289,8 +289,8 @@ func TestIntegration_MaxTurnsEnforced(t *testing.T) {
 
 	// Run prompt
 	err = h.Prompt(context.Background(), "Keep going")
-	if err != nil {
-		t.Fatalf("prompt failed: %v", err)
+	if !errors.Is(err, harness.ErrMaxTurnsExceeded) {
+		t.Fatalf("expected ErrMaxTurnsExceeded, got %v", err)
 	}
 
 	// Verify exactly 2 API calls were made (MaxTurns = 2)
@@ -460,3 +460,70 @@ func TestIntegration_ConversationHistory(t *testing.T) {
 		t.Errorf("expected 4 messages after second prompt, got %d", len(msgs))
 	}
 }
+
+// serverToolRecorder extends MockEventHandler with ServerToolHandler support.
+type serverToolRecorder struct {
+	MockEventHandler
+	Uses    []struct{ ID, Name string }
+	Results []struct{ ToolUseID, BlockType, Content string }
+}
+
+func (h *serverToolRecorder) OnServerToolUse(id string, name string, input json.RawMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Uses = append(h.Uses, struct{ ID, Name string }{id, name})
+}
+
+func (h *serverToolRecorder) OnServerToolResult(toolUseID string, blockType string, content json.RawMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Results = append(h.Results, struct{ ToolUseID, BlockType, Content string }{toolUseID, blockType, string(content)})
+}
+
+// TestIntegration_ServerToolEvents tests that provider-executed tool blocks are
+// surfaced through ServerToolHandler and are not executed locally.
+func TestIntegration_ServerToolEvents(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddServerToolUse("srvtoolu_1", "web_search", map[string]string{"query": "golang release"}).
+		AddWebSearchResult("srvtoolu_1", `[{"type":"web_search_result","url":"https://go.dev","title":"Go","encrypted_content":"x"}]`).
+		AddText("Go 1.23 is out.").
+		Build())
+
+	handler := &serverToolRecorder{}
+	h, err := harness.NewHarnessWithStreamer(
+		harness.Config{Model: "test-model", EnableWebSearch: true, WebSearchMaxUses: 3},
+		nil,
+		handler,
+		mockStreamer,
+	)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	if err := h.Prompt(context.Background(), "What's new in Go?"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(handler.Uses) != 1 || handler.Uses[0].ID != "srvtoolu_1" || handler.Uses[0].Name != "web_search" {
+		t.Errorf("unexpected server tool uses: %+v", handler.Uses)
+	}
+	if len(handler.Results) != 1 {
+		t.Fatalf("expected 1 server tool result, got %d", len(handler.Results))
+	}
+	if handler.Results[0].BlockType != "web_search_tool_result" {
+		t.Errorf("expected block type web_search_tool_result, got %q", handler.Results[0].BlockType)
+	}
+	if len(handler.ToolResults) != 0 {
+		t.Errorf("server tools should not produce local tool results, got %d", len(handler.ToolResults))
+	}
+
+	// Web search tool should be offered to the model
+	params := mockStreamer.RecordedParams[0]
+	if len(params.Tools) != 1 || params.Tools[0].OfWebSearchTool20250305 == nil {
+		t.Fatalf("expected web search tool param, got %+v", params.Tools)
+	}
+	if params.Tools[0].OfWebSearchTool20250305.MaxUses.Value != 3 {
+		t.Errorf("expected max_uses 3, got %d", params.Tools[0].OfWebSearchTool20250305.MaxUses.Value)
+	}
+}
@@ -0,0 +1,138 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// PromptOptions overrides the configuration for a single prompt. Unset
+// fields keep the configured values.
+type PromptOptions struct {
+	// Profile applies the named Config.Profiles entry instead of the
+	// session's (see SetProfile). The other options override its values.
+	Profile string `json:"profile,omitempty"`
+
+	// Model overrides Config.Model.
+	Model string `json:"model,omitempty"`
+
+	// MaxTurns overrides Config.MaxTurns.
+	MaxTurns int `json:"max_turns,omitempty"`
+
+	// System is added to the system prompt, after Config.SystemPrompt.
+	System string `json:"system,omitempty"`
+
+	// Tools restricts the local tools offered to the model to those named;
+	// an empty non-nil list offers none. Server tools are unaffected.
+	Tools []string `json:"tools,omitempty"`
+
+	// Temperature overrides Config.Temperature.
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// TopP overrides Config.TopP.
+	TopP *float64 `json:"top_p,omitempty"`
+
+	// StopSequences overrides Config.StopSequences; an empty non-nil list
+	// removes them.
+	StopSequences []string `json:"stop_sequences,omitempty"`
+}
+
+// Validate checks the options and returns an error if invalid. Tool names
+// are checked by Harness.ValidateOptions.
+func (o PromptOptions) Validate() error {
+	if o.MaxTurns < 0 {
+		return errors.New("max_turns must not be negative")
+	}
+	return validateSampling(o.Temperature, o.TopP)
+}
+
+// ValidateOptions checks opts for a prompt on h, including that the tools
+// it names exist.
+func (h *Harness) ValidateOptions(opts PromptOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if _, ok := h.config.Profiles[opts.Profile]; opts.Profile != "" && !ok {
+		return fmt.Errorf("unknown profile %q", opts.Profile)
+	}
+	for _, name := range opts.Tools {
+		if _, ok := h.tools[name]; !ok {
+			return fmt.Errorf("unknown tool %q", name)
+		}
+	}
+	return nil
+}
+
+// validateSampling checks temperature and topP, either of which may be nil.
+func validateSampling(temperature, topP *float64) error {
+	if temperature != nil && (*temperature < 0 || *temperature > 1) {
+		return errors.New("temperature must be between 0 and 1")
+	}
+	if topP != nil && (*topP < 0 || *topP > 1) {
+		return errors.New("top_p must be between 0 and 1")
+	}
+	return nil
+}
+
+// applySampling sets the sampling parameters of the running prompt on
+// params: the prompt's overrides, or else the configured values.
+func (h *Harness) applySampling(params *anthropic.MessageNewParams) {
+	temperature, topP, stop := h.config.Temperature, h.config.TopP, h.config.StopSequences
+	if h.options.Temperature != nil {
+		temperature = h.options.Temperature
+	}
+	if h.options.TopP != nil {
+		topP = h.options.TopP
+	}
+	if h.options.StopSequences != nil {
+		stop = h.options.StopSequences
+	}
+
+	if temperature != nil {
+		params.Temperature = anthropic.Float(*temperature)
+	}
+	if topP != nil {
+		params.TopP = anthropic.Float(*topP)
+	}
+	params.StopSequences = stop
+}
+
+// model returns the model of the running prompt.
+func (h *Harness) model() string {
+	if h.fallback > 0 {
+		return h.config.FallbackModels[h.fallback-1]
+	}
+	if h.options.Model != "" {
+		return h.options.Model
+	}
+	return h.config.Model
+}
+
+// maxTurns returns the turn limit of the running prompt.
+func (h *Harness) maxTurns() int {
+	if h.options.MaxTurns > 0 {
+		return h.options.MaxTurns
+	}
+	return h.config.MaxTurns
+}
+
+// systemBlocks returns the system prompt of the running prompt: the
+// profile's or configured one followed by the prompt's addition, if any.
+func (h *Harness) systemBlocks() []anthropic.TextBlockParam {
+	var blocks []anthropic.TextBlockParam
+	if system := h.systemPrompt(); system != "" {
+		blocks = append(blocks, anthropic.TextBlockParam{Text: system})
+	}
+	if h.options.System != "" {
+		blocks = append(blocks, anthropic.TextBlockParam{Text: h.options.System})
+	}
+	return blocks
+}
+
+// toolAllowed reports whether the running prompt may use the named local
+// tool.
+func (h *Harness) toolAllowed(name string) bool {
+	return h.options.Tools == nil || slices.Contains(h.options.Tools, name)
+}
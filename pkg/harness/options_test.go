@@ -0,0 +1,156 @@
+package harness_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestPromptSampling(t *testing.T) {
+	temperature, topP, override := 0.2, 0.9, 0.7
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("one"))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("two"))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("three"))
+	config := harness.Config{Model: "test-model", Temperature: &temperature, TopP: &topP, StopSequences: []string{"END"}}
+	h, err := harness.NewHarnessWithStreamer(config, nil, &MockEventHandler{}, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := h.Prompt(ctx, "configured"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if _, err := h.PromptWithOptions(ctx, "overridden", harness.PromptOptions{Temperature: &override, StopSequences: []string{}}); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	// Overrides last for one prompt only
+	if err := h.Prompt(ctx, "configured again"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	tests := []struct {
+		temperature float64
+		stop        []string
+	}{
+		{0.2, []string{"END"}},
+		{0.7, []string{}},
+		{0.2, []string{"END"}},
+	}
+	for i, tt := range tests {
+		params := mockStreamer.RecordedParams[i]
+		if params.Temperature.Value != tt.temperature || params.TopP.Value != 0.9 {
+			t.Errorf("request %d: expected temperature %v and top_p 0.9, got %v and %v", i+1, tt.temperature, params.Temperature.Value, params.TopP.Value)
+		}
+		if !slices.Equal(params.StopSequences, tt.stop) {
+			t.Errorf("request %d: expected stop sequences %q, got %q", i+1, tt.stop, params.StopSequences)
+		}
+	}
+}
+
+func TestPromptSampling_Defaults(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, &MockEventHandler{}, mockStreamer)
+	if err := h.Prompt(context.Background(), "hi"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	params := mockStreamer.RecordedParams[0]
+	if params.Temperature.Valid() || params.TopP.Valid() || params.StopSequences != nil {
+		t.Errorf("expected API defaults, got temperature %v, top_p %v, stop %q", params.Temperature, params.TopP, params.StopSequences)
+	}
+}
+
+func TestPromptSampling_Invalid(t *testing.T) {
+	high, negative := 1.5, -0.1
+
+	config := harness.Config{APIKey: "test-key", Temperature: &high}
+	err := config.Validate()
+	if err == nil || err.Error() != "temperature must be between 0 and 1" {
+		t.Errorf("expected a temperature error, got %v", err)
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, &MockEventHandler{}, mockStreamer)
+	_, err = h.PromptWithOptions(context.Background(), "hi", harness.PromptOptions{TopP: &negative})
+	if err == nil || err.Error() != "top_p must be between 0 and 1" {
+		t.Errorf("expected a top_p error, got %v", err)
+	}
+	if len(mockStreamer.RecordedParams) != 0 || len(h.Messages()) != 0 {
+		t.Error("expected an invalid prompt not to start")
+	}
+}
+
+func TestPromptWithOptions(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "write", map[string]string{}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_2", "read", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+	handler := &MockEventHandler{}
+	tools := []tool.Tool{&MockTool{name: "read"}, &MockTool{name: "write"}}
+	config := harness.Config{Model: "test-model", SystemPrompt: "be brief", MaxTurns: 10}
+	h, _ := harness.NewHarnessWithStreamer(config, tools, handler, mockStreamer)
+
+	opts := harness.PromptOptions{Model: "other-model", MaxTurns: 2, System: "answer in French", Tools: []string{"read"}}
+	result, err := h.PromptWithOptions(context.Background(), "go", opts)
+	if !errors.Is(err, harness.ErrMaxTurnsExceeded) {
+		t.Fatalf("expected ErrMaxTurnsExceeded, got %v", err)
+	}
+
+	if len(mockStreamer.RecordedParams) != 2 || result.StopReason != harness.StopReasonMaxTurns || result.Turns != 2 {
+		t.Fatalf("expected the run to stop after 2 turns, got %d requests", len(mockStreamer.RecordedParams))
+	}
+	params := mockStreamer.RecordedParams[0]
+	if params.Model != "other-model" {
+		t.Errorf("expected model other-model, got %s", params.Model)
+	}
+	if len(params.System) != 2 || params.System[0].Text != "be brief" || params.System[1].Text != "answer in French" {
+		t.Errorf("expected the system prompt and its addition, got %+v", params.System)
+	}
+	if len(params.Tools) != 1 || params.Tools[0].OfTool.Name != "read" {
+		t.Errorf("expected only the read tool, got %d tools", len(params.Tools))
+	}
+	if r := handler.ToolResults[0]; !r.IsError || !strings.Contains(r.Result, "tool not allowed for this prompt: write") {
+		t.Errorf("expected the write call to be refused, got %+v", r)
+	}
+
+	// The next prompt uses the configuration again
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+	if err := h.Prompt(context.Background(), "again"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	params = mockStreamer.RecordedParams[2]
+	if params.Model != "test-model" || len(params.System) != 1 || len(params.Tools) != 2 {
+		t.Errorf("expected the configured model, system prompt, and tools, got %s, %d, %d", params.Model, len(params.System), len(params.Tools))
+	}
+}
+
+func TestPromptWithOptions_Invalid(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{&MockTool{name: "read"}}, &MockEventHandler{}, mockStreamer)
+
+	tests := []struct {
+		opts harness.PromptOptions
+		err  string
+	}{
+		{harness.PromptOptions{Tools: []string{"read", "deploy"}}, `unknown tool "deploy"`},
+		{harness.PromptOptions{MaxTurns: -1}, "max_turns must not be negative"},
+	}
+	for _, tt := range tests {
+		if _, err := h.PromptWithOptions(context.Background(), "hi", tt.opts); err == nil || err.Error() != tt.err {
+			t.Errorf("expected error %q, got %v", tt.err, err)
+		}
+	}
+	if len(mockStreamer.RecordedParams) != 0 {
+		t.Error("expected invalid prompts not to start")
+	}
+}
@@ -0,0 +1,255 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// Output summarization defaults.
+const (
+	DefaultOutputMinBytes = 2000
+	DefaultOutputMaxLines = 50
+
+	// outputSummaryMaxTokens bounds the model summary of a tool's output.
+	outputSummaryMaxTokens = 512
+	// outputSummaryMaxInput is how much output (from the end) the summary model sees.
+	outputSummaryMaxInput = 50000
+)
+
+// DefaultOutputPatterns select error, warning, and test failure lines.
+var DefaultOutputPatterns = []string{
+	`(?i)\b(error|errors|fail|failed|failure|panic|fatal|exception)\b`,
+	`(?i)\bwarn(ing)?\b`,
+	`^--- FAIL`,
+	`^\s*\S+\.\w+:\d+(:\d+)?:`,
+}
+
+// outputSummaryInstruction asks the summary model to distill tool output.
+const outputSummaryInstruction = "Summarize this command output for a coding agent. " +
+	"List each failure or error with its file:line location and message; " +
+	"omit passing results and noise. Be brief.\n\n"
+
+// OutputSummary configures distillation of a tool's output before it is
+// added to the conversation. The raw output is saved as an artifact and the
+// model receives only the extracted lines and an optional summary.
+type OutputSummary struct {
+	// MinBytes is the result size at which output is distilled. Default: 2000
+	MinBytes int
+
+	// Patterns are regular expressions selecting lines to keep.
+	// Default: DefaultOutputPatterns
+	Patterns []string
+
+	// MaxLines caps the extracted lines. Default: 50
+	MaxLines int
+
+	// Model, if set, also asks this (typically cheaper) model for a summary.
+	Model string
+}
+
+// distilledOutput is the tool result the model sees in place of raw output.
+type distilledOutput struct {
+	Summary      string         `json:"summary,omitempty"`
+	Matches      []string       `json:"matches"`
+	OmittedLines int            `json:"omitted_lines"`
+	TotalLines   int            `json:"total_lines"`
+	Artifact     string         `json:"artifact,omitempty"`
+	Fields       map[string]any `json:"fields,omitempty"`
+}
+
+// validateOutputSummaries checks that all configured patterns compile.
+func validateOutputSummaries(summaries map[string]OutputSummary) error {
+	_, err := compileOutputSummaries(summaries)
+	return err
+}
+
+// compileOutputSummaries compiles each tool's patterns, or
+// DefaultOutputPatterns for tools that set none.
+func compileOutputSummaries(summaries map[string]OutputSummary) (map[string][]*regexp.Regexp, error) {
+	compiled := make(map[string][]*regexp.Regexp, len(summaries))
+	for name, cfg := range summaries {
+		patterns := cfg.Patterns
+		if len(patterns) == 0 {
+			patterns = DefaultOutputPatterns
+		}
+		res := make([]*regexp.Regexp, 0, len(patterns))
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("output summary for %s: invalid pattern %q: %w", name, p, err)
+			}
+			res = append(res, re)
+		}
+		compiled[name] = res
+	}
+	return compiled, nil
+}
+
+// distillOutput replaces a large tool result with its error and warning lines
+// when summarization is configured for the tool. The raw result is written to
+// the run's artifact directory. On any failure the original result is returned.
+func (h *Harness) distillOutput(ctx context.Context, call ToolCall, result string) string {
+	cfg, ok := h.config.OutputSummaries[call.Name]
+	if !ok {
+		return result
+	}
+	minBytes := cfg.MinBytes
+	if minBytes == 0 {
+		minBytes = DefaultOutputMinBytes
+	}
+	if len(result) < minBytes {
+		return result
+	}
+
+	text, fields := outputText(result)
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	matches := matchLines(lines, h.outputPatterns[call.Name], cfg.MaxLines)
+
+	out := distilledOutput{
+		Matches:      matches,
+		OmittedLines: len(lines) - len(matches),
+		TotalLines:   len(lines),
+		Fields:       fields,
+	}
+
+	logger := log.ForContext(h.logger, ctx)
+	if path, err := h.saveArtifact(ctx, call.ID, result); err != nil {
+		logger.Warn("tool", "Failed to save output artifact",
+			log.F("tool", call.Name),
+			log.F("id", call.ID),
+			log.F("error", err.Error()),
+		)
+	} else {
+		out.Artifact = path
+	}
+
+	if cfg.Model != "" {
+		summary, err := h.summarizeOutput(ctx, cfg.Model, text)
+		if err != nil {
+			logger.Warn("tool", "Output summary failed",
+				log.F("tool", call.Name),
+				log.F("id", call.ID),
+				log.F("error", err.Error()),
+			)
+		}
+		out.Summary = summary
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return result
+	}
+	logger.Info("tool", "Output distilled",
+		log.F("tool", call.Name),
+		log.F("id", call.ID),
+		log.F("original_bytes", len(result)),
+		log.F("distilled_bytes", len(data)),
+	)
+	return string(data)
+}
+
+// outputText extracts the text to scan from a tool result. For JSON objects
+// the string fields are joined in key order and the remaining fields (e.g. an
+// exit code) are returned separately; other results are scanned as-is.
+func outputText(result string) (string, map[string]any) {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(result), &obj); err != nil {
+		return result, nil
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	fields := make(map[string]any)
+	for _, k := range keys {
+		if s, ok := obj[k].(string); ok {
+			if s != "" {
+				parts = append(parts, s)
+			}
+		} else {
+			fields[k] = obj[k]
+		}
+	}
+	if len(fields) == 0 {
+		fields = nil
+	}
+	return strings.Join(parts, "\n"), fields
+}
+
+// matchLines returns the lines matching any pattern, up to maxLines
+// (default DefaultOutputMaxLines).
+func matchLines(lines []string, patterns []*regexp.Regexp, maxLines int) []string {
+	if maxLines == 0 {
+		maxLines = DefaultOutputMaxLines
+	}
+
+	matches := []string{}
+	for _, line := range lines {
+		if len(matches) >= maxLines {
+			break
+		}
+		for _, re := range patterns {
+			if re.MatchString(line) {
+				matches = append(matches, line)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// runArtifactDir returns where the run with temporary directory tempDir
+// saves artifacts: a subdirectory of ArtifactDir named after the run, or
+// the run's temporary directory when ArtifactDir is unset. Either is
+// removed with the run's temporary directory.
+func (h *Harness) runArtifactDir(tempDir string) string {
+	if h.config.ArtifactDir == "" {
+		return filepath.Join(tempDir, "artifacts")
+	}
+	return filepath.Join(h.config.ArtifactDir, filepath.Base(tempDir))
+}
+
+// saveArtifact writes raw tool output to the run's artifact directory.
+func (h *Harness) saveArtifact(ctx context.Context, id string, content string) (string, error) {
+	tempDir := tool.TempDir(ctx)
+	if tempDir == "" {
+		return "", errors.New("run has no temp dir")
+	}
+	dir := h.runArtifactDir(tempDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, filepath.Base(id)+".txt")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// summarizeOutput asks model for a brief summary of command output.
+func (h *Harness) summarizeOutput(ctx context.Context, model string, text string) (string, error) {
+	if len(text) > outputSummaryMaxInput {
+		text = text[len(text)-outputSummaryMaxInput:]
+	}
+	message, err := h.complete(ctx, model, "", outputSummaryMaxTokens, []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock(outputSummaryInstruction + text)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return messageText(message), nil
+}
@@ -0,0 +1,145 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// noisyBashTool returns build output with many passing lines and two failures.
+func noisyBashTool() *MockTool {
+	return &MockTool{
+		name: "bash",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			var b strings.Builder
+			for i := 0; i < 200; i++ {
+				b.WriteString("ok  \tpkg/module/passing\t0.01s\n")
+			}
+			b.WriteString("--- FAIL: TestParse (0.00s)\n")
+			b.WriteString("    parse_test.go:42: unexpected token\n")
+			data, _ := json.Marshal(map[string]any{"stdout": b.String(), "stderr": "", "exitCode": 1})
+			return string(data), nil
+		},
+	}
+}
+
+// artifactHandler records the content of each distilled result's artifact
+// while the run is still in progress.
+type artifactHandler struct {
+	MockEventHandler
+	artifacts []string
+}
+
+func (h *artifactHandler) OnToolResult(id string, result string, isError bool) {
+	h.MockEventHandler.OnToolResult(id, result, isError)
+	var out struct {
+		Artifact string `json:"artifact"`
+	}
+	if json.Unmarshal([]byte(result), &out) == nil && out.Artifact != "" {
+		raw, _ := os.ReadFile(out.Artifact)
+		h.artifacts = append(h.artifacts, string(raw))
+	}
+}
+
+func TestOutputSummary_DistillsLargeOutput(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("b1", "bash", map[string]string{"command": "go test ./..."}).Build())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("TestParse fails at parse_test.go:42").Build())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("fixing").Build())
+
+	handler := &artifactHandler{}
+	artifacts := t.TempDir()
+	config := harness.Config{
+		Model:           "test-model",
+		OutputSummaries: map[string]harness.OutputSummary{"bash": {Model: "cheap-model"}},
+		ArtifactDir:     artifacts,
+	}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{noisyBashTool()}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "run the tests"); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if len(handler.ToolResults) != 1 {
+		t.Fatalf("expected 1 tool result, got %d", len(handler.ToolResults))
+	}
+	var out struct {
+		Summary      string         `json:"summary"`
+		Matches      []string       `json:"matches"`
+		OmittedLines int            `json:"omitted_lines"`
+		Artifact     string         `json:"artifact"`
+		Fields       map[string]any `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(handler.ToolResults[0].Result), &out); err != nil {
+		t.Fatalf("distilled result is not JSON: %v", err)
+	}
+	if len(out.Matches) != 2 || !strings.HasPrefix(out.Matches[0], "--- FAIL") {
+		t.Errorf("unexpected matches: %v", out.Matches)
+	}
+	if out.OmittedLines != 200 {
+		t.Errorf("expected 200 omitted lines, got %d", out.OmittedLines)
+	}
+	if out.Fields["exitCode"] != float64(1) {
+		t.Errorf("expected exitCode to be preserved, got %v", out.Fields)
+	}
+	if out.Summary != "TestParse fails at parse_test.go:42" {
+		t.Errorf("unexpected summary: %q", out.Summary)
+	}
+
+	if filepath.Dir(filepath.Dir(out.Artifact)) != artifacts {
+		t.Errorf("expected artifact in a run subdirectory of %s, got %s", artifacts, out.Artifact)
+	}
+	if len(handler.artifacts) != 1 || !strings.Contains(handler.artifacts[0], "pkg/module/passing") {
+		t.Error("artifact should contain the raw output during the run")
+	}
+	if _, err := os.Stat(filepath.Dir(out.Artifact)); !os.IsNotExist(err) {
+		t.Errorf("expected the run's artifact directory to be removed, got %v", err)
+	}
+
+	if len(mockStreamer.RecordedParams) != 3 {
+		t.Fatalf("expected 3 API calls, got %d", len(mockStreamer.RecordedParams))
+	}
+	if mockStreamer.RecordedParams[1].Model != "cheap-model" {
+		t.Errorf("expected summary call to use cheap-model, got %q", mockStreamer.RecordedParams[1].Model)
+	}
+}
+
+func TestOutputSummary_SmallOutputUnchanged(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("b1", "bash", map[string]string{"command": "true"}).Build())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("done").Build())
+
+	handler := &MockEventHandler{}
+	small := &MockTool{name: "bash"}
+	config := harness.Config{
+		Model:           "test-model",
+		OutputSummaries: map[string]harness.OutputSummary{"bash": {}},
+		ArtifactDir:     t.TempDir(),
+	}
+	h, _ := harness.NewHarnessWithStreamer(config, []tool.Tool{small}, handler, mockStreamer)
+	if err := h.Prompt(context.Background(), "run"); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+	if handler.ToolResults[0].Result != `{"result":"mock result"}` {
+		t.Errorf("small output should pass through, got %s", handler.ToolResults[0].Result)
+	}
+}
+
+func TestConfig_ValidateOutputPatterns(t *testing.T) {
+	config := harness.Config{
+		APIKey:          "test-key",
+		OutputSummaries: map[string]harness.OutputSummary{"bash": {Patterns: []string{"("}}},
+	}
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,58 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrToolDenied is returned for a tool call the PermissionHandler rejected.
+// The model receives it as an error result; it does not count toward the
+// fail-safe.
+var ErrToolDenied = errors.New("tool call denied")
+
+// PermissionHandler decides whether a tool call may run. The harness consults
+// it before executing each tool; Approve may block, e.g. to wait for a user.
+type PermissionHandler interface {
+	// Approve returns true to run call. An error (including ctx cancellation)
+	// denies the call.
+	Approve(ctx context.Context, call ToolCall) (bool, error)
+}
+
+// PermissionFunc adapts a function to a PermissionHandler.
+type PermissionFunc func(ctx context.Context, call ToolCall) (bool, error)
+
+// Approve calls f.
+func (f PermissionFunc) Approve(ctx context.Context, call ToolCall) (bool, error) {
+	return f(ctx, call)
+}
+
+// SetPermissionHandler gates every tool call on p. Sessions created afterwards
+// inherit it. A nil handler runs all calls.
+func (h *Harness) SetPermissionHandler(p PermissionHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.permission = p
+}
+
+// checkPermission asks the permission handler, if any, whether call may run.
+func (h *Harness) checkPermission(ctx context.Context, call ToolCall) error {
+	h.mu.Lock()
+	p := h.permission
+	h.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+
+	approved, err := p.Approve(ctx, call)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %s: %v", ErrToolDenied, call.Name, err)
+	}
+	if !approved {
+		return fmt.Errorf("%w: %s was not approved by the user", ErrToolDenied, call.Name)
+	}
+	return nil
+}
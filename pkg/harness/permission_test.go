@@ -0,0 +1,112 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestPermission_DeniedCallIsNotExecuted(t *testing.T) {
+	executed := false
+	bash := &MockTool{
+		name: "bash",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			executed = true
+			return `{"exit_code":0}`, nil
+		},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "bash", map[string]string{"command": "rm -rf /"}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_2", "bash", map[string]string{"command": "rm -rf /"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Understood"))
+
+	handler := &MockEventHandler{}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model", MaxMutatingFailures: 1}, []tool.Tool{bash}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	var asked []harness.ToolCall
+	h.SetPermissionHandler(harness.PermissionFunc(func(ctx context.Context, call harness.ToolCall) (bool, error) {
+		asked = append(asked, call)
+		return false, nil
+	}))
+
+	if err := h.Prompt(context.Background(), "wipe"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if executed {
+		t.Error("denied tool was executed")
+	}
+	if len(asked) != 2 || asked[0].ID != "call_1" || asked[0].Name != "bash" {
+		t.Errorf("unexpected permission requests %+v", asked)
+	}
+	if len(handler.ToolResults) != 2 || !handler.ToolResults[0].IsError || !strings.Contains(handler.ToolResults[0].Result, harness.ErrToolDenied.Error()) {
+		t.Errorf("expected denied error results, got %+v", handler.ToolResults)
+	}
+	// A denial is not a tool failure, so two of them do not activate the
+	// fail-safe
+	if tools := mockStreamer.RecordedParams[2].Tools; len(tools) != 1 {
+		t.Errorf("expected bash to remain available, got %d tools", len(tools))
+	}
+}
+
+func TestPermission_CancelWhileWaiting(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "bash", map[string]string{}))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{&MockTool{name: "bash"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	waiting := make(chan struct{})
+	h.SetPermissionHandler(harness.PermissionFunc(func(ctx context.Context, call harness.ToolCall) (bool, error) {
+		close(waiting)
+		<-ctx.Done()
+		return false, ctx.Err()
+	}))
+
+	done := make(chan error, 1)
+	go func() { done <- h.Prompt(context.Background(), "go") }()
+	<-waiting
+	h.Cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("prompt did not stop")
+	}
+}
+
+func TestPermission_InheritedBySessions(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "echo", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done"))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{&MockTool{name: "echo"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	asked := 0
+	h.SetPermissionHandler(harness.PermissionFunc(func(ctx context.Context, call harness.ToolCall) (bool, error) {
+		asked++
+		return true, nil
+	}))
+
+	if err := h.NewSession(nil).Prompt(context.Background(), "go"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if asked != 1 {
+		t.Errorf("expected the session to ask once, asked %d times", asked)
+	}
+}
@@ -0,0 +1,38 @@
+package harness
+
+import (
+	"encoding/json"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// Plan returns the task plan the todo tool keeps for the conversation,
+// empty if it has not been used.
+func (h *Harness) Plan() []tool.TodoItem {
+	return h.plan.Items()
+}
+
+// clearPlan empties the task plan and reports the empty plan if there was
+// one.
+func (h *Harness) clearPlan() {
+	if len(h.plan.Items()) == 0 {
+		return
+	}
+	h.plan.Clear()
+	h.emitPlan([]tool.TodoItem{})
+}
+
+// emitPlan forwards the task plan to the event handler.
+func (h *Harness) emitPlan(payload any) {
+	ph, ok := h.handler.(PlanHandler)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		h.logger.Warn("tool", "Plan not serializable", log.F("error", err.Error()))
+		return
+	}
+	ph.OnPlan(data)
+}
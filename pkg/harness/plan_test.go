@@ -0,0 +1,72 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// planRecorder extends MockEventHandler with PlanHandler support.
+type planRecorder struct {
+	toolEventRecorder
+	Plans []string
+}
+
+func (h *planRecorder) OnPlan(plan json.RawMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Plans = append(h.Plans, string(plan))
+}
+
+func TestPlan_KeptPerConversationAndReported(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "todo", map[string]any{
+		"todos": []map[string]string{{"content": "Write code"}, {"content": "Test it"}},
+	}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_2", "todo", map[string]any{
+		"updates": []map[string]string{{"id": "1", "status": "done"}},
+	}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done."))
+
+	handler := &planRecorder{}
+	h, err := harness.NewHarnessWithStreamer(
+		harness.Config{Model: "test-model", ToolEventTypes: []string{"test_result"}},
+		[]tool.Tool{tool.NewTodoTool()},
+		handler,
+		mockStreamer,
+	)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "plan"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	want := []string{
+		`[{"id":"1","content":"Write code","status":"pending"},{"id":"2","content":"Test it","status":"pending"}]`,
+		`[{"id":"1","content":"Write code","status":"done"},{"id":"2","content":"Test it","status":"pending"}]`,
+	}
+	if len(handler.Plans) != 2 || handler.Plans[0] != want[0] || handler.Plans[1] != want[1] {
+		t.Fatalf("unexpected plans: %v", handler.Plans)
+	}
+	if len(handler.Events) != 0 {
+		t.Errorf("plans should not be reported as tool events, got %+v", handler.Events)
+	}
+	if plan := h.Plan(); len(plan) != 2 || plan[0].Status != "done" {
+		t.Errorf("unexpected plan: %+v", plan)
+	}
+
+	if err := h.Reset(); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if len(h.Plan()) != 0 {
+		t.Errorf("expected an empty plan after reset, got %+v", h.Plan())
+	}
+	if len(handler.Plans) != 3 || handler.Plans[2] != "[]" {
+		t.Errorf("expected an empty plan to be reported after reset, got %v", handler.Plans)
+	}
+}
@@ -0,0 +1,25 @@
+package harness
+
+import "errors"
+
+// ErrPreempted is returned by Prompt when the run was stopped by Preempt.
+var ErrPreempted = errors.New("run preempted")
+
+// Preempt asks the running prompt to stop gracefully: the tool calls of the
+// current turn finish and their results are recorded, then Prompt returns
+// ErrPreempted instead of making another API request.
+// Safe to call when no prompt is running (no-op).
+func (h *Harness) Preempt() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.running {
+		h.preempted = true
+	}
+}
+
+// preemptRequested reports whether Preempt was called for the running prompt.
+func (h *Harness) preemptRequested() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.preempted
+}
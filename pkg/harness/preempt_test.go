@@ -0,0 +1,77 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestPreempt_StopsAfterCurrentTools(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := &MockTool{
+		name: "slow",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			close(started)
+			<-release
+			return `{"ok":true}`, nil
+		},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "slow", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Should not be requested"))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{slow}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- h.Prompt(context.Background(), "go") }()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for tool to start")
+	}
+	h.Preempt()
+	close(release)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, harness.ErrPreempted) {
+			t.Fatalf("expected ErrPreempted, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for prompt to stop")
+	}
+
+	// The tool finished and its result was recorded before stopping
+	if msgs := h.Messages(); len(msgs) != 3 {
+		t.Errorf("expected user, tool use, and tool result messages, got %d", len(msgs))
+	}
+	if len(mockStreamer.RecordedParams) != 1 {
+		t.Errorf("expected 1 API request, got %d", len(mockStreamer.RecordedParams))
+	}
+}
+
+func TestPreempt_NoOpWhenIdle(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Hello"))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	h.Preempt()
+	if err := h.Prompt(context.Background(), "hi"); err != nil {
+		t.Errorf("expected prompt to run, got %v", err)
+	}
+}
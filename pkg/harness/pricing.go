@@ -0,0 +1,55 @@
+package harness
+
+import "strings"
+
+// ModelPrice is the price of a model in US dollars per million tokens.
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+
+	// WebSearch is the price per 1,000 web search requests.
+	WebSearch float64 `json:"web_search"`
+}
+
+// Cost returns the estimated cost of u in US dollars.
+func (p ModelPrice) Cost(u Usage) float64 {
+	tokens := float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheCreationInputTokens)*p.CacheWrite +
+		float64(u.CacheReadInputTokens)*p.CacheRead
+	return tokens/1e6 + float64(u.WebSearchRequests)*p.WebSearch/1e3
+}
+
+// DefaultPrices holds Anthropic list prices, keyed by model ID prefix so
+// dated snapshots and -latest aliases match.
+var DefaultPrices = map[string]ModelPrice{
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheWrite: 0.30, CacheRead: 0.03, WebSearch: 10},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08, WebSearch: 10},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10, WebSearch: 10},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30, WebSearch: 10},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30, WebSearch: 10},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30, WebSearch: 10},
+	"claude-3-opus":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50, WebSearch: 10},
+	"claude-opus-4":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50, WebSearch: 10},
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50, WebSearch: 10},
+}
+
+// LookupPrice returns the price of model from prices, falling back to
+// DefaultPrices. Keys match the model ID exactly or as a prefix; the
+// longest match wins.
+func LookupPrice(prices map[string]ModelPrice, model string) (ModelPrice, bool) {
+	for _, table := range []map[string]ModelPrice{prices, DefaultPrices} {
+		best := ""
+		for key := range table {
+			if strings.HasPrefix(model, key) && len(key) > len(best) {
+				best = key
+			}
+		}
+		if best != "" {
+			return table[best], true
+		}
+	}
+	return ModelPrice{}, false
+}
@@ -0,0 +1,127 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestLookupPrice(t *testing.T) {
+	custom := map[string]harness.ModelPrice{
+		"claude-sonnet-4-5": {Input: 1},
+		"my-model":          {Input: 2},
+	}
+	tests := []struct {
+		model     string
+		wantInput float64
+		wantOK    bool
+	}{
+		{"claude-3-5-haiku-20241022", 0.80, true},
+		{"claude-opus-4-1-20250805", 15, true},
+		{"claude-opus-4-5-20251101", 5, true},   // longest prefix wins
+		{"claude-sonnet-4-5-20250929", 1, true}, // custom prices take precedence
+		{"claude-sonnet-4-20250514", 3, true},
+		{"my-model", 2, true},
+		{"unknown-model", 0, false},
+	}
+	for _, tt := range tests {
+		price, ok := harness.LookupPrice(custom, tt.model)
+		if ok != tt.wantOK || price.Input != tt.wantInput {
+			t.Errorf("%s: expected %v %v, got %v %v", tt.model, tt.wantInput, tt.wantOK, price.Input, ok)
+		}
+	}
+}
+
+func TestModelPrice_Cost(t *testing.T) {
+	price := harness.ModelPrice{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30, WebSearch: 10}
+	cost := price.Cost(harness.Usage{
+		InputTokens:              1_000_000,
+		OutputTokens:             100_000,
+		CacheCreationInputTokens: 200_000,
+		CacheReadInputTokens:     1_000_000,
+		WebSearchRequests:        3,
+	})
+	// 3 + 1.5 + 0.75 + 0.30 + 0.03
+	if math.Abs(cost-5.58) > 1e-9 {
+		t.Errorf("expected cost 5.58, got %v", cost)
+	}
+}
+
+// usageRecorder records usage reports.
+type usageRecorder struct {
+	MockEventHandler
+	mu      sync.Mutex
+	reports []harness.UsageReport
+}
+
+func (r *usageRecorder) OnUsage(usage json.RawMessage) {
+	var report harness.UsageReport
+	json.Unmarshal(usage, &report)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, report)
+}
+
+func TestUsage_ReportedPerTurnWithCost(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddToolUse("call_1", "test_tool", map[string]string{}).
+		WithUsage(1000, 100).
+		WithCacheUsage(2000, 0).
+		BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddText("Done").
+		WithUsage(500, 50).
+		WithCacheUsage(0, 2000).
+		Build())
+
+	recorder := &usageRecorder{}
+	config := harness.Config{
+		Model:  "test-model",
+		Prices: map[string]harness.ModelPrice{"test-model": {Input: 1, Output: 10, CacheWrite: 2, CacheRead: 0.5}},
+	}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{&MockTool{name: "test_tool"}}, recorder, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "go"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(recorder.reports) != 2 {
+		t.Fatalf("expected a usage report per turn, got %d", len(recorder.reports))
+	}
+	first, second := recorder.reports[0], recorder.reports[1]
+	if first.Turn.CacheCreationInputTokens != 2000 || second.Turn.CacheReadInputTokens != 2000 {
+		t.Errorf("expected cache tokens per turn, got %+v, %+v", first.Turn, second.Turn)
+	}
+	// (1000*1 + 100*10 + 2000*2) / 1e6 and (500*1 + 50*10 + 2000*0.5) / 1e6
+	if math.Abs(first.Turn.CostUSD-0.006) > 1e-12 || math.Abs(second.Turn.CostUSD-0.002) > 1e-12 {
+		t.Errorf("unexpected turn costs %v, %v", first.Turn.CostUSD, second.Turn.CostUSD)
+	}
+	if second.Run.InputTokens != 1500 || math.Abs(second.Run.CostUSD-0.008) > 1e-12 {
+		t.Errorf("unexpected run totals %+v", second.Run)
+	}
+	if second.Session != second.Run {
+		t.Errorf("expected session totals to match the only run, got %+v", second.Session)
+	}
+	if got := h.LastRun().Usage; got != second.Run {
+		t.Errorf("expected LastRun usage %+v, got %+v", second.Run, got)
+	}
+
+	// The session total accumulates across prompts
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("Again").WithUsage(100, 10).Build())
+	h.Prompt(context.Background(), "again")
+	if got := h.Usage(); got.InputTokens != 1600 || got.OutputTokens != 160 {
+		t.Errorf("unexpected session usage %+v", got)
+	}
+	if got := h.LastRun().Usage; got.InputTokens != 100 {
+		t.Errorf("expected per-prompt usage, got %+v", got)
+	}
+}
@@ -0,0 +1,139 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Profile is a named agent preset in Config.Profiles, such as a "reviewer"
+// with read-only tools and a review system prompt, or a "builder" with every
+// tool. A prompt selects one with PromptOptions.Profile, and a session with
+// SetProfile. Unset fields keep the configured values.
+type Profile struct {
+	// Description tells users what the profile is for.
+	Description string `json:"description,omitempty"`
+
+	// SystemPrompt replaces Config.SystemPrompt.
+	SystemPrompt string `json:"system_prompt,omitempty"`
+
+	// Model overrides Config.Model.
+	Model string `json:"model,omitempty"`
+
+	// MaxTurns overrides Config.MaxTurns.
+	MaxTurns int `json:"max_turns,omitempty"`
+
+	// Tools restricts the local tools offered to the model to those named;
+	// an empty non-nil list offers none.
+	Tools []string `json:"tools,omitempty"`
+
+	// Temperature and TopP override Config.Temperature and Config.TopP.
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+}
+
+// ProfileInfo describes a profile for listing.
+type ProfileInfo struct {
+	Name string `json:"name"`
+	Profile
+}
+
+// validateProfiles checks the configured profiles and that the default
+// profile is one of them. Tool names are checked by NewHarness.
+func validateProfiles(profiles map[string]Profile, name string) error {
+	for n, p := range profiles {
+		if n == "" {
+			return errors.New("profile names must not be empty")
+		}
+		if p.MaxTurns < 0 {
+			return fmt.Errorf("profile %s: max_turns must not be negative", n)
+		}
+		if err := validateSampling(p.Temperature, p.TopP); err != nil {
+			return fmt.Errorf("profile %s: %w", n, err)
+		}
+	}
+	if _, ok := profiles[name]; name != "" && !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	return nil
+}
+
+// validateProfileTools checks that the tools the profiles name exist.
+func (h *Harness) validateProfileTools() error {
+	for n, p := range h.config.Profiles {
+		for _, name := range p.Tools {
+			if _, ok := h.tools[name]; !ok {
+				return fmt.Errorf("profile %s: unknown tool %q", n, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Profiles returns the configured profiles, sorted by name.
+func (h *Harness) Profiles() []ProfileInfo {
+	infos := make([]ProfileInfo, 0, len(h.config.Profiles))
+	for name, p := range h.config.Profiles {
+		infos = append(infos, ProfileInfo{Name: name, Profile: p})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// SetProfile selects the profile of the session's prompts that do not name
+// one; "" selects none. It starts as Config.Profile, and affects prompts
+// started afterwards.
+func (h *Harness) SetProfile(name string) error {
+	if _, ok := h.config.Profiles[name]; name != "" && !ok {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.profile = name
+	return nil
+}
+
+// Profile returns the session's profile, selected by SetProfile.
+func (h *Harness) Profile() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.profile
+}
+
+// withProfile returns opts with the session's profile if they name none,
+// and the profile's values under the options set. Callers hold h.mu.
+func (h *Harness) withProfile(opts PromptOptions) PromptOptions {
+	if opts.Profile == "" {
+		opts.Profile = h.profile
+	}
+	p, ok := h.config.Profiles[opts.Profile]
+	if !ok {
+		return opts
+	}
+	if opts.Model == "" {
+		opts.Model = p.Model
+	}
+	if opts.MaxTurns == 0 {
+		opts.MaxTurns = p.MaxTurns
+	}
+	if opts.Tools == nil {
+		opts.Tools = slices.Clone(p.Tools)
+	}
+	if opts.Temperature == nil {
+		opts.Temperature = p.Temperature
+	}
+	if opts.TopP == nil {
+		opts.TopP = p.TopP
+	}
+	return opts
+}
+
+// systemPrompt returns the base system prompt of the running prompt: its
+// profile's, or else the configured one.
+func (h *Harness) systemPrompt() string {
+	if p, ok := h.config.Profiles[h.options.Profile]; ok && p.SystemPrompt != "" {
+		return p.SystemPrompt
+	}
+	return h.config.SystemPrompt
+}
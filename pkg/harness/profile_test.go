@@ -0,0 +1,97 @@
+package harness_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// requestTools returns the names of the tools offered in a request.
+func requestTools(params anthropic.MessageNewParams) string {
+	var names []string
+	for _, t := range params.Tools {
+		names = append(names, t.OfTool.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestProfiles_SelectPerPromptAndSession(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	for range 3 {
+		mockStreamer.AddResponse(testutil.TextOnlyResponse("Done"))
+	}
+
+	config := harness.Config{
+		Model:        "test-model",
+		SystemPrompt: "You are a coding agent.",
+		Profiles: map[string]harness.Profile{
+			"reviewer": {SystemPrompt: "You review code.", Tools: []string{"read"}},
+			"builder":  {Model: "big-model", MaxTurns: 30},
+		},
+		Profile: "reviewer",
+	}
+	tools := []tool.Tool{&MockTool{name: "read"}, &MockTool{name: "edit"}}
+	h, err := harness.NewHarnessWithStreamer(config, tools, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	// The default profile, with an option added
+	if _, err := h.PromptWithOptions(context.Background(), "review", harness.PromptOptions{System: "Be brief."}); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	// A profile named by the prompt, with an option overriding it
+	if _, err := h.PromptWithOptions(context.Background(), "build", harness.PromptOptions{Profile: "builder", Model: "other-model"}); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	// No profile for the session
+	if err := h.SetProfile(""); err != nil {
+		t.Fatalf("SetProfile failed: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "plain"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	review, build, plain := mockStreamer.RecordedParams[0], mockStreamer.RecordedParams[1], mockStreamer.RecordedParams[2]
+	if len(review.System) != 2 || review.System[0].Text != "You review code." || review.System[1].Text != "Be brief." {
+		t.Errorf("expected the reviewer prompt and the addition, got %+v", review.System)
+	}
+	if got := requestTools(review); got != "read" {
+		t.Errorf("expected only the reviewer's tools, got %s", got)
+	}
+	if build.Model != "other-model" || build.System[0].Text != "You are a coding agent." || requestTools(build) != "read,edit" {
+		t.Errorf("unexpected builder request: model %s, system %+v, tools %s", build.Model, build.System, requestTools(build))
+	}
+	if plain.Model != "test-model" || requestTools(plain) != "read,edit" {
+		t.Errorf("expected the configuration without a profile, got model %s, tools %s", plain.Model, requestTools(plain))
+	}
+}
+
+func TestProfiles_Validation(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	tools := []tool.Tool{&MockTool{name: "read"}}
+
+	_, err := harness.NewHarnessWithStreamer(harness.Config{
+		Profiles: map[string]harness.Profile{"reviewer": {Tools: []string{"grep"}}},
+	}, tools, nil, mockStreamer)
+	if err == nil || !strings.Contains(err.Error(), `unknown tool "grep"`) {
+		t.Errorf("expected an unknown tool error, got %v", err)
+	}
+	_, err = harness.NewHarnessWithStreamer(harness.Config{Profile: "reviewer"}, tools, nil, mockStreamer)
+	if err == nil || !strings.Contains(err.Error(), `unknown profile "reviewer"`) {
+		t.Errorf("expected an unknown profile error, got %v", err)
+	}
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{}, tools, nil, mockStreamer)
+	if err := h.ValidateOptions(harness.PromptOptions{Profile: "reviewer"}); err == nil {
+		t.Error("expected an error for an unknown prompt profile")
+	}
+	if err := h.SetProfile("reviewer"); err == nil {
+		t.Error("expected an error selecting an unknown profile")
+	}
+}
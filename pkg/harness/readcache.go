@@ -0,0 +1,72 @@
+package harness
+
+import (
+	"context"
+	"sync"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// readCacheTotals sums the read cache statistics of a harness's runs,
+// shared with its sessions.
+type readCacheTotals struct {
+	mu sync.Mutex
+	// finished sums the hits, misses, and evictions of finished runs.
+	finished tool.ReadCacheStats
+	// active holds the caches of the runs in progress.
+	active map[*tool.ReadCache]bool
+}
+
+// startReadCache attaches a read cache for the run to ctx, if enabled. A
+// sub-agent shares the cache of its parent's run.
+func (h *Harness) startReadCache(ctx context.Context) (context.Context, *tool.ReadCache) {
+	if h.config.ReadCacheBytes <= 0 || tool.ReadCacheFromContext(ctx) != nil {
+		return ctx, nil
+	}
+	cache := tool.NewReadCache(h.config.ReadCacheBytes)
+	h.readCache.mu.Lock()
+	h.readCache.active[cache] = true
+	h.readCache.mu.Unlock()
+	return tool.WithReadCache(ctx, cache), cache
+}
+
+// finishReadCache adds the run's cache statistics to the totals and logs
+// them. The cache's content is dropped with it.
+func (h *Harness) finishReadCache(ctx context.Context, cache *tool.ReadCache) {
+	if cache == nil {
+		return
+	}
+	stats := cache.Stats()
+	h.readCache.mu.Lock()
+	delete(h.readCache.active, cache)
+	h.readCache.finished.Hits += stats.Hits
+	h.readCache.finished.Misses += stats.Misses
+	h.readCache.finished.Evictions += stats.Evictions
+	h.readCache.mu.Unlock()
+	log.ForContext(h.logger, ctx).Debug("harness", "Read cache",
+		log.F("hits", stats.Hits),
+		log.F("misses", stats.Misses),
+		log.F("evictions", stats.Evictions),
+		log.F("bytes", stats.Bytes),
+	)
+}
+
+// ReadCacheStats returns the read cache's hits, misses, and evictions
+// summed over the runs of the harness and its sessions, and the bytes and
+// entries held by the caches of the runs in progress. It is zero when
+// Config.ReadCacheBytes is not set.
+func (h *Harness) ReadCacheStats() tool.ReadCacheStats {
+	h.readCache.mu.Lock()
+	defer h.readCache.mu.Unlock()
+	total := h.readCache.finished
+	for cache := range h.readCache.active {
+		stats := cache.Stats()
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Evictions += stats.Evictions
+		total.Bytes += stats.Bytes
+		total.Entries += stats.Entries
+	}
+	return total
+}
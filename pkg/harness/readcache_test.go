@@ -0,0 +1,45 @@
+package harness_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestReadCache_PerRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(path, []byte("remember this\n"), 0644)
+	input := map[string]string{"path": path}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	for range 2 {
+		mockStreamer.AddResponse(testutil.SingleToolResponse("t1", "read", input))
+		mockStreamer.AddResponse(testutil.SingleToolResponse("t2", "read", input))
+		mockStreamer.AddResponse(testutil.TextOnlyResponse("Done"))
+	}
+
+	config := harness.Config{Model: "test-model", ReadCacheBytes: 1 << 20}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{tool.NewReadTool()}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	// Each run starts with an empty cache, so every run misses once
+	for range 2 {
+		if err := h.Prompt(context.Background(), "read the notes twice"); err != nil {
+			t.Fatalf("prompt failed: %v", err)
+		}
+	}
+	stats := h.ReadCacheStats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.HitRate() != 0.5 {
+		t.Errorf("expected one hit and one miss per run, got %+v", stats)
+	}
+	if stats.Bytes != 0 || stats.Entries != 0 {
+		t.Errorf("expected no content held between runs, got %+v", stats)
+	}
+}
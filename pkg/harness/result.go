@@ -0,0 +1,114 @@
+package harness
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Stop reasons of a RunResult besides the API stop reasons, such as
+// "end_turn", of the last response.
+const (
+	// StopReasonMaxTurns means the run reached Config.MaxTurns.
+	StopReasonMaxTurns = "max_turns"
+	// StopReasonCancelled means the run's context was cancelled.
+	StopReasonCancelled = "cancelled"
+	// StopReasonPreempted means a higher-priority run preempted the run.
+	StopReasonPreempted = "preempted"
+	// StopReasonBudgetExceeded means the run used up Config.MaxCostUSD or
+	// Config.MaxTokensPerPrompt.
+	StopReasonBudgetExceeded = "budget_exceeded"
+)
+
+// RunResult summarizes one Prompt call.
+type RunResult struct {
+	RunID      string `json:"run_id"`      // ID in the run's log lines, from the context or generated
+	FinalText  string `json:"final_text"`  // text blocks of the last assistant message
+	StopReason string `json:"stop_reason"` // API stop reason of the last response, or one of the StopReason constants
+	Turns      int    `json:"turns"`       // API requests made
+	Usage      Usage  `json:"usage"`       // tokens used by this run only
+	DurationMs int64  `json:"duration_ms"` // wall time of the run
+
+	// Tool call interrupted by cancellation, if the run was cancelled
+	// while a tool was running
+	InterruptedTool   string `json:"interrupted_tool,omitempty"`
+	InterruptedToolID string `json:"interrupted_tool_id,omitempty"`
+
+	// Environment captured at run start
+	Environment *Fingerprint `json:"environment,omitempty"`
+
+	// NeedsReview is set when the run entered fail-safe mode, so a human
+	// should check what its failed tool calls left behind
+	NeedsReview bool `json:"needs_review,omitempty"`
+}
+
+// RunID returns the ID of the running prompt, or "" if none is running.
+func (h *Harness) RunID() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.running {
+		return ""
+	}
+	return h.result.RunID
+}
+
+// newRunID returns a random run identifier.
+func newRunID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "run_" + hex.EncodeToString(buf)
+}
+
+// recordTurn folds one API response into the running prompt's result.
+func (h *Harness) recordTurn(msg *anthropic.Message, usage Usage) {
+	var text []string
+	for _, block := range msg.Content {
+		if block.Type == "text" {
+			text = append(text, block.Text)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.result.Turns++
+	h.result.Usage = h.result.Usage.Add(usage)
+	h.result.StopReason = string(msg.StopReason)
+	h.result.FinalText = strings.Join(text, "\n")
+}
+
+// recordInterrupted notes that call was running when the run was cancelled.
+func (h *Harness) recordInterrupted(call ToolCall) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.result.InterruptedTool = call.Name
+	h.result.InterruptedToolID = call.ID
+}
+
+// finishResult records how the running prompt ended and returns its result.
+func (h *Harness) finishResult(err error, duration time.Duration) RunResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case errors.Is(err, ErrPreempted):
+		h.result.StopReason = StopReasonPreempted
+	case errors.Is(err, context.Canceled):
+		h.result.StopReason = StopReasonCancelled
+	case errors.Is(err, ErrBudgetExceeded):
+		h.result.StopReason = StopReasonBudgetExceeded
+	}
+	h.result.DurationMs = duration.Milliseconds()
+	h.result.NeedsReview = h.failSafe.active
+	return h.result
+}
+
+// LastRun returns the result of the running or most recent prompt.
+func (h *Harness) LastRun() RunResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.result
+}
@@ -0,0 +1,177 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestLastRun_SummarizesPrompt(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddText("Let me check.").
+		AddToolUse("call_1", "test_tool", map[string]string{}).
+		WithUsage(100, 20).
+		BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddText("All good.").
+		AddText("Nothing to change.").
+		WithUsage(150, 30).
+		Build())
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{&MockTool{name: "test_tool"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "check"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	got := h.LastRun()
+	if got.FinalText != "All good.\nNothing to change." {
+		t.Errorf("unexpected final text %q", got.FinalText)
+	}
+	if got.StopReason != "end_turn" || got.Turns != 2 {
+		t.Errorf("unexpected stop reason %q or turns %d", got.StopReason, got.Turns)
+	}
+	if got.Usage.InputTokens != 250 || got.Usage.OutputTokens != 50 {
+		t.Errorf("unexpected usage %+v", got.Usage)
+	}
+
+	// The next prompt starts a fresh result
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("Hi").WithUsage(10, 1).Build())
+	h.Prompt(context.Background(), "hello")
+	if got := h.LastRun(); got.Turns != 1 || got.Usage.InputTokens != 10 {
+		t.Errorf("expected per-run result, got %+v", got)
+	}
+}
+
+func TestLastRun_MaxTurns(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "test_tool", map[string]string{}))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model", MaxTurns: 1}, []tool.Tool{&MockTool{name: "test_tool"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	h.Prompt(context.Background(), "loop")
+	if got := h.LastRun().StopReason; got != harness.StopReasonMaxTurns {
+		t.Errorf("expected stop reason %q, got %q", harness.StopReasonMaxTurns, got)
+	}
+}
+
+func TestPromptWithOptions_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	blocking := &MockTool{name: "test_tool", executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+		cancel()
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "test_tool", map[string]string{}))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{blocking}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	result, err := h.PromptWithOptions(ctx, "wait", harness.PromptOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if result.StopReason != harness.StopReasonCancelled || result.Turns != 1 {
+		t.Errorf("expected a cancelled result after 1 turn, got %+v", result)
+	}
+	if result.InterruptedTool != "test_tool" || result.InterruptedToolID != "call_1" {
+		t.Errorf("expected the interrupted tool call, got %+v", result)
+	}
+	if h.LastRun().StopReason != harness.StopReasonCancelled {
+		t.Errorf("expected LastRun to match, got %+v", h.LastRun())
+	}
+}
+
+func TestPromptWithOptions_CancelledOnLastTurn(t *testing.T) {
+	// A tool cancelled on the last allowed turn ends the run as cancelled,
+	// not at the turn limit
+	ctx, cancel := context.WithCancel(context.Background())
+	blocking := &MockTool{name: "test_tool", executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+		cancel()
+		return "", ctx.Err()
+	}}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "test_tool", map[string]string{}))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model", MaxTurns: 1}, []tool.Tool{blocking}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	result, err := h.PromptWithOptions(ctx, "wait", harness.PromptOptions{})
+	if !errors.Is(err, context.Canceled) || errors.Is(err, harness.ErrMaxTurnsExceeded) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if result.StopReason != harness.StopReasonCancelled {
+		t.Errorf("expected a cancelled result, got %+v", result)
+	}
+}
+
+func TestPromptWithOptions_RunID(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("one"))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("two"))
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+
+	// The caller's run ID is kept; without one, an ID is generated
+	result, err := h.PromptWithOptions(log.WithRunID(context.Background(), "run_given"), "hi", harness.PromptOptions{})
+	if err != nil || result.RunID != "run_given" {
+		t.Errorf("expected run_given, got %q (%v)", result.RunID, err)
+	}
+	result, err = h.PromptWithOptions(context.Background(), "again", harness.PromptOptions{})
+	if err != nil || !strings.HasPrefix(result.RunID, "run_") || result.RunID == "run_given" {
+		t.Errorf("expected a generated run ID, got %q (%v)", result.RunID, err)
+	}
+	if h.RunID() != "" {
+		t.Errorf("expected no run ID while idle, got %q", h.RunID())
+	}
+}
+
+func TestContinue(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "test_tool", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Finished"))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model", MaxTurns: 1}, []tool.Tool{&MockTool{name: "test_tool"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if _, err := h.Continue(context.Background()); !errors.Is(err, harness.ErrNothingToContinue) {
+		t.Errorf("expected ErrNothingToContinue before any prompt, got %v", err)
+	}
+
+	if err := h.Prompt(context.Background(), "loop"); !errors.Is(err, harness.ErrMaxTurnsExceeded) {
+		t.Fatalf("expected ErrMaxTurnsExceeded, got %v", err)
+	}
+	if !h.CanContinue() {
+		t.Fatal("expected the run to be resumable")
+	}
+
+	// The loop resumes from the tool results, without a new user message
+	result, err := h.Continue(context.Background())
+	if err != nil {
+		t.Fatalf("continue failed: %v", err)
+	}
+	if result.StopReason != "end_turn" || result.FinalText != "Finished" || result.Turns != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if got := len(h.Messages()); got != 4 {
+		t.Errorf("expected 4 messages, got %d", got)
+	}
+	if h.CanContinue() {
+		t.Error("expected nothing left to continue")
+	}
+}
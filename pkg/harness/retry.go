@@ -0,0 +1,146 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+)
+
+// DefaultRetryBaseDelay is the first retry delay when Config.RetryBaseDelay is unset.
+const DefaultRetryBaseDelay = time.Second
+
+// maxRetryDelay caps the exponential backoff of a single retry.
+const maxRetryDelay = time.Minute
+
+// streamWithRetry streams one API turn, retrying rate-limit, overload, and
+// server errors up to Config.MaxRetries times with exponential backoff. If
+// the retries fail too, the turn is retried with the next of
+// Config.FallbackModels, which the run keeps for its later turns.
+func (h *Harness) streamWithRetry(ctx context.Context, params anthropic.MessageNewParams) (anthropic.Message, error) {
+	for {
+		message, err := h.streamWithBackoff(ctx, params)
+		if err == nil || !isRetryable(err) || ctx.Err() != nil || h.fallback >= len(h.config.FallbackModels) {
+			return message, err
+		}
+
+		from := string(params.Model)
+		h.fallback++
+		params.Model = anthropic.Model(h.model())
+		log.ForContext(h.logger, ctx).Warn("api", "Switching to fallback model",
+			log.F("from", from),
+			log.F("to", string(params.Model)),
+			log.F("error", err.Error()),
+		)
+		h.emitStreamReset(err)
+		if mh, ok := h.handler.(ModelChangeHandler); ok {
+			mh.OnModelChanged(from, string(params.Model), err)
+		}
+	}
+}
+
+// streamWithBackoff streams one API request, retrying rate-limit, overload,
+// and server errors up to Config.MaxRetries times with exponential backoff.
+func (h *Harness) streamWithBackoff(ctx context.Context, params anthropic.MessageNewParams) (anthropic.Message, error) {
+	for attempt := 1; ; attempt++ {
+		spanCtx, span := startAPISpan(ctx, string(params.Model), attempt)
+		message, err := h.streamMessage(spanCtx, params)
+		endAPISpan(span, &message, err)
+		if err == nil || attempt > h.config.MaxRetries || !isRetryable(err) || ctx.Err() != nil {
+			return message, err
+		}
+
+		delay := h.retryDelay(attempt, err)
+		log.ForContext(h.logger, ctx).Warn("api", "Retrying request",
+			log.F("attempt", attempt),
+			log.F("max_retries", h.config.MaxRetries),
+			log.F("delay_ms", delay.Milliseconds()),
+			log.F("error", err.Error()),
+		)
+		h.emitStreamReset(err)
+		if rh, ok := h.handler.(RetryHandler); ok {
+			rh.OnRetry(attempt, delay, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return anthropic.Message{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// streamMessage sends one streaming request and accumulates the response.
+func (h *Harness) streamMessage(ctx context.Context, params anthropic.MessageNewParams) (anthropic.Message, error) {
+	h.streamed = false
+	stream := h.streamer.NewStreaming(ctx, params)
+
+	message := anthropic.Message{}
+	for stream.Next() {
+		event := stream.Current()
+		if err := message.Accumulate(event); err != nil {
+			return message, err
+		}
+
+		// Stream text deltas; emit complete blocks on ContentBlockStopEvent
+		switch e := event.AsAny().(type) {
+		case anthropic.ContentBlockDeltaEvent:
+			h.emitTextDelta(e)
+		case anthropic.ContentBlockStopEvent:
+			h.emitBlockComplete(&message, e.Index)
+		}
+	}
+	return message, stream.Err()
+}
+
+// emitStreamReset tells handlers implementing StreamResetHandler to discard
+// the events of a request that failed with err partway through its stream,
+// before the turn is requested again.
+func (h *Harness) emitStreamReset(err error) {
+	if !h.streamed {
+		return
+	}
+	h.streamed = false
+	if rh, ok := h.handler.(StreamResetHandler); ok {
+		rh.OnStreamReset(err)
+	}
+}
+
+// isRetryable reports whether err is an API error worth retrying:
+// 429 (rate limited), 529 (overloaded), or any other 5xx.
+func isRetryable(err error) bool {
+	var apiErr *anthropic.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+}
+
+// retryDelay returns the wait before retry attempt (1-based). It honors a
+// Retry-After header in seconds; otherwise it doubles the base delay per
+// attempt and picks a random delay in its upper half.
+func (h *Harness) retryDelay(attempt int, err error) time.Duration {
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) && apiErr.Response != nil {
+		if secs, err := strconv.Atoi(apiErr.Response.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return min(time.Duration(secs)*time.Second, maxRetryDelay)
+		}
+	}
+
+	base := h.config.RetryBaseDelay
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	delay := maxRetryDelay
+	if attempt <= 16 {
+		delay = min(base<<(attempt-1), maxRetryDelay)
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
@@ -0,0 +1,314 @@
+package harness_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// retryRecorder extends MockEventHandler with RetryHandler support.
+type retryRecorder struct {
+	MockEventHandler
+	Attempts []int
+	Delays   []time.Duration
+}
+
+func (h *retryRecorder) OnRetry(attempt int, delay time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Attempts = append(h.Attempts, attempt)
+	h.Delays = append(h.Delays, delay)
+}
+
+// modelChangeRecorder extends retryRecorder with ModelChangeHandler support.
+type modelChangeRecorder struct {
+	retryRecorder
+	Changes []string
+}
+
+func (h *modelChangeRecorder) OnModelChanged(from, to string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Changes = append(h.Changes, from+" -> "+to)
+}
+
+// apiError builds an API error with the given status code.
+func apiError(status int) *anthropic.Error {
+	req, _ := http.NewRequest(http.MethodPost, "https://api.anthropic.com/v1/messages", nil)
+	return &anthropic.Error{
+		StatusCode: status,
+		Request:    req,
+		Response:   &http.Response{StatusCode: status, Header: http.Header{}},
+	}
+}
+
+func TestRetry_RecoversFromRetryableErrors(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.ErrorResponse(apiError(529)))
+	mockStreamer.AddResponse(testutil.ErrorResponse(apiError(429)))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Recovered"))
+
+	handler := &retryRecorder{}
+	config := harness.Config{Model: "test-model", MaxRetries: 3, RetryBaseDelay: time.Millisecond}
+	h, err := harness.NewHarnessWithStreamer(config, nil, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "hi"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(handler.Attempts) != 2 || handler.Attempts[0] != 1 || handler.Attempts[1] != 2 {
+		t.Errorf("expected retry attempts [1 2], got %v", handler.Attempts)
+	}
+	if len(mockStreamer.RecordedParams) != 3 {
+		t.Errorf("expected 3 API requests, got %d", len(mockStreamer.RecordedParams))
+	}
+	if len(handler.TextEvents) != 1 || handler.TextEvents[0] != "Recovered" {
+		t.Errorf("unexpected text events %v", handler.TextEvents)
+	}
+}
+
+func TestRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	for range 3 {
+		mockStreamer.AddResponse(testutil.ErrorResponse(apiError(500)))
+	}
+
+	handler := &retryRecorder{}
+	config := harness.Config{Model: "test-model", MaxRetries: 2, RetryBaseDelay: time.Millisecond}
+	h, err := harness.NewHarnessWithStreamer(config, nil, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	err = h.Prompt(context.Background(), "hi")
+	var apiErr *anthropic.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 {
+		t.Fatalf("expected the 500 error, got %v", err)
+	}
+	if len(handler.Attempts) != 2 {
+		t.Errorf("expected 2 retries, got %d", len(handler.Attempts))
+	}
+	if len(mockStreamer.RecordedParams) != 3 {
+		t.Errorf("expected 3 API requests, got %d", len(mockStreamer.RecordedParams))
+	}
+}
+
+func TestRetry_NonRetryableErrorFailsImmediately(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.ErrorResponse(apiError(400)))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Should not be requested"))
+
+	handler := &retryRecorder{}
+	config := harness.Config{Model: "test-model", MaxRetries: 3, RetryBaseDelay: time.Millisecond}
+	h, err := harness.NewHarnessWithStreamer(config, nil, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	if err := h.Prompt(context.Background(), "hi"); err == nil {
+		t.Fatal("expected the 400 error")
+	}
+	if len(handler.Attempts) != 0 {
+		t.Errorf("expected no retries, got %v", handler.Attempts)
+	}
+}
+
+func TestRetry_BackoffGrowsAndHonorsRetryAfter(t *testing.T) {
+	retryAfter := apiError(429)
+	retryAfter.Response.Header.Set("Retry-After", "0")
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.ErrorResponse(apiError(503)))
+	mockStreamer.AddResponse(testutil.ErrorResponse(apiError(503)))
+	mockStreamer.AddResponse(testutil.ErrorResponse(retryAfter))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done"))
+
+	handler := &retryRecorder{}
+	base := 10 * time.Millisecond
+	config := harness.Config{Model: "test-model", MaxRetries: 3, RetryBaseDelay: base}
+	h, err := harness.NewHarnessWithStreamer(config, nil, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "hi"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(handler.Delays) != 3 {
+		t.Fatalf("expected 3 retries, got %d", len(handler.Delays))
+	}
+	if d := handler.Delays[0]; d < base/2 || d > base {
+		t.Errorf("first delay %v outside [%v, %v]", d, base/2, base)
+	}
+	if d := handler.Delays[1]; d < base || d > 2*base {
+		t.Errorf("second delay %v outside [%v, %v]", d, base, 2*base)
+	}
+	if d := handler.Delays[2]; d != 0 {
+		t.Errorf("expected Retry-After delay 0, got %v", d)
+	}
+}
+
+func TestRetry_CancelDuringBackoff(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.ErrorResponse(apiError(529)))
+
+	config := harness.Config{Model: "test-model", MaxRetries: 3, RetryBaseDelay: time.Minute}
+	h, err := harness.NewHarnessWithStreamer(config, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := h.Prompt(ctx, "hi"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("backoff was not interrupted (took %v)", elapsed)
+	}
+}
+
+func TestRetry_FallsBackToNextModel(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.ErrorResponse(apiError(529)))
+	mockStreamer.AddResponse(testutil.ErrorResponse(apiError(529)))
+	mockStreamer.AddResponse(testutil.ErrorResponse(apiError(429)))
+	mockStreamer.AddResponse(testutil.ErrorResponse(apiError(429)))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "mock_tool", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done"))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Back on the primary"))
+
+	handler := &modelChangeRecorder{}
+	config := harness.Config{
+		Model:          "primary",
+		MaxRetries:     1,
+		RetryBaseDelay: time.Millisecond,
+		FallbackModels: []string{"second", "third"},
+	}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{&MockTool{name: "mock_tool"}}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "hi"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	// Each model gets its retries; the run stays on the model that answered
+	var models []string
+	for _, params := range mockStreamer.RecordedParams {
+		models = append(models, string(params.Model))
+	}
+	if got := strings.Join(models, ","); got != "primary,primary,second,second,third,third" {
+		t.Errorf("unexpected request models %s", got)
+	}
+	if got := strings.Join(handler.Changes, ","); got != "primary -> second,second -> third" {
+		t.Errorf("unexpected model changes %s", got)
+	}
+
+	// The next run starts with the primary model again
+	if err := h.Prompt(context.Background(), "again"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if last := mockStreamer.RecordedParams[len(mockStreamer.RecordedParams)-1]; last.Model != "primary" {
+		t.Errorf("expected the next run to use the primary model, got %s", last.Model)
+	}
+}
+
+// streamRecorder records text deltas, texts, stream resets, retries, and
+// model changes in the order they arrive.
+type streamRecorder struct {
+	MockEventHandler
+	Events []string
+}
+
+func (h *streamRecorder) record(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Events = append(h.Events, event)
+}
+
+func (h *streamRecorder) OnText(text string)                                  { h.record("text " + text) }
+func (h *streamRecorder) OnTextDelta(blockIndex int, delta string)            { h.record("delta " + delta) }
+func (h *streamRecorder) OnStreamReset(err error)                             { h.record("reset") }
+func (h *streamRecorder) OnRetry(attempt int, delay time.Duration, err error) { h.record("retry") }
+func (h *streamRecorder) OnModelChanged(from, to string, err error)           { h.record("model " + to) }
+
+// failingStream returns the first n events of a stream, then fails with err.
+type failingStream struct {
+	harness.StreamIterator
+	n   int
+	err error
+}
+
+func (s *failingStream) Next() bool {
+	if s.n == 0 {
+		return false
+	}
+	s.n--
+	return s.StreamIterator.Next()
+}
+
+func (s *failingStream) Err() error { return s.err }
+
+func TestRetry_ResetsPartialStream(t *testing.T) {
+	// message_start, content_block_start, and two deltas, then a 529
+	partial := func() harness.StreamIterator {
+		return &failingStream{testutil.TextDeltaResponse("Hel", "lo", " there"), 4, apiError(529)}
+	}
+
+	tests := []struct {
+		name   string
+		config harness.Config
+		first  harness.StreamIterator
+		want   string
+	}{
+		{
+			name:   "retry",
+			config: harness.Config{Model: "test-model", MaxRetries: 1, RetryBaseDelay: time.Millisecond},
+			first:  partial(),
+			want:   "delta Hel, delta lo, reset, retry, delta Hello, delta  world, text Hello world",
+		},
+		{
+			name:   "fallback model",
+			config: harness.Config{Model: "test-model", FallbackModels: []string{"fallback-model"}},
+			first:  partial(),
+			want:   "delta Hel, delta lo, reset, model fallback-model, delta Hello, delta  world, text Hello world",
+		},
+		{
+			name:   "nothing streamed",
+			config: harness.Config{Model: "test-model", MaxRetries: 1, RetryBaseDelay: time.Millisecond},
+			first:  testutil.ErrorResponse(apiError(529)),
+			want:   "retry, delta Hello, delta  world, text Hello world",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStreamer := testutil.NewMockMessageStreamer()
+			mockStreamer.AddResponse(tt.first)
+			mockStreamer.AddResponse(testutil.TextDeltaResponse("Hello", " world"))
+
+			handler := &streamRecorder{}
+			h, err := harness.NewHarnessWithStreamer(tt.config, nil, handler, mockStreamer)
+			if err != nil {
+				t.Fatalf("failed to create harness: %v", err)
+			}
+			if err := h.Prompt(context.Background(), "hi"); err != nil {
+				t.Fatalf("prompt failed: %v", err)
+			}
+			if got := strings.Join(handler.Events, ", "); got != tt.want {
+				t.Errorf("expected events %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
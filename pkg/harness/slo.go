@@ -0,0 +1,206 @@
+package harness
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// DefaultSLOWindow is the rolling window used for latency statistics.
+const DefaultSLOWindow = 5 * time.Minute
+
+// maxLatencySamples caps the samples kept per operation kind, so a busy
+// window cannot grow without bound.
+const maxLatencySamples = 10000
+
+// Latency operation kinds.
+const (
+	LatencyAPI  = "api"
+	LatencyTool = "tool"
+)
+
+// SLO holds latency thresholds for one kind of operation.
+// A zero threshold is not enforced.
+type SLO struct {
+	P50 time.Duration
+	P95 time.Duration
+}
+
+// LatencySummary is the rolling latency summary for one kind of operation.
+type LatencySummary struct {
+	Count int   `json:"count"`
+	P50Ms int64 `json:"p50_ms"`
+	P95Ms int64 `json:"p95_ms"`
+}
+
+// LatencyStats is a snapshot of rolling latency statistics.
+type LatencyStats struct {
+	WindowSeconds int64          `json:"window_seconds"`
+	API           LatencySummary `json:"api"`
+	Tool          LatencySummary `json:"tool"`
+	// Violations lists the SLOs currently being exceeded, as "kind_percentile".
+	Violations []string `json:"violations"`
+}
+
+// latencySample is one observed duration.
+type latencySample struct {
+	at time.Time
+	d  time.Duration
+}
+
+// latencyTracker keeps rolling latency samples per operation kind.
+// It is shared by all sessions created from the same harness.
+type latencyTracker struct {
+	mu        sync.Mutex
+	window    time.Duration
+	samples   map[string][]latencySample
+	violating map[string]bool
+}
+
+// newLatencyTracker creates a tracker with the given rolling window.
+func newLatencyTracker(window time.Duration) *latencyTracker {
+	if window <= 0 {
+		window = DefaultSLOWindow
+	}
+	return &latencyTracker{
+		window:    window,
+		samples:   make(map[string][]latencySample),
+		violating: make(map[string]bool),
+	}
+}
+
+// record adds a sample and drops samples that fell out of the window.
+func (t *latencyTracker) record(kind string, d time.Duration, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	samples := append(t.samples[kind], latencySample{at: now, d: d})
+	if len(samples) > maxLatencySamples {
+		samples = samples[len(samples)-maxLatencySamples:]
+	}
+	t.samples[kind] = t.pruneLocked(samples, now)
+}
+
+// pruneLocked returns samples newer than the window. Must hold mu.
+func (t *latencyTracker) pruneLocked(samples []latencySample, now time.Time) []latencySample {
+	cutoff := now.Add(-t.window)
+	i := 0
+	for i < len(samples) && samples[i].at.Before(cutoff) {
+		i++
+	}
+	return samples[i:]
+}
+
+// percentiles returns the sample count, p50, and p95 for kind.
+func (t *latencyTracker) percentiles(kind string, now time.Time) (int, time.Duration, time.Duration) {
+	t.mu.Lock()
+	samples := t.pruneLocked(t.samples[kind], now)
+	t.samples[kind] = samples
+	durations := make([]time.Duration, len(samples))
+	for i, s := range samples {
+		durations[i] = s.d
+	}
+	t.mu.Unlock()
+
+	if len(durations) == 0 {
+		return 0, 0, 0
+	}
+	slices.Sort(durations)
+	return len(durations), percentile(durations, 0.50), percentile(durations, 0.95)
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.999999) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// transition records whether key is violating and reports whether it just
+// started violating. Violations are reported once until the SLO recovers.
+func (t *latencyTracker) transition(key string, violating bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	was := t.violating[key]
+	t.violating[key] = violating
+	return violating && !was
+}
+
+// activeViolations returns the currently violated SLO keys in sorted order.
+func (t *latencyTracker) activeViolations() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	keys := []string{}
+	for key, v := range t.violating {
+		if v {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// sloFor returns the configured SLO for kind.
+func (h *Harness) sloFor(kind string) SLO {
+	if kind == LatencyAPI {
+		return h.config.APILatencySLO
+	}
+	return h.config.ToolLatencySLO
+}
+
+// recordLatency adds a latency sample and emits an SLO violation for each
+// threshold the rolling percentile newly exceeds.
+func (h *Harness) recordLatency(kind string, d time.Duration) {
+	now := time.Now()
+	h.latency.record(kind, d, now)
+
+	slo := h.sloFor(kind)
+	if slo.P50 == 0 && slo.P95 == 0 {
+		return
+	}
+	_, p50, p95 := h.latency.percentiles(kind, now)
+	h.checkSLO(kind, "p50", p50, slo.P50)
+	h.checkSLO(kind, "p95", p95, slo.P95)
+}
+
+// checkSLO compares an observed percentile against its threshold.
+func (h *Harness) checkSLO(kind, pct string, observed, threshold time.Duration) {
+	if threshold == 0 {
+		return
+	}
+	if !h.latency.transition(kind+"_"+pct, observed > threshold) {
+		return
+	}
+
+	h.logger.Warn("harness", "Latency SLO violated",
+		log.F("kind", kind),
+		log.F("percentile", pct),
+		log.F("observed_ms", observed.Milliseconds()),
+		log.F("threshold_ms", threshold.Milliseconds()),
+	)
+	if sh, ok := h.handler.(SLOHandler); ok {
+		sh.OnSLOViolation(kind, pct, observed, threshold)
+	}
+}
+
+// LatencyStats returns rolling p50/p95 latencies for API turns and tool
+// executions, including those of sessions created with NewSession.
+func (h *Harness) LatencyStats() LatencyStats {
+	now := time.Now()
+	summary := func(kind string) LatencySummary {
+		count, p50, p95 := h.latency.percentiles(kind, now)
+		return LatencySummary{Count: count, P50Ms: p50.Milliseconds(), P95Ms: p95.Milliseconds()}
+	}
+	return LatencyStats{
+		WindowSeconds: int64(h.latency.window / time.Second),
+		API:           summary(LatencyAPI),
+		Tool:          summary(LatencyTool),
+		Violations:    h.latency.activeViolations(),
+	}
+}
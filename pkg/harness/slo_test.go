@@ -0,0 +1,108 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// sloRecorder extends MockEventHandler with SLOHandler support.
+type sloRecorder struct {
+	MockEventHandler
+	Violations []string
+}
+
+func (h *sloRecorder) OnSLOViolation(kind string, percentile string, observed time.Duration, threshold time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Violations = append(h.Violations, kind+"_"+percentile)
+}
+
+func TestSLO_ToolViolationReportedOnce(t *testing.T) {
+	slowTool := &MockTool{
+		name:        "slow",
+		description: "Sleeps briefly",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			time.Sleep(20 * time.Millisecond)
+			return `{"ok":true}`, nil
+		},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("t1", "slow", json.RawMessage(`{}`)).Build())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("t2", "slow", json.RawMessage(`{}`)).Build())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("done").Build())
+
+	handler := &sloRecorder{}
+	config := harness.Config{
+		Model:          "test-model",
+		ToolLatencySLO: harness.SLO{P95: 5 * time.Millisecond},
+	}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{slowTool}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+
+	if err := h.Prompt(context.Background(), "run slow twice"); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if len(handler.Violations) != 1 || handler.Violations[0] != "tool_p95" {
+		t.Errorf("expected a single tool_p95 violation, got %v", handler.Violations)
+	}
+
+	stats := h.LatencyStats()
+	if stats.Tool.Count != 2 {
+		t.Errorf("expected 2 tool samples, got %d", stats.Tool.Count)
+	}
+	if stats.API.Count != 3 {
+		t.Errorf("expected 3 API samples, got %d", stats.API.Count)
+	}
+	if stats.Tool.P95Ms < 20 {
+		t.Errorf("expected tool p95 >= 20ms, got %d", stats.Tool.P95Ms)
+	}
+	if len(stats.Violations) != 1 || stats.Violations[0] != "tool_p95" {
+		t.Errorf("expected active tool_p95 violation, got %v", stats.Violations)
+	}
+	if stats.WindowSeconds != int64(harness.DefaultSLOWindow/time.Second) {
+		t.Errorf("expected default window, got %ds", stats.WindowSeconds)
+	}
+}
+
+func TestSLO_NoThresholdsNoViolations(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("hi").Build())
+
+	handler := &sloRecorder{}
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, handler, mockStreamer)
+	if err := h.Prompt(context.Background(), "hello"); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if len(handler.Violations) != 0 {
+		t.Errorf("expected no violations, got %v", handler.Violations)
+	}
+	if got := h.LatencyStats().API.Count; got != 1 {
+		t.Errorf("expected 1 API sample, got %d", got)
+	}
+}
+
+func TestSLO_SessionsShareStats(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("one").Build())
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	session := h.NewSession(nil)
+	if err := session.Prompt(context.Background(), "hello"); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	if got := h.LatencyStats().API.Count; got != 1 {
+		t.Errorf("expected session sample in parent stats, got %d", got)
+	}
+}
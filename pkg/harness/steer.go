@@ -0,0 +1,96 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+)
+
+// ErrNotRunning is returned by Inject when no prompt is running.
+var ErrNotRunning = errors.New("no prompt is running")
+
+// Inject adds a user message to the running prompt without interrupting it.
+// The message is added to the conversation before the next API request:
+// after the tool results of the current turn, or as a new user message if
+// the model has just finished, in which case the run continues so the model
+// can respond. Messages still pending when the run stops, because it reached
+// MaxTurns, was cancelled, or was preempted, are dropped with a warning.
+func (h *Harness) Inject(ctx context.Context, content string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if content == "" {
+		return errors.New("content is required")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.running {
+		return ErrNotRunning
+	}
+	h.steering = append(h.steering, content)
+	h.logger.Info("harness", "Steering message received",
+		log.F("content_length", len(content)),
+		log.F("pending", len(h.steering)),
+	)
+	return nil
+}
+
+// steeringPending reports whether injected messages await the next turn.
+func (h *Harness) steeringPending() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.steering) > 0
+}
+
+// applySteering adds pending injected messages to the conversation as text
+// blocks of the last user message, or of a new one after an assistant
+// message, and reports them to SteeringHandler.
+func (h *Harness) applySteering() {
+	h.mu.Lock()
+	pending := h.steering
+	h.steering = nil
+	if len(pending) == 0 {
+		h.mu.Unlock()
+		return
+	}
+	blocks := make([]anthropic.ContentBlockParamUnion, len(pending))
+	for i, content := range pending {
+		blocks[i] = anthropic.NewTextBlock(content)
+	}
+	if last := len(h.messages) - 1; last >= 0 && h.messages[last].Role == anthropic.MessageParamRoleUser {
+		// Copy the message: its content may be shared with a caller of
+		// Messages
+		msg := h.messages[last]
+		msg.Content = append(append([]anthropic.ContentBlockParamUnion{}, msg.Content...), blocks...)
+		h.messages[last] = msg
+	} else {
+		h.messages = append(h.messages, anthropic.NewUserMessage(blocks...))
+		h.messageTimes = append(h.messageTimes, time.Now())
+	}
+	h.mu.Unlock()
+
+	h.logger.Info("harness", "Steering messages injected",
+		log.F("messages", len(pending)),
+	)
+	if sh, ok := h.handler.(SteeringHandler); ok {
+		for _, content := range pending {
+			sh.OnSteering(content)
+		}
+	}
+}
+
+// dropSteering discards injected messages the finished run did not use.
+func (h *Harness) dropSteering() {
+	h.mu.Lock()
+	dropped := len(h.steering)
+	h.steering = nil
+	h.mu.Unlock()
+	if dropped > 0 {
+		h.logger.Warn("harness", "Steering messages dropped",
+			log.F("messages", dropped),
+		)
+	}
+}
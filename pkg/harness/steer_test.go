@@ -0,0 +1,144 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// steeringRecorder extends MockEventHandler with SteeringHandler support.
+type steeringRecorder struct {
+	MockEventHandler
+	Steering []string
+}
+
+func (h *steeringRecorder) OnSteering(content string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Steering = append(h.Steering, content)
+}
+
+// hookedStreamer calls onRequest with the number of each API request, from 1,
+// before returning its response.
+type hookedStreamer struct {
+	*testutil.MockMessageStreamer
+	requests  int
+	onRequest func(n int)
+}
+
+func (s *hookedStreamer) NewStreaming(ctx context.Context, params anthropic.MessageNewParams) harness.StreamIterator {
+	s.requests++
+	s.onRequest(s.requests)
+	return s.MockMessageStreamer.NewStreaming(ctx, params)
+}
+
+// lastUserText returns the text blocks of the last message of a request.
+func lastUserText(params anthropic.MessageNewParams) []string {
+	var text []string
+	for _, block := range params.Messages[len(params.Messages)-1].Content {
+		if block.OfText != nil {
+			text = append(text, block.OfText.Text)
+		}
+	}
+	return text
+}
+
+func TestInject_AfterToolResults(t *testing.T) {
+	var h *harness.Harness
+	steer := &MockTool{name: "build", executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+		if err := h.Inject(ctx, "use the release profile"); err != nil {
+			t.Errorf("inject failed: %v", err)
+		}
+		return `{"ok":true}`, nil
+	}}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "build", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Rebuilt with the release profile"))
+	handler := &steeringRecorder{}
+	h, _ = harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{steer}, handler, mockStreamer)
+
+	if err := h.Prompt(context.Background(), "build it"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	// The message joins the tool results of the turn
+	params := mockStreamer.RecordedParams[1]
+	last := params.Messages[len(params.Messages)-1]
+	if len(last.Content) != 2 || last.Content[0].OfToolResult == nil {
+		t.Fatalf("expected the tool result and the injected text, got %d blocks", len(last.Content))
+	}
+	if text := lastUserText(params); len(text) != 1 || text[0] != "use the release profile" {
+		t.Errorf("unexpected injected text: %q", text)
+	}
+	if len(handler.Steering) != 1 || handler.Steering[0] != "use the release profile" {
+		t.Errorf("expected one steering event, got %q", handler.Steering)
+	}
+}
+
+func TestInject_ContinuesFinishedRun(t *testing.T) {
+	mock := testutil.NewMockMessageStreamer()
+	mock.AddResponse(testutil.TextOnlyResponse("Done in Go"))
+	mock.AddResponse(testutil.TextOnlyResponse("Done in Rust"))
+	streamer := &hookedStreamer{MockMessageStreamer: mock}
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, &MockEventHandler{}, streamer)
+	streamer.onRequest = func(n int) {
+		if n == 1 {
+			h.Inject(context.Background(), "actually, use Rust")
+		}
+	}
+
+	if err := h.Prompt(context.Background(), "write hello world"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(mock.RecordedParams) != 2 {
+		t.Fatalf("expected the run to continue for the injected message, got %d requests", len(mock.RecordedParams))
+	}
+	params := mock.RecordedParams[1]
+	if len(params.Messages) != 3 || params.Messages[2].Role != anthropic.MessageParamRoleUser {
+		t.Fatalf("expected a new user message after the answer, got %d messages", len(params.Messages))
+	}
+	if text := lastUserText(params); len(text) != 1 || text[0] != "actually, use Rust" {
+		t.Errorf("unexpected injected text: %q", text)
+	}
+	if h.LastRun().FinalText != "Done in Rust" || h.LastRun().Turns != 2 {
+		t.Errorf("unexpected result: %+v", h.LastRun())
+	}
+}
+
+func TestInject_NotRunning(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	if err := h.Inject(context.Background(), "hello"); !errors.Is(err, harness.ErrNotRunning) {
+		t.Errorf("expected ErrNotRunning, got %v", err)
+	}
+}
+
+func TestInject_DroppedAtMaxTurns(t *testing.T) {
+	mock := testutil.NewMockMessageStreamer()
+	mock.AddResponse(testutil.TextOnlyResponse("done"))
+	streamer := &hookedStreamer{MockMessageStreamer: mock}
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model", MaxTurns: 1}, nil, &MockEventHandler{}, streamer)
+	streamer.onRequest = func(int) { h.Inject(context.Background(), "one more thing") }
+
+	if err := h.Prompt(context.Background(), "go"); !errors.Is(err, harness.ErrMaxTurnsExceeded) {
+		t.Fatalf("expected ErrMaxTurnsExceeded, got %v", err)
+	}
+	if len(mock.RecordedParams) != 1 || len(h.Messages()) != 2 {
+		t.Errorf("expected the injected message to be dropped, got %d requests and %d messages", len(mock.RecordedParams), len(h.Messages()))
+	}
+
+	// The next prompt starts without it
+	mock.AddResponse(testutil.TextOnlyResponse("again"))
+	streamer.onRequest = func(int) {}
+	h.Prompt(context.Background(), "again")
+	if text := lastUserText(mock.RecordedParams[1]); len(text) != 1 || text[0] != "again" {
+		t.Errorf("expected only the new prompt, got %q", text)
+	}
+}
@@ -0,0 +1,238 @@
+package harness
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+)
+
+// ErrConversationNotFound is returned by Store.Load for an unknown conversation.
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// ErrNoStore is returned by LoadConversation when no Store is configured.
+var ErrNoStore = errors.New("no conversation store configured")
+
+// validConversationID matches IDs that are safe as file names.
+var validConversationID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// Store persists conversation histories by ID.
+type Store interface {
+	// Save replaces the stored history of conversation id.
+	Save(id string, messages []anthropic.MessageParam) error
+
+	// Load returns the stored history of conversation id, or
+	// ErrConversationNotFound.
+	Load(id string) ([]anthropic.MessageParam, error)
+}
+
+// checkConversationID validates a conversation ID.
+func checkConversationID(id string) error {
+	if !validConversationID.MatchString(id) {
+		return fmt.Errorf("invalid conversation id %q: use letters, digits, '-' and '_'", id)
+	}
+	return nil
+}
+
+// FileStore is a Store that keeps each conversation as <dir>/<id>.json.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create conversation dir: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Save writes the conversation atomically via a temp file and rename.
+func (s *FileStore) Save(id string, messages []anthropic.MessageParam) error {
+	if err := checkConversationID(id); err != nil {
+		return err
+	}
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, id+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, id+".json"))
+}
+
+// Load reads the conversation file.
+func (s *FileStore) Load(id string) ([]anthropic.MessageParam, error) {
+	if err := checkConversationID(id); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var messages []anthropic.MessageParam
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("decode conversation %s: %w", id, err)
+	}
+	return messages, nil
+}
+
+// SQLiteStore is a Store backed by a SQLite database through database/sql.
+// The harness does not import a driver: open db with one registered by the
+// caller (e.g. modernc.org/sqlite or github.com/mattn/go-sqlite3).
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates the conversations table if needed and returns a store.
+func NewSQLiteStore(db *sql.DB) (*SQLiteStore, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS conversations (
+		id         TEXT PRIMARY KEY,
+		messages   TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create conversations table: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Save upserts the conversation row.
+func (s *SQLiteStore) Save(id string, messages []anthropic.MessageParam) error {
+	if err := checkConversationID(id); err != nil {
+		return err
+	}
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO conversations (id, messages, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET messages = excluded.messages, updated_at = excluded.updated_at`,
+		id, string(data), time.Now().Unix())
+	return err
+}
+
+// Load reads the conversation row.
+func (s *SQLiteStore) Load(id string) ([]anthropic.MessageParam, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT messages FROM conversations WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var messages []anthropic.MessageParam
+	if err := json.Unmarshal([]byte(data), &messages); err != nil {
+		return nil, fmt.Errorf("decode conversation %s: %w", id, err)
+	}
+	return messages, nil
+}
+
+// SetStore persists the conversation to store under id after every turn.
+// It does not load anything; use LoadConversation to resume a stored history.
+// A nil store disables persistence.
+func (h *Harness) SetStore(store Store, id string) error {
+	if store != nil {
+		if err := checkConversationID(id); err != nil {
+			return err
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.store = store
+	h.conversationID = id
+	return nil
+}
+
+// ConversationID returns the ID the conversation is persisted under, or ""
+// without a store.
+func (h *Harness) ConversationID() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.store == nil {
+		return ""
+	}
+	return h.conversationID
+}
+
+// LoadConversation replaces the conversation with the history stored under
+// id and continues persisting under that id. The task plan and checkpoints
+// are cleared. Returns ErrNoStore without a store, ErrPromptInProgress while
+// a prompt is running, and ErrConversationNotFound for an unknown id.
+func (h *Harness) LoadConversation(id string) error {
+	h.mu.Lock()
+	store := h.store
+	running := h.running
+	h.mu.Unlock()
+	if store == nil {
+		return ErrNoStore
+	}
+	if running {
+		return ErrPromptInProgress
+	}
+
+	messages, err := store.Load(id)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	if h.running {
+		h.mu.Unlock()
+		return ErrPromptInProgress
+	}
+	h.setMessages(messages, time.Time{})
+	h.clearCheckpoints()
+	h.conversationID = id
+	h.contextTokens = 0
+	h.mu.Unlock()
+	h.clearPlan()
+
+	h.logger.Info("harness", "Conversation loaded",
+		log.F("conversation_id", id),
+		log.F("messages", len(messages)),
+	)
+	return nil
+}
+
+// saveConversation persists the current history, if a store is configured.
+// Failures are logged; they never fail the run.
+func (h *Harness) saveConversation() {
+	h.mu.Lock()
+	store, id := h.store, h.conversationID
+	messages := make([]anthropic.MessageParam, len(h.messages))
+	copy(messages, h.messages)
+	h.mu.Unlock()
+	if store == nil {
+		return
+	}
+
+	if err := store.Save(id, messages); err != nil {
+		h.logger.Warn("harness", "Failed to save conversation",
+			log.F("conversation_id", id),
+			log.F("error", err.Error()),
+		)
+	}
+}
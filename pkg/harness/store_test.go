@@ -0,0 +1,129 @@
+package harness_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestFileStore_LoadMissingAndInvalid(t *testing.T) {
+	store, err := harness.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	if _, err := store.Load("nope"); !errors.Is(err, harness.ErrConversationNotFound) {
+		t.Errorf("expected ErrConversationNotFound, got %v", err)
+	}
+	if err := store.Save("../escape", nil); err == nil {
+		t.Error("expected invalid id to be rejected")
+	}
+}
+
+func TestStore_PersistAndResume(t *testing.T) {
+	dir := t.TempDir()
+	store, err := harness.NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "test_tool", map[string]string{"value": "x"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done with x"))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{&MockTool{name: "test_tool"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.SetStore(store, "main"); err != nil {
+		t.Fatalf("SetStore failed: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "do x"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	// A fresh harness, as after a restart, resumes the stored history
+	restarted := testutil.NewMockMessageStreamer()
+	restarted.AddResponse(testutil.TextOnlyResponse("Still here"))
+	h2, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{&MockTool{name: "test_tool"}}, nil, restarted)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	h2.SetStore(store, "main")
+	if err := h2.LoadConversation("main"); err != nil {
+		t.Fatalf("LoadConversation failed: %v", err)
+	}
+	if got := len(h2.Messages()); got != 4 {
+		t.Fatalf("expected 4 restored messages, got %d", got)
+	}
+	if err := h2.Prompt(context.Background(), "still there?"); err != nil {
+		t.Fatalf("prompt after resume failed: %v", err)
+	}
+	if got := len(restarted.RecordedParams[0].Messages); got != 5 {
+		t.Errorf("expected restored history plus new prompt in request, got %d messages", got)
+	}
+
+	// The new turn was persisted too
+	msgs, err := store.Load("main")
+	if err != nil || len(msgs) != 6 {
+		t.Errorf("expected 6 stored messages, got %d, %v", len(msgs), err)
+	}
+}
+
+func TestLoadConversation_Errors(t *testing.T) {
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.LoadConversation("main"); !errors.Is(err, harness.ErrNoStore) {
+		t.Errorf("expected ErrNoStore, got %v", err)
+	}
+
+	store, _ := harness.NewFileStore(t.TempDir())
+	if err := h.SetStore(store, "bad id"); err == nil {
+		t.Error("expected invalid conversation id to be rejected")
+	}
+	h.SetStore(store, "main")
+	if err := h.LoadConversation("other"); !errors.Is(err, harness.ErrConversationNotFound) {
+		t.Errorf("expected ErrConversationNotFound, got %v", err)
+	}
+	if h.ConversationID() != "main" {
+		t.Errorf("expected conversation id unchanged after failed load, got %q", h.ConversationID())
+	}
+}
+
+func TestReset_ClearsAndPersists(t *testing.T) {
+	store, err := harness.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("First"))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Second"))
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	h.SetStore(store, "main")
+
+	if err := h.Prompt(context.Background(), "one"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if err := h.Reset(); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if got := len(h.Messages()); got != 0 {
+		t.Errorf("expected empty history after reset, got %d messages", got)
+	}
+	if msgs, err := store.Load("main"); err != nil || len(msgs) != 0 {
+		t.Errorf("expected reset to be persisted, got %d messages, %v", len(msgs), err)
+	}
+
+	// The next prompt starts a fresh conversation
+	if err := h.Prompt(context.Background(), "two"); err != nil {
+		t.Fatalf("prompt after reset failed: %v", err)
+	}
+	if got := len(mockStreamer.RecordedParams[1].Messages); got != 1 {
+		t.Errorf("expected only the new prompt in the request, got %d messages", got)
+	}
+}
@@ -0,0 +1,214 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// summaryInstruction asks the model to condense the conversation so far.
+const summaryInstruction = "Summarize this session so it can be resumed later without the full history. " +
+	"Include the user's goals, decisions made, files created or modified, commands run and their outcomes, " +
+	"and any open questions or next steps. Be concise and factual."
+
+// summaryPreamble introduces a summary that replaces earlier history.
+const summaryPreamble = "The earlier part of this session was summarized to save context:\n\n"
+
+// summaryAck is the assistant turn that follows the summary in the replaced history.
+const summaryAck = "Understood. I'll continue from this summary."
+
+// ErrEmptyConversation is returned when summarizing a conversation with no messages.
+var ErrEmptyConversation = errors.New("conversation is empty")
+
+// complete sends a single non-tool request built from messages to model and
+// returns the accumulated response. The request defines no tools, so tool
+// calls and results in messages are sent as text (see textOnly). No events
+// are emitted.
+func (h *Harness) complete(ctx context.Context, model string, system string, maxTokens int, messages []anthropic.MessageParam) (anthropic.Message, error) {
+	var systemBlocks []anthropic.TextBlockParam
+	if system != "" {
+		systemBlocks = []anthropic.TextBlockParam{{Text: system}}
+	}
+
+	stream := h.streamer.NewStreaming(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(model),
+		MaxTokens: int64(maxTokens),
+		System:    systemBlocks,
+		Messages:  textOnly(messages),
+	})
+
+	message := anthropic.Message{}
+	for stream.Next() {
+		if err := message.Accumulate(stream.Current()); err != nil {
+			return message, err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return message, err
+	}
+	h.recordUsage(h.usageFromAPI(model, message.Usage))
+	return message, nil
+}
+
+// textOnly returns messages with their tool calls and results rewritten as
+// text blocks and thinking blocks dropped. The API rejects tool_use and
+// tool_result blocks in a request that defines no tools, and a summary
+// needs what the tools did, not the blocks themselves.
+func textOnly(messages []anthropic.MessageParam) []anthropic.MessageParam {
+	names := make(map[string]string)
+	out := make([]anthropic.MessageParam, 0, len(messages))
+	for _, msg := range messages {
+		content := make([]anthropic.ContentBlockParamUnion, 0, len(msg.Content))
+		for _, block := range msg.Content {
+			switch {
+			case block.OfToolUse != nil:
+				names[block.OfToolUse.ID] = block.OfToolUse.Name
+				input, _ := json.Marshal(block.OfToolUse.Input)
+				content = append(content, anthropic.NewTextBlock(fmt.Sprintf("[Called tool %s with input %s]", block.OfToolUse.Name, input)))
+			case block.OfServerToolUse != nil:
+				input, _ := json.Marshal(block.OfServerToolUse.Input)
+				content = append(content, anthropic.NewTextBlock(fmt.Sprintf("[Called tool %s with input %s]", block.OfServerToolUse.Name, input)))
+			case block.OfToolResult != nil:
+				content = append(content, toolResultText(block.OfToolResult, names[block.OfToolResult.ToolUseID])...)
+			case block.OfWebSearchToolResult != nil:
+				var sb strings.Builder
+				sb.WriteString("[Web search results]")
+				for _, r := range block.OfWebSearchToolResult.Content.OfWebSearchToolResultBlockItem {
+					fmt.Fprintf(&sb, "\n- %s (%s)", r.Title, r.URL)
+				}
+				content = append(content, anthropic.NewTextBlock(sb.String()))
+			case block.OfThinking != nil, block.OfRedactedThinking != nil:
+			default:
+				content = append(content, block)
+			}
+		}
+		if len(content) == 0 {
+			content = append(content, anthropic.NewTextBlock("[No text]"))
+		}
+		out = append(out, anthropic.MessageParam{Role: msg.Role, Content: content})
+	}
+	return out
+}
+
+// toolResultText returns a tool result as a text block, followed by any
+// images it holds.
+func toolResultText(result *anthropic.ToolResultBlockParam, name string) []anthropic.ContentBlockParamUnion {
+	if name == "" {
+		name = result.ToolUseID
+	}
+	label := "Result of tool " + name
+	if result.IsError.Value {
+		label = "Tool " + name + " failed"
+	}
+	var texts []string
+	var images []anthropic.ContentBlockParamUnion
+	for _, c := range result.Content {
+		switch {
+		case c.OfText != nil:
+			texts = append(texts, c.OfText.Text)
+		case c.OfImage != nil:
+			images = append(images, anthropic.ContentBlockParamUnion{OfImage: c.OfImage})
+		}
+	}
+	text := fmt.Sprintf("[%s]\n%s", label, strings.Join(texts, "\n"))
+	return append([]anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(text)}, images...)
+}
+
+// messageText concatenates the text blocks of a message.
+func messageText(msg anthropic.Message) string {
+	var parts []string
+	for _, block := range msg.Content {
+		if b, ok := block.AsAny().(anthropic.TextBlock); ok {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Summarize asks the model to summarize the conversation and replaces the
+// history with the summary, so subsequent prompts re-prime from it instead of
+// the full transcript. Returns the summary text.
+// Returns ErrPromptInProgress if a prompt is running and ErrEmptyConversation
+// if there is nothing to summarize. Cancel stops a running summary, leaving
+// the history as it was.
+func (h *Harness) Summarize(ctx context.Context) (string, error) {
+	h.mu.Lock()
+	if h.running {
+		h.mu.Unlock()
+		return "", ErrPromptInProgress
+	}
+	if len(h.messages) == 0 {
+		h.mu.Unlock()
+		return "", ErrEmptyConversation
+	}
+	h.running = true
+	// Cancel stops the summary like a prompt
+	ctx, cancel := context.WithCancel(ctx)
+	h.cancelFunc = cancel
+	history := make([]anthropic.MessageParam, len(h.messages), len(h.messages)+1)
+	copy(history, h.messages)
+	h.mu.Unlock()
+
+	defer func() {
+		cancel()
+		h.mu.Lock()
+		h.running = false
+		h.cancelFunc = nil
+		h.mu.Unlock()
+	}()
+
+	history = append(history, anthropic.NewUserMessage(anthropic.NewTextBlock(summaryInstruction)))
+	message, err := h.complete(ctx, h.config.Model, h.config.SystemPrompt, h.config.MaxTokens, history)
+	if err != nil {
+		h.logger.Error("harness", "Session summary failed", log.F("error", err.Error()))
+		return "", err
+	}
+
+	summary := strings.TrimSpace(messageText(message))
+	if summary == "" {
+		return "", errors.New("model returned an empty summary")
+	}
+
+	h.mu.Lock()
+	replaced := len(h.messages)
+	h.setMessages([]anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock(summaryPreamble + summary)),
+		anthropic.NewAssistantMessage(anthropic.NewTextBlock(summaryAck)),
+	}, time.Now())
+	h.contextTokens = 0
+	h.mu.Unlock()
+	h.saveConversation()
+
+	h.logger.Info("harness", "Session summarized",
+		log.F("replaced_messages", replaced),
+		log.F("summary_length", len(summary)),
+	)
+	return summary, nil
+}
+
+// ReleaseResources releases heavyweight resources held by tools (e.g.,
+// persistent shells or indexes), and removes the temporary directory the
+// last run kept. Tools recreate their resources lazily when next used.
+func (h *Harness) ReleaseResources() {
+	h.removeKeptTempDir(context.Background())
+	for name, t := range h.tools {
+		if r, ok := t.(tool.Releaser); ok {
+			r.Release()
+			h.logger.Debug("tool", "Resources released", log.F("tool", name))
+		}
+	}
+}
+
+// IsRunning reports whether a prompt (or summary) is currently in progress.
+func (h *Harness) IsRunning() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.running
+}
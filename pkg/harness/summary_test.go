@@ -0,0 +1,170 @@
+package harness_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// releasableMockTool records Release calls.
+type releasableMockTool struct {
+	MockTool
+	released int
+}
+
+func (t *releasableMockTool) Release() { t.released++ }
+
+func TestSummarize_ReplacesHistory(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("I fixed the bug in main.go."))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("User asked to fix a bug; main.go was fixed."))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "fix the bug"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	summary, err := h.Summarize(context.Background())
+	if err != nil {
+		t.Fatalf("summarize failed: %v", err)
+	}
+	if summary != "User asked to fix a bug; main.go was fixed." {
+		t.Errorf("unexpected summary: %q", summary)
+	}
+
+	// The summary request carries the full history plus the instruction, without tools
+	req := mockStreamer.RecordedParams[1]
+	if len(req.Messages) != 3 {
+		t.Errorf("expected 3 messages in summary request, got %d", len(req.Messages))
+	}
+	if len(req.Tools) != 0 {
+		t.Errorf("summary request should not offer tools")
+	}
+
+	msgs := h.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("expected history replaced by 2 messages, got %d", len(msgs))
+	}
+	if msgs[0].Content[0].OfText == nil || !strings.Contains(msgs[0].Content[0].OfText.Text, summary) {
+		t.Error("first message should contain the summary")
+	}
+	if msgs[1].Role != "assistant" {
+		t.Errorf("second message should be an assistant acknowledgement, got %s", msgs[1].Role)
+	}
+}
+
+func TestSummarize_ToolHistorySentAsText(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "echo", map[string]string{"text": "hi"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Echoed hi."))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("The user ran echo."))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{&MockTool{name: "echo"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "echo hi"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if _, err := h.Summarize(context.Background()); err != nil {
+		t.Fatalf("summarize failed: %v", err)
+	}
+
+	req := mockStreamer.RecordedParams[2]
+	if len(req.Tools) != 0 || len(req.Messages) != 5 {
+		t.Fatalf("expected 5 messages and no tools, got %d messages and %d tools", len(req.Messages), len(req.Tools))
+	}
+	assertTextOnly(t, req)
+	if text := paramText(req.Messages[1]); text != `[Called tool echo with input {"text":"hi"}]` {
+		t.Errorf("expected the tool call as text, got %q", text)
+	}
+	if text := paramText(req.Messages[2]); text != `[Result of tool echo]`+"\n"+`{"result":"mock result"}` {
+		t.Errorf("expected the tool result as text, got %q", text)
+	}
+}
+
+// blockingStreamer holds its request number block until the request's
+// context is done, and fails it with the context's error.
+type blockingStreamer struct {
+	*testutil.MockMessageStreamer
+	requests int
+	block    int
+	started  chan struct{}
+}
+
+func (s *blockingStreamer) NewStreaming(ctx context.Context, params anthropic.MessageNewParams) harness.StreamIterator {
+	s.requests++
+	if s.requests == s.block {
+		close(s.started)
+		<-ctx.Done()
+		return testutil.ErrorResponse(ctx.Err())
+	}
+	return s.MockMessageStreamer.NewStreaming(ctx, params)
+}
+
+func TestSummarize_Cancel(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done."))
+	streamer := &blockingStreamer{MockMessageStreamer: mockStreamer, block: 2, started: make(chan struct{})}
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, streamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "hello"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := h.Summarize(context.Background())
+		done <- err
+	}()
+	<-streamer.started
+	h.Cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancel did not stop the summary")
+	}
+	if h.IsRunning() || len(h.Messages()) != 2 {
+		t.Errorf("expected the history kept and the harness idle, got %d messages, running %v", len(h.Messages()), h.IsRunning())
+	}
+}
+
+func TestSummarize_EmptyConversation(t *testing.T) {
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if _, err := h.Summarize(context.Background()); !errors.Is(err, harness.ErrEmptyConversation) {
+		t.Errorf("expected ErrEmptyConversation, got %v", err)
+	}
+}
+
+func TestReleaseResources(t *testing.T) {
+	rt := &releasableMockTool{MockTool: MockTool{name: "bash"}}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"},
+		[]tool.Tool{rt, &MockTool{name: "read"}}, nil, testutil.NewMockMessageStreamer())
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	h.ReleaseResources()
+	if rt.released != 1 {
+		t.Errorf("expected Release to be called once, got %d", rt.released)
+	}
+}
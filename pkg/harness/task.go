@@ -0,0 +1,242 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// TaskToolName is the name of the tool returned by NewTaskTool.
+const TaskToolName = "task"
+
+// TaskToolCallEventType is the custom tool event the task tool emits for
+// each tool call of its sub-agent.
+const TaskToolCallEventType = "task_tool_call"
+
+// sessionKey is the context key for the harness executing a tool call.
+type sessionKey struct{}
+
+// TaskTool delegates a prompt to a sub-agent: a new session of the harness
+// running the tool, with its own conversation, tools, and turn budget. Only
+// the sub-agent's final answer is returned, so exploratory work stays out of
+// the parent's context window.
+type TaskTool struct{}
+
+// taskInput defines the expected input parameters for the task tool.
+type taskInput struct {
+	Prompt      string   `json:"prompt"`
+	Description string   `json:"description"`
+	Tools       []string `json:"tools"`
+	ReadOnly    bool     `json:"read_only"`
+	MaxTurns    int      `json:"max_turns"`
+}
+
+// taskOutput defines the success response format.
+type taskOutput struct {
+	Result     string `json:"result"`
+	StopReason string `json:"stop_reason"`
+	Turns      int    `json:"turns"`
+	Usage      Usage  `json:"usage"`
+}
+
+// taskError defines the error response format.
+type taskError struct {
+	Error string `json:"error"`
+}
+
+// NewTaskTool creates a new TaskTool instance. It works only when executed
+// by a Harness.
+func NewTaskTool() *TaskTool {
+	return &TaskTool{}
+}
+
+// Name returns the tool identifier.
+func (t *TaskTool) Name() string {
+	return TaskToolName
+}
+
+// Description returns a human-readable description of the tool.
+func (t *TaskTool) Description() string {
+	return "Delegate a self-contained task to a sub-agent with a fresh conversation, such as searching a large codebase or investigating a question, and get back only its final answer. The sub-agent cannot see this conversation, so the prompt must include everything it needs and say what to report. Restrict its tools, or make it read-only, when the task should not change anything"
+}
+
+// Timeout disables the harness's time limit for the tool: a sub-agent runs
+// for several turns, and each of its own tool calls is limited.
+func (t *TaskTool) Timeout() time.Duration {
+	return -1
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *TaskTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"prompt": {"type": "string", "description": "Complete instructions for the sub-agent, including what its final answer should contain"},
+			"description": {"type": "string", "description": "Short label for the task, used in logs"},
+			"tools": {"type": "array", "items": {"type": "string"}, "description": "Names of the tools the sub-agent may use (default: all of yours)"},
+			"read_only": {"type": "boolean", "description": "Only give the sub-agent read-only tools (default false)"},
+			"max_turns": {"type": "integer", "description": "Maximum number of sub-agent turns (default and maximum: the configured task turn limit)"}
+		},
+		"required": ["prompt"]
+	}`)
+}
+
+// Execute runs the prompt in a sub-agent session and returns its final text.
+func (t *TaskTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params taskInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatTaskError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	parent, ok := ctx.Value(sessionKey{}).(*Harness)
+	if !ok {
+		return formatTaskError("the task tool must be run by a harness"), nil
+	}
+	if strings.TrimSpace(params.Prompt) == "" {
+		return formatTaskError("prompt is required"), nil
+	}
+	if params.MaxTurns < 0 {
+		return formatTaskError("max_turns must be at least 1"), nil
+	}
+	tools, err := parent.taskTools(params.Tools, params.ReadOnly)
+	if err != nil {
+		return formatTaskError(err.Error()), nil
+	}
+
+	if err := parent.checkBudget(); err != nil {
+		return formatTaskError(err.Error()), nil
+	}
+
+	child := parent.newTaskSession(tools, params.MaxTurns, &taskEventHandler{ctx: ctx})
+	parent.taskBudget(child)
+	parent.logger.Info("harness", "Task started",
+		log.F("description", params.Description),
+		log.F("tools", len(tools)),
+		log.F("max_turns", child.config.MaxTurns),
+	)
+	err = child.Prompt(ctx, params.Prompt)
+	result := child.LastRun()
+	parent.addTaskUsage(result.Usage)
+	// A sub-agent out of turns or budget still reports its progress, with
+	// stop reason max_turns or budget_exceeded
+	if err != nil && !errors.Is(err, ErrMaxTurnsExceeded) && !errors.Is(err, ErrBudgetExceeded) {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return formatTaskError("task failed: " + err.Error()), nil
+	}
+	parent.logger.Info("harness", "Task finished",
+		log.F("description", params.Description),
+		log.F("turns", result.Turns),
+		log.F("stop_reason", result.StopReason),
+	)
+
+	data, _ := json.Marshal(taskOutput{
+		Result:     result.FinalText,
+		StopReason: result.StopReason,
+		Turns:      result.Turns,
+		Usage:      result.Usage,
+	})
+	return string(data), nil
+}
+
+// taskTools returns the tools for a sub-agent: the named ones, or all of
+// h's, never including the task tool itself or tools the running prompt may
+// not use.
+func (h *Harness) taskTools(names []string, readOnly bool) ([]tool.Tool, error) {
+	var tools []tool.Tool
+	if names == nil {
+		for name, t := range h.tools {
+			if name != TaskToolName && h.toolAllowed(name) {
+				tools = append(tools, t)
+			}
+		}
+		slices.SortFunc(tools, func(a, b tool.Tool) int { return strings.Compare(a.Name(), b.Name()) })
+	} else {
+		for _, name := range names {
+			t, ok := h.tools[name]
+			if !ok || name == TaskToolName || !h.toolAllowed(name) {
+				return nil, fmt.Errorf("unknown tool %q for a task", name)
+			}
+			if !slices.Contains(tools, t) {
+				tools = append(tools, t)
+			}
+		}
+	}
+	if readOnly {
+		tools = slices.DeleteFunc(tools, func(t tool.Tool) bool { return !tool.IsReadOnly(t) })
+	}
+	return tools, nil
+}
+
+// newTaskSession returns a session like NewSession that offers only tools
+// and runs at most maxTurns turns, capped by Config.TaskMaxTurns.
+func (h *Harness) newTaskSession(tools []tool.Tool, maxTurns int, handler EventHandler) *Harness {
+	session := h.NewSession(handler)
+	limit := session.config.TaskMaxTurns
+	if limit <= 0 {
+		limit = session.config.MaxTurns
+	}
+	if maxTurns <= 0 || maxTurns > limit {
+		maxTurns = limit
+	}
+	session.config.MaxTurns = maxTurns
+	// The parent's checkpoints cover the sub-agent's file changes
+	session.config.Checkpoints = 0
+
+	session.tools = make(map[string]tool.Tool, len(tools))
+	session.toolParams = make([]anthropic.ToolUnionParam, 0, len(tools))
+	for _, t := range tools {
+		session.tools[t.Name()] = t
+		session.toolParams = append(session.toolParams, toolToParam(t))
+	}
+	session.toolParams = append(session.toolParams, serverToolParams(session.config)...)
+	return session
+}
+
+// addTaskUsage adds a sub-agent's usage to the running prompt and session
+// totals.
+func (h *Harness) addTaskUsage(u Usage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.result.Usage = h.result.Usage.Add(u)
+	h.usage = h.usage.Add(u)
+}
+
+// taskEventHandler reports a sub-agent's tool calls as custom events of the
+// task tool call.
+type taskEventHandler struct {
+	ctx context.Context
+}
+
+func (h *taskEventHandler) OnText(text string) {}
+
+func (h *taskEventHandler) OnToolCall(id string, name string, input json.RawMessage) {
+	tool.Emit(h.ctx, TaskToolCallEventType, map[string]any{"id": id, "name": name, "input": input})
+}
+
+func (h *taskEventHandler) OnToolResult(id string, result string, isError bool) {}
+
+func (h *taskEventHandler) OnReasoning(content string) {}
+
+// formatTaskError formats an error response.
+func formatTaskError(msg string) string {
+	output := taskError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
@@ -0,0 +1,115 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestTaskTool_RunsSubAgent(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	// Parent delegates, the sub-agent searches and answers, the parent finishes
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "task", map[string]any{
+		"prompt":    "Find where the config is parsed and report the file",
+		"read_only": true,
+	}))
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddToolUse("sub_1", "search", map[string]string{"value": "config"}).
+		WithUsage(100, 10).
+		BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("config.go parses it").WithUsage(200, 20).Build())
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("The config is parsed in config.go."))
+
+	handler := &toolEventRecorder{}
+	search := &readOnlyMockTool{MockTool{name: "search"}}
+	write := &MockTool{name: "write"}
+	h, err := harness.NewHarnessWithStreamer(
+		harness.Config{Model: "test-model", MaxTurns: 10, TaskMaxTurns: 3},
+		[]tool.Tool{search, write, harness.NewTaskTool()},
+		handler,
+		mockStreamer,
+	)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "where is the config parsed?"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	// The parent sees only the sub-agent's final answer
+	if len(handler.ToolResults) != 1 {
+		t.Fatalf("expected 1 parent tool result, got %d", len(handler.ToolResults))
+	}
+	var out struct {
+		Result     string        `json:"result"`
+		StopReason string        `json:"stop_reason"`
+		Turns      int           `json:"turns"`
+		Usage      harness.Usage `json:"usage"`
+	}
+	json.Unmarshal([]byte(handler.ToolResults[0].Result), &out)
+	if out.Result != "config.go parses it" || out.StopReason != "end_turn" || out.Turns != 2 || out.Usage.InputTokens != 300 {
+		t.Errorf("unexpected task result: %s", handler.ToolResults[0].Result)
+	}
+	if len(h.Messages()) != 4 {
+		t.Errorf("sub-agent messages leaked into the parent: %d messages", len(h.Messages()))
+	}
+
+	// The sub-agent was offered only read-only tools, never the task tool
+	sub := mockStreamer.RecordedParams[1]
+	if len(sub.Tools) != 1 || sub.Tools[0].OfTool.Name != "search" {
+		t.Errorf("unexpected sub-agent tools: %+v", sub.Tools)
+	}
+	if len(handler.Events) != 1 || handler.Events[0].Type != harness.TaskToolCallEventType || !strings.Contains(handler.Events[0].Payload, `"name":"search"`) {
+		t.Errorf("unexpected task events: %+v", handler.Events)
+	}
+
+	// The sub-agent's usage counts toward the parent's totals
+	if usage := h.LastRun().Usage; usage.InputTokens != 300 {
+		t.Errorf("expected sub-agent usage in the run, got %+v", usage)
+	}
+}
+
+func TestTaskTool_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  map[string]any
+		errMsg string
+	}{
+		{"missing prompt", map[string]any{"prompt": " "}, "prompt is required"},
+		{"unknown tool", map[string]any{"prompt": "x", "tools": []string{"nope"}}, `unknown tool "nope"`},
+		{"nested task", map[string]any{"prompt": "x", "tools": []string{"task"}}, `unknown tool "task"`},
+		{"negative turns", map[string]any{"prompt": "x", "max_turns": -1}, "max_turns must be at least 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStreamer := testutil.NewMockMessageStreamer()
+			mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "task", tt.input))
+			mockStreamer.AddResponse(testutil.TextOnlyResponse("ok"))
+			handler := &MockEventHandler{}
+			h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{harness.NewTaskTool()}, handler, mockStreamer)
+			if err := h.Prompt(context.Background(), "go"); err != nil {
+				t.Fatalf("prompt failed: %v", err)
+			}
+			if len(handler.ToolResults) != 1 {
+				t.Fatalf("expected 1 tool result, got %d", len(handler.ToolResults))
+			}
+			var out struct{ Error string }
+			json.Unmarshal([]byte(handler.ToolResults[0].Result), &out)
+			if !strings.Contains(out.Error, tt.errMsg) {
+				t.Errorf("expected error containing %q, got %s", tt.errMsg, handler.ToolResults[0].Result)
+			}
+		})
+	}
+
+	// Outside a harness
+	result, _ := harness.NewTaskTool().Execute(context.Background(), json.RawMessage(`{"prompt": "x"}`))
+	if !strings.Contains(result, "must be run by a harness") {
+		t.Errorf("unexpected result: %s", result)
+	}
+}
@@ -0,0 +1,80 @@
+package harness
+
+import (
+	"context"
+	"os"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// startTempDir creates the run's temporary directory and attaches it to ctx.
+// If the directory cannot be created the run proceeds without one.
+func (h *Harness) startTempDir(ctx context.Context) (context.Context, string) {
+	dir, err := os.MkdirTemp("", "harness-run-*")
+	if err != nil {
+		log.ForContext(h.logger, ctx).Warn("harness", "Failed to create run temp dir",
+			log.F("error", err.Error()),
+		)
+		return ctx, ""
+	}
+	return tool.WithTempDir(ctx, dir), dir
+}
+
+// finishTempDir removes the run's temporary directory and its distilled
+// output artifacts, unless a tool marked the directory as holding artifacts
+// to keep.
+func (h *Harness) finishTempDir(ctx context.Context, dir string) {
+	if dir == "" {
+		return
+	}
+	if tool.TempDirKept(ctx) {
+		h.mu.Lock()
+		h.keptTempDir = dir
+		h.mu.Unlock()
+		log.ForContext(h.logger, ctx).Info("harness", "Run temp dir kept",
+			log.F("path", dir),
+		)
+		return
+	}
+	h.removeRunDirs(ctx, dir, "Failed to remove run temp dir")
+}
+
+// removeKeptTempDir removes the temporary directory the most recent run
+// kept, if any. A kept directory lasts until the next run starts, the
+// conversation is reset, or resources are released.
+func (h *Harness) removeKeptTempDir(ctx context.Context) {
+	h.mu.Lock()
+	dir := h.keptTempDir
+	h.keptTempDir = ""
+	h.mu.Unlock()
+	if dir == "" {
+		return
+	}
+	h.removeRunDirs(ctx, dir, "Failed to remove kept run temp dir")
+}
+
+// removeRunDirs removes a run's temporary directory and, when ArtifactDir is
+// set, the run's subdirectory there.
+func (h *Harness) removeRunDirs(ctx context.Context, dir string, failure string) {
+	dirs := []string{dir}
+	if h.config.ArtifactDir != "" {
+		dirs = append(dirs, h.runArtifactDir(dir))
+	}
+	for _, d := range dirs {
+		if err := os.RemoveAll(d); err != nil {
+			log.ForContext(h.logger, ctx).Warn("harness", failure,
+				log.F("path", d),
+				log.F("error", err.Error()),
+			)
+		}
+	}
+}
+
+// KeptTempDir returns the temporary directory of the most recent run if a
+// tool marked it to keep, or "" if it was removed.
+func (h *Harness) KeptTempDir() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.keptTempDir
+}
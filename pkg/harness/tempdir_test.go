@@ -0,0 +1,96 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// runTempDirPrompt runs one tool call with a tool that writes a scratch file
+// into the run temp dir, optionally keeping it. It returns the dir seen by
+// the tool.
+func runTempDirPrompt(t *testing.T, keep bool) (*harness.Harness, string) {
+	t.Helper()
+	var seen string
+	scratch := &MockTool{
+		name: "scratch",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			seen = tool.TempDir(ctx)
+			if err := os.WriteFile(filepath.Join(seen, "out.txt"), []byte("intermediate"), 0644); err != nil {
+				return "", err
+			}
+			if keep {
+				tool.KeepTempDir(ctx)
+			}
+			return `{"ok":true}`, nil
+		},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "scratch", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done."))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{scratch}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "go"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if seen == "" {
+		t.Fatal("expected tool to receive a temp dir")
+	}
+	return h, seen
+}
+
+func TestTempDir_RemovedAtRunEnd(t *testing.T) {
+	h, dir := runTempDirPrompt(t, false)
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected temp dir %s removed, stat err: %v", dir, err)
+	}
+	if kept := h.KeptTempDir(); kept != "" {
+		t.Errorf("expected no kept temp dir, got %q", kept)
+	}
+}
+
+func TestTempDir_KeptWhenMarked(t *testing.T) {
+	h, dir := runTempDirPrompt(t, true)
+	defer os.RemoveAll(dir)
+
+	if kept := h.KeptTempDir(); kept != dir {
+		t.Errorf("expected kept temp dir %q, got %q", dir, kept)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "out.txt")); err != nil || string(data) != "intermediate" {
+		t.Errorf("expected artifact kept, got %q, %v", data, err)
+	}
+}
+
+func TestTempDir_KeptUntilNextRunOrReset(t *testing.T) {
+	h, dir := runTempDirPrompt(t, true)
+	defer os.RemoveAll(dir)
+
+	// The next run removes the directory the previous one kept
+	h.Prompt(context.Background(), "again")
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected kept temp dir %s removed by the next run, stat err: %v", dir, err)
+	}
+
+	h, dir = runTempDirPrompt(t, true)
+	defer os.RemoveAll(dir)
+	if err := h.Reset(); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected kept temp dir %s removed by Reset, stat err: %v", dir, err)
+	}
+	if kept := h.KeptTempDir(); kept != "" {
+		t.Errorf("expected no kept temp dir after Reset, got %q", kept)
+	}
+}
@@ -0,0 +1,50 @@
+package harness_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+)
+
+// textDeltaRecorder extends MockEventHandler with TextDeltaHandler support.
+type textDeltaRecorder struct {
+	MockEventHandler
+	Deltas  []string
+	Indexes []int
+}
+
+func (h *textDeltaRecorder) OnTextDelta(blockIndex int, delta string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Deltas = append(h.Deltas, delta)
+	h.Indexes = append(h.Indexes, blockIndex)
+}
+
+func TestTextDelta_StreamedBeforeCompleteBlock(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextDeltaResponse("Hel", "lo, ", "world"))
+
+	handler := &textDeltaRecorder{}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "greet"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(handler.Deltas) != 3 || strings.Join(handler.Deltas, "") != "Hello, world" {
+		t.Errorf("unexpected deltas %q", handler.Deltas)
+	}
+	for _, idx := range handler.Indexes {
+		if idx != 0 {
+			t.Errorf("expected block index 0, got %d", idx)
+		}
+	}
+	if len(handler.TextEvents) != 1 || handler.TextEvents[0] != "Hello, world" {
+		t.Errorf("expected complete text block after deltas, got %q", handler.TextEvents)
+	}
+}
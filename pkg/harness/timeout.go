@@ -0,0 +1,71 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// ErrToolTimeout is returned for a tool call that exceeded its time limit.
+var ErrToolTimeout = errors.New("tool timed out")
+
+// toolTimeout returns the time limit of t: its own, if it implements
+// tool.TimeoutProvider, or Config.ToolTimeout. Zero means no limit.
+func (h *Harness) toolTimeout(t tool.Tool) time.Duration {
+	if tp, ok := t.(tool.TimeoutProvider); ok {
+		if d := tp.Timeout(); d != 0 {
+			return max(d, 0)
+		}
+	}
+	return h.config.ToolTimeout
+}
+
+// runWithTimeout runs a tool call through execute, cancelling its context
+// after timeout. A tool that ignores the cancellation is abandoned, so a hung
+// tool cannot block the prompt; its eventual result is discarded.
+func (h *Harness) runWithTimeout(ctx context.Context, call ToolCall, timeout time.Duration, execute func(context.Context) (string, *tool.Display, error)) (string, *tool.Display, error) {
+	if timeout <= 0 {
+		return execute(ctx)
+	}
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result  string
+		display *tool.Display
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, display, err := execute(callCtx)
+		done <- outcome{result, display, err}
+	}()
+
+	select {
+	case out := <-done:
+		// A tool that failed once the deadline passed failed because of it
+		if errors.Is(callCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil && (out.err != nil || tool.IsErrorResult(out.result)) {
+			return "", nil, h.toolTimedOut(ctx, call, timeout)
+		}
+		return out.result, out.display, out.err
+	case <-callCtx.Done():
+		if err := ctx.Err(); err != nil {
+			return "", nil, err
+		}
+		return "", nil, h.toolTimedOut(ctx, call, timeout)
+	}
+}
+
+// toolTimedOut logs and returns the timeout error of call.
+func (h *Harness) toolTimedOut(ctx context.Context, call ToolCall, timeout time.Duration) error {
+	log.ForContext(h.logger, ctx).Warn("tool", "Execution timed out",
+		log.F("tool", call.Name),
+		log.F("id", call.ID),
+		log.F("timeout_ms", timeout.Milliseconds()),
+	)
+	return fmt.Errorf("%w: %s did not finish within %s", ErrToolTimeout, call.Name, timeout)
+}
@@ -0,0 +1,81 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// timeoutMockTool is a MockTool with its own time limit.
+type timeoutMockTool struct {
+	MockTool
+	timeout time.Duration
+}
+
+func (t *timeoutMockTool) Timeout() time.Duration { return t.timeout }
+
+// runTimeoutPrompt runs one call of tl and returns its tool result.
+func runTimeoutPrompt(t *testing.T, config harness.Config, tl tool.Tool) (string, bool) {
+	t.Helper()
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", tl.Name(), map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+
+	handler := &MockEventHandler{}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{tl}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "go"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	if len(handler.ToolResults) != 1 {
+		t.Fatalf("expected 1 tool result, got %d", len(handler.ToolResults))
+	}
+	return handler.ToolResults[0].Result, handler.ToolResults[0].IsError
+}
+
+func TestToolTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	hung := func(ctx context.Context, input json.RawMessage) (string, error) {
+		<-release // ignores ctx
+		return `{"ok":true}`, nil
+	}
+	cooperative := func(ctx context.Context, input json.RawMessage) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	slow := func(ctx context.Context, input json.RawMessage) (string, error) {
+		time.Sleep(100 * time.Millisecond)
+		return `{"ok":true}`, nil
+	}
+
+	tests := []struct {
+		name    string
+		timeout time.Duration // harness default
+		tool    tool.Tool
+		want    string
+		isError bool
+	}{
+		{"hung tool is abandoned", 50 * time.Millisecond, &MockTool{name: "hung", executeFunc: hung}, "tool timed out: hung did not finish within 50ms", true},
+		{"cancelled tool", 50 * time.Millisecond, &MockTool{name: "wait", executeFunc: cooperative}, "tool timed out: wait", true},
+		{"tool overrides default", 0, &timeoutMockTool{MockTool{name: "wait", executeFunc: cooperative}, 20 * time.Millisecond}, "did not finish within 20ms", true},
+		{"tool disables limit", 20 * time.Millisecond, &timeoutMockTool{MockTool{name: "slow", executeFunc: slow}, -1}, `{"ok":true}`, false},
+		{"fast tool", time.Second, &MockTool{name: "fast"}, "mock result", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, isError := runTimeoutPrompt(t, harness.Config{Model: "test-model", ToolTimeout: tt.timeout}, tt.tool)
+			if isError != tt.isError || !strings.Contains(result, tt.want) {
+				t.Errorf("expected result containing %q (error %v), got %q (error %v)", tt.want, tt.isError, result, isError)
+			}
+		})
+	}
+}
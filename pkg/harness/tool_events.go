@@ -0,0 +1,83 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// toolEmitter returns an Emitter that forwards a tool's custom events to the
+// event handler, tagged with the tool call ID.
+func (h *Harness) toolEmitter(ctx context.Context, call ToolCall) tool.Emitter {
+	logger := log.ForContext(h.logger, ctx)
+	return tool.EmitterFunc(func(eventType string, payload any) {
+		if eventType == tool.OutputEventType {
+			h.emitToolOutput(ctx, call, payload)
+			return
+		}
+		if eventType == tool.PlanEventType {
+			h.emitPlan(payload)
+			return
+		}
+		if len(h.config.ToolEventTypes) > 0 && !slices.Contains(h.config.ToolEventTypes, eventType) {
+			logger.Debug("tool", "Custom event dropped",
+				log.F("tool", call.Name),
+				log.F("id", call.ID),
+				log.F("event_type", eventType),
+			)
+			return
+		}
+
+		data, err := json.Marshal(payload)
+		if err != nil {
+			logger.Warn("tool", "Custom event payload not serializable",
+				log.F("tool", call.Name),
+				log.F("id", call.ID),
+				log.F("event_type", eventType),
+				log.F("error", err.Error()),
+			)
+			return
+		}
+
+		if th, ok := h.handler.(ToolEventHandler); ok {
+			th.OnToolEvent(call.ID, eventType, data)
+		}
+	})
+}
+
+// emitToolOutput forwards a chunk of a tool's process output to the event handler.
+func (h *Harness) emitToolOutput(ctx context.Context, call ToolCall, payload any) {
+	out, ok := payload.(tool.Output)
+	if !ok {
+		log.ForContext(h.logger, ctx).Warn("tool", "Output event has unexpected payload",
+			log.F("tool", call.Name),
+			log.F("id", call.ID),
+		)
+		return
+	}
+	if oh, ok := h.handler.(ToolOutputHandler); ok {
+		oh.OnToolOutput(call.ID, out.Stream, out.Text)
+	}
+}
+
+// emitToolDisplay forwards a tool's display hint to the event handler.
+func (h *Harness) emitToolDisplay(ctx context.Context, call ToolCall, display *tool.Display) {
+	dh, ok := h.handler.(ToolDisplayHandler)
+	if !ok {
+		return
+	}
+	payload, err := json.Marshal(display.Payload)
+	if err != nil {
+		log.ForContext(h.logger, ctx).Warn("tool", "Display payload not serializable",
+			log.F("tool", call.Name),
+			log.F("id", call.ID),
+			log.F("kind", display.Kind),
+			log.F("error", err.Error()),
+		)
+		return
+	}
+	dh.OnToolDisplay(call.ID, display.Kind, payload)
+}
@@ -0,0 +1,202 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// toolEventRecorder extends MockEventHandler with ToolEventHandler support.
+type toolEventRecorder struct {
+	MockEventHandler
+	Events []struct{ ID, Type, Payload string }
+}
+
+func (h *toolEventRecorder) OnToolEvent(id string, eventType string, payload json.RawMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Events = append(h.Events, struct{ ID, Type, Payload string }{id, eventType, string(payload)})
+}
+
+// testRunnerTool emits one test_result event per test plus a noisy debug event.
+func testRunnerTool() *MockTool {
+	return &MockTool{
+		name: "run_tests",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			tool.Emit(ctx, "test_result", map[string]any{"name": "TestA", "passed": true})
+			tool.Emit(ctx, "debug", "ignored unless allowed")
+			tool.Emit(ctx, "test_result", map[string]any{"name": "TestB", "passed": false})
+			return `{"passed":1,"failed":1}`, nil
+		},
+	}
+}
+
+func runToolEventPrompt(t *testing.T, config harness.Config) *toolEventRecorder {
+	t.Helper()
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "run_tests", map[string]string{"value": "./..."}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("One test failed."))
+
+	handler := &toolEventRecorder{}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{testRunnerTool()}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "run the tests"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	return handler
+}
+
+func TestToolEvents_ForwardedWithCallID(t *testing.T) {
+	handler := runToolEventPrompt(t, harness.Config{Model: "test-model"})
+
+	if len(handler.Events) != 3 {
+		t.Fatalf("expected 3 tool events, got %d", len(handler.Events))
+	}
+	for _, e := range handler.Events {
+		if e.ID != "call_1" {
+			t.Errorf("expected tool call ID 'call_1', got %q", e.ID)
+		}
+	}
+	if handler.Events[0].Type != "test_result" || handler.Events[0].Payload != `{"name":"TestA","passed":true}` {
+		t.Errorf("unexpected first event: %+v", handler.Events[0])
+	}
+}
+
+func TestToolEvents_AllowlistFiltersTypes(t *testing.T) {
+	handler := runToolEventPrompt(t, harness.Config{
+		Model:          "test-model",
+		ToolEventTypes: []string{"test_result"},
+	})
+
+	if len(handler.Events) != 2 {
+		t.Fatalf("expected 2 allowed tool events, got %d", len(handler.Events))
+	}
+	for _, e := range handler.Events {
+		if e.Type != "test_result" {
+			t.Errorf("unexpected event type %q passed the allowlist", e.Type)
+		}
+	}
+}
+
+// displayRecorder extends MockEventHandler with ToolDisplayHandler support.
+type displayRecorder struct {
+	MockEventHandler
+	Displays []struct{ ID, Kind, Payload string }
+}
+
+func (h *displayRecorder) OnToolDisplay(id string, kind string, payload json.RawMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Displays = append(h.Displays, struct{ ID, Kind, Payload string }{id, kind, string(payload)})
+}
+
+// displayMockTool returns a table display alongside its result.
+type displayMockTool struct {
+	MockTool
+}
+
+func (t *displayMockTool) ExecuteWithDisplay(ctx context.Context, input json.RawMessage) (string, *tool.Display, error) {
+	return `{"rows":2}`, &tool.Display{
+		Kind:    "table",
+		Payload: map[string]any{"columns": []string{"name"}, "rows": [][]string{{"a"}, {"b"}}},
+	}, nil
+}
+
+func TestToolDisplay_ForwardedToHandlerOnly(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "query", map[string]string{"value": "x"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Two rows."))
+
+	handler := &displayRecorder{}
+	h, err := harness.NewHarnessWithStreamer(
+		harness.Config{Model: "test-model"},
+		[]tool.Tool{&displayMockTool{MockTool{name: "query"}}},
+		handler,
+		mockStreamer,
+	)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "query"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(handler.Displays) != 1 {
+		t.Fatalf("expected 1 display event, got %d", len(handler.Displays))
+	}
+	d := handler.Displays[0]
+	if d.ID != "call_1" || d.Kind != "table" {
+		t.Errorf("unexpected display event: %+v", d)
+	}
+	if d.Payload != `{"columns":["name"],"rows":[["a"],["b"]]}` {
+		t.Errorf("unexpected display payload: %s", d.Payload)
+	}
+
+	// Only the model-facing string is sent back to the model
+	if len(handler.ToolResults) != 1 || handler.ToolResults[0].Result != `{"rows":2}` {
+		t.Errorf("unexpected tool results: %+v", handler.ToolResults)
+	}
+	msgs := h.Messages()
+	resultJSON, _ := json.Marshal(msgs[2])
+	if strings.Contains(string(resultJSON), "columns") {
+		t.Error("display payload must not be sent to the model")
+	}
+}
+
+// outputRecorder extends toolEventRecorder with ToolOutputHandler support.
+type outputRecorder struct {
+	toolEventRecorder
+	Output []struct{ ID, Stream, Text string }
+}
+
+func (h *outputRecorder) OnToolOutput(id string, stream string, text string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Output = append(h.Output, struct{ ID, Stream, Text string }{id, stream, text})
+}
+
+func TestToolOutput_ForwardedToOutputHandler(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "build", map[string]string{"value": "all"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Built."))
+
+	build := &MockTool{
+		name: "build",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			tool.EmitOutput(ctx, "stdout", "compiling\n")
+			tool.EmitOutput(ctx, "stderr", "warning: unused\n")
+			return `{"exitCode":0}`, nil
+		},
+	}
+
+	handler := &outputRecorder{}
+	// The allowlist applies to custom events only, not to process output
+	config := harness.Config{Model: "test-model", ToolEventTypes: []string{"test_result"}}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{build}, handler, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "build it"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	if len(handler.Output) != 2 {
+		t.Fatalf("expected 2 output chunks, got %+v", handler.Output)
+	}
+	if o := handler.Output[0]; o.ID != "call_1" || o.Stream != "stdout" || o.Text != "compiling\n" {
+		t.Errorf("unexpected first chunk: %+v", o)
+	}
+	if o := handler.Output[1]; o.Stream != "stderr" || o.Text != "warning: unused\n" {
+		t.Errorf("unexpected second chunk: %+v", o)
+	}
+	if len(handler.Events) != 0 {
+		t.Errorf("output must not be delivered as custom tool events, got %+v", handler.Events)
+	}
+}
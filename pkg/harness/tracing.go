@@ -0,0 +1,98 @@
+package harness
+
+import (
+	"context"
+	"errors"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the harness's spans.
+const tracerName = "github.com/user/harness/pkg/harness"
+
+// Span attributes. API and tool spans follow the OpenTelemetry GenAI
+// semantic conventions.
+const (
+	attrRunID         = "harness.run_id"
+	attrTurn          = "harness.turn"
+	attrTurns         = "harness.turns"
+	attrAttempt       = "harness.attempt"
+	attrStopReason    = "harness.stop_reason"
+	attrGenAISystem   = "gen_ai.system"
+	attrGenAIOp       = "gen_ai.operation.name"
+	attrModel         = "gen_ai.request.model"
+	attrInputTokens   = "gen_ai.usage.input_tokens"
+	attrOutputTokens  = "gen_ai.usage.output_tokens"
+	attrFinishReasons = "gen_ai.response.finish_reasons"
+	attrToolName      = "gen_ai.tool.name"
+	attrToolCallID    = "gen_ai.tool.call.id"
+)
+
+// startSpan starts a span with the global TracerProvider, which records
+// nothing unless one has been installed. The provider is looked up on every
+// call so one installed after the harness was created is used.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, marking it failed if err is not nil. A cancelled run
+// is not a failure.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, context.Canceled) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// startRunSpan starts the span covering a whole run.
+func (h *Harness) startRunSpan(ctx context.Context) (context.Context, trace.Span) {
+	return startSpan(ctx, "harness.run",
+		attribute.String(attrRunID, log.RunID(ctx)),
+		attribute.String(attrModel, h.model()),
+	)
+}
+
+// startTurnSpan starts the span covering turn n, from 1: the API request
+// and the tool calls of its response.
+func startTurnSpan(ctx context.Context, n int) (context.Context, trace.Span) {
+	return startSpan(ctx, "harness.turn", attribute.Int(attrTurn, n))
+}
+
+// startAPISpan starts the span covering one attempt at an API request.
+func startAPISpan(ctx context.Context, model string, attempt int) (context.Context, trace.Span) {
+	return startSpan(ctx, "chat "+model,
+		attribute.String(attrGenAISystem, "anthropic"),
+		attribute.String(attrGenAIOp, "chat"),
+		attribute.String(attrModel, model),
+		attribute.Int(attrAttempt, attempt),
+	)
+}
+
+// endAPISpan records the usage and stop reason of message on span and ends
+// it.
+func endAPISpan(span trace.Span, message *anthropic.Message, err error) {
+	if err == nil {
+		span.SetAttributes(
+			attribute.Int64(attrInputTokens, message.Usage.InputTokens),
+			attribute.Int64(attrOutputTokens, message.Usage.OutputTokens),
+			attribute.StringSlice(attrFinishReasons, []string{string(message.StopReason)}),
+		)
+	}
+	endSpan(span, err)
+}
+
+// startToolSpan starts the span covering one tool call, including any wait
+// for approval.
+func startToolSpan(ctx context.Context, call ToolCall) (context.Context, trace.Span) {
+	return startSpan(ctx, "execute_tool "+call.Name,
+		attribute.String(attrGenAIOp, "execute_tool"),
+		attribute.String(attrToolName, call.Name),
+		attribute.String(attrToolCallID, call.ID),
+	)
+}
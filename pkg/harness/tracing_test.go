@@ -0,0 +1,101 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddToolUse("call_1", "ok_tool", map[string]string{}).
+		AddToolUse("call_2", "failing_tool", map[string]string{}).
+		WithUsage(100, 20).
+		BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("Done.").WithUsage(150, 30).Build())
+
+	failing := &MockTool{name: "failing_tool", executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+		return "", errors.New("boom")
+	}}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"},
+		[]tool.Tool{&MockTool{name: "ok_tool"}, failing}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(log.WithRunID(context.Background(), "run-1"), "Go"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	byName := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		byName[span.Name()] = append(byName[span.Name()], span)
+	}
+	if len(byName["harness.run"]) != 1 || len(byName["harness.turn"]) != 2 || len(byName["chat test-model"]) != 2 {
+		t.Fatalf("unexpected spans %v", byName)
+	}
+
+	run := byName["harness.run"][0]
+	if run.Parent().IsValid() {
+		t.Error("expected the run span to be a root span")
+	}
+	attrs := attributes(run)
+	if attrs["harness.run_id"] != attribute.StringValue("run-1") || attrs["harness.turns"] != attribute.IntValue(2) {
+		t.Errorf("unexpected run attributes %v", attrs)
+	}
+
+	// Turns are children of the run; API calls and tools of their turn
+	for _, turn := range byName["harness.turn"] {
+		if turn.Parent().SpanID() != run.SpanContext().SpanID() {
+			t.Errorf("expected turn %v to be a child of the run", attributes(turn)["harness.turn"])
+		}
+	}
+	firstTurn := byName["harness.turn"][0]
+	chat := byName["chat test-model"][0]
+	if chat.Parent().SpanID() != firstTurn.SpanContext().SpanID() {
+		t.Error("expected the first API call to be a child of the first turn")
+	}
+	if attrs := attributes(chat); attrs["gen_ai.usage.input_tokens"] != attribute.Int64Value(100) {
+		t.Errorf("unexpected API call attributes %v", attrs)
+	}
+
+	ok := byName["execute_tool ok_tool"]
+	failed := byName["execute_tool failing_tool"]
+	if len(ok) != 1 || len(failed) != 1 {
+		t.Fatalf("expected one span per tool call, got %v", byName)
+	}
+	if ok[0].Parent().SpanID() != firstTurn.SpanContext().SpanID() {
+		t.Error("expected the tool call to be a child of the first turn")
+	}
+	if attributes(ok[0])["gen_ai.tool.call.id"] != attribute.StringValue("call_1") || ok[0].Status().Code == codes.Error {
+		t.Errorf("unexpected successful tool span %v %v", attributes(ok[0]), ok[0].Status())
+	}
+	if failed[0].Status().Code != codes.Error {
+		t.Errorf("expected the failed tool span to have an error status, got %v", failed[0].Status())
+	}
+}
+
+// attributes returns the attributes of span by key.
+func attributes(span sdktrace.ReadOnlySpan) map[string]attribute.Value {
+	attrs := make(map[string]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[string(kv.Key)] = kv.Value
+	}
+	return attrs
+}
@@ -0,0 +1,69 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// truncationLineSlack is how far a cut may move to land on a line boundary.
+const truncationLineSlack = 200
+
+// resultLimit returns the maximum result size of the named tool in bytes, or
+// 0 for no limit.
+func (h *Harness) resultLimit(name string) int {
+	if limit, ok := h.config.ToolResultLimits[name]; ok {
+		return max(limit, 0)
+	}
+	return h.config.MaxToolResultBytes
+}
+
+// limitResult truncates a tool result larger than the tool's limit,
+// keeping its head and tail around a marker.
+func (h *Harness) limitResult(ctx context.Context, call ToolCall, result string) string {
+	limit := h.resultLimit(call.Name)
+	if limit <= 0 || len(result) <= limit {
+		return result
+	}
+	truncated := truncateMiddle(result, limit)
+	log.ForContext(h.logger, ctx).Warn("tool", "Result truncated",
+		log.F("tool", call.Name),
+		log.F("id", call.ID),
+		log.F("bytes", len(result)),
+		log.F("limit", limit),
+	)
+	return truncated
+}
+
+// truncateMiddle shortens s to about limit bytes by keeping its beginning
+// and end and replacing the middle with a marker saying how much was cut.
+// Cuts fall on line boundaries when one is near, and never split a rune.
+func truncateMiddle(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	headEnd := limit / 2
+	tailStart := len(s) - (limit - headEnd)
+
+	// Prefer to end the head after a newline and start the tail after one
+	if i := strings.LastIndexByte(s[:headEnd], '\n'); i >= 0 && headEnd-i <= truncationLineSlack {
+		headEnd = i + 1
+	}
+	if i := strings.IndexByte(s[tailStart:], '\n'); i >= 0 && i < truncationLineSlack && tailStart+i+1 < len(s) {
+		tailStart += i + 1
+	}
+	for headEnd > 0 && !utf8.RuneStart(s[headEnd]) {
+		headEnd--
+	}
+	for tailStart < len(s) && !utf8.RuneStart(s[tailStart]) {
+		tailStart++
+	}
+
+	omitted := s[headEnd:tailStart]
+	marker := fmt.Sprintf("\n[... %d bytes (%d lines) truncated; the result was %d bytes. Showing the first %d and last %d bytes. Narrow the request to see the rest ...]\n",
+		len(omitted), strings.Count(omitted, "\n"), len(s), headEnd, len(s)-tailStart)
+	return s[:headEnd] + marker + s[tailStart:]
+}
@@ -0,0 +1,68 @@
+package harness_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestToolResultLimit(t *testing.T) {
+	var lines []string
+	for i := 0; i < 1000; i++ {
+		lines = append(lines, strings.Repeat("x", 20)+" line")
+	}
+	big := strings.Join(lines, "\n")
+	bigTool := func(ctx context.Context, input json.RawMessage) (string, error) { return big, nil }
+
+	tests := []struct {
+		name      string
+		config    harness.Config
+		tool      string
+		truncated bool
+	}{
+		{"default limit", harness.Config{Model: "test-model", MaxToolResultBytes: 2000}, "grep", true},
+		{"no limit", harness.Config{Model: "test-model"}, "grep", false},
+		{"override disables", harness.Config{Model: "test-model", MaxToolResultBytes: 2000, ToolResultLimits: map[string]int{"read": 0}}, "read", false},
+		{"override applies", harness.Config{Model: "test-model", ToolResultLimits: map[string]int{"grep": 2000}}, "grep", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStreamer := testutil.NewMockMessageStreamer()
+			mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", tt.tool, map[string]string{}))
+			mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+			handler := &MockEventHandler{}
+			h, _ := harness.NewHarnessWithStreamer(tt.config, []tool.Tool{&MockTool{name: tt.tool, executeFunc: bigTool}}, handler, mockStreamer)
+			if err := h.Prompt(context.Background(), "go"); err != nil {
+				t.Fatalf("prompt failed: %v", err)
+			}
+
+			result := handler.ToolResults[0].Result
+			if !tt.truncated {
+				if result != big {
+					t.Errorf("expected the full result, got %d bytes", len(result))
+				}
+				return
+			}
+			head, tail, ok := strings.Cut(result, "\n[... ")
+			if !ok || !strings.HasPrefix(big, head) || !strings.HasSuffix(head, "line\n") {
+				t.Fatalf("expected the head to end at a line boundary, got %q", result[:min(len(result), 100)])
+			}
+			if !strings.Contains(tail, "truncated; the result was 25999 bytes") {
+				t.Errorf("unexpected marker: %q", tail[:min(len(tail), 200)])
+			}
+			_, rest, _ := strings.Cut(tail, "...]\n")
+			if !strings.HasSuffix(big, rest) || !strings.HasPrefix(rest, "xxx") {
+				t.Errorf("expected the tail to start at a line, got %q", rest[:min(len(rest), 50)])
+			}
+			if len(head)+len(rest) > 2000 {
+				t.Errorf("kept %d bytes, over the 2000 byte limit", len(head)+len(rest))
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package harness
+
+import (
+	"encoding/json"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Usage holds token counts accumulated across API calls, with their
+// estimated cost.
+type Usage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	WebSearchRequests        int64 `json:"web_search_requests"`
+
+	// CostUSD is the estimated cost in US dollars from the model price
+	// table. Usage of models without a price adds nothing.
+	CostUSD float64 `json:"cost_usd"`
+}
+
+// Add returns the sum of u and other.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:              u.InputTokens + other.InputTokens,
+		OutputTokens:             u.OutputTokens + other.OutputTokens,
+		CacheCreationInputTokens: u.CacheCreationInputTokens + other.CacheCreationInputTokens,
+		CacheReadInputTokens:     u.CacheReadInputTokens + other.CacheReadInputTokens,
+		WebSearchRequests:        u.WebSearchRequests + other.WebSearchRequests,
+		CostUSD:                  u.CostUSD + other.CostUSD,
+	}
+}
+
+// UsageReport is the payload of UsageHandler.OnUsage.
+type UsageReport struct {
+	Turn    Usage         `json:"turn"`    // the API response just received
+	Run     Usage         `json:"run"`     // the running prompt so far
+	Session Usage         `json:"session"` // the harness's lifetime
+	Context ContextBudget `json:"context"` // context window use after the turn
+}
+
+// usageFromAPI converts an API usage block for model to a Usage, pricing it
+// from the price table.
+func (h *Harness) usageFromAPI(model string, u anthropic.Usage) Usage {
+	usage := Usage{
+		InputTokens:              u.InputTokens,
+		OutputTokens:             u.OutputTokens,
+		CacheCreationInputTokens: u.CacheCreationInputTokens,
+		CacheReadInputTokens:     u.CacheReadInputTokens,
+		WebSearchRequests:        u.ServerToolUse.WebSearchRequests,
+	}
+	if price, ok := LookupPrice(h.config.Prices, model); ok {
+		usage.CostUSD = price.Cost(usage)
+	}
+	return usage
+}
+
+// recordUsage adds the usage of one API response to the session total.
+func (h *Harness) recordUsage(u Usage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.usage = h.usage.Add(u)
+}
+
+// Usage returns the token usage accumulated over the harness's lifetime.
+func (h *Harness) Usage() Usage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.usage
+}
+
+// emitUsage reports a turn's usage with the prompt and session totals.
+func (h *Harness) emitUsage(turn Usage) {
+	uh, ok := h.handler.(UsageHandler)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	report := UsageReport{Turn: turn, Run: h.result.Usage, Session: h.usage, Context: h.contextBudget()}
+	h.mu.Unlock()
+	data, err := json.Marshal(report)
+	if err != nil {
+		return
+	}
+	uh.OnUsage(data)
+}
@@ -0,0 +1,53 @@
+package harness
+
+import "github.com/anthropics/anthropic-sdk-go"
+
+// Variant overrides parts of the configuration for one arm of an experiment.
+// Empty fields keep the parent harness's value.
+type Variant struct {
+	ID           string `json:"id"`
+	Model        string `json:"model,omitempty"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	Profile      string `json:"profile,omitempty"`
+}
+
+// NewVariantSession returns a new session like NewSession, with the model and
+// system prompt overridden by v and its profile selected. An unknown profile
+// is ignored; callers check it with ValidateOptions.
+func (h *Harness) NewVariantSession(v Variant, handler EventHandler) *Harness {
+	session := h.NewSession(handler)
+	if v.Model != "" {
+		session.config.Model = v.Model
+	}
+	if v.SystemPrompt != "" {
+		session.config.SystemPrompt = v.SystemPrompt
+	}
+	if v.Profile != "" {
+		session.SetProfile(v.Profile)
+	}
+	return session
+}
+
+// Model returns the model this harness sends requests to.
+func (h *Harness) Model() string {
+	return h.config.Model
+}
+
+// Turns returns the number of assistant responses in the conversation.
+func (h *Harness) Turns() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	turns := 0
+	for _, msg := range h.messages {
+		if msg.Role == anthropic.MessageParamRoleAssistant {
+			turns++
+		}
+	}
+	return turns
+}
+
+// IsMutatingTool reports whether the named tool may modify files or the
+// environment. Unknown tools are treated as mutating.
+func (h *Harness) IsMutatingTool(name string) bool {
+	return h.isMutating(name)
+}
@@ -8,26 +8,67 @@ import (
 	"time"
 )
 
-// AgentLogger defines the interface for logging agent interactions.
+// Agent log entry types.
+const (
+	AgentPrompt      = "prompt"       // user prompt or injected message
+	AgentAPIRequest  = "api_request"  // request sent to the API
+	AgentAPIResponse = "api_response" // complete message received from the API
+	AgentToolCall    = "tool_call"    // tool call requested by the model
+	AgentToolResult  = "tool_result"  // result of a tool call
+	AgentText        = "text"         // text block of an assistant message
+)
+
+// AgentEntry is one line of the JSON agent log. Which fields are set
+// depends on Type.
+type AgentEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	RunID     string    `json:"run_id,omitempty"`
+
+	// For prompt and text entries
+	Content string `json:"content,omitempty"`
+
+	// For tool_call and tool_result entries
+	ID      string          `json:"id,omitempty"`
+	Name    string          `json:"name,omitempty"`
+	Input   json.RawMessage `json:"input,omitempty"`
+	Result  string          `json:"result,omitempty"`
+	IsError bool            `json:"is_error,omitempty"`
+
+	// For api_request entries: the JSON encoding of the
+	// anthropic.MessageNewParams sent
+	Request json.RawMessage `json:"request,omitempty"`
+
+	// For api_response entries: the JSON encoding of the anthropic.Message
+	// received
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// AgentLogger defines the interface for logging agent interactions. runID
+// is the run the entry belongs to, or "" if unknown.
 type AgentLogger interface {
-	// LogUser logs a user prompt.
-	LogUser(content string)
-	// LogAssistant logs an assistant response.
-	LogAssistant(content string)
+	// LogPrompt logs a user prompt.
+	LogPrompt(runID, content string)
+	// LogText logs assistant text.
+	LogText(runID, content string)
 	// LogToolCall logs a tool call from the assistant.
-	LogToolCall(id, name string, input json.RawMessage)
+	LogToolCall(runID, id, name string, input json.RawMessage)
 	// LogToolResult logs a tool execution result.
-	LogToolResult(id string, result string, isError bool)
+	LogToolResult(runID, id, result string, isError bool)
+	// LogAPIRequest logs the JSON encoding of an API request.
+	LogAPIRequest(runID string, params json.RawMessage)
+	// LogAPIResponse logs the JSON encoding of an API response message.
+	LogAPIResponse(runID string, message json.RawMessage)
 	// Close closes the agent logger and any open files.
 	Close() error
 }
 
 // agentLogger is the concrete implementation of AgentLogger.
 type agentLogger struct {
-	mu      sync.Mutex
-	config  AgentLogConfig
-	writer  *rotatingWriter
-	format  Format
+	mu     sync.Mutex
+	config AgentLogConfig
+	writer *rotatingWriter
+	format Format
 }
 
 // NewAgentLogger creates a new AgentLogger with the given configuration.
@@ -50,24 +91,34 @@ func NewAgentLogger(config AgentLogConfig) AgentLogger {
 	}
 }
 
-// LogUser logs a user prompt.
-func (l *agentLogger) LogUser(content string) {
-	l.log("user", "", "", content, nil, false)
+// LogPrompt logs a user prompt.
+func (l *agentLogger) LogPrompt(runID, content string) {
+	l.log(AgentEntry{Type: AgentPrompt, RunID: runID, Content: content})
 }
 
-// LogAssistant logs an assistant response.
-func (l *agentLogger) LogAssistant(content string) {
-	l.log("assistant", "", "", content, nil, false)
+// LogText logs assistant text.
+func (l *agentLogger) LogText(runID, content string) {
+	l.log(AgentEntry{Type: AgentText, RunID: runID, Content: content})
 }
 
 // LogToolCall logs a tool call from the assistant.
-func (l *agentLogger) LogToolCall(id, name string, input json.RawMessage) {
-	l.log("tool_call", id, name, "", input, false)
+func (l *agentLogger) LogToolCall(runID, id, name string, input json.RawMessage) {
+	l.log(AgentEntry{Type: AgentToolCall, RunID: runID, ID: id, Name: name, Input: input})
 }
 
 // LogToolResult logs a tool execution result.
-func (l *agentLogger) LogToolResult(id string, result string, isError bool) {
-	l.log("tool_result", id, "", result, nil, isError)
+func (l *agentLogger) LogToolResult(runID, id, result string, isError bool) {
+	l.log(AgentEntry{Type: AgentToolResult, RunID: runID, ID: id, Result: result, IsError: isError})
+}
+
+// LogAPIRequest logs the JSON encoding of an API request.
+func (l *agentLogger) LogAPIRequest(runID string, params json.RawMessage) {
+	l.log(AgentEntry{Type: AgentAPIRequest, RunID: runID, Request: params})
+}
+
+// LogAPIResponse logs the JSON encoding of an API response message.
+func (l *agentLogger) LogAPIResponse(runID string, message json.RawMessage) {
+	l.log(AgentEntry{Type: AgentAPIResponse, RunID: runID, Response: message})
 }
 
 // Close closes the agent logger.
@@ -81,14 +132,14 @@ func (l *agentLogger) Close() error {
 }
 
 // log writes a log entry.
-func (l *agentLogger) log(eventType, id, name, content string, input json.RawMessage, isError bool) {
-	timestamp := time.Now().UTC()
+func (l *agentLogger) log(entry AgentEntry) {
+	entry.Timestamp = time.Now().UTC()
 
 	var output string
 	if l.format == FormatJSON {
-		output = l.formatJSON(timestamp, eventType, id, name, content, input, isError)
+		output = formatAgentJSON(entry)
 	} else {
-		output = l.formatText(timestamp, eventType, id, name, content, input, isError)
+		output = formatAgentText(entry)
 	}
 
 	l.mu.Lock()
@@ -96,63 +147,71 @@ func (l *agentLogger) log(eventType, id, name, content string, input json.RawMes
 	l.mu.Unlock()
 }
 
-// formatText formats an agent log entry as text.
-func (l *agentLogger) formatText(timestamp time.Time, eventType, id, name, content string, input json.RawMessage, isError bool) string {
-	ts := timestamp.Format(time.RFC3339Nano)
-
-	switch eventType {
-	case "user":
-		return fmt.Sprintf("=== %s USER ===\n%s\n\n", ts, content)
-	case "assistant":
-		return fmt.Sprintf("=== %s ASSISTANT ===\n%s\n\n", ts, content)
-	case "tool_call":
-		return fmt.Sprintf("=== %s TOOL_CALL [%s] id=%s ===\n%s\n\n", ts, name, id, string(input))
-	case "tool_result":
+// formatAgentText formats an agent log entry as text. API requests and
+// responses are summarized; the JSON format keeps them in full.
+func formatAgentText(entry AgentEntry) string {
+	ts := entry.Timestamp.Format(time.RFC3339Nano)
+	run := ""
+	if entry.RunID != "" {
+		run = " run=" + entry.RunID
+	}
+
+	switch entry.Type {
+	case AgentPrompt:
+		return fmt.Sprintf("=== %s USER%s ===\n%s\n\n", ts, run, entry.Content)
+	case AgentText:
+		return fmt.Sprintf("=== %s ASSISTANT%s ===\n%s\n\n", ts, run, entry.Content)
+	case AgentToolCall:
+		return fmt.Sprintf("=== %s TOOL_CALL [%s] id=%s%s ===\n%s\n\n", ts, entry.Name, entry.ID, run, string(entry.Input))
+	case AgentToolResult:
 		status := "success"
-		if isError {
+		if entry.IsError {
 			status = "error"
 		}
-		return fmt.Sprintf("=== %s TOOL_RESULT [%s] %s ===\n%s\n\n", ts, id, status, content)
+		return fmt.Sprintf("=== %s TOOL_RESULT [%s] %s%s ===\n%s\n\n", ts, entry.ID, status, run, entry.Result)
+	case AgentAPIRequest:
+		var params struct {
+			Model    string            `json:"model"`
+			Messages []json.RawMessage `json:"messages"`
+		}
+		json.Unmarshal(entry.Request, &params)
+		return fmt.Sprintf("=== %s API_REQUEST [%s]%s ===\n%d messages\n\n", ts, params.Model, run, len(params.Messages))
+	case AgentAPIResponse:
+		var message struct {
+			StopReason string `json:"stop_reason"`
+			Usage      struct {
+				InputTokens  int64 `json:"input_tokens"`
+				OutputTokens int64 `json:"output_tokens"`
+			} `json:"usage"`
+		}
+		json.Unmarshal(entry.Response, &message)
+		return fmt.Sprintf("=== %s API_RESPONSE [%s]%s ===\n%d input tokens, %d output tokens\n\n",
+			ts, message.StopReason, run, message.Usage.InputTokens, message.Usage.OutputTokens)
 	default:
 		return ""
 	}
 }
 
-// formatJSON formats an agent log entry as JSON (NDJSON).
-func (l *agentLogger) formatJSON(timestamp time.Time, eventType, id, name, content string, input json.RawMessage, isError bool) string {
-	entry := map[string]any{
-		"timestamp": timestamp.Format(time.RFC3339Nano),
-		"type":      eventType,
+// formatAgentJSON formats an agent log entry as one line of JSON (NDJSON).
+func formatAgentJSON(entry AgentEntry) string {
+	// Keep invalid tool input as a string rather than failing the entry
+	if len(entry.Input) > 0 && !json.Valid(entry.Input) {
+		entry.Input, _ = json.Marshal(string(entry.Input))
 	}
-
-	switch eventType {
-	case "user", "assistant":
-		entry["content"] = content
-	case "tool_call":
-		entry["id"] = id
-		entry["name"] = name
-		// Parse input to include as object, not string
-		var inputObj any
-		if json.Unmarshal(input, &inputObj) == nil {
-			entry["input"] = inputObj
-		} else {
-			entry["input"] = string(input)
-		}
-	case "tool_result":
-		entry["id"] = id
-		entry["success"] = !isError
-		entry["result"] = content
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return ""
 	}
-
-	data, _ := json.Marshal(entry)
 	return string(data) + "\n"
 }
 
 // NopAgentLogger is an agent logger that does nothing. Useful for testing.
 type NopAgentLogger struct{}
 
-func (NopAgentLogger) LogUser(content string)                                  {}
-func (NopAgentLogger) LogAssistant(content string)                             {}
-func (NopAgentLogger) LogToolCall(id, name string, input json.RawMessage)      {}
-func (NopAgentLogger) LogToolResult(id string, result string, isError bool)    {}
-func (NopAgentLogger) Close() error                                            { return nil }
+func (NopAgentLogger) LogPrompt(runID, content string)                           {}
+func (NopAgentLogger) LogText(runID, content string)                             {}
+func (NopAgentLogger) LogToolCall(runID, id, name string, input json.RawMessage) {}
+func (NopAgentLogger) LogToolResult(runID, id, result string, isError bool)      {}
+func (NopAgentLogger) LogAPIRequest(runID string, params json.RawMessage)        {}
+func (NopAgentLogger) LogAPIResponse(runID string, message json.RawMessage)      {}
+func (NopAgentLogger) Close() error                                              { return nil }
@@ -33,11 +33,13 @@ func TestAgentLoggerTextFormat(t *testing.T) {
 	defer logger.Close()
 
 	// Log events
-	logger.LogUser("What's in config.json?")
-	logger.LogAssistant("I'll read that file for you.")
-	logger.LogToolCall("toolu_123", "read", json.RawMessage(`{"path": "/config.json"}`))
-	logger.LogToolResult("toolu_123", "port=8080", false)
-	logger.LogToolResult("toolu_456", "file not found", true)
+	logger.LogPrompt("run_1", "What's in config.json?")
+	logger.LogAPIRequest("run_1", json.RawMessage(`{"model":"test-model","messages":[{"role":"user","content":[]}]}`))
+	logger.LogText("run_1", "I'll read that file for you.")
+	logger.LogToolCall("run_1", "toolu_123", "read", json.RawMessage(`{"path": "/config.json"}`))
+	logger.LogAPIResponse("run_1", json.RawMessage(`{"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`))
+	logger.LogToolResult("run_1", "toolu_123", "port=8080", false)
+	logger.LogToolResult("run_1", "toolu_456", "file not found", true)
 
 	// Close to flush
 	logger.Close()
@@ -51,7 +53,7 @@ func TestAgentLoggerTextFormat(t *testing.T) {
 	output := string(content)
 
 	// Check user message
-	if !strings.Contains(output, "USER ===") {
+	if !strings.Contains(output, "USER run=run_1 ===") {
 		t.Errorf("expected USER marker in output: %s", output)
 	}
 	if !strings.Contains(output, "What's in config.json?") {
@@ -59,7 +61,7 @@ func TestAgentLoggerTextFormat(t *testing.T) {
 	}
 
 	// Check assistant message
-	if !strings.Contains(output, "ASSISTANT ===") {
+	if !strings.Contains(output, "ASSISTANT run=run_1 ===") {
 		t.Errorf("expected ASSISTANT marker in output: %s", output)
 	}
 	if !strings.Contains(output, "I'll read that file for you.") {
@@ -71,6 +73,14 @@ func TestAgentLoggerTextFormat(t *testing.T) {
 		t.Errorf("expected TOOL_CALL marker in output: %s", output)
 	}
 
+	// API requests and responses are summarized
+	if !strings.Contains(output, "API_REQUEST [test-model] run=run_1 ===\n1 messages") {
+		t.Errorf("expected API_REQUEST summary in output: %s", output)
+	}
+	if !strings.Contains(output, "API_RESPONSE [tool_use] run=run_1 ===\n10 input tokens, 5 output tokens") {
+		t.Errorf("expected API_RESPONSE summary in output: %s", output)
+	}
+
 	// Check tool result
 	if !strings.Contains(output, "TOOL_RESULT [toolu_123] success") {
 		t.Errorf("expected success TOOL_RESULT marker in output: %s", output)
@@ -95,10 +105,12 @@ func TestAgentLoggerJSONFormat(t *testing.T) {
 	defer logger.Close()
 
 	// Log events
-	logger.LogUser("Hello")
-	logger.LogAssistant("Hi there")
-	logger.LogToolCall("toolu_1", "read", json.RawMessage(`{"path": "/test.txt"}`))
-	logger.LogToolResult("toolu_1", "test content", false)
+	logger.LogPrompt("run_1", "Hello")
+	logger.LogText("run_1", "Hi there")
+	logger.LogToolCall("run_1", "toolu_1", "read", json.RawMessage(`{"path": "/test.txt"}`))
+	logger.LogToolResult("run_1", "toolu_1", "test content", true)
+	logger.LogAPIRequest("run_1", json.RawMessage(`{"model":"test-model"}`))
+	logger.LogToolCall("", "toolu_2", "read", json.RawMessage(`not json`))
 
 	// Close to flush
 	logger.Close()
@@ -111,20 +123,20 @@ func TestAgentLoggerJSONFormat(t *testing.T) {
 
 	// Parse each line as JSON
 	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
-	if len(lines) != 4 {
-		t.Fatalf("expected 4 lines, got %d", len(lines))
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 lines, got %d", len(lines))
 	}
 
-	// Check user entry
+	// Check prompt entry
 	var userEntry map[string]any
 	if err := json.Unmarshal([]byte(lines[0]), &userEntry); err != nil {
-		t.Fatalf("failed to parse user entry: %v", err)
+		t.Fatalf("failed to parse prompt entry: %v", err)
 	}
-	if userEntry["type"] != "user" {
-		t.Errorf("expected type user, got %v", userEntry["type"])
+	if userEntry["type"] != "prompt" {
+		t.Errorf("expected type prompt, got %v", userEntry["type"])
 	}
-	if userEntry["content"] != "Hello" {
-		t.Errorf("expected content Hello, got %v", userEntry["content"])
+	if userEntry["content"] != "Hello" || userEntry["run_id"] != "run_1" {
+		t.Errorf("expected content Hello of run_1, got %v", userEntry)
 	}
 
 	// Check tool_call entry
@@ -147,8 +159,26 @@ func TestAgentLoggerJSONFormat(t *testing.T) {
 	if toolResultEntry["type"] != "tool_result" {
 		t.Errorf("expected type tool_result, got %v", toolResultEntry["type"])
 	}
-	if toolResultEntry["success"] != true {
-		t.Errorf("expected success true, got %v", toolResultEntry["success"])
+	if toolResultEntry["is_error"] != true {
+		t.Errorf("expected is_error true, got %v", toolResultEntry["is_error"])
+	}
+
+	// Entries decode as AgentEntry; API requests are kept in full
+	var request AgentEntry
+	if err := json.Unmarshal([]byte(lines[4]), &request); err != nil {
+		t.Fatalf("failed to parse api_request entry: %v", err)
+	}
+	if request.Type != AgentAPIRequest || string(request.Request) != `{"model":"test-model"}` || request.Timestamp.IsZero() {
+		t.Errorf("unexpected api_request entry: %+v", request)
+	}
+
+	// Invalid tool input is kept as a string
+	var invalid AgentEntry
+	if err := json.Unmarshal([]byte(lines[5]), &invalid); err != nil {
+		t.Fatalf("failed to parse tool_call entry: %v", err)
+	}
+	if string(invalid.Input) != `"not json"` || invalid.RunID != "" {
+		t.Errorf("unexpected tool_call entry: %+v", invalid)
 	}
 }
 
@@ -156,10 +186,12 @@ func TestNopAgentLogger(t *testing.T) {
 	logger := NopAgentLogger{}
 
 	// Should not panic
-	logger.LogUser("test")
-	logger.LogAssistant("test")
-	logger.LogToolCall("id", "name", json.RawMessage(`{}`))
-	logger.LogToolResult("id", "result", false)
+	logger.LogPrompt("run", "test")
+	logger.LogText("run", "test")
+	logger.LogToolCall("run", "id", "name", json.RawMessage(`{}`))
+	logger.LogToolResult("run", "id", "result", false)
+	logger.LogAPIRequest("run", json.RawMessage(`{}`))
+	logger.LogAPIResponse("run", json.RawMessage(`{}`))
 
 	if err := logger.Close(); err != nil {
 		t.Errorf("NopAgentLogger.Close() should return nil, got %v", err)
@@ -43,17 +43,26 @@ func (l Level) String() string {
 // ParseLevel parses a string into a Level.
 // Returns LevelInfo if the string is not recognized.
 func ParseLevel(s string) Level {
+	if level, ok := LookupLevel(s); ok {
+		return level
+	}
+	return LevelInfo
+}
+
+// LookupLevel parses a level name, in any case, reporting whether it is
+// one.
+func LookupLevel(s string) (Level, bool) {
 	switch strings.ToUpper(s) {
 	case "DEBUG":
-		return LevelDebug
+		return LevelDebug, true
 	case "INFO":
-		return LevelInfo
+		return LevelInfo, true
 	case "WARN":
-		return LevelWarn
+		return LevelWarn, true
 	case "ERROR":
-		return LevelError
+		return LevelError, true
 	default:
-		return LevelInfo
+		return LevelInfo, false
 	}
 }
 
@@ -94,7 +103,7 @@ type LogConfig struct {
 type AgentLogConfig struct {
 	// FilePath is the file path for agent logs. Empty means disabled.
 	FilePath string
-	// Format is the output format (text, json). Default: text
+	// Format is the output format (text, json). LoadFromEnv defaults to json
 	Format Format
 	// MaxSize is the maximum file size in bytes before rotation. Default: 10MB
 	MaxSize int64
@@ -117,9 +126,14 @@ func LoadFromEnv() (LogConfig, AgentLogConfig) {
 		Output:     os.Stderr,
 	}
 
+	// The agent log is JSON unless text is asked for, so it can be replayed
+	agentFormat := FormatJSON
+	if format := os.Getenv("HARNESS_AGENT_LOG_FORMAT"); format != "" {
+		agentFormat = ParseFormat(format)
+	}
 	agentConfig := AgentLogConfig{
 		FilePath: os.Getenv("HARNESS_AGENT_LOG"),
-		Format:   ParseFormat(os.Getenv("HARNESS_AGENT_LOG_FORMAT")),
+		Format:   agentFormat,
 		MaxSize:  DefaultMaxSize,
 		MaxFiles: DefaultMaxFiles,
 	}
@@ -114,6 +114,9 @@ func TestLoadFromEnv(t *testing.T) {
 		if agentConfig.FilePath != "" {
 			t.Errorf("expected empty file path, got %q", agentConfig.FilePath)
 		}
+		if agentConfig.Format != FormatJSON {
+			t.Errorf("expected default agent format json, got %v", agentConfig.Format)
+		}
 	})
 
 	t.Run("custom values", func(t *testing.T) {
@@ -0,0 +1,67 @@
+package log
+
+import "context"
+
+// runIDKey is the context key for the ID of the run a request belongs to.
+type runIDKey struct{}
+
+// WithRunID returns a copy of ctx carrying the run ID, which ForContext adds
+// to log lines and tools can read with RunID.
+func WithRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, id)
+}
+
+// RunID returns the run ID carried by ctx, or "" if there is none.
+func RunID(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// ForContext returns a logger that adds ctx's run ID to every line as
+// run_id, or l itself if ctx carries none.
+func ForContext(l Logger, ctx context.Context) Logger {
+	if id := RunID(ctx); id != "" {
+		return With(l, F("run_id", id))
+	}
+	return l
+}
+
+// With returns a logger that appends fields to every line logged through l.
+func With(l Logger, fields ...Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	return &fieldLogger{next: l, fields: fields}
+}
+
+// fieldLogger adds fields to the lines of another logger.
+type fieldLogger struct {
+	next   Logger
+	fields []Field
+}
+
+func (l *fieldLogger) Debug(category string, message string, fields ...Field) {
+	l.next.Debug(category, message, l.with(fields)...)
+}
+
+func (l *fieldLogger) Info(category string, message string, fields ...Field) {
+	l.next.Info(category, message, l.with(fields)...)
+}
+
+func (l *fieldLogger) Warn(category string, message string, fields ...Field) {
+	l.next.Warn(category, message, l.with(fields)...)
+}
+
+func (l *fieldLogger) Error(category string, message string, fields ...Field) {
+	l.next.Error(category, message, l.with(fields)...)
+}
+
+func (l *fieldLogger) IsDebugEnabled() bool {
+	return l.next.IsDebugEnabled()
+}
+
+// with returns fields followed by l's fields.
+func (l *fieldLogger) with(fields []Field) []Field {
+	all := make([]Field, 0, len(fields)+len(l.fields))
+	return append(append(all, fields...), l.fields...)
+}
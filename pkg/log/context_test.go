@@ -0,0 +1,32 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestForContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(LogConfig{
+		Level:  LevelDebug,
+		Format: FormatText,
+		Output: &buf,
+	})
+
+	ctx := WithRunID(context.Background(), "run_123")
+	if got := RunID(ctx); got != "run_123" {
+		t.Errorf("expected run_123, got %q", got)
+	}
+	ForContext(logger, ctx).Info("tool", "Execution started", F("tool", "read"))
+
+	output := buf.String()
+	if !strings.Contains(output, "tool=read run_id=run_123") {
+		t.Errorf("expected the run ID after the line's fields: %s", output)
+	}
+
+	if ForContext(logger, context.Background()) != logger {
+		t.Error("expected the logger unchanged without a run ID")
+	}
+}
@@ -2,6 +2,8 @@ package log
 
 import (
 	"encoding/json"
+	"sync"
+	"time"
 )
 
 // EventHandler defines the interface that the harness uses for events.
@@ -13,12 +15,95 @@ type EventHandler interface {
 	OnReasoning(content string)
 }
 
+// ServerToolHandler mirrors harness.ServerToolHandler to avoid import cycles.
+type ServerToolHandler interface {
+	OnServerToolUse(id string, name string, input json.RawMessage)
+	OnServerToolResult(toolUseID string, blockType string, content json.RawMessage)
+}
+
+// FailSafeHandler mirrors harness.FailSafeHandler to avoid import cycles.
+type FailSafeHandler interface {
+	OnFailSafe(failures int)
+}
+
+// ToolEventHandler mirrors harness.ToolEventHandler to avoid import cycles.
+type ToolEventHandler interface {
+	OnToolEvent(id string, eventType string, payload json.RawMessage)
+}
+
+// ToolOutputHandler mirrors harness.ToolOutputHandler to avoid import cycles.
+type ToolOutputHandler interface {
+	OnToolOutput(id string, stream string, text string)
+}
+
+// ToolDisplayHandler mirrors harness.ToolDisplayHandler to avoid import cycles.
+type ToolDisplayHandler interface {
+	OnToolDisplay(id string, kind string, payload json.RawMessage)
+}
+
+// SLOHandler mirrors harness.SLOHandler to avoid import cycles.
+type SLOHandler interface {
+	OnSLOViolation(kind string, percentile string, observed time.Duration, threshold time.Duration)
+}
+
+// TextDeltaHandler mirrors harness.TextDeltaHandler to avoid import cycles.
+type TextDeltaHandler interface {
+	OnTextDelta(blockIndex int, delta string)
+}
+
+// RetryHandler mirrors harness.RetryHandler to avoid import cycles.
+type RetryHandler interface {
+	OnRetry(attempt int, delay time.Duration, err error)
+}
+
+// StreamResetHandler mirrors harness.StreamResetHandler to avoid import cycles.
+type StreamResetHandler interface {
+	OnStreamReset(err error)
+}
+
+// ModelChangeHandler mirrors harness.ModelChangeHandler to avoid import cycles.
+type ModelChangeHandler interface {
+	OnModelChanged(from, to string, err error)
+}
+
+// CompactionHandler mirrors harness.CompactionHandler to avoid import cycles.
+type CompactionHandler interface {
+	OnCompaction(compacted int, summary string)
+}
+
+// UsageHandler mirrors harness.UsageHandler to avoid import cycles.
+type UsageHandler interface {
+	OnUsage(usage json.RawMessage)
+}
+
+// PlanHandler mirrors harness.PlanHandler to avoid import cycles.
+type PlanHandler interface {
+	OnPlan(plan json.RawMessage)
+}
+
+// SteeringHandler mirrors harness.SteeringHandler to avoid import cycles.
+type SteeringHandler interface {
+	OnSteering(content string)
+}
+
+// APIHandler mirrors harness.APIHandler to avoid import cycles.
+type APIHandler interface {
+	OnAPIRequest(runID string, params json.RawMessage)
+	OnAPIResponse(runID string, message json.RawMessage)
+}
+
 // LoggingEventHandler wraps an EventHandler and logs agent interactions.
 // It delegates all events to the wrapped handler while also logging them
 // to an AgentLogger for debugging and review purposes.
+//
+// Text and tool events carry no run ID; they are logged with the run of the
+// latest API request or response, which produced them.
 type LoggingEventHandler struct {
 	wrapped     EventHandler
 	agentLogger AgentLogger
+
+	mu    sync.Mutex
+	runID string
 }
 
 // NewLoggingEventHandler creates a new LoggingEventHandler.
@@ -34,7 +119,7 @@ func NewLoggingEventHandler(wrapped EventHandler, agentLogger AgentLogger) *Logg
 // OnText handles assistant text events.
 func (h *LoggingEventHandler) OnText(text string) {
 	if h.agentLogger != nil {
-		h.agentLogger.LogAssistant(text)
+		h.agentLogger.LogText(h.currentRun(), text)
 	}
 	if h.wrapped != nil {
 		h.wrapped.OnText(text)
@@ -44,7 +129,7 @@ func (h *LoggingEventHandler) OnText(text string) {
 // OnToolCall handles tool call events from the assistant.
 func (h *LoggingEventHandler) OnToolCall(id string, name string, input json.RawMessage) {
 	if h.agentLogger != nil {
-		h.agentLogger.LogToolCall(id, name, input)
+		h.agentLogger.LogToolCall(h.currentRun(), id, name, input)
 	}
 	if h.wrapped != nil {
 		h.wrapped.OnToolCall(id, name, input)
@@ -54,7 +139,7 @@ func (h *LoggingEventHandler) OnToolCall(id string, name string, input json.RawM
 // OnToolResult handles tool result events.
 func (h *LoggingEventHandler) OnToolResult(id string, result string, isError bool) {
 	if h.agentLogger != nil {
-		h.agentLogger.LogToolResult(id, result, isError)
+		h.agentLogger.LogToolResult(h.currentRun(), id, result, isError)
 	}
 	if h.wrapped != nil {
 		h.wrapped.OnToolResult(id, result, isError)
@@ -70,10 +155,166 @@ func (h *LoggingEventHandler) OnReasoning(content string) {
 	}
 }
 
-// LogUserPrompt logs a user prompt to the agent logger.
+// OnServerToolUse forwards server tool calls to the wrapped handler if it supports them.
+func (h *LoggingEventHandler) OnServerToolUse(id string, name string, input json.RawMessage) {
+	if h.agentLogger != nil {
+		h.agentLogger.LogToolCall(h.currentRun(), id, name, input)
+	}
+	if sh, ok := h.wrapped.(ServerToolHandler); ok {
+		sh.OnServerToolUse(id, name, input)
+	}
+}
+
+// OnServerToolResult forwards server tool results to the wrapped handler if it supports them.
+func (h *LoggingEventHandler) OnServerToolResult(toolUseID string, blockType string, content json.RawMessage) {
+	if h.agentLogger != nil {
+		h.agentLogger.LogToolResult(h.currentRun(), toolUseID, string(content), false)
+	}
+	if sh, ok := h.wrapped.(ServerToolHandler); ok {
+		sh.OnServerToolResult(toolUseID, blockType, content)
+	}
+}
+
+// OnFailSafe forwards fail-safe activation to the wrapped handler if it supports it.
+func (h *LoggingEventHandler) OnFailSafe(failures int) {
+	if fh, ok := h.wrapped.(FailSafeHandler); ok {
+		fh.OnFailSafe(failures)
+	}
+}
+
+// OnToolEvent forwards custom tool events to the wrapped handler if it supports them.
+func (h *LoggingEventHandler) OnToolEvent(id string, eventType string, payload json.RawMessage) {
+	if th, ok := h.wrapped.(ToolEventHandler); ok {
+		th.OnToolEvent(id, eventType, payload)
+	}
+}
+
+// OnToolOutput forwards streamed tool output to the wrapped handler if it supports it.
+// The complete output is logged with the tool result, so chunks are not logged.
+func (h *LoggingEventHandler) OnToolOutput(id string, stream string, text string) {
+	if oh, ok := h.wrapped.(ToolOutputHandler); ok {
+		oh.OnToolOutput(id, stream, text)
+	}
+}
+
+// OnToolDisplay forwards display hints to the wrapped handler if it supports them.
+// Display hints are client-only and are not written to the agent log.
+func (h *LoggingEventHandler) OnToolDisplay(id string, kind string, payload json.RawMessage) {
+	if dh, ok := h.wrapped.(ToolDisplayHandler); ok {
+		dh.OnToolDisplay(id, kind, payload)
+	}
+}
+
+// OnSLOViolation forwards latency SLO violations to the wrapped handler if it supports them.
+func (h *LoggingEventHandler) OnSLOViolation(kind string, percentile string, observed time.Duration, threshold time.Duration) {
+	if sh, ok := h.wrapped.(SLOHandler); ok {
+		sh.OnSLOViolation(kind, percentile, observed, threshold)
+	}
+}
+
+// OnTextDelta forwards streamed text to the wrapped handler if it supports it.
+// Deltas are not logged; the complete block is logged by OnText.
+func (h *LoggingEventHandler) OnTextDelta(blockIndex int, delta string) {
+	if th, ok := h.wrapped.(TextDeltaHandler); ok {
+		th.OnTextDelta(blockIndex, delta)
+	}
+}
+
+// OnRetry forwards retry notices to the wrapped handler if it supports it.
+func (h *LoggingEventHandler) OnRetry(attempt int, delay time.Duration, err error) {
+	if rh, ok := h.wrapped.(RetryHandler); ok {
+		rh.OnRetry(attempt, delay, err)
+	}
+}
+
+// OnStreamReset forwards stream resets to the wrapped handler if it supports them.
+func (h *LoggingEventHandler) OnStreamReset(err error) {
+	if rh, ok := h.wrapped.(StreamResetHandler); ok {
+		rh.OnStreamReset(err)
+	}
+}
+
+// OnModelChanged forwards model switches to the wrapped handler if it supports them.
+func (h *LoggingEventHandler) OnModelChanged(from, to string, err error) {
+	if mh, ok := h.wrapped.(ModelChangeHandler); ok {
+		mh.OnModelChanged(from, to, err)
+	}
+}
+
+// OnCompaction forwards compaction notices to the wrapped handler if it supports it.
+func (h *LoggingEventHandler) OnCompaction(compacted int, summary string) {
+	if ch, ok := h.wrapped.(CompactionHandler); ok {
+		ch.OnCompaction(compacted, summary)
+	}
+}
+
+// OnUsage forwards usage reports to the wrapped handler if it supports them.
+func (h *LoggingEventHandler) OnUsage(usage json.RawMessage) {
+	if uh, ok := h.wrapped.(UsageHandler); ok {
+		uh.OnUsage(usage)
+	}
+}
+
+// OnPlan forwards task plans to the wrapped handler if it supports them.
+func (h *LoggingEventHandler) OnPlan(plan json.RawMessage) {
+	if ph, ok := h.wrapped.(PlanHandler); ok {
+		ph.OnPlan(plan)
+	}
+}
+
+// OnSteering forwards injected user messages to the wrapped handler if it
+// supports them.
+func (h *LoggingEventHandler) OnSteering(content string) {
+	if sh, ok := h.wrapped.(SteeringHandler); ok {
+		sh.OnSteering(content)
+	}
+}
+
+// OnAPIRequest logs an API request and forwards it to the wrapped handler
+// if it supports it.
+func (h *LoggingEventHandler) OnAPIRequest(runID string, params json.RawMessage) {
+	h.setRun(runID)
+	if h.agentLogger != nil {
+		h.agentLogger.LogAPIRequest(runID, params)
+	}
+	if ah, ok := h.wrapped.(APIHandler); ok {
+		ah.OnAPIRequest(runID, params)
+	}
+}
+
+// OnAPIResponse logs an API response and forwards it to the wrapped handler
+// if it supports it.
+func (h *LoggingEventHandler) OnAPIResponse(runID string, message json.RawMessage) {
+	h.setRun(runID)
+	if h.agentLogger != nil {
+		h.agentLogger.LogAPIResponse(runID, message)
+	}
+	if ah, ok := h.wrapped.(APIHandler); ok {
+		ah.OnAPIResponse(runID, message)
+	}
+}
+
+// LogUserPrompt logs a user prompt of run runID to the agent logger.
 // This should be called when a user submits a prompt, before the harness processes it.
-func (h *LoggingEventHandler) LogUserPrompt(content string) {
+func (h *LoggingEventHandler) LogUserPrompt(runID, content string) {
 	if h.agentLogger != nil {
-		h.agentLogger.LogUser(content)
+		h.agentLogger.LogPrompt(runID, content)
 	}
 }
+
+// setRun records the run that following text and tool events belong to.
+func (h *LoggingEventHandler) setRun(runID string) {
+	if runID == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.runID = runID
+}
+
+// currentRun returns the run of the latest API request or response.
+func (h *LoggingEventHandler) currentRun() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.runID
+}
@@ -2,6 +2,7 @@ package log
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -47,22 +48,38 @@ type mockAgentLogger struct {
 	assistantCalls  []string
 	toolCallCalls   []toolCallRecord
 	toolResultCalls []toolResultRecord
+	apiCalls        []string
+	runIDs          []string
 }
 
-func (m *mockAgentLogger) LogUser(content string) {
+func (m *mockAgentLogger) LogPrompt(runID, content string) {
 	m.userCalls = append(m.userCalls, content)
+	m.runIDs = append(m.runIDs, runID)
 }
 
-func (m *mockAgentLogger) LogAssistant(content string) {
+func (m *mockAgentLogger) LogText(runID, content string) {
 	m.assistantCalls = append(m.assistantCalls, content)
+	m.runIDs = append(m.runIDs, runID)
 }
 
-func (m *mockAgentLogger) LogToolCall(id, name string, input json.RawMessage) {
+func (m *mockAgentLogger) LogToolCall(runID, id, name string, input json.RawMessage) {
 	m.toolCallCalls = append(m.toolCallCalls, toolCallRecord{id, name, input})
+	m.runIDs = append(m.runIDs, runID)
 }
 
-func (m *mockAgentLogger) LogToolResult(id string, result string, isError bool) {
+func (m *mockAgentLogger) LogToolResult(runID, id, result string, isError bool) {
 	m.toolResultCalls = append(m.toolResultCalls, toolResultRecord{id, result, isError})
+	m.runIDs = append(m.runIDs, runID)
+}
+
+func (m *mockAgentLogger) LogAPIRequest(runID string, params json.RawMessage) {
+	m.apiCalls = append(m.apiCalls, "request")
+	m.runIDs = append(m.runIDs, runID)
+}
+
+func (m *mockAgentLogger) LogAPIResponse(runID string, message json.RawMessage) {
+	m.apiCalls = append(m.apiCalls, "response")
+	m.runIDs = append(m.runIDs, runID)
 }
 
 func (m *mockAgentLogger) Close() error {
@@ -145,9 +162,34 @@ func TestLoggingEventHandlerLogUserPrompt(t *testing.T) {
 	agentLogger := &mockAgentLogger{}
 	handler := NewLoggingEventHandler(nil, agentLogger)
 
-	handler.LogUserPrompt("What's in the file?")
+	handler.LogUserPrompt("run_1", "What's in the file?")
 
 	if len(agentLogger.userCalls) != 1 || agentLogger.userCalls[0] != "What's in the file?" {
 		t.Errorf("LogUserPrompt not logged: %v", agentLogger.userCalls)
 	}
+	if agentLogger.runIDs[0] != "run_1" {
+		t.Errorf("expected the prompt's run ID, got %v", agentLogger.runIDs)
+	}
+}
+
+func TestLoggingEventHandlerRunIDs(t *testing.T) {
+	agentLogger := &mockAgentLogger{}
+	handler := NewLoggingEventHandler(&mockEventHandler{}, agentLogger)
+
+	// Text and tool events take the run of the latest API request
+	handler.OnText("before any request")
+	handler.OnAPIRequest("run_1", json.RawMessage(`{}`))
+	handler.OnToolCall("id1", "read", json.RawMessage(`{}`))
+	handler.OnAPIResponse("run_1", json.RawMessage(`{}`))
+	handler.OnToolResult("id1", "content", false)
+	handler.OnAPIRequest("run_2", json.RawMessage(`{}`))
+	handler.OnText("Done")
+
+	want := []string{"", "run_1", "run_1", "run_1", "run_1", "run_2", "run_2"}
+	if strings.Join(agentLogger.runIDs, ",") != strings.Join(want, ",") {
+		t.Errorf("expected run IDs %v, got %v", want, agentLogger.runIDs)
+	}
+	if strings.Join(agentLogger.apiCalls, ",") != "request,response,request" {
+		t.Errorf("unexpected API calls %v", agentLogger.apiCalls)
+	}
 }
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -34,28 +36,72 @@ type Logger interface {
 	IsDebugEnabled() bool
 }
 
+// Filter selects the entries a logger writes.
+type Filter struct {
+	// Level is the minimum level written.
+	Level Level
+	// Categories are the categories written. Empty means all.
+	Categories []string
+}
+
+// ReconfigurableLogger is a Logger whose filter can be changed while it is
+// in use. Loggers returned by NewLogger implement it.
+type ReconfigurableLogger interface {
+	Logger
+	// Filter returns the current filter.
+	Filter() Filter
+	// SetFilter replaces the filter. Entries logged concurrently are
+	// filtered by either the old or the new filter as a whole.
+	SetFilter(filter Filter)
+}
+
 // serverLogger is the concrete implementation of Logger.
 type serverLogger struct {
-	mu         sync.Mutex
-	config     LogConfig
+	mu     sync.Mutex
+	config LogConfig
+	filter atomic.Pointer[compiledFilter]
+}
+
+// compiledFilter is a Filter with its categories in a set.
+type compiledFilter struct {
+	level      Level
 	categories map[string]struct{} // nil means all categories
 }
 
-// NewLogger creates a new Logger with the given configuration.
-func NewLogger(config LogConfig) Logger {
-	// Build category lookup map
-	var cats map[string]struct{}
-	if len(config.Categories) > 0 {
-		cats = make(map[string]struct{})
-		for _, c := range config.Categories {
-			cats[c] = struct{}{}
+// compileFilter builds the lookup form of filter.
+func compileFilter(filter Filter) *compiledFilter {
+	f := &compiledFilter{level: filter.Level}
+	if len(filter.Categories) > 0 {
+		f.categories = make(map[string]struct{})
+		for _, c := range filter.Categories {
+			f.categories[c] = struct{}{}
 		}
 	}
+	return f
+}
+
+// NewLogger creates a new Logger with the given configuration. It
+// implements ReconfigurableLogger.
+func NewLogger(config LogConfig) Logger {
+	l := &serverLogger{config: config}
+	l.filter.Store(compileFilter(Filter{Level: config.Level, Categories: config.Categories}))
+	return l
+}
 
-	return &serverLogger{
-		config:     config,
-		categories: cats,
+// Filter returns the current filter, with categories sorted.
+func (l *serverLogger) Filter() Filter {
+	f := l.filter.Load()
+	filter := Filter{Level: f.level}
+	for c := range f.categories {
+		filter.Categories = append(filter.Categories, c)
 	}
+	sort.Strings(filter.Categories)
+	return filter
+}
+
+// SetFilter replaces the filter.
+func (l *serverLogger) SetFilter(filter Filter) {
+	l.filter.Store(compileFilter(filter))
 }
 
 // Debug logs a debug-level message.
@@ -80,19 +126,18 @@ func (l *serverLogger) Error(category string, message string, fields ...Field) {
 
 // IsDebugEnabled returns true if debug-level logging is enabled.
 func (l *serverLogger) IsDebugEnabled() bool {
-	return l.config.Level <= LevelDebug
+	return l.filter.Load().level <= LevelDebug
 }
 
 // log performs the actual logging.
 func (l *serverLogger) log(level Level, category string, message string, fields []Field) {
-	// Check level
-	if level < l.config.Level {
+	// Check level and category
+	filter := l.filter.Load()
+	if level < filter.level {
 		return
 	}
-
-	// Check category
-	if l.categories != nil {
-		if _, ok := l.categories[category]; !ok {
+	if filter.categories != nil {
+		if _, ok := filter.categories[category]; !ok {
 			return
 		}
 	}
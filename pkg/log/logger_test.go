@@ -3,7 +3,9 @@ package log
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -214,3 +216,52 @@ func TestNopLogger(t *testing.T) {
 		t.Error("NopLogger should return false for IsDebugEnabled")
 	}
 }
+
+func TestLoggerSetFilter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(LogConfig{Level: LevelInfo, Output: &buf}).(ReconfigurableLogger)
+
+	logger.Debug("api", "hidden")
+	logger.SetFilter(Filter{Level: LevelDebug, Categories: []string{"tool", "api"}})
+	if !logger.IsDebugEnabled() {
+		t.Error("expected debug to be enabled")
+	}
+	logger.Debug("api", "shown")
+	logger.Info("http", "filtered")
+
+	output := buf.String()
+	if strings.Contains(output, "hidden") || strings.Contains(output, "filtered") || !strings.Contains(output, "shown") {
+		t.Errorf("unexpected output %q", output)
+	}
+	filter := logger.Filter()
+	if filter.Level != LevelDebug || strings.Join(filter.Categories, ",") != "api,tool" {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+
+	// Empty categories enable all of them again
+	logger.SetFilter(Filter{Level: LevelWarn})
+	if filter := logger.Filter(); filter.Level != LevelWarn || filter.Categories != nil {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+}
+
+func TestLoggerSetFilterConcurrent(t *testing.T) {
+	logger := NewLogger(LogConfig{Level: LevelInfo, Output: io.Discard}).(ReconfigurableLogger)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				logger.Info("api", "message")
+				logger.IsDebugEnabled()
+			}
+		}()
+	}
+	for j := 0; j < 1000; j++ {
+		logger.SetFilter(Filter{Level: Level(j % 4), Categories: []string{"api"}})
+		logger.Filter()
+	}
+	wg.Wait()
+}
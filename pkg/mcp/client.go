@@ -0,0 +1,254 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// ErrClosed is returned for requests on a client whose connection has ended.
+var ErrClosed = errors.New("mcp connection closed")
+
+// Client is a connection to one MCP server.
+type Client struct {
+	name      string
+	transport transport
+	logger    log.Logger
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[string]chan *message
+	err     error // set when the connection ends (guarded by mu)
+	closing bool  // set by Close (guarded by mu)
+}
+
+// Connect starts or connects to the server described by config, performs
+// the initialize handshake, and returns the connected client. ctx bounds the
+// connection attempt only. If logger is nil, a NopLogger is used.
+func Connect(ctx context.Context, config ServerConfig, logger log.Logger) (*Client, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = log.NopLogger{}
+	}
+
+	var t transport
+	if config.Command != "" {
+		t = &stdioTransport{
+			command: config.Command,
+			args:    config.Args,
+			env:     config.Env,
+			stderr:  io.Discard,
+		}
+	} else {
+		t = &sseTransport{url: config.URL, headers: config.Headers, http: &http.Client{}}
+	}
+
+	c := &Client{
+		name:      config.Name,
+		transport: t,
+		logger:    logger,
+		pending:   make(map[string]chan *message),
+	}
+	if err := t.start(ctx, c.receive, c.disconnected); err != nil {
+		return nil, fmt.Errorf("mcp server %s: %w", config.Name, err)
+	}
+
+	var result initializeResult
+	err := c.call(ctx, "initialize", initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      implementation{Name: "harness", Version: "1.0.0"},
+	}, &result)
+	if err == nil {
+		err = c.notify(ctx, "notifications/initialized", nil)
+	}
+	if err != nil {
+		t.close()
+		return nil, fmt.Errorf("mcp server %s: initialize: %w", config.Name, err)
+	}
+
+	logger.Info("mcp", "Connected to MCP server",
+		log.F("server", config.Name),
+		log.F("server_name", result.ServerInfo.Name),
+		log.F("server_version", result.ServerInfo.Version),
+		log.F("protocol_version", result.ProtocolVersion),
+	)
+	return c, nil
+}
+
+// Name returns the configured server name.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Tools lists the server's tools and returns a Tool for each that calls it
+// on the server. Tool names are prefixed with the server name.
+func (c *Client) Tools(ctx context.Context) ([]tool.Tool, error) {
+	var tools []tool.Tool
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var result listToolsResult
+		if err := c.call(ctx, "tools/list", params, &result); err != nil {
+			return nil, fmt.Errorf("mcp server %s: list tools: %w", c.name, err)
+		}
+		for _, info := range result.Tools {
+			tools = append(tools, newMCPTool(c, info))
+		}
+		if result.NextCursor == "" || result.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// CallTool calls the named server tool with JSON arguments.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*callToolResult, error) {
+	var result callToolResult
+	if err := c.call(ctx, "tools/call", callToolParams{Name: name, Arguments: arguments}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close disconnects from the server, stopping it if it is a subprocess.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closing = true
+	c.mu.Unlock()
+	return c.transport.close()
+}
+
+// call sends a request and decodes its result into out.
+// If ctx is cancelled first, the server is told to cancel the request.
+func (c *Client) call(ctx context.Context, method string, params any, out any) error {
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.nextID++
+	requestID := c.nextID
+	id := strconv.FormatInt(requestID, 10)
+	reply := make(chan *message, 1)
+	c.pending[id] = reply
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(ctx, message{ID: json.RawMessage(id), Method: method, Params: params}); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		c.notify(context.Background(), "notifications/cancelled", map[string]any{
+			"requestId": requestID,
+			"reason":    ctx.Err().Error(),
+		})
+		return ctx.Err()
+	case resp, ok := <-reply:
+		if !ok {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.err
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if out != nil {
+			if err := json.Unmarshal(resp.Result, out); err != nil {
+				return fmt.Errorf("invalid %s result: %w", method, err)
+			}
+		}
+		return nil
+	}
+}
+
+// notify sends a notification, which has no response.
+func (c *Client) notify(ctx context.Context, method string, params any) error {
+	return c.write(ctx, message{Method: method, Params: params})
+}
+
+// write encodes and sends a message.
+func (c *Client) write(ctx context.Context, msg message) error {
+	msg.JSONRPC = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.transport.send(ctx, data)
+}
+
+// receive handles one message from the server: responses are routed to the
+// waiting call, and server requests are answered.
+func (c *Client) receive(data []byte) {
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.logger.Warn("mcp", "Invalid message from MCP server",
+			log.F("server", c.name),
+			log.F("error", err.Error()),
+		)
+		return
+	}
+
+	switch {
+	case msg.Method != "" && len(msg.ID) > 0:
+		// A request from the server; only ping is supported
+		reply := message{ID: msg.ID}
+		if msg.Method == "ping" {
+			reply.Result = json.RawMessage("{}")
+		} else {
+			reply.Error = &rpcError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+		}
+		go c.write(context.Background(), reply)
+	case msg.Method != "":
+		// Notifications (e.g. progress, list changes) are not used
+	default:
+		c.mu.Lock()
+		reply, ok := c.pending[string(msg.ID)]
+		c.mu.Unlock()
+		if ok {
+			select {
+			case reply <- &msg:
+			default:
+				// Duplicate response; the first one wins
+			}
+		}
+	}
+}
+
+// disconnected fails all pending and future calls once the connection ends.
+func (c *Client) disconnected(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = fmt.Errorf("%w: %v", ErrClosed, err)
+	for id, reply := range c.pending {
+		close(reply)
+		delete(c.pending, id)
+	}
+	if !c.closing {
+		c.logger.Warn("mcp", "MCP server disconnected",
+			log.F("server", c.name),
+			log.F("error", err.Error()),
+		)
+	}
+}
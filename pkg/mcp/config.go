@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// validServerName matches server names usable as a tool name prefix.
+var validServerName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// ServerConfig describes how to reach one MCP server. Exactly one of Command
+// (stdio transport) or URL (SSE transport) must be set.
+type ServerConfig struct {
+	// Name identifies the server and prefixes its tool names.
+	Name string `json:"-"`
+
+	// Command and Args start a stdio server; Env adds to the inherited environment.
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+
+	// URL is the SSE endpoint of a remote server; Headers are sent with every
+	// request (e.g. Authorization).
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Validate checks that the configuration names exactly one transport.
+func (c ServerConfig) Validate() error {
+	if !validServerName.MatchString(c.Name) {
+		return fmt.Errorf("invalid mcp server name %q: use up to 32 letters, digits, _ or -", c.Name)
+	}
+	if c.Command == "" && c.URL == "" {
+		return fmt.Errorf("mcp server %s: command or url is required", c.Name)
+	}
+	if c.Command != "" && c.URL != "" {
+		return fmt.Errorf("mcp server %s: set only one of command and url", c.Name)
+	}
+	return nil
+}
+
+// configFile is the on-disk configuration format, shared with other MCP clients:
+//
+//	{"mcpServers": {"name": {"command": "...", "args": [...]}, "other": {"url": "..."}}}
+type configFile struct {
+	Servers map[string]ServerConfig `json:"mcpServers"`
+}
+
+// LoadConfig reads server configurations from a JSON file, sorted by name.
+func LoadConfig(path string) ([]ServerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file configFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid mcp config %s: %w", path, err)
+	}
+	if file.Servers == nil {
+		return nil, errors.New("invalid mcp config " + path + ": missing mcpServers")
+	}
+
+	configs := make([]ServerConfig, 0, len(file.Servers))
+	for name, config := range file.Servers {
+		config.Name = name
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+	return configs, nil
+}
@@ -0,0 +1,375 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/tool"
+)
+
+// TestMain runs the test binary as a stdio MCP server when asked to, so the
+// stdio transport can be tested against a real subprocess.
+func TestMain(m *testing.M) {
+	if os.Getenv("MCP_TEST_SERVER") == "1" {
+		serveStdio()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// serveStdio answers newline-delimited requests on stdin until it closes.
+func serveStdio() {
+	var mu sync.Mutex
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req message
+		if json.Unmarshal(scanner.Bytes(), &req) != nil {
+			continue
+		}
+		go func() {
+			if resp := fakeHandle(req); resp != nil {
+				data, _ := json.Marshal(resp)
+				mu.Lock()
+				os.Stdout.Write(append(data, '\n'))
+				mu.Unlock()
+			}
+		}()
+	}
+}
+
+// fakeHandle implements a small MCP server with echo, fail, and slow tools,
+// listed over two pages.
+func fakeHandle(req message) *message {
+	if len(req.ID) == 0 {
+		return nil
+	}
+	resp := &message{JSONRPC: "2.0", ID: req.ID}
+	params, _ := json.Marshal(req.Params)
+	switch req.Method {
+	case "initialize":
+		resp.Result = json.RawMessage(`{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"fake","version":"0.1"}}`)
+	case "tools/list":
+		if strings.Contains(string(params), `"cursor":"2"`) {
+			resp.Result = json.RawMessage(`{"tools":[{"name":"fail","description":"Always fails"},{"name":"slow","description":"Never returns"}]}`)
+		} else {
+			resp.Result = json.RawMessage(`{"tools":[{"name":"echo.text","description":"Echo text","inputSchema":{"type":"object","properties":{"text":{"type":"string"}}},"annotations":{"readOnlyHint":true}}],"nextCursor":"2"}`)
+		}
+	case "tools/call":
+		var call struct {
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments"`
+		}
+		json.Unmarshal(params, &call)
+		switch call.Name {
+		case "echo.text":
+			text, _ := json.Marshal(call.Arguments["text"])
+			resp.Result = json.RawMessage(`{"content":[{"type":"text","text":` + string(text) + `},{"type":"image","mimeType":"image/png","data":"AA=="}]}`)
+		case "fail":
+			resp.Result = json.RawMessage(`{"content":[{"type":"text","text":"boom"}],"isError":true}`)
+		case "slow":
+			return nil
+		default:
+			resp.Error = &rpcError{Code: -32602, Message: "unknown tool " + call.Name}
+		}
+	default:
+		resp.Error = &rpcError{Code: codeMethodNotFound, Message: "method not found"}
+	}
+	return resp
+}
+
+// newSSEServer serves fakeHandle over the HTTP+SSE transport and requires
+// the given bearer token.
+func newSSEServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	responses := make(chan []byte, 16)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": connected\n\nevent: endpoint\ndata: /messages?session=1\n\n")
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case data := <-responses:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+				w.(http.Flusher).Flush()
+			}
+		}
+	})
+	mux.HandleFunc("POST /messages", func(w http.ResponseWriter, r *http.Request) {
+		var req message
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		if resp := fakeHandle(req); resp != nil {
+			data, _ := json.Marshal(resp)
+			responses <- data
+		}
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func stdioConfig() ServerConfig {
+	return ServerConfig{
+		Name:    "fake",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^$"},
+		Env:     map[string]string{"MCP_TEST_SERVER": "1"},
+	}
+}
+
+// connectAndList connects to the server and returns its tools by name.
+func connectAndList(t *testing.T, config ServerConfig) (*Client, map[string]tool.Tool) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := Connect(ctx, config, nil)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	tools, err := client.Tools(ctx)
+	if err != nil {
+		t.Fatalf("Tools failed: %v", err)
+	}
+	byName := map[string]tool.Tool{}
+	for _, tl := range tools {
+		byName[tl.Name()] = tl
+	}
+	return client, byName
+}
+
+// testTools exercises the fake server's tools through the Tool interface.
+func testTools(t *testing.T, tools map[string]tool.Tool) {
+	t.Helper()
+	if len(tools) != 3 {
+		t.Fatalf("expected 3 tools across both pages, got %v", tools)
+	}
+
+	echo := tools["fake_echo_text"]
+	if echo == nil {
+		t.Fatalf("expected server-prefixed, sanitized name fake_echo_text, got %v", tools)
+	}
+	if echo.Description() != "Echo text" || !tool.IsReadOnly(echo) || !strings.Contains(string(echo.InputSchema()), `"text"`) {
+		t.Errorf("unexpected echo metadata: %q %v %s", echo.Description(), tool.IsReadOnly(echo), echo.InputSchema())
+	}
+	if tool.IsReadOnly(tools["fake_fail"]) || !strings.Contains(string(tools["fake_fail"].InputSchema()), `"object"`) {
+		t.Error("expected fail to be mutating with a default object schema")
+	}
+
+	result, err := echo.Execute(context.Background(), json.RawMessage(`{"text":"hello"}`))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result != `{"content":"hello\n[image content: image/png]"}` {
+		t.Errorf("unexpected echo result %s", result)
+	}
+
+	result, err = tools["fake_fail"].Execute(context.Background(), json.RawMessage(`{}`))
+	if err != nil || result != `{"error":"boom"}` {
+		t.Errorf("expected error result, got %s, %v", result, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := tools["fake_slow"].Execute(ctx, json.RawMessage(`{}`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestClient_Stdio(t *testing.T) {
+	_, tools := connectAndList(t, stdioConfig())
+	testTools(t, tools)
+}
+
+func TestClient_SSE(t *testing.T) {
+	ts := newSSEServer(t, "secret")
+	_, tools := connectAndList(t, ServerConfig{
+		Name:    "fake",
+		URL:     ts.URL + "/sse",
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	})
+	testTools(t, tools)
+}
+
+func TestClient_SSEUnauthorized(t *testing.T) {
+	ts := newSSEServer(t, "secret")
+	_, err := Connect(context.Background(), ServerConfig{Name: "fake", URL: ts.URL + "/sse"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected 401 error, got %v", err)
+	}
+}
+
+func TestClient_ServerExitFailsCalls(t *testing.T) {
+	client, tools := connectAndList(t, stdioConfig())
+	client.Close()
+
+	result, err := tools["fake_echo_text"].Execute(context.Background(), json.RawMessage(`{"text":"hi"}`))
+	if err != nil {
+		t.Fatalf("unexpected Go error: %v", err)
+	}
+	if !strings.Contains(result, "mcp server fake") || !strings.Contains(result, "closed") {
+		t.Errorf("expected connection closed error result, got %s", result)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "mcp.json")
+		os.WriteFile(path, []byte(content), 0644)
+		return path
+	}
+
+	configs, err := LoadConfig(write(`{"mcpServers": {
+		"web": {"url": "http://localhost:9000/sse", "headers": {"Authorization": "Bearer x"}},
+		"files": {"command": "mcp-files", "args": ["--root", "."], "env": {"DEBUG": "1"}}
+	}}`))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(configs) != 2 || configs[0].Name != "files" || configs[0].Command != "mcp-files" || configs[1].URL == "" {
+		t.Errorf("unexpected configs %+v", configs)
+	}
+
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`{"mcpServers": {"x": {}}}`, "command or url is required"},
+		{`{"mcpServers": {"x": {"command": "a", "url": "b"}}}`, "only one of command and url"},
+		{`{"mcpServers": {"bad name": {"command": "a"}}}`, "invalid mcp server name"},
+		{`{}`, "missing mcpServers"},
+		{`not json`, "invalid mcp config"},
+	}
+	for _, tt := range tests {
+		if _, err := LoadConfig(write(tt.content)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("config %s: expected error containing %q, got %v", tt.content, tt.want, err)
+		}
+	}
+}
+
+// localTool is a tool served by the tests of Server.
+type localTool struct {
+	name     string
+	readOnly bool
+	execute  func(ctx context.Context, input json.RawMessage) (string, error)
+}
+
+func (t *localTool) Name() string                 { return t.name }
+func (t *localTool) Description() string          { return "Local " + t.name }
+func (t *localTool) InputSchema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *localTool) ReadOnly() bool               { return t.readOnly }
+func (t *localTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	return t.execute(ctx, input)
+}
+
+// localTools returns an echo tool, a tool that fails, and one that blocks
+// until cancelled, reporting the cancellation on cancelled.
+func localTools(cancelled chan<- struct{}) []tool.Tool {
+	return []tool.Tool{
+		&localTool{name: "echo", readOnly: true, execute: func(ctx context.Context, input json.RawMessage) (string, error) {
+			return string(input), nil
+		}},
+		&localTool{name: "fail", execute: func(ctx context.Context, input json.RawMessage) (string, error) {
+			return `{"error":"boom"}`, nil
+		}},
+		&localTool{name: "slow", execute: func(ctx context.Context, input json.RawMessage) (string, error) {
+			<-ctx.Done()
+			cancelled <- struct{}{}
+			return "", ctx.Err()
+		}},
+	}
+}
+
+func TestServer_SSE(t *testing.T) {
+	srv := NewServer(localTools(make(chan struct{}, 1)), "secret", nil)
+	ts := httptest.NewServer(srv.Handler())
+	// Closed after the client, whose event stream would otherwise hold it open
+	t.Cleanup(ts.Close)
+
+	if _, err := Connect(context.Background(), ServerConfig{Name: "local", URL: ts.URL + "/sse"}, nil); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected 401 without the token, got %v", err)
+	}
+
+	_, tools := connectAndList(t, ServerConfig{
+		Name:    "local",
+		URL:     ts.URL + "/sse",
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	})
+	if len(tools) != 3 || !tool.IsReadOnly(tools["local_echo"]) || tool.IsReadOnly(tools["local_fail"]) {
+		t.Fatalf("unexpected tools %v", tools)
+	}
+
+	result, err := tools["local_echo"].Execute(context.Background(), json.RawMessage(`{"text":"hi"}`))
+	if err != nil || result != `{"content":"{\"text\":\"hi\"}"}` {
+		t.Errorf("unexpected echo result %s, %v", result, err)
+	}
+	result, err = tools["local_fail"].Execute(context.Background(), json.RawMessage(`{}`))
+	if err != nil || result != `{"error":"boom"}` {
+		t.Errorf("expected the error message, got %s, %v", result, err)
+	}
+}
+
+func TestServer_Stdio(t *testing.T) {
+	cancelled := make(chan struct{}, 1)
+	srv := NewServer(localTools(cancelled), "", nil)
+	in, send := io.Pipe()
+	var out strings.Builder
+	done := make(chan error, 1)
+	go func() { done <- srv.ServeStdio(context.Background(), in, &out) }()
+
+	fmt.Fprintln(send, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`)
+	fmt.Fprintln(send, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`)
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the slow call to be cancelled")
+	}
+	fmt.Fprintln(send, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"missing"}}`)
+	fmt.Fprintln(send, `not json`)
+	fmt.Fprintln(send, `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	send.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("ServeStdio failed: %v", err)
+	}
+
+	var responses []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var msg message
+		json.Unmarshal([]byte(line), &msg)
+		switch {
+		case msg.Error != nil:
+			responses = append(responses, fmt.Sprintf("%s:%d", msg.ID, msg.Error.Code))
+		default:
+			responses = append(responses, fmt.Sprintf("%s:%s", msg.ID, msg.Result))
+		}
+	}
+	sort.Strings(responses)
+	if got := strings.Join(responses, " "); got != "2:-32602 3:{} null:-32700" {
+		t.Errorf("expected no response to the cancelled call, got %s", got)
+	}
+}
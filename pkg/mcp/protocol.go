@@ -0,0 +1,117 @@
+// Package mcp connects to Model Context Protocol servers and exposes their
+// tools through the tool.Tool interface, so external tool providers can be
+// used without code changes. Server does the reverse, serving local tools to
+// other MCP clients.
+//
+// Two transports are supported:
+//
+//   - stdio: the server is started as a subprocess and exchanges
+//     newline-delimited JSON-RPC messages over stdin/stdout.
+//   - SSE: the client opens an event stream at the server URL, receives the
+//     endpoint to POST messages to in an "endpoint" event, and receives
+//     responses as "message" events.
+//
+// On connect the client performs the initialize handshake, then lists the
+// server's tools with tools/list and calls them with tools/call.
+package mcp
+
+import "encoding/json"
+
+// ProtocolVersion is the MCP protocol revision the client requests.
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// message is a JSON-RPC 2.0 request, notification, or response.
+// Requests have an ID and a method, notifications only a method, and
+// responses an ID with either a result or an error.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error object.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// implementation identifies a client or server in the initialize handshake.
+type implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// initializeParams are the parameters of the initialize request.
+type initializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      implementation `json:"clientInfo"`
+}
+
+// initializeResult is the server's response to initialize.
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities,omitempty"`
+	ServerInfo      implementation `json:"serverInfo"`
+}
+
+// toolInfo describes a tool published by a server.
+type toolInfo struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	InputSchema json.RawMessage  `json:"inputSchema"`
+	Annotations *toolAnnotations `json:"annotations,omitempty"`
+}
+
+// toolAnnotations are hints about a tool's behavior.
+type toolAnnotations struct {
+	ReadOnlyHint bool `json:"readOnlyHint"`
+}
+
+// listToolsResult is the server's response to tools/list.
+type listToolsResult struct {
+	Tools      []toolInfo `json:"tools"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+// callToolParams are the parameters of the tools/call request.
+type callToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// callToolResult is the server's response to tools/call.
+type callToolResult struct {
+	Content           []content       `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+// content is one item of a tool result. Only text is passed to the model;
+// other kinds are summarized.
+type content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Resource *struct {
+		URI  string `json:"uri"`
+		Text string `json:"text,omitempty"`
+	} `json:"resource,omitempty"`
+}
@@ -0,0 +1,418 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+	"github.com/user/harness/pkg/toolapi"
+)
+
+// sseSessionBuffer is how many messages an SSE session queues for its
+// stream before sends block.
+const sseSessionBuffer = 64
+
+// Server serves local tools to MCP clients, so other agents can use them
+// without the harness's model loop. It answers initialize, ping, tools/list,
+// and tools/call, over stdio with ServeStdio or HTTP+SSE with Handler.
+type Server struct {
+	tools  map[string]tool.Tool
+	infos  []toolInfo
+	token  string
+	logger log.Logger
+
+	mu       sync.Mutex
+	sessions map[string]*session // SSE sessions by ID
+}
+
+// NewServer creates a Server serving the given tools.
+// If token is non-empty, HTTP requests must carry it as a bearer token.
+// If logger is nil, a NopLogger is used.
+func NewServer(tools []tool.Tool, token string, logger log.Logger) *Server {
+	if logger == nil {
+		logger = log.NopLogger{}
+	}
+	s := &Server{
+		tools:    make(map[string]tool.Tool, len(tools)),
+		infos:    make([]toolInfo, len(tools)),
+		token:    token,
+		logger:   logger,
+		sessions: make(map[string]*session),
+	}
+	for i, t := range tools {
+		s.tools[t.Name()] = t
+		s.infos[i] = toolInfo{
+			Name:        t.Name(),
+			Description: t.Description(),
+			InputSchema: t.InputSchema(),
+		}
+		if tool.IsReadOnly(t) {
+			s.infos[i].Annotations = &toolAnnotations{ReadOnlyHint: true}
+		}
+	}
+	return s
+}
+
+// ServeStdio serves one client over newline-delimited JSON on in and out
+// until in ends and its requests are answered, or ctx is cancelled. Tool
+// calls run with contexts derived from ctx.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var mu sync.Mutex // serializes writes to out
+	c := s.newSession(ctx, func(data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := out.Write(append(data, '\n'))
+		return err
+	})
+	defer c.close(cancel)
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReaderSize(in, 64*1024)
+		for {
+			line, err := readLine(reader)
+			if len(bytes.TrimSpace(line)) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line := <-lines:
+			c.receive(line)
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				// The client is done sending; answer what it sent
+				c.wg.Wait()
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Handler returns the HTTP+SSE transport: GET /sse opens a session's event
+// stream, which announces the endpoint to POST the session's messages to,
+// and POST /message?sessionId=ID delivers them. Tool calls run with contexts
+// derived from the stream's request and are cancelled when it ends.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", s.HandleStream)
+	mux.HandleFunc("POST /message", s.HandleMessage)
+	return s.authMiddleware(mux)
+}
+
+// authMiddleware rejects requests without the configured bearer token.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			got := r.Header.Get("Authorization")
+			want := "Bearer " + s.token
+			if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleStream handles GET /sse requests by opening a session and streaming
+// its responses as "message" events until the client disconnects.
+func (s *Server) HandleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	id := newSessionID()
+	ctx, cancel := context.WithCancel(r.Context())
+	outgoing := make(chan []byte, sseSessionBuffer)
+	c := s.newSession(ctx, func(data []byte) error {
+		select {
+		case outgoing <- data:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	s.mu.Lock()
+	s.sessions[id] = c
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, id)
+		s.mu.Unlock()
+		c.close(cancel)
+	}()
+	s.logger.Info("mcp", "MCP client connected", log.F("session", id))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// The endpoint is relative, so the handler can be mounted under a prefix
+	fmt.Fprintf(w, "event: endpoint\ndata: message?sessionId=%s\n\n", id)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("mcp", "MCP client disconnected", log.F("session", id))
+			return
+		case data := <-outgoing:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// HandleMessage handles POST /message requests by delivering the message to
+// its session. The response to a request arrives on the session's stream.
+func (s *Server) HandleMessage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	c := s.sessions[r.URL.Query().Get("sessionId")]
+	s.mu.Unlock()
+	if c == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize+1))
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxMessageSize {
+		http.Error(w, fmt.Sprintf("message exceeds %d bytes", maxMessageSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+	c.receive(data)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// request is a JSON-RPC request or notification received by the server.
+type request struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// session is one client connection to the server.
+type session struct {
+	server *Server
+	ctx    context.Context
+	send   func([]byte) error
+
+	mu     sync.Mutex
+	calls  map[string]context.CancelFunc // requests in flight by ID
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// newSession creates a session whose requests run under ctx and whose
+// responses are written with send.
+func (s *Server) newSession(ctx context.Context, send func([]byte) error) *session {
+	return &session{
+		server: s,
+		ctx:    ctx,
+		send:   send,
+		calls:  make(map[string]context.CancelFunc),
+	}
+}
+
+// close cancels the session's requests with cancel and waits for them.
+func (c *session) close(cancel context.CancelFunc) {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	cancel()
+	c.wg.Wait()
+}
+
+// receive handles one message from the client. Each request is answered
+// from its own goroutine, so a slow tool call does not hold up the others.
+func (c *session) receive(data []byte) {
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		c.reply(json.RawMessage("null"), nil, &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()})
+		return
+	}
+
+	switch {
+	case req.Method == "" && len(req.ID) > 0:
+		// A response; the server sends no requests
+	case req.Method == "":
+		c.reply(json.RawMessage("null"), nil, &rpcError{Code: codeInvalidRequest, Message: "method is required"})
+	case len(req.ID) == 0:
+		c.notification(req)
+	default:
+		ctx, cancel := context.WithCancel(c.ctx)
+		id := string(req.ID)
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			cancel()
+			return
+		}
+		c.calls[id] = cancel
+		c.wg.Add(1)
+		c.mu.Unlock()
+
+		go func() {
+			defer c.wg.Done()
+			defer func() {
+				c.mu.Lock()
+				delete(c.calls, id)
+				c.mu.Unlock()
+				cancel()
+			}()
+			result, rpcErr := c.server.handle(ctx, req)
+			if ctx.Err() != nil {
+				// Cancelled requests get no response
+				return
+			}
+			c.reply(req.ID, result, rpcErr)
+		}()
+	}
+}
+
+// notification handles a notification. Only cancellation is acted on.
+func (c *session) notification(req request) {
+	if req.Method != "notifications/cancelled" {
+		return
+	}
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if json.Unmarshal(req.Params, &params) != nil {
+		return
+	}
+	c.mu.Lock()
+	cancel, ok := c.calls[string(params.RequestID)]
+	c.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// reply sends the response to the request with the given ID.
+func (c *session) reply(id json.RawMessage, result any, rpcErr *rpcError) {
+	msg := message{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			msg.Error = &rpcError{Code: codeInternalError, Message: err.Error()}
+		} else {
+			msg.Result = data
+		}
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	if err := c.send(data); err != nil && c.ctx.Err() == nil {
+		c.server.logger.Warn("mcp", "Failed to send MCP response", log.F("error", err.Error()))
+	}
+}
+
+// handle answers a request.
+func (s *Server) handle(ctx context.Context, req request) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      implementation{Name: "harness", Version: "1.0.0"},
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return listToolsResult{Tools: s.infos}, nil
+	case "tools/call":
+		var params callToolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "tools/call requires a tool name"}
+		}
+		return s.callTool(ctx, params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+// callTool executes a tool call. Tool failures are results with isError
+// set, so the client's model can react to them.
+func (s *Server) callTool(ctx context.Context, params callToolParams) (any, *rpcError) {
+	t, ok := s.tools[params.Name]
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + params.Name}
+	}
+	input := params.Arguments
+	if len(input) == 0 {
+		input = json.RawMessage("{}")
+	}
+
+	start := time.Now()
+	result, err := t.Execute(ctx, input)
+	if err != nil {
+		result = toolapi.Failure(err.Error())
+	}
+	isError := tool.IsErrorResult(result)
+
+	s.logger.Info("tool", "MCP tool call completed",
+		log.F("tool", params.Name),
+		log.F("is_error", isError),
+		log.F("duration_ms", time.Since(start).Milliseconds()),
+	)
+
+	text := result
+	if isError {
+		text = errorMessage(result)
+	}
+	return callToolResult{Content: []content{{Type: "text", Text: text}}, IsError: isError}, nil
+}
+
+// errorMessage returns the message of an error result that has nothing but
+// its message, and the whole result otherwise.
+func errorMessage(result string) string {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal([]byte(result), &fields) != nil || len(fields) != 1 {
+		return result
+	}
+	var msg string
+	if json.Unmarshal(fields["error"], &msg) != nil {
+		return result
+	}
+	return msg
+}
+
+// newSessionID returns a random SSE session identifier.
+func newSessionID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/user/harness/pkg/toolapi"
+)
+
+// maxToolNameLength is the longest tool name the API accepts.
+const maxToolNameLength = 64
+
+// invalidToolNameChars matches characters not allowed in API tool names.
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// MCPTool is a Tool whose calls execute on an MCP server.
+type MCPTool struct {
+	client   *Client
+	name     string
+	info     toolInfo
+	readOnly bool
+}
+
+// mcpOutput is the result returned to the model for a successful call.
+type mcpOutput struct {
+	Content    string          `json:"content"`
+	Structured json.RawMessage `json:"structured,omitempty"`
+}
+
+// newMCPTool wraps a server tool, prefixing its name with the server name.
+func newMCPTool(c *Client, info toolInfo) *MCPTool {
+	name := invalidToolNameChars.ReplaceAllString(c.name+"_"+info.Name, "_")
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	schema := info.InputSchema
+	if len(schema) == 0 {
+		schema = json.RawMessage(`{"type": "object", "properties": {}}`)
+	}
+	info.InputSchema = schema
+	return &MCPTool{
+		client:   c,
+		name:     name,
+		info:     info,
+		readOnly: info.Annotations != nil && info.Annotations.ReadOnlyHint,
+	}
+}
+
+// Name returns the server-prefixed tool name, e.g. "github_create_issue".
+func (t *MCPTool) Name() string {
+	return t.name
+}
+
+// Description returns the description published by the server.
+func (t *MCPTool) Description() string {
+	return t.info.Description
+}
+
+// InputSchema returns the input schema published by the server.
+func (t *MCPTool) InputSchema() json.RawMessage {
+	return t.info.InputSchema
+}
+
+// ReadOnly reports whether the server annotated the tool as read-only.
+func (t *MCPTool) ReadOnly() bool {
+	return t.readOnly
+}
+
+// Execute calls the tool on the server.
+// Server and transport failures are returned as JSON error results so the
+// model can react to them; cancellation is returned as a Go error.
+func (t *MCPTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	result, err := t.client.CallTool(ctx, t.info.Name, input)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return toolapi.Failure(fmt.Sprintf("mcp server %s: %s", t.client.Name(), err)), nil
+	}
+
+	text := contentText(result.Content)
+	if result.IsError {
+		return toolapi.Failure(text), nil
+	}
+	return toolapi.Success(mcpOutput{Content: text, Structured: result.StructuredContent}), nil
+}
+
+// contentText joins the text of a tool result, describing non-text items.
+func contentText(items []content) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		switch {
+		case item.Type == "text":
+			parts = append(parts, item.Text)
+		case item.Type == "resource" && item.Resource != nil && item.Resource.Text != "":
+			parts = append(parts, item.Resource.Text)
+		case item.Type == "resource" && item.Resource != nil:
+			parts = append(parts, fmt.Sprintf("[resource: %s]", item.Resource.URI))
+		default:
+			parts = append(parts, fmt.Sprintf("[%s content: %s]", item.Type, item.MimeType))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
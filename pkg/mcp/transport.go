@@ -0,0 +1,301 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxMessageSize is the largest message accepted from a server (16 MB).
+	maxMessageSize = 16 * 1024 * 1024
+	// stdioExitTimeout is how long a stdio server may take to exit after its
+	// stdin is closed before it is killed.
+	stdioExitTimeout = 2 * time.Second
+)
+
+// transport carries JSON-RPC messages to and from a server.
+type transport interface {
+	// start connects and begins delivering incoming messages to deliver.
+	// done is called once when the connection ends.
+	start(ctx context.Context, deliver func([]byte), done func(error)) error
+	// send writes one message to the server.
+	send(ctx context.Context, msg []byte) error
+	// close disconnects and releases the transport's resources.
+	close() error
+}
+
+// stdioTransport runs a server as a subprocess and talks to it over
+// newline-delimited JSON on stdin and stdout.
+type stdioTransport struct {
+	command string
+	args    []string
+	env     map[string]string
+	stderr  io.Writer
+
+	mu     sync.Mutex // serializes writes to stdin
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	exited chan struct{}
+}
+
+// start launches the subprocess and reads its stdout until it exits.
+func (t *stdioTransport) start(ctx context.Context, deliver func([]byte), done func(error)) error {
+	// The process outlives ctx, which only bounds the connection attempt
+	cmd := exec.Command(t.command, t.args...)
+	cmd.Env = os.Environ()
+	for k, v := range t.env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = t.stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start %s: %w", t.command, err)
+	}
+	t.cmd, t.stdin, t.exited = cmd, stdin, make(chan struct{})
+
+	go func() {
+		reader := bufio.NewReaderSize(stdout, 64*1024)
+		var readErr error
+		for {
+			line, err := readLine(reader)
+			if len(bytes.TrimSpace(line)) > 0 {
+				deliver(line)
+			}
+			if err != nil {
+				readErr = err
+				break
+			}
+		}
+		waitErr := cmd.Wait()
+		close(t.exited)
+		if errors.Is(readErr, io.EOF) {
+			readErr = waitErr
+		}
+		done(orError(readErr, errors.New("server exited")))
+	}()
+	return nil
+}
+
+// send writes msg followed by a newline to the subprocess's stdin.
+func (t *stdioTransport) send(ctx context.Context, msg []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := t.stdin.Write(append(msg, '\n'))
+	return err
+}
+
+// close closes stdin, which asks the server to exit, and kills it if it
+// does not.
+func (t *stdioTransport) close() error {
+	if t.cmd == nil {
+		return nil
+	}
+	t.stdin.Close()
+	select {
+	case <-t.exited:
+		return nil
+	case <-time.After(stdioExitTimeout):
+		return t.cmd.Process.Kill()
+	}
+}
+
+// readLine reads one newline-terminated line of at most maxMessageSize bytes.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		line = append(line, chunk...)
+		if len(line) > maxMessageSize {
+			return nil, fmt.Errorf("message exceeds %d bytes", maxMessageSize)
+		}
+		if err != nil || !isPrefix {
+			return line, err
+		}
+	}
+}
+
+// sseTransport talks to a server over the HTTP+SSE transport: responses
+// arrive on a long-lived event stream, and requests are POSTed to the
+// endpoint the server announces on that stream.
+type sseTransport struct {
+	url     string
+	headers map[string]string
+	http    *http.Client
+
+	endpoint string
+	cancel   context.CancelFunc
+}
+
+// start opens the event stream and waits for the endpoint event.
+func (t *sseTransport) start(ctx context.Context, deliver func([]byte), done func(error)) error {
+	// The stream outlives ctx, which only bounds the connection attempt
+	streamCtx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, t.url, nil)
+	if err != nil {
+		cancel()
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	t.setHeaders(req)
+
+	type connected struct {
+		resp *http.Response
+		err  error
+	}
+	result := make(chan connected, 1)
+	go func() {
+		resp, err := t.http.Do(req)
+		result <- connected{resp, err}
+	}()
+
+	var resp *http.Response
+	select {
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	case c := <-result:
+		if c.err != nil {
+			cancel()
+			return c.err
+		}
+		resp = c.resp
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+
+	endpoint := make(chan string, 1)
+	go func() {
+		defer resp.Body.Close()
+		err := readEvents(resp.Body, func(event, data string) {
+			switch event {
+			case "endpoint":
+				select {
+				case endpoint <- data:
+				default:
+				}
+			case "message", "":
+				deliver([]byte(data))
+			}
+		})
+		done(orError(err, errors.New("event stream closed")))
+		close(endpoint)
+	}()
+
+	select {
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	case e, ok := <-endpoint:
+		if !ok {
+			return errors.New("event stream closed before the endpoint event")
+		}
+		base, _ := url.Parse(t.url)
+		ref, err := url.Parse(e)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("invalid endpoint %q: %w", e, err)
+		}
+		t.endpoint = base.ResolveReference(ref).String()
+		return nil
+	}
+}
+
+// send POSTs msg to the announced endpoint.
+func (t *sseTransport) send(ctx context.Context, msg []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	t.setHeaders(req)
+
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// close ends the event stream.
+func (t *sseTransport) close() error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	return nil
+}
+
+// setHeaders adds the configured headers to req.
+func (t *sseTransport) setHeaders(req *http.Request) {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+}
+
+// readEvents parses a text/event-stream body and calls fn for each event.
+func readEvents(body io.Reader, fn func(event, data string)) error {
+	reader := bufio.NewReaderSize(body, 64*1024)
+	var event string
+	var data []string
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			return err
+		}
+		text := string(line)
+		switch {
+		case text == "":
+			if len(data) > 0 {
+				fn(event, strings.Join(data, "\n"))
+			}
+			event, data = "", nil
+		case strings.HasPrefix(text, ":"):
+			// Comment, e.g. a keepalive
+		default:
+			field, value, _ := strings.Cut(text, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+		}
+	}
+}
+
+// orError returns err, or fallback if err is nil.
+func orError(err, fallback error) error {
+	if err != nil {
+		return err
+	}
+	return fallback
+}
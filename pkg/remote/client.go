@@ -0,0 +1,145 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/user/harness/pkg/tool"
+	"github.com/user/harness/pkg/toolapi"
+)
+
+// Client dispatches jobs to a remote worker.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewClient creates a Client for the worker at baseURL.
+// token may be empty if the worker does not require authentication.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{},
+	}
+}
+
+// Tools fetches the worker's published tools and returns a Tool for each
+// that executes on the worker.
+func (c *Client) Tools(ctx context.Context) ([]tool.Tool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/tools", nil)
+	if err != nil {
+		return nil, err
+	}
+	var specs []ToolSpec
+	if err := c.do(req, &specs); err != nil {
+		return nil, fmt.Errorf("list worker tools: %w", err)
+	}
+
+	tools := make([]tool.Tool, len(specs))
+	for i, spec := range specs {
+		tools[i] = &RemoteTool{spec: spec, client: c}
+	}
+	return tools, nil
+}
+
+// Execute sends a job to the worker and waits for its result.
+// Cancelling ctx aborts the request, which cancels the job on the worker.
+func (c *Client) Execute(ctx context.Context, job Job) (JobResult, error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return JobResult{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/jobs", bytes.NewReader(body))
+	if err != nil {
+		return JobResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var result JobResult
+	if err := c.do(req, &result); err != nil {
+		return JobResult{}, err
+	}
+	return result, nil
+}
+
+// do sends req and decodes a JSON response into out.
+func (c *Client) do(req *http.Request, out any) error {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("worker returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// RemoteTool is a Tool whose calls execute on a remote worker.
+type RemoteTool struct {
+	spec   ToolSpec
+	client *Client
+}
+
+// Name returns the tool name published by the worker.
+func (t *RemoteTool) Name() string {
+	return t.spec.Name
+}
+
+// Description returns the tool description published by the worker.
+func (t *RemoteTool) Description() string {
+	return t.spec.Description
+}
+
+// InputSchema returns the input schema published by the worker.
+func (t *RemoteTool) InputSchema() json.RawMessage {
+	return t.spec.InputSchema
+}
+
+// ReadOnly reports whether the worker declared the tool read-only.
+func (t *RemoteTool) ReadOnly() bool {
+	return t.spec.ReadOnly
+}
+
+// Execute dispatches the call to the worker.
+// Transport failures are returned as JSON error results so the model can
+// react to an unavailable worker; cancellation is returned as a Go error.
+func (t *RemoteTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	result, err := t.client.Execute(ctx, Job{ID: newJobID(), Tool: t.spec.Name, Input: input})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return toolapi.Failure("remote worker: " + err.Error()), nil
+	}
+	if result.Error != "" {
+		return "", errors.New(result.Error)
+	}
+	return result.Result, nil
+}
+
+// newJobID returns a random job identifier.
+func newJobID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "job_" + hex.EncodeToString(buf)
+}
@@ -0,0 +1,39 @@
+// Package remote runs tool calls on remote workers over a simple HTTP job
+// protocol. A worker is a process with access to the code; it publishes its
+// tools and executes jobs. The harness side wraps each published tool in a
+// Tool that serializes calls to the worker, so the reasoning server can run
+// separately from the machines that hold the files.
+//
+// Protocol:
+//
+//	GET  /tools  -> []ToolSpec
+//	POST /jobs   Job -> JobResult
+//
+// Requests carry "Authorization: Bearer <token>" when a token is configured.
+// A job is executed synchronously; closing the request cancels it.
+package remote
+
+import "encoding/json"
+
+// ToolSpec describes a tool published by a worker.
+type ToolSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+	ReadOnly    bool            `json:"read_only,omitempty"`
+}
+
+// Job is a single tool call dispatched to a worker.
+type Job struct {
+	ID    string          `json:"id"`
+	Tool  string          `json:"tool"`
+	Input json.RawMessage `json:"input"`
+}
+
+// JobResult is a worker's response to a Job.
+// Error is set when the tool returned a Go error; Result holds its output otherwise.
+type JobResult struct {
+	ID     string `json:"id"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,138 @@
+package remote_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/remote"
+	"github.com/user/harness/pkg/tool"
+)
+
+// echoTool returns its input; fails with a Go error when input has "fail".
+type echoTool struct{ readOnly bool }
+
+func (t *echoTool) Name() string        { return "echo" }
+func (t *echoTool) Description() string { return "Echo input" }
+func (t *echoTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{"value":{"type":"string"}}}`)
+}
+func (t *echoTool) ReadOnly() bool { return t.readOnly }
+func (t *echoTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	if strings.Contains(string(input), "fail") {
+		return "", errors.New("echo failed")
+	}
+	if strings.Contains(string(input), "block") {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	return string(input), nil
+}
+
+func newWorker(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	w := remote.NewWorker([]tool.Tool{&echoTool{readOnly: true}}, token, nil)
+	ts := httptest.NewServer(w.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClient_ToolsMirrorWorkerSpecs(t *testing.T) {
+	ts := newWorker(t, "")
+	tools, err := remote.NewClient(ts.URL, "").Tools(context.Background())
+	if err != nil {
+		t.Fatalf("Tools failed: %v", err)
+	}
+	if len(tools) != 1 {
+		t.Fatalf("expected 1 tool, got %d", len(tools))
+	}
+	if tools[0].Name() != "echo" || tools[0].Description() != "Echo input" {
+		t.Errorf("unexpected tool metadata: %s / %s", tools[0].Name(), tools[0].Description())
+	}
+	if !tool.IsReadOnly(tools[0]) {
+		t.Error("expected read-only flag to be carried over")
+	}
+}
+
+func TestRemoteTool_Execute(t *testing.T) {
+	ts := newWorker(t, "secret")
+	tools, err := remote.NewClient(ts.URL, "secret").Tools(context.Background())
+	if err != nil {
+		t.Fatalf("Tools failed: %v", err)
+	}
+	echo := tools[0]
+
+	result, err := echo.Execute(context.Background(), json.RawMessage(`{"value":"hi"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != `{"value":"hi"}` {
+		t.Errorf("unexpected result: %s", result)
+	}
+
+	_, err = echo.Execute(context.Background(), json.RawMessage(`{"value":"fail"}`))
+	if err == nil || err.Error() != "echo failed" {
+		t.Errorf("expected worker error to propagate, got %v", err)
+	}
+}
+
+func TestRemoteTool_Cancellation(t *testing.T) {
+	ts := newWorker(t, "")
+	tools, _ := remote.NewClient(ts.URL, "").Tools(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := tools[0].Execute(ctx, json.RawMessage(`{"value":"block"}`))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	ts := newWorker(t, "secret")
+	_, err := remote.NewClient(ts.URL, "wrong").Tools(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected 401 error, got %v", err)
+	}
+}
+
+func TestRemoteTool_UnreachableWorkerIsErrorResult(t *testing.T) {
+	ts := newWorker(t, "")
+	tools, _ := remote.NewClient(ts.URL, "").Tools(context.Background())
+	ts.Close()
+
+	result, err := tools[0].Execute(context.Background(), json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("expected error result, got Go error %v", err)
+	}
+	if !tool.IsErrorResult(result) {
+		t.Errorf("expected JSON error result, got %s", result)
+	}
+}
+
+func TestListenAddr(t *testing.T) {
+	tests := []struct {
+		addr, token, want string
+		wantErr           bool
+	}{
+		{"", "secret", remote.DefaultAddr, false},
+		{"", "", remote.DefaultInsecureAddr, false},
+		{"0.0.0.0:9000", "secret", "0.0.0.0:9000", false},
+		{"127.0.0.1:9000", "", "127.0.0.1:9000", false},
+		{"[::1]:9000", "", "[::1]:9000", false},
+		{"localhost:9000", "", "localhost:9000", false},
+		{":9000", "", "", true},
+		{"10.0.0.5:9000", "", "", true},
+		{"example.com:9000", "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := remote.ListenAddr(tt.addr, tt.token)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ListenAddr(%q, %q) = %q, %v; want %q, error %v", tt.addr, tt.token, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
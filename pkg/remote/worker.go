@@ -0,0 +1,138 @@
+package remote
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
+)
+
+// Default worker listen addresses. Without a token the worker accepts
+// connections only from the local machine.
+const (
+	DefaultAddr         = ":8081"
+	DefaultInsecureAddr = "127.0.0.1:8081"
+)
+
+// ListenAddr returns the address a worker with token should listen on.
+// An empty addr selects the default. Without a token, jobs are
+// unauthenticated, so addr must be a loopback address.
+func ListenAddr(addr, token string) (string, error) {
+	if token != "" {
+		if addr == "" {
+			return DefaultAddr, nil
+		}
+		return addr, nil
+	}
+	if addr == "" {
+		return DefaultInsecureAddr, nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid worker address %q: %w", addr, err)
+	}
+	if ip, err := netip.ParseAddr(host); (err == nil && ip.IsLoopback()) || host == "localhost" {
+		return addr, nil
+	}
+	return "", fmt.Errorf("worker address %q is not a loopback address: set a token to listen on other interfaces", addr)
+}
+
+// Worker executes jobs against a local set of tools.
+type Worker struct {
+	tools  map[string]tool.Tool
+	specs  []ToolSpec
+	token  string
+	logger log.Logger
+}
+
+// NewWorker creates a Worker serving the given tools.
+// If token is non-empty, requests must carry it as a bearer token.
+// If logger is nil, a NopLogger is used.
+func NewWorker(tools []tool.Tool, token string, logger log.Logger) *Worker {
+	if logger == nil {
+		logger = log.NopLogger{}
+	}
+	w := &Worker{
+		tools:  make(map[string]tool.Tool, len(tools)),
+		specs:  make([]ToolSpec, len(tools)),
+		token:  token,
+		logger: logger,
+	}
+	for i, t := range tools {
+		w.tools[t.Name()] = t
+		w.specs[i] = ToolSpec{
+			Name:        t.Name(),
+			Description: t.Description(),
+			InputSchema: t.InputSchema(),
+			ReadOnly:    tool.IsReadOnly(t),
+		}
+	}
+	return w
+}
+
+// Handler returns the worker's HTTP handler.
+func (w *Worker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tools", w.HandleTools)
+	mux.HandleFunc("POST /jobs", w.HandleJob)
+	return w.authMiddleware(mux)
+}
+
+// authMiddleware rejects requests without the configured bearer token.
+func (w *Worker) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if w.token != "" {
+			got := r.Header.Get("Authorization")
+			want := "Bearer " + w.token
+			if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+				http.Error(rw, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// HandleTools handles GET /tools requests.
+func (w *Worker) HandleTools(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(w.specs)
+}
+
+// HandleJob handles POST /jobs requests by executing the tool call.
+// The job runs with the request context, so a dropped connection cancels it.
+func (w *Worker) HandleJob(rw http.ResponseWriter, r *http.Request) {
+	var job Job
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		http.Error(rw, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	t, ok := w.tools[job.Tool]
+	if !ok {
+		http.Error(rw, "unknown tool: "+job.Tool, http.StatusNotFound)
+		return
+	}
+
+	start := time.Now()
+	result, err := t.Execute(r.Context(), job.Input)
+	resp := JobResult{ID: job.ID, Result: result}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	w.logger.Info("tool", "Remote job completed",
+		log.F("tool", job.Tool),
+		log.F("id", job.ID),
+		log.F("is_error", err != nil),
+		log.F("duration_ms", time.Since(start).Milliseconds()),
+	)
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(resp)
+}
@@ -0,0 +1,393 @@
+// Package replay reads JSON agent logs, reconstructs the conversations they
+// record, and re-drives a harness against them with mocked API responses and
+// tool results to check that it still makes the same requests.
+package replay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// ErrNoRequests is returned for a log without api_request entries, such as
+// one written in the text format.
+var ErrNoRequests = errors.New("log has no api_request entries")
+
+// maxLineSize bounds one log line; API requests carry the whole history.
+const maxLineSize = 64 << 20
+
+// Read parses a JSON agent log, one AgentEntry per line. Blank lines are
+// skipped.
+func Read(r io.Reader) ([]log.AgentEntry, error) {
+	var entries []log.AgentEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry log.AgentEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// FilterRun returns the entries of run runID.
+func FilterRun(entries []log.AgentEntry, runID string) []log.AgentEntry {
+	var filtered []log.AgentEntry
+	for _, entry := range entries {
+		if entry.RunID == runID {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// Conversation reconstructs the conversation as it stood at the end of the
+// entries: the history sent with the last API request, followed by the
+// response to it and the results of its tool calls.
+func Conversation(entries []log.AgentEntry) ([]anthropic.MessageParam, error) {
+	last := -1
+	for i, entry := range entries {
+		if entry.Type == log.AgentAPIRequest {
+			last = i
+		}
+	}
+	if last < 0 {
+		return nil, ErrNoRequests
+	}
+
+	var request struct {
+		Messages []anthropic.MessageParam `json:"messages"`
+	}
+	if err := json.Unmarshal(entries[last].Request, &request); err != nil {
+		return nil, fmt.Errorf("decode api_request: %w", err)
+	}
+	messages := request.Messages
+
+	var results []anthropic.ContentBlockParamUnion
+	for _, entry := range entries[last+1:] {
+		switch entry.Type {
+		case log.AgentAPIResponse:
+			message, err := decodeResponse(entry)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, message.ToParam())
+		case log.AgentToolResult:
+			results = append(results, anthropic.NewToolResultBlock(entry.ID, entry.Result, entry.IsError))
+		}
+	}
+	if len(results) > 0 {
+		messages = append(messages, anthropic.NewUserMessage(results...))
+	}
+	return messages, nil
+}
+
+// decodeResponse decodes the message of an api_response entry.
+func decodeResponse(entry log.AgentEntry) (anthropic.Message, error) {
+	var message anthropic.Message
+	if err := json.Unmarshal(entry.Response, &message); err != nil {
+		return anthropic.Message{}, fmt.Errorf("decode api_response: %w", err)
+	}
+	return message, nil
+}
+
+// Report is the outcome of replaying a log.
+type Report struct {
+	Runs        int          // runs replayed
+	Requests    int          // API requests the harness made
+	Divergences []Divergence // differences from the log, in order
+}
+
+// Divergence is a difference between a replayed run and the log.
+type Divergence struct {
+	RunID   string
+	Request int // index of the API request within the run, from 0
+	Message string
+}
+
+// String formats the divergence for display.
+func (d Divergence) String() string {
+	return fmt.Sprintf("%s request %d: %s", d.RunID, d.Request, d.Message)
+}
+
+// loggedRun is the part of the log one run recorded.
+type loggedRun struct {
+	id        string
+	prompt    *string                     // nil for a continued run
+	requests  []json.RawMessage           // logged MessageNewParams
+	responses []anthropic.Message         // in request order
+	steering  map[int][]string            // injected messages by the request carrying them
+	results   map[string][]log.AgentEntry // tool results by tool name, in order
+}
+
+// Run re-drives a harness through the runs of a log, in order, on one
+// conversation. Each API request is answered with the logged response and
+// each tool call with the logged result, and the messages the harness sends
+// are compared with the logged requests. Injected messages are injected
+// before the request that carried them. Entries without a run ID are
+// ignored.
+func Run(ctx context.Context, entries []log.AgentEntry) (Report, error) {
+	runs, err := splitRuns(entries)
+	if err != nil {
+		return Report{}, err
+	}
+	if len(runs) == 0 {
+		return Report{}, ErrNoRequests
+	}
+
+	var model string
+	var first struct {
+		Model string `json:"model"`
+	}
+	if json.Unmarshal(runs[0].requests[0], &first) == nil {
+		model = first.Model
+	}
+
+	streamer := &replayStreamer{}
+	var tools []tool.Tool
+	results := &toolResults{queues: make(map[string][]log.AgentEntry)}
+	for _, name := range toolNames(entries) {
+		tools = append(tools, &replayTool{name: name, results: results})
+	}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: model}, tools, nil, streamer)
+	if err != nil {
+		return Report{}, err
+	}
+	streamer.harness = h
+
+	var report Report
+	for _, run := range runs {
+		streamer.start(run)
+		results.set(run.results)
+
+		runCtx := log.WithRunID(ctx, run.id)
+		if run.prompt != nil {
+			_, err = h.PromptWithOptions(runCtx, *run.prompt, harness.PromptOptions{MaxTurns: len(run.requests)})
+		} else {
+			_, err = h.Continue(runCtx)
+		}
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		report.Runs++
+		report.Requests += len(streamer.recorded)
+		report.Divergences = append(report.Divergences, compareRequests(run, streamer.recorded)...)
+		if errors.Is(err, harness.ErrNothingToContinue) {
+			report.Divergences = append(report.Divergences, Divergence{RunID: run.id, Message: "the conversation could not be continued"})
+		}
+	}
+	return report, nil
+}
+
+// splitRuns groups the entries of each run, in the order the runs started.
+// Runs without API requests are left out.
+func splitRuns(entries []log.AgentEntry) ([]*loggedRun, error) {
+	byID := make(map[string]*loggedRun)
+	var order []*loggedRun
+	names := make(map[string]string) // tool name by call ID
+	for _, entry := range entries {
+		if entry.RunID == "" {
+			continue
+		}
+		run := byID[entry.RunID]
+		if run == nil {
+			run = &loggedRun{
+				id:       entry.RunID,
+				steering: make(map[int][]string),
+				results:  make(map[string][]log.AgentEntry),
+			}
+			byID[entry.RunID] = run
+			order = append(order, run)
+		}
+
+		switch entry.Type {
+		case log.AgentPrompt:
+			if run.prompt == nil && len(run.requests) == 0 {
+				content := entry.Content
+				run.prompt = &content
+			} else {
+				// Steering is carried by the next request
+				run.steering[len(run.requests)] = append(run.steering[len(run.requests)], entry.Content)
+			}
+		case log.AgentAPIRequest:
+			run.requests = append(run.requests, entry.Request)
+		case log.AgentAPIResponse:
+			message, err := decodeResponse(entry)
+			if err != nil {
+				return nil, err
+			}
+			run.responses = append(run.responses, message)
+		case log.AgentToolCall:
+			names[entry.ID] = entry.Name
+		case log.AgentToolResult:
+			name := names[entry.ID]
+			run.results[name] = append(run.results[name], entry)
+		}
+	}
+
+	var runs []*loggedRun
+	for _, run := range order {
+		if len(run.requests) > 0 {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+// toolNames returns the names of the tools called in the log.
+func toolNames(entries []log.AgentEntry) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, entry := range entries {
+		if entry.Type == log.AgentToolCall && !seen[entry.Name] {
+			seen[entry.Name] = true
+			names = append(names, entry.Name)
+		}
+	}
+	return names
+}
+
+// compareRequests reports where the requests the harness made differ from
+// the logged ones. Only the messages are compared: the system prompt and
+// tool definitions of the replay harness are not the original ones.
+func compareRequests(run *loggedRun, recorded []anthropic.MessageNewParams) []Divergence {
+	var divergences []Divergence
+	for i, logged := range run.requests {
+		if i >= len(recorded) {
+			divergences = append(divergences, Divergence{RunID: run.id, Request: i,
+				Message: fmt.Sprintf("the harness made %d requests, the log has %d", len(recorded), len(run.requests))})
+			break
+		}
+		var want struct {
+			Messages []any `json:"messages"`
+		}
+		json.Unmarshal(logged, &want)
+		var got struct {
+			Messages []any `json:"messages"`
+		}
+		if data, err := json.Marshal(recorded[i]); err == nil {
+			json.Unmarshal(data, &got)
+		}
+		if msg, ok := diffMessages(want.Messages, got.Messages); !ok {
+			divergences = append(divergences, Divergence{RunID: run.id, Request: i, Message: msg})
+		}
+	}
+	if len(recorded) > len(run.requests) {
+		divergences = append(divergences, Divergence{RunID: run.id, Request: len(run.requests),
+			Message: fmt.Sprintf("the harness made %d requests, the log has %d", len(recorded), len(run.requests))})
+	}
+	return divergences
+}
+
+// diffMessages describes the first difference between two decoded message
+// lists.
+func diffMessages(want, got []any) (string, bool) {
+	for i := range min(len(want), len(got)) {
+		if !reflect.DeepEqual(want[i], got[i]) {
+			return fmt.Sprintf("message %d differs", i), false
+		}
+	}
+	if len(want) != len(got) {
+		return fmt.Sprintf("%d messages sent, the log has %d", len(got), len(want)), false
+	}
+	return "", true
+}
+
+// replayStreamer answers the requests of the current run with its logged
+// responses, recording the requests made.
+type replayStreamer struct {
+	harness  *harness.Harness
+	run      *loggedRun
+	recorded []anthropic.MessageNewParams
+}
+
+// start prepares the streamer for run.
+func (s *replayStreamer) start(run *loggedRun) {
+	s.run = run
+	s.recorded = nil
+}
+
+// NewStreaming returns the logged response to the next request. Messages
+// injected before the request after it are injected now, while the run is
+// in progress, so the harness adds them before that request.
+func (s *replayStreamer) NewStreaming(ctx context.Context, params anthropic.MessageNewParams) harness.StreamIterator {
+	i := len(s.recorded)
+	s.recorded = append(s.recorded, params)
+	for _, content := range s.run.steering[i+1] {
+		s.harness.Inject(ctx, content)
+	}
+	if i >= len(s.run.responses) {
+		return testutil.NewMockStreamWithError(fmt.Errorf("no logged response to request %d of %s", i, s.run.id))
+	}
+	return testutil.NewMockStreamWithMessage(s.run.responses[i])
+}
+
+// toolResults holds the logged tool results of the current run.
+type toolResults struct {
+	mu     sync.Mutex
+	queues map[string][]log.AgentEntry
+}
+
+// set replaces the results with those of a run.
+func (r *toolResults) set(queues map[string][]log.AgentEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queues = make(map[string][]log.AgentEntry, len(queues))
+	for name, queue := range queues {
+		r.queues[name] = append([]log.AgentEntry(nil), queue...)
+	}
+}
+
+// next returns the next logged result of the named tool.
+func (r *toolResults) next(name string) (log.AgentEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queue := r.queues[name]
+	if len(queue) == 0 {
+		return log.AgentEntry{}, false
+	}
+	r.queues[name] = queue[1:]
+	return queue[0], true
+}
+
+// replayTool stands in for a logged tool, returning its logged results in
+// order.
+type replayTool struct {
+	name    string
+	results *toolResults
+}
+
+func (t *replayTool) Name() string        { return t.name }
+func (t *replayTool) Description() string { return "Replays the logged results of " + t.name }
+func (t *replayTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{"type":"object"}`)
+}
+
+// Execute returns the next logged result; a logged error is returned as an
+// error, so the harness reports the same text.
+func (t *replayTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	entry, ok := t.results.next(t.name)
+	if !ok {
+		return "", fmt.Errorf("no logged result for %s", t.name)
+	}
+	if entry.IsError {
+		return "", errors.New(entry.Result)
+	}
+	return entry.Result, nil
+}
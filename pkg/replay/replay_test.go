@@ -0,0 +1,155 @@
+package replay_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/replay"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// echoTool returns its input, or fails if asked to.
+type echoTool struct{}
+
+func (echoTool) Name() string                 { return "echo" }
+func (echoTool) Description() string          { return "Echoes its input" }
+func (echoTool) InputSchema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (echoTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var args struct {
+		Text string `json:"text"`
+		Fail bool   `json:"fail"`
+	}
+	json.Unmarshal(input, &args)
+	if args.Fail {
+		return "", errors.New("echo failed: " + args.Text)
+	}
+	return args.Text, nil
+}
+
+// recordSession runs two prompts with a JSON agent log and returns the log
+// entries and the harness.
+func recordSession(t *testing.T) ([]log.AgentEntry, *harness.Harness) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "agent.log")
+	agentLogger := log.NewAgentLogger(log.AgentLogConfig{FilePath: path, Format: log.FormatJSON})
+	handler := log.NewLoggingEventHandler(nil, agentLogger)
+
+	streamer := testutil.NewMockMessageStreamer()
+	streamer.AddResponse(testutil.NewMessageBuilder().
+		AddText("Echoing").
+		AddToolUse("tool_1", "echo", map[string]any{"text": "hello"}).
+		WithUsage(10, 5).
+		BuildWithToolUse())
+	streamer.AddResponse(testutil.NewMessageBuilder().AddText("It said hello").Build())
+	streamer.AddResponse(testutil.NewMessageBuilder().
+		AddToolUse("tool_2", "echo", map[string]any{"text": "bye", "fail": true}).
+		BuildWithToolUse())
+	streamer.AddResponse(testutil.NewMessageBuilder().AddText("It failed").Build())
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{echoTool{}}, handler, streamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	for _, run := range []struct{ id, prompt string }{
+		{"run-1", "Echo hello"},
+		{"run-2", "Echo bye"},
+	} {
+		handler.LogUserPrompt(run.id, run.prompt)
+		if err := h.Prompt(log.WithRunID(context.Background(), run.id), run.prompt); err != nil {
+			t.Fatalf("prompt %s failed: %v", run.id, err)
+		}
+	}
+	agentLogger.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	defer f.Close()
+	entries, err := replay.Read(f)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	return entries, h
+}
+
+func TestConversation(t *testing.T) {
+	entries, h := recordSession(t)
+
+	messages, err := replay.Conversation(entries)
+	if err != nil {
+		t.Fatalf("Conversation failed: %v", err)
+	}
+	got, _ := json.Marshal(messages)
+	want, _ := json.Marshal(h.Messages())
+	if string(got) != string(want) {
+		t.Errorf("reconstructed conversation differs:\n got %s\nwant %s", got, want)
+	}
+
+	// A run's entries give the conversation as that run left it
+	messages, err = replay.Conversation(replay.FilterRun(entries, "run-1"))
+	if err != nil {
+		t.Fatalf("Conversation failed: %v", err)
+	}
+	if len(messages) != 4 {
+		t.Errorf("expected 4 messages after run-1, got %d", len(messages))
+	}
+
+	if _, err := replay.Conversation(nil); !errors.Is(err, replay.ErrNoRequests) {
+		t.Errorf("expected ErrNoRequests, got %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	entries, _ := recordSession(t)
+
+	report, err := replay.Run(context.Background(), entries)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Runs != 2 || report.Requests != 4 {
+		t.Errorf("expected 2 runs and 4 requests, got %+v", report)
+	}
+	if len(report.Divergences) != 0 {
+		t.Errorf("expected no divergences, got %v", report.Divergences)
+	}
+}
+
+func TestRun_Divergence(t *testing.T) {
+	entries, _ := recordSession(t)
+
+	// Pretend the first run was prompted with something else
+	for i, entry := range entries {
+		if entry.Type == log.AgentAPIRequest && entry.RunID == "run-1" {
+			entries[i].Request = json.RawMessage(strings.Replace(string(entry.Request), "Echo hello", "Echo howdy", 1))
+			break
+		}
+	}
+
+	report, err := replay.Run(context.Background(), entries)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(report.Divergences) != 1 {
+		t.Fatalf("expected one divergence, got %v", report.Divergences)
+	}
+	d := report.Divergences[0]
+	if d.RunID != "run-1" || d.Request != 0 || d.Message != "message 0 differs" {
+		t.Errorf("unexpected divergence %v", d)
+	}
+}
+
+func TestRead_InvalidLine(t *testing.T) {
+	_, err := replay.Read(strings.NewReader("{\"type\":\"prompt\"}\n\nnot json\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3:") {
+		t.Errorf("expected an error for line 3, got %v", err)
+	}
+}
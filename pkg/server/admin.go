@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// LogSettings is the server logger's filter, returned by GET and PUT
+// /admin/log.
+type LogSettings struct {
+	Level      string   `json:"level"`
+	Categories []string `json:"categories"` // empty means all
+}
+
+// logSettingsRequest is the body of PUT /admin/log. Omitted fields are left
+// unchanged; an empty categories list enables every category.
+type logSettingsRequest struct {
+	Level      *string   `json:"level,omitempty"`
+	Categories *[]string `json:"categories,omitempty"`
+}
+
+// logSettings returns the settings of filter.
+func logSettings(filter log.Filter) LogSettings {
+	categories := filter.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+	return LogSettings{Level: filter.Level.String(), Categories: categories}
+}
+
+// EnableAdmin serves the /admin endpoints without authentication. They are
+// served when authentication is configured (see SetAuth); otherwise they
+// return 404 unless enabled, since anyone who can reach the server could
+// then change its logging.
+func (s *Server) EnableAdmin() {
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+	s.adminEnabled = true
+}
+
+// adminOnly serves an /admin endpoint if authentication is configured or
+// EnableAdmin was called, and responds 404 otherwise.
+func (s *Server) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.authMu.RLock()
+		enabled := s.auth.enabled() || s.adminEnabled
+		s.authMu.RUnlock()
+		if !enabled {
+			writeError(w, http.StatusNotFound, "admin endpoints are not enabled")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// reconfigurableLogger returns the server's logger if its filter can be
+// changed, writing an error response otherwise.
+func (s *Server) reconfigurableLogger(w http.ResponseWriter) (log.ReconfigurableLogger, bool) {
+	logger, ok := s.logger.(log.ReconfigurableLogger)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "the logger cannot be reconfigured")
+	}
+	return logger, ok
+}
+
+// HandleGetLogSettings handles GET /admin/log requests, returning the
+// server logger's level and categories.
+func (s *Server) HandleGetLogSettings(w http.ResponseWriter, r *http.Request) {
+	logger, ok := s.reconfigurableLogger(w)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(logSettings(logger.Filter()))
+}
+
+// HandleSetLogSettings handles PUT /admin/log requests, changing the server
+// logger's level and categories without a restart. The harness shares the
+// logger, so its api, tool, and harness lines follow the new settings.
+func (s *Server) HandleSetLogSettings(w http.ResponseWriter, r *http.Request) {
+	logger, ok := s.reconfigurableLogger(w)
+	if !ok {
+		return
+	}
+	var req logSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	// Serialize updates so one changing only the level does not undo
+	// another's categories
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	previous := logger.Filter()
+	filter := previous
+	if req.Level != nil {
+		level, ok := log.LookupLevel(*req.Level)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "level must be DEBUG, INFO, WARN, or ERROR")
+			return
+		}
+		filter.Level = level
+	}
+	if req.Categories != nil {
+		filter.Categories = nil
+		for _, c := range *req.Categories {
+			if c = strings.TrimSpace(c); c != "" {
+				filter.Categories = append(filter.Categories, c)
+			}
+		}
+	}
+	logger.SetFilter(filter)
+
+	settings := logSettings(logger.Filter())
+	// Logged at WARN so the change is recorded under most filters
+	s.logger.Warn("http", "Log settings changed",
+		log.F("level", settings.Level),
+		log.F("categories", strings.Join(settings.Categories, ",")),
+		log.F("previous_level", previous.Level.String()),
+		log.F("previous_categories", strings.Join(previous.Categories, ",")),
+	)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
@@ -0,0 +1,163 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+// syncBuffer is a bytes.Buffer safe for the logger and the test to share.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func putLogSettings(t *testing.T, baseURL, body string) (*http.Response, server.LogSettings) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPut, baseURL+"/admin/log", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT /admin/log failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var settings server.LogSettings
+	json.NewDecoder(resp.Body).Decode(&settings)
+	return resp, settings
+}
+
+func TestAdminLog(t *testing.T) {
+	var output syncBuffer
+	logger := log.NewLogger(log.LogConfig{Level: log.LevelInfo, Output: &output})
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", logger)
+	s.EnableAdmin()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/admin/log")
+	if err != nil {
+		t.Fatalf("GET /admin/log failed: %v", err)
+	}
+	var settings server.LogSettings
+	json.NewDecoder(resp.Body).Decode(&settings)
+	resp.Body.Close()
+	if settings.Level != "INFO" || settings.Categories == nil || len(settings.Categories) != 0 {
+		t.Errorf("unexpected initial settings %+v", settings)
+	}
+
+	// Change both, then only the level
+	resp, settings = putLogSettings(t, ts.URL, `{"level":"debug","categories":["tool","api"]}`)
+	if resp.StatusCode != http.StatusOK || settings.Level != "DEBUG" || strings.Join(settings.Categories, ",") != "api,tool" {
+		t.Fatalf("unexpected response %d %+v", resp.StatusCode, settings)
+	}
+	logger.Debug("api", "api debug line")
+	logger.Info("sse", "sse info line")
+	if out := output.String(); !strings.Contains(out, "api debug line") || strings.Contains(out, "sse info line") {
+		t.Errorf("expected the new filter to apply, got %q", out)
+	}
+
+	_, settings = putLogSettings(t, ts.URL, `{"level":"WARN"}`)
+	if settings.Level != "WARN" || strings.Join(settings.Categories, ",") != "api,tool" {
+		t.Errorf("expected the categories to be kept, got %+v", settings)
+	}
+
+	// An empty list enables every category
+	_, settings = putLogSettings(t, ts.URL, `{"categories":[]}`)
+	if settings.Level != "WARN" || len(settings.Categories) != 0 {
+		t.Errorf("expected all categories, got %+v", settings)
+	}
+
+	resp, _ = putLogSettings(t, ts.URL, `{"level":"verbose"}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown level, got %d", resp.StatusCode)
+	}
+	if filter := logger.(log.ReconfigurableLogger).Filter(); filter.Level != log.LevelWarn {
+		t.Errorf("expected a rejected update to change nothing, got %+v", filter)
+	}
+}
+
+func TestAdminLog_Unsupported(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", nil)
+	s.EnableAdmin()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, _ := putLogSettings(t, ts.URL, `{"level":"DEBUG"}`)
+	if resp.StatusCode != http.StatusNotImplemented {
+		t.Errorf("expected 501 for a logger that cannot be reconfigured, got %d", resp.StatusCode)
+	}
+}
+
+func TestAdminLog_RequiresAuth(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", log.NewLogger(log.LogConfig{Output: &syncBuffer{}}))
+	s.SetAuth(server.AuthConfig{BearerToken: "secret"})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, _ := putLogSettings(t, ts.URL, `{"level":"DEBUG"}`)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", resp.StatusCode)
+	}
+}
+
+func TestAdminLog_DisabledWithoutAuth(t *testing.T) {
+	var output syncBuffer
+	logger := log.NewLogger(log.LogConfig{Level: log.LevelInfo, Output: &output})
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", logger)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/admin/log")
+	if err != nil {
+		t.Fatalf("GET /admin/log failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for GET without authentication, got %d", resp.StatusCode)
+	}
+	resp, _ = putLogSettings(t, ts.URL, `{"level":"DEBUG"}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for PUT without authentication, got %d", resp.StatusCode)
+	}
+	if filter := logger.(log.ReconfigurableLogger).Filter(); filter.Level != log.LevelInfo {
+		t.Errorf("expected the level unchanged, got %v", filter.Level)
+	}
+
+	// Configuring authentication serves them to authenticated requests
+	s.SetAuth(server.AuthConfig{BearerToken: "secret"})
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/admin/log", strings.NewReader(`{"level":"DEBUG"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT /admin/log failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 with credentials, got %d", resp.StatusCode)
+	}
+}
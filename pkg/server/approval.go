@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+)
+
+// DefaultApprovalTools are the tools gated by EnableApproval when none are given.
+var DefaultApprovalTools = []string{"bash", "write", "edit"}
+
+// Approval is a tool call waiting for a user decision.
+type Approval struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	CreatedAt int64           `json:"created_at"`
+}
+
+// pendingApproval is an Approval and the channel its decision is sent on.
+type pendingApproval struct {
+	approval Approval
+	decision chan bool
+	runID    string // run that made the call
+}
+
+// approvalRequest is the body of POST /approve.
+type approvalRequest struct {
+	ID       string `json:"id"`
+	Approved *bool  `json:"approved"`
+}
+
+// EnableApproval gates the named tools (DefaultApprovalTools if none) on a
+// user decision: each call broadcasts an approval_request event and waits
+// for POST /approve. Sessions created afterwards are gated too.
+func (s *Server) EnableApproval(tools []string) {
+	if len(tools) == 0 {
+		tools = DefaultApprovalTools
+	}
+	gated := make(map[string]bool, len(tools))
+	for _, name := range tools {
+		gated[name] = true
+	}
+
+	s.approvalMu.Lock()
+	s.approvalTools = gated
+	s.approvals = make(map[string]*pendingApproval)
+	s.approvalMu.Unlock()
+
+	s.harness.SetPermissionHandler(harness.PermissionFunc(s.approve))
+}
+
+// approve is the PermissionHandler of interactive mode. Ungated tools run
+// without asking; gated ones wait for a decision or ctx cancellation.
+func (s *Server) approve(ctx context.Context, call harness.ToolCall) (bool, error) {
+	s.approvalMu.Lock()
+	if !s.approvalTools[call.Name] {
+		s.approvalMu.Unlock()
+		return true, nil
+	}
+	pending := &pendingApproval{
+		approval: Approval{
+			ID:        call.ID,
+			Name:      call.Name,
+			Input:     call.Input,
+			CreatedAt: time.Now().Unix(),
+		},
+		decision: make(chan bool, 1),
+		runID:    log.RunID(ctx),
+	}
+	s.approvals[call.ID] = pending
+	s.approvalMu.Unlock()
+
+	defer func() {
+		s.approvalMu.Lock()
+		delete(s.approvals, call.ID)
+		s.approvalMu.Unlock()
+	}()
+
+	log.ForContext(s.logger, ctx).Info("http", "Approval requested",
+		log.F("tool", call.Name),
+		log.F("id", call.ID),
+	)
+	s.broadcast(Event{Type: "status", State: "awaiting_approval", Message: call.Name, RunID: pending.runID})
+	s.broadcast(Event{Type: "approval_request", ID: call.ID, Name: call.Name, Input: call.Input, RunID: pending.runID})
+
+	select {
+	case approved := <-pending.decision:
+		return approved, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// HandleApprove handles POST /approve requests, resolving a pending approval.
+func (s *Server) HandleApprove(w http.ResponseWriter, r *http.Request) {
+	var req approvalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ID == "" || req.Approved == nil {
+		writeError(w, http.StatusBadRequest, "id and approved are required")
+		return
+	}
+
+	s.approvalMu.Lock()
+	pending, ok := s.approvals[req.ID]
+	if ok {
+		delete(s.approvals, req.ID)
+	}
+	s.approvalMu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no pending approval with that id")
+		return
+	}
+	pending.decision <- *req.Approved
+
+	state := "denied"
+	if *req.Approved {
+		state = "approved"
+	}
+	s.logger.Info("http", "Approval resolved",
+		log.F("tool", pending.approval.Name),
+		log.F("id", req.ID),
+		log.F("state", state),
+		log.F("run_id", pending.runID),
+	)
+	s.broadcast(Event{Type: "approval_resolved", ID: req.ID, Name: pending.approval.Name, State: state, RunID: pending.runID})
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleListApprovals handles GET /approvals requests, listing pending
+// approvals oldest first.
+func (s *Server) HandleListApprovals(w http.ResponseWriter, r *http.Request) {
+	s.approvalMu.Lock()
+	list := make([]Approval, 0, len(s.approvals))
+	for _, pending := range s.approvals {
+		list = append(list, pending.approval)
+	}
+	s.approvalMu.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt != list[j].CreatedAt {
+			return list[i].CreatedAt < list[j].CreatedAt
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
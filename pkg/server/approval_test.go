@@ -0,0 +1,134 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// waitForApproval polls GET /approvals until a call is pending.
+func waitForApproval(t *testing.T, baseURL string) server.Approval {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(baseURL + "/approvals")
+		if err != nil {
+			t.Fatalf("GET /approvals failed: %v", err)
+		}
+		var pending []server.Approval
+		json.NewDecoder(resp.Body).Decode(&pending)
+		resp.Body.Close()
+		if len(pending) > 0 {
+			return pending[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no approval requested")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newApprovalServer starts a server in interactive mode with a mock bash tool
+// that counts its executions.
+func newApprovalServer(t *testing.T, mockStreamer *testutil.MockMessageStreamer) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var executed atomic.Int32
+	bash := &MockTool{
+		name: "bash",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			executed.Add(1)
+			return `{"exit_code":0}`, nil
+		},
+	}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{bash}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	s.EnableApproval(nil)
+	return httptest.NewServer(s.Handler()), &executed
+}
+
+func TestApproval_ApprovedCallRuns(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "bash", map[string]string{"command": "rm -rf build"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Cleaned"))
+
+	ts, executed := newApprovalServer(t, mockStreamer)
+	defer ts.Close()
+
+	runID := promptRunID(t, ts.URL, `{"content":"clean up"}`)
+	pending := waitForApproval(t, ts.URL)
+	if pending.ID != "call_1" || pending.Name != "bash" || !strings.Contains(string(pending.Input), "rm -rf build") {
+		t.Fatalf("unexpected approval %+v", pending)
+	}
+	if executed.Load() != 0 {
+		t.Fatal("tool ran before approval")
+	}
+
+	resp := postJSON(t, ts.URL+"/approve", `{"id":"call_1","approved":true}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	if run := waitForRun(t, ts.URL, runID); run.Status != "completed" {
+		t.Fatalf("expected completed run, got %+v", run)
+	}
+	if executed.Load() != 1 {
+		t.Errorf("expected the tool to run once, ran %d times", executed.Load())
+	}
+}
+
+func TestApproval_DeniedCallIsReportedToModel(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "bash", map[string]string{"command": "rm -rf /"}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("OK, I won't"))
+
+	ts, executed := newApprovalServer(t, mockStreamer)
+	defer ts.Close()
+
+	runID := promptRunID(t, ts.URL, `{"content":"wipe it"}`)
+	waitForApproval(t, ts.URL)
+	resp := postJSON(t, ts.URL+"/approve", `{"id":"call_1","approved":false}`)
+	resp.Body.Close()
+
+	run := waitForRun(t, ts.URL, runID)
+	if run.Status != "completed" {
+		t.Fatalf("expected completed run, got %+v", run)
+	}
+	if executed.Load() != 0 {
+		t.Error("denied tool was executed")
+	}
+	if len(run.Transcript) < 3 || !strings.Contains(string(run.Transcript[2]), "was not approved") {
+		t.Errorf("expected a denial tool result in the transcript, got %s", run.Transcript)
+	}
+}
+
+func TestApproval_UnknownID(t *testing.T) {
+	ts, _ := newApprovalServer(t, testutil.NewMockMessageStreamer())
+	defer ts.Close()
+
+	resp := postJSON(t, ts.URL+"/approve", `{"id":"call_missing","approved":true}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+
+	resp = postJSON(t, ts.URL+"/approve", `{"id":"call_missing"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 without approved, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// APIKeyHeader is the header that carries AuthConfig.APIKey.
+const APIKeyHeader = "X-API-Key"
+
+// AuthConfig configures request authentication. A request is accepted if it
+// presents any configured credential. With neither set, all requests are
+// accepted.
+type AuthConfig struct {
+	// BearerToken is accepted in an "Authorization: Bearer <token>" header.
+	BearerToken string
+	// APIKey is accepted in the X-API-Key header.
+	APIKey string
+}
+
+// enabled reports whether any credential is configured.
+func (c AuthConfig) enabled() bool {
+	return c.BearerToken != "" || c.APIKey != ""
+}
+
+// authorized reports whether r presents a configured credential. GET /events
+// may also pass either credential as ?token=, since browsers' EventSource
+// cannot set headers.
+func (c AuthConfig) authorized(r *http.Request) bool {
+	if c.BearerToken != "" && secretEqual(r.Header.Get("Authorization"), "Bearer "+c.BearerToken) {
+		return true
+	}
+	if c.APIKey != "" && secretEqual(r.Header.Get(APIKeyHeader), c.APIKey) {
+		return true
+	}
+	if r.Method == http.MethodGet && r.URL.Path == "/events" {
+		if token := r.URL.Query().Get("token"); token != "" {
+			return (c.BearerToken != "" && secretEqual(token, c.BearerToken)) ||
+				(c.APIKey != "" && secretEqual(token, c.APIKey))
+		}
+	}
+	return false
+}
+
+// secretEqual compares credentials in constant time.
+func secretEqual(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// SetAuth requires every request to present one of the credentials in
+// config. The zero AuthConfig disables authentication.
+func (s *Server) SetAuth(config AuthConfig) {
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+	s.auth = config
+}
+
+// isPublicPath reports whether r is served without credentials: the web UI's
+// static files and the OpenAPI document, which hold no data.
+func isPublicPath(r *http.Request) bool {
+	return isWebUIPath(r) || (r.Method == http.MethodGet && r.URL.Path == OpenAPIPath)
+}
+
+// authMiddleware rejects requests without a configured credential.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.authMu.RLock()
+		auth := s.auth
+		s.authMu.RUnlock()
+
+		if auth.enabled() && !auth.authorized(r) && !isPublicPath(r) {
+			s.logger.Warn("http", "Unauthorized request",
+				log.F("method", r.Method),
+				log.F("path", r.URL.Path),
+				log.F("remote_addr", r.RemoteAddr),
+			)
+			if auth.BearerToken != "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
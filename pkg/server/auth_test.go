@@ -0,0 +1,95 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func newAuthServer(t *testing.T, config server.AuthConfig) *httptest.Server {
+	t.Helper()
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	s.SetAuth(config)
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// authStatus sends a request and returns its status. Event streams are
+// cut short once the headers arrive.
+func authStatus(t *testing.T, method, url string, header http.Header) int {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, method, url, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAuth_Credentials(t *testing.T) {
+	ts := newAuthServer(t, server.AuthConfig{BearerToken: "tok", APIKey: "key"})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header http.Header
+		want   int
+	}{
+		{"no credentials", "POST", "/cancel", nil, http.StatusUnauthorized},
+		{"wrong bearer", "POST", "/cancel", http.Header{"Authorization": {"Bearer nope"}}, http.StatusUnauthorized},
+		{"bearer", "POST", "/cancel", http.Header{"Authorization": {"Bearer tok"}}, http.StatusOK},
+		{"api key", "POST", "/cancel", http.Header{"X-Api-Key": {"key"}}, http.StatusOK},
+		{"prompt without credentials", "POST", "/prompt", nil, http.StatusUnauthorized},
+		{"other endpoints", "GET", "/status", nil, http.StatusUnauthorized},
+		{"events without credentials", "GET", "/events", nil, http.StatusUnauthorized},
+		{"events query token", "GET", "/events?token=tok", nil, http.StatusOK},
+		{"events query api key", "GET", "/events?token=key", nil, http.StatusOK},
+		{"events wrong query token", "GET", "/events?token=nope", nil, http.StatusUnauthorized},
+		{"query token only for events", "GET", "/status?token=tok", nil, http.StatusUnauthorized},
+		{"preflight", "OPTIONS", "/prompt", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := authStatus(t, tt.method, ts.URL+tt.path, tt.header); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAuth_APIKeyOnly(t *testing.T) {
+	ts := newAuthServer(t, server.AuthConfig{APIKey: "key"})
+
+	// An empty bearer token must not match an unconfigured one
+	if got := authStatus(t, "POST", ts.URL+"/cancel", http.Header{"Authorization": {"Bearer "}}); got != http.StatusUnauthorized {
+		t.Errorf("expected 401 for empty bearer, got %d", got)
+	}
+	if got := authStatus(t, "POST", ts.URL+"/cancel", http.Header{"X-Api-Key": {"key"}}); got != http.StatusOK {
+		t.Errorf("expected 200 with api key, got %d", got)
+	}
+}
+
+func TestAuth_Disabled(t *testing.T) {
+	ts := newAuthServer(t, server.AuthConfig{})
+	if got := authStatus(t, "POST", ts.URL+"/cancel", nil); got != http.StatusOK {
+		t.Errorf("expected 200 without auth configured, got %d", got)
+	}
+}
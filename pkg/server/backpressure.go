@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+)
+
+const (
+	// DefaultClientBufferSize is the number of events queued for an SSE
+	// client before further events are dropped.
+	DefaultClientBufferSize = 100
+	// DefaultClientStallTimeout is how long an SSE client may keep a full
+	// buffer before it is disconnected.
+	DefaultClientStallTimeout = 30 * time.Second
+)
+
+// SetClientBufferSize sets how many events are queued for each SSE client
+// connecting afterwards (DefaultClientBufferSize by default). Events beyond
+// it are dropped until the client catches up.
+func (s *Server) SetClientBufferSize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientBuffer = max(size, 1)
+}
+
+// SetClientStallTimeout sets how long an SSE client may keep dropping events
+// before it is disconnected (DefaultClientStallTimeout by default). Zero
+// never disconnects stalled clients.
+func (s *Server) SetClientStallTimeout(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stallTimeout = d
+}
+
+// send queues an event on a client without blocking, so a slow client
+// cannot stall broadcasts. Once its buffer is full, events are dropped and
+// counted; when there is room again, a dropped_events notice precedes the
+// next event. A client stalled beyond the stall timeout is disconnected.
+// Callers hold s.mu.
+func (s *Server) send(client *sseClient, eventType string, data, dedupData sseMessage) {
+	if client.evicted {
+		return
+	}
+	payload := data
+	if client.dedup {
+		payload = dedupData
+	}
+
+	// Resume a client that caught up with the notice, then the event
+	if client.dropped > 0 && cap(client.events)-len(client.events) >= 2 {
+		client.events <- droppedNotice(client.dropped)
+		s.logger.Info("sse", "Client caught up",
+			log.F("client_id", client.id),
+			log.F("dropped_events", client.dropped),
+		)
+		client.dropped = 0
+		client.stalledSince = time.Time{}
+	}
+	if client.dropped == 0 {
+		select {
+		case client.events <- payload:
+			return
+		default:
+		}
+	}
+
+	client.dropped++
+	s.droppedEvents++
+	now := time.Now()
+	if client.stalledSince.IsZero() {
+		client.stalledSince = now
+		s.logger.Warn("sse", "Client stalled - dropping events",
+			log.F("client_id", client.id),
+			log.F("event_type", eventType),
+		)
+	}
+	if s.stallTimeout > 0 && now.Sub(client.stalledSince) > s.stallTimeout {
+		client.evicted = true
+		close(client.evict)
+		s.evictedClients++
+		s.logger.Warn("sse", "Client disconnected - stalled",
+			log.F("client_id", client.id),
+			log.F("dropped_events", client.dropped),
+			log.F("stalled_ms", now.Sub(client.stalledSince).Milliseconds()),
+		)
+	}
+}
+
+// droppedNotice returns the dropped_events event telling a client how many
+// events it missed.
+func droppedNotice(dropped int) sseMessage {
+	data, _ := json.Marshal(Event{
+		Type:      "dropped_events",
+		Timestamp: time.Now().Unix(),
+		Dropped:   dropped,
+		Message:   fmt.Sprintf("%d events were dropped while the client fell behind; reconnect with Last-Event-ID to replay those still retained", dropped),
+	})
+	return sseMessage{data: data}
+}
+
+// sseStats returns the events dropped for slow SSE clients and the clients
+// disconnected for stalling, since the server started.
+func (s *Server) sseStats() (dropped, evicted uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.droppedEvents, s.evictedClients
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSend_DropsAndNotifiesSlowClient(t *testing.T) {
+	h := createTestHarness(t)
+	s := NewServer(h, ":8080", nil)
+	s.SetClientBufferSize(2)
+	client := s.addClient("test:1234")
+	defer s.removeClient(client, 0)
+
+	for i := 0; i < 5; i++ {
+		s.broadcast(Event{Type: "text", Content: "event"})
+	}
+	if len(client.events) != 2 {
+		t.Fatalf("expected a full buffer of 2, got %d", len(client.events))
+	}
+	<-client.events
+	<-client.events
+
+	// The next event is preceded by the count of dropped events
+	s.broadcast(Event{Type: "text", Content: "after"})
+	var notice, next Event
+	json.Unmarshal((<-client.events).data, &notice)
+	json.Unmarshal((<-client.events).data, &next)
+	if notice.Type != "dropped_events" || notice.Dropped != 3 {
+		t.Errorf("expected a dropped_events notice for 3 events, got %+v", notice)
+	}
+	if next.Content != "after" {
+		t.Errorf("expected the event after the notice, got %+v", next)
+	}
+	if dropped, evicted := s.sseStats(); dropped != 3 || evicted != 0 {
+		t.Errorf("expected 3 dropped and no evicted clients, got %d and %d", dropped, evicted)
+	}
+}
+
+func TestSend_DisconnectsStalledClient(t *testing.T) {
+	h := createTestHarness(t)
+	s := NewServer(h, ":8080", nil)
+	s.SetClientBufferSize(1)
+	s.SetClientStallTimeout(time.Millisecond)
+	client := s.addClient("test:1234")
+	defer s.removeClient(client, 0)
+
+	s.broadcast(Event{Type: "text", Content: "fills the buffer"})
+	s.broadcast(Event{Type: "text", Content: "dropped"})
+	time.Sleep(5 * time.Millisecond)
+	s.broadcast(Event{Type: "text", Content: "dropped after the timeout"})
+
+	select {
+	case <-client.evict:
+	default:
+		t.Fatal("expected the stalled client to be disconnected")
+	}
+	if _, evicted := s.sseStats(); evicted != 1 {
+		t.Errorf("expected 1 evicted client, got %d", evicted)
+	}
+	// Evicted clients get no further events
+	s.broadcast(Event{Type: "text", Content: "ignored"})
+	if dropped, _ := s.sseStats(); dropped != 2 {
+		t.Errorf("expected 2 dropped events, got %d", dropped)
+	}
+}
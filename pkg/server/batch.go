@@ -0,0 +1,440 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+)
+
+const (
+	// maxBatchItems is the maximum number of prompts accepted in one batch.
+	maxBatchItems = 500
+	// maxBatchConcurrency caps how many batch items run at the same time.
+	maxBatchConcurrency = 8
+	// webhookTimeout bounds the completion webhook request.
+	webhookTimeout = 10 * time.Second
+	// batchRetention is how long a finished batch's status is kept.
+	batchRetention = time.Hour
+	// maxFinishedBatches caps how many finished batches are kept; the
+	// oldest are forgotten first.
+	maxFinishedBatches = 100
+)
+
+// errBatchCancelled is the error of items stopped by POST /batch/{id}/cancel.
+var errBatchCancelled = errors.New("batch cancelled")
+
+// Batch item and batch states.
+const (
+	batchPending   = "pending"
+	batchRunning   = "running"
+	batchCompleted = "completed"
+	batchFailed    = "failed"
+	batchCancelled = "cancelled"
+)
+
+// batchRequest is the body of POST /batch.
+type batchRequest struct {
+	Prompts     []string `json:"prompts"`
+	Concurrency int      `json:"concurrency,omitempty"`
+	WebhookURL  string   `json:"webhook_url,omitempty"`
+	WorkspaceID string   `json:"workspace_id,omitempty"`
+	Profile     string   `json:"profile,omitempty"` // profile of every item's session
+}
+
+// batchResponse is the body of an accepted POST /batch.
+type batchResponse struct {
+	BatchID string `json:"batch_id"`
+}
+
+// BatchItem is the status of one prompt within a batch.
+type BatchItem struct {
+	Index     int           `json:"index"`
+	Status    string        `json:"status"`
+	FinalText string        `json:"final_text,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Usage     harness.Usage `json:"usage"`
+}
+
+// BatchStatus is the status of a batch as returned by GET /batch/{id}
+// and posted to the completion webhook.
+type BatchStatus struct {
+	ID          string        `json:"id"`
+	Status      string        `json:"status"`
+	Items       []BatchItem   `json:"items"`
+	Usage       harness.Usage `json:"usage"`
+	CreatedAt   int64         `json:"created_at"`
+	CompletedAt int64         `json:"completed_at,omitempty"`
+}
+
+// batch tracks a running or finished batch.
+type batch struct {
+	mu         sync.Mutex
+	status     BatchStatus
+	prompts    []string
+	webhookURL string
+	workspace  string
+	profile    string
+
+	// Items run with ctx, derived from the server's lifecycle context;
+	// cancel stops the batch. done is closed when it has finished, at
+	// finished.
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	done     chan struct{}
+	finished time.Time
+}
+
+// snapshot returns a copy of the batch status that is safe to serialize.
+func (b *batch) snapshot() BatchStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := b.status
+	status.Items = make([]BatchItem, len(b.status.Items))
+	copy(status.Items, b.status.Items)
+	return status
+}
+
+// updateItem applies fn to the item at index under the batch lock.
+func (b *batch) updateItem(index int, fn func(item *BatchItem)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fn(&b.status.Items[index])
+}
+
+// textCollector is an EventHandler that keeps the most recent assistant text.
+type textCollector struct {
+	mu   sync.Mutex
+	text string
+	// committed is the text as of the last tool result, restored when the
+	// turn after it is streamed again
+	committed string
+}
+
+func (c *textCollector) OnText(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.text = text
+}
+func (c *textCollector) OnToolCall(id string, name string, input json.RawMessage) {}
+func (c *textCollector) OnToolResult(id string, result string, isError bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.committed = c.text
+}
+func (c *textCollector) OnReasoning(content string) {}
+
+// OnStreamReset drops the text of a turn that failed partway.
+func (c *textCollector) OnStreamReset(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.text = c.committed
+}
+
+// lastText returns the most recent assistant text.
+func (c *textCollector) lastText() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.text
+}
+
+// HandleBatch handles POST /batch requests.
+// Each prompt runs in its own session; the response contains the batch ID.
+func (s *Server) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	if s.refuseIfClosing(w) {
+		return
+	}
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Prompts) == 0 {
+		writeError(w, http.StatusBadRequest, "prompts is required")
+		return
+	}
+	if len(req.Prompts) > maxBatchItems {
+		writeError(w, http.StatusBadRequest, "too many prompts")
+		return
+	}
+	for _, p := range req.Prompts {
+		if strings.TrimSpace(p) == "" {
+			writeError(w, http.StatusBadRequest, "prompts must not be empty")
+			return
+		}
+	}
+	if req.WebhookURL != "" {
+		if err := s.checkWebhookURL(req.WebhookURL); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := s.harness.ValidateOptions(harness.PromptOptions{Profile: req.Profile}); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The workspace cannot be deleted until the batch is registered
+	var workspace string
+	if req.WorkspaceID != "" {
+		s.workspaceMu.RLock()
+		defer s.workspaceMu.RUnlock()
+		ws, ok := s.workspaces[req.WorkspaceID]
+		if !ok {
+			writeError(w, http.StatusNotFound, "workspace not found")
+			return
+		}
+		workspace = ws.Path
+	}
+
+	concurrency := req.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > maxBatchConcurrency {
+		concurrency = maxBatchConcurrency
+	}
+
+	b := &batch{
+		prompts:    req.Prompts,
+		webhookURL: req.WebhookURL,
+		workspace:  workspace,
+		profile:    req.Profile,
+		done:       make(chan struct{}),
+		status: BatchStatus{
+			ID:        newBatchID(),
+			Status:    batchRunning,
+			Items:     make([]BatchItem, len(req.Prompts)),
+			CreatedAt: time.Now().Unix(),
+		},
+	}
+	for i := range b.status.Items {
+		b.status.Items[i] = BatchItem{Index: i, Status: batchPending}
+	}
+
+	b.ctx, b.cancel = context.WithCancelCause(s.ctx)
+
+	s.batchMu.Lock()
+	s.pruneBatches(time.Now())
+	s.batches[b.status.ID] = b
+	s.batchMu.Unlock()
+
+	s.logger.Info("http", "Batch accepted",
+		log.F("batch_id", b.status.ID),
+		log.F("items", len(req.Prompts)),
+		log.F("concurrency", concurrency),
+	)
+
+	go s.runBatch(b, concurrency)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(batchResponse{BatchID: b.status.ID})
+}
+
+// HandleBatchStatus handles GET /batch/{id} requests.
+func (s *Server) HandleBatchStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.batchMu.RLock()
+	b, ok := s.batches[id]
+	s.batchMu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "batch not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b.snapshot())
+}
+
+// HandleCancelBatch handles POST /batch/{id}/cancel requests. Running items
+// are cancelled and pending ones skipped; both end as cancelled. Cancelling
+// a finished batch has no effect.
+func (s *Server) HandleCancelBatch(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.batchMu.RLock()
+	b, ok := s.batches[id]
+	s.batchMu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "batch not found")
+		return
+	}
+
+	s.logger.Info("http", "Batch cancel requested", log.F("batch_id", id))
+	b.cancel(errBatchCancelled)
+	w.WriteHeader(http.StatusOK)
+}
+
+// pruneBatches forgets finished batches older than batchRetention, and the
+// oldest finished batches beyond maxFinishedBatches. The caller must hold
+// batchMu.
+func (s *Server) pruneBatches(now time.Time) {
+	var finished []*batch
+	for id, b := range s.batches {
+		b.mu.Lock()
+		at := b.finished
+		b.mu.Unlock()
+		switch {
+		case at.IsZero():
+		case now.Sub(at) > batchRetention:
+			delete(s.batches, id)
+		default:
+			finished = append(finished, b)
+		}
+	}
+	if len(finished) <= maxFinishedBatches {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].finished.Before(finished[j].finished)
+	})
+	for _, b := range finished[:len(finished)-maxFinishedBatches] {
+		delete(s.batches, b.status.ID)
+	}
+}
+
+// runningBatches returns the batches that have not finished.
+func (s *Server) runningBatches() []*batch {
+	s.batchMu.RLock()
+	defer s.batchMu.RUnlock()
+	var running []*batch
+	for _, b := range s.batches {
+		select {
+		case <-b.done:
+		default:
+			running = append(running, b)
+		}
+	}
+	return running
+}
+
+// runBatch processes all items of a batch with bounded concurrency,
+// then marks the batch completed, or cancelled if any item was, and fires
+// the webhook. Once the batch is cancelled, no more items start.
+func (s *Server) runBatch(b *batch, concurrency int) {
+	defer close(b.done)
+	defer b.cancel(nil)
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, prompt := range b.prompts {
+		select {
+		case sem <- struct{}{}:
+		case <-b.ctx.Done():
+		}
+		if b.ctx.Err() != nil {
+			b.updateItem(i, func(item *BatchItem) {
+				item.Status = batchCancelled
+				item.Error = context.Cause(b.ctx).Error()
+			})
+			continue
+		}
+		wg.Add(1)
+		go func(index int, prompt string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.runBatchItem(b, index, prompt)
+		}(i, prompt)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	b.mu.Lock()
+	b.status.Status = batchCompleted
+	b.status.CompletedAt = now.Unix()
+	var total harness.Usage
+	for _, item := range b.status.Items {
+		total = total.Add(item.Usage)
+		if item.Status == batchCancelled {
+			b.status.Status = batchCancelled
+		}
+	}
+	b.status.Usage = total
+	b.finished = now
+	b.mu.Unlock()
+
+	status := b.snapshot()
+	s.logger.Info("http", "Batch completed",
+		log.F("batch_id", status.ID),
+		log.F("input_tokens", status.Usage.InputTokens),
+		log.F("output_tokens", status.Usage.OutputTokens),
+	)
+	s.broadcast(Event{Type: "batch_completed", ID: status.ID})
+
+	if b.webhookURL != "" {
+		s.postBatchWebhook(b.webhookURL, status)
+	}
+}
+
+// runBatchItem runs one prompt in a fresh session and records its outcome.
+func (s *Server) runBatchItem(b *batch, index int, prompt string) {
+	b.updateItem(index, func(item *BatchItem) { item.Status = batchRunning })
+
+	collector := &textCollector{}
+	session := s.harness.NewSession(collector)
+	if b.workspace != "" {
+		session.SetWorkspace(b.workspace)
+	}
+	if b.profile != "" {
+		session.SetProfile(b.profile)
+	}
+	err := session.Prompt(b.ctx, prompt)
+
+	b.updateItem(index, func(item *BatchItem) {
+		item.Usage = session.Usage()
+		item.FinalText = collector.lastText()
+		switch {
+		case err != nil && b.ctx.Err() != nil && errors.Is(err, context.Canceled):
+			item.Status = batchCancelled
+			item.Error = context.Cause(b.ctx).Error()
+		case err != nil:
+			item.Status = batchFailed
+			item.Error = err.Error()
+		default:
+			item.Status = batchCompleted
+		}
+	})
+}
+
+// postBatchWebhook delivers the final batch status to the webhook URL.
+func (s *Server) postBatchWebhook(url string, status BatchStatus) {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return
+	}
+	resp, err := s.webhookClient().Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		s.logger.Warn("http", "Batch webhook failed",
+			log.F("batch_id", status.ID),
+			log.F("error", err.Error()),
+		)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		s.logger.Warn("http", "Batch webhook rejected",
+			log.F("batch_id", status.ID),
+			log.F("status", resp.StatusCode),
+		)
+	}
+}
+
+// newBatchID returns a random batch identifier.
+func newBatchID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "batch_" + hex.EncodeToString(buf)
+}
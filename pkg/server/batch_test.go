@@ -0,0 +1,299 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestBatch_ProcessesPromptsAndCallsWebhook(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("review 1").WithUsage(10, 5).Build())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("review 2").WithUsage(20, 7).Build())
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	if err := s.SetWebhookAllowlist([]string{"127.0.0.0/8", "::1"}); err != nil {
+		t.Fatalf("SetWebhookAllowlist failed: %v", err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	webhook := make(chan server.BatchStatus, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var status server.BatchStatus
+		json.NewDecoder(r.Body).Decode(&status)
+		webhook <- status
+	}))
+	defer hook.Close()
+
+	body, _ := json.Marshal(map[string]any{
+		"prompts":     []string{"review a.go", "review b.go"},
+		"webhook_url": hook.URL,
+	})
+	resp, err := http.Post(ts.URL+"/batch", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /batch failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", resp.StatusCode)
+	}
+	var accepted struct {
+		BatchID string `json:"batch_id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&accepted)
+	if accepted.BatchID == "" {
+		t.Fatal("expected batch_id in response")
+	}
+
+	var status server.BatchStatus
+	select {
+	case status = <-webhook:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for completion webhook")
+	}
+
+	if status.ID != accepted.BatchID {
+		t.Errorf("webhook batch ID %q does not match %q", status.ID, accepted.BatchID)
+	}
+	if status.Status != "completed" {
+		t.Errorf("expected status completed, got %q", status.Status)
+	}
+	if len(status.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(status.Items))
+	}
+	for _, item := range status.Items {
+		if item.Status != "completed" {
+			t.Errorf("item %d: expected completed, got %q (%s)", item.Index, item.Status, item.Error)
+		}
+	}
+	if status.Items[0].FinalText != "review 1" || status.Items[1].FinalText != "review 2" {
+		t.Errorf("unexpected final texts: %q, %q", status.Items[0].FinalText, status.Items[1].FinalText)
+	}
+	if status.Usage.InputTokens != 30 || status.Usage.OutputTokens != 12 {
+		t.Errorf("expected aggregated usage 30/12, got %d/%d", status.Usage.InputTokens, status.Usage.OutputTokens)
+	}
+
+	// Batches run in independent sessions; the main conversation is untouched
+	if len(h.Messages()) != 0 {
+		t.Errorf("batch items should not modify the main conversation, got %d messages", len(h.Messages()))
+	}
+
+	// Status endpoint reports the same result
+	getResp, err := http.Get(ts.URL + "/batch/" + accepted.BatchID)
+	if err != nil {
+		t.Fatalf("GET /batch failed: %v", err)
+	}
+	defer getResp.Body.Close()
+	var fetched server.BatchStatus
+	json.NewDecoder(getResp.Body).Decode(&fetched)
+	if fetched.Status != "completed" || len(fetched.Items) != 2 {
+		t.Errorf("unexpected fetched status: %+v", fetched)
+	}
+}
+
+func TestBatch_Validation(t *testing.T) {
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `not json`},
+		{"no prompts", `{"prompts":[]}`},
+		{"empty prompt", `{"prompts":["ok",""]}`},
+		{"bad webhook", `{"prompts":["ok"],"webhook_url":"ftp://x"}`},
+		{"loopback webhook", `{"prompts":["ok"],"webhook_url":"http://127.0.0.1:8080/hook"}`},
+		{"metadata webhook", `{"prompts":["ok"],"webhook_url":"http://169.254.169.254/latest/meta-data"}`},
+		{"private webhook", `{"prompts":["ok"],"webhook_url":"https://[fd00::1]/hook"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL+"/batch", "application/json", bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", resp.StatusCode)
+			}
+		})
+	}
+
+	resp, err := http.Get(ts.URL + "/batch/unknown")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown batch, got %d", resp.StatusCode)
+	}
+
+	resp = postJSON(t, ts.URL+"/batch/unknown/cancel", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 cancelling an unknown batch, got %d", resp.StatusCode)
+	}
+
+	if err := s.SetWebhookAllowlist([]string{"localhost"}); err == nil {
+		t.Error("expected an error for an allowlist entry that is not an address")
+	}
+}
+
+// submitBatch posts a batch and returns its ID.
+func submitBatch(t *testing.T, url, body string) string {
+	t.Helper()
+	resp := postJSON(t, url+"/batch", body)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", resp.StatusCode)
+	}
+	var accepted struct {
+		BatchID string `json:"batch_id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&accepted)
+	return accepted.BatchID
+}
+
+// waitForBatch polls GET /batch/{id} until the batch has finished.
+func waitForBatch(t *testing.T, url, id string) server.BatchStatus {
+	t.Helper()
+	var status server.BatchStatus
+	deadline := time.Now().Add(5 * time.Second)
+	for status.Status == "" || status.Status == "running" {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for the batch, last status %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		resp, err := http.Get(url + "/batch/" + id)
+		if err != nil {
+			t.Fatalf("GET /batch failed: %v", err)
+		}
+		json.NewDecoder(resp.Body).Decode(&status)
+		resp.Body.Close()
+	}
+	return status
+}
+
+// blockingBatchHarness returns a harness whose first prompt calls a tool
+// that blocks until cancelled, and a channel closed when it starts.
+func blockingBatchHarness(t *testing.T) (*harness.Harness, <-chan struct{}) {
+	t.Helper()
+	started := make(chan struct{})
+	blocking := &MockTool{
+		name: "blocking",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "blocking", map[string]string{}))
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{blocking}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	return h, started
+}
+
+func TestBatch_Cancel(t *testing.T) {
+	h, started := blockingBatchHarness(t)
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	id := submitBatch(t, ts.URL, `{"prompts":["block","skipped"]}`)
+	<-started
+	resp := postJSON(t, ts.URL+"/batch/"+id+"/cancel", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	status := waitForBatch(t, ts.URL, id)
+	if status.Status != "cancelled" {
+		t.Errorf("expected the batch cancelled, got %q", status.Status)
+	}
+	for _, item := range status.Items {
+		if item.Status != "cancelled" || item.Error != "batch cancelled" {
+			t.Errorf("item %d: expected cancelled with batch cancelled, got %q (%s)", item.Index, item.Status, item.Error)
+		}
+	}
+}
+
+func TestBatch_WebhookRefusesLoopbackHost(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	called := make(chan struct{}, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called <- struct{}{}
+	}))
+	defer hook.Close()
+
+	// A name is accepted, but refused once it resolves to a loopback address
+	hookURL := strings.Replace(hook.URL, "127.0.0.1", "localhost", 1)
+	id := submitBatch(t, ts.URL, `{"prompts":["go"],"webhook_url":"`+hookURL+`"}`)
+	if status := waitForBatch(t, ts.URL, id); status.Status != "completed" {
+		t.Fatalf("expected the batch completed, got %+v", status)
+	}
+	select {
+	case <-called:
+		t.Error("expected the webhook to a loopback host to be refused")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestBatch_FinalTextDropsResetTurn(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	// The first attempt completes a text block, then fails; the retry only
+	// calls a tool, and the last turn has no text
+	draft := testutil.NewMessageBuilder().AddText("draft").AddToolUse("call_1", "echo", map[string]string{}).BuildWithToolUse()
+	mockStreamer.AddResponse(&failingStream{draft, 3})
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "echo", map[string]string{}))
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().Build())
+
+	config := harness.Config{Model: "test-model", MaxRetries: 1, RetryBaseDelay: time.Millisecond}
+	h, err := harness.NewHarnessWithStreamer(config, []tool.Tool{&MockTool{name: "echo"}}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	status := waitForBatch(t, ts.URL, submitBatch(t, ts.URL, `{"prompts":["go"]}`))
+	if status.Status != "completed" || status.Items[0].Status != "completed" || status.Items[0].FinalText != "" {
+		t.Errorf("expected a completed item without the reset turn's text, got %+v", status.Items[0])
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+)
+
+// Conversation is the main session's history.
+type Conversation struct {
+	ID       string                   `json:"id,omitempty"`
+	Messages []anthropic.MessageParam `json:"messages"`
+}
+
+// HandleGetConversation handles GET /conversation requests.
+func (s *Server) HandleGetConversation(w http.ResponseWriter, r *http.Request) {
+	s.writeConversation(w)
+}
+
+// loadConversationRequest is the body of POST /conversation/load.
+type loadConversationRequest struct {
+	ID string `json:"id"`
+}
+
+// HandleLoadConversation handles POST /conversation/load requests, replacing
+// the main session's history with a stored conversation.
+func (s *Server) HandleLoadConversation(w http.ResponseWriter, r *http.Request) {
+	var req loadConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ID == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	if err := s.harness.LoadConversation(req.ID); err != nil {
+		s.logger.Warn("http", "Conversation load failed",
+			log.F("conversation_id", req.ID),
+			log.F("error", err.Error()),
+		)
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+
+	s.broadcast(Event{Type: "conversation_loaded", ID: req.ID})
+	s.writeConversation(w)
+}
+
+// HandleReset handles POST /reset requests, clearing the main session's
+// history so the next prompt starts a fresh conversation.
+func (s *Server) HandleReset(w http.ResponseWriter, r *http.Request) {
+	if err := s.harness.Reset(); err != nil {
+		writeErr(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.broadcast(Event{Type: "conversation_reset"})
+	s.writeConversation(w)
+}
+
+// HandleListCheckpoints handles GET /checkpoints requests, listing the turns
+// POST /rollback can undo.
+func (s *Server) HandleListCheckpoints(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(checkpointsResponse{Checkpoints: s.harness.Checkpoints()})
+}
+
+// checkpointsResponse is the body of GET /checkpoints.
+type checkpointsResponse struct {
+	Checkpoints []harness.Checkpoint `json:"checkpoints"`
+}
+
+// rollbackRequest is the optional body of POST /rollback.
+type rollbackRequest struct {
+	Turns int `json:"turns,omitempty"` // turns to undo, default 1
+}
+
+// rollbackResponse is the body of a successful POST /rollback: the rolled
+// back conversation and the files restored.
+type rollbackResponse struct {
+	Conversation
+	Restored []string `json:"restored"`
+	// Errors lists files that could not be restored
+	Errors string `json:"errors,omitempty"`
+}
+
+// HandleRollback handles POST /rollback requests, undoing the main
+// session's last turns (default 1) and the file changes they made.
+func (s *Server) HandleRollback(w http.ResponseWriter, r *http.Request) {
+	req := rollbackRequest{Turns: 1}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	restored, err := s.harness.Rollback(req.Turns)
+	if errors.Is(err, harness.ErrPromptInProgress) || errors.Is(err, harness.ErrNoCheckpoint) {
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+	resp := rollbackResponse{
+		Conversation: Conversation{ID: s.harness.ConversationID(), Messages: s.harness.Messages()},
+		Restored:     restored,
+	}
+	if resp.Restored == nil {
+		resp.Restored = []string{}
+	}
+	if err != nil {
+		s.logger.Warn("http", "Rollback left files unrestored",
+			log.F("error", err.Error()),
+		)
+		resp.Errors = err.Error()
+	}
+
+	s.broadcast(Event{Type: "rollback", Turns: req.Turns})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeConversation responds with the main session's history.
+func (s *Server) writeConversation(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Conversation{
+		ID:       s.harness.ConversationID(),
+		Messages: s.harness.Messages(),
+	})
+}
@@ -0,0 +1,140 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func TestConversation_GetAndLoad(t *testing.T) {
+	store, err := harness.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+
+	// Seed a stored conversation with one prompt
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Seeded"))
+	seed, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	seed.SetStore(store, "saved")
+	seed.Prompt(context.Background(), "seed")
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	h.SetStore(store, "fresh")
+	ts := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/conversation")
+	if err != nil {
+		t.Fatalf("GET /conversation failed: %v", err)
+	}
+	var conv struct {
+		ID       string            `json:"id"`
+		Messages []json.RawMessage `json:"messages"`
+	}
+	json.NewDecoder(resp.Body).Decode(&conv)
+	resp.Body.Close()
+	if conv.ID != "fresh" || len(conv.Messages) != 0 {
+		t.Errorf("expected empty fresh conversation, got %+v", conv)
+	}
+
+	resp = postJSON(t, ts.URL+"/conversation/load", `{"id":"missing"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown conversation, got %d", resp.StatusCode)
+	}
+
+	resp = postJSON(t, ts.URL+"/conversation/load", `{"id":"saved"}`)
+	json.NewDecoder(resp.Body).Decode(&conv)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || conv.ID != "saved" || len(conv.Messages) != 2 {
+		t.Errorf("expected loaded conversation, got %d %+v", resp.StatusCode, conv)
+	}
+}
+
+func TestConversation_Reset(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Hello"))
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	h.Prompt(context.Background(), "hi")
+	ts := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer ts.Close()
+
+	resp := postJSON(t, ts.URL+"/reset", "")
+	var conv struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	json.NewDecoder(resp.Body).Decode(&conv)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(conv.Messages) != 0 {
+		t.Errorf("expected empty conversation, got %d %+v", resp.StatusCode, conv)
+	}
+	if got := len(h.Messages()); got != 0 {
+		t.Errorf("expected harness history cleared, got %d messages", got)
+	}
+
+	// The reset was broadcast; replay it from the event history
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/events?last_event_id=0", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `"type":"conversation_reset"`) {
+		t.Errorf("expected conversation_reset event, got:\n%s", body)
+	}
+}
+
+func TestConversation_Rollback(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Hello"))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Hello again"))
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model", Checkpoints: 5}, nil, nil, mockStreamer)
+	h.Prompt(context.Background(), "hi")
+	h.Prompt(context.Background(), "hi again")
+	ts := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/checkpoints")
+	if err != nil {
+		t.Fatalf("GET /checkpoints failed: %v", err)
+	}
+	var list struct {
+		Checkpoints []harness.Checkpoint `json:"checkpoints"`
+	}
+	json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if len(list.Checkpoints) != 2 || list.Checkpoints[1].Messages != 2 {
+		t.Fatalf("unexpected checkpoints: %+v", list.Checkpoints)
+	}
+
+	// The default undoes one turn: the second prompt
+	resp = postJSON(t, ts.URL+"/rollback", "")
+	var conv struct {
+		Messages []json.RawMessage `json:"messages"`
+		Restored []string          `json:"restored"`
+	}
+	json.NewDecoder(resp.Body).Decode(&conv)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(conv.Messages) != 2 || conv.Restored == nil {
+		t.Errorf("expected the first prompt's conversation, got %d %+v", resp.StatusCode, conv)
+	}
+
+	resp = postJSON(t, ts.URL+"/rollback", `{"turns": 2}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 beyond the checkpoints, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures which browser origins may call the server.
+type CORSConfig struct {
+	// AllowedOrigins are the origins (e.g. "https://app.example.com") that
+	// may make cross-origin requests; "*" allows any origin. Empty allows
+	// none.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed in cross-origin requests.
+	// Default: GET, POST, PUT, DELETE, OPTIONS.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed in cross-origin
+	// requests. Default: Content-Type, Authorization, X-API-Key.
+	AllowedHeaders []string
+	// AllowCredentials lets browsers send cookies and HTTP authentication
+	// with cross-origin requests. It cannot be combined with "*".
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response; zero
+	// leaves it to the browser.
+	MaxAge time.Duration
+}
+
+// ErrCORSWildcardCredentials is returned by SetCORS for a configuration
+// allowing credentials from any origin, which browsers reject and which
+// would expose authenticated sessions to every site.
+var ErrCORSWildcardCredentials = errors.New(`CORS credentials require explicit origins, not "*"`)
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", APIKeyHeader}
+)
+
+// DefaultCORSConfig allows requests from any origin without credentials,
+// which suits local use. Deployments should list their origins instead.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: slices.Clone(defaultCORSMethods),
+		AllowedHeaders: slices.Clone(defaultCORSHeaders),
+	}
+}
+
+// SetCORS replaces the server's CORS configuration, which defaults to
+// DefaultCORSConfig. Empty methods and headers take their defaults. Returns
+// ErrCORSWildcardCredentials if AllowCredentials is combined with "*".
+func (s *Server) SetCORS(config CORSConfig) error {
+	if config.AllowCredentials && slices.Contains(config.AllowedOrigins, "*") {
+		return ErrCORSWildcardCredentials
+	}
+	if len(config.AllowedMethods) == 0 {
+		config.AllowedMethods = defaultCORSMethods
+	}
+	if len(config.AllowedHeaders) == 0 {
+		config.AllowedHeaders = defaultCORSHeaders
+	}
+	s.corsMu.Lock()
+	defer s.corsMu.Unlock()
+	s.cors = config
+	return nil
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request
+// from origin, or "" if the origin is not allowed.
+func (c CORSConfig) allowOrigin(origin string) string {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" && !c.AllowCredentials {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// corsMiddleware adds CORS headers for allowed origins and answers
+// preflight requests. Preflight requests carry no credentials, so it runs
+// before authentication.
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.corsMu.RLock()
+		config := s.cors
+		s.corsMu.RUnlock()
+
+		allowed := config.allowOrigin(r.Header.Get("Origin"))
+		if allowed != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			if allowed != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+			if config.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if r.Method == http.MethodOptions {
+			if allowed == "" {
+				writeError(w, http.StatusForbidden, "origin not allowed")
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+			if config.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
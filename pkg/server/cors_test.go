@@ -0,0 +1,100 @@
+package server_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func newCORSServer(t *testing.T, config server.CORSConfig) *httptest.Server {
+	t.Helper()
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", nil)
+	if err := s.SetCORS(config); err != nil {
+		t.Fatalf("SetCORS failed: %v", err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func corsRequest(t *testing.T, method, url, origin string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	req.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", "POST")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestCORS_AllowedOrigins(t *testing.T) {
+	ts := newCORSServer(t, server.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+
+	resp := corsRequest(t, "GET", ts.URL+"/status", "https://app.example.com")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("expected the origin echoed, got %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("expected credentials allowed, got %q", got)
+	}
+	if got := resp.Header.Get("Vary"); got != "Origin" {
+		t.Errorf("expected Vary: Origin, got %q", got)
+	}
+
+	resp = corsRequest(t, "OPTIONS", ts.URL+"/prompt", "https://app.example.com")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 for an allowed preflight, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Methods"); got != "GET, POST, PUT, DELETE, OPTIONS" {
+		t.Errorf("expected the default methods, got %q", got)
+	}
+	if got := resp.Header.Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("expected max age 600, got %q", got)
+	}
+
+	// Other origins get no CORS headers, and their preflights are refused
+	resp = corsRequest(t, "GET", ts.URL+"/status", "https://evil.example.com")
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected no Allow-Origin for another origin, got %q", got)
+	}
+	resp = corsRequest(t, "OPTIONS", ts.URL+"/prompt", "https://evil.example.com")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for a preflight from another origin, got %d", resp.StatusCode)
+	}
+}
+
+func TestCORS_Disabled(t *testing.T) {
+	ts := newCORSServer(t, server.CORSConfig{})
+	resp := corsRequest(t, "GET", ts.URL+"/status", "https://app.example.com")
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected same-origin style requests to succeed, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected no Allow-Origin, got %q", got)
+	}
+}
+
+func TestCORS_WildcardCredentials(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", nil)
+	err := s.SetCORS(server.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	if !errors.Is(err, server.ErrCORSWildcardCredentials) {
+		t.Errorf("expected ErrCORSWildcardCredentials, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+const (
+	// dedupMinBytes is the payload size at which event bodies are deduplicated.
+	dedupMinBytes = 1024
+	// contentStoreMaxBytes bounds the memory used by deduplicated payloads.
+	contentStoreMaxBytes = 32 << 20
+)
+
+// contentStore keeps large event payloads by content hash so repeated bodies
+// can be sent as references. The oldest entries are evicted first.
+type contentStore struct {
+	mu      sync.Mutex
+	entries map[string]string
+	order   []string
+	size    int
+}
+
+// newContentStore creates an empty content store.
+func newContentStore() *contentStore {
+	return &contentStore{entries: make(map[string]string)}
+}
+
+// intern stores body and returns its hash and whether it was already stored.
+func (c *contentStore) intern(body string) (string, bool) {
+	sum := sha256.Sum256([]byte(body))
+	hash := hex.EncodeToString(sum[:])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[hash]; ok {
+		return hash, true
+	}
+	c.entries[hash] = body
+	c.order = append(c.order, hash)
+	c.size += len(body)
+	for c.size > contentStoreMaxBytes && len(c.order) > 1 {
+		oldest := c.order[0]
+		c.order = c.order[1:]
+		c.size -= len(c.entries[oldest])
+		delete(c.entries, oldest)
+	}
+	return hash, false
+}
+
+// get returns the body stored under hash.
+func (c *contentStore) get(hash string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	body, ok := c.entries[hash]
+	return body, ok
+}
+
+// dedupe returns a copy of event for deduplicating clients. Large content,
+// result, and input bodies are tagged with their hash in Refs; bodies that
+// were broadcast before are omitted so clients resolve them from their cache
+// or GET /content/{hash}.
+func (c *contentStore) dedupe(event Event) Event {
+	refs := make(map[string]string)
+	if len(event.Content) >= dedupMinBytes {
+		hash, seen := c.intern(event.Content)
+		refs["content"] = hash
+		if seen {
+			event.Content = ""
+		}
+	}
+	if len(event.Result) >= dedupMinBytes {
+		hash, seen := c.intern(event.Result)
+		refs["result"] = hash
+		if seen {
+			event.Result = ""
+		}
+	}
+	if len(event.Input) >= dedupMinBytes {
+		hash, seen := c.intern(string(event.Input))
+		refs["input"] = hash
+		if seen {
+			event.Input = nil
+		}
+	}
+	if len(refs) > 0 {
+		event.Refs = refs
+	}
+	return event
+}
+
+// HandleContent handles GET /content/{hash} requests, returning a
+// deduplicated event body.
+func (s *Server) HandleContent(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.content.get(r.PathValue("hash"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "content not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write([]byte(body))
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/tool"
+)
+
+// Error codes of error responses. Each status has a default code; errors
+// clients may want to tell apart from others of the same status have their
+// own.
+const (
+	CodeInvalidRequest    = "invalid_request"
+	CodeUnauthorized      = "unauthorized"
+	CodeForbidden         = "forbidden"
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodePayloadTooLarge   = "payload_too_large"
+	CodeRateLimited       = "rate_limited"
+	CodeInternal          = "internal_error"
+	CodeUpstream          = "upstream_error"
+	CodeNotImplemented    = "not_implemented"
+	CodeUnavailable       = "unavailable"
+	CodeBusy              = "busy"                // a prompt is running on the main session
+	CodeNotRunning        = "not_running"         // no prompt is running to steer
+	CodeNothingToContinue = "nothing_to_continue" // the last run cannot be continued
+	CodeNoCheckpoint      = "no_checkpoint"       // rollback beyond the checkpoints kept
+)
+
+// statusCodes are the default codes of error statuses.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeInvalidRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusNotImplemented:        CodeNotImplemented,
+	http.StatusBadGateway:            CodeUpstream,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// errorMappings map errors from the harness, tools, and server to the
+// response they get; the first match wins.
+var errorMappings = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{harness.ErrPromptInProgress, http.StatusConflict, CodeBusy},
+	{harness.ErrNotRunning, http.StatusConflict, CodeNotRunning},
+	{harness.ErrNothingToContinue, http.StatusConflict, CodeNothingToContinue},
+	{harness.ErrNoCheckpoint, http.StatusBadRequest, CodeNoCheckpoint},
+	{harness.ErrConversationNotFound, http.StatusNotFound, CodeNotFound},
+	{harness.ErrNoStore, http.StatusNotFound, CodeNotFound},
+	{tool.ErrMemoryNotFound, http.StatusNotFound, CodeNotFound},
+	{ErrShuttingDown, http.StatusServiceUnavailable, CodeUnavailable},
+}
+
+// APIError is an error response, sent as {"error": {"code", "message",
+// "details"}}.
+type APIError struct {
+	Status  int            `json:"-"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Error returns the message.
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// errorResponse is the body of every error response.
+type errorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+// newAPIError returns an error response with the default code of status.
+func newAPIError(status int, message string) *APIError {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = CodeInternal
+	}
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+// toAPIError returns the response for err: an *APIError as is, a mapped
+// error by errorMappings, and any other error with the fallback status.
+func toAPIError(err error, fallback int) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return &APIError{Status: m.status, Code: m.code, Message: err.Error()}
+		}
+	}
+	return newAPIError(fallback, err.Error())
+}
+
+// writeError writes an error response with the default code of status.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeAPIError(w, newAPIError(status, message))
+}
+
+// writeErr writes the error response err maps to, with the fallback status
+// for errors without a mapping.
+func writeErr(w http.ResponseWriter, err error, fallback int) {
+	writeAPIError(w, toAPIError(err, fallback))
+}
+
+// writeAPIError writes e as an error response.
+func writeAPIError(w http.ResponseWriter, e *APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(errorResponse{Error: e})
+}
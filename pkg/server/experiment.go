@@ -0,0 +1,549 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+)
+
+const (
+	// maxExperimentVariants caps the number of variants in one experiment.
+	maxExperimentVariants = 4
+	// experimentRetention is how long a finished experiment is kept.
+	experimentRetention = time.Hour
+	// maxFinishedExperiments caps how many finished experiments are kept;
+	// the oldest are forgotten first.
+	maxFinishedExperiments = 100
+)
+
+// errExperimentCancelled is the error of variants stopped by
+// POST /experiments/{id}/cancel.
+var errExperimentCancelled = errors.New("experiment cancelled")
+
+// experimentRequest is the body of POST /experiments.
+type experimentRequest struct {
+	Prompt   string            `json:"prompt"`
+	Variants []harness.Variant `json:"variants"`
+}
+
+// experimentResponse is the body of an accepted POST /experiments.
+type experimentResponse struct {
+	ExperimentID string `json:"experiment_id"`
+}
+
+// VariantResult summarizes one variant's run for comparison.
+type VariantResult struct {
+	ID           string        `json:"id"`
+	Model        string        `json:"model"`
+	Status       string        `json:"status"`
+	Turns        int           `json:"turns"`
+	Usage        harness.Usage `json:"usage"`
+	FilesChanged []string      `json:"files_changed"`
+	// Workspace is the variant's copy of the workspace, kept with its
+	// changes until the experiment is forgotten.
+	Workspace  string `json:"workspace,omitempty"`
+	FinalText  string `json:"final_text,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ExperimentStatus is the comparison summary returned by GET /experiments/{id}.
+type ExperimentStatus struct {
+	ID          string          `json:"id"`
+	Prompt      string          `json:"prompt"`
+	Status      string          `json:"status"`
+	Variants    []VariantResult `json:"variants"`
+	CreatedAt   int64           `json:"created_at"`
+	CompletedAt int64           `json:"completed_at,omitempty"`
+}
+
+// experiment tracks a running or finished experiment.
+type experiment struct {
+	mu     sync.Mutex
+	status ExperimentStatus
+
+	// source is the workspace each variant runs in a copy of, under dir.
+	source string
+	dir    string
+
+	// Variants run with ctx, derived from the server's lifecycle context;
+	// cancel stops them. done is closed when all have finished, at
+	// finished.
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	done     chan struct{}
+	finished time.Time
+}
+
+// snapshot returns a copy of the experiment status that is safe to serialize.
+func (e *experiment) snapshot() ExperimentStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	status := e.status
+	status.Variants = make([]VariantResult, len(e.status.Variants))
+	copy(status.Variants, e.status.Variants)
+	return status
+}
+
+// variantEventHandler broadcasts a variant session's events tagged with its
+// variant ID and records which files the variant changed.
+type variantEventHandler struct {
+	server     *Server
+	experiment string
+	variant    string
+
+	mu       sync.Mutex
+	session  *harness.Harness
+	pending  map[string][]string // tool call ID -> paths
+	files    []string
+	lastText string
+	// committedText is lastText as of the last tool result, restored when
+	// the turn after it is streamed again
+	committedText string
+}
+
+func (h *variantEventHandler) broadcast(event Event) {
+	event.Variant = h.variant
+	event.Experiment = h.experiment
+	event.Session = variantSession(h.experiment, h.variant)
+	h.server.broadcast(event)
+}
+
+func (h *variantEventHandler) OnText(text string) {
+	h.mu.Lock()
+	h.lastText = text
+	h.mu.Unlock()
+	h.broadcast(Event{Type: "text", Content: text})
+}
+
+func (h *variantEventHandler) OnToolCall(id string, name string, input json.RawMessage) {
+	h.mu.Lock()
+	if h.session != nil && h.session.IsMutatingTool(name) {
+		h.pending[id] = inputPaths(input)
+	}
+	h.mu.Unlock()
+	h.broadcast(Event{Type: "tool_call", ID: id, Name: name, Input: input})
+}
+
+func (h *variantEventHandler) OnToolResult(id string, result string, isError bool) {
+	h.mu.Lock()
+	h.committedText = h.lastText
+	if paths, ok := h.pending[id]; ok {
+		delete(h.pending, id)
+		if !isError {
+			for _, p := range paths {
+				if !slices.Contains(h.files, p) {
+					h.files = append(h.files, p)
+				}
+			}
+		}
+	}
+	h.mu.Unlock()
+	h.broadcast(Event{Type: "tool_result", ID: id, Result: result, IsError: isError})
+}
+
+func (h *variantEventHandler) OnReasoning(content string) {
+	h.broadcast(Event{Type: "reasoning", Content: content})
+}
+
+// OnStreamReset drops the text of a turn that failed partway and tells the
+// variant's clients to do the same.
+func (h *variantEventHandler) OnStreamReset(err error) {
+	h.mu.Lock()
+	h.lastText = h.committedText
+	h.mu.Unlock()
+	h.broadcast(Event{Type: "stream_reset", Message: err.Error()})
+}
+
+// variantSession returns the SSE session ID of an experiment variant.
+func variantSession(experiment, variant string) string {
+	return experiment + "/" + variant
+}
+
+// inputPaths extracts file paths from a mutating tool's input.
+func inputPaths(input json.RawMessage) []string {
+	var fields struct {
+		Path        string `json:"path"`
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+	}
+	json.Unmarshal(input, &fields)
+	var paths []string
+	for _, p := range []string{fields.Path, fields.Source, fields.Destination} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// HandleExperiment handles POST /experiments requests.
+// The prompt runs once per variant in parallel isolated sessions, each in
+// its own copy of the main session's workspace, or of the working
+// directory if it has none.
+func (s *Server) HandleExperiment(w http.ResponseWriter, r *http.Request) {
+	if s.refuseIfClosing(w) {
+		return
+	}
+	var req experimentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		writeError(w, http.StatusBadRequest, "prompt is required")
+		return
+	}
+	if len(req.Variants) < 2 || len(req.Variants) > maxExperimentVariants {
+		writeError(w, http.StatusBadRequest, "between 2 and 4 variants are required")
+		return
+	}
+	seen := make(map[string]bool)
+	for _, v := range req.Variants {
+		if v.ID == "" || seen[v.ID] {
+			writeError(w, http.StatusBadRequest, "variant ids must be unique and non-empty")
+			return
+		}
+		if err := s.harness.ValidateOptions(harness.PromptOptions{Profile: v.Profile}); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("variant %s: %v", v.ID, err))
+			return
+		}
+		seen[v.ID] = true
+	}
+
+	// The workspace cannot be deleted until the experiment is registered
+	s.workspaceMu.RLock()
+	defer s.workspaceMu.RUnlock()
+	source := s.harness.Workspace()
+	if source == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to resolve the working directory")
+			return
+		}
+		source = wd
+	}
+	dir, err := os.MkdirTemp("", "harness-experiment-")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create experiment workspaces")
+		return
+	}
+
+	e := &experiment{
+		source: source,
+		dir:    dir,
+		done:   make(chan struct{}),
+		status: ExperimentStatus{
+			ID:        newExperimentID(),
+			Prompt:    req.Prompt,
+			Status:    batchRunning,
+			Variants:  make([]VariantResult, len(req.Variants)),
+			CreatedAt: time.Now().Unix(),
+		},
+	}
+	for i, v := range req.Variants {
+		e.status.Variants[i] = VariantResult{ID: v.ID, Status: batchPending, FilesChanged: []string{}}
+	}
+
+	e.ctx, e.cancel = context.WithCancelCause(s.ctx)
+
+	s.experimentMu.Lock()
+	pruned := s.pruneExperiments(time.Now())
+	s.experiments[e.status.ID] = e
+	s.experimentMu.Unlock()
+	removeExperimentDirs(pruned)
+
+	s.logger.Info("http", "Experiment accepted",
+		log.F("experiment_id", e.status.ID),
+		log.F("variants", len(req.Variants)),
+	)
+
+	go s.runExperiment(e, req.Variants)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(experimentResponse{ExperimentID: e.status.ID})
+}
+
+// HandleExperimentStatus handles GET /experiments/{id} requests.
+func (s *Server) HandleExperimentStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.experimentMu.RLock()
+	e, ok := s.experiments[id]
+	s.experimentMu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "experiment not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e.snapshot())
+}
+
+// HandleCancelExperiment handles POST /experiments/{id}/cancel requests.
+// Running variants are cancelled and end as cancelled. Cancelling a
+// finished experiment has no effect.
+func (s *Server) HandleCancelExperiment(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.experimentMu.RLock()
+	e, ok := s.experiments[id]
+	s.experimentMu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "experiment not found")
+		return
+	}
+
+	s.logger.Info("http", "Experiment cancel requested", log.F("experiment_id", id))
+	e.cancel(errExperimentCancelled)
+	w.WriteHeader(http.StatusOK)
+}
+
+// pruneExperiments forgets finished experiments older than
+// experimentRetention, and the oldest finished experiments beyond
+// maxFinishedExperiments, and returns them so the caller can remove their
+// workspaces. The caller must hold experimentMu.
+func (s *Server) pruneExperiments(now time.Time) []*experiment {
+	var pruned, finished []*experiment
+	for id, e := range s.experiments {
+		e.mu.Lock()
+		at := e.finished
+		e.mu.Unlock()
+		switch {
+		case at.IsZero():
+		case now.Sub(at) > experimentRetention:
+			delete(s.experiments, id)
+			pruned = append(pruned, e)
+		default:
+			finished = append(finished, e)
+		}
+	}
+	if len(finished) <= maxFinishedExperiments {
+		return pruned
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].finished.Before(finished[j].finished)
+	})
+	for _, e := range finished[:len(finished)-maxFinishedExperiments] {
+		delete(s.experiments, e.status.ID)
+		pruned = append(pruned, e)
+	}
+	return pruned
+}
+
+// runningExperiments returns the experiments that have not finished.
+func (s *Server) runningExperiments() []*experiment {
+	s.experimentMu.RLock()
+	defer s.experimentMu.RUnlock()
+	var running []*experiment
+	for _, e := range s.experiments {
+		select {
+		case <-e.done:
+		default:
+			running = append(running, e)
+		}
+	}
+	return running
+}
+
+// removeExperiments forgets all experiments and removes their workspaces.
+// Shutdown calls it once they have stopped.
+func (s *Server) removeExperiments() {
+	s.experimentMu.Lock()
+	experiments := make([]*experiment, 0, len(s.experiments))
+	for id, e := range s.experiments {
+		delete(s.experiments, id)
+		experiments = append(experiments, e)
+	}
+	s.experimentMu.Unlock()
+	removeExperimentDirs(experiments)
+}
+
+// removeExperimentDirs removes the variant workspaces of experiments.
+func removeExperimentDirs(experiments []*experiment) {
+	for _, e := range experiments {
+		os.RemoveAll(e.dir)
+	}
+}
+
+// runExperiment runs all variants in parallel and records the comparison.
+// The experiment is cancelled if any variant was.
+func (s *Server) runExperiment(e *experiment, variants []harness.Variant) {
+	defer close(e.done)
+	defer e.cancel(nil)
+
+	var wg sync.WaitGroup
+	for i, v := range variants {
+		wg.Add(1)
+		go func(index int, v harness.Variant) {
+			defer wg.Done()
+			s.runVariant(e, index, v)
+		}(i, v)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	e.mu.Lock()
+	e.status.Status = batchCompleted
+	e.status.CompletedAt = now.Unix()
+	for _, v := range e.status.Variants {
+		if v.Status == batchCancelled {
+			e.status.Status = batchCancelled
+		}
+	}
+	e.finished = now
+	e.mu.Unlock()
+
+	s.logger.Info("http", "Experiment completed", log.F("experiment_id", e.status.ID))
+	s.broadcast(Event{Type: "experiment_completed", ID: e.status.ID})
+}
+
+// runVariant runs the experiment prompt in a session configured by v,
+// jailed to a copy of the experiment's workspace.
+func (s *Server) runVariant(e *experiment, index int, v harness.Variant) {
+	workspace := filepath.Join(e.dir, strconv.Itoa(index))
+	e.mu.Lock()
+	e.status.Variants[index].Workspace = workspace
+	e.mu.Unlock()
+	if err := copyTree(e.ctx, e.source, workspace); err != nil {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		result := &e.status.Variants[index]
+		if e.ctx.Err() != nil {
+			result.Status = batchCancelled
+			result.Error = context.Cause(e.ctx).Error()
+		} else {
+			result.Status = batchFailed
+			result.Error = "copying the workspace: " + err.Error()
+		}
+		return
+	}
+
+	handler := &variantEventHandler{
+		server:     s,
+		experiment: e.status.ID,
+		variant:    v.ID,
+		pending:    make(map[string][]string),
+	}
+	session := s.harness.NewVariantSession(v, handler)
+	session.SetWorkspace(workspace)
+	handler.mu.Lock()
+	handler.session = session
+	handler.mu.Unlock()
+
+	e.mu.Lock()
+	e.status.Variants[index].Status = batchRunning
+	e.status.Variants[index].Model = session.Model()
+	e.mu.Unlock()
+
+	start := time.Now()
+	err := session.Prompt(e.ctx, e.status.Prompt)
+
+	handler.mu.Lock()
+	files := append([]string{}, handler.files...)
+	finalText := handler.lastText
+	handler.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	result := &e.status.Variants[index]
+	result.Turns = session.Turns()
+	result.Usage = session.Usage()
+	result.FilesChanged = files
+	result.FinalText = finalText
+	result.DurationMs = time.Since(start).Milliseconds()
+	switch {
+	case err != nil && e.ctx.Err() != nil && errors.Is(err, context.Canceled):
+		result.Status = batchCancelled
+		result.Error = context.Cause(e.ctx).Error()
+	case err != nil:
+		result.Status = batchFailed
+		result.Error = err.Error()
+	default:
+		result.Status = batchCompleted
+	}
+}
+
+// copyTree copies the directory src to dst, which must not exist. Regular
+// files keep their permissions and symlinks are recreated as they are;
+// other files are skipped, as is the directory holding dst when it is
+// inside src.
+func copyTree(ctx context.Context, src, dst string) error {
+	parent := filepath.Dir(dst)
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() && path == parent {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.Mkdir(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// copyFile copies the regular file src to a new file dst with mode perm.
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// newExperimentID returns a random experiment identifier.
+func newExperimentID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "exp_" + hex.EncodeToString(buf)
+}
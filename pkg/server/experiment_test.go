@@ -0,0 +1,270 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// modelStreamer serves a separate response queue per model, so parallel
+// variant sessions get deterministic responses.
+type modelStreamer struct {
+	mu     sync.Mutex
+	queues map[string][]harness.StreamIterator
+}
+
+func (m *modelStreamer) NewStreaming(ctx context.Context, params anthropic.MessageNewParams) harness.StreamIterator {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	queue := m.queues[string(params.Model)]
+	if len(queue) == 0 {
+		return testutil.NewMessageBuilder().AddText("").Build()
+	}
+	m.queues[string(params.Model)] = queue[1:]
+	return queue[0]
+}
+
+// fileTool is a mutating tool that succeeds without touching the filesystem.
+type fileTool struct{}
+
+func (fileTool) Name() string        { return "write" }
+func (fileTool) Description() string { return "Write a file" }
+func (fileTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"}}}`)
+}
+func (fileTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	return `{"ok":true}`, nil
+}
+
+// startExperiment posts an experiment and returns its ID.
+func startExperiment(t *testing.T, url, body string) string {
+	t.Helper()
+	resp := postJSON(t, url+"/experiments", body)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d", resp.StatusCode)
+	}
+	var accepted struct {
+		ExperimentID string `json:"experiment_id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&accepted)
+	return accepted.ExperimentID
+}
+
+// waitForExperiment polls GET /experiments/{id} until the experiment has
+// finished.
+func waitForExperiment(t *testing.T, url, id string) server.ExperimentStatus {
+	t.Helper()
+	var status server.ExperimentStatus
+	deadline := time.Now().Add(5 * time.Second)
+	for status.Status == "" || status.Status == "running" {
+		if time.Now().After(deadline) {
+			t.Fatalf("experiment did not finish: %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		resp, err := http.Get(url + "/experiments/" + id)
+		if err != nil {
+			t.Fatalf("GET /experiments failed: %v", err)
+		}
+		json.NewDecoder(resp.Body).Decode(&status)
+		resp.Body.Close()
+	}
+	return status
+}
+
+func TestExperiment_ComparesVariants(t *testing.T) {
+	streamer := &modelStreamer{queues: map[string][]harness.StreamIterator{
+		"model-a": {
+			testutil.NewMessageBuilder().AddToolUse("w1", "write", map[string]string{"path": "main.go"}).WithUsage(10, 5).Build(),
+			testutil.NewMessageBuilder().AddText("answer a").WithUsage(20, 5).Build(),
+		},
+		"model-b": {
+			testutil.NewMessageBuilder().AddText("answer b").WithUsage(7, 3).Build(),
+		},
+	}}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "model-a", Workspace: t.TempDir()}, []tool.Tool{fileTool{}}, nil, streamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	body, _ := json.Marshal(map[string]any{
+		"prompt": "fix the bug",
+		"variants": []map[string]string{
+			{"id": "control"},
+			{"id": "candidate", "model": "model-b", "system_prompt": "Be brief."},
+		},
+	})
+	status := waitForExperiment(t, ts.URL, startExperiment(t, ts.URL, string(body)))
+	if status.Status != "completed" {
+		t.Fatalf("expected the experiment to complete, got %+v", status)
+	}
+
+	control, candidate := status.Variants[0], status.Variants[1]
+	if control.Model != "model-a" || candidate.Model != "model-b" {
+		t.Errorf("unexpected models: %q, %q", control.Model, candidate.Model)
+	}
+	if control.Turns != 2 || candidate.Turns != 1 {
+		t.Errorf("unexpected turns: %d, %d", control.Turns, candidate.Turns)
+	}
+	if control.FinalText != "answer a" || candidate.FinalText != "answer b" {
+		t.Errorf("unexpected final texts: %q, %q", control.FinalText, candidate.FinalText)
+	}
+	if control.Usage.InputTokens != 30 || candidate.Usage.InputTokens != 7 {
+		t.Errorf("unexpected usage: %+v, %+v", control.Usage, candidate.Usage)
+	}
+	if len(control.FilesChanged) != 1 || control.FilesChanged[0] != "main.go" {
+		t.Errorf("expected control to change main.go, got %v", control.FilesChanged)
+	}
+	if len(candidate.FilesChanged) != 0 {
+		t.Errorf("expected candidate to change no files, got %v", candidate.FilesChanged)
+	}
+	if len(h.Messages()) != 0 {
+		t.Errorf("experiments should not modify the main conversation")
+	}
+}
+
+func TestExperiment_Validation(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	for name, body := range map[string]string{
+		"no prompt":     `{"variants":[{"id":"a"},{"id":"b"}]}`,
+		"one variant":   `{"prompt":"x","variants":[{"id":"a"}]}`,
+		"duplicate ids": `{"prompt":"x","variants":[{"id":"a"},{"id":"a"}]}`,
+	} {
+		resp, err := http.Post(ts.URL+"/experiments", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, resp.StatusCode)
+		}
+	}
+
+	resp, _ := http.Get(ts.URL + "/experiments/missing")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown experiment, got %d", resp.StatusCode)
+	}
+}
+
+func TestExperiment_VariantsEditSeparateCopies(t *testing.T) {
+	workspace := t.TempDir()
+	os.WriteFile(filepath.Join(workspace, "main.go"), []byte("package main\n"), 0644)
+	os.Mkdir(filepath.Join(workspace, "sub"), 0755)
+	os.WriteFile(filepath.Join(workspace, "sub", "notes.txt"), []byte("notes\n"), 0644)
+
+	write := func(content string) harness.StreamIterator {
+		return testutil.NewMessageBuilder().AddToolUse("w1", "write", map[string]string{"path": "main.go", "content": content}).Build()
+	}
+	streamer := &modelStreamer{queues: map[string][]harness.StreamIterator{
+		"model-a": {write("package a\n"), testutil.NewMessageBuilder().AddText("done a").Build()},
+		"model-b": {write("package b\n"), testutil.NewMessageBuilder().AddText("done b").Build()},
+	}}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "model-a", Workspace: workspace}, []tool.Tool{tool.NewWriteTool()}, nil, streamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	id := startExperiment(t, ts.URL, `{"prompt":"edit","variants":[{"id":"a"},{"id":"b","model":"model-b"}]}`)
+	status := waitForExperiment(t, ts.URL, id)
+
+	for i, want := range []string{"package a\n", "package b\n"} {
+		variant := status.Variants[i]
+		if variant.Status != "completed" || variant.Workspace == "" || variant.Workspace == workspace {
+			t.Fatalf("variant %s: expected a completed run in a copy, got %+v", variant.ID, variant)
+		}
+		if data, _ := os.ReadFile(filepath.Join(variant.Workspace, "main.go")); string(data) != want {
+			t.Errorf("variant %s: expected main.go %q, got %q", variant.ID, want, data)
+		}
+		if data, _ := os.ReadFile(filepath.Join(variant.Workspace, "sub", "notes.txt")); string(data) != "notes\n" {
+			t.Errorf("variant %s: expected the workspace to be copied, got notes %q", variant.ID, data)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "main.go")); string(data) != "package main\n" {
+		t.Errorf("expected the source workspace to be unchanged, got %q", data)
+	}
+
+	// Shutdown removes the copies
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if _, err := os.Stat(status.Variants[0].Workspace); !os.IsNotExist(err) {
+		t.Errorf("expected the variant workspace to be removed at shutdown, got %v", err)
+	}
+}
+
+func TestExperiment_Cancel(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(2)
+	blocking := &MockTool{
+		name: "blocking",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			started.Done()
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+	block := func() harness.StreamIterator {
+		return testutil.SingleToolResponse("call_1", "blocking", map[string]string{})
+	}
+	streamer := &modelStreamer{queues: map[string][]harness.StreamIterator{
+		"model-a": {block()},
+		"model-b": {block()},
+	}}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "model-a", Workspace: t.TempDir()}, []tool.Tool{blocking}, nil, streamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	id := startExperiment(t, ts.URL, `{"prompt":"block","variants":[{"id":"a"},{"id":"b","model":"model-b"}]}`)
+	started.Wait()
+	resp := postJSON(t, ts.URL+"/experiments/"+id+"/cancel", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	status := waitForExperiment(t, ts.URL, id)
+	if status.Status != "cancelled" {
+		t.Errorf("expected the experiment to be cancelled, got %q", status.Status)
+	}
+	for _, variant := range status.Variants {
+		if variant.Status != "cancelled" || variant.Error != "experiment cancelled" {
+			t.Errorf("variant %s: expected cancelled, got %q (%s)", variant.ID, variant.Status, variant.Error)
+		}
+	}
+
+	resp = postJSON(t, ts.URL+"/experiments/missing/cancel", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown experiment, got %d", resp.StatusCode)
+	}
+}
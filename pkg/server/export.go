@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Export formats accepted by GET /export.
+const (
+	exportMarkdown = "markdown"
+	exportJSON     = "json"
+)
+
+// Export is the main session's conversation as returned by
+// GET /export?format=json.
+type Export struct {
+	ID         string              `json:"id,omitempty"`
+	Model      string              `json:"model"`
+	ExportedAt int64               `json:"exported_at"`
+	Messages   []TranscriptMessage `json:"messages"`
+}
+
+// HandleExport handles GET /export requests, rendering the main session's
+// full history as a Markdown document (the default) or as JSON for sharing.
+// It is built from the harness's history, so it includes every turn
+// regardless of which events a client received.
+func (s *Server) HandleExport(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportMarkdown
+	}
+	if format != exportMarkdown && format != exportJSON {
+		writeError(w, http.StatusBadRequest, "format must be markdown or json")
+		return
+	}
+
+	export := Export{
+		ID:         s.harness.ConversationID(),
+		Model:      s.harness.Model(),
+		ExportedAt: time.Now().Unix(),
+		Messages:   transcriptMessages(s.harness.TimedMessages()),
+	}
+	name := "conversation"
+	if export.ID != "" {
+		name += "-" + export.ID
+	}
+
+	if format == exportJSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".json"))
+		json.NewEncoder(w).Encode(export)
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".md"))
+	w.Write([]byte(renderMarkdown(export)))
+}
+
+// renderMarkdown renders an export as Markdown. Reasoning and tool results
+// are folded into <details> blocks, and a user message holding only tool
+// results continues the assistant's section rather than opening a new one.
+func renderMarkdown(export Export) string {
+	var b strings.Builder
+	b.WriteString("# Conversation")
+	if export.ID != "" {
+		b.WriteString(" " + export.ID)
+	}
+	fmt.Fprintf(&b, "\n\n_Model %s, exported %s_\n", export.Model,
+		time.Unix(export.ExportedAt, 0).UTC().Format(time.RFC3339))
+
+	for _, msg := range export.Messages {
+		if !onlyToolResults(msg) {
+			heading := "User"
+			if msg.Role == "assistant" {
+				heading = "Assistant"
+			}
+			b.WriteString("\n## " + heading)
+			if msg.Timestamp != 0 {
+				b.WriteString(" (" + time.Unix(msg.Timestamp, 0).UTC().Format(time.RFC3339) + ")")
+			}
+			b.WriteString("\n")
+		}
+		for _, block := range msg.Content {
+			b.WriteString("\n")
+			writeMarkdownBlock(&b, block)
+		}
+	}
+	return b.String()
+}
+
+// writeMarkdownBlock renders one transcript block.
+func writeMarkdownBlock(b *strings.Builder, block TranscriptBlock) {
+	switch block.Type {
+	case "text":
+		b.WriteString(strings.TrimSpace(block.Content) + "\n")
+	case "reasoning":
+		b.WriteString("<details>\n<summary>Reasoning</summary>\n\n")
+		b.WriteString(strings.TrimSpace(block.Content) + "\n\n</details>\n")
+	case "tool_call", "server_tool":
+		fmt.Fprintf(b, "**Tool call:** `%s` (`%s`)\n\n", block.Name, block.ID)
+		writeCodeBlock(b, "json", prettyJSON(block.Input))
+	case "tool_result", "server_tool_result":
+		summary := "Result"
+		if block.IsError {
+			summary = "Error"
+		}
+		fmt.Fprintf(b, "<details>\n<summary>%s of <code>%s</code></summary>\n\n", summary, block.ID)
+		writeCodeBlock(b, "", block.Result)
+		b.WriteString("\n</details>\n")
+	default:
+		fmt.Fprintf(b, "_[%s block]_\n", block.Type)
+	}
+}
+
+// writeCodeBlock writes text in a fenced code block whose fence is longer
+// than any run of backticks in the text.
+func writeCodeBlock(b *strings.Builder, lang, text string) {
+	longest, run := 0, 0
+	for _, c := range text {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	b.WriteString(fence + lang + "\n")
+	b.WriteString(strings.TrimRight(text, "\n") + "\n")
+	b.WriteString(fence + "\n")
+}
+
+// onlyToolResults reports whether msg holds nothing but tool results.
+func onlyToolResults(msg TranscriptMessage) bool {
+	if len(msg.Content) == 0 {
+		return false
+	}
+	for _, block := range msg.Content {
+		if block.Type != "tool_result" && block.Type != "server_tool_result" {
+			return false
+		}
+	}
+	return true
+}
+
+// prettyJSON indents raw JSON, returning it unchanged if it is invalid.
+func prettyJSON(raw json.RawMessage) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, raw, "", "  "); err != nil {
+		return string(raw)
+	}
+	return out.String()
+}
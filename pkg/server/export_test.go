@@ -0,0 +1,95 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestExport(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddThinking("need the file").
+		AddToolUse("tool_1", "mock_tool", map[string]string{"value": "a.txt"}).
+		BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("It holds ``` fences."))
+
+	mockTool := &MockTool{
+		name: "mock_tool",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			return "a ``` b", nil
+		},
+	}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{mockTool}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "read a.txt"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+	ts := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer ts.Close()
+
+	get := func(query string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/export" + query)
+		if err != nil {
+			t.Fatalf("GET /export failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, md := get("")
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/markdown") {
+		t.Errorf("expected markdown, got %q", ct)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.Contains(cd, ".md") {
+		t.Errorf("expected a .md attachment, got %q", cd)
+	}
+	for _, want := range []string{
+		"# Conversation",
+		"_Model test-model, exported ",
+		"## User",
+		"read a.txt",
+		"## Assistant",
+		"<summary>Reasoning</summary>\n\nneed the file",
+		"**Tool call:** `mock_tool` (`tool_1`)\n\n```json\n{\n  \"value\": \"a.txt\"\n}\n```",
+		"<summary>Result of <code>tool_1</code></summary>\n\n````\na ``` b\n````",
+		"It holds ``` fences.",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected %q in the export:\n%s", want, md)
+		}
+	}
+	// The tool result message continues the assistant's section
+	if n := strings.Count(md, "## User"); n != 1 {
+		t.Errorf("expected 1 user section, got %d:\n%s", n, md)
+	}
+
+	resp, body := get("?format=json")
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON, got %q", ct)
+	}
+	var export server.Export
+	if err := json.Unmarshal([]byte(body), &export); err != nil {
+		t.Fatalf("failed to decode export: %v", err)
+	}
+	if export.Model != "test-model" || export.ExportedAt == 0 || len(export.Messages) != 4 {
+		t.Errorf("unexpected export %+v", export)
+	}
+
+	if resp, _ := get("?format=pdf"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", resp.StatusCode)
+	}
+}
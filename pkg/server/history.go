@@ -0,0 +1,84 @@
+package server
+
+// DefaultEventHistorySize is the number of recent events kept for replay
+// to clients that reconnect with Last-Event-ID.
+const DefaultEventHistorySize = 1000
+
+// historyEntry is a broadcast event retained for replay.
+type historyEntry struct {
+	id        uint64
+	session   string
+	data      []byte
+	dedupData []byte
+}
+
+// payload returns the encoding of the entry a client receives.
+func (e historyEntry) payload(dedup bool) []byte {
+	if dedup {
+		return e.dedupData
+	}
+	return e.data
+}
+
+// eventHistory is a ring buffer of the most recent events. Event IDs
+// increase monotonically for the life of the server, including events
+// that no longer fit in the buffer. It is guarded by Server.mu.
+type eventHistory struct {
+	entries []historyEntry
+	start   int // index of the oldest entry
+	count   int
+	lastID  uint64
+}
+
+// newEventHistory creates a history holding up to size events.
+// A size of 0 assigns IDs but retains nothing.
+func newEventHistory(size int) *eventHistory {
+	return &eventHistory{entries: make([]historyEntry, max(size, 0))}
+}
+
+// add records an event and returns its ID.
+func (h *eventHistory) add(session string, data, dedupData []byte) uint64 {
+	h.lastID++
+	if len(h.entries) == 0 {
+		return h.lastID
+	}
+	entry := historyEntry{id: h.lastID, session: session, data: data, dedupData: dedupData}
+	if h.count < len(h.entries) {
+		h.entries[(h.start+h.count)%len(h.entries)] = entry
+		h.count++
+	} else {
+		h.entries[h.start] = entry
+		h.start = (h.start + 1) % len(h.entries)
+	}
+	return h.lastID
+}
+
+// since returns the retained events after lastID that a client following
+// session receives ("" for all sessions), oldest first, and the number of
+// events after lastID that are no longer retained. A lastID newer than any
+// event, as after a server restart, replays everything retained.
+func (h *eventHistory) since(lastID uint64, session string) ([]historyEntry, uint64) {
+	if lastID > h.lastID {
+		lastID = 0
+	}
+	var missed uint64
+	if h.count > 0 {
+		if oldest := h.entries[h.start].id; lastID+1 < oldest {
+			missed = oldest - lastID - 1
+		}
+	} else {
+		missed = h.lastID - lastID
+	}
+
+	var entries []historyEntry
+	for i := range h.count {
+		entry := h.entries[(h.start+i)%len(h.entries)]
+		if entry.id <= lastID {
+			continue
+		}
+		if session == "" || entry.session == session {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, missed
+}
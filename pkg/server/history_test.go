@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventHistory_RingBuffer(t *testing.T) {
+	h := newEventHistory(3)
+	for i := range 5 {
+		session := MainSession
+		if i%2 == 1 {
+			session = "exp_1/a"
+		}
+		if id := h.add(session, []byte{byte('a' + i)}, nil); id != uint64(i+1) {
+			t.Fatalf("expected id %d, got %d", i+1, id)
+		}
+	}
+
+	ids := func(entries []historyEntry) []uint64 {
+		var got []uint64
+		for _, e := range entries {
+			got = append(got, e.id)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name       string
+		lastID     uint64
+		session    string
+		wantIDs    []uint64
+		wantMissed uint64
+	}{
+		{"all retained", 2, "", []uint64{3, 4, 5}, 0},
+		{"partially evicted", 0, "", []uint64{3, 4, 5}, 2},
+		{"up to date", 5, "", nil, 0},
+		{"recent", 4, "", []uint64{5}, 0},
+		{"session filter", 2, MainSession, []uint64{3, 5}, 0},
+		{"server restarted", 42, "", []uint64{3, 4, 5}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, missed := h.since(tt.lastID, tt.session)
+			if got := ids(entries); fmt.Sprint(got) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("expected ids %v, got %v", tt.wantIDs, got)
+			}
+			if missed != tt.wantMissed {
+				t.Errorf("expected %d missed, got %d", tt.wantMissed, missed)
+			}
+		})
+	}
+
+	empty := newEventHistory(0)
+	empty.add("", []byte("x"), nil)
+	if entries, missed := empty.since(0, ""); len(entries) != 0 || missed != 1 {
+		t.Errorf("disabled history: expected nothing retained and 1 missed, got %d, %d", len(entries), missed)
+	}
+}
+
+func TestServer_HandleSSEResume(t *testing.T) {
+	h := createTestHarness(t)
+	s := NewServer(h, ":8080", nil)
+	s.SetEventHistorySize(2)
+
+	s.broadcast(Event{Type: "text", Content: "first"})
+	s.broadcast(Event{Type: "text", Content: "second"})
+	s.broadcast(Event{Type: "text", Content: "third"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", "0")
+	rec := httptest.NewRecorder()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.HandleSSE(rec, req)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	s.broadcast(Event{Type: "text", Content: "live"})
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	body := rec.Body.String()
+	if strings.Contains(body, "first") {
+		t.Error("evicted event should not be replayed")
+	}
+	gap := strings.Index(body, `"type":"history_gap"`)
+	if gap < 0 || !strings.Contains(body, "1 events are no longer available") {
+		t.Fatalf("expected history_gap event, got:\n%s", body)
+	}
+	order := []string{"id: 2\ndata: ", "second", "id: 3\ndata: ", "third", "id: 4\ndata: ", "live"}
+	pos := gap
+	for _, want := range order {
+		i := strings.Index(body[pos:], want)
+		if i < 0 {
+			t.Fatalf("expected %q after offset %d, got:\n%s", want, pos, body)
+		}
+		pos += i
+	}
+}
+
+func TestServer_HandleSSEFreshConnectionSkipsHistory(t *testing.T) {
+	h := createTestHarness(t)
+	s := NewServer(h, ":8080", nil)
+	s.broadcast(Event{Type: "text", Content: "before"})
+
+	client, replay, missed := s.connectClient("test:1", "", false, nil)
+	defer s.removeClient(client, 0)
+	if len(replay) != 0 || missed != 0 {
+		t.Errorf("fresh connection should not replay, got %d events, %d missed", len(replay), missed)
+	}
+
+	s.broadcast(Event{Type: "text", Content: "after"})
+	select {
+	case msg := <-client.events:
+		if msg.id != 2 || !strings.Contains(string(msg.data), "after") {
+			t.Errorf("expected event 2 'after', got %d %s", msg.id, msg.data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+)
+
+// IdleConfig configures automatic session summarization when the server is idle.
+type IdleConfig struct {
+	// Timeout is how long the server must have no SSE clients and no prompts
+	// before the session is summarized. Zero disables idle detection.
+	Timeout time.Duration
+
+	// SummaryDir is where summaries are persisted as markdown files.
+	// Empty disables persistence.
+	SummaryDir string
+}
+
+// idleState tracks activity for idle detection.
+type idleState struct {
+	lastActivity time.Time
+	summarized   bool   // session summarized since the last prompt
+	pending      string // summary not yet announced to a returning client
+}
+
+// StartIdleMonitor starts a background goroutine that summarizes the session
+// once the server has been idle for cfg.Timeout. It stops when ctx is done.
+func (s *Server) StartIdleMonitor(ctx context.Context, cfg IdleConfig) {
+	if cfg.Timeout <= 0 {
+		return
+	}
+	interval := cfg.Timeout / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.checkIdle(ctx, cfg)
+			}
+		}
+	}()
+}
+
+// touchActivity records activity without announcing a pending summary.
+func (s *Server) touchActivity() {
+	s.idleMu.Lock()
+	defer s.idleMu.Unlock()
+	s.idle.lastActivity = time.Now()
+}
+
+// markActive records user activity. If the session was summarized while the
+// user was away, a session_summarized event is broadcast so clients know the
+// model will continue from the summary. A new prompt re-arms idle detection.
+func (s *Server) markActive(isPrompt bool) {
+	s.idleMu.Lock()
+	s.idle.lastActivity = time.Now()
+	summary := s.idle.pending
+	s.idle.pending = ""
+	if isPrompt {
+		s.idle.summarized = false
+	}
+	s.idleMu.Unlock()
+
+	if summary != "" {
+		s.broadcast(Event{Type: "session_summarized", Content: summary})
+	}
+}
+
+// checkIdle summarizes the session if the idle conditions are met.
+func (s *Server) checkIdle(ctx context.Context, cfg IdleConfig) {
+	s.mu.RLock()
+	clients := len(s.clients)
+	s.mu.RUnlock()
+
+	s.idleMu.Lock()
+	idleFor := time.Since(s.idle.lastActivity)
+	summarized := s.idle.summarized
+	s.idleMu.Unlock()
+
+	if clients > 0 || summarized || idleFor < cfg.Timeout || s.harness.IsRunning() {
+		return
+	}
+
+	summary, err := s.harness.Summarize(ctx)
+	if err != nil {
+		if errors.Is(err, harness.ErrEmptyConversation) {
+			s.idleMu.Lock()
+			s.idle.summarized = true
+			s.idleMu.Unlock()
+			return
+		}
+		if !errors.Is(err, harness.ErrPromptInProgress) {
+			s.logger.Warn("harness", "Idle summarization failed", log.F("error", err.Error()))
+		}
+		return
+	}
+
+	if cfg.SummaryDir != "" {
+		if path, err := persistSummary(cfg.SummaryDir, summary); err != nil {
+			s.logger.Warn("harness", "Failed to persist session summary", log.F("error", err.Error()))
+		} else {
+			s.logger.Info("harness", "Session summary persisted", log.F("path", path))
+		}
+	}
+
+	s.harness.ReleaseResources()
+
+	s.idleMu.Lock()
+	s.idle.summarized = true
+	s.idle.pending = summary
+	s.idleMu.Unlock()
+
+	s.logger.Info("harness", "Session summarized after idle",
+		log.F("idle_s", int(idleFor.Seconds())),
+	)
+}
+
+// persistSummary writes a summary to a timestamped markdown file in dir.
+func persistSummary(dir, summary string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	path := filepath.Join(dir, fmt.Sprintf("session-%s.md", now.Format("20060102T150405Z")))
+	content := fmt.Sprintf("# Session summary\n\nSummarized: %s\n\n%s\n", now.Format(time.RFC3339), summary)
+	return path, os.WriteFile(path, []byte(content), 0644)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func TestIdle_SummarizesAndAnnouncesOnReturn(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done."))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Session summary text."))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "do it"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	s := NewServer(h, ":0", nil)
+	dir := t.TempDir()
+	cfg := IdleConfig{Timeout: time.Nanosecond, SummaryDir: dir}
+
+	// A connected client keeps the session active
+	client := s.addClient("test:1")
+	s.checkIdle(context.Background(), cfg)
+	if len(mockStreamer.RecordedParams) != 1 {
+		t.Fatal("session must not be summarized while a client is connected")
+	}
+	s.removeClient(client, 0)
+
+	time.Sleep(time.Millisecond)
+	s.checkIdle(context.Background(), cfg)
+	if len(mockStreamer.RecordedParams) != 2 {
+		t.Fatalf("expected summary request, got %d API calls", len(mockStreamer.RecordedParams))
+	}
+
+	// Second idle check must not re-summarize
+	s.checkIdle(context.Background(), cfg)
+	if len(mockStreamer.RecordedParams) != 2 {
+		t.Error("session should only be summarized once per idle period")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "session-*.md"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 persisted summary, got %d", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), "Session summary text.") {
+		t.Errorf("summary file missing summary text: %s", data)
+	}
+
+	// The returning client is told the session was summarized
+	returning := s.addClient("test:2")
+	defer s.removeClient(returning, 0)
+	s.markActive(false)
+
+	select {
+	case msg := <-returning.events:
+		data := msg.data
+		var event Event
+		json.Unmarshal(data, &event)
+		if event.Type != "session_summarized" {
+			t.Errorf("expected session_summarized event, got %q", event.Type)
+		}
+		if event.Content != "Session summary text." {
+			t.Errorf("unexpected summary content: %q", event.Content)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for session_summarized event")
+	}
+}
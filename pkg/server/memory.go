@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+
+	"github.com/user/harness/pkg/tool"
+)
+
+// memoryResponse is the body of GET /memory.
+type memoryResponse struct {
+	Path     string        `json:"path"`
+	Memories []tool.Memory `json:"memories"`
+	Bytes    int           `json:"bytes"`
+	Quota    int           `json:"quota"`
+}
+
+// memoryStore returns the memory store of the workspace named by the
+// workspace_id query parameter, or of the main session's workspace.
+func (s *Server) memoryStore(r *http.Request) (*tool.MemoryStore, string, error) {
+	root := s.harness.Workspace()
+	if id := r.URL.Query().Get("workspace_id"); id != "" {
+		path, err := s.resolveWorkspace(id)
+		if err != nil {
+			return nil, "", err
+		}
+		root = path
+	}
+	if root == "" {
+		root = "."
+	}
+	return tool.OpenMemoryStore(root), root, nil
+}
+
+// HandleListMemories handles GET /memory requests with the memories the
+// memory tool has stored.
+func (s *Server) HandleListMemories(w http.ResponseWriter, r *http.Request) {
+	store, root, err := s.memoryStore(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	memories, err := store.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(memoryResponse{
+		Path:     filepath.Join(root, tool.MemoryFile),
+		Memories: memories,
+		Bytes:    tool.MemoriesSize(memories),
+		Quota:    tool.MemoryQuota,
+	})
+}
+
+// HandleDeleteMemory handles DELETE /memory/{name} requests.
+func (s *Server) HandleDeleteMemory(w http.ResponseWriter, r *http.Request) {
+	store, _, err := s.memoryStore(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err := store.Delete(r.PathValue("name")); err != nil {
+		writeErr(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,58 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestMemoryEndpoints(t *testing.T) {
+	root := t.TempDir()
+	if _, err := tool.OpenMemoryStore(root).Save("build", "make all"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	h.SetWorkspace(root)
+	ts := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/memory")
+	if err != nil {
+		t.Fatalf("GET /memory failed: %v", err)
+	}
+	var body struct {
+		Memories []tool.Memory `json:"memories"`
+		Bytes    int           `json:"bytes"`
+		Quota    int           `json:"quota"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if len(body.Memories) != 1 || body.Memories[0].Content != "make all" || body.Bytes != 13 || body.Quota != tool.MemoryQuota {
+		t.Errorf("unexpected memories: %+v", body)
+	}
+
+	for _, want := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/memory/build", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("DELETE failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("expected %d, got %d", want, resp.StatusCode)
+		}
+	}
+
+	resp, _ = http.Get(ts.URL + "/memory?workspace_id=nope")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown workspace, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+)
+
+// Transcript is the main session's history in a form suited to rendering,
+// as returned by GET /messages.
+type Transcript struct {
+	ID       string              `json:"id,omitempty"`
+	Messages []TranscriptMessage `json:"messages"`
+}
+
+// TranscriptMessage is one message of a Transcript.
+type TranscriptMessage struct {
+	Role string `json:"role"`
+	// Timestamp is when the message was added, in Unix seconds; omitted
+	// when unknown, as for messages of a loaded conversation.
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Content   []TranscriptBlock `json:"content"`
+}
+
+// TranscriptBlock is one content block of a message. Type and field names
+// match the corresponding SSE events, so clients can render history and
+// live events the same way:
+//
+//   - text: Content is the text
+//   - reasoning: Content is the model's thinking
+//   - tool_call: ID, Name, and Input of a tool invocation
+//   - tool_result: ID of the call, Result, and IsError
+//   - server_tool: ID, Name, and Input of a provider-executed tool
+//   - server_tool_result: ID of the call, Name (the block type), and Result
+//
+// Other blocks, such as images, have only their API block type.
+type TranscriptBlock struct {
+	Type    string          `json:"type"`
+	Content string          `json:"content,omitempty"`
+	ID      string          `json:"id,omitempty"`
+	Name    string          `json:"name,omitempty"`
+	Input   json.RawMessage `json:"input,omitempty"`
+	Result  string          `json:"result,omitempty"`
+	IsError bool            `json:"isError,omitempty"`
+}
+
+// HandleGetMessages handles GET /messages requests.
+func (s *Server) HandleGetMessages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Transcript{
+		ID:       s.harness.ConversationID(),
+		Messages: transcriptMessages(s.harness.TimedMessages()),
+	})
+}
+
+// transcriptMessages converts conversation history to transcript messages.
+func transcriptMessages(messages []harness.TimedMessage) []TranscriptMessage {
+	result := make([]TranscriptMessage, len(messages))
+	for i, msg := range messages {
+		tm := TranscriptMessage{
+			Role:    string(msg.Message.Role),
+			Content: make([]TranscriptBlock, 0, len(msg.Message.Content)),
+		}
+		if !msg.Time.IsZero() {
+			tm.Timestamp = msg.Time.Unix()
+		}
+		for _, block := range msg.Message.Content {
+			tm.Content = append(tm.Content, transcriptBlock(block))
+		}
+		result[i] = tm
+	}
+	return result
+}
+
+// transcriptBlock converts one API content block.
+func transcriptBlock(block anthropic.ContentBlockParamUnion) TranscriptBlock {
+	switch {
+	case block.OfText != nil:
+		return TranscriptBlock{Type: "text", Content: block.OfText.Text}
+	case block.OfThinking != nil:
+		return TranscriptBlock{Type: "reasoning", Content: block.OfThinking.Thinking}
+	case block.OfToolUse != nil:
+		return TranscriptBlock{
+			Type:  "tool_call",
+			ID:    block.OfToolUse.ID,
+			Name:  block.OfToolUse.Name,
+			Input: rawInput(block.OfToolUse.Input),
+		}
+	case block.OfToolResult != nil:
+		var text []string
+		for _, c := range block.OfToolResult.Content {
+			if c.OfText != nil {
+				text = append(text, c.OfText.Text)
+			}
+		}
+		return TranscriptBlock{
+			Type:    "tool_result",
+			ID:      block.OfToolResult.ToolUseID,
+			Result:  strings.Join(text, "\n"),
+			IsError: block.OfToolResult.IsError.Value,
+		}
+	case block.OfServerToolUse != nil:
+		return TranscriptBlock{
+			Type:  "server_tool",
+			ID:    block.OfServerToolUse.ID,
+			Name:  string(block.OfServerToolUse.Name),
+			Input: rawInput(block.OfServerToolUse.Input),
+		}
+	case block.OfWebSearchToolResult != nil:
+		result, _ := json.Marshal(block.OfWebSearchToolResult.Content)
+		return TranscriptBlock{
+			Type:   "server_tool_result",
+			ID:     block.OfWebSearchToolResult.ToolUseID,
+			Name:   string(block.OfWebSearchToolResult.Type),
+			Result: string(result),
+		}
+	}
+	if t := block.GetType(); t != nil {
+		return TranscriptBlock{Type: *t}
+	}
+	return TranscriptBlock{Type: "unknown"}
+}
+
+// rawInput encodes a tool input, which may already be raw JSON.
+func rawInput(input any) json.RawMessage {
+	if raw, ok := input.(json.RawMessage); ok {
+		return raw
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil
+	}
+	return data
+}
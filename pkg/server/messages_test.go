@@ -0,0 +1,123 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestMessages_Transcript(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddThinking("need the file").
+		AddText("Reading it.").
+		AddToolUse("tool_1", "mock_tool", map[string]string{"value": "a.txt"}).
+		BuildWithToolUse())
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("All done."))
+
+	mockTool := &MockTool{
+		name: "mock_tool",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			return "", errors.New("no such file")
+		},
+	}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{mockTool}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	start := time.Now().Unix()
+	if err := h.Prompt(context.Background(), "read a.txt"); err != nil {
+		t.Fatalf("prompt failed: %v", err)
+	}
+
+	ts := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/messages")
+	if err != nil {
+		t.Fatalf("GET /messages failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %q", ct)
+	}
+	var transcript server.Transcript
+	if err := json.NewDecoder(resp.Body).Decode(&transcript); err != nil {
+		t.Fatalf("failed to decode transcript: %v", err)
+	}
+
+	msgs := transcript.Messages
+	if len(msgs) != 4 {
+		t.Fatalf("expected 4 messages, got %+v", msgs)
+	}
+	for i, want := range []string{"user", "assistant", "user", "assistant"} {
+		if msgs[i].Role != want {
+			t.Errorf("message %d: expected role %s, got %s", i, want, msgs[i].Role)
+		}
+		if msgs[i].Timestamp < start {
+			t.Errorf("message %d: expected timestamp, got %d", i, msgs[i].Timestamp)
+		}
+	}
+
+	if got := msgs[0].Content; len(got) != 1 || got[0].Type != "text" || got[0].Content != "read a.txt" {
+		t.Errorf("unexpected prompt content %+v", got)
+	}
+	call := msgs[1].Content
+	if len(call) != 3 || call[0].Type != "reasoning" || call[0].Content != "need the file" || call[1].Content != "Reading it." {
+		t.Fatalf("unexpected assistant content %+v", call)
+	}
+	if call[2].Type != "tool_call" || call[2].ID != "tool_1" || call[2].Name != "mock_tool" || string(call[2].Input) != `{"value":"a.txt"}` {
+		t.Errorf("unexpected tool call %+v", call[2])
+	}
+	result := msgs[2].Content
+	if len(result) != 1 || result[0].Type != "tool_result" || result[0].ID != "tool_1" || !result[0].IsError || !strings.Contains(result[0].Result, "no such file") {
+		t.Errorf("unexpected tool result %+v", result)
+	}
+}
+
+func TestMessages_LoadedConversationHasNoTimestamps(t *testing.T) {
+	store, err := harness.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Seeded"))
+	seed, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	seed.SetStore(store, "saved")
+	seed.Prompt(context.Background(), "seed")
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	h.SetStore(store, "fresh")
+	if err := h.LoadConversation("saved"); err != nil {
+		t.Fatalf("LoadConversation failed: %v", err)
+	}
+	ts := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/messages")
+	if err != nil {
+		t.Fatalf("GET /messages failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var raw struct {
+		ID       string           `json:"id"`
+		Messages []map[string]any `json:"messages"`
+	}
+	json.NewDecoder(resp.Body).Decode(&raw)
+	if raw.ID != "saved" || len(raw.Messages) != 2 {
+		t.Fatalf("unexpected transcript %+v", raw)
+	}
+	if _, ok := raw.Messages[0]["timestamp"]; ok {
+		t.Errorf("loaded messages should omit timestamp, got %v", raw.Messages[0])
+	}
+}
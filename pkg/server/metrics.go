@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/tool"
+)
+
+// statusResponse is the body of GET /status.
+type statusResponse struct {
+	Running bool                 `json:"running"`
+	Queued  int                  `json:"queued"`
+	Latency harness.LatencyStats `json:"latency"`
+	Plan    []tool.TodoItem      `json:"plan"`
+}
+
+// HandleStatus handles GET /status requests with the harness state, the
+// number of queued prompts, rolling latency statistics, and the task plan as
+// JSON.
+func (s *Server) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statusResponse{
+		Running: s.harness.IsRunning(),
+		Queued:  s.queueLength(),
+		Latency: s.harness.LatencyStats(),
+		Plan:    s.harness.Plan(),
+	})
+}
+
+// usageResponse is the body of GET /usage.
+type usageResponse struct {
+	Model   string                `json:"model"`
+	Session harness.Usage         `json:"session"`  // over the server's lifetime
+	LastRun harness.Usage         `json:"last_run"` // the running or most recent prompt
+	Context harness.ContextBudget `json:"context"`  // context window use after the latest turn
+}
+
+// HandleUsage handles GET /usage requests with the main session's token
+// usage and estimated cost.
+func (s *Server) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usageResponse{
+		Model:   s.harness.Model(),
+		Session: s.harness.Usage(),
+		LastRun: s.harness.LastRun().Usage,
+		Context: s.harness.ContextBudget(),
+	})
+}
+
+// HandleMetrics handles GET /metrics requests with rolling latency
+// statistics in the Prometheus text exposition format.
+func (s *Server) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	stats := s.harness.LatencyStats()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintln(w, "# HELP harness_latency_seconds Rolling latency percentiles by operation kind.")
+	fmt.Fprintln(w, "# TYPE harness_latency_seconds gauge")
+	for _, m := range []struct {
+		kind    string
+		summary harness.LatencySummary
+	}{
+		{harness.LatencyAPI, stats.API},
+		{harness.LatencyTool, stats.Tool},
+	} {
+		fmt.Fprintf(w, "harness_latency_seconds{kind=%q,quantile=\"0.5\"} %g\n", m.kind, float64(m.summary.P50Ms)/1000)
+		fmt.Fprintf(w, "harness_latency_seconds{kind=%q,quantile=\"0.95\"} %g\n", m.kind, float64(m.summary.P95Ms)/1000)
+	}
+	fmt.Fprintln(w, "# HELP harness_latency_samples Samples in the rolling window by operation kind.")
+	fmt.Fprintln(w, "# TYPE harness_latency_samples gauge")
+	fmt.Fprintf(w, "harness_latency_samples{kind=%q} %d\n", harness.LatencyAPI, stats.API.Count)
+	fmt.Fprintf(w, "harness_latency_samples{kind=%q} %d\n", harness.LatencyTool, stats.Tool.Count)
+	fmt.Fprintln(w, "# HELP harness_slo_violations SLOs currently exceeded.")
+	fmt.Fprintln(w, "# TYPE harness_slo_violations gauge")
+	fmt.Fprintf(w, "harness_slo_violations %d\n", len(stats.Violations))
+	fmt.Fprintln(w, "# HELP harness_latency_window_seconds Length of the rolling window.")
+	fmt.Fprintln(w, "# TYPE harness_latency_window_seconds gauge")
+	fmt.Fprintf(w, "harness_latency_window_seconds %d\n", stats.WindowSeconds)
+
+	dropped, evicted := s.sseStats()
+	fmt.Fprintln(w, "# HELP harness_sse_dropped_events_total Events dropped for SSE clients that fell behind.")
+	fmt.Fprintln(w, "# TYPE harness_sse_dropped_events_total counter")
+	fmt.Fprintf(w, "harness_sse_dropped_events_total %d\n", dropped)
+	fmt.Fprintln(w, "# HELP harness_sse_stalled_disconnects_total SSE clients disconnected for staying stalled.")
+	fmt.Fprintln(w, "# TYPE harness_sse_stalled_disconnects_total counter")
+	fmt.Fprintf(w, "harness_sse_stalled_disconnects_total %d\n", evicted)
+	fmt.Fprintln(w, "# HELP harness_rate_limited_total Prompt submissions rejected by a rate limit.")
+	fmt.Fprintln(w, "# TYPE harness_rate_limited_total counter")
+	fmt.Fprintf(w, "harness_rate_limited_total %d\n", s.limiter.rateLimited())
+
+	cache := s.harness.ReadCacheStats()
+	fmt.Fprintln(w, "# HELP harness_read_cache_lookups_total Read cache lookups by result.")
+	fmt.Fprintln(w, "# TYPE harness_read_cache_lookups_total counter")
+	fmt.Fprintf(w, "harness_read_cache_lookups_total{result=\"hit\"} %d\n", cache.Hits)
+	fmt.Fprintf(w, "harness_read_cache_lookups_total{result=\"miss\"} %d\n", cache.Misses)
+	fmt.Fprintln(w, "# HELP harness_read_cache_evictions_total Read cache entries evicted to stay within the memory cap.")
+	fmt.Fprintln(w, "# TYPE harness_read_cache_evictions_total counter")
+	fmt.Fprintf(w, "harness_read_cache_evictions_total %d\n", cache.Evictions)
+	fmt.Fprintln(w, "# HELP harness_read_cache_bytes File content held by the read caches of running prompts.")
+	fmt.Fprintln(w, "# TYPE harness_read_cache_bytes gauge")
+	fmt.Fprintf(w, "harness_read_cache_bytes %d\n", cache.Bytes)
+}
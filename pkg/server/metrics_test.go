@@ -0,0 +1,121 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func TestStatusAndMetrics_ReportLatency(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("hi").Build())
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	if err := h.Prompt(context.Background(), "hello"); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/status")
+	if err != nil {
+		t.Fatalf("GET /status failed: %v", err)
+	}
+	var status struct {
+		Running bool                 `json:"running"`
+		Latency harness.LatencyStats `json:"latency"`
+	}
+	json.NewDecoder(resp.Body).Decode(&status)
+	resp.Body.Close()
+	if status.Running {
+		t.Error("expected running=false")
+	}
+	if status.Latency.API.Count != 1 {
+		t.Errorf("expected 1 API sample, got %d", status.Latency.API.Count)
+	}
+
+	resp, err = http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{
+		`harness_latency_seconds{kind="api",quantile="0.95"}`,
+		`harness_latency_samples{kind="api"} 1`,
+		`harness_slo_violations 0`,
+		`harness_read_cache_lookups_total{result="hit"} 0`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestUsage_EventAndEndpoint(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("hi").WithUsage(1000, 100).Build())
+
+	config := harness.Config{
+		Model:  "claude-sonnet-4-20250514",
+		Prices: map[string]harness.ModelPrice{"claude-sonnet-4": {Input: 1, Output: 10}},
+	}
+	h, err := harness.NewHarnessWithStreamer(config, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events := subscribeUntilDone(t, ctx, ts.URL)
+	time.Sleep(50 * time.Millisecond)
+	if err := h.Prompt(context.Background(), "hello"); err != nil {
+		t.Fatalf("Prompt failed: %v", err)
+	}
+	var usage *server.Event
+	for e := range events {
+		if e.Type == "usage" {
+			usage = &e
+			break
+		}
+	}
+	if usage == nil || usage.Usage == nil || usage.RunUsage == nil || usage.SessionUsage == nil {
+		t.Fatalf("expected usage event with turn, run, and session usage, got %+v", usage)
+	}
+	if usage.Usage.InputTokens != 1000 || usage.Usage.CostUSD != 0.002 || usage.SessionUsage.OutputTokens != 100 {
+		t.Errorf("unexpected usage event %+v %+v", usage.Usage, usage.SessionUsage)
+	}
+
+	resp, err := http.Get(ts.URL + "/usage")
+	if err != nil {
+		t.Fatalf("GET /usage failed: %v", err)
+	}
+	var body struct {
+		Model   string        `json:"model"`
+		Session harness.Usage `json:"session"`
+		LastRun harness.Usage `json:"last_run"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if body.Model != config.Model || body.Session.InputTokens != 1000 || body.LastRun.CostUSD != 0.002 {
+		t.Errorf("unexpected /usage response %+v", body)
+	}
+}
@@ -0,0 +1,419 @@
+package server
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// OpenAPIPath is where the server serves its OpenAPI document.
+const OpenAPIPath = "/openapi.json"
+
+// apiOperation describes one endpoint of the HTTP API. Request and response
+// bodies are given as Go values whose types are reflected into JSON Schemas,
+// so the document follows the types the handlers decode and encode.
+type apiOperation struct {
+	method  string
+	path    string
+	id      string
+	summary string
+	params  []apiParam
+
+	request     any    // request body, or nil for none
+	requestType string // of the request body; application/json if empty
+	optionalReq bool   // the request body may be omitted
+
+	status      int    // success status; 200 if zero
+	response    any    // success body, or nil for an empty response
+	contentType string // of the success body; application/json if empty
+	textType    string // another content type of the success body, as text
+
+	errors []int // error statuses, answered with an errorResponse
+	busy   bool  // may be rejected as busy by writeBusy
+}
+
+// apiParam is a path or query parameter of an operation.
+type apiParam struct {
+	name        string
+	in          string // "path" or "query"
+	description string
+}
+
+// apiOperations lists the operations described by the OpenAPI document.
+var apiOperations = []apiOperation{
+	{method: "POST", path: "/prompt", id: "submitPrompt", summary: "Start a prompt on the main session, or queue it",
+		request: promptRequest{}, response: promptResponse{}, errors: []int{400, 404, 413, 429, 503}, busy: true},
+	{method: "POST", path: "/prompt/stream", id: "streamPrompt", summary: "Start a prompt and stream its events as NDJSON",
+		request: promptRequest{}, response: Event{}, contentType: "application/x-ndjson", errors: []int{400, 404, 413, 429, 503}, busy: true},
+	{method: "POST", path: "/continue", id: "continueRun", summary: "Resume a run that stopped at its turn limit",
+		response: promptResponse{}, errors: []int{409, 429, 503}, busy: true},
+	{method: "POST", path: "/cancel", id: "cancelPrompt", summary: "Cancel a running or queued run, or whatever is running",
+		request: cancelRequest{}, optionalReq: true, errors: []int{400, 404}},
+	{method: "POST", path: "/files", id: "uploadFiles", summary: "Upload files for prompts to attach",
+		request: uploadForm{}, requestType: "multipart/form-data",
+		status: http.StatusCreated, response: uploadResponse{}, errors: []int{400, 404, 413}},
+	{method: "POST", path: "/steer", id: "steerPrompt", summary: "Add a user message to the running prompt",
+		request: steerRequest{}, status: http.StatusAccepted, errors: []int{400, 409, 413}},
+	{method: "POST", path: "/approve", id: "approveToolCall", summary: "Approve or deny a pending tool call",
+		request: approvalRequest{}, errors: []int{400, 404}},
+	{method: "GET", path: "/approvals", id: "listApprovals", summary: "List pending tool call approvals",
+		response: []Approval{}},
+	{method: "POST", path: "/answer", id: "answerQuestion", summary: "Answer a pending ask_user question",
+		request: answerRequest{}, errors: []int{400, 404}},
+	{method: "GET", path: "/questions", id: "listQuestions", summary: "List ask_user questions awaiting an answer",
+		response: []PendingQuestion{}},
+	{method: "GET", path: "/events", id: "subscribeEvents", summary: "Follow events over Server-Sent Events",
+		params: []apiParam{
+			{"session", "query", "Only events of this session: main, or <experiment_id>/<variant_id>"},
+			{"last_event_id", "query", "Replay the retained events after this ID (or send Last-Event-ID)"},
+			{"dedup", "query", "1 to replace large bodies with content hashes"},
+		},
+		response: Event{}, contentType: "text/event-stream"},
+	{method: "GET", path: "/messages", id: "getMessages", summary: "Get the main session's transcript",
+		response: Transcript{}},
+	{method: "GET", path: "/export", id: "exportConversation", summary: "Export the main session's conversation as Markdown or JSON",
+		params:   []apiParam{{"format", "query", "markdown (the default) or json"}},
+		response: Export{}, textType: "text/markdown", errors: []int{400}},
+	{method: "GET", path: "/conversation", id: "getConversation", summary: "Get the main session's history in API format",
+		response: Conversation{}},
+	{method: "POST", path: "/conversation/load", id: "loadConversation", summary: "Replace the main session's history with a stored conversation",
+		request: loadConversationRequest{}, response: Conversation{}, errors: []int{400, 404, 409}},
+	{method: "POST", path: "/reset", id: "resetConversation", summary: "Clear the main session's history",
+		response: Conversation{}, errors: []int{409}},
+	{method: "GET", path: "/checkpoints", id: "listCheckpoints", summary: "List the turns that can be rolled back",
+		response: checkpointsResponse{}},
+	{method: "POST", path: "/rollback", id: "rollback", summary: "Undo the main session's last turns and their file changes",
+		request: rollbackRequest{}, optionalReq: true, response: rollbackResponse{}, errors: []int{400, 409}},
+	{method: "GET", path: "/runs/{id}", id: "getRun", summary: "Get a run's status, transcript, and annotations",
+		params: []apiParam{{"id", "path", "Run ID"}}, response: Run{}, errors: []int{404}},
+	{method: "POST", path: "/runs/{id}/annotations", id: "annotateRun", summary: "Rate a message or tool call of a run",
+		params: []apiParam{{"id", "path", "Run ID"}}, request: annotationRequest{},
+		status: http.StatusCreated, response: Annotation{}, errors: []int{400, 404, 409}},
+	{method: "GET", path: "/annotations/export", id: "exportAnnotations", summary: "Export every annotation as JSON Lines",
+		response: annotationExport{}, contentType: "application/x-ndjson"},
+	{method: "POST", path: "/batch", id: "submitBatch", summary: "Run prompts in parallel sessions",
+		request: batchRequest{}, status: http.StatusAccepted, response: batchResponse{}, errors: []int{400, 404, 429}},
+	{method: "GET", path: "/batch/{id}", id: "getBatch", summary: "Get a batch's status",
+		params: []apiParam{{"id", "path", "Batch ID"}}, response: BatchStatus{}, errors: []int{404}},
+	{method: "POST", path: "/batch/{id}/cancel", id: "cancelBatch", summary: "Cancel a batch's remaining items",
+		params: []apiParam{{"id", "path", "Batch ID"}}, errors: []int{404}},
+	{method: "POST", path: "/experiments", id: "startExperiment", summary: "Run a prompt under several variants",
+		request: experimentRequest{}, status: http.StatusAccepted, response: experimentResponse{}, errors: []int{400, 429}},
+	{method: "GET", path: "/experiments/{id}", id: "getExperiment", summary: "Get an experiment's comparison",
+		params: []apiParam{{"id", "path", "Experiment ID"}}, response: ExperimentStatus{}, errors: []int{404}},
+	{method: "POST", path: "/experiments/{id}/cancel", id: "cancelExperiment", summary: "Cancel an experiment's running variants",
+		params: []apiParam{{"id", "path", "Experiment ID"}}, errors: []int{404}},
+	{method: "POST", path: "/workspaces", id: "createWorkspace", summary: "Create a workspace, optionally cloning a git repository",
+		request: workspaceRequest{}, status: http.StatusCreated, response: Workspace{}, errors: []int{400, 404, 409, 502}},
+	{method: "GET", path: "/workspaces", id: "listWorkspaces", summary: "List workspaces",
+		response: []Workspace{}, errors: []int{404}},
+	{method: "DELETE", path: "/workspaces/{id}", id: "deleteWorkspace", summary: "Delete a workspace",
+		params: []apiParam{{"id", "path", "Workspace ID"}}, status: http.StatusNoContent, errors: []int{404, 409}},
+	{method: "GET", path: "/tools", id: "listTools", summary: "List the registered tools",
+		response: []ToolInfo{}},
+	{method: "GET", path: "/profiles", id: "listProfiles", summary: "List the configured agent profiles",
+		response: profilesResponse{}},
+	{method: "GET", path: "/memory", id: "listMemories", summary: "List a workspace's memories",
+		params:   []apiParam{{"workspace_id", "query", "Workspace to read; the main session's by default"}},
+		response: memoryResponse{}, errors: []int{404}},
+	{method: "DELETE", path: "/memory/{name}", id: "deleteMemory", summary: "Delete a memory",
+		params: []apiParam{{"name", "path", "Memory name"}, {"workspace_id", "query", "Workspace to modify; the main session's by default"}},
+		status: http.StatusNoContent, errors: []int{404}},
+	{method: "GET", path: "/content/{hash}", id: "getContent", summary: "Get a deduplicated body by its hash",
+		params:   []apiParam{{"hash", "path", "SHA-256 hash from an event's refs"}},
+		response: "", contentType: "application/octet-stream", errors: []int{404}},
+	{method: "GET", path: "/status", id: "getStatus", summary: "Get the harness state, queue length, latency, and plan",
+		response: statusResponse{}},
+	{method: "GET", path: "/usage", id: "getUsage", summary: "Get the main session's token usage and cost",
+		response: usageResponse{}},
+	{method: "GET", path: "/metrics", id: "getMetrics", summary: "Get metrics in the Prometheus text format",
+		response: "", contentType: "text/plain"},
+	{method: "GET", path: "/admin/log", id: "getLogSettings", summary: "Get the server logger's level and categories",
+		response: LogSettings{}, errors: []int{404, 501}},
+	{method: "PUT", path: "/admin/log", id: "setLogSettings", summary: "Change the server logger's level and categories",
+		request: logSettingsRequest{}, response: LogSettings{}, errors: []int{400, 404, 501}},
+}
+
+// HandleOpenAPI handles GET /openapi.json requests with the OpenAPI document
+// describing the HTTP API.
+func (s *Server) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	s.authMu.RLock()
+	auth := s.auth
+	s.authMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(openAPIDocument(auth))
+}
+
+// openAPIDocument builds the OpenAPI 3.1 document for apiOperations. The
+// security requirements follow auth.
+func openAPIDocument(auth AuthConfig) map[string]any {
+	b := newSchemaBuilder()
+	paths := map[string]any{}
+	for _, op := range apiOperations {
+		item, ok := paths[op.path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = b.operation(op)
+	}
+
+	components := map[string]any{"schemas": b.schemas}
+	doc := map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Mini-Code harness API",
+			"version": "1.0.0",
+		},
+		"paths":      paths,
+		"components": components,
+	}
+
+	// Declare only the configured credentials
+	schemes := map[string]any{}
+	var security []map[string][]string
+	if auth.BearerToken != "" {
+		schemes["bearerAuth"] = map[string]any{"type": "http", "scheme": "bearer"}
+		security = append(security, map[string][]string{"bearerAuth": {}})
+	}
+	if auth.APIKey != "" {
+		schemes["apiKeyAuth"] = map[string]any{"type": "apiKey", "in": "header", "name": APIKeyHeader}
+		security = append(security, map[string][]string{"apiKeyAuth": {}})
+	}
+	if len(schemes) > 0 {
+		components["securitySchemes"] = schemes
+		doc["security"] = security
+	}
+	return doc
+}
+
+// operation returns the OpenAPI operation object for op.
+func (b *schemaBuilder) operation(op apiOperation) map[string]any {
+	out := map[string]any{
+		"operationId": op.id,
+		"summary":     op.summary,
+	}
+
+	if len(op.params) > 0 {
+		params := make([]map[string]any, 0, len(op.params))
+		for _, p := range op.params {
+			params = append(params, map[string]any{
+				"name":        p.name,
+				"in":          p.in,
+				"description": p.description,
+				"required":    p.in == "path",
+				"schema":      map[string]any{"type": "string"},
+			})
+		}
+		out["parameters"] = params
+	}
+
+	if op.request != nil {
+		requestType := op.requestType
+		if requestType == "" {
+			requestType = "application/json"
+		}
+		out["requestBody"] = map[string]any{
+			"required": !op.optionalReq,
+			"content": map[string]any{
+				requestType: map[string]any{"schema": b.schema(reflect.TypeOf(op.request))},
+			},
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	if op.response != nil {
+		contentType := op.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		content := map[string]any{
+			contentType: map[string]any{"schema": b.schema(reflect.TypeOf(op.response))},
+		}
+		if op.textType != "" {
+			content[op.textType] = map[string]any{"schema": map[string]any{"type": "string"}}
+		}
+		success["content"] = content
+	}
+	responses := map[string]any{strconv.Itoa(status): success}
+
+	errorContent := map[string]any{
+		"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(errorResponse{}))},
+	}
+	for _, code := range op.errors {
+		resp := map[string]any{
+			"description": http.StatusText(code),
+			"content":     errorContent,
+		}
+		if code == http.StatusTooManyRequests {
+			resp["headers"] = map[string]any{
+				"Retry-After": map[string]any{
+					"description": "Seconds until the rate limit allows another prompt",
+					"schema":      map[string]any{"type": "integer"},
+				},
+			}
+		}
+		responses[strconv.Itoa(code)] = resp
+	}
+	if op.busy {
+		responses[strconv.Itoa(http.StatusConflict)] = map[string]any{
+			"description": "Another run is active and the queue is full or disabled (code busy)",
+			"content":     errorContent,
+		}
+	}
+	out["responses"] = responses
+	return out
+}
+
+// Types with a fixed schema rather than one reflected from their fields.
+var (
+	rawMessageType   = reflect.TypeOf(json.RawMessage{})
+	timeType         = reflect.TypeOf(time.Time{})
+	messageParamType = reflect.TypeOf(anthropic.MessageParam{})
+	fileHeaderType   = reflect.TypeOf(multipart.FileHeader{})
+)
+
+// schemaBuilder reflects Go types into JSON Schemas. Named struct types are
+// added once to schemas and referenced by name.
+type schemaBuilder struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		schemas: map[string]any{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+// schema returns the JSON Schema of values of type t as encoding/json
+// marshals them.
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	switch t {
+	case rawMessageType:
+		return map[string]any{} // any JSON value
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case fileHeaderType:
+		return map[string]any{"type": "string", "format": "binary"}
+	case messageParamType:
+		return map[string]any{
+			"type":        "object",
+			"description": "A message in the Anthropic Messages API format",
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + b.define(t)}
+	}
+	return map[string]any{}
+}
+
+// define adds the schema of the named struct type t to b.schemas, unless it
+// is already there, and returns its name.
+func (b *schemaBuilder) define(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := exportedName(t.Name())
+	if _, taken := b.schemas[name]; taken {
+		// The same name in another package
+		name = exportedName(path.Base(t.PkgPath())) + name
+	}
+	// Register the name first so recursive types refer to it
+	b.names[t] = name
+	b.schemas[name] = nil
+	b.schemas[name] = b.object(t)
+	return name
+}
+
+// object returns the schema of struct type t. Fields without omitempty are
+// always present, so they are required.
+func (b *schemaBuilder) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	b.fields(t, properties, &required)
+	out := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// fields adds the JSON fields of struct type t to properties, flattening
+// embedded structs as encoding/json does.
+func (b *schemaBuilder) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			b.fields(ft, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = b.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// exportedName upper-cases the first letter of name, so unexported types
+// such as promptRequest get conventional schema names.
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,122 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func TestOpenAPI(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", nil)
+	s.SetAuth(server.AuthConfig{BearerToken: "tok"})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// The document is served without credentials
+	resp, err := http.Get(ts.URL + server.OpenAPIPath)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	type schema struct {
+		Ref        string            `json:"$ref"`
+		Properties map[string]schema `json:"properties"`
+		Required   []string          `json:"required"`
+	}
+	type media struct {
+		Schema schema `json:"schema"`
+	}
+	type operation struct {
+		OperationID string `json:"operationId"`
+		RequestBody *struct {
+			Content map[string]media `json:"content"`
+		} `json:"requestBody"`
+		Responses map[string]struct {
+			Content map[string]media `json:"content"`
+		} `json:"responses"`
+	}
+	var doc struct {
+		OpenAPI    string                          `json:"openapi"`
+		Paths      map[string]map[string]operation `json:"paths"`
+		Components struct {
+			Schemas         map[string]schema `json:"schemas"`
+			SecuritySchemes map[string]any    `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("expected OpenAPI 3, got %q", doc.OpenAPI)
+	}
+
+	for _, p := range []struct{ path, method string }{
+		{"/prompt", "post"},
+		{"/prompt/stream", "post"},
+		{"/cancel", "post"},
+		{"/events", "get"},
+		{"/messages", "get"},
+		{"/runs/{id}", "get"},
+		{"/workspaces/{id}", "delete"},
+	} {
+		if _, ok := doc.Paths[p.path][p.method]; !ok {
+			t.Errorf("expected %s %s in the document", p.method, p.path)
+		}
+	}
+
+	// Request bodies and events reference the reflected schemas
+	prompt := doc.Paths["/prompt"]["post"]
+	if ref := prompt.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/PromptRequest" {
+		t.Errorf("unexpected prompt request schema %q", ref)
+	}
+	if ref := prompt.Responses["409"].Content["application/json"].Schema.Ref; ref != "#/components/schemas/ErrorResponse" {
+		t.Errorf("unexpected busy response schema %q", ref)
+	}
+	if ref := doc.Paths["/events"]["get"].Responses["200"].Content["text/event-stream"].Schema.Ref; ref != "#/components/schemas/Event" {
+		t.Errorf("unexpected event schema %q", ref)
+	}
+
+	// Embedded options are flattened into the prompt request
+	req := doc.Components.Schemas["PromptRequest"]
+	for _, name := range []string{"content", "workspace_id", "model", "max_turns", "tools"} {
+		if _, ok := req.Properties[name]; !ok {
+			t.Errorf("expected %q in PromptRequest", name)
+		}
+	}
+	if !slices.Equal(req.Required, []string{"content"}) {
+		t.Errorf("expected only content to be required, got %v", req.Required)
+	}
+
+	// Every field of Event is described
+	event := doc.Components.Schemas["Event"]
+	typ := reflect.TypeOf(server.Event{})
+	for i := range typ.NumField() {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if _, ok := event.Properties[name]; !ok {
+			t.Errorf("expected %q in the Event schema", name)
+		}
+	}
+	if ref := event.Properties["usage"].Ref; ref != "#/components/schemas/Usage" {
+		t.Errorf("unexpected usage schema %q", ref)
+	}
+	if _, ok := doc.Components.Schemas["Usage"]; !ok {
+		t.Error("expected a Usage schema")
+	}
+
+	if _, ok := doc.Components.SecuritySchemes["bearerAuth"]; !ok {
+		t.Errorf("expected the bearer scheme, got %v", doc.Components.SecuritySchemes)
+	}
+}
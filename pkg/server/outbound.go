@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"net/netip"
+	"sync"
+)
+
+// sharedAddressSpace is the carrier-grade NAT range, which some clouds use
+// for their metadata services.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// addressGuard decides which addresses the server may connect to on a
+// client's behalf: public addresses, and private, loopback, or link-local
+// ones only when allowlisted.
+type addressGuard struct {
+	mu    sync.RWMutex
+	allow []netip.Prefix
+}
+
+// setAllowlist replaces the allowlist with the given IP addresses or CIDR
+// ranges; what names the setting in errors.
+func (g *addressGuard) setAllowlist(what string, networks []string) error {
+	prefixes := make([]netip.Prefix, 0, len(networks))
+	for _, n := range networks {
+		prefix, err := netip.ParsePrefix(n)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(n)
+			if addrErr != nil {
+				return fmt.Errorf("invalid %s network %q: use an IP address or CIDR range", what, n)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.allow = prefixes
+	return nil
+}
+
+// allowed reports whether the server may connect to addr.
+func (g *addressGuard) allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr) {
+		return true
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, prefix := range g.allow {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// Prompt priorities.
+const (
+	priorityLow    = "low"
+	priorityNormal = "normal"
+	priorityHigh   = "high"
+)
+
+// parsePriority validates a prompt priority; empty means normal.
+func parsePriority(p string) (string, error) {
+	switch p {
+	case "":
+		return priorityNormal, nil
+	case priorityLow, priorityNormal, priorityHigh:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid priority %q: must be low, normal, or high", p)
+}
+
+// preempts reports whether a prompt with priority p may preempt a running
+// run with priority running. Only high-priority prompts preempt, and only
+// low-priority runs.
+func preempts(p, running string) bool {
+	return p == priorityHigh && running == priorityLow
+}
+
+// activeRun is the run currently executing on the main session, or one
+// queued to execute there.
+type activeRun struct {
+	rec      *runRecord
+	priority string
+	done     chan struct{}
+	ready    chan struct{} // closed when a queued run becomes active
+	dropped  bool          // set before ready is closed if Shutdown dropped the run
+
+	// The run executes with ctx; cancel stops it, or skips it if it has not
+	// started
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newActiveRun returns the activeRun of a prompt's run record. The run's
+// context keeps the values of ctx, the submitting request's, such as its
+// trace span, but not its cancellation: the run outlives the request.
+func newActiveRun(ctx context.Context, rec *runRecord, priority string) *activeRun {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	return &activeRun{
+		rec:      rec,
+		priority: priority,
+		done:     make(chan struct{}),
+		ready:    make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// claimActive makes run the active run on the main session. If a run is
+// already active and run preempts it, the active run is asked to stop after
+// its current tool calls, a preempted event is broadcast, and the returned
+// channel is closed once it has stopped. Otherwise run is queued, if the
+// queue has room: position is its place in the queue and the returned
+// channel is closed when its turn comes. claimed is false when run can be
+// neither started nor queued.
+func (s *Server) claimActive(run *activeRun) (wait <-chan struct{}, position int, claimed bool) {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	if s.isClosing() {
+		return nil, 0, false
+	}
+
+	prev := s.active
+	if prev != nil {
+		if !preempts(run.priority, prev.priority) {
+			if position = s.enqueue(run); position == 0 {
+				return nil, 0, false
+			}
+			return run.ready, position, true
+		}
+		prevID := prev.rec.run.ID
+		s.logger.Info("http", "Run preempted",
+			log.F("run_id", prevID),
+			log.F("by_run_id", run.rec.run.ID),
+		)
+		s.broadcast(Event{
+			Type:    "preempted",
+			RunID:   prevID,
+			Message: fmt.Sprintf("preempted by %s-priority run %s; stopping after the current tool calls", run.priority, run.rec.run.ID),
+		})
+		s.harness.Preempt()
+		wait = prev.done
+	}
+	s.active = run
+	return wait, 0, true
+}
+
+// releaseActive clears run as the active run, starting the next queued run,
+// and wakes any run waiting to preempt it.
+func (s *Server) releaseActive(run *activeRun) {
+	s.activeMu.Lock()
+	if s.active == run {
+		s.active = nil
+		s.startNext()
+	}
+	s.activeMu.Unlock()
+	close(run.done)
+}
+
+// cancelRun cancels the active or a queued run with the given ID. A queued
+// run leaves the queue at once and the runs behind it move up. It reports
+// false if no such run is active or queued.
+func (s *Server) cancelRun(id string) bool {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	if s.active != nil && s.active.rec.run.ID == id {
+		s.active.cancel()
+		return true
+	}
+	for i, run := range s.queue {
+		if run.rec.run.ID != id {
+			continue
+		}
+		s.queue = append(s.queue[:i:i], s.queue[i+1:]...)
+		run.cancel()
+		close(run.ready)
+		for j, behind := range s.queue[i:] {
+			s.broadcastQueued(behind, i+j+1)
+		}
+		return true
+	}
+	return false
+}
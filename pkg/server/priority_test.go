@@ -0,0 +1,120 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func promptRunID(t *testing.T, baseURL, body string) string {
+	t.Helper()
+	resp := postJSON(t, baseURL+"/prompt", body)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var accepted struct {
+		RunID string `json:"run_id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&accepted)
+	return accepted.RunID
+}
+
+func TestPriority_HighPreemptsLow(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := &MockTool{
+		name: "slow",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			close(started)
+			<-release
+			return `{"ok":true}`, nil
+		},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "slow", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Urgent answer"))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{slow}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// Collect preempted events
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	preempted := make(chan server.Event, 1)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var e server.Event
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok && json.Unmarshal([]byte(data), &e) == nil && e.Type == "preempted" {
+				preempted <- e
+				return
+			}
+		}
+	}()
+
+	lowID := promptRunID(t, ts.URL, `{"content":"background chore","priority":"low"}`)
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for tool to start")
+	}
+
+	highID := promptRunID(t, ts.URL, `{"content":"urgent question","priority":"high"}`)
+	select {
+	case e := <-preempted:
+		if e.RunID != lowID || !strings.Contains(e.Message, highID) {
+			t.Errorf("unexpected preempted event: %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for preempted event")
+	}
+	close(release)
+
+	low := waitForRun(t, ts.URL, lowID)
+	if low.Status != "preempted" || low.Priority != "low" {
+		t.Errorf("expected low run preempted, got %+v", low)
+	}
+	if len(low.Transcript) != 3 {
+		t.Errorf("expected preempted run to keep its tool result, got %d messages", len(low.Transcript))
+	}
+	high := waitForRun(t, ts.URL, highID)
+	if high.Status != "completed" || !strings.Contains(string(high.Transcript[len(high.Transcript)-1]), "Urgent answer") {
+		t.Errorf("expected high run completed, got %+v", high)
+	}
+}
+
+func TestPriority_Invalid(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	ts := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer ts.Close()
+
+	resp := postJSON(t, ts.URL+"/prompt", `{"content":"hi","priority":"urgent"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/user/harness/pkg/harness"
+)
+
+// profilesResponse is the body of GET /profiles.
+type profilesResponse struct {
+	Default  string                `json:"default,omitempty"` // profile of prompts that name none
+	Profiles []harness.ProfileInfo `json:"profiles"`
+}
+
+// HandleListProfiles handles GET /profiles requests, listing the configured
+// agent profiles that prompts, batches, and experiment variants can select.
+func (s *Server) HandleListProfiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profilesResponse{
+		Default:  s.harness.Profile(),
+		Profiles: s.harness.Profiles(),
+	})
+}
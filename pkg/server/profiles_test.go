@@ -0,0 +1,56 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func TestProfiles_ListAndSelect(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Looks good."))
+
+	config := harness.Config{
+		Model: "test-model",
+		Profiles: map[string]harness.Profile{
+			"reviewer": {Description: "Reviews changes", SystemPrompt: "You review code."},
+			"builder":  {Description: "Implements changes"},
+		},
+		Profile: "builder",
+	}
+	h, _ := harness.NewHarnessWithStreamer(config, nil, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/profiles")
+	if err != nil {
+		t.Fatalf("GET /profiles failed: %v", err)
+	}
+	var list struct {
+		Default  string                `json:"default"`
+		Profiles []harness.ProfileInfo `json:"profiles"`
+	}
+	json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if list.Default != "builder" || len(list.Profiles) != 2 || list.Profiles[1].Name != "reviewer" || list.Profiles[1].Description != "Reviews changes" {
+		t.Errorf("unexpected profiles %+v", list)
+	}
+
+	resp = postJSON(t, ts.URL+"/prompt", `{"content":"review","profile":"tester"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown profile, got %d", resp.StatusCode)
+	}
+
+	runID := promptRunID(t, ts.URL, `{"content":"review","profile":"reviewer"}`)
+	waitForRun(t, ts.URL, runID)
+	if system := mockStreamer.RecordedParams[0].System; len(system) != 1 || system[0].Text != "You review code." {
+		t.Errorf("expected the reviewer's system prompt, got %+v", system)
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Default limits of prompt requests.
+const (
+	DefaultMaxPromptBytes = 1 << 20 // request body of POST /prompt, /prompt/stream, and /steer
+	DefaultMaxPromptChars = 200000  // characters of the prompt content
+)
+
+// PromptLimits caps the size of prompt requests, which otherwise go
+// straight into an API request. Zero fields use the defaults.
+type PromptLimits struct {
+	MaxBytes int64 // request body size in bytes
+	MaxChars int   // content length in characters, after null bytes are removed
+}
+
+// SetPromptLimits sets the size limits of POST /prompt, POST /prompt/stream,
+// and POST /steer requests.
+func (s *Server) SetPromptLimits(limits PromptLimits) {
+	s.promptLimitMu.Lock()
+	defer s.promptLimitMu.Unlock()
+	s.promptLimits = limits
+}
+
+// currentPromptLimits returns the prompt limits with defaults applied.
+func (s *Server) currentPromptLimits() PromptLimits {
+	s.promptLimitMu.Lock()
+	limits := s.promptLimits
+	s.promptLimitMu.Unlock()
+	if limits.MaxBytes <= 0 {
+		limits.MaxBytes = DefaultMaxPromptBytes
+	}
+	if limits.MaxChars <= 0 {
+		limits.MaxChars = DefaultMaxPromptChars
+	}
+	return limits
+}
+
+// decodePromptBody decodes a JSON request body into v. A body over the
+// byte limit is refused with 413, and one that is not valid UTF-8 or JSON
+// with 400; encoding/json would otherwise replace invalid bytes silently.
+func (s *Server) decodePromptBody(r *http.Request, v any) *APIError {
+	limit := s.currentPromptLimits().MaxBytes
+	data, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return newAPIError(http.StatusBadRequest, "invalid request body")
+	}
+	if int64(len(data)) > limit {
+		e := newAPIError(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+		e.Details = map[string]any{"limit": limit}
+		return e
+	}
+	if !utf8.Valid(data) {
+		return newAPIError(http.StatusBadRequest, "request body is not valid UTF-8")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return newAPIError(http.StatusBadRequest, "invalid request body")
+	}
+	return nil
+}
+
+// sanitizePrompt removes null bytes from content and refuses it with 413
+// when it has more characters than the limit.
+func (s *Server) sanitizePrompt(content string) (string, *APIError) {
+	content = strings.ReplaceAll(content, "\x00", "")
+	limit := s.currentPromptLimits().MaxChars
+	if n := utf8.RuneCountInString(content); n > limit {
+		e := newAPIError(http.StatusRequestEntityTooLarge, fmt.Sprintf("content has %d characters, more than the limit of %d", n, limit))
+		e.Details = map[string]any{"limit": limit}
+		return "", e
+	}
+	return content, nil
+}
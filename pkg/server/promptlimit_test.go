@@ -0,0 +1,58 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func TestPromptLimits(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Hello"))
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	s.SetPromptLimits(server.PromptLimits{MaxBytes: 100, MaxChars: 10})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"body too large", `{"content":"` + strings.Repeat("a", 100) + `"}`, http.StatusRequestEntityTooLarge},
+		{"too many characters", `{"content":"ééééééééééé"}`, http.StatusRequestEntityTooLarge},
+		{"invalid UTF-8", "{\"content\":\"caf\xe9\"}", http.StatusBadRequest},
+		{"only null bytes", `{"content":"\u0000\u0000"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := postJSON(t, ts.URL+"/prompt", tt.body)
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, resp.StatusCode)
+			}
+			var body struct {
+				Error *server.APIError `json:"error"`
+			}
+			json.NewDecoder(resp.Body).Decode(&body)
+			if tt.status == http.StatusRequestEntityTooLarge && (body.Error.Code != "payload_too_large" || body.Error.Details["limit"] == nil) {
+				t.Errorf("expected payload_too_large with the limit, got %+v", body.Error)
+			}
+		})
+	}
+
+	// Null bytes are removed before the characters are counted
+	runID := promptRunID(t, ts.URL, `{"content":"hello\u0000world"}`)
+	waitForRun(t, ts.URL, runID)
+	if got := mockStreamer.RecordedParams[0].Messages[0].Content[0].OfText.Text; got != "helloworld" {
+		t.Errorf("expected the null byte removed, got %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+)
+
+// PendingQuestion is an ask_user question waiting for the user's answer.
+type PendingQuestion struct {
+	ID        string   `json:"id"`
+	Question  string   `json:"question"`
+	Choices   []string `json:"choices,omitempty"`
+	RunID     string   `json:"run_id,omitempty"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+// pendingQuestion is a PendingQuestion and the channel its answer is sent on.
+type pendingQuestion struct {
+	question PendingQuestion
+	answer   chan string
+}
+
+// answerRequest is the body of POST /answer.
+type answerRequest struct {
+	ID     string  `json:"id"`
+	Answer *string `json:"answer"`
+}
+
+// ask is the harness's QuestionHandler: it broadcasts a question event and
+// waits for POST /answer or ctx cancellation.
+func (s *Server) ask(ctx context.Context, q harness.Question) (string, error) {
+	pending := &pendingQuestion{
+		question: PendingQuestion{
+			ID:        q.ID,
+			Question:  q.Question,
+			Choices:   q.Choices,
+			RunID:     log.RunID(ctx),
+			CreatedAt: time.Now().Unix(),
+		},
+		answer: make(chan string, 1),
+	}
+	s.questionMu.Lock()
+	s.questions[q.ID] = pending
+	s.questionMu.Unlock()
+
+	defer func() {
+		s.questionMu.Lock()
+		delete(s.questions, q.ID)
+		s.questionMu.Unlock()
+	}()
+
+	log.ForContext(s.logger, ctx).Info("http", "Question asked",
+		log.F("id", q.ID),
+		log.F("choices", len(q.Choices)),
+	)
+	runID := pending.question.RunID
+	s.broadcast(Event{Type: "status", State: "awaiting_answer", RunID: runID})
+	s.broadcast(Event{Type: "question", ID: q.ID, Content: q.Question, Choices: q.Choices, RunID: runID})
+
+	select {
+	case answer := <-pending.answer:
+		return answer, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// HandleAnswer handles POST /answer requests, resuming the run waiting on
+// an ask_user question with the user's answer.
+func (s *Server) HandleAnswer(w http.ResponseWriter, r *http.Request) {
+	var req answerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ID == "" || req.Answer == nil {
+		writeError(w, http.StatusBadRequest, "id and answer are required")
+		return
+	}
+
+	s.questionMu.Lock()
+	pending, ok := s.questions[req.ID]
+	if ok {
+		delete(s.questions, req.ID)
+	}
+	s.questionMu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no pending question with that id")
+		return
+	}
+	pending.answer <- *req.Answer
+
+	s.logger.Info("http", "Question answered",
+		log.F("id", req.ID),
+		log.F("run_id", pending.question.RunID),
+	)
+	s.broadcast(Event{Type: "question_answered", ID: req.ID, Content: *req.Answer, RunID: pending.question.RunID})
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleListQuestions handles GET /questions requests, listing unanswered
+// questions oldest first.
+func (s *Server) HandleListQuestions(w http.ResponseWriter, r *http.Request) {
+	s.questionMu.Lock()
+	list := make([]PendingQuestion, 0, len(s.questions))
+	for _, pending := range s.questions {
+		list = append(list, pending.question)
+	}
+	s.questionMu.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt != list[j].CreatedAt {
+			return list[i].CreatedAt < list[j].CreatedAt
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
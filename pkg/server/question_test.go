@@ -0,0 +1,129 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// waitForQuestion polls GET /questions until a question is pending.
+func waitForQuestion(t *testing.T, baseURL string) server.PendingQuestion {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(baseURL + "/questions")
+		if err != nil {
+			t.Fatalf("GET /questions failed: %v", err)
+		}
+		var pending []server.PendingQuestion
+		json.NewDecoder(resp.Body).Decode(&pending)
+		resp.Body.Close()
+		if len(pending) > 0 {
+			return pending[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no question asked")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQuestion_AnswerResumesRun(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "ask_user", map[string]any{
+		"question": "Which database?",
+		"choices":  []string{"postgres", "sqlite"},
+	}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Using sqlite."))
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{harness.NewAskUserTool()}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer ts.Close()
+	defer cancel()
+
+	events := subscribeUntilDone(t, ctx, ts.URL)
+	runID := promptRunID(t, ts.URL, `{"content":"set up storage"}`)
+	pending := waitForQuestion(t, ts.URL)
+	if pending.ID != "call_1" || pending.Question != "Which database?" || len(pending.Choices) != 2 || pending.RunID != runID {
+		t.Fatalf("unexpected question %+v", pending)
+	}
+
+	resp := postJSON(t, ts.URL+"/answer", `{"id":"call_1"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 without an answer, got %d", resp.StatusCode)
+	}
+	resp = postJSON(t, ts.URL+"/answer", `{"id":"call_1","answer":"sqlite"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	resp = postJSON(t, ts.URL+"/answer", `{"id":"call_1","answer":"sqlite"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for an answered question, got %d", resp.StatusCode)
+	}
+
+	var question, answered, result *server.Event
+	for _, e := range collectRunEvents(t, events) {
+		switch e.Type {
+		case "question":
+			question = &e
+		case "question_answered":
+			answered = &e
+		case "tool_result":
+			result = &e
+		}
+	}
+	if question == nil || question.ID != "call_1" || question.Content != "Which database?" || strings.Join(question.Choices, ",") != "postgres,sqlite" {
+		t.Errorf("unexpected question event %+v", question)
+	}
+	if answered == nil || answered.Content != "sqlite" || answered.RunID != runID {
+		t.Errorf("unexpected question_answered event %+v", answered)
+	}
+	if result == nil || result.Result != `{"answer":"sqlite"}` {
+		t.Errorf("expected the answer as the tool result, got %+v", result)
+	}
+	if run := waitForRun(t, ts.URL, runID); run.Status != "completed" {
+		t.Errorf("expected completed run, got %q", run.Status)
+	}
+}
+
+func TestQuestion_CancelAbandonsRun(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "ask_user", map[string]string{"question": "Which database?"}))
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{harness.NewAskUserTool()}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	runID := promptRunID(t, ts.URL, `{"content":"set up storage"}`)
+	waitForQuestion(t, ts.URL)
+	resp := postJSON(t, ts.URL+"/cancel", `{"run_id":"`+runID+`"}`)
+	resp.Body.Close()
+
+	if run := waitForRun(t, ts.URL, runID); run.Status != "cancelled" {
+		t.Errorf("expected cancelled run, got %q", run.Status)
+	}
+	resp, _ = http.Get(ts.URL + "/questions")
+	var pending []server.PendingQuestion
+	json.NewDecoder(resp.Body).Decode(&pending)
+	resp.Body.Close()
+	if len(pending) != 0 {
+		t.Errorf("expected no pending questions after cancelling, got %+v", pending)
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+)
+
+// SetPromptQueueSize sets how many prompts may wait for the run on the main
+// session to finish. Queued prompts run in the order they arrived, except
+// that high-priority prompts run before normal and low ones. Zero, the
+// default, rejects prompts while another is running.
+func (s *Server) SetPromptQueueSize(size int) {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	s.queueSize = size
+}
+
+// enqueue adds run to the prompt queue and returns its position, from 1, or
+// 0 if the queue is full. A high-priority run jumps ahead of the normal and
+// low ones, which are told their new positions. Callers hold s.activeMu.
+func (s *Server) enqueue(run *activeRun) int {
+	if len(s.queue) >= s.queueSize {
+		return 0
+	}
+	index := len(s.queue)
+	if run.priority == priorityHigh {
+		index = 0
+		for index < len(s.queue) && s.queue[index].priority == priorityHigh {
+			index++
+		}
+	}
+	s.queue = slices.Insert(s.queue, index, run)
+	run.rec.setStatus(runQueued)
+	position := index + 1
+	s.logger.Info("http", "Run queued",
+		log.F("run_id", run.rec.run.ID),
+		log.F("position", position),
+	)
+	s.broadcastQueued(run, position)
+	for i, behind := range s.queue[index+1:] {
+		s.broadcastQueued(behind, position+i+1)
+	}
+	return position
+}
+
+// startNext makes the first queued run the active run and tells the runs
+// behind it their new positions. Callers hold s.activeMu.
+func (s *Server) startNext() {
+	if len(s.queue) == 0 {
+		return
+	}
+	next := s.queue[0]
+	s.queue = s.queue[1:]
+	s.active = next
+	next.rec.start()
+	close(next.ready)
+	for i, run := range s.queue {
+		s.broadcastQueued(run, i+1)
+	}
+}
+
+// queueLength returns the number of queued prompts.
+func (s *Server) queueLength() int {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	return len(s.queue)
+}
+
+// broadcastQueued sends a queued status event with the run's position.
+func (s *Server) broadcastQueued(run *activeRun, position int) {
+	s.broadcast(Event{Type: "status", State: "queued", RunID: run.rec.run.ID, Position: position})
+}
+
+// writeBusy rejects a prompt because another run is active on the main
+// session and the queue is full or disabled: 409 with code busy and, in its
+// details, the active run's run_id, when it started (running_since, in Unix
+// seconds), and the number of queued prompts.
+func (s *Server) writeBusy(w http.ResponseWriter) {
+	e := toAPIError(harness.ErrPromptInProgress, http.StatusConflict)
+	var runID string
+	s.activeMu.Lock()
+	e.Details = map[string]any{"queued": len(s.queue)}
+	if s.active != nil {
+		run := s.active.rec.snapshot()
+		runID = run.ID
+		e.Details["run_id"] = run.ID
+		e.Details["running_since"] = run.StartedAt
+	}
+	s.activeMu.Unlock()
+
+	s.logger.Warn("http", "Prompt rejected",
+		log.F("error", e.Message),
+		log.F("run_id", runID),
+	)
+	writeAPIError(w, e)
+}
@@ -0,0 +1,287 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestPromptQueue(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := &MockTool{
+		name: "slow",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			close(started)
+			<-release
+			return `{"ok":true}`, nil
+		},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "slow", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("first done"))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("second done"))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("third done"))
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{slow}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	s.SetPromptQueueSize(2)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	queued := make(chan server.Event, 10)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var e server.Event
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok && json.Unmarshal([]byte(data), &e) == nil && e.State == "queued" {
+				queued <- e
+			}
+		}
+	}()
+
+	firstID := promptRunID(t, ts.URL, `{"content":"first"}`)
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for tool to start")
+	}
+
+	var ids []string
+	for i, content := range []string{"second", "third"} {
+		resp := postJSON(t, ts.URL+"/prompt", `{"content":"`+content+`"}`)
+		var accepted struct {
+			RunID         string `json:"run_id"`
+			QueuePosition int    `json:"queue_position"`
+		}
+		json.NewDecoder(resp.Body).Decode(&accepted)
+		resp.Body.Close()
+		if accepted.QueuePosition != i+1 {
+			t.Errorf("expected %s at queue position %d, got %d", content, i+1, accepted.QueuePosition)
+		}
+		ids = append(ids, accepted.RunID)
+	}
+	if run := getRun(t, ts.URL, ids[0]); run.Status != "queued" {
+		t.Errorf("expected a queued run, got %s", run.Status)
+	}
+	var status struct {
+		Queued int `json:"queued"`
+	}
+	statusResp, _ := http.Get(ts.URL + "/status")
+	json.NewDecoder(statusResp.Body).Decode(&status)
+	statusResp.Body.Close()
+	if status.Queued != 2 {
+		t.Errorf("expected 2 queued prompts, got %d", status.Queued)
+	}
+	close(release)
+
+	for _, id := range append([]string{firstID}, ids...) {
+		if run := waitForRun(t, ts.URL, id); run.Status != "completed" {
+			t.Errorf("expected run %s completed, got %s (%s)", id, run.Status, run.Error)
+		}
+	}
+	// The queued prompts ran in order
+	for i, content := range []string{"second", "third"} {
+		params := mockStreamer.RecordedParams[2+i]
+		last := params.Messages[len(params.Messages)-1]
+		if last.Role != anthropic.MessageParamRoleUser || last.Content[0].OfText.Text != content {
+			t.Errorf("expected request %d to prompt %q", 3+i, content)
+		}
+	}
+
+	// Positions are broadcast on queueing and as the queue moves
+	want := []struct {
+		id       string
+		position int
+	}{{ids[0], 1}, {ids[1], 2}, {ids[1], 1}}
+	for _, w := range want {
+		select {
+		case e := <-queued:
+			if e.RunID != w.id || e.Position != w.position {
+				t.Errorf("expected %s at position %d, got %+v", w.id, w.position, e)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for queued event")
+		}
+	}
+}
+
+func TestPromptQueue_HighPriorityJumpsAhead(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := &MockTool{
+		name: "slow",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			close(started)
+			<-release
+			return `{"ok":true}`, nil
+		},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "slow", map[string]string{}))
+	for i := 0; i < 5; i++ {
+		mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+	}
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{slow}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	s.SetPromptQueueSize(4)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	queued := make(chan server.Event, 20)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var e server.Event
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok && json.Unmarshal([]byte(data), &e) == nil && e.State == "queued" {
+				queued <- e
+			}
+		}
+	}()
+
+	// A normal-priority run is not preempted, so every prompt is queued
+	firstID := promptRunID(t, ts.URL, `{"content":"first"}`)
+	<-started
+
+	ids := make(map[string]string)
+	for _, p := range []struct {
+		content, priority string
+		position          int
+	}{{"second", "normal", 1}, {"third", "low", 2}, {"urgent", "high", 1}, {"urgent2", "high", 2}} {
+		resp := postJSON(t, ts.URL+"/prompt", `{"content":"`+p.content+`","priority":"`+p.priority+`"}`)
+		var accepted struct {
+			RunID         string `json:"run_id"`
+			QueuePosition int    `json:"queue_position"`
+		}
+		json.NewDecoder(resp.Body).Decode(&accepted)
+		resp.Body.Close()
+		if accepted.QueuePosition != p.position {
+			t.Errorf("expected %s at queue position %d, got %d", p.content, p.position, accepted.QueuePosition)
+		}
+		ids[p.content] = accepted.RunID
+	}
+	close(release)
+
+	waitForRun(t, ts.URL, firstID)
+	for _, id := range ids {
+		waitForRun(t, ts.URL, id)
+	}
+	// High-priority prompts ran first, in the order they arrived
+	for i, content := range []string{"urgent", "urgent2", "second", "third"} {
+		params := mockStreamer.RecordedParams[2+i]
+		last := params.Messages[len(params.Messages)-1]
+		if got := last.Content[0].OfText.Text; got != content {
+			t.Errorf("expected request %d to prompt %q, got %q", 3+i, content, got)
+		}
+	}
+
+	// The runs a high-priority prompt jumps ahead of are told their new
+	// positions
+	want := []struct {
+		content  string
+		position int
+	}{
+		{"second", 1}, {"third", 2},
+		{"urgent", 1}, {"second", 2}, {"third", 3},
+		{"urgent2", 2}, {"second", 3}, {"third", 4},
+	}
+	for _, w := range want {
+		select {
+		case e := <-queued:
+			if e.RunID != ids[w.content] || e.Position != w.position {
+				t.Errorf("expected %s at position %d, got %+v", w.content, w.position, e)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for queued event")
+		}
+	}
+}
+
+func TestPromptQueue_Full(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	slow := &MockTool{
+		name: "slow",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			close(started)
+			<-release
+			return `{"ok":true}`, nil
+		},
+	}
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "slow", map[string]string{}))
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{slow}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	s.SetPromptQueueSize(1)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	firstID := promptRunID(t, ts.URL, `{"content":"first"}`)
+	<-started
+	promptRunID(t, ts.URL, `{"content":"second"}`)
+
+	resp := postJSON(t, ts.URL+"/prompt", `{"content":"third"}`)
+	defer resp.Body.Close()
+	var busy struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				RunID        string `json:"run_id"`
+				RunningSince int64  `json:"running_since"`
+				Queued       int    `json:"queued"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&busy)
+	details := busy.Error.Details
+	if resp.StatusCode != http.StatusConflict || busy.Error.Code != "busy" || details.RunID != firstID || details.RunningSince == 0 || details.Queued != 1 {
+		t.Errorf("expected 409 busy naming the running run, got %d %+v", resp.StatusCode, busy)
+	}
+}
+
+// getRun returns the run record from GET /runs/{id}.
+func getRun(t *testing.T, baseURL, id string) server.Run {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/runs/" + id)
+	if err != nil {
+		t.Fatalf("GET /runs failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var run server.Run
+	json.NewDecoder(resp.Body).Decode(&run)
+	return run
+}
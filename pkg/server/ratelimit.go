@@ -0,0 +1,241 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// maxRateLimitClients bounds the per-IP buckets kept before idle ones are
+// swept.
+const maxRateLimitClients = 4096
+
+// RateLimit is a token bucket: Burst prompts may be submitted at once, and
+// the bucket refills at PerMinute prompts a minute. A zero PerMinute
+// disables the limit.
+type RateLimit struct {
+	PerMinute float64
+	Burst     int
+}
+
+// enabled reports whether the limit applies.
+func (l RateLimit) enabled() bool {
+	return l.PerMinute > 0
+}
+
+// burst returns the bucket size, at least one prompt.
+func (l RateLimit) burst() float64 {
+	return float64(max(l.Burst, 1))
+}
+
+// RateLimitConfig limits prompt submissions per client IP and across all
+// clients. A submission must fit both limits.
+type RateLimitConfig struct {
+	PerIP  RateLimit
+	Global RateLimit
+}
+
+// tokenBucket is the state of one RateLimit.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the last update, up to the burst.
+func (b *tokenBucket) refill(limit RateLimit, now time.Time) {
+	if b.last.IsZero() {
+		b.tokens = limit.burst()
+	} else {
+		earned := now.Sub(b.last).Minutes() * limit.PerMinute
+		b.tokens = math.Min(limit.burst(), b.tokens+earned)
+	}
+	b.last = now
+}
+
+// wait returns how long until the bucket holds a whole token, or zero if it
+// does now.
+func (b *tokenBucket) wait(limit RateLimit) time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / limit.PerMinute * float64(time.Minute))
+}
+
+// rateLimiter holds the buckets of a RateLimitConfig.
+type rateLimiter struct {
+	mu      sync.Mutex
+	config  RateLimitConfig
+	global  tokenBucket
+	clients map[string]*tokenBucket
+	limited uint64
+	proxies []netip.Prefix // trusted to set X-Forwarded-For
+}
+
+// SetRateLimit limits how often prompts may be submitted with POST /prompt,
+// POST /prompt/stream, POST /continue, POST /batch, and POST /experiments.
+// Requests over a limit get 429 with a Retry-After header. The zero config,
+// the default, disables rate limiting.
+func (s *Server) SetRateLimit(config RateLimitConfig) {
+	s.limiter.mu.Lock()
+	defer s.limiter.mu.Unlock()
+	s.limiter.config = config
+	s.limiter.global = tokenBucket{}
+	s.limiter.clients = make(map[string]*tokenBucket)
+}
+
+// SetTrustedProxies makes the rate limiter identify clients behind the given
+// reverse proxies, IP addresses or CIDR ranges, by X-Forwarded-For. Requests
+// from other addresses are identified by their own, so clients cannot spoof
+// the header. None are trusted by default.
+func (s *Server) SetTrustedProxies(proxies []string) error {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, p := range proxies {
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(p)
+			if addrErr != nil {
+				return fmt.Errorf("invalid trusted proxy %q: use an IP address or CIDR range", p)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	s.limiter.mu.Lock()
+	defer s.limiter.mu.Unlock()
+	s.limiter.proxies = prefixes
+	return nil
+}
+
+// allow takes a token for a submission from ip from both buckets, or
+// returns how long the client should wait before retrying.
+func (l *rateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	var client *tokenBucket
+	if l.config.PerIP.enabled() {
+		client = l.clients[ip]
+		if client == nil {
+			if len(l.clients) >= maxRateLimitClients {
+				l.sweep(now)
+			}
+			client = &tokenBucket{}
+			l.clients[ip] = client
+		}
+		client.refill(l.config.PerIP, now)
+		wait = max(wait, client.wait(l.config.PerIP))
+	}
+	if l.config.Global.enabled() {
+		l.global.refill(l.config.Global, now)
+		wait = max(wait, l.global.wait(l.config.Global))
+	}
+	if wait > 0 {
+		l.limited++
+		return false, wait
+	}
+
+	// Only take tokens once both limits allow the submission
+	if client != nil {
+		client.tokens--
+	}
+	if l.config.Global.enabled() {
+		l.global.tokens--
+	}
+	return true, 0
+}
+
+// sweep forgets clients whose buckets have refilled, as they behave like
+// new ones.
+func (l *rateLimiter) sweep(now time.Time) {
+	for ip, b := range l.clients {
+		b.refill(l.config.PerIP, now)
+		if b.tokens >= l.config.PerIP.burst() {
+			delete(l.clients, ip)
+		}
+	}
+}
+
+// rateLimited returns the number of submissions rejected by a limit.
+func (l *rateLimiter) rateLimited() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limited
+}
+
+// rateLimit wraps a prompt-submitting handler, rejecting requests over the
+// configured limits before they reach it.
+func (s *Server) rateLimit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := s.limiter.clientIP(r)
+		ok, wait := s.limiter.allow(ip, time.Now())
+		if !ok {
+			retry := int(math.Ceil(wait.Seconds()))
+			s.logger.Warn("http", "Rate limit exceeded",
+				log.F("method", r.Method),
+				log.F("path", r.URL.Path),
+				log.F("remote_addr", ip),
+				log.F("retry_after", retry),
+			)
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			e := newAPIError(http.StatusTooManyRequests, "rate limit exceeded")
+			e.Details = map[string]any{"retry_after": retry}
+			writeAPIError(w, e)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// clientIP returns the IP address of r's client: the host part of its
+// remote address or, for requests from trusted proxies, the last address in
+// X-Forwarded-For that is not a trusted proxy.
+func (l *rateLimiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.trusted(host) {
+		return host
+	}
+	// Proxies append the address they received the request from, so the
+	// client is the rightmost address that was not added by a trusted proxy
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		host = addr.Unmap().String()
+		if !l.trusted(host) {
+			break
+		}
+	}
+	return host
+}
+
+// trusted reports whether ip belongs to a trusted proxy. The caller must
+// hold l.mu.
+func (l *rateLimiter) trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range l.proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Buckets(t *testing.T) {
+	l := &rateLimiter{
+		config: RateLimitConfig{
+			PerIP:  RateLimit{PerMinute: 6, Burst: 2},
+			Global: RateLimit{PerMinute: 3, Burst: 3},
+		},
+		clients: make(map[string]*tokenBucket),
+	}
+	now := time.Now()
+
+	// The per-IP burst allows two prompts, then one every 10 seconds
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("prompt %d should be allowed", i+1)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok || wait != 10*time.Second {
+		t.Errorf("expected a 10s wait, got %v %v", ok, wait)
+	}
+	if ok, _ := l.allow("a", now.Add(10*time.Second)); !ok {
+		t.Error("expected a prompt after the refill")
+	}
+
+	// The global bucket (3 at once, then one every 20 seconds) is shared
+	// across clients: a has used it up, though b has its own tokens left
+	if ok, _ := l.allow("b", now.Add(10*time.Second)); ok {
+		t.Error("expected the global limit to reject b")
+	}
+	if ok, _ := l.allow("b", now.Add(20*time.Second)); !ok {
+		t.Error("expected b to be allowed once the global bucket refilled")
+	}
+
+	// A rejected prompt takes no tokens from the other bucket
+	if got := l.clients["b"].tokens; got != 1 {
+		t.Errorf("expected b to have 1 token left, got %v", got)
+	}
+	if got := l.rateLimited(); got != 2 {
+		t.Errorf("expected 2 rejections, got %d", got)
+	}
+}
+
+func TestRateLimit_RetryAfter(t *testing.T) {
+	s := NewServer(createTestHarness(t), ":0", nil)
+	s.SetRateLimit(RateLimitConfig{PerIP: RateLimit{PerMinute: 1}})
+	handler := s.Handler()
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/prompt", strings.NewReader(`{}`))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	// The first request reaches the handler, which rejects the empty body
+	if w := post(); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	w := post()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected Retry-After 60, got %q", got)
+	}
+
+	// Other endpoints are not limited
+	req := httptest.NewRequest("GET", "/status", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for /status, got %d", rec.Code)
+	}
+}
+
+func TestRateLimit_TrustedProxies(t *testing.T) {
+	s := NewServer(createTestHarness(t), ":0", nil)
+	if err := s.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"}); err != nil {
+		t.Fatalf("SetTrustedProxies failed: %v", err)
+	}
+	if err := s.SetTrustedProxies([]string{"proxy"}); err == nil {
+		t.Error("expected an error for an invalid proxy")
+	}
+
+	tests := []struct {
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"203.0.113.5:1234", []string{"198.51.100.1"}, "203.0.113.5"},
+		{"10.1.2.3:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"10.1.2.3:1234", []string{"1.2.3.4, 198.51.100.1, 192.168.1.1"}, "198.51.100.1"},
+		{"10.1.2.3:1234", []string{"198.51.100.1", "10.0.0.9"}, "198.51.100.1"},
+		{"10.1.2.3:1234", []string{"10.0.0.7"}, "10.0.0.7"},
+		{"10.1.2.3:1234", []string{"garbage"}, "10.1.2.3"},
+		{"10.1.2.3:1234", nil, "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/prompt", nil)
+		req.RemoteAddr = tt.remote
+		for _, v := range tt.forwarded {
+			req.Header.Add("X-Forwarded-For", v)
+		}
+		if got := s.limiter.clientIP(req); got != tt.want {
+			t.Errorf("%s via %v: expected %s, got %s", tt.forwarded, tt.remote, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+)
+
+// Run states.
+const (
+	runQueued    = "queued"
+	runRunning   = "running"
+	runCompleted = "completed"
+	runFailed    = "failed"
+	runCancelled = "cancelled"
+	runPreempted = "preempted"
+	// Stopped at the turn limit; POST /continue resumes it as a new run
+	runMaxTurns = "max_turns_exceeded"
+	// Stopped by a cost or token budget
+	runBudgetExceeded = "budget_exceeded"
+)
+
+// Run is the record of one prompt on the main session: its transcript
+// (the messages it added to the conversation) and any feedback annotations.
+type Run struct {
+	ID          string            `json:"id"`
+	Prompt      string            `json:"prompt"`
+	Priority    string            `json:"priority"`
+	Status      string            `json:"status"`
+	Error       string            `json:"error,omitempty"`
+	StartedAt   int64             `json:"started_at"`
+	CompletedAt int64             `json:"completed_at,omitempty"`
+	Transcript  []json.RawMessage `json:"transcript"`
+	Annotations []Annotation      `json:"annotations"`
+
+	// Final text, stop reason, turns, and usage, once the run has finished
+	Result *harness.RunResult `json:"result,omitempty"`
+
+	// Run temp dir, when a tool kept it for its artifacts
+	TempDir string `json:"temp_dir,omitempty"`
+}
+
+// runOutcome is what a finished prompt produced.
+type runOutcome struct {
+	messages []anthropic.MessageParam // messages the run added
+	result   harness.RunResult
+	tempDir  string // kept temp dir, if any
+	err      error
+}
+
+// status returns the run state for the outcome.
+func (o runOutcome) status() string {
+	switch {
+	case errors.Is(o.err, harness.ErrPreempted):
+		return runPreempted
+	case errors.Is(o.err, context.Canceled), errors.Is(o.err, ErrShuttingDown):
+		return runCancelled
+	case errors.Is(o.err, harness.ErrMaxTurnsExceeded):
+		return runMaxTurns
+	case errors.Is(o.err, harness.ErrBudgetExceeded):
+		return runBudgetExceeded
+	case o.err != nil:
+		return runFailed
+	}
+	return runCompleted
+}
+
+// Annotation is human feedback on one message of a run transcript.
+type Annotation struct {
+	ID          string `json:"id"`
+	TargetIndex int    `json:"target_index"`
+	ToolCallID  string `json:"tool_call_id,omitempty"`
+	Rating      int    `json:"rating"`
+	Comment     string `json:"comment,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// annotationRequest is the body of POST /runs/{id}/annotations.
+type annotationRequest struct {
+	TargetIndex *int   `json:"target_index"`
+	ToolCallID  string `json:"tool_call_id,omitempty"`
+	Rating      int    `json:"rating"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+// runRecord guards a Run.
+type runRecord struct {
+	mu  sync.Mutex
+	run Run
+}
+
+// snapshot returns a copy of the run that is safe to serialize.
+func (r *runRecord) snapshot() Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run := r.run
+	run.Transcript = append([]json.RawMessage{}, r.run.Transcript...)
+	run.Annotations = append([]Annotation{}, r.run.Annotations...)
+	return run
+}
+
+// EnableRunStore persists runs and their annotations as JSON files in dir
+// and loads runs saved by earlier processes.
+func (s *Server) EnableRunStore(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	s.runDir = dir
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		var run Run
+		if err := json.Unmarshal(data, &run); err != nil {
+			s.logger.Warn("http", "Skipping invalid run file",
+				log.F("file", entry.Name()),
+				log.F("error", err.Error()),
+			)
+			continue
+		}
+		s.runs[run.ID] = &runRecord{run: run}
+	}
+	return nil
+}
+
+// newRun returns the record of a new run for prompt.
+func newRun(prompt, priority string) *runRecord {
+	return &runRecord{run: Run{
+		ID:          newRunID(),
+		Prompt:      prompt,
+		Priority:    priority,
+		Status:      runRunning,
+		StartedAt:   time.Now().Unix(),
+		Transcript:  []json.RawMessage{},
+		Annotations: []Annotation{},
+	}}
+}
+
+// addRun records rec as a run of the main session.
+func (s *Server) addRun(rec *runRecord) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	s.runs[rec.run.ID] = rec
+}
+
+// setStatus sets the status of an unfinished run.
+func (r *runRecord) setStatus(status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.run.Status = status
+}
+
+// start marks a queued run as running from now.
+func (r *runRecord) start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.run.Status = runRunning
+	r.run.StartedAt = time.Now().Unix()
+}
+
+// finishRun records the run outcome and saves it.
+func (s *Server) finishRun(rec *runRecord, out runOutcome) {
+	transcript := make([]json.RawMessage, 0, len(out.messages))
+	for _, msg := range out.messages {
+		if data, err := json.Marshal(msg); err == nil {
+			transcript = append(transcript, data)
+		}
+	}
+	result := out.result
+
+	rec.mu.Lock()
+	rec.run.Transcript = transcript
+	rec.run.Result = &result
+	rec.run.TempDir = out.tempDir
+	rec.run.CompletedAt = time.Now().Unix()
+	rec.run.Status = out.status()
+	if out.err != nil && rec.run.Status != runPreempted {
+		rec.run.Error = out.err.Error()
+	}
+	rec.mu.Unlock()
+
+	s.saveRun(rec)
+}
+
+// broadcastDone sends a run's terminal done event. Its state is the run
+// status, and message carries the error of unsuccessful runs.
+func (s *Server) broadcastDone(runID string, out runOutcome) {
+	event := Event{
+		Type:        "done",
+		RunID:       runID,
+		State:       out.status(),
+		Content:     out.result.FinalText,
+		StopReason:  out.result.StopReason,
+		Turns:       out.result.Turns,
+		Usage:       &out.result.Usage,
+		DurationMs:  out.result.DurationMs,
+		NeedsReview: out.result.NeedsReview,
+	}
+	if out.err != nil {
+		event.Message = out.err.Error()
+	}
+	s.broadcast(event)
+}
+
+// saveRun writes the run to the run store, if enabled.
+func (s *Server) saveRun(rec *runRecord) {
+	s.runMu.RLock()
+	dir := s.runDir
+	s.runMu.RUnlock()
+	if dir == "" {
+		return
+	}
+	run := rec.snapshot()
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, run.ID+".json"), data, 0644)
+	}
+	if err != nil {
+		s.logger.Warn("http", "Failed to save run",
+			log.F("run_id", run.ID),
+			log.F("error", err.Error()),
+		)
+	}
+}
+
+// run returns the run record with the given ID.
+func (s *Server) run(id string) (*runRecord, bool) {
+	s.runMu.RLock()
+	defer s.runMu.RUnlock()
+	rec, ok := s.runs[id]
+	return rec, ok
+}
+
+// HandleGetRun handles GET /runs/{id} requests, returning the transcript and annotations.
+func (s *Server) HandleGetRun(w http.ResponseWriter, r *http.Request) {
+	rec, ok := s.run(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rec.snapshot())
+}
+
+// HandleAnnotate handles POST /runs/{id}/annotations requests.
+func (s *Server) HandleAnnotate(w http.ResponseWriter, r *http.Request) {
+	rec, ok := s.run(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "run not found")
+		return
+	}
+	var req annotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Rating < -1 || req.Rating > 1 {
+		writeError(w, http.StatusBadRequest, "rating must be -1, 0, or 1")
+		return
+	}
+	if req.TargetIndex == nil {
+		writeError(w, http.StatusBadRequest, "target_index is required")
+		return
+	}
+
+	rec.mu.Lock()
+	if rec.run.Status == runRunning || rec.run.Status == runQueued {
+		rec.mu.Unlock()
+		writeError(w, http.StatusConflict, "run is still in progress")
+		return
+	}
+	if *req.TargetIndex < 0 || *req.TargetIndex >= len(rec.run.Transcript) {
+		rec.mu.Unlock()
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("target_index must be between 0 and %d", len(rec.run.Transcript)-1))
+		return
+	}
+	if req.ToolCallID != "" && !strings.Contains(string(rec.run.Transcript[*req.TargetIndex]), `"`+req.ToolCallID+`"`) {
+		rec.mu.Unlock()
+		writeError(w, http.StatusBadRequest, "tool_call_id not found in target message")
+		return
+	}
+	annotation := Annotation{
+		ID:          newAnnotationID(),
+		TargetIndex: *req.TargetIndex,
+		ToolCallID:  req.ToolCallID,
+		Rating:      req.Rating,
+		Comment:     req.Comment,
+		CreatedAt:   time.Now().Unix(),
+	}
+	rec.run.Annotations = append(rec.run.Annotations, annotation)
+	rec.mu.Unlock()
+
+	s.saveRun(rec)
+	s.logger.Info("http", "Annotation added",
+		log.F("run_id", r.PathValue("id")),
+		log.F("target_index", annotation.TargetIndex),
+		log.F("rating", annotation.Rating),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(annotation)
+}
+
+// annotationExport is one line of GET /annotations/export.
+type annotationExport struct {
+	RunID   string          `json:"run_id"`
+	Prompt  string          `json:"prompt"`
+	Message json.RawMessage `json:"message"`
+	Annotation
+}
+
+// HandleExportAnnotations handles GET /annotations/export requests, writing
+// every annotation with its run prompt and target message as JSON Lines.
+func (s *Server) HandleExportAnnotations(w http.ResponseWriter, r *http.Request) {
+	s.runMu.RLock()
+	runs := make([]Run, 0, len(s.runs))
+	for _, rec := range s.runs {
+		runs = append(runs, rec.snapshot())
+	}
+	s.runMu.RUnlock()
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].StartedAt != runs[j].StartedAt {
+			return runs[i].StartedAt < runs[j].StartedAt
+		}
+		return runs[i].ID < runs[j].ID
+	})
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	for _, run := range runs {
+		for _, a := range run.Annotations {
+			enc.Encode(annotationExport{
+				RunID:      run.ID,
+				Prompt:     run.Prompt,
+				Message:    run.Transcript[a.TargetIndex],
+				Annotation: a,
+			})
+		}
+	}
+}
+
+// newRunID returns a random run identifier.
+func newRunID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "run_" + hex.EncodeToString(buf)
+}
+
+// newAnnotationID returns a random annotation identifier.
+func newAnnotationID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "ann_" + hex.EncodeToString(buf)
+}
@@ -0,0 +1,511 @@
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// waitForRun polls GET /runs/{id} until the run has finished.
+func waitForRun(t *testing.T, baseURL, id string) server.Run {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(baseURL + "/runs/" + id)
+		if err != nil {
+			t.Fatalf("GET /runs failed: %v", err)
+		}
+		var run server.Run
+		json.NewDecoder(resp.Body).Decode(&run)
+		resp.Body.Close()
+		if run.Status != "" && run.Status != "running" && run.Status != "queued" {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run %s did not finish", id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func postJSON(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", url, err)
+	}
+	return resp
+}
+
+func TestRuns_AnnotateAndExport(t *testing.T) {
+	dir := t.TempDir()
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("The answer is 42").Build())
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	if err := s.EnableRunStore(dir); err != nil {
+		t.Fatalf("EnableRunStore failed: %v", err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp := postJSON(t, ts.URL+"/prompt", `{"content":"what is the answer?"}`)
+	var accepted struct {
+		RunID string `json:"run_id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&accepted)
+	resp.Body.Close()
+	if accepted.RunID == "" {
+		t.Fatal("expected run_id in prompt response")
+	}
+
+	run := waitForRun(t, ts.URL, accepted.RunID)
+	if run.Status != "completed" || run.Prompt != "what is the answer?" {
+		t.Errorf("unexpected run: %+v", run)
+	}
+	if len(run.Transcript) != 2 {
+		t.Fatalf("expected user and assistant messages, got %d", len(run.Transcript))
+	}
+
+	// Invalid annotations are rejected
+	for name, body := range map[string]string{
+		"missing target": `{"rating":1}`,
+		"out of range":   `{"target_index":5,"rating":1}`,
+		"bad rating":     `{"target_index":1,"rating":3}`,
+		"unknown call":   `{"target_index":1,"rating":1,"tool_call_id":"toolu_missing"}`,
+	} {
+		resp := postJSON(t, ts.URL+"/runs/"+accepted.RunID+"/annotations", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, resp.StatusCode)
+		}
+	}
+
+	resp = postJSON(t, ts.URL+"/runs/"+accepted.RunID+"/annotations", `{"target_index":1,"rating":-1,"comment":"should show work"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+
+	// Export includes the annotated message
+	resp, err = http.Get(ts.URL + "/annotations/export")
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	var lines []map[string]any
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line map[string]any
+		json.Unmarshal(scanner.Bytes(), &line)
+		lines = append(lines, line)
+	}
+	resp.Body.Close()
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 exported annotation, got %d", len(lines))
+	}
+	if lines[0]["run_id"] != accepted.RunID || lines[0]["comment"] != "should show work" {
+		t.Errorf("unexpected export line: %v", lines[0])
+	}
+	if msg, _ := json.Marshal(lines[0]["message"]); !bytes.Contains(msg, []byte("The answer is 42")) {
+		t.Errorf("expected exported message content, got %s", msg)
+	}
+
+	// Annotations persist with the transcript
+	s2 := server.NewServer(h, ":0", nil)
+	if err := s2.EnableRunStore(dir); err != nil {
+		t.Fatalf("EnableRunStore failed: %v", err)
+	}
+	ts2 := httptest.NewServer(s2.Handler())
+	defer ts2.Close()
+	reloaded := waitForRun(t, ts2.URL, accepted.RunID)
+	if len(reloaded.Annotations) != 1 || len(reloaded.Transcript) != 2 {
+		t.Errorf("expected persisted run, got %+v", reloaded)
+	}
+}
+
+func TestRuns_NotFound(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	ts := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer ts.Close()
+
+	resp := postJSON(t, ts.URL+"/runs/run_missing/annotations", `{"target_index":0,"rating":1}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+// subscribeUntilDone streams events from /events into the returned channel,
+// which is closed after the first done event.
+func subscribeUntilDone(t *testing.T, ctx context.Context, baseURL string) <-chan server.Event {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, "GET", baseURL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	events := make(chan server.Event, 100)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var e server.Event
+			if json.Unmarshal([]byte(data), &e) != nil {
+				continue
+			}
+			events <- e
+			if e.Type == "done" {
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// collectRunEvents returns all events up to and including done.
+func collectRunEvents(t *testing.T, events <-chan server.Event) []server.Event {
+	t.Helper()
+	var got []server.Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return got
+			}
+			got = append(got, e)
+		case <-timeout:
+			t.Fatalf("timeout waiting for done event, got %+v", got)
+		}
+	}
+}
+
+func TestRuns_DoneEventLast(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("Finished").WithUsage(12, 3).Build())
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer ts.Close()
+	defer cancel()
+
+	events := subscribeUntilDone(t, ctx, ts.URL)
+	runID := promptRunID(t, ts.URL, `{"content":"finish up"}`)
+	got := collectRunEvents(t, events)
+
+	done := got[len(got)-1]
+	if done.RunID != runID || done.State != "completed" || done.Content != "Finished" {
+		t.Errorf("unexpected done event: %+v", done)
+	}
+	if done.StopReason != "end_turn" || done.Turns != 1 || done.Usage == nil || done.Usage.InputTokens != 12 {
+		t.Errorf("unexpected done summary: %+v", done)
+	}
+	if prev := got[len(got)-2]; prev.Type != "status" || prev.State != "idle" {
+		t.Errorf("expected idle status before done, got %+v", prev)
+	}
+}
+
+func TestRuns_RunIDOnEventsAndLogs(t *testing.T) {
+	echo := &MockTool{
+		name: "echo",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			return `{"run_id":"` + log.RunID(ctx) + `"}`, nil
+		},
+	}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "echo", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Done"))
+
+	var logs bytes.Buffer
+	logger := log.NewLogger(log.LogConfig{Level: log.LevelInfo, Format: log.FormatJSON, Output: &logs})
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{echo}, nil, mockStreamer)
+	h.SetLogger(logger)
+	s := server.NewServer(h, ":0", logger)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := subscribeUntilDone(t, ctx, ts.URL)
+	runID := promptRunID(t, ts.URL, `{"content":"echo"}`)
+	got := collectRunEvents(t, events)
+	cancel()
+	ts.Close()
+
+	for _, e := range got {
+		if e.RunID != runID {
+			t.Errorf("expected run_id %s on every event, got %+v", runID, e)
+		}
+		if e.Type == "tool_result" && !strings.Contains(e.Result, runID) {
+			t.Errorf("expected the tool to see the run ID, got %s", e.Result)
+		}
+	}
+
+	// The run's http, api, and tool lines carry its ID
+	tagged := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		if entry["run_id"] == runID {
+			tagged[entry["category"].(string)] = true
+		}
+	}
+	for _, category := range []string{"http", "api", "tool", "harness"} {
+		if !tagged[category] {
+			t.Errorf("expected %s log lines with the run ID, got %v", category, tagged)
+		}
+	}
+}
+
+func TestRuns_DoneEventOnCancel(t *testing.T) {
+	started := make(chan struct{})
+	blocking := &MockTool{
+		name: "blocking_tool",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "blocking_tool", map[string]string{}))
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{blocking}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer ts.Close()
+	defer cancel()
+
+	events := subscribeUntilDone(t, ctx, ts.URL)
+	runID := promptRunID(t, ts.URL, `{"content":"block"}`)
+	<-started
+	resp := postJSON(t, ts.URL+"/cancel", `{}`)
+	resp.Body.Close()
+	got := collectRunEvents(t, events)
+
+	done := got[len(got)-1]
+	if done.Type != "done" || done.RunID != runID || done.State != "cancelled" || done.StopReason != "cancelled" || done.Message == "" {
+		t.Errorf("unexpected done event: %+v", done)
+	}
+	if run := waitForRun(t, ts.URL, runID); run.Status != "cancelled" {
+		t.Errorf("expected cancelled run, got %q", run.Status)
+	}
+}
+
+func TestRuns_BudgetExceeded(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().
+		AddToolUse("call_1", "test_tool", map[string]string{}).
+		WithUsage(100, 20).
+		BuildWithToolUse())
+
+	config := harness.Config{Model: "test-model", MaxTokensPerPrompt: 100}
+	h, _ := harness.NewHarnessWithStreamer(config, []tool.Tool{&MockTool{name: "test_tool"}}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer ts.Close()
+	defer cancel()
+
+	events := subscribeUntilDone(t, ctx, ts.URL)
+	runID := promptRunID(t, ts.URL, `{"content":"loop"}`)
+	got := collectRunEvents(t, events)
+	if status := got[len(got)-2]; status.Type != "status" || status.State != "budget_exceeded" || status.Message == "" {
+		t.Errorf("expected budget_exceeded status, got %+v", status)
+	}
+	if done := got[len(got)-1]; done.State != "budget_exceeded" || done.StopReason != harness.StopReasonBudgetExceeded {
+		t.Errorf("unexpected done event: %+v", done)
+	}
+	if run := waitForRun(t, ts.URL, runID); run.Status != "budget_exceeded" || run.Error == "" {
+		t.Errorf("expected budget_exceeded run with its error, got %q %q", run.Status, run.Error)
+	}
+}
+
+func TestRuns_DoneEventNeedsReview(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "write", map[string]string{}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_2", "write", map[string]string{}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_3", "write", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("The writes failed."))
+	write := &MockTool{
+		name: "write",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			return `{"error":"permission denied"}`, nil
+		},
+	}
+
+	config := harness.Config{Model: "test-model", MaxMutatingFailures: 2}
+	h, _ := harness.NewHarnessWithStreamer(config, []tool.Tool{write}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer ts.Close()
+	defer cancel()
+
+	events := subscribeUntilDone(t, ctx, ts.URL)
+	runID := promptRunID(t, ts.URL, `{"content":"write it"}`)
+	got := collectRunEvents(t, events)
+	if done := got[len(got)-1]; done.Type != "done" || done.State != "completed" || !done.NeedsReview {
+		t.Errorf("expected a completed done event flagged for review, got %+v", done)
+	}
+	if run := waitForRun(t, ts.URL, runID); run.Result == nil || !run.Result.NeedsReview {
+		t.Errorf("expected the run record's result flagged for review, got %+v", run.Result)
+	}
+
+	// The next run starts unflagged
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Hello"))
+	events = subscribeUntilDone(t, ctx, ts.URL)
+	promptRunID(t, ts.URL, `{"content":"hi"}`)
+	got = collectRunEvents(t, events)
+	if done := got[len(got)-1]; done.NeedsReview {
+		t.Errorf("expected the next run not flagged, got %+v", done)
+	}
+}
+
+func TestRuns_MaxTurnsAndContinue(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "test_tool", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Finished"))
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model", MaxTurns: 1}, []tool.Tool{&MockTool{name: "test_tool"}}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer ts.Close()
+	defer cancel()
+
+	resp := postJSON(t, ts.URL+"/continue", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected 409 with nothing to continue, got %d", resp.StatusCode)
+	}
+
+	events := subscribeUntilDone(t, ctx, ts.URL)
+	runID := promptRunID(t, ts.URL, `{"content":"loop"}`)
+	got := collectRunEvents(t, events)
+	if status := got[len(got)-2]; status.Type != "status" || status.State != "max_turns_exceeded" {
+		t.Errorf("expected max_turns_exceeded status, got %+v", status)
+	}
+	if done := got[len(got)-1]; done.State != "max_turns_exceeded" || done.StopReason != harness.StopReasonMaxTurns {
+		t.Errorf("unexpected done event: %+v", done)
+	}
+	if run := waitForRun(t, ts.URL, runID); run.Status != "max_turns_exceeded" {
+		t.Errorf("expected max_turns_exceeded run, got %q", run.Status)
+	}
+
+	// Continuing starts a new run that answers the tool results
+	events = subscribeUntilDone(t, ctx, ts.URL)
+	resp = postJSON(t, ts.URL+"/continue", "")
+	var body struct {
+		RunID string `json:"run_id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || body.RunID == "" || body.RunID == runID {
+		t.Fatalf("expected a new run, got %d %+v", resp.StatusCode, body)
+	}
+	got = collectRunEvents(t, events)
+	if done := got[len(got)-1]; done.RunID != body.RunID || done.State != "completed" || done.Content != "Finished" {
+		t.Errorf("unexpected done event: %+v", done)
+	}
+}
+
+func TestRuns_CancelByID(t *testing.T) {
+	started := make(chan struct{})
+	slow := &MockTool{
+		name: "slow",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "slow", map[string]string{}))
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{slow}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	s.SetPromptQueueSize(1)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer ts.Close()
+	defer cancel()
+
+	events := subscribeUntilDone(t, ctx, ts.URL)
+	firstID := promptRunID(t, ts.URL, `{"content":"first"}`)
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for tool to start")
+	}
+	secondID := promptRunID(t, ts.URL, `{"content":"second"}`)
+
+	cancelRun := func(id string) int {
+		resp := postJSON(t, ts.URL+"/cancel", `{"run_id":"`+id+`"}`)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// A queued run is cancelled without starting
+	if code := cancelRun(secondID); code != http.StatusOK {
+		t.Fatalf("expected 200 cancelling the queued run, got %d", code)
+	}
+	got := collectRunEvents(t, events)
+	done := got[len(got)-1]
+	if done.RunID != secondID || done.State != "cancelled" {
+		t.Errorf("expected the queued run to finish cancelled, got %+v", done)
+	}
+	if prev := got[len(got)-2]; prev.Type != "status" || prev.State != "cancelled" || prev.RunID != secondID {
+		t.Errorf("expected a cancelled status before done, got %+v", prev)
+	}
+	if run := getRun(t, ts.URL, firstID); run.Status != "running" {
+		t.Errorf("expected the first run to keep running, got %s", run.Status)
+	}
+
+	// The running run is cancelled
+	if code := cancelRun(firstID); code != http.StatusOK {
+		t.Fatalf("expected 200 cancelling the running run, got %d", code)
+	}
+	if run := waitForRun(t, ts.URL, firstID); run.Status != "cancelled" {
+		t.Errorf("expected the first run cancelled, got %s (%s)", run.Status, run.Error)
+	}
+
+	// Finished and unknown runs cannot be cancelled
+	for _, id := range []string{firstID, "run_missing"} {
+		if code := cancelRun(id); code != http.StatusNotFound {
+			t.Errorf("expected 404 cancelling %s, got %d", id, code)
+		}
+	}
+}
@@ -6,16 +6,20 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/user/harness/pkg/harness"
 	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/tool"
 )
 
-// UserPromptLogger is a callback for logging user prompts.
-type UserPromptLogger func(content string)
+// UserPromptLogger is a callback for logging user prompts with the ID of
+// the run they start or steer.
+type UserPromptLogger func(runID, content string)
 
 // Server wraps a Harness and exposes it over HTTP.
 type Server struct {
@@ -26,59 +30,213 @@ type Server struct {
 	// Optional callback to log user prompts for agent interaction logging
 	userPromptLogger UserPromptLogger
 
-	// SSE client management
+	// SSE client management; fanout indexes clients by the session they
+	// follow, with "" for clients that receive every session
 	mu      sync.RWMutex
 	clients map[*sseClient]struct{}
+	fanout  map[string]map[*sseClient]struct{}
 	nextID  int
+	history *eventHistory
+
+	// Backpressure: per-client buffer size, how long a client may stall
+	// before it is disconnected, and counts of dropped events and
+	// disconnected clients (guarded by mu)
+	clientBuffer   int
+	stallTimeout   time.Duration
+	droppedEvents  uint64
+	evictedClients uint64
+
+	// Batch tracking
+	batchMu sync.RWMutex
+	batches map[string]*batch
+
+	// Addresses batch webhooks and workspace clones may reach
+	webhookGuard addressGuard
+	cloneGuard   addressGuard
+
+	// Workspace management (disabled until EnableWorkspaces)
+	workspaceMu   sync.RWMutex
+	workspaceRoot string
+	workspaces    map[string]*Workspace
+
+	// Run records for prompts on the main session
+	runMu  sync.RWMutex
+	runs   map[string]*runRecord
+	runDir string
+
+	// Run executing on the main session, for priority preemption, and the
+	// prompts queued behind it
+	activeMu  sync.Mutex
+	active    *activeRun
+	queue     []*activeRun
+	queueSize int
+
+	// Tool calls awaiting user approval (disabled until EnableApproval)
+	approvalMu    sync.Mutex
+	approvalTools map[string]bool
+	approvals     map[string]*pendingApproval
+
+	// ask_user questions awaiting an answer, by tool call ID
+	questionMu sync.Mutex
+	questions  map[string]*pendingQuestion
+
+	// Tool registry for GET /tools; nil lists the harness's tools
+	toolMu   sync.RWMutex
+	registry *tool.Registry
+
+	// Experiment tracking
+	experimentMu sync.RWMutex
+	experiments  map[string]*experiment
+
+	// Idle detection
+	idleMu sync.Mutex
+	idle   idleState
+
+	// Large event bodies by content hash, for deduplicating clients
+	content *contentStore
+
+	// Files uploaded with POST /files by ID, the scratch directories
+	// holding them, and the name of this server's directories
+	uploadMu      sync.Mutex
+	uploads       map[string]*Upload
+	uploadDirs    map[string]bool
+	uploadSession string
+	maxUpload     int64
+
+	// Size limits of prompt requests
+	promptLimitMu sync.Mutex
+	promptLimits  PromptLimits
+
+	// Prompt submission rate limits (disabled until SetRateLimit)
+	limiter rateLimiter
+
+	// Serializes changes to the logger's filter by PUT /admin/log
+	logMu sync.Mutex
+
+	// Credentials required of every request; zero disables authentication.
+	// adminEnabled serves the /admin endpoints without it
+	authMu       sync.RWMutex
+	auth         AuthConfig
+	adminEnabled bool
+
+	// Embedded chat UI, served unless disabled
+	webMu         sync.RWMutex
+	webUIDisabled bool
+
+	// Origins allowed to make cross-origin requests
+	corsMu sync.RWMutex
+	cors   CORSConfig
+
+	// Graceful shutdown: closing is set by Shutdown, and shutdownCh is
+	// closed once SSE clients have been sent the last event. ctx is the
+	// server's lifecycle context, which background work such as batch
+	// items runs under; Shutdown cancels it with stop.
+	shutdownMu sync.Mutex
+	closing    bool
+	httpServer *http.Server
+	shutdownCh chan struct{}
+	ctx        context.Context
+	stop       context.CancelCauseFunc
 }
 
 // sseClient represents a connected SSE client.
 type sseClient struct {
-	id     int
-	events chan []byte
+	id      int
+	events  chan sseMessage
+	dedup   bool   // receives deduplicated events (guarded by Server.mu)
+	session string // session whose events it receives; "" for all
+
+	// Backpressure state (guarded by Server.mu): events dropped since the
+	// buffer filled, when it filled, and evict, closed to disconnect a
+	// client stalled too long
+	dropped      int
+	stalledSince time.Time
+	evict        chan struct{}
+	evicted      bool
 }
 
-// NewServer creates a new HTTP server for the given harness.
+// NewServer creates a new HTTP server for the given harness, which sends
+// the questions of its ask_user tool to the server's clients.
 // If logger is nil, a NopLogger is used.
 func NewServer(h *harness.Harness, addr string, logger log.Logger) *Server {
 	if logger == nil {
 		logger = log.NopLogger{}
 	}
-	return &Server{
-		harness: h,
-		addr:    addr,
-		logger:  logger,
-		clients: make(map[*sseClient]struct{}),
+	s := &Server{
+		harness:      h,
+		addr:         addr,
+		logger:       logger,
+		clients:      make(map[*sseClient]struct{}),
+		fanout:       make(map[string]map[*sseClient]struct{}),
+		history:      newEventHistory(DefaultEventHistorySize),
+		clientBuffer: DefaultClientBufferSize,
+		stallTimeout: DefaultClientStallTimeout,
+		batches:      make(map[string]*batch),
+		experiments:  make(map[string]*experiment),
+		runs:         make(map[string]*runRecord),
+		idle:         idleState{lastActivity: time.Now()},
+		content:      newContentStore(),
+		uploads:      make(map[string]*Upload),
+		uploadDirs:   make(map[string]bool),
+		cors:         DefaultCORSConfig(),
+		questions:    make(map[string]*pendingQuestion),
+		shutdownCh:   make(chan struct{}),
 	}
+	s.ctx, s.stop = context.WithCancelCause(context.Background())
+	h.SetQuestionHandler(harness.QuestionFunc(s.ask))
+	return s
 }
 
-// ListenAndServe starts the HTTP server and blocks until it's shut down.
-func (s *Server) ListenAndServe() error {
+// Handler returns the HTTP handler with all routes and middleware registered.
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /events", s.HandleSSE)
-	mux.HandleFunc("POST /prompt", s.HandlePrompt)
+	mux.HandleFunc("GET /content/{hash}", s.HandleContent)
+	mux.HandleFunc("POST /prompt", s.rateLimit(s.HandlePrompt))
+	mux.HandleFunc("POST /prompt/stream", s.rateLimit(s.HandlePromptStream))
 	mux.HandleFunc("POST /cancel", s.HandleCancel)
+	mux.HandleFunc("POST /files", s.HandleUpload)
+	mux.HandleFunc("POST /steer", s.HandleSteer)
+	mux.HandleFunc("POST /continue", s.rateLimit(s.HandleContinue))
+	mux.HandleFunc("POST /approve", s.HandleApprove)
+	mux.HandleFunc("GET /approvals", s.HandleListApprovals)
+	mux.HandleFunc("POST /answer", s.HandleAnswer)
+	mux.HandleFunc("GET /questions", s.HandleListQuestions)
+	mux.HandleFunc("GET /conversation", s.HandleGetConversation)
+	mux.HandleFunc("POST /conversation/load", s.HandleLoadConversation)
+	mux.HandleFunc("GET /messages", s.HandleGetMessages)
+	mux.HandleFunc("GET /export", s.HandleExport)
+	mux.HandleFunc("POST /reset", s.HandleReset)
+	mux.HandleFunc("GET /checkpoints", s.HandleListCheckpoints)
+	mux.HandleFunc("POST /rollback", s.HandleRollback)
+	mux.HandleFunc("POST /batch", s.rateLimit(s.HandleBatch))
+	mux.HandleFunc("GET /batch/{id}", s.HandleBatchStatus)
+	mux.HandleFunc("POST /batch/{id}/cancel", s.HandleCancelBatch)
+	mux.HandleFunc("GET /runs/{id}", s.HandleGetRun)
+	mux.HandleFunc("POST /runs/{id}/annotations", s.HandleAnnotate)
+	mux.HandleFunc("GET /annotations/export", s.HandleExportAnnotations)
+	mux.HandleFunc("POST /experiments", s.rateLimit(s.HandleExperiment))
+	mux.HandleFunc("GET /experiments/{id}", s.HandleExperimentStatus)
+	mux.HandleFunc("POST /experiments/{id}/cancel", s.HandleCancelExperiment)
+	mux.HandleFunc("POST /workspaces", s.HandleCreateWorkspace)
+	mux.HandleFunc("GET /workspaces", s.HandleListWorkspaces)
+	mux.HandleFunc("DELETE /workspaces/{id}", s.HandleDeleteWorkspace)
+	mux.HandleFunc("GET /tools", s.HandleListTools)
+	mux.HandleFunc("GET /profiles", s.HandleListProfiles)
+	mux.HandleFunc("GET /memory", s.HandleListMemories)
+	mux.HandleFunc("DELETE /memory/{name}", s.HandleDeleteMemory)
+	mux.HandleFunc("GET /status", s.HandleStatus)
+	mux.HandleFunc("GET /metrics", s.HandleMetrics)
+	mux.HandleFunc("GET /usage", s.HandleUsage)
+	mux.HandleFunc("GET /admin/log", s.adminOnly(s.HandleGetLogSettings))
+	mux.HandleFunc("PUT /admin/log", s.adminOnly(s.HandleSetLogSettings))
+	mux.HandleFunc("GET "+OpenAPIPath, s.HandleOpenAPI)
+	mux.Handle("GET /{$}", s.webUIHandler())
+	mux.Handle("GET /ui/", s.webUIHandler())
 
-	// Add CORS headers middleware
-	handler := corsMiddleware(mux)
-
-	return http.ListenAndServe(s.addr, handler)
-}
-
-// corsMiddleware adds CORS headers to all responses.
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
+	// Add CORS headers middleware; preflight requests carry no credentials,
+	// so CORS is handled before authentication. Tracing covers both.
+	return tracingMiddleware(s.corsMiddleware(s.authMiddleware(mux)))
 }
 
 // HandlePrompt handles POST /prompt requests.
@@ -90,19 +248,70 @@ func (s *Server) HandlePrompt(w http.ResponseWriter, r *http.Request) {
 		log.F("content_length", r.ContentLength),
 	)
 
-	var req struct {
-		Content string `json:"content"`
+	runID, position, ok := s.startPrompt(w, r)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(start)
+	s.logger.Info("http", "Response sent",
+		log.F("method", r.Method),
+		log.F("path", r.URL.Path),
+		log.F("status", http.StatusOK),
+		log.F("duration_ms", duration.Milliseconds()),
+		log.F("run_id", runID),
+	)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(promptResponse{RunID: runID, QueuePosition: position})
+}
+
+// promptRequest is the body of POST /prompt and POST /prompt/stream.
+type promptRequest struct {
+	Content     string `json:"content"`
+	WorkspaceID string `json:"workspace_id,omitempty"`
+	Priority    string `json:"priority,omitempty"`
+
+	// IDs of files uploaded with POST /files to attach
+	Files []string `json:"files,omitempty"`
+
+	// Overrides for this prompt, such as the model or allowed tools
+	harness.PromptOptions
+}
+
+// promptResponse is the body of an accepted POST /prompt or POST /continue.
+type promptResponse struct {
+	RunID         string `json:"run_id"`
+	QueuePosition int    `json:"queue_position,omitempty"` // place in the prompt queue, from 1; absent when started
+}
+
+// startPrompt validates a prompt request and starts its run on the main
+// session, or queues it. It returns the run's ID and queue position, or
+// writes the error response and returns false.
+func (s *Server) startPrompt(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	var req promptRequest
+
+	if e := s.decodePromptBody(r, &req); e != nil {
+		s.logger.Warn("http", "Request validation failed",
+			log.F("method", r.Method),
+			log.F("path", r.URL.Path),
+			log.F("error", e.Message),
+		)
+		writeAPIError(w, e)
+		return "", 0, false
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	content, e := s.sanitizePrompt(req.Content)
+	if e != nil {
 		s.logger.Warn("http", "Request validation failed",
 			log.F("method", r.Method),
 			log.F("path", r.URL.Path),
-			log.F("error", "invalid request body"),
+			log.F("error", e.Message),
 		)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
-		return
+		writeAPIError(w, e)
+		return "", 0, false
 	}
+	req.Content = content
 
 	if req.Content == "" {
 		s.logger.Warn("http", "Request validation failed",
@@ -110,71 +319,321 @@ func (s *Server) HandlePrompt(w http.ResponseWriter, r *http.Request) {
 			log.F("path", r.URL.Path),
 			log.F("error", "content is required"),
 		)
-		http.Error(w, "content is required", http.StatusBadRequest)
-		return
+		writeError(w, http.StatusBadRequest, "content is required")
+		return "", 0, false
 	}
 
+	priority, err := parsePriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", 0, false
+	}
+	if s.refuseIfClosing(w) {
+		return "", 0, false
+	}
+	if err := s.harness.ValidateOptions(req.PromptOptions); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", 0, false
+	}
+
+	// Bind the session to the requested workspace. The binding is sticky:
+	// later prompts without a workspace_id run in it too. Workspaces cannot
+	// be deleted until the run is claimed, after which deletion sees it
+	s.workspaceMu.RLock()
+	defer s.workspaceMu.RUnlock()
+	if req.WorkspaceID != "" {
+		ws, ok := s.workspaces[req.WorkspaceID]
+		if !ok {
+			writeError(w, http.StatusNotFound, "workspace not found")
+			return "", 0, false
+		}
+		path := ws.Path
+		if s.harness.IsRunning() && s.harness.Workspace() != path {
+			writeError(w, http.StatusConflict, "cannot switch workspace while a prompt is running")
+			return "", 0, false
+		}
+		s.harness.SetWorkspace(path)
+	}
+
+	// List the attached files in the message
+	content, err = s.attachUploads(req.Content, req.Files, s.harness.Workspace())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", 0, false
+	}
+	req.Content = content
+
+	// Start the run on the main session, or queue it, unless it is busy
+	run := newRun(req.Content, priority)
+	active := newActiveRun(r.Context(), run, priority)
+	wait, position, claimed := s.claimActive(active)
+	if !claimed {
+		s.writeBusy(w)
+		return "", 0, false
+	}
+	s.addRun(run)
+
+	s.markActive(true)
+
 	// Log user prompt to agent log if logger is set
 	if s.userPromptLogger != nil {
-		s.userPromptLogger(req.Content)
+		s.userPromptLogger(run.run.ID, req.Content)
 	}
 
-	// Broadcast user message event before starting
-	s.broadcast(Event{Type: "user", Content: req.Content})
+	if position == 0 {
+		// Broadcast user message event before starting
+		s.broadcast(Event{Type: "user", Content: req.Content, RunID: run.run.ID})
+	}
 
 	// Run prompt asynchronously
-	// Note: We use context.Background() here because the prompt runs independently
-	// of the HTTP request lifecycle. The harness has its own Cancel() method for
-	// explicit cancellation via the /cancel endpoint.
-	go func() {
-		// Broadcast status: thinking
-		s.broadcast(Event{Type: "status", State: "thinking"})
-
-		err := s.harness.Prompt(context.Background(), req.Content)
-		if err != nil {
-			// Broadcast error status
-			s.broadcast(Event{Type: "status", State: "error", Message: err.Error()})
-		} else {
-			// Broadcast idle status
-			s.broadcast(Event{Type: "status", State: "idle"})
+	go s.executeRun(run, active, wait, position, req.Content, func(ctx context.Context) (harness.RunResult, error) {
+		return s.harness.PromptWithOptions(ctx, req.Content, req.PromptOptions)
+	})
+	return run.run.ID, position, true
+}
+
+// executeRun runs a claimed run on the main session once it is active,
+// records its outcome, and broadcasts its status and done events. content
+// is the run's user message, broadcast when a queued run starts; it is
+// empty for a continued run. execute gets a context carrying the run ID,
+// which tags the run's log lines and events.
+func (s *Server) executeRun(run *runRecord, active *activeRun, wait <-chan struct{}, position int, content string, execute func(context.Context) (harness.RunResult, error)) {
+	defer s.releaseActive(active)
+	// Let a preempted run stop first, or wait for a queued run's turn
+	if wait != nil {
+		<-wait
+	}
+	defer active.cancel()
+	if active.dropped {
+		out := runOutcome{err: ErrShuttingDown}
+		s.finishRun(run, out)
+		s.broadcastDone(run.run.ID, out)
+		return
+	}
+	runID := run.run.ID
+	if err := active.ctx.Err(); err != nil {
+		// Cancelled while queued or waiting for a preempted run
+		out := runOutcome{err: err}
+		s.finishRun(run, out)
+		s.broadcast(Event{Type: "status", State: runCancelled, RunID: runID})
+		s.broadcastDone(runID, out)
+		return
+	}
+	if position > 0 && content != "" {
+		s.broadcast(Event{Type: "user", Content: content, RunID: runID})
+	}
+
+	// Broadcast status: thinking
+	s.broadcast(Event{Type: "status", State: "thinking", RunID: runID})
+
+	before := len(s.harness.Messages())
+	// Note: The run's context is not the HTTP request's, because the prompt
+	// runs independently of the request lifecycle. POST /cancel cancels it
+	// by run ID, or through the harness's Cancel() method.
+	result, err := execute(log.WithRunID(active.ctx, runID))
+
+	// Record what this run added to the conversation
+	out := runOutcome{err: err}
+	if !errors.Is(err, harness.ErrPromptInProgress) {
+		if msgs := s.harness.Messages(); len(msgs) >= before {
+			out.messages = msgs[before:]
 		}
-	}()
+		out.result = result
+		out.tempDir = s.harness.KeptTempDir()
+	}
+	s.finishRun(run, out)
 
-	duration := time.Since(start)
-	s.logger.Info("http", "Response sent",
-		log.F("method", r.Method),
-		log.F("path", r.URL.Path),
-		log.F("status", http.StatusOK),
-		log.F("duration_ms", duration.Milliseconds()),
-	)
-	w.WriteHeader(http.StatusOK)
+	switch {
+	case errors.Is(err, harness.ErrPreempted):
+		// The preempting run reports its own status
+	case errors.Is(err, context.Canceled):
+		// Cancelled runs are told apart from failures, with the tool call
+		// they interrupted, if any
+		s.broadcast(Event{
+			Type:    "status",
+			State:   runCancelled,
+			Message: err.Error(),
+			ID:      result.InterruptedToolID,
+			Name:    result.InterruptedTool,
+			RunID:   runID,
+		})
+	case errors.Is(err, harness.ErrMaxTurnsExceeded):
+		// POST /continue resumes the run
+		s.broadcast(Event{Type: "status", State: "max_turns_exceeded", Message: err.Error(), RunID: runID})
+	case errors.Is(err, harness.ErrBudgetExceeded):
+		s.broadcast(Event{Type: "status", State: "budget_exceeded", Message: err.Error(), RunID: runID})
+	case err != nil:
+		// Broadcast error status
+		s.broadcast(Event{Type: "status", State: "error", Message: err.Error(), RunID: runID})
+	default:
+		// Broadcast idle status
+		s.broadcast(Event{Type: "status", State: "idle", RunID: runID})
+	}
+
+	// The done event is always the last event of a run
+	s.broadcastDone(run.run.ID, out)
 }
 
-// HandleCancel handles POST /cancel requests.
+// HandleContinue handles POST /continue requests, resuming a run that
+// stopped at its turn limit as a new run.
+func (s *Server) HandleContinue(w http.ResponseWriter, r *http.Request) {
+	if s.refuseIfClosing(w) {
+		return
+	}
+	if s.harness.IsRunning() {
+		s.writeBusy(w)
+		return
+	}
+	if !s.harness.CanContinue() {
+		writeErr(w, harness.ErrNothingToContinue, http.StatusConflict)
+		return
+	}
+
+	run := newRun("", priorityNormal)
+	active := newActiveRun(r.Context(), run, priorityNormal)
+	wait, position, claimed := s.claimActive(active)
+	if !claimed {
+		s.writeBusy(w)
+		return
+	}
+	s.addRun(run)
+	s.markActive(true)
+	s.logger.Info("http", "Run continued", log.F("run_id", run.run.ID))
+
+	go s.executeRun(run, active, wait, position, "", func(ctx context.Context) (harness.RunResult, error) {
+		return s.harness.Continue(ctx)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promptResponse{RunID: run.run.ID, QueuePosition: position})
+}
+
+// cancelRequest is the optional body of POST /cancel.
+type cancelRequest struct {
+	RunID string `json:"run_id,omitempty"`
+}
+
+// HandleCancel handles POST /cancel requests. With a run_id it cancels that
+// run, whether running or queued, and returns 404 if it is neither; without
+// one it cancels whatever is running on the main session.
 func (s *Server) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	var req cancelRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
 	s.logger.Info("http", "Cancel requested",
 		log.F("method", r.Method),
 		log.F("path", r.URL.Path),
+		log.F("run_id", req.RunID),
 	)
-	s.harness.Cancel()
+
+	if req.RunID == "" {
+		s.harness.Cancel()
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if !s.cancelRun(req.RunID) {
+		if _, ok := s.run(req.RunID); ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("run %s is not running or queued", req.RunID))
+		} else {
+			writeError(w, http.StatusNotFound, "run not found")
+		}
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
-// addClient registers a new SSE client and returns it.
+// steerRequest is the body of POST /steer.
+type steerRequest struct {
+	Content string `json:"content"`
+}
+
+// HandleSteer handles POST /steer requests, adding a user message to the
+// running prompt before its next API request.
+func (s *Server) HandleSteer(w http.ResponseWriter, r *http.Request) {
+	var req steerRequest
+	if e := s.decodePromptBody(r, &req); e != nil {
+		writeAPIError(w, e)
+		return
+	}
+	content, e := s.sanitizePrompt(req.Content)
+	if e != nil {
+		writeAPIError(w, e)
+		return
+	}
+	req.Content = content
+	if req.Content == "" {
+		writeError(w, http.StatusBadRequest, "content is required")
+		return
+	}
+
+	if err := s.harness.Inject(r.Context(), req.Content); err != nil {
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+	s.markActive(false)
+	if s.userPromptLogger != nil {
+		s.userPromptLogger(s.harness.RunID(), req.Content)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// addClient registers a new SSE client that receives every session's events.
 func (s *Server) addClient(remoteAddr string) *sseClient {
+	return s.addSessionClient(remoteAddr, "")
+}
+
+// addSessionClient registers a new SSE client following session ("" for all
+// sessions) and returns it.
+func (s *Server) addSessionClient(remoteAddr string, session string) *sseClient {
+	client, _, _ := s.connectClient(remoteAddr, session, false, nil)
+	return client
+}
+
+// connectClient registers an SSE client. If lastEventID is non-nil, it also
+// returns the retained events after it and the number no longer retained;
+// both are taken atomically with registration, so the client sees every
+// later event exactly once.
+func (s *Server) connectClient(remoteAddr string, session string, dedup bool, lastEventID *uint64) (*sseClient, []historyEntry, uint64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.nextID++
 	client := &sseClient{
-		id:     s.nextID,
-		events: make(chan []byte, 100), // Buffer to prevent blocking
+		id:      s.nextID,
+		events:  make(chan sseMessage, s.clientBuffer),
+		dedup:   dedup,
+		session: session,
+		evict:   make(chan struct{}),
 	}
 	s.clients[client] = struct{}{}
+	if s.fanout[session] == nil {
+		s.fanout[session] = make(map[*sseClient]struct{})
+	}
+	s.fanout[session][client] = struct{}{}
 	s.logger.Info("sse", "Client connected",
 		log.F("client_id", client.id),
 		log.F("remote_addr", remoteAddr),
+		log.F("session", session),
 	)
-	return client
+	if lastEventID == nil {
+		return client, nil, 0
+	}
+	replay, missed := s.history.since(*lastEventID, session)
+	return client, replay, missed
+}
+
+// SetEventHistorySize sets how many recent events are kept for replay
+// (DefaultEventHistorySize by default; 0 disables replay). Retained events
+// are discarded; event IDs keep increasing.
+func (s *Server) SetEventHistorySize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	history := newEventHistory(size)
+	history.lastID = s.history.lastID
+	s.history = history
 }
 
 // removeClient unregisters an SSE client.
@@ -182,7 +641,12 @@ func (s *Server) removeClient(client *sseClient, duration time.Duration) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.clients, client)
+	delete(s.fanout[client.session], client)
+	if len(s.fanout[client.session]) == 0 {
+		delete(s.fanout, client.session)
+	}
 	close(client.events)
+	s.touchActivity()
 	s.logger.Info("sse", "Client disconnected",
 		log.F("client_id", client.id),
 		log.F("duration_s", int(duration.Seconds())),
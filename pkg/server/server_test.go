@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -89,6 +92,35 @@ func TestServer_HandlePrompt_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestServer_HandlePrompt_InvalidOptions(t *testing.T) {
+	h := createTestHarness(t)
+	s := NewServer(h, ":8080", nil)
+
+	body := bytes.NewBufferString(`{"content":"hi","temperature":1.5}`)
+	req := httptest.NewRequest("POST", "/prompt", body)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	s.HandlePrompt(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "temperature must be between 0 and 1") {
+		t.Errorf("unexpected body: %s", rec.Body.String())
+	}
+
+	body = bytes.NewBufferString(`{"content":"hi","tools":["deploy"]}`)
+	req = httptest.NewRequest("POST", "/prompt", body)
+	rec = httptest.NewRecorder()
+
+	s.HandlePrompt(rec, req)
+
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `unknown tool \"deploy\"`) {
+		t.Errorf("expected 400 for an unknown tool, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
 func TestServer_HandleCancel(t *testing.T) {
 	h := createTestHarness(t)
 	s := NewServer(h, ":8080", nil)
@@ -103,6 +135,28 @@ func TestServer_HandleCancel(t *testing.T) {
 	}
 }
 
+func TestServer_HandleSteer(t *testing.T) {
+	h := createTestHarness(t)
+	s := NewServer(h, ":8080", nil)
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"content":""}`, http.StatusBadRequest},
+		{`invalid json`, http.StatusBadRequest},
+		{`{"content":"use Rust"}`, http.StatusConflict}, // no prompt running
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/steer", bytes.NewBufferString(tt.body))
+		rec := httptest.NewRecorder()
+		s.HandleSteer(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.status, rec.Code)
+		}
+	}
+}
+
 func TestServer_SSEClientManagement(t *testing.T) {
 	h := createTestHarness(t)
 	s := NewServer(h, ":8080", nil)
@@ -161,7 +215,8 @@ func TestServer_Broadcast(t *testing.T) {
 
 	// Wait for event
 	select {
-	case data := <-client.events:
+	case msg := <-client.events:
+		data := msg.data
 		var received Event
 		if err := json.Unmarshal(data, &received); err != nil {
 			t.Fatalf("failed to unmarshal event: %v", err)
@@ -197,7 +252,8 @@ func TestServer_BroadcastToMultipleClients(t *testing.T) {
 	// Both clients should receive the event
 	for i, client := range []*sseClient{client1, client2} {
 		select {
-		case data := <-client.events:
+		case msg := <-client.events:
+			data := msg.data
 			var received Event
 			if err := json.Unmarshal(data, &received); err != nil {
 				t.Fatalf("client %d: failed to unmarshal event: %v", i+1, err)
@@ -211,6 +267,54 @@ func TestServer_BroadcastToMultipleClients(t *testing.T) {
 	}
 }
 
+func TestServer_BroadcastPerSession(t *testing.T) {
+	h := createTestHarness(t)
+	s := NewServer(h, ":8080", nil)
+
+	all := s.addClient("test:1234")
+	main := s.addSessionClient("test:1234", MainSession)
+	variant := s.addSessionClient("test:1234", "exp_1/a")
+	defer s.removeClient(all, 0)
+	defer s.removeClient(main, 0)
+
+	s.broadcast(Event{Type: "text", Content: "from main"})
+	s.broadcast(Event{Type: "text", Content: "from variant", Session: "exp_1/a"})
+	s.broadcast(Event{Type: "text", Content: "from other", Session: "exp_1/b"})
+
+	received := func(client *sseClient) []string {
+		var contents []string
+		for {
+			select {
+			case msg := <-client.events:
+				data := msg.data
+				var e Event
+				json.Unmarshal(data, &e)
+				contents = append(contents, e.Content)
+			default:
+				return contents
+			}
+		}
+	}
+	if got := received(all); len(got) != 3 {
+		t.Errorf("unfiltered client: expected 3 events, got %q", got)
+	}
+	if got := received(main); len(got) != 1 || got[0] != "from main" {
+		t.Errorf("main client: expected only the main event, got %q", got)
+	}
+	if got := received(variant); len(got) != 1 || got[0] != "from variant" {
+		t.Errorf("variant client: expected only its event, got %q", got)
+	}
+
+	// Removing the last client of a session drops its fan-out entry
+	s.removeClient(variant, 0)
+	s.mu.RLock()
+	_, ok := s.fanout["exp_1/a"]
+	s.mu.RUnlock()
+	if ok {
+		t.Error("expected the empty session to be removed")
+	}
+}
+
 func TestSSEEventHandler(t *testing.T) {
 	h := createTestHarness(t)
 	s := NewServer(h, ":8080", nil)
@@ -224,7 +328,8 @@ func TestSSEEventHandler(t *testing.T) {
 	handler.OnText("test text")
 
 	select {
-	case data := <-client.events:
+	case msg := <-client.events:
+		data := msg.data
 		var event Event
 		json.Unmarshal(data, &event)
 		if event.Type != "text" {
@@ -242,7 +347,8 @@ func TestSSEEventHandler(t *testing.T) {
 
 	// First should be status event
 	select {
-	case data := <-client.events:
+	case msg := <-client.events:
+		data := msg.data
 		var event Event
 		json.Unmarshal(data, &event)
 		if event.Type != "status" {
@@ -257,7 +363,8 @@ func TestSSEEventHandler(t *testing.T) {
 
 	// Then tool_call event
 	select {
-	case data := <-client.events:
+	case msg := <-client.events:
+		data := msg.data
 		var event Event
 		json.Unmarshal(data, &event)
 		if event.Type != "tool_call" {
@@ -278,7 +385,8 @@ func TestSSEEventHandler(t *testing.T) {
 
 	// First should be tool_result event
 	select {
-	case data := <-client.events:
+	case msg := <-client.events:
+		data := msg.data
 		var event Event
 		json.Unmarshal(data, &event)
 		if event.Type != "tool_result" {
@@ -299,7 +407,8 @@ func TestSSEEventHandler(t *testing.T) {
 
 	// Then status back to thinking
 	select {
-	case data := <-client.events:
+	case msg := <-client.events:
+		data := msg.data
 		var event Event
 		json.Unmarshal(data, &event)
 		if event.Type != "status" {
@@ -313,6 +422,42 @@ func TestSSEEventHandler(t *testing.T) {
 	}
 }
 
+func TestSSEEventHandler_ServerTools(t *testing.T) {
+	h := createTestHarness(t)
+	s := NewServer(h, ":8080", nil)
+	handler, ok := s.EventHandler().(harness.ServerToolHandler)
+	if !ok {
+		t.Fatal("SSE event handler should implement harness.ServerToolHandler")
+	}
+
+	client := s.addClient("test:1234")
+	defer s.removeClient(client, 0)
+
+	handler.OnServerToolUse("srvtoolu_1", "web_search", json.RawMessage(`{"query":"go"}`))
+	handler.OnServerToolResult("srvtoolu_1", "web_search_tool_result", json.RawMessage(`[]`))
+
+	expected := []struct{ Type, ID string }{
+		{"server_tool", "srvtoolu_1"},
+		{"server_tool_result", "srvtoolu_1"},
+	}
+	for _, want := range expected {
+		select {
+		case msg := <-client.events:
+			data := msg.data
+			var event Event
+			json.Unmarshal(data, &event)
+			if event.Type != want.Type {
+				t.Errorf("expected type %q, got %q", want.Type, event.Type)
+			}
+			if event.ID != want.ID {
+				t.Errorf("expected ID %q, got %q", want.ID, event.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %s event", want.Type)
+		}
+	}
+}
+
 func TestServer_HandleSSE(t *testing.T) {
 	h := createTestHarness(t)
 	s := NewServer(h, ":8080", nil)
@@ -363,7 +508,7 @@ func TestCORSMiddleware(t *testing.T) {
 	s := NewServer(h, ":8080", nil)
 
 	// Create test handler
-	handler := corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -384,7 +529,67 @@ func TestCORSMiddleware(t *testing.T) {
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status 200 for OPTIONS, got %d", rec.Code)
 	}
+}
+
+func TestServer_PruneBatches(t *testing.T) {
+	s := NewServer(createTestHarness(t), ":0", nil)
 
-	// Just use s to avoid unused variable warning
-	_ = s
+	now := time.Now()
+	add := func(id string, finished time.Time) {
+		s.batches[id] = &batch{status: BatchStatus{ID: id}, finished: finished}
+	}
+	add("running", time.Time{})
+	add("expired", now.Add(-batchRetention-time.Minute))
+	for i := 0; i <= maxFinishedBatches; i++ {
+		add(fmt.Sprintf("finished_%d", i), now.Add(-time.Duration(maxFinishedBatches-i)*time.Second))
+	}
+
+	s.pruneBatches(now)
+	if len(s.batches) != maxFinishedBatches+1 {
+		t.Errorf("expected %d batches kept, got %d", maxFinishedBatches+1, len(s.batches))
+	}
+	for _, id := range []string{"expired", "finished_0"} {
+		if _, ok := s.batches[id]; ok {
+			t.Errorf("expected %s to be pruned", id)
+		}
+	}
+	for _, id := range []string{"running", "finished_1", fmt.Sprintf("finished_%d", maxFinishedBatches)} {
+		if _, ok := s.batches[id]; !ok {
+			t.Errorf("expected %s to be kept", id)
+		}
+	}
+}
+
+func TestServer_PruneExperiments(t *testing.T) {
+	s := NewServer(createTestHarness(t), ":0", nil)
+
+	now := time.Now()
+	add := func(id string, finished time.Time) {
+		s.experiments[id] = &experiment{status: ExperimentStatus{ID: id}, dir: filepath.Join(t.TempDir(), id), finished: finished}
+		os.Mkdir(s.experiments[id].dir, 0755)
+	}
+	add("running", time.Time{})
+	add("expired", now.Add(-experimentRetention-time.Minute))
+	for i := 0; i <= maxFinishedExperiments; i++ {
+		add(fmt.Sprintf("finished_%d", i), now.Add(-time.Duration(maxFinishedExperiments-i)*time.Second))
+	}
+	expired, oldest := s.experiments["expired"], s.experiments["finished_0"]
+
+	removeExperimentDirs(s.pruneExperiments(now))
+	if len(s.experiments) != maxFinishedExperiments+1 {
+		t.Errorf("expected %d experiments kept, got %d", maxFinishedExperiments+1, len(s.experiments))
+	}
+	for _, e := range []*experiment{expired, oldest} {
+		if _, ok := s.experiments[e.status.ID]; ok {
+			t.Errorf("expected %s to be pruned", e.status.ID)
+		}
+		if _, err := os.Stat(e.dir); !os.IsNotExist(err) {
+			t.Errorf("expected the workspaces of %s to be removed", e.status.ID)
+		}
+	}
+	for _, id := range []string{"running", "finished_1", fmt.Sprintf("finished_%d", maxFinishedExperiments)} {
+		if _, ok := s.experiments[id]; !ok {
+			t.Errorf("expected %s to be kept", id)
+		}
+	}
 }
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// ErrShuttingDown is the error of queued runs dropped by Shutdown. Requests
+// that would start work during shutdown get 503 Service Unavailable.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// shutdownCancelGrace bounds the wait for a run to stop after Shutdown
+// cancels it.
+const shutdownCancelGrace = 5 * time.Second
+
+// ListenAndServe starts the HTTP server and blocks until it's shut down.
+// It returns nil after Shutdown.
+func (s *Server) ListenAndServe() error {
+	srv := &http.Server{Addr: s.addr, Handler: s.Handler()}
+	s.shutdownMu.Lock()
+	if s.closing {
+		s.shutdownMu.Unlock()
+		return ErrShuttingDown
+	}
+	s.httpServer = srv
+	s.shutdownMu.Unlock()
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops the server gracefully:
+//   - new prompts, batches, and experiments are refused with 503
+//   - queued prompts are dropped and finish as cancelled
+//   - the running prompt, batches, and experiments may finish until ctx is
+//     done, and are then cancelled
+//   - SSE clients receive a server_shutdown event and their streams end
+//   - the HTTP server stops, and tools release their resources
+//
+// It returns ctx's error if the running prompt, a batch, or an experiment
+// had to be cancelled or the HTTP server did not stop in time. Shutdown may only be called once.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownMu.Lock()
+	s.closing = true
+	srv := s.httpServer
+	s.shutdownMu.Unlock()
+	s.logger.Info("http", "Shutdown started")
+
+	// Drop queued runs; their goroutines record them as cancelled
+	s.activeMu.Lock()
+	active := s.active
+	for _, run := range s.queue {
+		run.dropped = true
+		close(run.ready)
+	}
+	s.queue = nil
+	s.activeMu.Unlock()
+
+	var err error
+	if active != nil {
+		select {
+		case <-active.done:
+		case <-ctx.Done():
+			err = ctx.Err()
+			s.logger.Warn("http", "Cancelling the running prompt for shutdown",
+				log.F("run_id", active.rec.run.ID),
+			)
+			s.harness.Cancel()
+			select {
+			case <-active.done:
+			case <-time.After(shutdownCancelGrace):
+				s.logger.Warn("http", "Running prompt did not stop",
+					log.F("run_id", active.rec.run.ID),
+				)
+			}
+		}
+	}
+
+	var running []<-chan struct{}
+	batches, experiments := s.runningBatches(), s.runningExperiments()
+	for _, b := range batches {
+		running = append(running, b.done)
+	}
+	for _, e := range experiments {
+		running = append(running, e.done)
+	}
+	if len(running) > 0 && !waitDone(ctx, running) {
+		err = ctx.Err()
+		s.logger.Warn("http", "Cancelling running batches and experiments for shutdown",
+			log.F("batches", len(batches)),
+			log.F("experiments", len(experiments)),
+		)
+		s.stop(ErrShuttingDown)
+		grace, cancel := context.WithTimeout(context.Background(), shutdownCancelGrace)
+		if !waitDone(grace, running) {
+			s.logger.Warn("http", "Running batches and experiments did not stop")
+		}
+		cancel()
+	}
+	s.stop(ErrShuttingDown)
+
+	// Flush SSE clients, then end their streams
+	s.broadcast(Event{Type: "server_shutdown", Message: ErrShuttingDown.Error()})
+	close(s.shutdownCh)
+
+	if srv != nil {
+		if shutdownErr := srv.Shutdown(ctx); shutdownErr != nil {
+			srv.Close()
+			if err == nil {
+				err = shutdownErr
+			}
+		}
+	}
+	s.harness.ReleaseResources()
+	s.removeUploads()
+	s.removeExperiments()
+	s.logger.Info("http", "Shutdown completed")
+	return err
+}
+
+// waitDone waits until the done channels of batches or experiments are
+// closed, and reports whether they were before ctx was done.
+func waitDone(ctx context.Context, done []<-chan struct{}) bool {
+	for _, d := range done {
+		select {
+		case <-d:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// isClosing reports whether Shutdown has been called.
+func (s *Server) isClosing() bool {
+	s.shutdownMu.Lock()
+	defer s.shutdownMu.Unlock()
+	return s.closing
+}
+
+// refuseIfClosing responds 503 during shutdown and reports whether it did.
+func (s *Server) refuseIfClosing(w http.ResponseWriter) bool {
+	if !s.isClosing() {
+		return false
+	}
+	writeErr(w, ErrShuttingDown, http.StatusServiceUnavailable)
+	return true
+}
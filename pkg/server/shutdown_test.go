@@ -0,0 +1,154 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestShutdown_CancelsRunningPrompt(t *testing.T) {
+	started := make(chan struct{})
+	blocking := &MockTool{
+		name: "blocking",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "blocking", map[string]string{}))
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{blocking}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	s.SetPromptQueueSize(1)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	types := make(chan []string, 1)
+	go func() {
+		var got []string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var e server.Event
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok && json.Unmarshal([]byte(data), &e) == nil {
+				got = append(got, e.Type)
+			}
+		}
+		types <- got // the stream ended
+	}()
+
+	runningID := promptRunID(t, ts.URL, `{"content":"block"}`)
+	<-started
+	queuedID := promptRunID(t, ts.URL, `{"content":"later"}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to cancel the prompt, got %v", err)
+	}
+
+	for _, id := range []string{runningID, queuedID} {
+		if run := waitForRun(t, ts.URL, id); run.Status != "cancelled" {
+			t.Errorf("expected run %s cancelled, got %q", id, run.Status)
+		}
+	}
+	select {
+	case got := <-types:
+		if len(got) == 0 || got[len(got)-1] != "server_shutdown" {
+			t.Errorf("expected server_shutdown as the last event, got %v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("SSE stream did not end")
+	}
+
+	resp = postJSON(t, ts.URL+"/prompt", `{"content":"too late"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 after shutdown, got %d", resp.StatusCode)
+	}
+}
+
+func TestShutdown_WaitsForRunningPrompt(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	slow := &MockTool{
+		name: "slow",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			close(started)
+			<-release
+			return `{"ok":true}`, nil
+		},
+	}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "slow", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{slow}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	runID := promptRunID(t, ts.URL, `{"content":"work"}`)
+	<-started
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if run := waitForRun(t, ts.URL, runID); run.Status != "completed" {
+		t.Errorf("expected the prompt to complete, got %q", run.Status)
+	}
+}
+
+func TestShutdown_CancelsRunningBatch(t *testing.T) {
+	h, started := blockingBatchHarness(t)
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	id := submitBatch(t, ts.URL, `{"prompts":["block","skipped"]}`)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to cancel the batch, got %v", err)
+	}
+
+	// Shutdown returns once the batch has stopped
+	resp, err := http.Get(ts.URL + "/batch/" + id)
+	if err != nil {
+		t.Fatalf("GET /batch failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var status server.BatchStatus
+	json.NewDecoder(resp.Body).Decode(&status)
+	if status.Status != "cancelled" {
+		t.Errorf("expected the batch cancelled, got %q", status.Status)
+	}
+	for _, item := range status.Items {
+		if item.Status != "cancelled" || item.Error != server.ErrShuttingDown.Error() {
+			t.Errorf("item %d: expected cancelled by shutdown, got %q (%s)", item.Index, item.Status, item.Error)
+		}
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/user/harness/pkg/log"
+	"github.com/user/harness/pkg/harness"
 )
 
 // Event represents a server-sent event.
@@ -14,21 +15,89 @@ type Event struct {
 	Type      string `json:"type"`
 	Timestamp int64  `json:"timestamp,omitempty"`
 
-	// For user/text/reasoning events
+	// For user/steer/text/reasoning/content_block_delta/compaction/tool_output events
 	Content string `json:"content,omitempty"`
 
-	// For tool_call events
+	// For content_block_delta events: the content block being streamed
+	Index *int `json:"index,omitempty"`
+
+	// For tool_call, server_tool, and approval events
 	ID    string          `json:"id,omitempty"`
 	Name  string          `json:"name,omitempty"`
 	Input json.RawMessage `json:"input,omitempty"`
 
-	// For tool_result events
+	// For tool_result and server_tool_result events
 	Result  string `json:"result,omitempty"`
 	IsError bool   `json:"isError,omitempty"`
 
 	// For status events
 	State   string `json:"state,omitempty"`
 	Message string `json:"message,omitempty"`
+
+	// For question events: the suggested answers; the question is sent in
+	// content
+	Choices []string `json:"choices,omitempty"`
+
+	// For queued status events: the run's place in the prompt queue, from 1
+	Position int `json:"position,omitempty"`
+
+	// For tool_event events (custom events emitted by tools)
+	Event string          `json:"event,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+
+	// For tool_output events: "stdout" or "stderr"; the text is sent in content
+	Stream string `json:"stream,omitempty"`
+
+	// For tool_display events
+	Display *DisplayHint `json:"display,omitempty"`
+
+	// Run the event belongs to: set on every event of a prompt on the main
+	// session, and on run lifecycle events (queued, preempted, done)
+	RunID string `json:"run_id,omitempty"`
+
+	// For done events; the final text is sent in content. Rollback events
+	// carry the turns undone
+	StopReason string         `json:"stop_reason,omitempty"`
+	Turns      int            `json:"turns,omitempty"`
+	Usage      *harness.Usage `json:"usage,omitempty"`
+	DurationMs int64          `json:"duration_ms,omitempty"`
+	// NeedsReview flags a done event's run for human review: it entered
+	// fail-safe mode
+	NeedsReview bool `json:"needs_review,omitempty"`
+
+	// For usage events, with the turn's usage in usage
+	RunUsage     *harness.Usage         `json:"run_usage,omitempty"`
+	SessionUsage *harness.Usage         `json:"session_usage,omitempty"`
+	Context      *harness.ContextBudget `json:"context,omitempty"`
+
+	// For model_changed events: the fallback model now used and the model
+	// it replaced; the error that caused the switch is sent in message
+	Model         string `json:"model,omitempty"`
+	PreviousModel string `json:"previous_model,omitempty"`
+
+	// For plan events: the complete task list, empty after a reset
+	Plan json.RawMessage `json:"plan,omitempty"`
+
+	// For dropped_events events: events not delivered to this client
+	Dropped int `json:"dropped,omitempty"`
+
+	// Session that produced the event; empty for the main session
+	Session string `json:"session,omitempty"`
+
+	// For events from experiment variant sessions
+	Experiment string `json:"experiment,omitempty"`
+	Variant    string `json:"variant,omitempty"`
+
+	// For deduplicating clients: content hashes of large bodies by field
+	// name ("content", "result", "input"). A referenced field that is empty
+	// was sent earlier and must be resolved by hash.
+	Refs map[string]string `json:"refs,omitempty"`
+}
+
+// DisplayHint is a client-facing rendering hint for a tool result.
+type DisplayHint struct {
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
 }
 
 // HandleSSE handles GET /events SSE connections.
@@ -42,19 +111,33 @@ func (s *Server) HandleSSE(w http.ResponseWriter, r *http.Request) {
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "SSE not supported", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "SSE not supported")
 		return
 	}
 
-	// Register this client
-	client := s.addClient(r.RemoteAddr)
+	// Register this client, replaying missed events when resuming
+	query := r.URL.Query()
+	dedup := query.Get("dedup") == "1"
+	client, replay, missed := s.connectClient(r.RemoteAddr, query.Get("session"), dedup, lastEventID(r))
 	defer func() {
 		s.removeClient(client, time.Since(start))
 	}()
+	s.markActive(false)
 
 	// Send initial connection comment to establish the stream
 	// This allows HTTP clients to know the connection is established
 	fmt.Fprintf(w, ": connected\n\n")
+	if missed > 0 {
+		gap, _ := json.Marshal(Event{
+			Type:      "history_gap",
+			Timestamp: time.Now().Unix(),
+			Message:   fmt.Sprintf("%d events are no longer available", missed),
+		})
+		writeSSE(w, sseMessage{data: gap})
+	}
+	for _, entry := range replay {
+		writeSSE(w, sseMessage{id: entry.id, data: entry.payload(dedup)})
+	}
 	flusher.Flush()
 
 	// Heartbeat ticker - 30 seconds
@@ -63,41 +146,99 @@ func (s *Server) HandleSSE(w http.ResponseWriter, r *http.Request) {
 
 	for {
 		select {
-		case event, ok := <-client.events:
+		case msg, ok := <-client.events:
 			if !ok {
 				return // Channel closed
 			}
-			fmt.Fprintf(w, "data: %s\n\n", event)
+			writeSSE(w, msg)
 			flusher.Flush()
 		case <-heartbeat.C:
 			fmt.Fprintf(w, ": heartbeat\n\n")
 			flusher.Flush()
 		case <-r.Context().Done():
 			return
+		case <-client.evict:
+			return // Stalled too long; see send
+		case <-s.shutdownCh:
+			// Deliver what is queued, ending with server_shutdown
+			for {
+				select {
+				case msg := <-client.events:
+					writeSSE(w, msg)
+				default:
+					flusher.Flush()
+					return
+				}
+			}
 		}
 	}
 }
 
-// broadcast sends an event to all connected SSE clients.
+// sseMessage is an encoded event queued for a client, with its event ID.
+type sseMessage struct {
+	id   uint64 // 0 for events that are not part of the history
+	data []byte
+}
+
+// writeSSE writes one event in text/event-stream format.
+func writeSSE(w http.ResponseWriter, msg sseMessage) {
+	if msg.id > 0 {
+		fmt.Fprintf(w, "id: %d\n", msg.id)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", msg.data)
+}
+
+// lastEventID returns the event ID a reconnecting client last received, from
+// the Last-Event-ID header or the last_event_id query parameter, or nil for
+// a fresh connection. last_event_id=0 requests all retained events.
+func lastEventID(r *http.Request) *uint64 {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// MainSession is the session ID of the server's own harness. Clients pass it
+// as GET /events?session=main to receive only main-session events.
+const MainSession = "main"
+
+// broadcast sends an event to the clients following its session and to
+// clients following all sessions.
 func (s *Server) broadcast(event Event) {
 	event.Timestamp = time.Now().Unix()
 	data, err := json.Marshal(event)
 	if err != nil {
 		return
 	}
+	// Always intern large bodies so later references resolve, even for
+	// clients that connect after the body was first sent.
+	dedupData, err := json.Marshal(s.content.dedupe(event))
+	if err != nil {
+		dedupData = data
+	}
 
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	for client := range s.clients {
-		select {
-		case client.events <- data:
-		default:
-			// Client buffer full, skip (non-blocking)
-			s.logger.Warn("sse", "Event dropped - client buffer full",
-				log.F("client_id", client.id),
-				log.F("event_type", event.Type),
-			)
-		}
+	session := event.Session
+	if session == "" {
+		session = MainSession
+	}
+
+	// Hold the write lock so events get IDs and reach clients in one order
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.history.add(session, data, dedupData)
+	for client := range s.fanout[""] {
+		s.send(client, event.Type, sseMessage{id: id, data: data}, sseMessage{id: id, data: dedupData})
+	}
+	for client := range s.fanout[session] {
+		s.send(client, event.Type, sseMessage{id: id, data: data}, sseMessage{id: id, data: dedupData})
 	}
 }
 
@@ -106,26 +247,148 @@ type sseEventHandler struct {
 	server *Server
 }
 
+// broadcast sends an event of the main session, tagged with the ID of the
+// running prompt.
+func (h *sseEventHandler) broadcast(event Event) {
+	if event.RunID == "" {
+		event.RunID = h.server.harness.RunID()
+	}
+	h.server.broadcast(event)
+}
+
 // OnText broadcasts a text event.
 func (h *sseEventHandler) OnText(text string) {
-	h.server.broadcast(Event{Type: "text", Content: text})
+	h.broadcast(Event{Type: "text", Content: text})
+}
+
+// OnTextDelta broadcasts a content_block_delta event with a streamed text fragment.
+func (h *sseEventHandler) OnTextDelta(blockIndex int, delta string) {
+	h.broadcast(Event{Type: "content_block_delta", Index: &blockIndex, Content: delta})
+}
+
+// OnRetry broadcasts status: retrying with the attempt, delay, and error.
+func (h *sseEventHandler) OnRetry(attempt int, delay time.Duration, err error) {
+	h.broadcast(Event{
+		Type:    "status",
+		State:   "retrying",
+		Message: fmt.Sprintf("attempt %d in %s: %v", attempt, delay.Round(time.Millisecond), err),
+	})
+}
+
+// OnStreamReset broadcasts a stream_reset event: clients drop the deltas and
+// blocks received since the last tool result, which the turn streams again.
+func (h *sseEventHandler) OnStreamReset(err error) {
+	h.broadcast(Event{Type: "stream_reset", Message: err.Error()})
+}
+
+// OnModelChanged broadcasts a model_changed event when the run switches to
+// a fallback model.
+func (h *sseEventHandler) OnModelChanged(from, to string, err error) {
+	h.broadcast(Event{
+		Type:          "model_changed",
+		Model:         to,
+		PreviousModel: from,
+		Message:       err.Error(),
+	})
+}
+
+// OnCompaction broadcasts a compaction event with the summary that replaced
+// older turns.
+func (h *sseEventHandler) OnCompaction(compacted int, summary string) {
+	h.broadcast(Event{
+		Type:    "compaction",
+		Content: summary,
+		Message: fmt.Sprintf("%d messages compacted", compacted),
+	})
+}
+
+// OnUsage broadcasts a usage event with the turn's usage and the prompt and
+// session totals.
+func (h *sseEventHandler) OnUsage(usage json.RawMessage) {
+	var report harness.UsageReport
+	if err := json.Unmarshal(usage, &report); err != nil {
+		return
+	}
+	h.broadcast(Event{
+		Type:         "usage",
+		Usage:        &report.Turn,
+		RunUsage:     &report.Run,
+		SessionUsage: &report.Session,
+		Context:      &report.Context,
+	})
+}
+
+// OnPlan broadcasts a plan event with the agent's task list.
+func (h *sseEventHandler) OnPlan(plan json.RawMessage) {
+	h.broadcast(Event{Type: "plan", Plan: plan})
+}
+
+// OnSteering broadcasts a steer event when an injected user message enters
+// the conversation.
+func (h *sseEventHandler) OnSteering(content string) {
+	h.broadcast(Event{Type: "steer", Content: content})
 }
 
 // OnToolCall broadcasts a tool_call event.
 func (h *sseEventHandler) OnToolCall(id string, name string, input json.RawMessage) {
 	// Broadcast status: running_tool
-	h.server.broadcast(Event{Type: "status", State: "running_tool", Message: name})
-	h.server.broadcast(Event{Type: "tool_call", ID: id, Name: name, Input: input})
+	h.broadcast(Event{Type: "status", State: "running_tool", Message: name})
+	h.broadcast(Event{Type: "tool_call", ID: id, Name: name, Input: input})
 }
 
 // OnToolResult broadcasts a tool_result event.
 func (h *sseEventHandler) OnToolResult(id string, result string, isError bool) {
-	h.server.broadcast(Event{Type: "tool_result", ID: id, Result: result, IsError: isError})
+	h.broadcast(Event{Type: "tool_result", ID: id, Result: result, IsError: isError})
 	// Set status back to thinking after tool result
-	h.server.broadcast(Event{Type: "status", State: "thinking"})
+	h.broadcast(Event{Type: "status", State: "thinking"})
 }
 
 // OnReasoning broadcasts a reasoning event.
 func (h *sseEventHandler) OnReasoning(content string) {
-	h.server.broadcast(Event{Type: "reasoning", Content: content})
+	h.broadcast(Event{Type: "reasoning", Content: content})
+}
+
+// OnServerToolUse broadcasts a server_tool event for a provider-executed tool call.
+func (h *sseEventHandler) OnServerToolUse(id string, name string, input json.RawMessage) {
+	h.broadcast(Event{Type: "server_tool", ID: id, Name: name, Input: input})
+}
+
+// OnServerToolResult broadcasts a server_tool_result event.
+// The raw result block content is sent as the result string.
+func (h *sseEventHandler) OnServerToolResult(toolUseID string, blockType string, content json.RawMessage) {
+	h.broadcast(Event{Type: "server_tool_result", ID: toolUseID, Name: blockType, Result: string(content)})
+}
+
+// OnFailSafe broadcasts a fail_safe event when mutating tools are disabled for the run.
+func (h *sseEventHandler) OnFailSafe(failures int) {
+	h.broadcast(Event{
+		Type:    "fail_safe",
+		Message: fmt.Sprintf("mutating tools disabled after %d consecutive failures", failures),
+	})
+}
+
+// OnToolEvent broadcasts a tool_event carrying a tool's custom event.
+func (h *sseEventHandler) OnToolEvent(id string, eventType string, payload json.RawMessage) {
+	h.broadcast(Event{Type: "tool_event", ID: id, Event: eventType, Data: payload})
+}
+
+// OnToolOutput broadcasts a tool_output event with a chunk of a running tool's output.
+func (h *sseEventHandler) OnToolOutput(id string, stream string, text string) {
+	h.broadcast(Event{Type: "tool_output", ID: id, Stream: stream, Content: text})
+}
+
+// OnToolDisplay broadcasts a tool_display event with a rendering hint for a tool result.
+func (h *sseEventHandler) OnToolDisplay(id string, kind string, payload json.RawMessage) {
+	h.broadcast(Event{Type: "tool_display", ID: id, Display: &DisplayHint{Kind: kind, Payload: payload}})
+}
+
+// OnSLOViolation broadcasts an slo_violation event when a rolling latency
+// percentile exceeds its threshold.
+func (h *sseEventHandler) OnSLOViolation(kind string, percentile string, observed time.Duration, threshold time.Duration) {
+	h.broadcast(Event{
+		Type: "slo_violation",
+		Name: kind,
+		Message: fmt.Sprintf("%s %s latency %dms exceeds SLO %dms",
+			kind, percentile, observed.Milliseconds(), threshold.Milliseconds()),
+	})
 }
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// RunIDHeader carries the run ID on POST /prompt/stream responses.
+const RunIDHeader = "X-Run-ID"
+
+// HandlePromptStream handles POST /prompt/stream requests. It starts a
+// prompt like POST /prompt, then streams the run's events as
+// newline-delimited JSON until its done event. Closing the connection stops
+// the stream, not the run; POST /cancel cancels it.
+func (s *Server) HandlePromptStream(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	s.logger.Info("http", "Request received",
+		log.F("method", r.Method),
+		log.F("path", r.URL.Path),
+		log.F("content_length", r.ContentLength),
+	)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	// Follow the main session before the run starts, so no event is missed
+	client, _, _ := s.connectClient(r.RemoteAddr, MainSession, false, nil)
+	defer func() {
+		s.removeClient(client, time.Since(start))
+	}()
+
+	runID, _, ok := s.startPrompt(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set(RunIDHeader, runID)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case msg := <-client.events:
+			var event struct {
+				Type  string `json:"type"`
+				RunID string `json:"run_id"`
+			}
+			if json.Unmarshal(msg.data, &event) != nil {
+				continue
+			}
+			// Pass on this run's events and notices for the connection
+			if event.RunID != runID && event.Type != "dropped_events" && event.Type != "server_shutdown" {
+				continue
+			}
+			w.Write(msg.data)
+			w.Write([]byte("\n"))
+			flusher.Flush()
+			if event.Type == "done" || event.Type == "server_shutdown" {
+				s.logger.Info("http", "Stream completed",
+					log.F("path", r.URL.Path),
+					log.F("run_id", runID),
+					log.F("duration_ms", time.Since(start).Milliseconds()),
+				)
+				return
+			}
+		case <-client.evict:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+func TestPromptStream(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("call_1", "echo", map[string]string{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("All done"))
+	echo := &MockTool{name: "echo"}
+
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{echo}, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp := postJSON(t, ts.URL+"/prompt/stream", `{"content":"go"}`)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("expected NDJSON, got %q", ct)
+	}
+	runID := resp.Header.Get(server.RunIDHeader)
+	if runID == "" {
+		t.Fatal("expected the run ID header")
+	}
+
+	// The body is the run's events, one per line, ending with done
+	var types []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var e server.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		if e.RunID != runID {
+			t.Errorf("expected run_id %s, got %+v", runID, e)
+		}
+		types = append(types, e.Type)
+	}
+	if len(types) == 0 || types[0] != "user" || types[len(types)-1] != "done" {
+		t.Fatalf("expected user first and done last, got %v", types)
+	}
+	for _, want := range []string{"tool_call", "tool_result", "text"} {
+		found := false
+		for _, got := range types {
+			found = found || got == want
+		}
+		if !found {
+			t.Errorf("expected a %s event, got %v", want, types)
+		}
+	}
+}
+
+func TestPromptStream_InvalidRequest(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp := postJSON(t, ts.URL+"/prompt/stream", `{"content":""}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an empty prompt, got %d", resp.StatusCode)
+	}
+}
+
+// failingStream returns the first n events of a stream, then fails with a
+// retryable server error.
+type failingStream struct {
+	harness.StreamIterator
+	n int
+}
+
+func (s *failingStream) Next() bool {
+	if s.n == 0 {
+		return false
+	}
+	s.n--
+	return s.StreamIterator.Next()
+}
+
+func (s *failingStream) Err() error {
+	req, _ := http.NewRequest(http.MethodPost, "https://api.anthropic.com/v1/messages", nil)
+	return &anthropic.Error{StatusCode: http.StatusInternalServerError, Request: req, Response: &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{}}}
+}
+
+func TestPromptStream_StreamReset(t *testing.T) {
+	mockStreamer := testutil.NewMockMessageStreamer()
+	// message_start, content_block_start, and one delta, then a 500
+	mockStreamer.AddResponse(&failingStream{testutil.TextDeltaResponse("Hel", "lo"), 3})
+	mockStreamer.AddResponse(testutil.TextDeltaResponse("Hello"))
+
+	config := harness.Config{Model: "test-model", MaxRetries: 1, RetryBaseDelay: time.Millisecond}
+	h, _ := harness.NewHarnessWithStreamer(config, nil, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp := postJSON(t, ts.URL+"/prompt/stream", `{"content":"go"}`)
+	defer resp.Body.Close()
+
+	// The failed attempt's delta is followed by a reset before the retry
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var e server.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		switch e.Type {
+		case "content_block_delta", "text":
+			events = append(events, e.Type+" "+e.Content)
+		case "stream_reset", "done":
+			events = append(events, e.Type)
+		case "status":
+			if e.State == "retrying" {
+				events = append(events, e.Type+" "+e.State)
+			}
+		}
+	}
+	want := "content_block_delta Hel, stream_reset, status retrying, content_block_delta Hello, text Hello, done"
+	if got := strings.Join(events, ", "); got != want {
+		t.Errorf("expected events %q, got %q", want, got)
+	}
+}
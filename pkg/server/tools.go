@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/user/harness/pkg/tool"
+)
+
+// ToolInfo describes a tool in the GET /tools response.
+type ToolInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+	ReadOnly    bool            `json:"read_only"`
+	Enabled     bool            `json:"enabled"`
+}
+
+// SetToolRegistry makes GET /tools list every tool in r, including disabled
+// ones. Without a registry, the harness's tools are listed.
+func (s *Server) SetToolRegistry(r *tool.Registry) {
+	s.toolMu.Lock()
+	defer s.toolMu.Unlock()
+	s.registry = r
+}
+
+// HandleListTools handles GET /tools requests, listing the registered tools
+// with their input schemas and whether the model can call them.
+func (s *Server) HandleListTools(w http.ResponseWriter, r *http.Request) {
+	s.toolMu.RLock()
+	registry := s.registry
+	s.toolMu.RUnlock()
+
+	infos := []ToolInfo{}
+	if registry != nil {
+		for _, t := range registry.All() {
+			infos = append(infos, toolInfo(t, registry.IsEnabled(t.Name())))
+		}
+	} else {
+		for _, t := range s.harness.Tools() {
+			infos = append(infos, toolInfo(t, true))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(infos)
+}
+
+// toolInfo describes t for GET /tools.
+func toolInfo(t tool.Tool, enabled bool) ToolInfo {
+	return ToolInfo{
+		Name:        t.Name(),
+		Description: t.Description(),
+		InputSchema: t.InputSchema(),
+		ReadOnly:    tool.IsReadOnly(t),
+		Enabled:     enabled,
+	}
+}
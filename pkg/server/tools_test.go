@@ -0,0 +1,84 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// getTools fetches GET /tools.
+func getTools(t *testing.T, baseURL string) []server.ToolInfo {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/tools")
+	if err != nil {
+		t.Fatalf("GET /tools failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var tools []server.ToolInfo
+	if err := json.NewDecoder(resp.Body).Decode(&tools); err != nil {
+		t.Fatalf("failed to decode tools: %v", err)
+	}
+	return tools
+}
+
+func TestHandleListTools_Registry(t *testing.T) {
+	registry := tool.NewBuiltinRegistry()
+	if err := registry.SetEnabled([]string{"read", "bash"}); err != nil {
+		t.Fatal(err)
+	}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, registry.Tools(), nil, testutil.NewMockMessageStreamer())
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	s.SetToolRegistry(registry)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	tools := getTools(t, ts.URL)
+	if len(tools) != len(tool.Builtins()) {
+		t.Fatalf("expected every registered tool, got %d", len(tools))
+	}
+	byName := map[string]server.ToolInfo{}
+	for _, info := range tools {
+		byName[info.Name] = info
+	}
+	if read := byName["read"]; !read.Enabled || !read.ReadOnly || read.Description == "" {
+		t.Errorf("unexpected read tool info %+v", read)
+	}
+	if bash := byName["bash"]; !bash.Enabled || bash.ReadOnly {
+		t.Errorf("unexpected bash tool info %+v", bash)
+	}
+	if byName["write"].Enabled {
+		t.Error("expected write to be listed as disabled")
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(byName["glob"].InputSchema, &schema); err != nil || schema["type"] != "object" {
+		t.Errorf("expected glob input schema, got %s", byName["glob"].InputSchema)
+	}
+}
+
+func TestHandleListTools_HarnessTools(t *testing.T) {
+	tools := []tool.Tool{&MockTool{name: "zeta"}, &MockTool{name: "alpha"}}
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, tools, nil, testutil.NewMockMessageStreamer())
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	ts := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer ts.Close()
+
+	got := getTools(t, ts.URL)
+	if len(got) != 2 || got[0].Name != "alpha" || got[1].Name != "zeta" || !got[0].Enabled {
+		t.Errorf("expected alpha and zeta enabled, got %+v", got)
+	}
+}
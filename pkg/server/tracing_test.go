@@ -0,0 +1,77 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing_PromptTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("Hi").Build())
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	s := server.NewServer(h, ":0", nil)
+	h.SetEventHandler(s.EventHandler())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// The client's trace is continued
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/prompt", strings.NewReader(`{"content":"Hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /prompt failed: %v", err)
+	}
+	var body struct {
+		RunID string `json:"run_id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	waitForRun(t, ts.URL, body.RunID)
+
+	var httpSpan, runSpan sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "POST /prompt":
+			httpSpan = span
+		case "harness.run":
+			runSpan = span
+		}
+	}
+	if httpSpan == nil || runSpan == nil {
+		t.Fatalf("expected HTTP and run spans, got %v", recorder.Ended())
+	}
+	if got := httpSpan.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("expected the HTTP span in trace %s, got %s", traceID, got)
+	}
+	if httpSpan.SpanKind() != trace.SpanKindServer {
+		t.Errorf("expected a server span, got %v", httpSpan.SpanKind())
+	}
+
+	// The run outlives the request but stays in its trace
+	if runSpan.Parent().SpanID() != httpSpan.SpanContext().SpanID() {
+		t.Error("expected the run span to be a child of the HTTP span")
+	}
+}
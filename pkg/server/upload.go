@@ -0,0 +1,282 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// UploadDir is the directory, relative to a workspace, that holds each
+// session's uploaded files.
+const UploadDir = ".harness/uploads"
+
+// DefaultMaxUploadBytes caps the size of a POST /files request.
+const DefaultMaxUploadBytes = 32 << 20
+
+// uploadMemory is how much of a multipart request is buffered in memory
+// before parts spill to temporary files.
+const uploadMemory = 1 << 20
+
+// Upload is a file uploaded with POST /files for prompts to attach.
+type Upload struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Path        string `json:"path"` // absolute path of the stored file
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	CreatedAt   int64  `json:"created_at"`
+
+	// root is the absolute workspace the file was stored in
+	root string
+}
+
+// uploadForm describes the multipart body of POST /files.
+type uploadForm struct {
+	File        []*multipart.FileHeader `json:"file"`
+	WorkspaceID string                  `json:"workspace_id,omitempty"`
+}
+
+// uploadResponse is the body of a successful POST /files.
+type uploadResponse struct {
+	Files []Upload `json:"files"`
+}
+
+// SetMaxUploadSize sets the largest POST /files request accepted, in bytes.
+// Zero or less restores DefaultMaxUploadBytes.
+func (s *Server) SetMaxUploadSize(n int64) {
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+	s.maxUpload = n
+}
+
+// maxUploadSize returns the upload size limit in bytes.
+func (s *Server) maxUploadSize() int64 {
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+	if s.maxUpload <= 0 {
+		return DefaultMaxUploadBytes
+	}
+	return s.maxUpload
+}
+
+// HandleUpload handles POST /files requests, storing each "file" part of a
+// multipart form in the session's scratch directory within the workspace
+// named by the workspace_id field, or the main session's workspace.
+func (s *Server) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	limit := s.maxUploadSize()
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	if err := r.ParseMultipartForm(uploadMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			e := newAPIError(http.StatusRequestEntityTooLarge, fmt.Sprintf("upload exceeds %d bytes", limit))
+			e.Details = map[string]any{"limit": limit}
+			writeAPIError(w, e)
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		writeError(w, http.StatusBadRequest, "file is required")
+		return
+	}
+
+	// The workspace cannot be deleted while the files are written into it
+	s.workspaceMu.RLock()
+	defer s.workspaceMu.RUnlock()
+	root := s.harness.Workspace()
+	if id := r.FormValue("workspace_id"); id != "" {
+		ws, ok := s.workspaces[id]
+		if !ok {
+			writeError(w, http.StatusNotFound, "workspace not found")
+			return
+		}
+		root = ws.Path
+	}
+	root, err := workspaceRoot(root)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	dir := filepath.Join(root, UploadDir, s.uploadSessionID())
+	uploads := make([]Upload, 0, len(headers))
+	for _, fh := range headers {
+		up, err := saveUpload(dir, fh)
+		if err != nil {
+			// Keep nothing from a partly stored request
+			for _, saved := range uploads {
+				os.RemoveAll(filepath.Dir(saved.Path))
+			}
+			s.logger.Warn("http", "Upload failed",
+				log.F("name", fh.Filename),
+				log.F("error", err.Error()),
+			)
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		up.root = root
+		uploads = append(uploads, up)
+	}
+
+	s.uploadMu.Lock()
+	for i := range uploads {
+		up := uploads[i]
+		s.uploads[up.ID] = &up
+	}
+	s.uploadDirs[dir] = true
+	s.uploadMu.Unlock()
+
+	s.logger.Info("http", "Files uploaded",
+		log.F("count", len(uploads)),
+		log.F("dir", dir),
+	)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(uploadResponse{Files: uploads})
+}
+
+// saveUpload copies an uploaded part to dir/<id>/<name>, keeping the
+// client's base file name so the agent sees it.
+func saveUpload(dir string, fh *multipart.FileHeader) (Upload, error) {
+	name := filepath.Base(fh.Filename)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return Upload{}, fmt.Errorf("invalid file name %q", fh.Filename)
+	}
+	id := newUploadID()
+	path := filepath.Join(dir, id, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Upload{}, err
+	}
+
+	src, err := fh.Open()
+	if err != nil {
+		return Upload{}, err
+	}
+	defer src.Close()
+	dst, err := os.Create(path)
+	if err != nil {
+		return Upload{}, err
+	}
+	size, err := io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.RemoveAll(filepath.Dir(path))
+		return Upload{}, err
+	}
+
+	contentType := fh.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(name)); byExt != "" {
+			contentType = byExt
+		}
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return Upload{
+		ID:          id,
+		Name:        name,
+		Path:        path,
+		Size:        size,
+		ContentType: contentType,
+		CreatedAt:   time.Now().Unix(),
+	}, nil
+}
+
+// attachUploads appends a note listing the uploaded files named by ids to
+// a prompt's content, so the agent can open them with its tools. Every file
+// must be stored in root, the workspace the prompt runs in.
+func (s *Server) attachUploads(content string, ids []string, root string) (string, error) {
+	if len(ids) == 0 {
+		return content, nil
+	}
+	root, err := workspaceRoot(root)
+	if err != nil {
+		return "", err
+	}
+
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+	var b strings.Builder
+	b.WriteString(content)
+	b.WriteString("\n\nAttached files:")
+	for _, id := range ids {
+		up, ok := s.uploads[id]
+		if !ok {
+			return "", fmt.Errorf("unknown file %q", id)
+		}
+		if up.root != root {
+			return "", fmt.Errorf("file %q was uploaded to another workspace", id)
+		}
+		fmt.Fprintf(&b, "\n- %s (%s, %d bytes, %s)", up.Path, up.Name, up.Size, up.ContentType)
+	}
+	return b.String(), nil
+}
+
+// removeUploads deletes the session's scratch directories.
+func (s *Server) removeUploads() {
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+	for dir := range s.uploadDirs {
+		if err := os.RemoveAll(dir); err != nil {
+			s.logger.Warn("http", "Failed to remove uploads",
+				log.F("dir", dir),
+				log.F("error", err.Error()),
+			)
+		}
+	}
+	s.uploads = make(map[string]*Upload)
+	s.uploadDirs = make(map[string]bool)
+}
+
+// uploadSessionID returns the ID naming this server's scratch directories,
+// generating it on first use.
+func (s *Server) uploadSessionID() string {
+	s.uploadMu.Lock()
+	defer s.uploadMu.Unlock()
+	if s.uploadSession == "" {
+		s.uploadSession = newUploadSessionID()
+	}
+	return s.uploadSession
+}
+
+// workspaceRoot returns the absolute path of a workspace root, where ""
+// means the working directory.
+func workspaceRoot(root string) (string, error) {
+	if root == "" {
+		root = "."
+	}
+	return filepath.Abs(root)
+}
+
+// newUploadID returns a random uploaded file ID.
+func newUploadID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "file_" + hex.EncodeToString(buf)
+}
+
+// newUploadSessionID returns a random scratch directory name.
+func newUploadSessionID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "session_" + hex.EncodeToString(buf)
+}
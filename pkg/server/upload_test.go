@@ -0,0 +1,120 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+// uploadFile posts a multipart form with one file part to POST /files.
+func uploadFile(t *testing.T, baseURL, name, content string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatalf("failed to create part: %v", err)
+	}
+	part.Write([]byte(content))
+	mw.Close()
+	resp, err := http.Post(baseURL+"/files", mw.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("POST /files failed: %v", err)
+	}
+	return resp
+}
+
+func TestUpload_AttachToPrompt(t *testing.T) {
+	workspace := t.TempDir()
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("Found the error"))
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, mockStreamer)
+	h.SetWorkspace(workspace)
+	s := server.NewServer(h, ":0", nil)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp := uploadFile(t, ts.URL, "app.log", "panic: nil map")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	var uploaded struct {
+		Files []server.Upload `json:"files"`
+	}
+	json.NewDecoder(resp.Body).Decode(&uploaded)
+	if len(uploaded.Files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(uploaded.Files))
+	}
+	file := uploaded.Files[0]
+	if file.Name != "app.log" || file.Size != 14 || !strings.HasPrefix(file.ID, "file_") {
+		t.Errorf("unexpected upload %+v", file)
+	}
+	if !strings.HasPrefix(file.Path, filepath.Join(workspace, server.UploadDir)) {
+		t.Errorf("expected the file under the workspace's upload dir, got %s", file.Path)
+	}
+	if data, err := os.ReadFile(file.Path); err != nil || string(data) != "panic: nil map" {
+		t.Errorf("stored file = %q, %v", data, err)
+	}
+
+	// Unknown IDs are rejected
+	bad := postJSON(t, ts.URL+"/prompt", `{"content": "look", "files": ["file_missing"]}`)
+	bad.Body.Close()
+	if bad.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown file, got %d", bad.StatusCode)
+	}
+
+	// The prompt lists the attached file for the agent
+	runID := promptRunID(t, ts.URL, fmt.Sprintf(`{"content": "What failed?", "files": [%q]}`, file.ID))
+	waitForRun(t, ts.URL, runID)
+	messages := h.Messages()
+	if len(messages) == 0 {
+		t.Fatal("expected the prompt in the conversation")
+	}
+	text := messages[0].Content[0].OfText.Text
+	if !strings.HasPrefix(text, "What failed?\n\nAttached files:\n- "+file.Path) {
+		t.Errorf("unexpected user message %q", text)
+	}
+
+	// Shutdown removes the session's uploads
+	s.Shutdown(context.Background())
+	if _, err := os.Stat(file.Path); !os.IsNotExist(err) {
+		t.Errorf("expected the upload to be removed, got %v", err)
+	}
+}
+
+func TestUpload_TooLarge(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	h.SetWorkspace(t.TempDir())
+	s := server.NewServer(h, ":0", nil)
+	s.SetMaxUploadSize(1024)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp := uploadFile(t, ts.URL, "big.bin", strings.Repeat("x", 4096))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", resp.StatusCode)
+	}
+
+	resp, err := http.Post(ts.URL+"/files", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("POST /files failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for a non-multipart body, got %d", resp.StatusCode)
+	}
+}
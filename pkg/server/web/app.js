@@ -0,0 +1,269 @@
+// Minimal chat client for the harness server: follows the main session over
+// SSE and submits prompts, cancellations, approvals, and answers over REST.
+(function () {
+  "use strict";
+
+  const log = document.getElementById("log");
+  const statusEl = document.getElementById("status");
+  const form = document.getElementById("prompt");
+  const content = document.getElementById("content");
+  const cancel = document.getElementById("cancel");
+
+  // Credentials for servers with HARNESS_AUTH_TOKEN or HARNESS_API_KEY set
+  let token = localStorage.getItem("harness-token") || "";
+  const tools = new Map(); // tool call ID -> details element
+  const questions = new Map(); // question ID -> question element
+  let running = false;
+  let events = null;
+
+  function headers() {
+    const h = { "Content-Type": "application/json" };
+    if (token) h["Authorization"] = "Bearer " + token;
+    return h;
+  }
+
+  function askToken() {
+    const value = prompt("This server requires a token:", token);
+    if (value === null) return false;
+    token = value.trim();
+    localStorage.setItem("harness-token", token);
+    return true;
+  }
+
+  async function post(path, body) {
+    let resp = await fetch(path, { method: "POST", headers: headers(), body: body ? JSON.stringify(body) : null });
+    if (resp.status === 401 && askToken()) {
+      connect();
+      resp = await fetch(path, { method: "POST", headers: headers(), body: body ? JSON.stringify(body) : null });
+    }
+    if (!resp.ok) throw new Error(await errorMessage(resp));
+    return resp;
+  }
+
+  async function errorMessage(resp) {
+    const text = await resp.text();
+    try {
+      return JSON.parse(text).error.message || resp.statusText;
+    } catch {
+      return text.trim() || resp.statusText;
+    }
+  }
+
+  function scrolledToBottom() {
+    return log.scrollHeight - log.scrollTop - log.clientHeight < 40;
+  }
+
+  function append(el) {
+    const stick = scrolledToBottom();
+    log.appendChild(el);
+    if (stick) log.scrollTop = log.scrollHeight;
+    return el;
+  }
+
+  function entry(cls, text) {
+    const el = document.createElement("div");
+    el.className = "entry " + cls;
+    el.textContent = text;
+    return append(el);
+  }
+
+  function pretty(value) {
+    if (typeof value !== "string") return JSON.stringify(value, null, 2);
+    try {
+      return JSON.stringify(JSON.parse(value), null, 2);
+    } catch (e) {
+      return value;
+    }
+  }
+
+  function setRunning(value) {
+    running = value;
+    cancel.disabled = !value;
+  }
+
+  function setStatus(text) {
+    statusEl.textContent = text;
+  }
+
+  function toolCall(e) {
+    const el = document.createElement("details");
+    el.className = "entry tool";
+    const summary = document.createElement("summary");
+    summary.textContent = e.name + " …";
+    const input = document.createElement("pre");
+    input.textContent = pretty(e.input || {});
+    el.append(summary, input);
+    tools.set(e.id, el);
+    append(el);
+  }
+
+  function toolResult(e) {
+    const el = tools.get(e.id);
+    if (!el) return;
+    const summary = el.querySelector("summary");
+    summary.textContent = summary.textContent.replace(/ …$/, e.isError ? " ✗" : " ✓");
+    if (e.isError) el.classList.add("failed");
+    const result = document.createElement("pre");
+    result.textContent = pretty(e.result || "");
+    el.appendChild(result);
+    tools.delete(e.id);
+  }
+
+  function approval(e) {
+    const el = entry("approval notice", "Allow " + e.name + "? ");
+    for (const approved of [true, false]) {
+      const button = document.createElement("button");
+      button.textContent = approved ? "Approve" : "Deny";
+      button.onclick = async () => {
+        el.querySelectorAll("button").forEach((b) => (b.disabled = true));
+        try {
+          await post("approve", { id: e.id, approved: approved });
+        } catch (err) {
+          entry("error", err.message);
+        }
+      };
+      el.appendChild(button);
+    }
+  }
+
+  function question(e) {
+    const el = entry("question", e.content + " ");
+    const send = async (answer) => {
+      el.querySelectorAll("button, input").forEach((c) => (c.disabled = true));
+      try {
+        await post("answer", { id: e.id, answer: answer });
+      } catch (err) {
+        entry("error", err.message);
+      }
+    };
+    for (const choice of e.choices || []) {
+      const button = document.createElement("button");
+      button.textContent = choice;
+      button.onclick = () => send(choice);
+      el.appendChild(button);
+    }
+    const input = document.createElement("input");
+    input.placeholder = "Answer…";
+    input.addEventListener("keydown", (ev) => {
+      if (ev.key === "Enter" && input.value.trim()) send(input.value.trim());
+    });
+    el.appendChild(input);
+    questions.set(e.id, el);
+  }
+
+  function answered(e) {
+    const el = questions.get(e.id);
+    if (!el) return;
+    el.querySelectorAll("button, input").forEach((c) => c.remove());
+    el.appendChild(document.createTextNode("→ " + e.content));
+    questions.delete(e.id);
+  }
+
+  function handle(e) {
+    switch (e.type) {
+      case "user":
+        entry("user", e.content);
+        setRunning(true);
+        break;
+      case "steer":
+        entry("user", e.content);
+        break;
+      case "text":
+        entry("text", e.content);
+        break;
+      case "reasoning":
+        entry("reasoning", e.content);
+        break;
+      case "tool_call":
+        toolCall(e);
+        break;
+      case "tool_result":
+        toolResult(e);
+        break;
+      case "approval_request":
+        approval(e);
+        break;
+      case "question":
+        question(e);
+        break;
+      case "question_answered":
+        answered(e);
+        break;
+      case "compaction":
+        entry("notice", "Earlier turns were summarized.");
+        break;
+      case "model_changed":
+        entry("notice", "Switched to " + e.model + " after " + e.previous_model + " failed.");
+        break;
+      case "status":
+        setStatus(e.state === "queued" ? "queued (" + e.position + ")" : e.state + (e.message ? ": " + e.message : ""));
+        if (e.state === "thinking") setRunning(true);
+        break;
+      case "done":
+        setRunning(false);
+        setStatus(e.state);
+        if (e.state === "cancelled") entry("notice", "Cancelled.");
+        else if (e.state !== "completed" && e.message) entry("error", e.message);
+        break;
+      case "conversation_reset":
+      case "conversation_loaded":
+        log.textContent = "";
+        break;
+      case "dropped_events":
+      case "history_gap":
+      case "server_shutdown":
+        entry("notice", e.message);
+        break;
+    }
+  }
+
+  function connect() {
+    if (events) events.close();
+    log.textContent = "";
+    tools.clear();
+    questions.clear();
+    // Replay the retained events of the main session, then follow it
+    let url = "events?session=main&last_event_id=0";
+    if (token) url += "&token=" + encodeURIComponent(token);
+    events = new EventSource(url);
+    events.onopen = () => setStatus(running ? "running" : "connected");
+    events.onmessage = (msg) => handle(JSON.parse(msg.data));
+    events.onerror = () => setStatus("disconnected, retrying…");
+  }
+
+  form.addEventListener("submit", async (ev) => {
+    ev.preventDefault();
+    const text = content.value.trim();
+    if (!text) return;
+    try {
+      // Steer a running prompt instead of queueing a new one
+      await post(running ? "steer" : "prompt", { content: text });
+      content.value = "";
+    } catch (err) {
+      entry("error", err.message);
+    }
+  });
+
+  content.addEventListener("keydown", (ev) => {
+    if (ev.key === "Enter" && !ev.shiftKey) {
+      ev.preventDefault();
+      form.requestSubmit();
+    }
+  });
+
+  cancel.addEventListener("click", async () => {
+    try {
+      await post("cancel");
+    } catch (err) {
+      entry("error", err.message);
+    }
+  });
+
+  // Ask for a token up front if the server requires one
+  fetch("status", { headers: headers() })
+    .then((resp) => {
+      if (resp.status === 401) askToken();
+    })
+    .catch(() => {})
+    .finally(connect);
+})();
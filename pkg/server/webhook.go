@@ -0,0 +1,62 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// errWebhookDestination is the error of a webhook whose host resolves to
+// an address outside the public internet that is not allowlisted.
+var errWebhookDestination = errors.New("webhook destination is a private, loopback, or link-local address")
+
+// SetWebhookAllowlist allows batch webhooks to the given IP addresses or
+// CIDR ranges even when they are private, loopback, or link-local. Webhooks
+// to such addresses, including cloud metadata endpoints, are refused by
+// default; public addresses are always allowed.
+func (s *Server) SetWebhookAllowlist(networks []string) error {
+	return s.webhookGuard.setAllowlist("webhook", networks)
+}
+
+// checkWebhookURL validates a batch's webhook URL. Only a host given as an
+// IP address can be checked here; names are checked when they are dialed.
+func (s *Server) checkWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("webhook_url must be an http(s) URL")
+	}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !s.webhookGuard.allowed(addr) {
+		return errWebhookDestination
+	}
+	return nil
+}
+
+// webhookClient returns an HTTP client for batch webhooks. It checks every
+// address it connects to, after name resolution and on redirects, so a
+// host cannot resolve to a refused address, and does not use a proxy,
+// which would hide the destination.
+func (s *Server) webhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !s.webhookGuard.allowed(addrPort.Addr()) {
+				return errWebhookDestination
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:       dialer.DialContext,
+			DisableKeepAlives: true,
+		},
+	}
+}
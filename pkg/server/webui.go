@@ -0,0 +1,52 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// webFiles is the embedded chat UI served at / and /ui/.
+//
+//go:embed web
+var webFiles embed.FS
+
+// SetWebUI enables or disables the embedded chat UI (enabled by default).
+func (s *Server) SetWebUI(enabled bool) {
+	s.webMu.Lock()
+	defer s.webMu.Unlock()
+	s.webUIDisabled = !enabled
+}
+
+// webUIEnabled reports whether the embedded chat UI is served.
+func (s *Server) webUIEnabled() bool {
+	s.webMu.RLock()
+	defer s.webMu.RUnlock()
+	return !s.webUIDisabled
+}
+
+// isWebUIPath reports whether r fetches a static file of the web UI. These
+// hold no data, so they are served without credentials; the UI then asks
+// for a token when the API requires one.
+func isWebUIPath(r *http.Request) bool {
+	return (r.Method == http.MethodGet || r.Method == http.MethodHead) &&
+		(r.URL.Path == "/" || strings.HasPrefix(r.URL.Path, "/ui/"))
+}
+
+// webUIHandler serves the web UI's page at / and its assets under /ui/.
+func (s *Server) webUIHandler() http.Handler {
+	root, _ := fs.Sub(webFiles, "web")
+	assets := http.StripPrefix("/ui/", http.FileServerFS(root))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.webUIEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/" {
+			http.ServeFileFS(w, r, root, "index.html")
+			return
+		}
+		assets.ServeHTTP(w, r)
+	})
+}
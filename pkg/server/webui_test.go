@@ -0,0 +1,63 @@
+package server_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+)
+
+func TestWebUI(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", nil)
+	s.SetAuth(server.AuthConfig{BearerToken: "tok"})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// The page and its assets are served without credentials
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/", "text/html", `src="ui/app.js"`},
+		{"/ui/app.js", "javascript", "new EventSource"},
+		{"/ui/style.css", "text/css", "details.tool"},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(ts.URL + tt.path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", tt.path, resp.StatusCode)
+			continue
+		}
+		if ct := resp.Header.Get("Content-Type"); !strings.Contains(ct, tt.contentType) {
+			t.Errorf("GET %s: expected %s, got %q", tt.path, tt.contentType, ct)
+		}
+		if !strings.Contains(string(body), tt.contains) {
+			t.Errorf("GET %s: expected %q in the body", tt.path, tt.contains)
+		}
+	}
+
+	// The API still requires credentials
+	if got := authStatus(t, "GET", ts.URL+"/status", nil); got != http.StatusUnauthorized {
+		t.Errorf("expected 401 for the API, got %d", got)
+	}
+	if got := authStatus(t, "GET", ts.URL+"/ui/missing.js", nil); got != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing asset, got %d", got)
+	}
+
+	s.SetWebUI(false)
+	if got := authStatus(t, "GET", ts.URL+"/", nil); got != http.StatusNotFound {
+		t.Errorf("expected 404 with the UI disabled, got %d", got)
+	}
+}
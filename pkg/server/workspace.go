@@ -0,0 +1,388 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/harness/pkg/log"
+)
+
+// cloneTimeout bounds how long a workspace git clone may take.
+const cloneTimeout = 5 * time.Minute
+
+// errCloneDestination is the error of a git_url whose host resolves to an
+// address outside the public internet that is not allowlisted.
+var errCloneDestination = errors.New("git_url host is a private, loopback, or link-local address")
+
+// Workspace is a project checkout managed by the server.
+type Workspace struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	GitURL    string `json:"git_url,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// workspaceRequest is the body of POST /workspaces.
+type workspaceRequest struct {
+	Name   string `json:"name"`
+	GitURL string `json:"git_url,omitempty"`
+}
+
+// EnableWorkspaces turns on the workspace endpoints. Workspaces are created
+// under root, and those from earlier runs are loaded from their metadata files.
+func (s *Server) EnableWorkspaces(root string) error {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]*Workspace)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(abs, entry.Name()))
+		if err != nil {
+			return err
+		}
+		var ws Workspace
+		if err := json.Unmarshal(data, &ws); err != nil {
+			s.logger.Warn("http", "Skipping invalid workspace metadata",
+				log.F("file", entry.Name()),
+				log.F("error", err.Error()),
+			)
+			continue
+		}
+		loaded[ws.ID] = &ws
+	}
+
+	s.workspaceMu.Lock()
+	s.workspaceRoot = abs
+	s.workspaces = loaded
+	s.workspaceMu.Unlock()
+
+	s.logger.Info("http", "Workspaces enabled",
+		log.F("root", abs),
+		log.F("workspaces", len(loaded)),
+	)
+	return nil
+}
+
+// workspace returns the workspace with the given ID.
+func (s *Server) workspace(id string) (*Workspace, bool) {
+	s.workspaceMu.RLock()
+	defer s.workspaceMu.RUnlock()
+	ws, ok := s.workspaces[id]
+	return ws, ok
+}
+
+// workspacesEnabled writes a 404 and returns false if workspaces are off.
+func (s *Server) workspacesEnabled(w http.ResponseWriter) bool {
+	s.workspaceMu.RLock()
+	defer s.workspaceMu.RUnlock()
+	if s.workspaceRoot == "" {
+		writeError(w, http.StatusNotFound, "workspaces are not enabled")
+		return false
+	}
+	return true
+}
+
+// HandleCreateWorkspace handles POST /workspaces requests.
+// The workspace is cloned from git_url if given, otherwise created empty.
+func (s *Server) HandleCreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	if !s.workspacesEnabled(w) {
+		return
+	}
+	var req workspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.GitURL != "" && !validGitURL(req.GitURL) {
+		writeError(w, http.StatusBadRequest, "git_url must be an http(s), ssh, or git@ URL")
+		return
+	}
+	var gitConfig []string
+	if req.GitURL != "" {
+		config, err := s.checkGitURL(r.Context(), req.GitURL)
+		switch {
+		case errors.Is(err, errCloneDestination):
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		case err != nil:
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		gitConfig = config
+	}
+
+	id := newWorkspaceID()
+	s.workspaceMu.RLock()
+	root := s.workspaceRoot
+	s.workspaceMu.RUnlock()
+
+	ws := &Workspace{
+		ID:        id,
+		Name:      req.Name,
+		Path:      filepath.Join(root, id),
+		GitURL:    req.GitURL,
+		CreatedAt: time.Now().Unix(),
+	}
+	if ws.Name == "" {
+		ws.Name = id
+	}
+
+	if err := createWorkspaceDir(r.Context(), ws, gitConfig); err != nil {
+		os.RemoveAll(ws.Path)
+		s.logger.Warn("http", "Workspace creation failed",
+			log.F("workspace_id", id),
+			log.F("error", err.Error()),
+		)
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	data, _ := json.MarshalIndent(ws, "", "  ")
+	if err := os.WriteFile(filepath.Join(root, id+".json"), data, 0644); err != nil {
+		os.RemoveAll(ws.Path)
+		writeError(w, http.StatusInternalServerError, "failed to save workspace")
+		return
+	}
+
+	s.workspaceMu.Lock()
+	s.workspaces[id] = ws
+	s.workspaceMu.Unlock()
+
+	s.logger.Info("http", "Workspace created",
+		log.F("workspace_id", id),
+		log.F("git_url", req.GitURL),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ws)
+}
+
+// HandleListWorkspaces handles GET /workspaces requests.
+func (s *Server) HandleListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	if !s.workspacesEnabled(w) {
+		return
+	}
+	s.workspaceMu.RLock()
+	list := make([]Workspace, 0, len(s.workspaces))
+	for _, ws := range s.workspaces {
+		list = append(list, *ws)
+	}
+	s.workspaceMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt != list[j].CreatedAt {
+			return list[i].CreatedAt < list[j].CreatedAt
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleDeleteWorkspace handles DELETE /workspaces/{id} requests.
+// A workspace in use by a running or queued prompt, a running batch, or a
+// running experiment cannot be deleted. The workspace lock is held from the
+// check until the files are removed, and prompts, batches, and experiments
+// hold it for reading from choosing a workspace until their work is
+// registered, as do uploads until their files are written, so a workspace
+// is never removed under work that chose it.
+func (s *Server) HandleDeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	if !s.workspacesEnabled(w) {
+		return
+	}
+	id := r.PathValue("id")
+
+	s.workspaceMu.Lock()
+	defer s.workspaceMu.Unlock()
+	ws, ok := s.workspaces[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if s.workspaceInUse(ws.Path) {
+		writeError(w, http.StatusConflict, "workspace is in use")
+		return
+	}
+	if s.harness.Workspace() == ws.Path {
+		s.harness.SetWorkspace("")
+	}
+	delete(s.workspaces, id)
+
+	os.Remove(filepath.Join(s.workspaceRoot, id+".json"))
+	if err := os.RemoveAll(ws.Path); err != nil {
+		s.logger.Warn("http", "Workspace removal failed",
+			log.F("workspace_id", id),
+			log.F("error", err.Error()),
+		)
+	}
+	s.logger.Info("http", "Workspace deleted", log.F("workspace_id", id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// workspaceInUse reports whether the main session is bound to the workspace
+// at path and busy, or a running batch uses it, or a running experiment
+// copies it. Callers hold workspaceMu.
+func (s *Server) workspaceInUse(path string) bool {
+	if s.harness.Workspace() == path {
+		if s.harness.IsRunning() {
+			return true
+		}
+		s.activeMu.Lock()
+		busy := s.active != nil || len(s.queue) > 0
+		s.activeMu.Unlock()
+		if busy {
+			return true
+		}
+	}
+	s.experimentMu.RLock()
+	for _, e := range s.experiments {
+		if e.source == path && e.snapshot().Status == batchRunning {
+			s.experimentMu.RUnlock()
+			return true
+		}
+	}
+	s.experimentMu.RUnlock()
+	s.batchMu.RLock()
+	defer s.batchMu.RUnlock()
+	for _, b := range s.batches {
+		if b.workspace == path && b.snapshot().Status == batchRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveWorkspace returns the path of the workspace with the given ID.
+func (s *Server) resolveWorkspace(id string) (string, error) {
+	ws, ok := s.workspace(id)
+	if !ok {
+		return "", errors.New("workspace not found")
+	}
+	return ws.Path, nil
+}
+
+// SetCloneAllowlist allows workspaces to be cloned from hosts at the given
+// IP addresses or CIDR ranges even when they are private, loopback, or
+// link-local. Clones from such hosts, including cloud metadata endpoints,
+// are refused by default; public addresses are always allowed.
+func (s *Server) SetCloneAllowlist(networks []string) error {
+	return s.cloneGuard.setAllowlist("clone", networks)
+}
+
+// checkGitURL resolves the host of a git URL accepted by validGitURL and
+// checks every address it resolves to. It returns the git configuration to
+// clone with: redirects are not followed, and http(s) clones connect only
+// to the checked addresses, so the host cannot be redirected or resolved
+// elsewhere during the clone.
+func (s *Server) checkGitURL(ctx context.Context, raw string) ([]string, error) {
+	// Only http(s) URLs, with their port, have addresses git can be pinned to
+	var host, port string
+	if rest, ok := strings.CutPrefix(raw, "git@"); ok {
+		host, _, _ = strings.Cut(rest, ":")
+		host = strings.Trim(host, "[]")
+	} else {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, errors.New("git_url must be an http(s), ssh, or git@ URL")
+		}
+		host = u.Hostname()
+		if port = u.Port(); port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			port = ""
+		}
+	}
+	if host == "" {
+		return nil, errors.New("git_url has no host")
+	}
+
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs, port = []netip.Addr{addr}, ""
+	} else {
+		addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve git_url host %q: %w", host, err)
+		}
+	}
+	pinned := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if !s.cloneGuard.allowed(addr) {
+			return nil, errCloneDestination
+		}
+		if addr = addr.Unmap(); addr.Is6() {
+			pinned = append(pinned, "["+addr.String()+"]")
+		} else {
+			pinned = append(pinned, addr.String())
+		}
+	}
+
+	config := []string{"-c", "http.followRedirects=false"}
+	if port != "" {
+		config = append(config, "-c", "http.curloptResolve="+host+":"+port+":"+strings.Join(pinned, ","))
+	}
+	return config, nil
+}
+
+// createWorkspaceDir clones or creates the workspace directory. gitConfig
+// holds the git options to clone with, from checkGitURL.
+func createWorkspaceDir(ctx context.Context, ws *Workspace, gitConfig []string) error {
+	if ws.GitURL == "" {
+		return os.Mkdir(ws.Path, 0755)
+	}
+	ctx, cancel := context.WithTimeout(ctx, cloneTimeout)
+	defer cancel()
+	args := append(gitConfig, "clone", "--", ws.GitURL, ws.Path)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git clone failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// validGitURL reports whether url is a remote git URL. Local paths and
+// file:// URLs are rejected so clients cannot copy arbitrary server files.
+func validGitURL(url string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git@"} {
+		if strings.HasPrefix(url, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// newWorkspaceID returns a random workspace identifier.
+func newWorkspaceID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "ws_" + hex.EncodeToString(buf)
+}
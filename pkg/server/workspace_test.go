@@ -0,0 +1,283 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/user/harness/pkg/harness"
+	"github.com/user/harness/pkg/server"
+	"github.com/user/harness/pkg/testutil"
+	"github.com/user/harness/pkg/tool"
+)
+
+// workspaceProbe records the workspace each call runs in.
+type workspaceProbe struct {
+	seen chan string
+}
+
+func (p *workspaceProbe) Name() string                 { return "probe" }
+func (p *workspaceProbe) Description() string          { return "Report workspace" }
+func (p *workspaceProbe) InputSchema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (p *workspaceProbe) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	p.seen <- tool.Workspace(ctx)
+	return `{"ok":true}`, nil
+}
+
+func TestWorkspaces_Lifecycle(t *testing.T) {
+	root := t.TempDir()
+	probe := &workspaceProbe{seen: make(chan string, 1)}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddToolUse("p1", "probe", map[string]any{}).Build())
+	mockStreamer.AddResponse(testutil.NewMessageBuilder().AddText("done").Build())
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{probe}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	if err := s.EnableWorkspaces(root); err != nil {
+		t.Fatalf("EnableWorkspaces failed: %v", err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	// Create an empty workspace
+	resp, err := http.Post(ts.URL+"/workspaces", "application/json", bytes.NewBufferString(`{"name":"demo"}`))
+	if err != nil {
+		t.Fatalf("POST /workspaces failed: %v", err)
+	}
+	var ws server.Workspace
+	json.NewDecoder(resp.Body).Decode(&ws)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if ws.Name != "demo" || ws.ID == "" {
+		t.Errorf("unexpected workspace: %+v", ws)
+	}
+	if info, err := os.Stat(ws.Path); err != nil || !info.IsDir() {
+		t.Fatalf("workspace directory not created: %v", err)
+	}
+
+	// Prompts bound to the workspace run tools inside it
+	resp, err = http.Post(ts.URL+"/prompt", "application/json",
+		bytes.NewBufferString(`{"content":"probe","workspace_id":"`+ws.ID+`"}`))
+	if err != nil {
+		t.Fatalf("POST /prompt failed: %v", err)
+	}
+	resp.Body.Close()
+	select {
+	case got := <-probe.seen:
+		if got != ws.Path {
+			t.Errorf("expected tool to run in %q, got %q", ws.Path, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for tool call")
+	}
+	for h.IsRunning() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Workspaces persist across servers sharing the root
+	s2 := server.NewServer(h, ":0", nil)
+	if err := s2.EnableWorkspaces(root); err != nil {
+		t.Fatalf("EnableWorkspaces failed: %v", err)
+	}
+	ts2 := httptest.NewServer(s2.Handler())
+	defer ts2.Close()
+	resp, _ = http.Get(ts2.URL + "/workspaces")
+	var list []server.Workspace
+	json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if len(list) != 1 || list[0].ID != ws.ID {
+		t.Fatalf("expected reloaded workspace, got %+v", list)
+	}
+
+	// Delete removes the directory and unbinds the session
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/workspaces/"+ws.ID, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", resp.StatusCode)
+	}
+	if _, err := os.Stat(ws.Path); !os.IsNotExist(err) {
+		t.Error("expected workspace directory to be removed")
+	}
+	if h.Workspace() != "" {
+		t.Errorf("expected session to be unbound, got %q", h.Workspace())
+	}
+}
+
+func TestWorkspaces_Validation(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+
+	// Disabled by default
+	disabled := httptest.NewServer(server.NewServer(h, ":0", nil).Handler())
+	defer disabled.Close()
+	resp, _ := http.Get(disabled.URL + "/workspaces")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 when disabled, got %d", resp.StatusCode)
+	}
+
+	s := server.NewServer(h, ":0", nil)
+	s.EnableWorkspaces(t.TempDir())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp, _ = http.Post(ts.URL+"/workspaces", "application/json", bytes.NewBufferString(`{"git_url":"/etc"}`))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for local git_url, got %d", resp.StatusCode)
+	}
+
+	resp, _ = http.Post(ts.URL+"/prompt", "application/json", bytes.NewBufferString(`{"content":"x","workspace_id":"ws_missing"}`))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown workspace, got %d", resp.StatusCode)
+	}
+}
+
+func TestWorkspaces_StickyBindingAndInUse(t *testing.T) {
+	probe := &workspaceProbe{seen: make(chan string, 1)}
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	blocking := &MockTool{
+		name: "blocking",
+		executeFunc: func(ctx context.Context, input json.RawMessage) (string, error) {
+			started <- struct{}{}
+			<-release
+			return `{"ok":true}`, nil
+		},
+	}
+	mockStreamer := testutil.NewMockMessageStreamer()
+	mockStreamer.AddResponse(testutil.SingleToolResponse("b1", "blocking", map[string]any{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("p1", "probe", map[string]any{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("b2", "blocking", map[string]any{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("b3", "blocking", map[string]any{}))
+	mockStreamer.AddResponse(testutil.SingleToolResponse("b4", "blocking", map[string]any{}))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+	mockStreamer.AddResponse(testutil.TextOnlyResponse("done"))
+
+	h, err := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, []tool.Tool{probe, blocking}, nil, mockStreamer)
+	if err != nil {
+		t.Fatalf("failed to create harness: %v", err)
+	}
+	s := server.NewServer(h, ":0", nil)
+	if err := s.EnableWorkspaces(t.TempDir()); err != nil {
+		t.Fatalf("EnableWorkspaces failed: %v", err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	resp := postJSON(t, ts.URL+"/workspaces", `{"name":"demo"}`)
+	var ws server.Workspace
+	json.NewDecoder(resp.Body).Decode(&ws)
+	resp.Body.Close()
+
+	deleteWorkspace := func() int {
+		req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/workspaces/"+ws.ID, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("DELETE failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// A prompt running in the workspace keeps it from being deleted
+	runID := promptRunID(t, ts.URL, `{"content":"block","workspace_id":"`+ws.ID+`"}`)
+	<-started
+	if code := deleteWorkspace(); code != http.StatusConflict {
+		t.Errorf("expected 409 deleting a workspace in use by a prompt, got %d", code)
+	}
+	release <- struct{}{}
+	waitForRun(t, ts.URL, runID)
+
+	// The binding is sticky: a later prompt without workspace_id runs in it
+	runID = promptRunID(t, ts.URL, `{"content":"probe"}`)
+	select {
+	case got := <-probe.seen:
+		if got != ws.Path {
+			t.Errorf("expected the later prompt to run in %q, got %q", ws.Path, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for tool call")
+	}
+	waitForRun(t, ts.URL, runID)
+
+	// So does a running batch
+	batchID := submitBatch(t, ts.URL, `{"prompts":["block"],"workspace_id":"`+ws.ID+`"}`)
+	<-started
+	if code := deleteWorkspace(); code != http.StatusConflict {
+		t.Errorf("expected 409 deleting a workspace in use by a batch, got %d", code)
+	}
+	release <- struct{}{}
+	waitForBatch(t, ts.URL, batchID)
+
+	// And a running experiment, whose variants copy the bound workspace
+	experimentID := startExperiment(t, ts.URL, `{"prompt":"block","variants":[{"id":"a"},{"id":"b"}]}`)
+	<-started
+	<-started
+	if code := deleteWorkspace(); code != http.StatusConflict {
+		t.Errorf("expected 409 deleting a workspace in use by an experiment, got %d", code)
+	}
+	release <- struct{}{}
+	release <- struct{}{}
+	waitForExperiment(t, ts.URL, experimentID)
+
+	if code := deleteWorkspace(); code != http.StatusNoContent {
+		t.Errorf("expected 204 once the workspace is unused, got %d", code)
+	}
+	if h.Workspace() != "" {
+		t.Errorf("expected the session to be unbound, got %q", h.Workspace())
+	}
+}
+
+func TestWorkspaces_CloneRefusesPrivateHosts(t *testing.T) {
+	h, _ := harness.NewHarnessWithStreamer(harness.Config{Model: "test-model"}, nil, nil, testutil.NewMockMessageStreamer())
+	s := server.NewServer(h, ":0", nil)
+	s.EnableWorkspaces(t.TempDir())
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	for _, gitURL := range []string{
+		"http://127.0.0.1:1/repo.git",
+		"https://169.254.169.254/repo.git",
+		"ssh://[::1]/repo.git",
+		"git@localhost:repo.git",
+		"https://10.0.0.5/repo.git",
+	} {
+		resp := postJSON(t, ts.URL+"/workspaces", `{"git_url":"`+gitURL+`"}`)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", gitURL, resp.StatusCode)
+		}
+	}
+
+	// An allowlisted host passes the check; nothing listens there, so the
+	// clone itself fails
+	if err := s.SetCloneAllowlist([]string{"127.0.0.0/8"}); err != nil {
+		t.Fatalf("SetCloneAllowlist failed: %v", err)
+	}
+	resp := postJSON(t, ts.URL+"/workspaces", `{"git_url":"http://127.0.0.1:1/repo.git"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected 502 for an allowlisted host, got %d", resp.StatusCode)
+	}
+	if err := s.SetCloneAllowlist([]string{"not-a-network"}); err == nil {
+		t.Error("expected an error for an invalid allowlist entry")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/user/harness/pkg/harness"
@@ -51,6 +52,25 @@ func createContentBlockStartEvent(index int64, block anthropic.ContentBlockUnion
 			"type":     "thinking",
 			"thinking": block.Thinking,
 		})
+	case "server_tool_use":
+		var inputAny any
+		if len(block.Input) > 0 {
+			json.Unmarshal(block.Input, &inputAny)
+		} else {
+			inputAny = map[string]any{}
+		}
+		contentBlockJSON, err = json.Marshal(map[string]any{
+			"type":  "server_tool_use",
+			"id":    block.ID,
+			"name":  block.Name,
+			"input": inputAny,
+		})
+	case "web_search_tool_result":
+		contentBlockJSON, err = json.Marshal(map[string]any{
+			"type":        "web_search_tool_result",
+			"tool_use_id": block.ToolUseID,
+			"content":     json.RawMessage(block.Content.RawJSON()),
+		})
 	default:
 		return anthropic.MessageStreamEventUnion{}, fmt.Errorf("unsupported block type: %s", block.Type)
 	}
@@ -92,6 +112,12 @@ func NewMockStreamWithMessage(msg anthropic.Message) *MockStreamWithMessage {
 			"role":        "assistant",
 			"content":     []any{},
 			"stop_reason": msg.StopReason,
+			"usage": map[string]any{
+				"input_tokens":                msg.Usage.InputTokens,
+				"output_tokens":               msg.Usage.OutputTokens,
+				"cache_creation_input_tokens": msg.Usage.CacheCreationInputTokens,
+				"cache_read_input_tokens":     msg.Usage.CacheReadInputTokens,
+			},
 		},
 	})
 	var msgStartEvent anthropic.MessageStreamEventUnion
@@ -131,6 +157,43 @@ func NewMockStreamWithMessage(msg anthropic.Message) *MockStreamWithMessage {
 	}
 }
 
+// NewMockStreamWithTextDeltas creates a stream for a text-only message whose
+// single text block arrives as the given text_delta fragments, as the real API
+// streams text.
+func NewMockStreamWithTextDeltas(chunks []string) *MockStreamWithMessage {
+	raw := []map[string]any{
+		{"type": "message_start", "message": map[string]any{
+			"type":        "message",
+			"role":        "assistant",
+			"content":     []any{},
+			"stop_reason": "end_turn",
+			"usage":       map[string]any{"input_tokens": 0, "output_tokens": 0},
+		}},
+		{"type": "content_block_start", "index": 0, "content_block": map[string]any{"type": "text", "text": ""}},
+	}
+	for _, chunk := range chunks {
+		raw = append(raw, map[string]any{
+			"type":  "content_block_delta",
+			"index": 0,
+			"delta": map[string]any{"type": "text_delta", "text": chunk},
+		})
+	}
+	raw = append(raw,
+		map[string]any{"type": "content_block_stop", "index": 0},
+		map[string]any{"type": "message_stop"},
+	)
+
+	events := make([]anthropic.MessageStreamEventUnion, len(raw))
+	for i, r := range raw {
+		data, _ := json.Marshal(r)
+		json.Unmarshal(data, &events[i])
+	}
+	return &MockStreamWithMessage{
+		events: events,
+		index:  -1,
+	}
+}
+
 // NewMockStreamWithError creates a stream that returns an error.
 func NewMockStreamWithError(err error) *MockStreamWithMessage {
 	return &MockStreamWithMessage{
@@ -166,6 +229,9 @@ type MockMessageStreamer struct {
 
 	// currentIndex tracks which response to return next.
 	currentIndex int
+
+	// mu guards concurrent NewStreaming calls from parallel sessions.
+	mu sync.Mutex
 }
 
 // NewMockMessageStreamer creates a new mock message streamer.
@@ -178,6 +244,8 @@ func NewMockMessageStreamer() *MockMessageStreamer {
 
 // NewStreaming returns the next configured stream response.
 func (m *MockMessageStreamer) NewStreaming(ctx context.Context, params anthropic.MessageNewParams) harness.StreamIterator {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.RecordedParams = append(m.RecordedParams, params)
 
 	if m.currentIndex >= len(m.Responses) {
@@ -209,6 +277,7 @@ func (m *MockMessageStreamer) Reset() {
 // MessageBuilder provides a fluent API for building mock messages.
 type MessageBuilder struct {
 	content []anthropic.ContentBlockUnion
+	usage   anthropic.Usage
 }
 
 // NewMessageBuilder creates a new MessageBuilder.
@@ -252,6 +321,50 @@ func (mb *MessageBuilder) AddThinking(thinking string) *MessageBuilder {
 	return mb
 }
 
+// AddServerToolUse adds a server tool use block (e.g., web_search) to the message.
+func (mb *MessageBuilder) AddServerToolUse(id, name string, input any) *MessageBuilder {
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		panic("failed to marshal server tool input: " + err.Error())
+	}
+	mb.content = append(mb.content, anthropic.ContentBlockUnion{
+		Type:  "server_tool_use",
+		ID:    id,
+		Name:  name,
+		Input: inputJSON,
+	})
+	return mb
+}
+
+// AddWebSearchResult adds a web_search_tool_result block with the given raw JSON content.
+func (mb *MessageBuilder) AddWebSearchResult(toolUseID string, content string) *MessageBuilder {
+	var block anthropic.ContentBlockUnion
+	blockJSON, _ := json.Marshal(map[string]any{
+		"type":        "web_search_tool_result",
+		"tool_use_id": toolUseID,
+		"content":     json.RawMessage(content),
+	})
+	if err := json.Unmarshal(blockJSON, &block); err != nil {
+		panic("failed to build web search result block: " + err.Error())
+	}
+	mb.content = append(mb.content, block)
+	return mb
+}
+
+// WithUsage sets the token usage reported for the message.
+func (mb *MessageBuilder) WithUsage(inputTokens, outputTokens int64) *MessageBuilder {
+	mb.usage.InputTokens = inputTokens
+	mb.usage.OutputTokens = outputTokens
+	return mb
+}
+
+// WithCacheUsage sets the prompt cache token counts reported for the message.
+func (mb *MessageBuilder) WithCacheUsage(creationTokens, readTokens int64) *MessageBuilder {
+	mb.usage.CacheCreationInputTokens = creationTokens
+	mb.usage.CacheReadInputTokens = readTokens
+	return mb
+}
+
 // Build returns a MockStreamWithMessage that contains the built message.
 func (mb *MessageBuilder) Build() *MockStreamWithMessage {
 	return mb.BuildWithStopReason(anthropic.StopReasonEndTurn)
@@ -270,6 +383,7 @@ func (mb *MessageBuilder) BuildWithStopReason(stopReason anthropic.StopReason) *
 		Role:       "assistant",
 		Content:    mb.content,
 		StopReason: stopReason,
+		Usage:      mb.usage,
 	}
 	return NewMockStreamWithMessage(msg)
 }
@@ -281,6 +395,11 @@ func TextOnlyResponse(text string) *MockStreamWithMessage {
 	return NewMessageBuilder().AddText(text).Build()
 }
 
+// TextDeltaResponse creates a stream that returns a text response streamed in chunks.
+func TextDeltaResponse(chunks ...string) *MockStreamWithMessage {
+	return NewMockStreamWithTextDeltas(chunks)
+}
+
 // SingleToolResponse creates a stream with one tool call.
 func SingleToolResponse(toolID, toolName string, input any) *MockStreamWithMessage {
 	return NewMessageBuilder().AddToolUse(toolID, toolName, input).BuildWithToolUse()
@@ -0,0 +1,470 @@
+package tool
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// maxArchiveList is the maximum number of members returned by list.
+	maxArchiveList = 1000
+	// maxArchiveRead is the maximum member size in bytes returned by read (1 MB).
+	maxArchiveRead = 1024 * 1024
+	// maxExtractSize is the maximum total bytes written by extract (100 MB).
+	maxExtractSize = 100 * 1024 * 1024
+	// maxExtractFiles is the maximum number of files written by extract.
+	maxExtractFiles = 10000
+)
+
+// errStopWalk stops an archive walk early without reporting an error.
+var errStopWalk = errors.New("stop walk")
+
+// ArchiveTool implements the Tool interface for listing, reading, and
+// extracting members of .zip, .tar, .tar.gz/.tgz, and .gz files.
+type ArchiveTool struct{}
+
+// archiveInput defines the expected input parameters for the archive tool.
+type archiveInput struct {
+	Action      string   `json:"action"`
+	Path        string   `json:"path"`
+	Member      string   `json:"member,omitempty"`
+	Members     []string `json:"members,omitempty"`
+	Destination string   `json:"destination,omitempty"`
+}
+
+// archiveMember describes one archive entry.
+type archiveMember struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	IsDir bool   `json:"is_dir,omitempty"`
+}
+
+// archiveListOutput is the success response for list.
+type archiveListOutput struct {
+	Members   []archiveMember `json:"members"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// archiveReadOutput is the success response for read.
+type archiveReadOutput struct {
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// archiveExtractOutput is the success response for extract.
+type archiveExtractOutput struct {
+	Destination string   `json:"destination"`
+	Extracted   []string `json:"extracted"`
+	Skipped     []string `json:"skipped,omitempty"`
+	Bytes       int64    `json:"bytes"`
+}
+
+// archiveError defines the error response format.
+type archiveError struct {
+	Error string `json:"error"`
+}
+
+// NewArchiveTool creates a new ArchiveTool instance.
+func NewArchiveTool() *ArchiveTool {
+	return &ArchiveTool{}
+}
+
+// Name returns the tool identifier.
+func (t *ArchiveTool) Name() string {
+	return "archive"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *ArchiveTool) Description() string {
+	return "List, read, or extract members of .zip, .tar, .tar.gz/.tgz, and .gz files"
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *ArchiveTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"action": {"type": "string", "enum": ["list", "read", "extract"], "description": "list members, read one member, or extract members to a directory"},
+			"path": {"type": "string", "description": "Path to the archive"},
+			"member": {"type": "string", "description": "Member to read (read only)"},
+			"members": {"type": "array", "items": {"type": "string"}, "description": "Members to extract; directories include their contents (default: all)"},
+			"destination": {"type": "string", "description": "Directory to extract into (extract only)"}
+		},
+		"required": ["action", "path"]
+	}`)
+}
+
+// Execute performs the requested archive action.
+func (t *ArchiveTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params archiveInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatArchiveError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	if params.Path == "" {
+		return formatArchiveError("path is required"), nil
+	}
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatArchiveError(err.Error()), nil
+	}
+	params.Path = resolved
+
+	info, err := os.Stat(params.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return formatArchiveError("file not found"), nil
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return formatArchiveError("permission denied"), nil
+		}
+		return formatArchiveError(err.Error()), nil
+	}
+	if info.IsDir() {
+		return formatArchiveError("path is a directory"), nil
+	}
+
+	var result any
+	switch params.Action {
+	case "list":
+		result, err = listArchive(ctx, params.Path)
+	case "read":
+		if params.Member == "" {
+			return formatArchiveError("member is required for read"), nil
+		}
+		result, err = readArchiveMember(ctx, params.Path, params.Member)
+	case "extract":
+		if params.Destination == "" {
+			return formatArchiveError("destination is required for extract"), nil
+		}
+		dest, rErr := ResolvePath(ctx, params.Destination)
+		if rErr != nil {
+			return formatArchiveError(rErr.Error()), nil
+		}
+		result, err = extractArchive(ctx, params.Path, dest, params.Members)
+	default:
+		return formatArchiveError("action must be list, read, or extract"), nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return formatArchiveError(err.Error()), nil
+	}
+
+	data, _ := json.Marshal(result)
+	return string(data), nil
+}
+
+// listArchive returns the archive's members, up to maxArchiveList.
+func listArchive(ctx context.Context, archivePath string) (*archiveListOutput, error) {
+	out := &archiveListOutput{Members: []archiveMember{}}
+	err := walkArchive(ctx, archivePath, func(m archiveMember, _ string, _ io.Reader) error {
+		if len(out.Members) == maxArchiveList {
+			out.Truncated = true
+			return errStopWalk
+		}
+		out.Members = append(out.Members, m)
+		return nil
+	})
+	return out, err
+}
+
+// readArchiveMember returns the contents of one member, up to maxArchiveRead bytes.
+func readArchiveMember(ctx context.Context, archivePath, member string) (*archiveReadOutput, error) {
+	var out *archiveReadOutput
+	err := walkArchive(ctx, archivePath, func(m archiveMember, kind string, r io.Reader) error {
+		if cleanMemberName(m.Name) != cleanMemberName(member) {
+			return nil
+		}
+		if kind != memberFile {
+			return fmt.Errorf("member %q is not a regular file", member)
+		}
+		data, err := io.ReadAll(io.LimitReader(r, maxArchiveRead+1))
+		if err != nil {
+			return err
+		}
+		out = &archiveReadOutput{Content: string(data)}
+		if len(data) > maxArchiveRead {
+			out.Content = string(data[:maxArchiveRead])
+			out.Truncated = true
+		}
+		return errStopWalk
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, fmt.Errorf("member %q not found", member)
+	}
+	return out, nil
+}
+
+// extractArchive writes the selected members (all if none are given) under
+// dest. Member names that would land outside dest, or outside the
+// workspace in ctx, are rejected; links and other special entries are skipped.
+func extractArchive(ctx context.Context, archivePath, dest string, members []string) (*archiveExtractOutput, error) {
+	dest, err := filepath.Abs(dest)
+	if err != nil {
+		return nil, err
+	}
+	out := &archiveExtractOutput{Destination: dest, Extracted: []string{}}
+
+	err = walkArchive(ctx, archivePath, func(m archiveMember, kind string, r io.Reader) error {
+		name := cleanMemberName(m.Name)
+		if !selectedMember(name, members) {
+			return nil
+		}
+		if kind == memberOther {
+			out.Skipped = append(out.Skipped, m.Name)
+			return nil
+		}
+		target, err := memberTarget(dest, name)
+		if err != nil {
+			return err
+		}
+		// Existing symlinks under dest must not lead out of the workspace
+		if target, err = ResolvePath(ctx, target); err != nil {
+			return err
+		}
+
+		if kind == memberDir {
+			return os.MkdirAll(target, defaultDirPermissions)
+		}
+		if len(out.Extracted) == maxExtractFiles {
+			return fmt.Errorf("extraction exceeds %d files", maxExtractFiles)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), defaultDirPermissions); err != nil {
+			return err
+		}
+		n, err := writeMember(target, r, maxExtractSize-out.Bytes)
+		out.Bytes += n
+		if err != nil {
+			return err
+		}
+		out.Extracted = append(out.Extracted, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// writeMember copies r to a new file at target, failing if more than limit
+// bytes would be written.
+func writeMember(target string, r io.Reader, limit int64) (int64, error) {
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, defaultFilePermissions)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, limit+1))
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil && n > limit {
+		err = fmt.Errorf("extraction exceeds %d bytes", maxExtractSize)
+	}
+	return n, err
+}
+
+// memberTarget returns the path under dest for a cleaned member name,
+// rejecting names that escape dest.
+func memberTarget(dest, name string) (string, error) {
+	if name == "" || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("member %q escapes the destination", name)
+	}
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	if !strings.HasPrefix(target, dest+string(filepath.Separator)) {
+		return "", fmt.Errorf("member %q escapes the destination", name)
+	}
+	return target, nil
+}
+
+// cleanMemberName normalizes an archive member name for comparison.
+func cleanMemberName(name string) string {
+	name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	return strings.TrimPrefix(name, "./")
+}
+
+// selectedMember reports whether name is one of members or inside one of
+// them. An empty selection selects everything.
+func selectedMember(name string, members []string) bool {
+	if len(members) == 0 {
+		return true
+	}
+	for _, m := range members {
+		m = cleanMemberName(m)
+		if name == m || strings.HasPrefix(name, m+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Member kinds passed to walk callbacks.
+const (
+	memberFile  = "file"
+	memberDir   = "dir"
+	memberOther = "other" // symlinks, hard links, devices
+)
+
+// walkFunc is called for each archive member with its kind and, for files,
+// a reader over its contents. Returning errStopWalk ends the walk.
+type walkFunc func(m archiveMember, kind string, r io.Reader) error
+
+// walkArchive calls fn for each member of the archive, choosing the format
+// from the file extension.
+func walkArchive(ctx context.Context, archivePath string, fn walkFunc) error {
+	lower := strings.ToLower(archivePath)
+	var err error
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		err = walkZip(ctx, archivePath, fn)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		err = walkTar(ctx, archivePath, true, fn)
+	case strings.HasSuffix(lower, ".tar"):
+		err = walkTar(ctx, archivePath, false, fn)
+	case strings.HasSuffix(lower, ".gz"):
+		err = walkGzip(archivePath, fn)
+	default:
+		return errors.New("unsupported archive format: expected .zip, .tar, .tar.gz, .tgz, or .gz")
+	}
+	if errors.Is(err, errStopWalk) {
+		return nil
+	}
+	return err
+}
+
+// walkZip walks the members of a zip file.
+func walkZip(ctx context.Context, archivePath string, fn walkFunc) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		m := archiveMember{Name: f.Name, Size: int64(f.UncompressedSize64)}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			m.IsDir = true
+			err = fn(m, memberDir, nil)
+		case mode.IsRegular():
+			err = walkZipFile(f, m, fn)
+		default:
+			err = fn(m, memberOther, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkZipFile opens one zip member and passes it to fn.
+func walkZipFile(f *zip.File, m archiveMember, fn walkFunc) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return fn(m, memberFile, rc)
+}
+
+// walkTar walks the members of a tar file, optionally gzip-compressed.
+func walkTar(ctx context.Context, archivePath string, gzipped bool, fn walkFunc) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if gzipped {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("invalid gzip data: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %w", err)
+		}
+		m := archiveMember{Name: hdr.Name, Size: hdr.Size}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			m.IsDir = true
+			err = fn(m, memberDir, nil)
+		case tar.TypeReg:
+			err = fn(m, memberFile, tr)
+		default:
+			err = fn(m, memberOther, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// walkGzip presents a plain gzip file as an archive with one member named
+// after the file without its .gz suffix. The size is unknown (-1) until read.
+func walkGzip(archivePath string, fn walkFunc) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("invalid gzip data: %w", err)
+	}
+	defer gz.Close()
+
+	name := gz.Name
+	if name == "" {
+		base := filepath.Base(archivePath)
+		name = base[:len(base)-len(".gz")]
+	}
+	return fn(archiveMember{Name: name, Size: -1}, memberFile, gz)
+}
+
+// formatArchiveError formats an error response.
+func formatArchiveError(msg string) string {
+	output := archiveError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
@@ -0,0 +1,206 @@
+package tool
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestZip creates a zip archive with the given name → content members.
+// Names ending in "/" are directories.
+func writeTestZip(t *testing.T, path string, members map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range members {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestTarGz creates a gzip-compressed tar archive with regular files
+// and one symlink.
+func writeTestTarGz(t *testing.T, path string, members map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range members {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.WriteHeader(&tar.Header{Name: "link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink})
+	tw.Close()
+	gz.Close()
+}
+
+func runArchive(t *testing.T, ctx context.Context, input map[string]any) map[string]any {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	result, err := NewArchiveTool().Execute(ctx, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal([]byte(result), &out); err != nil {
+		t.Fatalf("failed to parse output %q: %v", result, err)
+	}
+	return out
+}
+
+func TestArchiveTool_ListAndReadZip(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "release.zip")
+	writeTestZip(t, archive, map[string]string{
+		"pkg/":        "",
+		"pkg/main.go": "package main",
+		"README.md":   "# Release",
+	})
+	ctx := context.Background()
+
+	out := runArchive(t, ctx, map[string]any{"action": "list", "path": archive})
+	members, _ := out["members"].([]any)
+	if len(members) != 3 {
+		t.Fatalf("expected 3 members, got %v", out)
+	}
+
+	out = runArchive(t, ctx, map[string]any{"action": "read", "path": archive, "member": "./pkg/main.go"})
+	if out["content"] != "package main" {
+		t.Errorf("expected member content, got %v", out)
+	}
+
+	out = runArchive(t, ctx, map[string]any{"action": "read", "path": archive, "member": "missing.txt"})
+	if !strings.Contains(out["error"].(string), "not found") {
+		t.Errorf("expected not found error, got %v", out)
+	}
+}
+
+func TestArchiveTool_ExtractTarGz(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "vendor.tar.gz")
+	writeTestTarGz(t, archive, map[string]string{
+		"lib/a.txt": "alpha",
+		"lib/b.txt": "beta",
+		"doc.txt":   "docs",
+	})
+	dest := filepath.Join(dir, "out")
+
+	out := runArchive(t, context.Background(), map[string]any{
+		"action": "extract", "path": archive, "destination": dest, "members": []string{"lib"},
+	})
+	if out["error"] != nil {
+		t.Fatalf("unexpected error: %v", out["error"])
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "lib", "a.txt")); err != nil || string(data) != "alpha" {
+		t.Errorf("expected lib/a.txt extracted, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "doc.txt")); !os.IsNotExist(err) {
+		t.Error("expected unselected member not extracted")
+	}
+	if extracted, _ := out["extracted"].([]any); len(extracted) != 2 {
+		t.Errorf("expected 2 extracted files, got %v", out["extracted"])
+	}
+
+	// Symlinks are skipped, never created
+	out = runArchive(t, context.Background(), map[string]any{"action": "extract", "path": archive, "destination": dest})
+	if skipped, _ := out["skipped"].([]any); len(skipped) != 1 || skipped[0] != "link" {
+		t.Errorf("expected symlink skipped, got %v", out)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "link")); !os.IsNotExist(err) {
+		t.Error("expected symlink not created")
+	}
+}
+
+func TestArchiveTool_ExtractRejectsEscape(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "evil.zip")
+	writeTestZip(t, archive, map[string]string{"../../escaped.txt": "pwned"})
+	dest := filepath.Join(dir, "out")
+
+	out := runArchive(t, context.Background(), map[string]any{"action": "extract", "path": archive, "destination": dest})
+	if !strings.Contains(out["error"].(string), "escapes the destination") {
+		t.Errorf("expected escape error, got %v", out)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("expected member not written outside destination")
+	}
+}
+
+func TestArchiveTool_WorkspaceJail(t *testing.T) {
+	root := t.TempDir()
+	archive := filepath.Join(root, "a.zip")
+	writeTestZip(t, archive, map[string]string{"f.txt": "x"})
+	ctx := WithWorkspace(context.Background(), root)
+
+	out := runArchive(t, ctx, map[string]any{"action": "extract", "path": "a.zip", "destination": t.TempDir()})
+	if !strings.Contains(out["error"].(string), "outside the workspace") {
+		t.Errorf("expected workspace error, got %v", out)
+	}
+
+	out = runArchive(t, ctx, map[string]any{"action": "extract", "path": "a.zip", "destination": "out"})
+	if out["error"] != nil {
+		t.Fatalf("unexpected error: %v", out["error"])
+	}
+	if _, err := os.Stat(filepath.Join(root, "out", "f.txt")); err != nil {
+		t.Errorf("expected extraction inside workspace: %v", err)
+	}
+}
+
+func TestArchiveTool_Gzip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log.gz")
+	f, _ := os.Create(path)
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte("line one\nline two"))
+	gz.Close()
+	f.Close()
+
+	out := runArchive(t, context.Background(), map[string]any{"action": "read", "path": path, "member": "app.log"})
+	if out["content"] != "line one\nline two" {
+		t.Errorf("expected decompressed content, got %v", out)
+	}
+}
+
+func TestArchiveTool_Validation(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "notes.txt")
+	os.WriteFile(plain, []byte("hi"), 0644)
+
+	tests := []struct {
+		input map[string]any
+		want  string
+	}{
+		{map[string]any{"action": "list"}, "path is required"},
+		{map[string]any{"action": "list", "path": plain}, "unsupported archive format"},
+		{map[string]any{"action": "unpack", "path": plain}, "action must be"},
+		{map[string]any{"action": "read", "path": plain}, "member is required"},
+		{map[string]any{"action": "extract", "path": plain}, "destination is required"},
+		{map[string]any{"action": "list", "path": filepath.Join(dir, "missing.zip")}, "file not found"},
+	}
+	for _, tt := range tests {
+		out := runArchive(t, context.Background(), tt.input)
+		if msg, _ := out["error"].(string); !strings.Contains(msg, tt.want) {
+			t.Errorf("input %v: expected error containing %q, got %v", tt.input, tt.want, out)
+		}
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"os"
 	"os/exec"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 const (
@@ -23,6 +26,8 @@ type BashTool struct{}
 // bashInput defines the expected input parameters for the bash tool.
 type bashInput struct {
 	Command string `json:"command"`
+	// KeepTempDir keeps the run's temporary directory after the run ends.
+	KeepTempDir bool `json:"keep_temp_dir,omitempty"`
 }
 
 // bashOutput defines the success response format.
@@ -49,7 +54,7 @@ func (t *BashTool) Name() string {
 
 // Description returns a human-readable description of the tool.
 func (t *BashTool) Description() string {
-	return "Execute a bash command and return stdout/stderr"
+	return "Execute a bash command and return stdout/stderr. $HARNESS_TMPDIR (also $TMPDIR) is a scratch directory removed when the run ends, unless a call sets keep_temp_dir to keep artifacts written there"
 }
 
 // InputSchema returns the JSON Schema for the tool's input parameters.
@@ -57,7 +62,8 @@ func (t *BashTool) InputSchema() json.RawMessage {
 	return json.RawMessage(`{
 		"type": "object",
 		"properties": {
-			"command": {"type": "string", "description": "The bash command to execute"}
+			"command": {"type": "string", "description": "The bash command to execute"},
+			"keep_temp_dir": {"type": "boolean", "description": "Keep $HARNESS_TMPDIR after the run ends, for artifacts the user should see"}
 		},
 		"required": ["command"]
 	}`)
@@ -82,17 +88,26 @@ func (t *BashTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		return formatBashError("command is required"), nil
 	}
 
+	if params.KeepTempDir {
+		KeepTempDir(ctx)
+	}
+
 	// Create context with timeout
 	cmdCtx, cancel := context.WithTimeout(ctx, bashTimeout)
 	defer cancel()
 
 	// Execute command using /bin/bash -c
 	cmd := exec.CommandContext(cmdCtx, "/bin/bash", "-c", params.Command)
+	cmd.Dir = Workspace(ctx)
+	if tmp := TempDir(ctx); tmp != "" {
+		cmd.Env = append(os.Environ(), "HARNESS_TMPDIR="+tmp, "TMPDIR="+tmp)
+	}
 
-	// Capture stdout and stderr separately
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Capture stdout and stderr separately, streaming them as they are produced
+	stdout := &outputWriter{ctx: ctx, stream: "stdout"}
+	stderr := &outputWriter{ctx: ctx, stream: "stderr"}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	// Run the command
 	err := cmd.Run()
@@ -118,13 +133,71 @@ func (t *BashTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		}
 	}
 
-	// Truncate output if necessary
+	// Flush partial characters and truncate output if necessary
+	stdout.flush()
+	stderr.flush()
 	stdoutStr := truncateOutput(stdout.String())
 	stderrStr := truncateOutput(stderr.String())
 
 	return formatBashSuccess(stdoutStr, stderrStr, exitCode), nil
 }
 
+// outputWriter captures one output stream of a command and emits each chunk
+// as a tool_output event. Only the first maxOutputSize+1 bytes are kept, which
+// is enough for truncateOutput; the stream itself is never cut short.
+type outputWriter struct {
+	ctx    context.Context
+	stream string
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	partial []byte // incomplete UTF-8 sequence held back from the last chunk
+}
+
+// Write records p and emits it, holding back a trailing incomplete UTF-8
+// sequence so multi-byte characters are never split across events.
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if room := maxOutputSize + 1 - w.buf.Len(); room > 0 {
+		w.buf.Write(p[:min(len(p), room)])
+	}
+
+	chunk := append(w.partial, p...)
+	cut := len(chunk)
+	for i := len(chunk) - 1; i >= 0 && i >= len(chunk)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(chunk[i]) {
+			if !utf8.FullRune(chunk[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	w.partial = append([]byte(nil), chunk[cut:]...)
+	if cut > 0 {
+		EmitOutput(w.ctx, w.stream, string(chunk[:cut]))
+	}
+	return len(p), nil
+}
+
+// flush emits any held-back bytes once the command has exited.
+func (w *outputWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		EmitOutput(w.ctx, w.stream, string(w.partial))
+		w.partial = nil
+	}
+}
+
+// String returns the captured output.
+func (w *outputWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
 // truncateOutput truncates the output if it exceeds maxOutputSize.
 func truncateOutput(output string) string {
 	if len(output) > maxOutputSize {
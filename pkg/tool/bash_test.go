@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -301,3 +302,114 @@ func TestBashTool_TimeoutActual(t *testing.T) {
 	// If we got an error response, it should mention timeout or similar
 	// This is acceptable behavior
 }
+
+func TestBashTool_TempDirEnv(t *testing.T) {
+	tool := NewBashTool()
+	dir := t.TempDir()
+	ctx := WithTempDir(context.Background(), dir)
+
+	input := `{"command": "echo \"$HARNESS_TMPDIR $TMPDIR\""}`
+	result, err := tool.Execute(ctx, json.RawMessage(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var output bashOutput
+	if err := json.Unmarshal([]byte(result), &output); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if want := dir + " " + dir + "\n"; output.Stdout != want {
+		t.Errorf("expected stdout %q, got %q", want, output.Stdout)
+	}
+}
+
+func TestBashTool_KeepTempDir(t *testing.T) {
+	tool := NewBashTool()
+	ctx := WithTempDir(context.Background(), t.TempDir())
+
+	if _, err := tool.Execute(ctx, json.RawMessage(`{"command": "true"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if TempDirKept(ctx) {
+		t.Error("expected the temp dir not to be kept by default")
+	}
+	if _, err := tool.Execute(ctx, json.RawMessage(`{"command": "echo report > \"$HARNESS_TMPDIR/report.txt\"", "keep_temp_dir": true}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !TempDirKept(ctx) {
+		t.Error("expected keep_temp_dir to keep the temp dir")
+	}
+}
+
+func TestBashTool_StreamsOutput(t *testing.T) {
+	var mu sync.Mutex
+	streamed := map[string]string{}
+	ctx := WithEmitter(context.Background(), EmitterFunc(func(eventType string, payload any) {
+		if eventType != OutputEventType {
+			t.Errorf("unexpected event type %q", eventType)
+			return
+		}
+		out := payload.(Output)
+		mu.Lock()
+		streamed[out.Stream] += out.Text
+		mu.Unlock()
+	}))
+
+	input := json.RawMessage(`{"command": "echo one; echo oops >&2; printf 'caf\\303'; sleep 0.05; printf '\\251\\n'"}`)
+	result, err := NewBashTool().Execute(ctx, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var output bashOutput
+	json.Unmarshal([]byte(result), &output)
+	if output.Stdout != "one\ncafé\n" {
+		t.Errorf("unexpected stdout %q", output.Stdout)
+	}
+	if streamed["stdout"] != output.Stdout || streamed["stderr"] != "oops\n" {
+		t.Errorf("streamed output does not match the result: %q", streamed)
+	}
+}
+
+func TestOutputWriter_HoldsBackPartialRunes(t *testing.T) {
+	var chunks []string
+	ctx := WithEmitter(context.Background(), EmitterFunc(func(eventType string, payload any) {
+		chunks = append(chunks, payload.(Output).Text)
+	}))
+
+	w := &outputWriter{ctx: ctx, stream: "stdout"}
+	w.Write([]byte("a\xe2\x82"))
+	w.Write([]byte("\xacb"))
+	w.Write([]byte("\xff"))
+	w.flush()
+
+	want := []string{"a", "€b", "\xff"}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected chunks %q, got %q", want, chunks)
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Errorf("chunk %d: expected %q, got %q", i, want[i], chunks[i])
+		}
+	}
+}
+
+func TestOutputWriter_CapsCapturedOutput(t *testing.T) {
+	var emitted int
+	ctx := WithEmitter(context.Background(), EmitterFunc(func(eventType string, payload any) {
+		emitted += len(payload.(Output).Text)
+	}))
+
+	w := &outputWriter{ctx: ctx, stream: "stdout"}
+	chunk := []byte(strings.Repeat("x", 64*1024))
+	for range 20 {
+		w.Write(chunk)
+	}
+
+	if len(w.String()) != maxOutputSize+1 {
+		t.Errorf("expected %d captured bytes, got %d", maxOutputSize+1, len(w.String()))
+	}
+	if emitted != 20*len(chunk) {
+		t.Errorf("expected all %d bytes to be streamed, got %d", 20*len(chunk), emitted)
+	}
+}
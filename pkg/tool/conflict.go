@@ -0,0 +1,147 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// maxConflictLines caps the lines of current content returned with a
+	// conflict.
+	maxConflictLines = 60
+	// maxConflictBytes caps the bytes of current content returned with a
+	// conflict.
+	maxConflictBytes = 16 * 1024
+)
+
+// conflictError reports that a file no longer has the content an edit or
+// write was based on: its digest is not the base_hash given.
+type conflictError struct {
+	base    string
+	current conflictRegion
+}
+
+// conflictRegion is the current content of a changed file around where an
+// edit or write was to go, numbered as read numbers lines, so the model can
+// re-plan the change without reading the file again.
+type conflictRegion struct {
+	// SHA256 is the file's current digest, the base_hash to retry with.
+	SHA256     string `json:"sha256"`
+	TotalLines int    `json:"total_lines"`
+	StartLine  int    `json:"start_line,omitempty"`
+	EndLine    int    `json:"end_line,omitempty"`
+	Content    string `json:"content"`
+}
+
+// conflictOutput is the error response for a conflict.
+type conflictOutput struct {
+	Error   string         `json:"error"`
+	Code    string         `json:"code"`
+	Current conflictRegion `json:"current"`
+}
+
+func (e *conflictError) Error() string {
+	return fmt.Sprintf("file has changed since it was read: expected sha256 %s, found %s; re-plan the change against the current content", e.base, e.current.SHA256)
+}
+
+// matchesBase reports whether the hex digest current matches base, ignoring
+// case.
+func matchesBase(base, current string) bool {
+	return strings.EqualFold(base, current)
+}
+
+// conflictLines returns the lines to show for a change to lines lo to hi:
+// the change with diffContextLines of context, capped at maxConflictLines.
+// hi may be past the end of the file.
+func conflictLines(lo, hi int) (int, int) {
+	lo = max(lo-diffContextLines, 1)
+	return lo, min(hi+diffContextLines, lo+maxConflictLines-1)
+}
+
+// newConflictError returns the conflict for a file with total lines and
+// digest current, showing the lines around lo to hi; line returns the text
+// of line n.
+func newConflictError(base, current string, total, lo, hi int, line func(n int) string) *conflictError {
+	lo, hi = conflictLines(lo, hi)
+	region := conflictRegion{SHA256: current, TotalLines: total}
+	var sb strings.Builder
+	for n := lo; n <= min(hi, total); n++ {
+		text := fmt.Sprintf("%6d\t%s", n, line(n))
+		if sb.Len()+len(text)+1 > maxConflictBytes {
+			break
+		}
+		if n > lo {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(text)
+		region.StartLine, region.EndLine = lo, n
+	}
+	region.Content = sb.String()
+	return &conflictError{base: base, current: region}
+}
+
+// changedLines returns the first and last lines of the file that ops
+// change, or 1 and 1 if none can be placed. content is the file's lines
+// joined with LF, to find the text of replace_string operations in.
+func changedLines(ops []Operation, content string) (int, int) {
+	lo, hi := 0, 0
+	add := func(start, end int) {
+		if lo == 0 || start < lo {
+			lo = start
+		}
+		hi = max(hi, end)
+	}
+	for _, op := range ops {
+		switch op.Op {
+		case "replace", "delete":
+			add(op.StartLine, op.EndLine)
+		case "insert":
+			add(max(op.AfterLine, 1), op.AfterLine+1)
+		case "replace_string":
+			old := strings.ReplaceAll(op.OldString, "\r\n", "\n")
+			if i := strings.Index(content, old); old != "" && i >= 0 {
+				start := strings.Count(content[:i], "\n") + 1
+				add(start, start+countLines(old)-1)
+			}
+		}
+	}
+	if lo == 0 {
+		return 1, 1
+	}
+	return lo, hi
+}
+
+// fileConflict returns the conflict for the file at path, with digest
+// current, showing its first lines: a write replaces the whole file.
+func fileConflict(path, base, current string) (*conflictError, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var head []string
+	lr := newLineReader(file, defaultMaxLineLength)
+	for {
+		line, err := lr.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(head) < maxConflictLines {
+			head = append(head, strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		}
+	}
+	return newConflictError(base, current, lr.n, 1, maxConflictLines, func(n int) string { return head[n-1] }), nil
+}
+
+// formatConflict formats a conflict error response.
+func formatConflict(err *conflictError) string {
+	data, _ := json.Marshal(conflictOutput{Error: err.Error(), Code: "conflict", Current: err.current})
+	return string(data)
+}
@@ -0,0 +1,102 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// parseConflict parses a conflict error result.
+func parseConflict(t *testing.T, result string) conflictOutput {
+	t.Helper()
+	var output conflictOutput
+	if err := json.Unmarshal([]byte(result), &output); err != nil {
+		t.Fatalf("failed to parse output %q: %v", result, err)
+	}
+	if output.Code != "conflict" || !strings.Contains(output.Error, "changed since it was read") {
+		t.Fatalf("expected a conflict, got %s", result)
+	}
+	return output
+}
+
+func TestEditTool_BaseHash(t *testing.T) {
+	ctx := context.Background()
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line%d", i))
+	}
+	path := createEditTestFile(t, strings.Join(lines, "\n")+"\n")
+	base := fileDigest(t, path)
+
+	// Someone else edits the file after the model read it
+	lines[10] = "theirs"
+	os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	current := fileDigest(t, path)
+
+	input, _ := json.Marshal(editInput{Path: path, BaseHash: base, Operations: []Operation{{Op: "replace", StartLine: 11, EndLine: 12, Content: []string{"mine"}}}})
+	result, _ := NewEditTool().Execute(ctx, input)
+	output := parseConflict(t, result)
+	region := output.Current
+	if region.SHA256 != current || region.TotalLines != 20 || region.StartLine != 8 || region.EndLine != 15 {
+		t.Errorf("unexpected region: %+v", region)
+	}
+	if !strings.Contains(region.Content, "    11\ttheirs\n") {
+		t.Errorf("expected the current content, got %q", region.Content)
+	}
+	if got, _ := os.ReadFile(path); !strings.Contains(string(got), "theirs") {
+		t.Errorf("file was changed: %q", got)
+	}
+
+	// A replace_string is placed by where its text is now
+	input, _ = json.Marshal(editInput{Path: path, BaseHash: base, Operations: []Operation{{Op: "replace_string", OldString: "line18", NewString: "x"}}})
+	result, _ = NewEditTool().Execute(ctx, input)
+	if region := parseConflict(t, result).Current; region.StartLine != 15 || region.EndLine != 20 {
+		t.Errorf("unexpected region: %+v", region)
+	}
+
+	// The streamed edit of a large file reports the same conflict
+	plan, err := planStreamEdit(path, path, 0644, []Operation{{Op: "replace", StartLine: 11, EndLine: 12, Content: []string{"mine"}}}, "", base, defaultMaxLineLength)
+	if conflict, ok := err.(*conflictError); !ok || plan != nil || conflict.current != region {
+		t.Errorf("expected conflict %+v, got %v", region, err)
+	}
+
+	// With the current digest the edit succeeds
+	input, _ = json.Marshal(editInput{Path: path, BaseHash: strings.ToUpper(current), Operations: []Operation{{Op: "delete", StartLine: 1, EndLine: 1}}})
+	if result, _ := NewEditTool().Execute(ctx, input); IsErrorResult(result) {
+		t.Errorf("unexpected error: %s", result)
+	}
+}
+
+func TestMultiEditTool_BaseHash(t *testing.T) {
+	path := createEditTestFile(t, "one\ntwo\n")
+	input, _ := json.Marshal(multiEditInput{Files: []multiEditFile{
+		{Path: path, BaseHash: strings.Repeat("0", 64), Operations: []Operation{{Op: "delete", StartLine: 2, EndLine: 2}}},
+	}})
+	result := parseMultiEditResult(t, mustExecute(t, NewMultiEditTool(), input))
+	if len(result.Files) != 1 || result.Files[0].Current == nil || result.Files[0].Current.Content != "     1\tone\n     2\ttwo" {
+		t.Errorf("expected the file's current content, got %+v", result.Files)
+	}
+}
+
+func TestWriteTool_BaseHashConflict(t *testing.T) {
+	path := createEditTestFile(t, "theirs\r\n")
+	input, _ := json.Marshal(writeInput{Path: path, Content: "mine", BaseHash: strings.Repeat("0", 64)})
+	result, _ := NewWriteTool().Execute(context.Background(), input)
+	output := parseConflict(t, result)
+	if output.Current.Content != "     1\ttheirs" || output.Current.SHA256 != fileDigest(t, path) {
+		t.Errorf("unexpected region: %+v", output.Current)
+	}
+}
+
+// mustExecute runs a tool and fails the test on a Go error.
+func mustExecute(t *testing.T, tool Tool, input json.RawMessage) string {
+	t.Helper()
+	result, err := tool.Execute(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return result
+}
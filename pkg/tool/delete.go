@@ -0,0 +1,212 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DeleteTool implements the Tool interface for removing files and directories.
+type DeleteTool struct{}
+
+// deleteInput defines the expected input parameters for the delete tool.
+type deleteInput struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+	DryRun    bool   `json:"dry_run"`
+}
+
+// deleteOutput defines the success response format.
+type deleteOutput struct {
+	Path   string `json:"path"`
+	Count  int    `json:"count"`
+	DryRun bool   `json:"dry_run,omitempty"`
+	// Entries lists the paths that would be removed, relative to Path's
+	// parent. Only set for dry runs.
+	Entries []string `json:"entries,omitempty"`
+}
+
+// deleteError defines the error response format.
+type deleteError struct {
+	Error string `json:"error"`
+}
+
+// NewDeleteTool creates a new DeleteTool instance.
+func NewDeleteTool() *DeleteTool {
+	return &DeleteTool{}
+}
+
+// Name returns the tool identifier.
+func (t *DeleteTool) Name() string {
+	return "delete"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *DeleteTool) Description() string {
+	return "Delete a file or directory. Non-empty directories require recursive: true; use dry_run to list what would be removed"
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *DeleteTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "description": "Path to the file or directory to delete"},
+			"recursive": {"type": "boolean", "description": "Delete a non-empty directory and everything in it (default false)"},
+			"dry_run": {"type": "boolean", "description": "List what would be removed without deleting anything (default false)"}
+		},
+		"required": ["path"]
+	}`)
+}
+
+// ChangedPaths returns the deleted path; a dry run changes nothing.
+func (t *DeleteTool) ChangedPaths(ctx context.Context, input json.RawMessage) []string {
+	var params deleteInput
+	if json.Unmarshal(input, &params) != nil || params.Path == "" || params.DryRun {
+		return nil
+	}
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return nil
+	}
+	return []string{resolved}
+}
+
+// Execute deletes the specified file or directory.
+func (t *DeleteTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params deleteInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatDeleteError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	if params.Path == "" {
+		return formatDeleteError("path is required"), nil
+	}
+
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatDeleteError(err.Error()), nil
+	}
+	abs, err := filepath.Abs(resolved)
+	if err != nil {
+		return formatDeleteError("invalid path: " + err.Error()), nil
+	}
+
+	// Never delete the workspace root (the working directory when tools are
+	// not jailed) or anything containing it
+	root := Workspace(ctx)
+	if root == "" {
+		if root, err = os.Getwd(); err != nil {
+			return formatDeleteError("cannot determine working directory: " + err.Error()), nil
+		}
+	}
+	if containsPath(abs, root) {
+		return formatDeleteError(fmt.Sprintf("refusing to delete workspace root: %s", params.Path)), nil
+	}
+
+	// Lstat so a symlink is removed itself rather than its target
+	info, err := os.Lstat(abs)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return formatDeleteError(fmt.Sprintf("path not found: %s", params.Path)), nil
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return formatDeleteError(fmt.Sprintf("permission denied: cannot read %s", params.Path)), nil
+		}
+		return formatDeleteError(err.Error()), nil
+	}
+
+	if info.IsDir() && !params.Recursive {
+		empty, err := isDirEmpty(abs)
+		if err != nil {
+			return formatDeleteError("failed to check directory: " + err.Error()), nil
+		}
+		if !empty {
+			return formatDeleteError(fmt.Sprintf("directory not empty: %s (set recursive: true to delete it and its contents)", params.Path)), nil
+		}
+	}
+
+	entries, err := deleteEntries(ctx, abs)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return formatDeleteError("failed to list entries: " + err.Error()), nil
+	}
+
+	if params.DryRun {
+		return formatDeleteSuccess(deleteOutput{Path: abs, Count: len(entries), DryRun: true, Entries: entries}), nil
+	}
+
+	if err := os.RemoveAll(abs); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return formatDeleteError(fmt.Sprintf("permission denied: cannot delete %s", params.Path)), nil
+		}
+		return formatDeleteError("failed to delete: " + err.Error()), nil
+	}
+
+	return formatDeleteSuccess(deleteOutput{Path: abs, Count: len(entries)}), nil
+}
+
+// containsPath reports whether path is dir or one of its ancestors.
+func containsPath(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	rel, err := filepath.Rel(path, dir)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// deleteEntries lists path and everything beneath it, relative to path's
+// parent, in the order they would be encountered by a walk. Symlinks are not
+// followed.
+func deleteEntries(ctx context.Context, path string) ([]string, error) {
+	parent := filepath.Dir(path)
+	var entries []string
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(parent, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			rel += string(os.PathSeparator)
+		}
+		entries = append(entries, rel)
+		return nil
+	})
+	return entries, err
+}
+
+// formatDeleteSuccess formats a successful delete response.
+func formatDeleteSuccess(output deleteOutput) string {
+	data, _ := json.Marshal(output)
+	return string(data)
+}
+
+// formatDeleteError formats an error response.
+func formatDeleteError(msg string) string {
+	output := deleteError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
@@ -0,0 +1,154 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runDelete(t *testing.T, ctx context.Context, input map[string]any) (deleteOutput, string) {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	result, err := NewDeleteTool().Execute(ctx, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var errResp deleteError
+	json.Unmarshal([]byte(result), &errResp)
+	var output deleteOutput
+	json.Unmarshal([]byte(result), &output)
+	return output, errResp.Error
+}
+
+func TestDeleteTool_InputSchema(t *testing.T) {
+	var parsed map[string]any
+	if err := json.Unmarshal(NewDeleteTool().InputSchema(), &parsed); err != nil {
+		t.Fatalf("schema should be valid JSON: %v", err)
+	}
+	props, _ := parsed["properties"].(map[string]any)
+	for _, name := range []string{"path", "recursive", "dry_run"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("schema should have %q property", name)
+		}
+	}
+}
+
+func TestDeleteTool_File(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	os.WriteFile(path, []byte("x"), 0644)
+
+	output, errMsg := runDelete(t, context.Background(), map[string]any{"path": path})
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if output.Path != path || output.Count != 1 {
+		t.Errorf("unexpected output %+v", output)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("file should be deleted")
+	}
+}
+
+func TestDeleteTool_Directory(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	os.Mkdir(empty, 0755)
+	full := filepath.Join(dir, "full")
+	os.MkdirAll(filepath.Join(full, "sub"), 0755)
+	os.WriteFile(filepath.Join(full, "sub", "b.txt"), []byte("x"), 0644)
+
+	if _, errMsg := runDelete(t, context.Background(), map[string]any{"path": empty}); errMsg != "" {
+		t.Fatalf("empty directory should delete without recursive: %s", errMsg)
+	}
+
+	_, errMsg := runDelete(t, context.Background(), map[string]any{"path": full})
+	if !strings.Contains(errMsg, "directory not empty") {
+		t.Fatalf("expected directory not empty error, got %q", errMsg)
+	}
+	if _, err := os.Stat(full); err != nil {
+		t.Fatal("non-empty directory should remain")
+	}
+
+	output, errMsg := runDelete(t, context.Background(), map[string]any{"path": full, "recursive": true})
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if output.Count != 3 {
+		t.Errorf("expected 3 entries removed, got %d", output.Count)
+	}
+	if _, err := os.Stat(full); !os.IsNotExist(err) {
+		t.Error("directory should be deleted")
+	}
+}
+
+func TestDeleteTool_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "pkg")
+	os.MkdirAll(filepath.Join(target, "sub"), 0755)
+	os.WriteFile(filepath.Join(target, "a.go"), []byte("x"), 0644)
+
+	output, errMsg := runDelete(t, context.Background(), map[string]any{"path": target, "recursive": true, "dry_run": true})
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	sep := string(os.PathSeparator)
+	want := []string{"pkg" + sep, filepath.Join("pkg", "a.go"), filepath.Join("pkg", "sub") + sep}
+	if !output.DryRun || strings.Join(output.Entries, ",") != strings.Join(want, ",") {
+		t.Errorf("expected entries %v, got %+v", want, output)
+	}
+	if _, err := os.Stat(filepath.Join(target, "a.go")); err != nil {
+		t.Error("dry run should not delete anything")
+	}
+}
+
+func TestDeleteTool_WorkspaceRoot(t *testing.T) {
+	root := t.TempDir()
+	ctx := WithWorkspace(context.Background(), root)
+
+	for _, path := range []string{".", root, filepath.Dir(root)} {
+		_, errMsg := runDelete(t, ctx, map[string]any{"path": path, "recursive": true})
+		if !strings.Contains(errMsg, "workspace root") && !strings.Contains(errMsg, "outside") {
+			t.Errorf("delete %q: expected refusal, got %q", path, errMsg)
+		}
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Fatal("workspace root should remain")
+	}
+
+	os.WriteFile(filepath.Join(root, "a.txt"), []byte("x"), 0644)
+	if _, errMsg := runDelete(t, ctx, map[string]any{"path": "a.txt"}); errMsg != "" {
+		t.Errorf("relative delete inside workspace failed: %s", errMsg)
+	}
+}
+
+func TestDeleteTool_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	os.Mkdir(target, 0755)
+	os.WriteFile(filepath.Join(target, "keep.txt"), []byte("x"), 0644)
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if _, errMsg := runDelete(t, context.Background(), map[string]any{"path": link}); errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Error("link should be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(target, "keep.txt")); err != nil {
+		t.Error("link target should remain")
+	}
+}
+
+func TestDeleteTool_NotFound(t *testing.T) {
+	_, errMsg := runDelete(t, context.Background(), map[string]any{"path": filepath.Join(t.TempDir(), "missing")})
+	if !strings.Contains(errMsg, "path not found") {
+		t.Errorf("expected not found error, got %q", errMsg)
+	}
+}
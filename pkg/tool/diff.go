@@ -0,0 +1,175 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContextLines is the number of unchanged lines shown around each
+	// change in a unified diff.
+	diffContextLines = 3
+	// maxDiffCells caps the size of the table used to align the changed
+	// region of two files. Larger regions are shown as a whole-block
+	// replacement instead of a minimal diff.
+	maxDiffCells = 4 << 20
+	// maxDiffBytes caps the length of a diff returned to the model.
+	maxDiffBytes = 64 * 1024
+)
+
+// diffLine is one line of an edit script.
+type diffLine struct {
+	kind byte // ' ' unchanged, '-' removed, or '+' added
+	text string
+	// a and b are the 0-based indexes in the old and new files of the
+	// next line at this point of the script.
+	a, b int
+}
+
+// unifiedDiff returns a unified diff turning a into b, with name in the
+// file headers. It returns "" when a and b are equal. Diffs longer than
+// maxDiffBytes are cut at a line boundary and end with a notice.
+func unifiedDiff(name string, a, b []string) string {
+	var sb strings.Builder
+	writeDiffHunks(&sb, name, diffLines(a, b))
+	return truncateDiff(sb.String())
+}
+
+// writeDiffHunks writes the hunks of an edit script, with diffContextLines
+// of context, preceded by the file headers if sb is empty.
+func writeDiffHunks(sb *strings.Builder, name string, lines []diffLine) {
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the gap to the next change is small enough
+		// for their context to touch
+		last := i
+		for j := i + 1; j < len(lines) && j-last <= 2*diffContextLines; j++ {
+			if lines[j].kind != ' ' {
+				last = j
+			}
+		}
+		start := max(i-diffContextLines, 0)
+		stop := min(last+diffContextLines+1, len(lines))
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(sb, "--- %s\n+++ %s\n", name, name)
+		}
+		writeDiffHunk(sb, lines[start:stop])
+		i = stop
+	}
+}
+
+// writeDiffHunk writes one hunk header and its lines.
+func writeDiffHunk(sb *strings.Builder, hunk []diffLine) {
+	aCount, bCount := 0, 0
+	for _, l := range hunk {
+		if l.kind != '+' {
+			aCount++
+		}
+		if l.kind != '-' {
+			bCount++
+		}
+	}
+	// An empty range is numbered by the line before it
+	aStart, bStart := hunk[0].a+1, hunk[0].b+1
+	if aCount == 0 {
+		aStart--
+	}
+	if bCount == 0 {
+		bStart--
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+	for _, l := range hunk {
+		sb.WriteByte(l.kind)
+		sb.WriteString(l.text)
+		sb.WriteByte('\n')
+	}
+}
+
+// truncateDiff cuts diff to at most maxDiffBytes at a line boundary.
+func truncateDiff(diff string) string {
+	if len(diff) <= maxDiffBytes {
+		return diff
+	}
+	cut := strings.LastIndexByte(diff[:maxDiffBytes], '\n') + 1
+	omitted := strings.Count(diff[cut:], "\n")
+	return diff[:cut] + fmt.Sprintf("... diff truncated, %d more lines\n", omitted)
+}
+
+// diffLines returns an edit script turning a into b. The common prefix and
+// suffix are matched directly; the region between them is aligned by
+// longest common subsequence when it fits in maxDiffCells.
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var script []diffLine
+	ai, bi := 0, 0
+	emit := func(kind byte, text string) {
+		script = append(script, diffLine{kind: kind, text: text, a: ai, b: bi})
+		if kind != '+' {
+			ai++
+		}
+		if kind != '-' {
+			bi++
+		}
+	}
+
+	for _, line := range a[:prefix] {
+		emit(' ', line)
+	}
+	am, bm := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(am)*len(bm) <= maxDiffCells {
+		// lcs[i][j] is the LCS length of am[i:] and bm[j:]
+		n, m := len(am), len(bm)
+		lcs := make([][]int32, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if am[i] == bm[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && am[i] == bm[j]:
+				emit(' ', am[i])
+				i++
+				j++
+			case j == m || i < n && lcs[i+1][j] >= lcs[i][j+1]:
+				emit('-', am[i])
+				i++
+			default:
+				emit('+', bm[j])
+				j++
+			}
+		}
+	} else {
+		for _, line := range am {
+			emit('-', line)
+		}
+		for _, line := range bm {
+			emit('+', line)
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		emit(' ', line)
+	}
+	return script
+}
@@ -0,0 +1,73 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb", "a\nb", ""},
+		{
+			"replace in middle",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9",
+			"1\n2\n3\n4\nfive\n6\n7\n8\n9",
+			"--- f\n+++ f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"insert at start",
+			"a\nb",
+			"new\na\nb",
+			"--- f\n+++ f\n@@ -1,2 +1,3 @@\n+new\n a\n b\n",
+		},
+		{
+			"delete everything",
+			"a\nb",
+			"",
+			"--- f\n+++ f\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12",
+			"one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve",
+			"--- f\n+++ f\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			"interleaved",
+			"a\nb\nc\nd",
+			"a\nx\nc\ny",
+			"--- f\n+++ f\n@@ -1,4 +1,4 @@\n a\n-b\n+x\n c\n-d\n+y\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("f", diffTestLines(tt.a), diffTestLines(tt.b)); got != tt.want {
+				t.Errorf("expected diff:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff_Truncated(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	diff := unifiedDiff("f", nil, strings.Split(b.String(), "\n"))
+	if len(diff) > maxDiffBytes+100 || !strings.Contains(diff, "... diff truncated") {
+		t.Errorf("expected a truncated diff, got %d bytes", len(diff))
+	}
+}
+
+// diffTestLines splits s into lines, with no lines for "".
+func diffTestLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
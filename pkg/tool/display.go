@@ -0,0 +1,74 @@
+package tool
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+// Display kinds of the built-in tools.
+const (
+	// DisplayDiff is a unified diff of a change, from edit and patch.
+	DisplayDiff = "diff"
+	// DisplayFile is a range of a text file's lines, from read.
+	DisplayFile = "file"
+)
+
+// DiffDisplay is the payload of a DisplayDiff hint.
+type DiffDisplay struct {
+	// Path is the file changed; empty for a patch, whose diff names its files.
+	Path string `json:"path,omitempty"`
+	Diff string `json:"diff"`
+	// DryRun is set when the change was previewed, not written.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// FileDisplay is the payload of a DisplayFile hint.
+type FileDisplay struct {
+	Path string `json:"path"`
+	// Language is the language to highlight the lines as, from the file's
+	// extension or name, or "" if unknown.
+	Language string `json:"language,omitempty"`
+	// StartLine and EndLine are the lines returned, from 1 and inclusive;
+	// both are 0 when none were.
+	StartLine  int `json:"start_line"`
+	EndLine    int `json:"end_line"`
+	TotalLines int `json:"total_lines"`
+}
+
+// fileLanguages maps file extensions, and names without one, to the
+// language their lines are highlighted as.
+var fileLanguages = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".mjs": "javascript",
+	".jsx": "jsx", ".ts": "typescript", ".tsx": "tsx", ".rs": "rust",
+	".java": "java", ".kt": "kotlin", ".c": "c", ".h": "c", ".cc": "cpp",
+	".cpp": "cpp", ".hpp": "cpp", ".cs": "csharp", ".rb": "ruby",
+	".php": "php", ".swift": "swift", ".sh": "bash", ".bash": "bash",
+	".sql": "sql", ".json": "json", ".yaml": "yaml", ".yml": "yaml",
+	".toml": "toml", ".xml": "xml", ".html": "html", ".css": "css",
+	".md": "markdown", ".proto": "protobuf", ".diff": "diff", ".patch": "diff",
+	"Dockerfile": "dockerfile", "Makefile": "makefile", "go.mod": "gomod",
+}
+
+// fileLanguage returns the language of the file at path, or "".
+func fileLanguage(path string) string {
+	name := filepath.Base(path)
+	if lang, ok := fileLanguages[name]; ok {
+		return lang
+	}
+	return fileLanguages[strings.ToLower(filepath.Ext(name))]
+}
+
+// diffDisplay returns a DisplayDiff hint, or nil if diff is empty.
+func diffDisplay(path, diff string, dryRun bool) *Display {
+	if diff == "" {
+		return nil
+	}
+	return &Display{Kind: DisplayDiff, Payload: DiffDisplay{Path: path, Diff: diff, DryRun: dryRun}}
+}
+
+// decodeResult decodes a successful tool result into v, and reports whether
+// result was one.
+func decodeResult(result string, v any) bool {
+	return !IsErrorResult(result) && json.Unmarshal([]byte(result), v) == nil
+}
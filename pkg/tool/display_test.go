@@ -0,0 +1,121 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// executeWithDisplay runs t with input and returns its display hint.
+func executeWithDisplay(t *testing.T, tool DisplayTool, input map[string]any) *Display {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	_, display, err := tool.ExecuteWithDisplay(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return display
+}
+
+func TestEditTool_DiffDisplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(path, []byte("one\ntwo\n"), 0644)
+
+	display := executeWithDisplay(t, NewEditTool(), map[string]any{
+		"path":       path,
+		"operations": []map[string]any{{"op": "replace", "startLine": 2, "endLine": 2, "content": []string{"TWO"}}},
+		"dry_run":    true,
+	})
+	if display == nil || display.Kind != DisplayDiff {
+		t.Fatalf("expected a diff hint, got %+v", display)
+	}
+	payload := display.Payload.(DiffDisplay)
+	if payload.Path != path || !payload.DryRun || payload.Diff == "" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	// Failed edits and edits that change nothing have none
+	for _, input := range []map[string]any{
+		{"path": path, "operations": []map[string]any{{"op": "delete", "startLine": 9, "endLine": 9}}},
+		{"path": path, "operations": []map[string]any{{"op": "replace", "startLine": 1, "endLine": 1, "content": []string{"one"}}}},
+	} {
+		if display := executeWithDisplay(t, NewEditTool(), input); display != nil {
+			t.Errorf("expected no hint for %v, got %+v", input, display)
+		}
+	}
+}
+
+func TestPatchTool_DiffDisplay(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\n"), 0644)
+	patch := "--- a.txt\n+++ a.txt\n@@ -1,2 +1,2 @@\n one\n-two\n+TWO\n"
+
+	data, _ := json.Marshal(map[string]any{"patch": patch})
+	_, display, err := NewPatchTool().ExecuteWithDisplay(WithWorkspace(context.Background(), dir), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if display == nil || display.Kind != DisplayDiff || display.Payload.(DiffDisplay).Diff != patch {
+		t.Errorf("expected the patch as a diff hint, got %+v", display)
+	}
+
+	// A patch none of whose hunks apply has none
+	_, display, _ = NewPatchTool().ExecuteWithDisplay(WithWorkspace(context.Background(), dir), data)
+	if display != nil {
+		t.Errorf("expected no hint for a patch that did not apply, got %+v", display)
+	}
+}
+
+func TestReadTool_FileDisplay(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0644)
+
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  FileDisplay
+	}{
+		{"whole file", map[string]any{"path": path}, FileDisplay{Path: path, Language: "go", StartLine: 1, EndLine: 3, TotalLines: 3}},
+		{"line range", map[string]any{"path": path, "start_line": 2, "end_line": 2}, FileDisplay{Path: path, Language: "go", StartLine: 2, EndLine: 2, TotalLines: 3}},
+		{"offset and limit", map[string]any{"path": path, "offset": 1, "limit": 5}, FileDisplay{Path: path, Language: "go", StartLine: 2, EndLine: 3, TotalLines: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display := executeWithDisplay(t, NewReadTool(), tt.input)
+			if display == nil || display.Kind != DisplayFile {
+				t.Fatalf("expected a file hint, got %+v", display)
+			}
+			if got := display.Payload.(FileDisplay); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	// Binary files and errors have none
+	binary := filepath.Join(dir, "data.bin")
+	os.WriteFile(binary, []byte{0, 1, 2}, 0644)
+	for _, input := range []map[string]any{{"path": binary}, {"path": filepath.Join(dir, "missing.go")}} {
+		if display := executeWithDisplay(t, NewReadTool(), input); display != nil {
+			t.Errorf("expected no hint for %v, got %+v", input, display)
+		}
+	}
+}
+
+func TestFileLanguage(t *testing.T) {
+	tests := map[string]string{
+		"pkg/tool/read.go": "go",
+		"web/App.TSX":      "tsx",
+		"Dockerfile":       "dockerfile",
+		"sub/go.mod":       "gomod",
+		"notes.txt":        "",
+		"LICENSE":          "",
+	}
+	for path, want := range tests {
+		if got := fileLanguage(path); got != want {
+			t.Errorf("fileLanguage(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package tool
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,13 +14,46 @@ import (
 	"strings"
 )
 
+const (
+	// defaultMaxLineLength is the longest line, in bytes, an edit reads
+	// unless the context sets another limit.
+	defaultMaxLineLength = 1 << 20
+	// streamEditSize is the file size above which line operations are
+	// applied while copying the file, rather than to its content in memory.
+	streamEditSize = 8 << 20
+)
+
 // EditTool implements the Tool interface for line-based file editing.
 type EditTool struct{}
 
+// maxLineLengthKey is the context key for the edit tools' line length limit.
+type maxLineLengthKey struct{}
+
+// WithMaxLineLength returns a copy of ctx in which the edit tools fail on a
+// file with a line longer than n bytes. n <= 0 restores the default, 1MB.
+func WithMaxLineLength(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxLineLengthKey{}, n)
+}
+
+// MaxLineLength returns the edit tools' line length limit in ctx.
+func MaxLineLength(ctx context.Context) int {
+	if n, _ := ctx.Value(maxLineLengthKey{}).(int); n > 0 {
+		return n
+	}
+	return defaultMaxLineLength
+}
+
 // editInput defines the expected input parameters for the edit tool.
 type editInput struct {
 	Path       string      `json:"path"`
 	Operations []Operation `json:"operations"`
+	DryRun     bool        `json:"dry_run,omitempty"`
+	// EOL is "lf" or "crlf" to rewrite the file with that line ending; by
+	// default the file's own line ending is kept.
+	EOL string `json:"eol,omitempty"`
+	// BaseHash guards the edit: it fails with a conflict unless the file's
+	// current content has this hex SHA-256 digest.
+	BaseHash string `json:"base_hash,omitempty"`
 }
 
 // Operation represents a single edit operation.
@@ -28,6 +63,11 @@ type Operation struct {
 	EndLine   int      `json:"endLine,omitempty"`
 	AfterLine int      `json:"afterLine,omitempty"`
 	Content   []string `json:"content,omitempty"`
+
+	// replace_string fields
+	OldString           string `json:"oldString,omitempty"`
+	NewString           string `json:"newString,omitempty"`
+	ExpectedOccurrences int    `json:"expectedOccurrences,omitempty"`
 }
 
 // editOutput defines the success response format.
@@ -35,6 +75,13 @@ type editOutput struct {
 	Path         string `json:"path"`
 	LinesChanged int    `json:"linesChanged"`
 	NewLineCount int    `json:"newLineCount"`
+	// Diff is a unified diff of the change, empty if the file is unchanged.
+	Diff string `json:"diff"`
+	// DryRun is set when the file was left unwritten.
+	DryRun bool `json:"dry_run,omitempty"`
+	// EOL is the line ending written, lf or crlf, when the eol option
+	// changed it.
+	EOL string `json:"eol,omitempty"`
 }
 
 // editError defines the error response format.
@@ -54,7 +101,7 @@ func (t *EditTool) Name() string {
 
 // Description returns a human-readable description of the tool.
 func (t *EditTool) Description() string {
-	return "Edit a file using line-based operations (replace, insert, delete)"
+	return "Edit a file using line-based operations (replace, insert, delete) or exact string replacement (replace_string). Returns a unified diff of the change to check against what you intended; set dry_run to preview the diff without writing. The file's line endings and final newline are preserved unless eol is set. Pass base_hash (the sha256 from read) so an edit to a file changed since you read it fails with its current content instead"
 }
 
 // InputSchema returns the JSON Schema for the tool's input parameters.
@@ -69,20 +116,39 @@ func (t *EditTool) InputSchema() json.RawMessage {
 				"items": {
 					"type": "object",
 					"properties": {
-						"op": {"type": "string", "enum": ["replace", "insert", "delete"]},
+						"op": {"type": "string", "enum": ["replace", "insert", "delete", "replace_string"]},
 						"startLine": {"type": "integer", "description": "First line (1-indexed) for replace/delete"},
 						"endLine": {"type": "integer", "description": "Last line (inclusive) for replace/delete"},
 						"afterLine": {"type": "integer", "description": "Insert after this line (0 = beginning)"},
-						"content": {"type": "array", "items": {"type": "string"}, "description": "Lines to insert/replace with"}
+						"content": {"type": "array", "items": {"type": "string"}, "description": "Lines to insert/replace with"},
+						"oldString": {"type": "string", "description": "Exact text to find for replace_string, may span lines"},
+						"newString": {"type": "string", "description": "Replacement text for replace_string"},
+						"expectedOccurrences": {"type": "integer", "description": "Number of times oldString must occur for replace_string (default: 1); every occurrence is replaced"}
 					},
 					"required": ["op"]
 				}
-			}
+			},
+			"dry_run": {"type": "boolean", "description": "Validate the operations and return the diff without writing the file (default false)"},
+			"eol": {"type": "string", "enum": ["lf", "crlf"], "description": "Line ending to write the whole file with (default: keep the file's own)"},
+			"base_hash": {"type": "string", "description": "Edit only if the file's current SHA-256 matches this hex digest, as returned by read"}
 		},
 		"required": ["path", "operations"]
 	}`)
 }
 
+// ChangedPaths returns the edited file; a dry run changes nothing.
+func (t *EditTool) ChangedPaths(ctx context.Context, input json.RawMessage) []string {
+	var params editInput
+	if json.Unmarshal(input, &params) != nil || params.Path == "" || params.DryRun {
+		return nil
+	}
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return nil
+	}
+	return []string{resolved}
+}
+
 // Execute performs the edit operations on the specified file.
 func (t *EditTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
 	var params editInput
@@ -97,120 +163,298 @@ func (t *EditTool) Execute(ctx context.Context, input json.RawMessage) (string,
 	default:
 	}
 
+	plan, err := planEdit(ctx, params.Path, params.Operations, params.EOL, params.BaseHash)
+	if err != nil {
+		var conflict *conflictError
+		if errors.As(err, &conflict) {
+			return formatConflict(conflict), nil
+		}
+		return formatEditError(err.Error()), nil
+	}
+	plan.output.DryRun = params.DryRun
+	if params.DryRun {
+		return formatEditSuccess(plan.output), nil
+	}
+
+	// Write atomically
+	if err := atomicWriteEditWith(plan.absPath, plan.perm, plan.write); err != nil {
+		return formatEditError(editWriteError(params.Path, err)), nil
+	}
+
+	return formatEditSuccess(plan.output), nil
+}
+
+// ExecuteWithDisplay performs the edit and attaches its diff as a
+// DisplayDiff hint, when it changed anything.
+func (t *EditTool) ExecuteWithDisplay(ctx context.Context, input json.RawMessage) (string, *Display, error) {
+	result, err := t.Execute(ctx, input)
+	var output editOutput
+	if err != nil || !decodeResult(result, &output) {
+		return result, nil, err
+	}
+	return result, diffDisplay(output.Path, output.Diff, output.DryRun), nil
+}
+
+// editPlan is a validated edit of one file, ready to be written.
+type editPlan struct {
+	absPath string
+	perm    os.FileMode
+	// write writes the edited file's content to w.
+	write  func(w *bufio.Writer) error
+	output editOutput
+}
+
+// planEdit reads the file at path and applies ops to its content in memory,
+// keeping the file's line ending unless eol is "lf" or "crlf". If baseHash
+// is set and the file's digest differs, it returns a *conflictError. Line
+// operations on a file larger than streamEditSize are planned by
+// planStreamEdit instead. Nothing is written. Errors are messages for the
+// model.
+func planEdit(ctx context.Context, path string, ops []Operation, eol, baseHash string) (*editPlan, error) {
 	// Validate path
-	if params.Path == "" {
-		return formatEditError("path is required"), nil
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+
+	// Resolve path within the workspace, if any
+	resolved, err := ResolvePath(ctx, path)
+	if err != nil {
+		return nil, err
 	}
 
 	// Resolve to absolute path
-	absPath, err := filepath.Abs(params.Path)
+	absPath, err := filepath.Abs(resolved)
 	if err != nil {
-		return formatEditError("invalid path: " + err.Error()), nil
+		return nil, errors.New("invalid path: " + err.Error())
 	}
 
 	// Check if file exists
 	info, err := os.Stat(absPath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return formatEditError(fmt.Sprintf("file not found: %s", params.Path)), nil
+			return nil, fmt.Errorf("file not found: %s", path)
 		}
 		if errors.Is(err, os.ErrPermission) {
-			return formatEditError(fmt.Sprintf("permission denied: %s", params.Path)), nil
+			return nil, fmt.Errorf("permission denied: %s", path)
 		}
-		return formatEditError(err.Error()), nil
+		return nil, err
 	}
 
 	// Check if path is a directory
 	if info.IsDir() {
-		return formatEditError(fmt.Sprintf("path is a directory: %s", params.Path)), nil
+		return nil, fmt.Errorf("path is a directory: %s", path)
+	}
+
+	// Validate operations; an eol change alone needs none
+	if eol != "" && eol != "lf" && eol != "crlf" {
+		return nil, fmt.Errorf("invalid eol: %q (must be lf or crlf)", eol)
+	}
+	if len(ops) == 0 && eol == "" {
+		return nil, errors.New("no operations provided")
 	}
 
-	// Validate operations
-	if len(params.Operations) == 0 {
-		return formatEditError("no operations provided"), nil
+	maxLine := MaxLineLength(ctx)
+	if info.Size() > streamEditSize && !hasStringOps(ops) {
+		return planStreamEdit(path, absPath, info.Mode(), ops, eol, baseHash, maxLine)
 	}
 
 	// Read file into lines
-	lines, err := readLines(absPath)
+	data, err := os.ReadFile(absPath)
 	if err != nil {
-		if errors.Is(err, os.ErrPermission) {
-			return formatEditError(fmt.Sprintf("permission denied: %s", params.Path)), nil
+		return nil, editReadError(path, err)
+	}
+	lines, layout := splitLines(string(data))
+	for i, line := range lines {
+		if len(line) > maxLine {
+			return nil, editReadError(path, lineTooLongError(i+1, maxLine))
+		}
+	}
+	if baseHash != "" {
+		sum := sha256.Sum256(data)
+		if current := hex.EncodeToString(sum[:]); !matchesBase(baseHash, current) {
+			lo, hi := changedLines(ops, strings.Join(lines, "\n"))
+			return nil, newConflictError(baseHash, current, len(lines), lo, hi, func(n int) string { return lines[n-1] })
 		}
-		return formatEditError("failed to read file: " + err.Error()), nil
 	}
 
 	totalLines := len(lines)
+	original := lines
 
 	// Validate all operations before applying any
-	if err := validateOperations(params.Operations, totalLines); err != nil {
-		return formatEditError(err.Error()), nil
+	if err := validateOperations(ops, totalLines); err != nil {
+		return nil, err
 	}
 
 	// Check for overlapping operations
-	if err := checkOverlaps(params.Operations); err != nil {
-		return formatEditError(err.Error()), nil
+	if err := checkOverlaps(ops); err != nil {
+		return nil, err
+	}
+
+	// Line operations refer to the original line numbers, so they are
+	// applied first; string replacements then run in the order given
+	var sortedOps, stringOps []Operation
+	for _, op := range ops {
+		if op.Op == "replace_string" {
+			stringOps = append(stringOps, op)
+		} else {
+			sortedOps = append(sortedOps, op)
+		}
 	}
 
 	// Sort operations by position descending (highest first)
-	sortedOps := make([]Operation, len(params.Operations))
-	copy(sortedOps, params.Operations)
 	sort.Slice(sortedOps, func(i, j int) bool {
 		return getOperationPosition(sortedOps[i]) > getOperationPosition(sortedOps[j])
 	})
 
-	// Count lines changed
-	linesChanged := 0
-	for _, op := range sortedOps {
-		switch op.Op {
-		case "replace":
-			linesChanged += (op.EndLine - op.StartLine + 1) + len(op.Content)
-		case "insert":
-			linesChanged += len(op.Content)
-		case "delete":
-			linesChanged += op.EndLine - op.StartLine + 1
-		}
-	}
+	linesChanged := countChangedLines(sortedOps)
 
 	// Apply each operation
 	for _, op := range sortedOps {
 		lines = applyOperation(lines, op)
 	}
 
-	// Write atomically
+	// String replacements see the lines joined with LF, whatever the file's
+	// line ending
 	content := strings.Join(lines, "\n")
-	if err := atomicWriteEdit(absPath, content, info.Mode()); err != nil {
-		if errors.Is(err, os.ErrPermission) {
-			return formatEditError(fmt.Sprintf("permission denied: %s", params.Path)), nil
+	for i, op := range stringOps {
+		var changed int
+		if layout.eol == "\r\n" {
+			op.OldString = strings.ReplaceAll(op.OldString, "\r\n", "\n")
+			op.NewString = strings.ReplaceAll(op.NewString, "\r\n", "\n")
+		}
+		content, changed, err = replaceString(content, op)
+		if err != nil {
+			return nil, fmt.Errorf("replace_string %d: %s", i+1, err)
 		}
-		return formatEditError("failed to write file: " + err.Error()), nil
+		linesChanged += changed
+	}
+	newLines := lines
+	if len(stringOps) > 0 {
+		newLines = nil
+		if content != "" {
+			newLines = strings.Split(content, "\n")
+		}
+	}
+
+	// An explicit line ending replaces the file's, and any stray carriage
+	// returns with it
+	eolChanged := layout.setEOL(eol)
+	if eol != "" {
+		newLines = trimCarriageReturns(newLines)
 	}
 
-	return formatEditSuccess(absPath, linesChanged, len(lines)), nil
+	content = layout.join(newLines)
+	return &editPlan{
+		absPath: absPath,
+		perm:    info.Mode(),
+		write: func(w *bufio.Writer) error {
+			_, err := w.WriteString(content)
+			return err
+		},
+		output: editOutput{
+			Path:         absPath,
+			LinesChanged: linesChanged,
+			NewLineCount: len(newLines),
+			Diff:         unifiedDiff(path, original, newLines),
+			EOL:          eolChanged,
+		},
+	}, nil
 }
 
-// readLines reads a file and returns its lines.
-func readLines(path string) ([]string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
+// editReadError returns the message for a failure to read the file at path.
+func editReadError(path string, err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("permission denied: %s", path)
 	}
-	defer file.Close()
+	return errors.New("failed to read file: " + err.Error())
+}
+
+// lineTooLongError returns the message for line n exceeding max bytes.
+func lineTooLongError(n, max int) error {
+	return fmt.Errorf("line %d is longer than %d bytes", n, max)
+}
 
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// hasStringOps reports whether ops include a replace_string operation.
+func hasStringOps(ops []Operation) bool {
+	for _, op := range ops {
+		if op.Op == "replace_string" {
+			return true
+		}
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	return false
+}
+
+// textLayout is how a file's lines are laid out: the line ending between
+// them and whether the last line ends with one too.
+type textLayout struct {
+	eol          string
+	finalNewline bool
+}
+
+// splitLines splits content into lines and returns them with its layout.
+// The line ending is CRLF if most lines end with CRLF, and then the lines
+// are returned without it; otherwise it is LF, and a line ending in a stray
+// carriage return keeps it, so the file is written back unchanged.
+func splitLines(content string) ([]string, textLayout) {
+	layout := textLayout{eol: "\n", finalNewline: strings.HasSuffix(content, "\n")}
+	if content == "" {
+		return []string{}, layout
+	}
+	crlf := strings.Count(content, "\r\n")
+	if crlf > strings.Count(content, "\n")-crlf {
+		layout.eol = "\r\n"
 	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if layout.eol == "\r\n" {
+		for i, line := range lines {
+			lines[i] = strings.TrimSuffix(line, "\r")
+		}
+	}
+	return lines, layout
+}
 
-	// Handle empty file
-	if lines == nil {
-		lines = []string{}
+// setEOL sets the layout's line ending to eol, "lf" or "crlf", if given,
+// and returns eol if that changed it, or "".
+func (l *textLayout) setEOL(eol string) string {
+	if eol == "" {
+		return ""
 	}
+	want := map[string]string{"lf": "\n", "crlf": "\r\n"}[eol]
+	if want == l.eol {
+		return ""
+	}
+	l.eol = want
+	return eol
+}
 
-	return lines, nil
+// join joins lines back into file content with the layout's line ending,
+// ending the last line with one if the file did.
+func (l textLayout) join(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	content := strings.Join(lines, l.eol)
+	if l.finalNewline {
+		content += l.eol
+	}
+	return content
+}
+
+// countChangedLines returns the lines removed plus the lines added by the
+// line operations in ops.
+func countChangedLines(ops []Operation) int {
+	changed := 0
+	for _, op := range ops {
+		switch op.Op {
+		case "replace":
+			changed += (op.EndLine - op.StartLine + 1) + len(op.Content)
+		case "insert":
+			changed += len(op.Content)
+		case "delete":
+			changed += op.EndLine - op.StartLine + 1
+		}
+	}
+	return changed
 }
 
 // validateOperations checks that all operations are valid for the given file.
@@ -253,6 +497,14 @@ func validateOperations(ops []Operation, totalLines int) error {
 				return fmt.Errorf("line %d out of range (file has %d lines)", op.EndLine, totalLines)
 			}
 
+		case "replace_string":
+			if op.OldString == "" {
+				return fmt.Errorf("oldString is required for replace_string (operation %d)", i+1)
+			}
+			if op.ExpectedOccurrences < 0 {
+				return fmt.Errorf("invalid expectedOccurrences: %d (must be >= 1)", op.ExpectedOccurrences)
+			}
+
 		default:
 			return fmt.Errorf("unknown operation: %s (operation %d)", op.Op, i+1)
 		}
@@ -323,6 +575,34 @@ func applyOperation(lines []string, op Operation) []string {
 	return lines
 }
 
+// replaceString replaces every occurrence of op.OldString in content after
+// checking the number of occurrences, and returns the new content and the
+// number of lines changed.
+func replaceString(content string, op Operation) (string, int, error) {
+	expected := op.ExpectedOccurrences
+	if expected == 0 {
+		expected = 1
+	}
+	count := strings.Count(content, op.OldString)
+	if count == 0 {
+		return "", 0, errors.New("oldString not found")
+	}
+	if count != expected {
+		return "", 0, fmt.Errorf("oldString found %d times, expected %d; add surrounding context or set expectedOccurrences", count, expected)
+	}
+	changed := count * (countLines(op.OldString) + countLines(op.NewString))
+	return strings.ReplaceAll(content, op.OldString, op.NewString), changed, nil
+}
+
+// countLines returns the number of lines in s, as a file of content s would
+// be split.
+func countLines(s string) int {
+	if s == "" {
+		return 0
+	}
+	return strings.Count(s, "\n") + 1
+}
+
 // splice removes deleteCount elements at start and inserts new elements.
 func splice(lines []string, start, deleteCount int, insert []string) []string {
 	result := make([]string, 0, len(lines)-deleteCount+len(insert))
@@ -334,55 +614,69 @@ func splice(lines []string, start, deleteCount int, insert []string) []string {
 
 // atomicWriteEdit writes content to a temporary file and renames it to the target path.
 func atomicWriteEdit(path, content string, perm os.FileMode) error {
+	return atomicWriteEditWith(path, perm, func(w *bufio.Writer) error {
+		_, err := w.WriteString(content)
+		return err
+	})
+}
+
+// atomicWriteEditWith is atomicWriteEdit with the content written by write.
+func atomicWriteEditWith(path string, perm os.FileMode, write func(w *bufio.Writer) error) error {
+	tmpPath, err := writeEditTemp(path, perm, write)
+	if err != nil {
+		return err
+	}
+
+	// Atomic rename
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// writeEditTemp writes the content written by write, with the given
+// permissions, to a new temporary file beside path, ready to be renamed over
+// it, and returns the temporary file's path. Nothing is left behind on
+// error.
+func writeEditTemp(path string, perm os.FileMode, write func(w *bufio.Writer) error) (string, error) {
 	dir := filepath.Dir(path)
 
 	// Create temp file in same directory for atomic rename
 	tmpFile, err := os.CreateTemp(dir, ".edit-*.tmp")
 	if err != nil {
-		return err
+		return "", err
 	}
 	tmpPath := tmpFile.Name()
 
-	// Clean up temp file on error
-	success := false
-	defer func() {
-		if !success {
-			os.Remove(tmpPath)
-		}
-	}()
-
 	// Write content
-	if _, err := tmpFile.WriteString(content); err != nil {
+	w := bufio.NewWriter(tmpFile)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
 		tmpFile.Close()
-		return err
+		os.Remove(tmpPath)
+		return "", err
 	}
 
 	// Close before rename
 	if err := tmpFile.Close(); err != nil {
-		return err
+		os.Remove(tmpPath)
+		return "", err
 	}
 
 	// Set permissions
 	if err := os.Chmod(tmpPath, perm); err != nil {
-		return err
+		os.Remove(tmpPath)
+		return "", err
 	}
-
-	// Atomic rename
-	if err := os.Rename(tmpPath, path); err != nil {
-		return err
-	}
-
-	success = true
-	return nil
+	return tmpPath, nil
 }
 
 // formatEditSuccess formats a successful edit response.
-func formatEditSuccess(path string, linesChanged, newLineCount int) string {
-	output := editOutput{
-		Path:         path,
-		LinesChanged: linesChanged,
-		NewLineCount: newLineCount,
-	}
+func formatEditSuccess(output editOutput) string {
 	data, _ := json.Marshal(output)
 	return string(data)
 }
@@ -0,0 +1,310 @@
+package tool
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// lineReader reads a file a line at a time, failing on a line longer than
+// its limit instead of holding it in memory.
+type lineReader struct {
+	r   *bufio.Reader
+	max int
+	// n is the number of lines read.
+	n int
+}
+
+// newLineReader returns a lineReader reading r, with lines of at most max
+// bytes.
+func newLineReader(r io.Reader, max int) *lineReader {
+	return &lineReader{r: bufio.NewReaderSize(r, 64*1024), max: max}
+}
+
+// next returns the next line with its line ending, if it has one, or
+// io.EOF after the last line.
+func (lr *lineReader) next() (string, error) {
+	var line []byte
+	for {
+		chunk, err := lr.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		// Allow for a CRLF line ending
+		if len(line) > lr.max+2 {
+			return "", lineTooLongError(lr.n+1, lr.max)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if len(line) == 0 {
+			return "", io.EOF
+		}
+		lr.n++
+		text := strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r")
+		if len(text) > lr.max {
+			return "", lineTooLongError(lr.n, lr.max)
+		}
+		return string(line), nil
+	}
+}
+
+// streamEdit is an edit of a file too large to hold in memory. The line
+// operations are applied while the file is copied to its replacement, and
+// only the lines the diff shows are read ahead.
+type streamEdit struct {
+	path string
+	// ops are the operations, sorted by the line they start at.
+	ops []Operation
+	// lines is the file's line count.
+	lines  int
+	layout textLayout
+	// crlf is set if the file's lines end in CRLF, and trimCR if any stray
+	// carriage return is dropped, for an explicit line ending.
+	crlf, trimCR bool
+	maxLine      int
+}
+
+// lineRange is a range of line numbers, lo to hi inclusive.
+type lineRange struct {
+	lo, hi int
+}
+
+// planStreamEdit plans the line operations ops on the large file at absPath
+// as planEdit does, reading the file once to count its lines, detect its
+// layout, and hold the lines around each operation for the diff. The file
+// is read again as the edit is written.
+func planStreamEdit(path, absPath string, perm os.FileMode, ops []Operation, eol, baseHash string, maxLine int) (*editPlan, error) {
+	if err := checkOverlaps(ops); err != nil {
+		return nil, err
+	}
+	ops = append([]Operation(nil), ops...)
+	sort.SliceStable(ops, func(i, j int) bool {
+		si, sj := opStartLine(ops[i]), opStartLine(ops[j])
+		if si != sj {
+			return si < sj
+		}
+		return ops[i].Op == "insert" && ops[j].Op != "insert"
+	})
+	windows := diffWindows(ops)
+	conflictLo, conflictHi := conflictLines(changedLines(ops, ""))
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return nil, editReadError(path, err)
+	}
+	defer file.Close()
+
+	held := make(map[int]string)
+	crlf, lf := 0, 0
+	layout := textLayout{eol: "\n"}
+	hash := sha256.New()
+	lr := newLineReader(io.TeeReader(file, hash), maxLine)
+	w := 0
+	for {
+		line, err := lr.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, editReadError(path, err)
+		}
+		if strings.HasSuffix(line, "\r\n") {
+			crlf++
+		} else if strings.HasSuffix(line, "\n") {
+			lf++
+		}
+		layout.finalNewline = strings.HasSuffix(line, "\n")
+		for w < len(windows) && windows[w].hi < lr.n {
+			w++
+		}
+		inConflict := baseHash != "" && lr.n >= conflictLo && lr.n <= conflictHi
+		if inConflict || w < len(windows) && windows[w].lo <= lr.n {
+			held[lr.n] = strings.TrimSuffix(line, "\n")
+		}
+	}
+	if crlf > lf {
+		layout.eol = "\r\n"
+	}
+	edit := &streamEdit{
+		path:    absPath,
+		ops:     ops,
+		lines:   lr.n,
+		crlf:    layout.eol == "\r\n",
+		trimCR:  eol != "",
+		maxLine: maxLine,
+	}
+	if current := hex.EncodeToString(hash.Sum(nil)); baseHash != "" && !matchesBase(baseHash, current) {
+		lo, hi := changedLines(ops, "")
+		return nil, newConflictError(baseHash, current, edit.lines, lo, hi, func(n int) string { return edit.text(held[n]) })
+	}
+	if err := validateOperations(ops, edit.lines); err != nil {
+		return nil, err
+	}
+	eolChanged := layout.setEOL(eol)
+	edit.layout = layout
+
+	// Diff each window of held lines against its edited version, numbering
+	// the lines by their place in the whole file
+	var sb strings.Builder
+	delta, next := 0, 0
+	for _, window := range windows {
+		hi := min(window.hi, edit.lines)
+		var original []string
+		for n := window.lo; n <= hi; n++ {
+			original = append(original, edit.text(held[n]))
+		}
+		var windowOps []Operation
+		for next < len(ops) && opStartLine(ops[next]) <= window.hi {
+			op := ops[next]
+			op.StartLine -= window.lo - 1
+			op.EndLine -= window.lo - 1
+			op.AfterLine -= window.lo - 1
+			windowOps = append(windowOps, op)
+			next++
+		}
+		edited := original
+		for i := len(windowOps) - 1; i >= 0; i-- {
+			edited = applyOperation(edited, windowOps[i])
+		}
+		if edit.trimCR {
+			edited = trimCarriageReturns(edited)
+		}
+
+		script := diffLines(original, edited)
+		for i := range script {
+			script[i].a += window.lo - 1
+			script[i].b += window.lo - 1 + delta
+		}
+		writeDiffHunks(&sb, path, script)
+		delta += len(edited) - len(original)
+	}
+
+	return &editPlan{
+		absPath: absPath,
+		perm:    perm,
+		write:   edit.write,
+		output: editOutput{
+			Path:         absPath,
+			LinesChanged: countChangedLines(ops),
+			NewLineCount: edit.lines + delta,
+			Diff:         truncateDiff(sb.String()),
+			EOL:          eolChanged,
+		},
+	}, nil
+}
+
+// opStartLine returns the first line of the original file an operation
+// applies at: the line after an insert's afterLine.
+func opStartLine(op Operation) int {
+	if op.Op == "insert" {
+		return op.AfterLine + 1
+	}
+	return op.StartLine
+}
+
+// diffWindows returns the ranges of lines a diff of the sorted operations
+// ops shows: each operation's lines with diffContextLines of context,
+// merged where they meet. Ranges may extend past the end of the file.
+func diffWindows(ops []Operation) []lineRange {
+	var windows []lineRange
+	for _, op := range ops {
+		lo, hi := opStartLine(op), op.EndLine
+		if op.Op == "insert" {
+			hi = op.AfterLine
+		}
+		window := lineRange{max(lo-diffContextLines, 1), hi + diffContextLines}
+		if n := len(windows); n > 0 && window.lo <= windows[n-1].hi+1 {
+			windows[n-1].hi = max(windows[n-1].hi, window.hi)
+			continue
+		}
+		windows = append(windows, window)
+	}
+	return windows
+}
+
+// trimCarriageReturns returns lines without a trailing carriage return.
+func trimCarriageReturns(lines []string) []string {
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed[i] = strings.TrimSuffix(line, "\r")
+	}
+	return trimmed
+}
+
+// text returns a line of the original file as planEdit would split it,
+// without its line ending.
+func (e *streamEdit) text(line string) string {
+	line = strings.TrimSuffix(line, "\n")
+	if e.crlf {
+		line = strings.TrimSuffix(line, "\r")
+	}
+	return line
+}
+
+// write writes the edited file to w, copying the original a line at a time
+// and applying the operations as their lines go by.
+func (e *streamEdit) write(w *bufio.Writer) error {
+	file, err := os.Open(e.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	written := 0
+	put := func(line string) {
+		if e.trimCR {
+			line = strings.TrimSuffix(line, "\r")
+		}
+		if written > 0 {
+			w.WriteString(e.layout.eol)
+		}
+		w.WriteString(line)
+		written++
+	}
+
+	lr := newLineReader(file, e.maxLine)
+	skipTo, next := 0, 0
+	for n := 1; n <= e.lines+1; n++ {
+		for next < len(e.ops) && opStartLine(e.ops[next]) == n {
+			op := e.ops[next]
+			for _, line := range op.Content {
+				put(line)
+			}
+			if op.Op != "insert" {
+				skipTo = op.EndLine
+			}
+			next++
+		}
+		line, err := lr.next()
+		if n > e.lines {
+			if err != io.EOF {
+				return errFileChanged
+			}
+			break
+		}
+		if err == io.EOF {
+			return errFileChanged
+		}
+		if err != nil {
+			return err
+		}
+		if n > skipTo {
+			put(e.text(line))
+		}
+	}
+	if e.layout.finalNewline && written > 0 {
+		w.WriteString(e.layout.eol)
+	}
+	return nil
+}
+
+// errFileChanged is returned when a streamed edit finds the file's lines
+// changed since the edit was planned.
+var errFileChanged = errors.New("file changed while it was being edited")
@@ -0,0 +1,141 @@
+package tool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// planContent returns the content an edit plan writes.
+func planContent(t *testing.T, plan *editPlan) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if err := plan.write(w); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+func TestPlanStreamEdit_MatchesInMemory(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 40; i++ {
+		lines = append(lines, fmt.Sprintf("line%d", i))
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		ops     []Operation
+		eol     string
+	}{
+		{
+			name:    "separate hunks",
+			content: strings.Join(lines, "\n") + "\n",
+			ops: []Operation{
+				{Op: "replace", StartLine: 2, EndLine: 3, Content: []string{"two", "three", "three and a half"}},
+				{Op: "delete", StartLine: 20, EndLine: 24},
+				{Op: "insert", AfterLine: 40, Content: []string{"end"}},
+			},
+		},
+		{
+			name:    "nearby operations share a hunk",
+			content: strings.Join(lines, "\r\n"),
+			ops: []Operation{
+				{Op: "insert", AfterLine: 0, Content: []string{"start"}},
+				{Op: "insert", AfterLine: 9, Content: []string{"nine and a half"}},
+				{Op: "replace", StartLine: 10, EndLine: 10, Content: []string{"ten"}},
+				{Op: "delete", StartLine: 14, EndLine: 14},
+			},
+		},
+		{
+			name:    "converts line endings",
+			content: strings.Join(lines, "\r\n") + "\r\n",
+			ops:     []Operation{{Op: "delete", StartLine: 40, EndLine: 40}},
+			eol:     "lf",
+		},
+		{
+			name:    "empty file",
+			content: "",
+			ops:     []Operation{{Op: "insert", AfterLine: 0, Content: []string{"only"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createEditTestFile(t, tt.content)
+			want, err := planEdit(context.Background(), path, tt.ops, tt.eol, "")
+			if err != nil {
+				t.Fatalf("planEdit failed: %v", err)
+			}
+			got, err := planStreamEdit(path, path, 0644, tt.ops, tt.eol, "", defaultMaxLineLength)
+			if err != nil {
+				t.Fatalf("planStreamEdit failed: %v", err)
+			}
+			if got.output != want.output {
+				t.Errorf("expected output %+v, got %+v", want.output, got.output)
+			}
+			if content, expected := planContent(t, got), planContent(t, want); content != expected {
+				t.Errorf("expected content %q, got %q", expected, content)
+			}
+		})
+	}
+}
+
+func TestPlanStreamEdit_Errors(t *testing.T) {
+	path := createEditTestFile(t, "line1\nline2\n")
+
+	_, err := planStreamEdit(path, path, 0644, []Operation{{Op: "delete", StartLine: 3, EndLine: 3}}, "", "", defaultMaxLineLength)
+	if err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Errorf("expected an out of range error, got %v", err)
+	}
+
+	// The file changing between planning and writing fails the write
+	plan, err := planStreamEdit(path, path, 0644, []Operation{{Op: "delete", StartLine: 1, EndLine: 1}}, "", "", defaultMaxLineLength)
+	if err != nil {
+		t.Fatalf("planStreamEdit failed: %v", err)
+	}
+	os.WriteFile(path, []byte("line1\n"), 0644)
+	if err := plan.write(bufio.NewWriter(&bytes.Buffer{})); err != errFileChanged {
+		t.Errorf("expected errFileChanged, got %v", err)
+	}
+}
+
+func TestEditTool_MaxLineLength(t *testing.T) {
+	path := createEditTestFile(t, "short\n"+strings.Repeat("x", 100)+"\nshort\n")
+	input, _ := json.Marshal(editInput{Path: path, Operations: []Operation{{Op: "delete", StartLine: 1, EndLine: 1}}})
+
+	ctx := WithMaxLineLength(context.Background(), 64)
+	result, _ := NewEditTool().Execute(ctx, input)
+	if !strings.Contains(result, "line 2 is longer than 64 bytes") {
+		t.Errorf("expected a line length error, got %s", result)
+	}
+	if _, err := planStreamEdit(path, path, 0644, []Operation{{Op: "delete", StartLine: 1, EndLine: 1}}, "", "", 64); err == nil || !strings.Contains(err.Error(), "line 2 is longer than 64 bytes") {
+		t.Errorf("expected a line length error from the streamed edit, got %v", err)
+	}
+
+	// The default limit allows the line
+	result, _ = NewEditTool().Execute(context.Background(), input)
+	if IsErrorResult(result) {
+		t.Errorf("unexpected error: %s", result)
+	}
+
+	// Lines longer than the reader's buffer are read whole
+	long := strings.Repeat("y", 150000)
+	path = createEditTestFile(t, "short\n"+long+"\r\nshort")
+	plan, err := planStreamEdit(path, path, 0644, []Operation{{Op: "delete", StartLine: 1, EndLine: 1}}, "", "", defaultMaxLineLength)
+	if err != nil {
+		t.Fatalf("planStreamEdit failed: %v", err)
+	}
+	if content := planContent(t, plan); content != long+"\r\nshort" {
+		t.Errorf("unexpected content of %d bytes", len(content))
+	}
+	if _, err := planStreamEdit(path, path, 0644, []Operation{{Op: "delete", StartLine: 1, EndLine: 1}}, "", "", 100000); err == nil {
+		t.Error("expected a line length error")
+	}
+}
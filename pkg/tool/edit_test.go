@@ -88,6 +88,11 @@ func TestEditTool_ReplaceSingleLine(t *testing.T) {
 	if string(content) != expected {
 		t.Errorf("expected content '%s', got '%s'", expected, string(content))
 	}
+	// The diff shows the change with its context
+	expectedDiff := "--- " + filePath + "\n+++ " + filePath + "\n@@ -1,5 +1,5 @@\n line1\n line2\n-line3\n+replaced line 3\n line4\n line5\n"
+	if output.Diff != expectedDiff {
+		t.Errorf("expected diff:\n%s\ngot:\n%s", expectedDiff, output.Diff)
+	}
 }
 
 func TestEditTool_ReplaceMultipleLines(t *testing.T) {
@@ -584,3 +589,231 @@ func TestEditTool_AbsolutePath(t *testing.T) {
 		t.Errorf("expected absolute path, got '%s'", output.Path)
 	}
 }
+
+func TestEditTool_ReplaceString(t *testing.T) {
+	tool := NewEditTool()
+	ctx := context.Background()
+
+	filePath := createEditTestFile(t, "func a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 2\n}")
+
+	input := `{
+		"path": "` + filePath + `",
+		"operations": [
+			{"op": "replace_string", "oldString": "func b() {\n\treturn 2", "newString": "func b() int {\n\tx := 2\n\treturn x"}
+		]
+	}`
+
+	result, err := tool.Execute(ctx, json.RawMessage(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var output editOutput
+	if err := json.Unmarshal([]byte(result), &output); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if output.LinesChanged != 5 || output.NewLineCount != 8 {
+		t.Errorf("expected 5 lines changed and 8 lines, got %+v", output)
+	}
+	if !strings.Contains(output.Diff, "-func b() {\n-\treturn 2\n+func b() int {\n+\tx := 2\n+\treturn x\n }\n") {
+		t.Errorf("unexpected diff:\n%s", output.Diff)
+	}
+
+	content, _ := os.ReadFile(filePath)
+	expected := "func a() {\n\treturn 1\n}\n\nfunc b() int {\n\tx := 2\n\treturn x\n}"
+	if string(content) != expected {
+		t.Errorf("expected content '%s', got '%s'", expected, string(content))
+	}
+}
+
+func TestEditTool_ReplaceStringOccurrences(t *testing.T) {
+	tool := NewEditTool()
+	ctx := context.Background()
+
+	original := "foo := 1\nbar(foo)\nbaz(foo)"
+	tests := []struct {
+		name     string
+		op       string
+		expected string
+		err      string
+	}{
+		{"ambiguous", `{"op": "replace_string", "oldString": "foo", "newString": "x"}`, original, "found 3 times, expected 1"},
+		{"not found", `{"op": "replace_string", "oldString": "qux", "newString": "x"}`, original, "oldString not found"},
+		{"empty oldString", `{"op": "replace_string", "newString": "x"}`, original, "oldString is required"},
+		{"all occurrences", `{"op": "replace_string", "oldString": "foo", "newString": "x", "expectedOccurrences": 3}`, "x := 1\nbar(x)\nbaz(x)", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := createEditTestFile(t, original)
+			input := `{"path": "` + filePath + `", "operations": [` + tt.op + `]}`
+
+			result, err := tool.Execute(ctx, json.RawMessage(input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var output editError
+			json.Unmarshal([]byte(result), &output)
+			if tt.err == "" && output.Error != "" || !strings.Contains(output.Error, tt.err) {
+				t.Errorf("expected error containing '%s', got '%s'", tt.err, output.Error)
+			}
+
+			content, _ := os.ReadFile(filePath)
+			if string(content) != tt.expected {
+				t.Errorf("expected content '%s', got '%s'", tt.expected, string(content))
+			}
+		})
+	}
+}
+
+func TestEditTool_ReplaceStringAfterLineOperations(t *testing.T) {
+	tool := NewEditTool()
+	ctx := context.Background()
+
+	filePath := createEditTestFile(t, "line1\nline2\nline3")
+
+	// Line numbers refer to the original file; the string replacement sees the result
+	input := `{
+		"path": "` + filePath + `",
+		"operations": [
+			{"op": "replace_string", "oldString": "inserted", "newString": "renamed"},
+			{"op": "insert", "afterLine": 0, "content": ["inserted"]},
+			{"op": "delete", "startLine": 2, "endLine": 2}
+		]
+	}`
+
+	if _, err := tool.Execute(ctx, json.RawMessage(input)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, _ := os.ReadFile(filePath)
+	expected := "renamed\nline1\nline3"
+	if string(content) != expected {
+		t.Errorf("expected content '%s', got '%s'", expected, string(content))
+	}
+}
+
+func TestEditTool_DryRun(t *testing.T) {
+	tool := NewEditTool()
+	ctx := context.Background()
+
+	original := "line1\nline2\nline3"
+	filePath := createEditTestFile(t, original)
+
+	input := `{
+		"path": "` + filePath + `",
+		"dry_run": true,
+		"operations": [
+			{"op": "delete", "startLine": 2, "endLine": 2},
+			{"op": "replace_string", "oldString": "line3", "newString": "last"}
+		]
+	}`
+	result, err := tool.Execute(ctx, json.RawMessage(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var output editOutput
+	if err := json.Unmarshal([]byte(result), &output); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if !output.DryRun || output.NewLineCount != 2 {
+		t.Errorf("expected a dry run leaving 2 lines, got %s", result)
+	}
+	if !strings.Contains(output.Diff, " line1\n-line2\n-line3\n+last\n") {
+		t.Errorf("unexpected diff:\n%s", output.Diff)
+	}
+	if content, _ := os.ReadFile(filePath); string(content) != original {
+		t.Errorf("dry run modified the file: %q", content)
+	}
+
+	// Invalid operations still fail
+	input = `{"path": "` + filePath + `", "dry_run": true, "operations": [{"op": "delete", "startLine": 9, "endLine": 9}]}`
+	result, _ = tool.Execute(ctx, json.RawMessage(input))
+	if !IsErrorResult(result) {
+		t.Errorf("expected an out of range error, got %s", result)
+	}
+}
+
+func TestEditTool_LineEndings(t *testing.T) {
+	tool := NewEditTool()
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		content  string
+		ops      string
+		eol      string
+		expected string
+		diff     string
+	}{
+		{
+			name:     "keeps CRLF and final newline",
+			content:  "line1\r\nline2\r\nline3\r\n",
+			ops:      `{"op": "insert", "afterLine": 1, "content": ["new"]}`,
+			expected: "line1\r\nnew\r\nline2\r\nline3\r\n",
+			diff:     " line1\n+new\n line2\n line3\n",
+		},
+		{
+			name:     "keeps LF final newline",
+			content:  "line1\nline2\n",
+			ops:      `{"op": "delete", "startLine": 2, "endLine": 2}`,
+			expected: "line1\n",
+		},
+		{
+			name:     "replace_string across CRLF lines",
+			content:  "a\r\nb\r\nc",
+			ops:      `{"op": "replace_string", "oldString": "a\nb", "newString": "x\r\ny\nz"}`,
+			expected: "x\r\ny\r\nz\r\nc",
+		},
+		{
+			name:     "keeps stray carriage return in LF file",
+			content:  "line1\r\nline2\nline3\n",
+			ops:      `{"op": "replace", "startLine": 3, "endLine": 3, "content": ["last"]}`,
+			expected: "line1\r\nline2\nlast\n",
+		},
+		{
+			name:     "converts to LF",
+			content:  "line1\r\nline2\r\n",
+			eol:      "lf",
+			expected: "line1\nline2\n",
+		},
+		{
+			name:     "converts to CRLF",
+			content:  "line1\nline2\r\nline3",
+			ops:      `{"op": "replace_string", "oldString": "line3", "newString": "end"}`,
+			eol:      "crlf",
+			expected: "line1\r\nline2\r\nend",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := createEditTestFile(t, tt.content)
+			input := `{"path": "` + filePath + `", "operations": [` + tt.ops + `]`
+			if tt.eol != "" {
+				input += `, "eol": "` + tt.eol + `"`
+			}
+			result, err := tool.Execute(ctx, json.RawMessage(input+"}"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if IsErrorResult(result) {
+				t.Fatalf("unexpected error result: %s", result)
+			}
+			if content, _ := os.ReadFile(filePath); string(content) != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, content)
+			}
+			var output editOutput
+			json.Unmarshal([]byte(result), &output)
+			if !strings.Contains(output.Diff, tt.diff) {
+				t.Errorf("expected diff containing %q, got %q", tt.diff, output.Diff)
+			}
+		})
+	}
+
+	filePath := createEditTestFile(t, "line1\n")
+	result, _ := tool.Execute(ctx, json.RawMessage(`{"path": "`+filePath+`", "operations": [], "eol": "cr"}`))
+	if !IsErrorResult(result) || !strings.Contains(result, "invalid eol") {
+		t.Errorf("expected an invalid eol error, got %s", result)
+	}
+}
@@ -0,0 +1,37 @@
+package tool
+
+import (
+	"context"
+
+	"github.com/user/harness/pkg/toolapi"
+)
+
+// Emitter receives custom events emitted by a tool while it executes.
+// See toolapi.Emitter.
+type Emitter = toolapi.Emitter
+
+// EmitterFunc adapts an ordinary function to the Emitter interface.
+type EmitterFunc = toolapi.EmitterFunc
+
+// WithEmitter returns a copy of ctx carrying the given Emitter.
+func WithEmitter(ctx context.Context, e Emitter) context.Context {
+	return toolapi.WithEmitter(ctx, e)
+}
+
+// Emit publishes a custom event through the Emitter in ctx.
+// It is a no-op when no Emitter is present, so tools can call it unconditionally.
+func Emit(ctx context.Context, eventType string, payload any) {
+	toolapi.Emit(ctx, eventType, payload)
+}
+
+// OutputEventType is the reserved event type for incremental process output.
+// See toolapi.OutputEventType.
+const OutputEventType = toolapi.OutputEventType
+
+// Output is the payload of an OutputEventType event. See toolapi.Output.
+type Output = toolapi.Output
+
+// EmitOutput publishes a chunk of a tool's stdout or stderr as it is produced.
+func EmitOutput(ctx context.Context, stream, text string) {
+	toolapi.EmitOutput(ctx, stream, text)
+}
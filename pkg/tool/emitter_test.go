@@ -0,0 +1,29 @@
+package tool
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEmit_NoEmitter(t *testing.T) {
+	// Must not panic without an emitter in the context
+	Emit(context.Background(), "progress", map[string]int{"done": 1})
+}
+
+func TestEmit_WithEmitter(t *testing.T) {
+	var gotType string
+	var gotPayload any
+	ctx := WithEmitter(context.Background(), EmitterFunc(func(eventType string, payload any) {
+		gotType = eventType
+		gotPayload = payload
+	}))
+
+	Emit(ctx, "test_result", "pass")
+
+	if gotType != "test_result" {
+		t.Errorf("expected event type 'test_result', got %q", gotType)
+	}
+	if gotPayload != "pass" {
+		t.Errorf("expected payload 'pass', got %v", gotPayload)
+	}
+}
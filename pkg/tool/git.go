@@ -0,0 +1,189 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitTimeout is the maximum time allowed for one git command.
+const gitTimeout = 30 * time.Second
+
+// errGitReadOnly is returned by mutating git operations when the context
+// disallows them.
+var errGitReadOnly = errors.New("mutating git operations are disabled")
+
+// gitReadOnlyKey is the context key for the git read-only switch.
+type gitReadOnlyKey struct{}
+
+// WithGitReadOnly returns a copy of ctx in which the git tools refuse
+// operations that change the repository, such as committing or creating
+// and switching branches. Inspecting the repository is still allowed.
+func WithGitReadOnly(ctx context.Context, readOnly bool) context.Context {
+	return context.WithValue(ctx, gitReadOnlyKey{}, readOnly)
+}
+
+// GitReadOnly reports whether ctx disallows mutating git operations.
+func GitReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(gitReadOnlyKey{}).(bool)
+	return readOnly
+}
+
+// gitErrorOutput defines the error response format of the git tools.
+type gitErrorOutput struct {
+	Error string `json:"error"`
+}
+
+// gitDir resolves the directory a git tool runs in: path within the
+// workspace, or the workspace itself when path is empty. The directory must
+// be inside a git work tree.
+func gitDir(ctx context.Context, path string) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	resolved, err := ResolvePath(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.Abs(resolved)
+	if err != nil {
+		return "", errors.New("invalid path: " + err.Error())
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("path not found: %s", path)
+		}
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("not a directory: %s", path)
+	}
+	if _, err := runGit(ctx, dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// runGit runs git with args in dir and returns its standard output. A
+// nonzero exit is an error carrying git's error output.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmdCtx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(cmdCtx, "git", args...)
+	cmd.Dir = dir
+	// Never prompt, keep output in English and uncolored, and don't take
+	// the index lock just to refresh stat information
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_OPTIONAL_LOCKS=0",
+		"LC_ALL=C",
+		"GIT_PAGER=cat",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if cmdCtx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("git %s timed out after 30 seconds", args[0])
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", errors.New("failed to run git: " + err.Error())
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if msg == "" {
+			msg = fmt.Sprintf("git %s exited with status %d", args[0], exitErr.ExitCode())
+		}
+		return "", errors.New(msg)
+	}
+	return stdout.String(), nil
+}
+
+// checkGitArg rejects a ref or branch name that git would parse as an
+// option.
+func checkGitArg(field, value string) error {
+	if strings.HasPrefix(value, "-") {
+		return fmt.Errorf("invalid %s %q: must not start with '-'", field, value)
+	}
+	return nil
+}
+
+// formatGitFailure formats err from gitDir or runGit as an error response,
+// or returns the context's error once it is done.
+func formatGitFailure(ctx context.Context, err error) (string, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", ctxErr
+	}
+	return formatGitError(err.Error()), nil
+}
+
+// formatGitError formats an error response of the git tools.
+func formatGitError(msg string) string {
+	output := gitErrorOutput{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
+
+// gitPaths resolves paths within the workspace, like the paths of other
+// tools, into absolute pathspecs for git.
+func gitPaths(ctx context.Context, paths []string) ([]string, error) {
+	specs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p == "" {
+			return nil, errors.New("paths must not contain empty strings")
+		}
+		resolved, err := ResolvePath(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		abs, err := filepath.Abs(resolved)
+		if err != nil {
+			return nil, errors.New("invalid path: " + err.Error())
+		}
+		specs = append(specs, abs)
+	}
+	return specs, nil
+}
+
+// gitStatusWord names a status letter from git status or git diff
+// --name-status. It returns "" for an unchanged side.
+func gitStatusWord(c byte) string {
+	switch c {
+	case 'M':
+		return "modified"
+	case 'A':
+		return "added"
+	case 'D':
+		return "deleted"
+	case 'R':
+		return "renamed"
+	case 'C':
+		return "copied"
+	case 'T':
+		return "type_changed"
+	case 'U':
+		return "unmerged"
+	case '?':
+		return "untracked"
+	case '!':
+		return "ignored"
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// gitBranchActions are the accepted git_branch actions.
+var gitBranchActions = []string{"list", "create", "switch", "delete"}
+
+// gitBranchFormat lists each branch as NUL-separated fields, one per line.
+const gitBranchFormat = "%(refname)%00%(objectname:short)%00%(HEAD)%00%(upstream:short)%00%(upstream:track,nobracket)"
+
+// GitBranchTool implements the Tool interface for listing, creating,
+// switching, and deleting branches.
+type GitBranchTool struct{}
+
+// gitBranchInput defines the expected input parameters for the git_branch tool.
+type gitBranchInput struct {
+	Path       string `json:"path"`
+	Action     string `json:"action"`
+	Name       string `json:"name"`
+	StartPoint string `json:"start_point"`
+	Remote     bool   `json:"remote"`
+}
+
+// gitBranchOutput defines the success response format. Every action returns
+// the branches as they are afterwards.
+type gitBranchOutput struct {
+	// Current is empty when HEAD is detached.
+	Current  string      `json:"current"`
+	Branches []gitBranch `json:"branches"`
+}
+
+// gitBranch describes one branch.
+type gitBranch struct {
+	Name     string `json:"name"`
+	Commit   string `json:"commit"`
+	Current  bool   `json:"current,omitempty"`
+	Remote   bool   `json:"remote,omitempty"`
+	Upstream string `json:"upstream,omitempty"`
+	Ahead    int    `json:"ahead,omitempty"`
+	Behind   int    `json:"behind,omitempty"`
+	// Gone is set when the upstream branch no longer exists.
+	Gone bool `json:"gone,omitempty"`
+}
+
+// NewGitBranchTool creates a new GitBranchTool instance.
+func NewGitBranchTool() *GitBranchTool {
+	return &GitBranchTool{}
+}
+
+// Name returns the tool identifier.
+func (t *GitBranchTool) Name() string {
+	return "git_branch"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *GitBranchTool) Description() string {
+	return "List git branches with their commits and upstream status, or create, switch to, or delete a branch. Deleting refuses branches that are not fully merged"
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *GitBranchTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "description": "Directory inside the repository (default: current directory)"},
+			"action": {"type": "string", "enum": ["list", "create", "switch", "delete"], "description": "What to do (default list)"},
+			"name": {"type": "string", "description": "Branch name, required for create, switch, and delete"},
+			"start_point": {"type": "string", "description": "Commit or branch to create the branch at (default: HEAD)"},
+			"remote": {"type": "boolean", "description": "Also list remote-tracking branches (default false)"}
+		}
+	}`)
+}
+
+// Execute performs the requested action and lists the branches.
+func (t *GitBranchTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params gitBranchInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatGitError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	// Validate parameters
+	if params.Action == "" {
+		params.Action = "list"
+	}
+	if !slices.Contains(gitBranchActions, params.Action) {
+		return formatGitError(fmt.Sprintf("invalid action %q: must be one of %s", params.Action, strings.Join(gitBranchActions, ", "))), nil
+	}
+	if params.Action != "list" {
+		if GitReadOnly(ctx) {
+			return formatGitError(errGitReadOnly.Error()), nil
+		}
+		if params.Name == "" {
+			return formatGitError("name is required for " + params.Action), nil
+		}
+	}
+	if params.StartPoint != "" && params.Action != "create" {
+		return formatGitError("start_point is only valid for create"), nil
+	}
+	if err := checkGitArg("name", params.Name); err != nil {
+		return formatGitError(err.Error()), nil
+	}
+	if err := checkGitArg("start_point", params.StartPoint); err != nil {
+		return formatGitError(err.Error()), nil
+	}
+
+	dir, err := gitDir(ctx, params.Path)
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+
+	switch params.Action {
+	case "create":
+		args := []string{"branch", params.Name}
+		if params.StartPoint != "" {
+			args = append(args, params.StartPoint)
+		}
+		_, err = runGit(ctx, dir, args...)
+	case "switch":
+		_, err = runGit(ctx, dir, "switch", params.Name)
+	case "delete":
+		_, err = runGit(ctx, dir, "branch", "--delete", params.Name)
+	}
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+
+	args := []string{"branch", "--list", "--no-color", "--format=" + gitBranchFormat}
+	if params.Remote {
+		args = append(args, "--all")
+	}
+	out, err := runGit(ctx, dir, args...)
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+	current, err := runGit(ctx, dir, "branch", "--show-current")
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+
+	output := gitBranchOutput{
+		Current:  strings.TrimSpace(current),
+		Branches: parseGitBranches(out),
+	}
+	data, _ := json.Marshal(output)
+	return string(data), nil
+}
+
+// parseGitBranches parses git branch output in gitBranchFormat. A detached
+// HEAD and symbolic remote HEADs are left out.
+func parseGitBranches(out string) []gitBranch {
+	branches := []gitBranch{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 5 {
+			continue
+		}
+		b := gitBranch{
+			Commit:   fields[1],
+			Current:  fields[2] == "*",
+			Upstream: fields[3],
+		}
+		if name, ok := strings.CutPrefix(fields[0], "refs/heads/"); ok {
+			b.Name = name
+		} else if name, ok := strings.CutPrefix(fields[0], "refs/remotes/"); ok && !strings.HasSuffix(name, "/HEAD") {
+			b.Name = name
+			b.Remote = true
+		} else {
+			continue
+		}
+		for _, part := range strings.Split(fields[4], ", ") {
+			if n, ok := strings.CutPrefix(part, "ahead "); ok {
+				b.Ahead, _ = strconv.Atoi(n)
+			}
+			if n, ok := strings.CutPrefix(part, "behind "); ok {
+				b.Behind, _ = strconv.Atoi(n)
+			}
+			if part == "gone" {
+				b.Gone = true
+			}
+		}
+		branches = append(branches, b)
+	}
+	return branches
+}
@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// GitCommitTool implements the Tool interface for recording a commit.
+type GitCommitTool struct{}
+
+// gitCommitInput defines the expected input parameters for the git_commit tool.
+type gitCommitInput struct {
+	Path    string   `json:"path"`
+	Message string   `json:"message"`
+	Paths   []string `json:"paths"`
+	All     bool     `json:"all"`
+}
+
+// gitCommitOutput defines the success response format.
+type gitCommitOutput struct {
+	Hash    string `json:"hash"`
+	Branch  string `json:"branch"`
+	Subject string `json:"subject"`
+	// Files lists the paths in the commit, relative to the work tree root.
+	Files []gitCommitFile `json:"files"`
+}
+
+// gitCommitFile is one file changed by a commit.
+type gitCommitFile struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+// NewGitCommitTool creates a new GitCommitTool instance.
+func NewGitCommitTool() *GitCommitTool {
+	return &GitCommitTool{}
+}
+
+// Name returns the tool identifier.
+func (t *GitCommitTool) Name() string {
+	return "git_commit"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *GitCommitTool) Description() string {
+	return "Create a git commit. Commits what is already staged, after staging the given paths, or every change including untracked files when all is set. Returns the new commit's hash and files"
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *GitCommitTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "description": "Directory inside the repository (default: current directory)"},
+			"message": {"type": "string", "description": "Commit message; the first line is the subject"},
+			"paths": {"type": "array", "items": {"type": "string"}, "description": "Files or directories to stage before committing"},
+			"all": {"type": "boolean", "description": "Stage every change, including new and deleted files, before committing (default false)"}
+		},
+		"required": ["message"]
+	}`)
+}
+
+// Execute stages the requested changes and commits them.
+func (t *GitCommitTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params gitCommitInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatGitError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	// Validate parameters
+	if GitReadOnly(ctx) {
+		return formatGitError(errGitReadOnly.Error()), nil
+	}
+	if strings.TrimSpace(params.Message) == "" {
+		return formatGitError("message is required"), nil
+	}
+	if params.All && len(params.Paths) > 0 {
+		return formatGitError("all cannot be combined with paths"), nil
+	}
+
+	dir, err := gitDir(ctx, params.Path)
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+	paths, err := gitPaths(ctx, params.Paths)
+	if err != nil {
+		return formatGitError(err.Error()), nil
+	}
+
+	// Stage, then commit
+	switch {
+	case params.All:
+		_, err = runGit(ctx, dir, "add", "--all")
+	case len(paths) > 0:
+		_, err = runGit(ctx, dir, slices.Concat([]string{"add", "--all", "--"}, paths)...)
+	}
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+	if _, err := runGit(ctx, dir, "commit", "--no-edit", "--message", params.Message); err != nil {
+		return formatGitFailure(ctx, err)
+	}
+
+	// Describe the new commit
+	out, err := runGit(ctx, dir, "log", "-1", "--format=%H%x00%s")
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+	hash, subject, _ := strings.Cut(strings.TrimSuffix(out, "\n"), "\x00")
+	branch, err := runGit(ctx, dir, "branch", "--show-current")
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+	changed, err := runGit(ctx, dir, "diff-tree", "--root", "--no-commit-id", "--name-status", "-r", "-z", "HEAD")
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+
+	output := gitCommitOutput{
+		Hash:    hash,
+		Branch:  strings.TrimSpace(branch),
+		Subject: subject,
+		Files:   parseGitNameStatus(changed),
+	}
+	data, _ := json.Marshal(output)
+	return string(data), nil
+}
+
+// parseGitNameStatus parses --name-status -z output: a status letter,
+// optionally followed by a similarity score, then the path, or the old and
+// new paths of a rename or copy.
+func parseGitNameStatus(out string) []gitCommitFile {
+	files := []gitCommitFile{}
+	fields := strings.Split(out, "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status := fields[i]
+		if status == "" {
+			continue
+		}
+		f := gitCommitFile{Path: fields[i+1], Status: gitStatusWord(status[0])}
+		if (status[0] == 'R' || status[0] == 'C') && i+2 < len(fields) {
+			i++
+			f.Path = fields[i+1]
+		}
+		files = append(files, f)
+	}
+	return files
+}
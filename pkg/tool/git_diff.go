@@ -0,0 +1,176 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxGitDiffContext caps the requested number of context lines.
+const maxGitDiffContext = 20
+
+// GitDiffTool implements the Tool interface for showing changes in a git
+// repository.
+type GitDiffTool struct{}
+
+// gitDiffInput defines the expected input parameters for the git_diff tool.
+type gitDiffInput struct {
+	Path   string   `json:"path"`
+	Staged bool     `json:"staged"`
+	Ref    string   `json:"ref"`
+	Paths  []string `json:"paths"`
+	// Context defaults to 3 lines.
+	Context *int `json:"context"`
+}
+
+// gitDiffOutput defines the success response format.
+type gitDiffOutput struct {
+	Files []gitDiffFile `json:"files"`
+	// Diff is the unified diff, cut at a line boundary if Truncated.
+	Diff      string `json:"diff"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// gitDiffFile summarizes the change to one file, relative to the work tree
+// root.
+type gitDiffFile struct {
+	Path string `json:"path"`
+	// OldPath is the path before a rename or copy.
+	OldPath   string `json:"old_path,omitempty"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary,omitempty"`
+}
+
+// NewGitDiffTool creates a new GitDiffTool instance.
+func NewGitDiffTool() *GitDiffTool {
+	return &GitDiffTool{}
+}
+
+// Name returns the tool identifier.
+func (t *GitDiffTool) Name() string {
+	return "git_diff"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *GitDiffTool) Description() string {
+	return "Show a git diff with per-file addition and deletion counts. By default shows unstaged changes; set staged for the index, or ref to compare against a commit or range. Untracked files are not included"
+}
+
+// ReadOnly reports that the tool has no side effects.
+func (t *GitDiffTool) ReadOnly() bool {
+	return true
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *GitDiffTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "description": "Directory inside the repository (default: current directory)"},
+			"staged": {"type": "boolean", "description": "Show staged changes instead of unstaged ones (default false)"},
+			"ref": {"type": "string", "description": "Commit to compare against (e.g. HEAD~1), or a range such as main..feature"},
+			"paths": {"type": "array", "items": {"type": "string"}, "description": "Limit the diff to these files or directories"},
+			"context": {"type": "integer", "description": "Lines of context around each change, 0-20 (default 3)"}
+		}
+	}`)
+}
+
+// Execute shows the requested diff.
+func (t *GitDiffTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params gitDiffInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatGitError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	// Validate parameters
+	contextLines := diffContextLines
+	if params.Context != nil {
+		contextLines = clampInt(*params.Context, 0, maxGitDiffContext)
+	}
+	if err := checkGitArg("ref", params.Ref); err != nil {
+		return formatGitError(err.Error()), nil
+	}
+	if params.Staged && strings.Contains(params.Ref, "..") {
+		return formatGitError("staged cannot be combined with a ref range"), nil
+	}
+
+	dir, err := gitDir(ctx, params.Path)
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+	paths, err := gitPaths(ctx, params.Paths)
+	if err != nil {
+		return formatGitError(err.Error()), nil
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if params.Staged {
+		args = append(args, "--cached")
+	}
+	if params.Ref != "" {
+		args = append(args, params.Ref)
+	}
+	pathspec := append([]string{"--"}, paths...)
+
+	stat, err := runGit(ctx, dir, slices.Concat(args, []string{"--numstat", "-z"}, pathspec)...)
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+	diff, err := runGit(ctx, dir, slices.Concat(args, []string{"-U" + strconv.Itoa(contextLines)}, pathspec)...)
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+
+	files, err := parseGitNumstat(stat)
+	if err != nil {
+		return formatGitError(err.Error()), nil
+	}
+	output := gitDiffOutput{
+		Files:     files,
+		Diff:      truncateDiff(diff),
+		Truncated: len(diff) > maxDiffBytes,
+	}
+	data, _ := json.Marshal(output)
+	return string(data), nil
+}
+
+// parseGitNumstat parses the output of git diff --numstat -z. Each entry is
+// "added\tdeleted\tpath", or "added\tdeleted\t" followed by the old and new
+// paths of a rename; binary files count "-" lines.
+func parseGitNumstat(out string) ([]gitDiffFile, error) {
+	files := []gitDiffFile{}
+	fields := strings.Split(out, "\x00")
+	for i := 0; i < len(fields); i++ {
+		if fields[i] == "" {
+			continue
+		}
+		parts := strings.SplitN(fields[i], "\t", 3)
+		if len(parts) != 3 {
+			return nil, errors.New("unexpected git diff --numstat output: " + fields[i])
+		}
+		f := gitDiffFile{Path: parts[2]}
+		if f.Path == "" && i+2 < len(fields) {
+			f.OldPath, f.Path = fields[i+1], fields[i+2]
+			i += 2
+		}
+		if parts[0] == "-" && parts[1] == "-" {
+			f.Binary = true
+		} else {
+			f.Additions, _ = strconv.Atoi(parts[0])
+			f.Deletions, _ = strconv.Atoi(parts[1])
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
@@ -0,0 +1,154 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultGitLogCount is how many commits git_log returns unless told
+	// otherwise.
+	defaultGitLogCount = 20
+	// maxGitLogCount caps the requested number of commits.
+	maxGitLogCount = 200
+)
+
+// gitLogFormat separates the fields of a commit with NUL and ends each
+// commit with a record separator, so subjects and bodies need no escaping.
+const gitLogFormat = "%H%x00%h%x00%an%x00%ae%x00%aI%x00%s%x00%b%x1e"
+
+// GitLogTool implements the Tool interface for listing commits.
+type GitLogTool struct{}
+
+// gitLogInput defines the expected input parameters for the git_log tool.
+type gitLogInput struct {
+	Path     string   `json:"path"`
+	Ref      string   `json:"ref"`
+	Paths    []string `json:"paths"`
+	MaxCount int      `json:"max_count"`
+	Skip     int      `json:"skip"`
+}
+
+// gitLogOutput defines the success response format.
+type gitLogOutput struct {
+	Commits []gitCommit `json:"commits"`
+}
+
+// gitCommit describes one commit.
+type gitCommit struct {
+	Hash      string `json:"hash"`
+	ShortHash string `json:"short_hash"`
+	Author    string `json:"author"`
+	Email     string `json:"email"`
+	// Date is the author date in RFC 3339 format.
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+	Body    string `json:"body,omitempty"`
+}
+
+// NewGitLogTool creates a new GitLogTool instance.
+func NewGitLogTool() *GitLogTool {
+	return &GitLogTool{}
+}
+
+// Name returns the tool identifier.
+func (t *GitLogTool) Name() string {
+	return "git_log"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *GitLogTool) Description() string {
+	return "List git commits, newest first, with hash, author, date, subject, and body. Optionally start from a ref or range and limit to commits touching given paths"
+}
+
+// ReadOnly reports that the tool has no side effects.
+func (t *GitLogTool) ReadOnly() bool {
+	return true
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *GitLogTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "description": "Directory inside the repository (default: current directory)"},
+			"ref": {"type": "string", "description": "Branch, commit, or range such as main..feature (default: HEAD)"},
+			"paths": {"type": "array", "items": {"type": "string"}, "description": "Only list commits touching these files or directories"},
+			"max_count": {"type": "integer", "description": "Maximum number of commits, 1-200 (default 20)"},
+			"skip": {"type": "integer", "description": "Number of commits to skip, for paging (default 0)"}
+		}
+	}`)
+}
+
+// Execute lists the requested commits.
+func (t *GitLogTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params gitLogInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatGitError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	// Validate parameters
+	maxCount := clampInt(params.MaxCount, defaultGitLogCount, maxGitLogCount)
+	if params.Skip < 0 {
+		return formatGitError("skip must be at least 0"), nil
+	}
+	if err := checkGitArg("ref", params.Ref); err != nil {
+		return formatGitError(err.Error()), nil
+	}
+
+	dir, err := gitDir(ctx, params.Path)
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+	paths, err := gitPaths(ctx, params.Paths)
+	if err != nil {
+		return formatGitError(err.Error()), nil
+	}
+
+	args := []string{
+		"log", "--no-color", "--format=" + gitLogFormat,
+		"--max-count=" + strconv.Itoa(maxCount),
+		"--skip=" + strconv.Itoa(params.Skip),
+	}
+	if params.Ref != "" {
+		args = append(args, params.Ref)
+	}
+	out, err := runGit(ctx, dir, slices.Concat(args, []string{"--"}, paths)...)
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+
+	data, _ := json.Marshal(gitLogOutput{Commits: parseGitLog(out)})
+	return string(data), nil
+}
+
+// parseGitLog parses git log output in gitLogFormat.
+func parseGitLog(out string) []gitCommit {
+	commits := []gitCommit{}
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x00")
+		if len(fields) != 7 {
+			continue
+		}
+		commits = append(commits, gitCommit{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Author:    fields[2],
+			Email:     fields[3],
+			Date:      fields[4],
+			Subject:   fields[5],
+			Body:      strings.TrimSpace(fields[6]),
+		})
+	}
+	return commits
+}
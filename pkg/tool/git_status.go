@@ -0,0 +1,180 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// maxGitStatusFiles caps the number of files in one git_status result.
+const maxGitStatusFiles = 1000
+
+// GitStatusTool implements the Tool interface for showing the working tree
+// status of a git repository.
+type GitStatusTool struct{}
+
+// gitStatusInput defines the expected input parameters for the git_status tool.
+type gitStatusInput struct {
+	Path string `json:"path"`
+}
+
+// gitStatusOutput defines the success response format.
+type gitStatusOutput struct {
+	// Root is the top-level directory of the work tree.
+	Root string `json:"root"`
+	// Branch is empty when HEAD is detached.
+	Branch    string          `json:"branch"`
+	Detached  bool            `json:"detached,omitempty"`
+	Upstream  string          `json:"upstream,omitempty"`
+	Ahead     int             `json:"ahead"`
+	Behind    int             `json:"behind"`
+	Clean     bool            `json:"clean"`
+	Files     []gitStatusFile `json:"files"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// gitStatusFile is one changed path, relative to the work tree root.
+type gitStatusFile struct {
+	Path string `json:"path"`
+	// OrigPath is the path before a rename or copy.
+	OrigPath string `json:"orig_path,omitempty"`
+	// Staged and Unstaged describe the change in the index and in the work
+	// tree; each is omitted when that side is unchanged.
+	Staged   string `json:"staged,omitempty"`
+	Unstaged string `json:"unstaged,omitempty"`
+	Conflict bool   `json:"conflict,omitempty"`
+}
+
+// NewGitStatusTool creates a new GitStatusTool instance.
+func NewGitStatusTool() *GitStatusTool {
+	return &GitStatusTool{}
+}
+
+// Name returns the tool identifier.
+func (t *GitStatusTool) Name() string {
+	return "git_status"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *GitStatusTool) Description() string {
+	return "Show the git working tree status as JSON: current branch, upstream and ahead/behind counts, and each staged, unstaged, untracked, or conflicted file"
+}
+
+// ReadOnly reports that the tool has no side effects.
+func (t *GitStatusTool) ReadOnly() bool {
+	return true
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *GitStatusTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "description": "Directory inside the repository (default: current directory)"}
+		}
+	}`)
+}
+
+// Execute reports the status of the repository containing the path.
+func (t *GitStatusTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params gitStatusInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatGitError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	dir, err := gitDir(ctx, params.Path)
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+	root, err := runGit(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+	out, err := runGit(ctx, dir, "status", "--porcelain=v1", "--branch", "-z")
+	if err != nil {
+		return formatGitFailure(ctx, err)
+	}
+
+	output := parseGitStatus(out)
+	output.Root = strings.TrimSpace(root)
+	data, _ := json.Marshal(output)
+	return string(data), nil
+}
+
+// parseGitStatus parses the output of git status --porcelain=v1 --branch -z.
+func parseGitStatus(out string) gitStatusOutput {
+	output := gitStatusOutput{Files: []gitStatusFile{}}
+	fields := strings.Split(out, "\x00")
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if header, ok := strings.CutPrefix(field, "## "); ok {
+			parseGitStatusBranch(header, &output)
+			continue
+		}
+		if len(field) < 4 {
+			continue
+		}
+		x, y := field[0], field[1]
+		f := gitStatusFile{Path: field[3:]}
+		// A rename or copy is followed by its original path
+		if x == 'R' || x == 'C' || y == 'R' || y == 'C' {
+			if i+1 < len(fields) {
+				i++
+				f.OrigPath = fields[i]
+			}
+		}
+		switch {
+		case x == 'U' || y == 'U' || x == 'A' && y == 'A' || x == 'D' && y == 'D':
+			f.Conflict = true
+		case x == '?' && y == '?':
+			f.Unstaged = "untracked"
+		default:
+			f.Staged = gitStatusWord(x)
+			f.Unstaged = gitStatusWord(y)
+		}
+		if len(output.Files) == maxGitStatusFiles {
+			output.Truncated = true
+			continue
+		}
+		output.Files = append(output.Files, f)
+	}
+	output.Clean = len(output.Files) == 0
+	return output
+}
+
+// parseGitStatusBranch parses the branch header of git status --branch, such
+// as "main...origin/main [ahead 1, behind 2]".
+func parseGitStatusBranch(header string, output *gitStatusOutput) {
+	if rest, ok := strings.CutPrefix(header, "No commits yet on "); ok {
+		output.Branch = rest
+		return
+	}
+	if rest, ok := strings.CutPrefix(header, "Initial commit on "); ok {
+		output.Branch = rest
+		return
+	}
+	if strings.HasPrefix(header, "HEAD (no branch)") {
+		output.Detached = true
+		return
+	}
+
+	branch, track, _ := strings.Cut(header, " [")
+	branch, output.Upstream, _ = strings.Cut(branch, "...")
+	output.Branch = branch
+	for _, part := range strings.Split(strings.TrimSuffix(track, "]"), ", ") {
+		if n, ok := strings.CutPrefix(part, "ahead "); ok {
+			output.Ahead, _ = strconv.Atoi(n)
+		}
+		if n, ok := strings.CutPrefix(part, "behind "); ok {
+			output.Behind, _ = strconv.Atoi(n)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// initGitRepo creates a repository on branch main with one commit of
+// a.txt, isolated from the user's git configuration.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{"a.txt": "one\ntwo\n"})
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "a.txt"},
+		{"commit", "-q", "-m", "Initial commit"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, out)
+		}
+	}
+	return root
+}
+
+// runGitTool executes a git tool and decodes its result into out, failing
+// the test on an error result.
+func runGitTool(t *testing.T, ctx context.Context, tl Tool, input map[string]any, out any) {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	result, err := tl.Execute(ctx, data)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %v", tl.Name(), err)
+	}
+	if IsErrorResult(result) {
+		t.Fatalf("%s: unexpected error result: %s", tl.Name(), result)
+	}
+	if err := json.Unmarshal([]byte(result), out); err != nil {
+		t.Fatalf("%s: failed to parse %s: %v", tl.Name(), result, err)
+	}
+}
+
+func TestGitTools(t *testing.T) {
+	root := initGitRepo(t)
+	ctx := WithWorkspace(context.Background(), root)
+
+	// A staged new file, an unstaged change, and an untracked file
+	writeTreeFiles(t, root, map[string]string{"a.txt": "one\n2\nthree\n", "b.txt": "new\n", "c.txt": "staged\n"})
+	if _, err := runGit(ctx, root, "add", "c.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	var status gitStatusOutput
+	runGitTool(t, ctx, NewGitStatusTool(), map[string]any{}, &status)
+	wantFiles := []gitStatusFile{
+		{Path: "a.txt", Unstaged: "modified"},
+		{Path: "c.txt", Staged: "added"},
+		{Path: "b.txt", Unstaged: "untracked"},
+	}
+	if status.Branch != "main" || status.Clean || !reflect.DeepEqual(status.Files, wantFiles) {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if resolved, _ := filepath.EvalSymlinks(root); status.Root != resolved {
+		t.Errorf("expected root %s, got %s", resolved, status.Root)
+	}
+
+	var diff gitDiffOutput
+	runGitTool(t, ctx, NewGitDiffTool(), map[string]any{}, &diff)
+	if !reflect.DeepEqual(diff.Files, []gitDiffFile{{Path: "a.txt", Additions: 2, Deletions: 1}}) {
+		t.Errorf("unexpected diff files: %+v", diff.Files)
+	}
+	if !strings.Contains(diff.Diff, "-two\n+2\n+three\n") {
+		t.Errorf("unexpected diff:\n%s", diff.Diff)
+	}
+	runGitTool(t, ctx, NewGitDiffTool(), map[string]any{"staged": true}, &diff)
+	if len(diff.Files) != 1 || diff.Files[0].Path != "c.txt" {
+		t.Errorf("unexpected staged diff files: %+v", diff.Files)
+	}
+
+	var commit gitCommitOutput
+	runGitTool(t, ctx, NewGitCommitTool(), map[string]any{"message": "Update files\n\nWith a body.", "all": true}, &commit)
+	wantCommitted := []gitCommitFile{
+		{Path: "a.txt", Status: "modified"},
+		{Path: "b.txt", Status: "added"},
+		{Path: "c.txt", Status: "added"},
+	}
+	if commit.Branch != "main" || commit.Subject != "Update files" || len(commit.Hash) != 40 || !reflect.DeepEqual(commit.Files, wantCommitted) {
+		t.Errorf("unexpected commit: %+v", commit)
+	}
+	runGitTool(t, ctx, NewGitStatusTool(), map[string]any{}, &status)
+	if !status.Clean {
+		t.Errorf("expected a clean tree after commit, got %+v", status.Files)
+	}
+
+	var log gitLogOutput
+	runGitTool(t, ctx, NewGitLogTool(), map[string]any{}, &log)
+	if len(log.Commits) != 2 || log.Commits[0].Hash != commit.Hash || log.Commits[0].Body != "With a body." || log.Commits[1].Subject != "Initial commit" {
+		t.Errorf("unexpected log: %+v", log.Commits)
+	}
+	runGitTool(t, ctx, NewGitLogTool(), map[string]any{"paths": []string{"b.txt"}}, &log)
+	if len(log.Commits) != 1 {
+		t.Errorf("expected 1 commit touching b.txt, got %d", len(log.Commits))
+	}
+
+	var branches gitBranchOutput
+	runGitTool(t, ctx, NewGitBranchTool(), map[string]any{"action": "create", "name": "feature", "start_point": "HEAD~1"}, &branches)
+	runGitTool(t, ctx, NewGitBranchTool(), map[string]any{"action": "switch", "name": "feature"}, &branches)
+	if branches.Current != "feature" || len(branches.Branches) != 2 || branches.Branches[0].Name != "feature" || !branches.Branches[0].Current {
+		t.Errorf("unexpected branches: %+v", branches)
+	}
+	if _, err := os.Stat(filepath.Join(root, "b.txt")); !os.IsNotExist(err) {
+		t.Error("switching to feature should remove b.txt")
+	}
+	runGitTool(t, ctx, NewGitBranchTool(), map[string]any{"action": "switch", "name": "main"}, &branches)
+	runGitTool(t, ctx, NewGitBranchTool(), map[string]any{"action": "delete", "name": "feature"}, &branches)
+	if branches.Current != "main" || len(branches.Branches) != 1 {
+		t.Errorf("unexpected branches after delete: %+v", branches)
+	}
+}
+
+func TestGitTools_Errors(t *testing.T) {
+	root := initGitRepo(t)
+	ctx := WithWorkspace(context.Background(), root)
+	readOnly := WithGitReadOnly(ctx, true)
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		tool   Tool
+		input  map[string]any
+		errMsg string
+	}{
+		{"commit read-only", readOnly, NewGitCommitTool(), map[string]any{"message": "x", "all": true}, "mutating git operations are disabled"},
+		{"branch read-only", readOnly, NewGitBranchTool(), map[string]any{"action": "create", "name": "x"}, "mutating git operations are disabled"},
+		{"commit without message", ctx, NewGitCommitTool(), map[string]any{"message": " "}, "message is required"},
+		{"nothing to commit", ctx, NewGitCommitTool(), map[string]any{"message": "x"}, "nothing to commit"},
+		{"option as ref", ctx, NewGitDiffTool(), map[string]any{"ref": "--output=x"}, "must not start with '-'"},
+		{"unknown ref", ctx, NewGitLogTool(), map[string]any{"ref": "nope"}, "bad revision"},
+		{"invalid action", ctx, NewGitBranchTool(), map[string]any{"action": "rename"}, "invalid action"},
+		{"delete current branch", ctx, NewGitBranchTool(), map[string]any{"action": "delete", "name": "main"}, "main"},
+		{"outside workspace", ctx, NewGitStatusTool(), map[string]any{"path": ".."}, "outside"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := json.Marshal(tt.input)
+			result, err := tt.tool.Execute(tt.ctx, data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var out gitErrorOutput
+			json.Unmarshal([]byte(result), &out)
+			if !strings.Contains(out.Error, tt.errMsg) {
+				t.Errorf("expected error containing %q, got %s", tt.errMsg, result)
+			}
+		})
+	}
+
+	// Listing branches is allowed in read-only mode
+	var branches gitBranchOutput
+	runGitTool(t, readOnly, NewGitBranchTool(), map[string]any{}, &branches)
+	if branches.Current != "main" {
+		t.Errorf("unexpected branches: %+v", branches)
+	}
+
+	// Outside any repository
+	result, _ := NewGitStatusTool().Execute(context.Background(), json.RawMessage(`{"path": "`+t.TempDir()+`"}`))
+	if !strings.Contains(result, "not a git repository") {
+		t.Errorf("expected not a git repository error, got %s", result)
+	}
+}
+
+func TestParseGitStatus(t *testing.T) {
+	out := "## main...origin/main [ahead 2, behind 1]\x00R  new.go\x00old.go\x00UU both.go\x00 D gone.go\x00"
+	got := parseGitStatus(out)
+	want := gitStatusOutput{
+		Branch:   "main",
+		Upstream: "origin/main",
+		Ahead:    2,
+		Behind:   1,
+		Files: []gitStatusFile{
+			{Path: "new.go", OrigPath: "old.go", Staged: "renamed"},
+			{Path: "both.go", Conflict: true},
+			{Path: "gone.go", Unstaged: "deleted"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGitStatus:\n got %+v\nwant %+v", got, want)
+	}
+
+	for header, want := range map[string]gitStatusOutput{
+		"No commits yet on main":  {Branch: "main"},
+		"HEAD (no branch)":        {Detached: true},
+		"dev...origin/dev [gone]": {Branch: "dev", Upstream: "origin/dev"},
+	} {
+		var got gitStatusOutput
+		parseGitStatusBranch(header, &got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parseGitStatusBranch(%q) = %+v, want %+v", header, got, want)
+		}
+	}
+}
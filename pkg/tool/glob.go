@@ -0,0 +1,337 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxGlobResults is the maximum number of paths returned by glob.
+	maxGlobResults = 1000
+	// maxGlobBraces is the maximum number of patterns a brace expansion may produce.
+	maxGlobBraces = 256
+)
+
+// GlobTool implements the Tool interface for finding files by pattern.
+// Patterns use doublestar semantics: "**" matches any number of directories.
+type GlobTool struct{}
+
+// globInput defines the expected input parameters for the glob tool.
+type globInput struct {
+	Pattern  string `json:"pattern"`
+	Path     string `json:"path,omitempty"`
+	NoIgnore bool   `json:"no_ignore"`
+}
+
+// globOutput defines the success response format.
+type globOutput struct {
+	Files     []string `json:"files"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// globError defines the error response format.
+type globError struct {
+	Error string `json:"error"`
+}
+
+// globMatch is a matched file and its modification time.
+type globMatch struct {
+	path    string
+	modTime int64
+}
+
+// NewGlobTool creates a new GlobTool instance.
+func NewGlobTool() *GlobTool {
+	return &GlobTool{}
+}
+
+// Name returns the tool identifier.
+func (t *GlobTool) Name() string {
+	return "glob"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *GlobTool) Description() string {
+	return "Find files matching a glob pattern such as **/*.go, newest first"
+}
+
+// ReadOnly reports that the tool has no side effects.
+func (t *GlobTool) ReadOnly() bool {
+	return true
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *GlobTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"pattern": {"type": "string", "description": "Glob pattern relative to path; ** matches any number of directories, {a,b} matches either"},
+			"path": {"type": "string", "description": "Directory to search (default: current directory)"},
+			"no_ignore": {"type": "boolean", "description": "Also match paths excluded by .gitignore, .ignore, and the global ignore list (default false)"}
+		},
+		"required": ["pattern"]
+	}`)
+}
+
+// Execute walks the directory and returns the files matching the pattern,
+// most recently modified first.
+func (t *GlobTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params globInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatGlobError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	// Validate parameters
+	if params.Pattern == "" {
+		return formatGlobError("pattern is required"), nil
+	}
+	if path.IsAbs(params.Pattern) || filepath.IsAbs(params.Pattern) {
+		return formatGlobError("pattern must be relative to path"), nil
+	}
+	if params.Path == "" {
+		params.Path = "."
+	}
+
+	patterns, err := expandBraces(filepath.ToSlash(params.Pattern))
+	if err != nil {
+		return formatGlobError(err.Error()), nil
+	}
+	var segments [][]string
+	for _, p := range patterns {
+		segs := strings.Split(path.Clean(p), "/")
+		for _, seg := range segs {
+			if seg == ".." {
+				return formatGlobError("pattern must not contain .."), nil
+			}
+			if _, err := path.Match(seg, ""); err != nil {
+				return formatGlobError("invalid pattern: " + p), nil
+			}
+		}
+		segments = append(segments, segs)
+	}
+
+	// Resolve path within the workspace, if any
+	root, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatGlobError(err.Error()), nil
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		return formatGlobError("invalid path: " + err.Error()), nil
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return formatGlobError("path not found"), nil
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return formatGlobError("permission denied"), nil
+		}
+		return formatGlobError(err.Error()), nil
+	}
+	if !info.IsDir() {
+		return formatGlobError("not a directory"), nil
+	}
+
+	var ignore *ignoreMatcher
+	if !params.NoIgnore {
+		ignore = newIgnoreMatcher(ctx, root)
+		ignore.enter(root)
+	}
+
+	var matches []globMatch
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// Skip unreadable entries rather than failing the whole search
+			if d != nil && d.IsDir() && p != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+
+		if d.IsDir() {
+			if d.Name() == ".git" || !anyPrefixMatch(segments, parts) || (ignore != nil && ignore.ignored(p, true)) {
+				return fs.SkipDir
+			}
+			if ignore != nil {
+				ignore.enter(p)
+			}
+			return nil
+		}
+		if ignore != nil && ignore.ignored(p, false) {
+			return nil
+		}
+		for _, segs := range segments {
+			if matchSegments(segs, parts) {
+				var modTime int64
+				if fi, err := d.Info(); err == nil {
+					modTime = fi.ModTime().UnixNano()
+				}
+				matches = append(matches, globMatch{path: filepath.ToSlash(rel), modTime: modTime})
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return formatGlobError(err.Error()), nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].modTime != matches[j].modTime {
+			return matches[i].modTime > matches[j].modTime
+		}
+		return matches[i].path < matches[j].path
+	})
+
+	output := globOutput{Files: []string{}}
+	for i, m := range matches {
+		if i == maxGlobResults {
+			output.Truncated = true
+			break
+		}
+		output.Files = append(output.Files, m.path)
+	}
+	data, _ := json.Marshal(output)
+	return string(data), nil
+}
+
+// matchSegments reports whether path parts match pattern segments, where a
+// "**" segment matches zero or more parts.
+func matchSegments(segs, parts []string) bool {
+	for len(segs) > 0 {
+		if segs[0] == "**" {
+			// Collapse repeated ** and try every split point
+			for len(segs) > 1 && segs[1] == "**" {
+				segs = segs[1:]
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(segs[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(segs[0], parts[0]); !ok {
+			return false
+		}
+		segs, parts = segs[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// anyPrefixMatch reports whether a directory with path parts could contain
+// a match for any of the patterns, so the walk can skip the rest.
+func anyPrefixMatch(patterns [][]string, parts []string) bool {
+	for _, segs := range patterns {
+		if prefixMatch(segs, parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixMatch reports whether parts can be the leading directories of a path
+// matching segs.
+func prefixMatch(segs, parts []string) bool {
+	for i, part := range parts {
+		if i >= len(segs)-1 {
+			// The last segment matches files, not directories, unless it is **
+			return len(segs) > 0 && segs[len(segs)-1] == "**"
+		}
+		if segs[i] == "**" {
+			return true
+		}
+		if ok, _ := path.Match(segs[i], part); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// expandBraces expands {a,b} alternatives into separate patterns.
+// Braces may nest; a pattern without braces is returned as is.
+func expandBraces(pattern string) ([]string, error) {
+	open := strings.IndexByte(pattern, '{')
+	if open < 0 {
+		if strings.IndexByte(pattern, '}') >= 0 {
+			return nil, errors.New("invalid pattern: unmatched }")
+		}
+		return []string{pattern}, nil
+	}
+
+	// Find the matching close brace and split the top-level alternatives
+	depth, start := 0, open+1
+	var alternatives []string
+	closing := -1
+	for i := open; i < len(pattern) && closing < 0; i++ {
+		switch pattern[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				alternatives = append(alternatives, pattern[start:i])
+				closing = i
+			}
+		case ',':
+			if depth == 1 {
+				alternatives = append(alternatives, pattern[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if closing < 0 {
+		return nil, errors.New("invalid pattern: unmatched {")
+	}
+
+	var expanded []string
+	for _, alt := range alternatives {
+		rest, err := expandBraces(pattern[:open] + alt + pattern[closing+1:])
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, rest...)
+		if len(expanded) > maxGlobBraces {
+			return nil, errors.New("invalid pattern: too many brace alternatives")
+		}
+	}
+	return expanded, nil
+}
+
+// formatGlobError formats an error response.
+func formatGlobError(msg string) string {
+	output := globError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeGlobTree creates files under dir with increasing modification times
+// in the order given, so the last file is the newest.
+func writeGlobTree(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	base := time.Now().Add(-time.Hour)
+	for i, name := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func runGlob(t *testing.T, ctx context.Context, input map[string]any) globOutput {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	result, err := NewGlobTool().Execute(ctx, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(result, `"error"`) {
+		t.Fatalf("unexpected error result: %s", result)
+	}
+	var out globOutput
+	if err := json.Unmarshal([]byte(result), &out); err != nil {
+		t.Fatalf("failed to parse output %q: %v", result, err)
+	}
+	return out
+}
+
+func TestGlobTool_DoublestarNewestFirst(t *testing.T) {
+	dir := t.TempDir()
+	writeGlobTree(t, dir,
+		"main.go",
+		"pkg/tool/glob.go",
+		"pkg/tool/README.md",
+		"pkg/harness/harness.go",
+		".git/hooks/pre-commit.go",
+	)
+
+	out := runGlob(t, context.Background(), map[string]any{"pattern": "**/*.go", "path": dir})
+	want := []string{"pkg/harness/harness.go", "pkg/tool/glob.go", "main.go"}
+	if !reflect.DeepEqual(out.Files, want) {
+		t.Errorf("expected %v, got %v", want, out.Files)
+	}
+}
+
+func TestGlobTool_Patterns(t *testing.T) {
+	dir := t.TempDir()
+	writeGlobTree(t, dir,
+		"a.go",
+		"a_test.go",
+		"b.txt",
+		"cmd/x/main.go",
+		"cmd/y/main.go",
+		"docs/guide.md",
+	)
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.go", []string{"a_test.go", "a.go"}},
+		{"*_test.go", []string{"a_test.go"}},
+		{"cmd/*/main.go", []string{"cmd/y/main.go", "cmd/x/main.go"}},
+		{"cmd/**", []string{"cmd/y/main.go", "cmd/x/main.go"}},
+		{"**/*.{md,txt}", []string{"docs/guide.md", "b.txt"}},
+		{"?.go", []string{"a.go"}},
+		{"nothing/**", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			out := runGlob(t, context.Background(), map[string]any{"pattern": tt.pattern, "path": dir})
+			if !reflect.DeepEqual(out.Files, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, out.Files)
+			}
+		})
+	}
+}
+
+func TestGlobTool_Truncates(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < maxGlobResults+5; i++ {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%04d", i)), nil, 0644)
+	}
+
+	out := runGlob(t, context.Background(), map[string]any{"pattern": "*", "path": dir})
+	if len(out.Files) != maxGlobResults || !out.Truncated {
+		t.Errorf("expected %d files and truncated, got %d (truncated=%v)", maxGlobResults, len(out.Files), out.Truncated)
+	}
+}
+
+func TestGlobTool_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeGlobTree(t, dir, "file.txt")
+
+	tests := []struct {
+		input map[string]any
+		want  string
+	}{
+		{map[string]any{"path": dir}, "pattern is required"},
+		{map[string]any{"pattern": "/etc/*", "path": dir}, "pattern must be relative"},
+		{map[string]any{"pattern": "../*", "path": dir}, "must not contain .."},
+		{map[string]any{"pattern": "[a-", "path": dir}, "invalid pattern"},
+		{map[string]any{"pattern": "{a,b", "path": dir}, "unmatched {"},
+		{map[string]any{"pattern": "*", "path": filepath.Join(dir, "missing")}, "path not found"},
+		{map[string]any{"pattern": "*", "path": filepath.Join(dir, "file.txt")}, "not a directory"},
+	}
+	for _, tt := range tests {
+		data, _ := json.Marshal(tt.input)
+		result, err := NewGlobTool().Execute(context.Background(), data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result, tt.want) {
+			t.Errorf("input %v: expected error containing %q, got %s", tt.input, tt.want, result)
+		}
+	}
+}
+
+func TestGlobTool_Workspace(t *testing.T) {
+	root := t.TempDir()
+	writeGlobTree(t, root, "src/app.go")
+	ctx := WithWorkspace(context.Background(), root)
+
+	out := runGlob(t, ctx, map[string]any{"pattern": "**/*.go"})
+	if !reflect.DeepEqual(out.Files, []string{"src/app.go"}) {
+		t.Errorf("expected workspace-relative match, got %v", out.Files)
+	}
+
+	data, _ := json.Marshal(map[string]any{"pattern": "*", "path": "/etc"})
+	result, _ := NewGlobTool().Execute(ctx, data)
+	if !strings.Contains(result, "outside the workspace") {
+		t.Errorf("expected path outside the workspace to be rejected, got %s", result)
+	}
+}
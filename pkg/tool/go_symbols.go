@@ -0,0 +1,656 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// defaultGoSymbolsMaxResults is the number of results returned unless
+	// the input asks for another limit.
+	defaultGoSymbolsMaxResults = 200
+	// maxGoSymbolsMaxResults caps the requested limit.
+	maxGoSymbolsMaxResults = 2000
+	// maxGoSignatureLength is the longest signature returned.
+	maxGoSignatureLength = 300
+)
+
+// goSymbolsModes are the accepted go_symbols modes.
+var goSymbolsModes = []string{"definitions", "references", "exported"}
+
+// GoSymbolsTool implements the Tool interface for finding Go definitions and
+// references by parsing source files, so an identifier is found as code
+// rather than as text.
+type GoSymbolsTool struct{}
+
+// goSymbolsInput defines the expected input parameters for the go_symbols
+// tool.
+type goSymbolsInput struct {
+	Name         string `json:"name"`
+	Path         string `json:"path"`
+	Mode         string `json:"mode"`
+	ExcludeTests bool   `json:"exclude_tests"`
+	MaxResults   int    `json:"max_results,omitempty"`
+}
+
+// goSymbol is one package-level definition.
+type goSymbol struct {
+	Name string `json:"name"`
+	// Kind is func, method, type, field, var, or const.
+	Kind string `json:"kind"`
+	// Receiver is the type a method or field belongs to.
+	Receiver  string `json:"receiver,omitempty"`
+	Package   string `json:"package"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	Signature string `json:"signature"`
+}
+
+// goReference is one use of an identifier.
+type goReference struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Text   string `json:"text"`
+}
+
+// goSymbolsOutput defines the success response format of the definitions
+// and exported modes.
+type goSymbolsOutput struct {
+	Symbols []goSymbol `json:"symbols"`
+	goSymbolsLimit
+}
+
+// goReferencesOutput defines the success response format of the
+// references mode.
+type goReferencesOutput struct {
+	References []goReference `json:"references"`
+	goSymbolsLimit
+}
+
+// goSymbolsLimit reports the results cut by max_results and the files that
+// could not be parsed.
+type goSymbolsLimit struct {
+	Truncated bool   `json:"truncated,omitempty"`
+	Omitted   int    `json:"omitted,omitempty"`
+	Notice    string `json:"notice,omitempty"`
+	// ParseErrors lists the files with syntax errors; what could be parsed
+	// of them is still searched.
+	ParseErrors []string `json:"parse_errors,omitempty"`
+}
+
+// goSymbolsError defines the error response format.
+type goSymbolsError struct {
+	Error string `json:"error"`
+}
+
+// NewGoSymbolsTool creates a new GoSymbolsTool instance.
+func NewGoSymbolsTool() *GoSymbolsTool {
+	return &GoSymbolsTool{}
+}
+
+// Name returns the tool identifier.
+func (t *GoSymbolsTool) Name() string {
+	return "go_symbols"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *GoSymbolsTool) Description() string {
+	return "Find Go definitions, references, or exported symbols by parsing the source, returning file, line, and signature. Prefer this to grep for Go identifiers: it tells definitions from uses and methods from functions. Name may be qualified as Type.Method, Type.Field, or pkg.Func"
+}
+
+// ReadOnly reports that the tool has no side effects.
+func (t *GoSymbolsTool) ReadOnly() bool {
+	return true
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *GoSymbolsTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "description": "Identifier to find, e.g. NewServer, Server.Handler, or tool.ResolvePath; optional for exported, where it selects a symbol and its methods and fields"},
+			"path": {"type": "string", "description": "Go file or directory to search, including subdirectories (default: current directory)"},
+			"mode": {"type": "string", "enum": ["definitions", "references", "exported"], "description": "definitions (default) finds where name is declared; references finds where it is used; exported lists the exported package-level symbols"},
+			"exclude_tests": {"type": "boolean", "description": "Skip _test.go files (default false; always skipped for exported)"},
+			"max_results": {"type": "integer", "description": "Maximum results to return (default 200, max 2000); the number omitted beyond it is reported"}
+		}
+	}`)
+}
+
+// Execute parses the Go files under the path and searches them.
+func (t *GoSymbolsTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params goSymbolsInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatGoSymbolsError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	// Validate parameters
+	mode := params.Mode
+	if mode == "" {
+		mode = "definitions"
+	}
+	if !slices.Contains(goSymbolsModes, mode) {
+		return formatGoSymbolsError(fmt.Sprintf("invalid mode %q: must be one of %s", mode, strings.Join(goSymbolsModes, ", "))), nil
+	}
+	qualifier, name, err := splitGoName(params.Name)
+	if err != nil {
+		return formatGoSymbolsError(err.Error()), nil
+	}
+	if name == "" && mode != "exported" {
+		return formatGoSymbolsError("name is required for " + mode), nil
+	}
+	path := params.Path
+	if path == "" {
+		path = "."
+	}
+
+	// Resolve path within the workspace, if any
+	root, err := ResolvePath(ctx, path)
+	if err != nil {
+		return formatGoSymbolsError(err.Error()), nil
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		return formatGoSymbolsError("invalid path: " + err.Error()), nil
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return formatGoSymbolsError("path not found"), nil
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return formatGoSymbolsError("permission denied"), nil
+		}
+		return formatGoSymbolsError(err.Error()), nil
+	}
+
+	s := &goSymbolsSearch{
+		mode:         mode,
+		qualifier:    qualifier,
+		name:         name,
+		excludeTests: params.ExcludeTests || mode == "exported",
+		maxResults:   clampInt(params.MaxResults, defaultGoSymbolsMaxResults, maxGoSymbolsMaxResults),
+		cache:        ReadCacheFromContext(ctx),
+	}
+	if !info.IsDir() {
+		if !strings.HasSuffix(root, ".go") {
+			return formatGoSymbolsError("not a Go file: " + path), nil
+		}
+		if err := s.searchFile(root, filepath.ToSlash(path)); err != nil {
+			return formatGoSymbolsError(err.Error()), nil
+		}
+		return formatGoSymbolsSuccess(s.output()), nil
+	}
+
+	ignore := newIgnoreMatcher(ctx, root)
+	ignore.enter(root)
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// Skip unreadable entries rather than failing the whole search
+			if d != nil && d.IsDir() && p != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if p == root {
+			return nil
+		}
+		if d.IsDir() {
+			// The go command ignores testdata and names starting with . or _
+			name := d.Name()
+			if name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || ignore.ignored(p, true) {
+				return fs.SkipDir
+			}
+			ignore.enter(p)
+			return nil
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(p, ".go") || ignore.ignored(p, false) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		// Unreadable files are skipped, like directories
+		s.searchFile(p, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return formatGoSymbolsError(err.Error()), nil
+	}
+
+	return formatGoSymbolsSuccess(s.output()), nil
+}
+
+// splitGoName splits a name into its qualifier, a type or package, and the
+// identifier.
+func splitGoName(name string) (string, string, error) {
+	qualifier, ident, ok := strings.Cut(name, ".")
+	if !ok {
+		qualifier, ident = "", name
+	}
+	for _, part := range []string{qualifier, ident} {
+		if part != "" && !token.IsIdentifier(part) || ok && part == "" {
+			return "", "", fmt.Errorf("invalid name: %q (use Name, Type.Name, or pkg.Name)", name)
+		}
+	}
+	return qualifier, ident, nil
+}
+
+// goSymbolsSearch holds the state of one go_symbols call.
+type goSymbolsSearch struct {
+	mode         string
+	qualifier    string
+	name         string
+	excludeTests bool
+	maxResults   int
+	cache        *ReadCache
+
+	symbols     []goSymbol
+	references  []goReference
+	omitted     int
+	parseErrors []string
+}
+
+// searchFile parses the Go file at path, reported as name, and adds its
+// results.
+func (s *goSymbolsSearch) searchFile(path, name string) error {
+	if s.excludeTests && strings.HasSuffix(path, "_test.go") {
+		return nil
+	}
+	src, err := s.cache.readFile(path)
+	if err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		s.parseErrors = append(s.parseErrors, name)
+	}
+	if file == nil {
+		return nil
+	}
+
+	f := &goFile{fset: fset, file: file, src: src, name: name}
+	if s.mode == "references" {
+		s.findReferences(f)
+		return nil
+	}
+	for _, sym := range f.symbols() {
+		if s.matches(sym) {
+			s.addSymbol(sym)
+		}
+	}
+	return nil
+}
+
+// matches reports whether sym is one the definitions or exported search
+// returns.
+func (s *goSymbolsSearch) matches(sym goSymbol) bool {
+	if s.mode == "exported" {
+		if !token.IsExported(sym.Name) || sym.Receiver != "" && !token.IsExported(sym.Receiver) {
+			return false
+		}
+		if s.name == "" {
+			return true
+		}
+		// A type's name also selects its methods and fields
+		if s.qualifier == "" && sym.Receiver == s.name {
+			return true
+		}
+	}
+	if sym.Name != s.name {
+		return false
+	}
+	return s.qualifier == "" || sym.Receiver == s.qualifier || sym.Receiver == "" && sym.Package == s.qualifier
+}
+
+// addSymbol adds a symbol, or counts it past the limit.
+func (s *goSymbolsSearch) addSymbol(sym goSymbol) {
+	if len(s.symbols) == s.maxResults {
+		s.omitted++
+		return
+	}
+	s.symbols = append(s.symbols, sym)
+}
+
+// findReferences adds the uses of the name in f. Without type information a
+// selector x.Name is a use of Type.Name whatever x is, unless x is an
+// imported package.
+func (s *goSymbolsSearch) findReferences(f *goFile) {
+	defs := make(map[*ast.Ident]bool)
+	for _, sym := range f.symbolIdents() {
+		defs[sym] = true
+	}
+	imports := make(map[string]bool)
+	for _, spec := range f.file.Imports {
+		imports[importName(spec)] = true
+	}
+
+	// Bare uses of pkg.Name are found within package pkg
+	bare := s.qualifier == "" || s.qualifier == f.file.Name.Name
+
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ImportSpec:
+			return false
+		case *ast.SelectorExpr:
+			if n.Sel.Name == s.name {
+				x, isIdent := n.X.(*ast.Ident)
+				switch {
+				case s.qualifier == "":
+					s.addReference(f, n.Sel)
+				case isIdent && imports[x.Name]:
+					if x.Name == s.qualifier {
+						s.addReference(f, n.Sel)
+					}
+				default:
+					s.addReference(f, n.Sel)
+				}
+			}
+			// The selected name is done; only the operand remains
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.CompositeLit:
+			// Field keys of a T{...} literal are uses of T.Name
+			if !bare && typeName(n.Type) == s.qualifier {
+				for _, elt := range n.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok && key.Name == s.name {
+							s.addReference(f, key)
+						}
+					}
+				}
+			}
+		case *ast.Ident:
+			if n.Name == s.name && bare && !defs[n] {
+				s.addReference(f, n)
+			}
+		}
+		return true
+	}
+	ast.Inspect(f.file, visit)
+}
+
+// addReference adds the use at ident, or counts it past the limit.
+func (s *goSymbolsSearch) addReference(f *goFile, ident *ast.Ident) {
+	if len(s.references) == s.maxResults {
+		s.omitted++
+		return
+	}
+	pos := f.fset.Position(ident.Pos())
+	s.references = append(s.references, goReference{
+		File:   f.name,
+		Line:   pos.Line,
+		Column: pos.Column,
+		Text:   truncateGrepLine(strings.TrimSpace(f.line(pos.Offset))),
+	})
+}
+
+// output returns the response for the search.
+func (s *goSymbolsSearch) output() any {
+	limit := goSymbolsLimit{ParseErrors: s.parseErrors}
+	if s.omitted > 0 {
+		limit.Truncated = true
+		limit.Omitted = s.omitted
+		limit.Notice = fmt.Sprintf("%d more results omitted; narrow the name or path, or raise max_results", s.omitted)
+	}
+	if s.mode == "references" {
+		refs := s.references
+		if refs == nil {
+			refs = []goReference{}
+		}
+		return goReferencesOutput{References: refs, goSymbolsLimit: limit}
+	}
+	symbols := s.symbols
+	if symbols == nil {
+		symbols = []goSymbol{}
+	}
+	return goSymbolsOutput{Symbols: symbols, goSymbolsLimit: limit}
+}
+
+// goFile is one parsed Go file.
+type goFile struct {
+	fset *token.FileSet
+	file *ast.File
+	src  []byte
+	// name is the file's path as reported.
+	name string
+}
+
+// symbols returns the package-level definitions in the file, with the
+// methods of its interfaces and the fields of its structs, in source order.
+func (f *goFile) symbols() []goSymbol {
+	var symbols []goSymbol
+	add := func(ident *ast.Ident, kind, receiver, signature string) {
+		pos := f.fset.Position(ident.Pos())
+		symbols = append(symbols, goSymbol{
+			Name:      ident.Name,
+			Kind:      kind,
+			Receiver:  receiver,
+			Package:   f.file.Name.Name,
+			File:      f.name,
+			Line:      pos.Line,
+			Column:    pos.Column,
+			Signature: signature,
+		})
+	}
+
+	for _, decl := range f.file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			sig := *decl
+			sig.Doc, sig.Body = nil, nil
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				add(decl.Name, "method", receiverName(decl.Recv.List[0].Type), f.print(&sig))
+			} else {
+				add(decl.Name, "func", "", f.print(&sig))
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec.Name, "type", "", f.typeSignature(spec))
+					f.members(spec, add)
+				case *ast.ValueSpec:
+					kind := decl.Tok.String()
+					for i, ident := range spec.Names {
+						one := &ast.ValueSpec{Names: []*ast.Ident{ident}, Type: spec.Type}
+						if len(spec.Values) == len(spec.Names) {
+							one.Values = []ast.Expr{spec.Values[i]}
+						}
+						add(ident, kind, "", f.print(&ast.GenDecl{Tok: decl.Tok, Specs: []ast.Spec{one}}))
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// members adds the fields of a struct type and the methods of an interface
+// type.
+func (f *goFile) members(spec *ast.TypeSpec, add func(*ast.Ident, string, string, string)) {
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			for _, ident := range field.Names {
+				add(ident, "field", spec.Name.Name, ident.Name+" "+f.print(field.Type))
+			}
+		}
+	case *ast.InterfaceType:
+		for _, method := range t.Methods.List {
+			if ft, ok := method.Type.(*ast.FuncType); ok && len(method.Names) > 0 {
+				add(method.Names[0], "method", spec.Name.Name, method.Names[0].Name+strings.TrimPrefix(f.print(ft), "func"))
+			}
+		}
+	}
+}
+
+// symbolIdents returns the identifiers that name the file's definitions.
+func (f *goFile) symbolIdents() []*ast.Ident {
+	var idents []*ast.Ident
+	for _, decl := range f.file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			idents = append(idents, decl.Name)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					idents = append(idents, spec.Name)
+					switch t := spec.Type.(type) {
+					case *ast.StructType:
+						for _, field := range t.Fields.List {
+							idents = append(idents, field.Names...)
+						}
+					case *ast.InterfaceType:
+						for _, method := range t.Methods.List {
+							idents = append(idents, method.Names...)
+						}
+					}
+				case *ast.ValueSpec:
+					idents = append(idents, spec.Names...)
+				}
+			}
+		}
+	}
+	return idents
+}
+
+// typeSignature returns the declaration of a type, with the body of a
+// struct or interface elided: its fields and methods are symbols of their
+// own.
+func (f *goFile) typeSignature(spec *ast.TypeSpec) string {
+	sig := *spec
+	sig.Doc, sig.Comment = nil, nil
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		sig.Type = ast.NewIdent("struct{...}")
+	case *ast.InterfaceType:
+		sig.Type = ast.NewIdent("interface{...}")
+	}
+	return f.print(&ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&sig}})
+}
+
+// print returns node as Go source on one line, cut to
+// maxGoSignatureLength.
+func (f *goFile) print(node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f.fset, node); err != nil {
+		return ""
+	}
+	s := strings.Join(strings.Fields(buf.String()), " ")
+	if len(s) > maxGoSignatureLength {
+		cut := maxGoSignatureLength
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut] + "…"
+	}
+	return s
+}
+
+// line returns the source line containing the byte offset.
+func (f *goFile) line(offset int) string {
+	start := bytes.LastIndexByte(f.src[:offset], '\n') + 1
+	end := bytes.IndexByte(f.src[offset:], '\n')
+	if end < 0 {
+		end = len(f.src) - offset
+	}
+	return strings.TrimSuffix(string(f.src[start:offset+end]), "\r")
+}
+
+// receiverName returns the type name of a method receiver, without pointer
+// or type parameters.
+func receiverName(expr ast.Expr) string {
+	return typeName(expr)
+}
+
+// typeName returns the name of the type expr refers to, without package,
+// pointer, or type parameters, or "" if it is not a named type.
+func typeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			return e.Sel.Name
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// importName returns the name an import is referred to by in its file: its
+// explicit name, or the last element of its path.
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	path, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+	name := path[strings.LastIndex(path, "/")+1:]
+	// Versioned paths such as example.com/mod/v2 are referred to by the
+	// element before the version
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		if i := strings.LastIndex(path, "/"); i > 0 {
+			prev := path[:i]
+			name = prev[strings.LastIndex(prev, "/")+1:]
+		}
+	}
+	return name
+}
+
+// formatGoSymbolsSuccess formats a successful response.
+func formatGoSymbolsSuccess(output any) string {
+	data, _ := json.Marshal(output)
+	return string(data)
+}
+
+// formatGoSymbolsError formats an error response.
+func formatGoSymbolsError(msg string) string {
+	output := goSymbolsError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
@@ -0,0 +1,368 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// goSymbolsResult is the go_symbols output of any mode.
+type goSymbolsResult struct {
+	Symbols    []goSymbol    `json:"symbols"`
+	References []goReference `json:"references"`
+	goSymbolsLimit
+	Error string `json:"error"`
+}
+
+// runGoSymbols runs the go_symbols tool with input and parses its output.
+func runGoSymbols(t *testing.T, input map[string]any) goSymbolsResult {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	output, err := NewGoSymbolsTool().Execute(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var result goSymbolsResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("failed to parse output JSON: %v", err)
+	}
+	return result
+}
+
+// symbolLines formats symbols as file:line kind receiver.name: signature,
+// one per line.
+func symbolLines(symbols []goSymbol) string {
+	var lines []string
+	for _, s := range symbols {
+		name := s.Name
+		if s.Receiver != "" {
+			name = s.Receiver + "." + name
+		}
+		lines = append(lines, fmt.Sprintf("%s:%d %s %s: %s", s.File, s.Line, s.Kind, name, s.Signature))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// referenceLines formats references as file:line:column, one per line.
+func referenceLines(refs []goReference) string {
+	var lines []string
+	for _, r := range refs {
+		lines = append(lines, fmt.Sprintf("%s:%d:%d", r.File, r.Line, r.Column))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeGoSymbolsTree creates a small module with a server package, a main
+// package using it, and files the search skips.
+func writeGoSymbolsTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"server/server.go": `package server
+
+import "net/http"
+
+// Server serves requests.
+type Server struct {
+	Addr    string
+	handler http.Handler
+}
+
+// Handler handles one request.
+type Handler interface {
+	Handle(name string) error
+}
+
+const DefaultAddr = ":8080"
+
+var count, limit = 0, 10
+
+// NewServer returns a server.
+func NewServer(addr string) *Server {
+	return &Server{Addr: addr}
+}
+
+// Start starts the server.
+func (s *Server) Start() error {
+	return http.ListenAndServe(s.Addr, s.handler)
+}
+
+func (s *Server) stop() {}
+`,
+		"server/server_test.go": `package server
+
+func TestStart() {
+	NewServer(DefaultAddr).Start()
+}
+`,
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/app/server"
+)
+
+func Start() {}
+
+func main() {
+	s := server.NewServer(server.DefaultAddr)
+	fmt.Println(s.Addr)
+	s.Start()
+	Start()
+}
+`,
+		"testdata/skip.go":   "package skip\n\nfunc NewServer() {}\n",
+		"_old/skip.go":       "package skip\n\nfunc NewServer() {}\n",
+		"generated/.keep.go": "package generated\n\nfunc NewServer() {}\n",
+		"broken/broken.go":   "package broken\n\nfunc NewServer() {}\n\nfunc (\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("generated/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestGoSymbolsTool_Definitions(t *testing.T) {
+	dir := writeGoSymbolsTree(t)
+
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  string
+	}{
+		{
+			name:  "function",
+			input: map[string]any{"name": "NewServer"},
+			want: "broken/broken.go:3 func NewServer: func NewServer()\n" +
+				"server/server.go:21 func NewServer: func NewServer(addr string) *Server",
+		},
+		{
+			name:  "function and method of the same name",
+			input: map[string]any{"name": "Start"},
+			want: "main.go:9 func Start: func Start()\n" +
+				"server/server.go:26 method Server.Start: func (s *Server) Start() error",
+		},
+		{
+			name:  "method by type",
+			input: map[string]any{"name": "Server.Start"},
+			want:  "server/server.go:26 method Server.Start: func (s *Server) Start() error",
+		},
+		{
+			name:  "function by package",
+			input: map[string]any{"name": "main.Start"},
+			want:  "main.go:9 func Start: func Start()",
+		},
+		{
+			name:  "struct type",
+			input: map[string]any{"name": "Server"},
+			want:  "server/server.go:6 type Server: type Server struct{...}",
+		},
+		{
+			name:  "field",
+			input: map[string]any{"name": "Server.handler"},
+			want:  "server/server.go:8 field Server.handler: handler http.Handler",
+		},
+		{
+			name:  "interface method",
+			input: map[string]any{"name": "Handle"},
+			want:  "server/server.go:13 method Handler.Handle: Handle(name string) error",
+		},
+		{
+			name:  "const",
+			input: map[string]any{"name": "DefaultAddr"},
+			want:  `server/server.go:16 const DefaultAddr: const DefaultAddr = ":8080"`,
+		},
+		{
+			name:  "one of several vars",
+			input: map[string]any{"name": "limit"},
+			want:  "server/server.go:18 var limit: var limit = 10",
+		},
+		{
+			name:  "test files",
+			input: map[string]any{"name": "TestStart"},
+			want:  "server/server_test.go:3 func TestStart: func TestStart()",
+		},
+		{
+			name:  "excluded test files",
+			input: map[string]any{"name": "TestStart", "exclude_tests": true},
+			want:  "",
+		},
+		{
+			name:  "single file",
+			input: map[string]any{"name": "Start", "path": filepath.Join(dir, "main.go")},
+			want:  "main.go:9 func Start: func Start()",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := tt.input["path"]; !ok {
+				tt.input["path"] = dir
+			}
+			result := runGoSymbols(t, tt.input)
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			got := symbolLines(result.Symbols)
+			if tt.name == "single file" {
+				got = strings.ReplaceAll(got, filepath.ToSlash(dir)+"/", "")
+			}
+			if got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGoSymbolsTool_ParseErrors(t *testing.T) {
+	dir := writeGoSymbolsTree(t)
+
+	result := runGoSymbols(t, map[string]any{"name": "NewServer", "path": dir})
+	if len(result.ParseErrors) != 1 || result.ParseErrors[0] != "broken/broken.go" {
+		t.Errorf("expected broken/broken.go in parse_errors, got %v", result.ParseErrors)
+	}
+}
+
+func TestGoSymbolsTool_References(t *testing.T) {
+	dir := writeGoSymbolsTree(t)
+
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  string
+	}{
+		{
+			name:  "bare name",
+			input: map[string]any{"name": "Start"},
+			want:  "main.go:14:4\nmain.go:15:2\nserver/server_test.go:4:25",
+		},
+		{
+			name:  "method by type",
+			input: map[string]any{"name": "Server.Start"},
+			want:  "main.go:14:4\nserver/server_test.go:4:25",
+		},
+		{
+			name:  "function by package",
+			input: map[string]any{"name": "server.NewServer"},
+			want:  "main.go:12:14\nserver/server_test.go:4:2",
+		},
+		{
+			name:  "other package's selector",
+			input: map[string]any{"name": "server.Println"},
+			want:  "",
+		},
+		{
+			name:  "field",
+			input: map[string]any{"name": "Server.Addr"},
+			want:  "main.go:13:16\nserver/server.go:22:17\nserver/server.go:27:31",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input["path"] = dir
+			tt.input["mode"] = "references"
+			result := runGoSymbols(t, tt.input)
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if got := referenceLines(result.References); got != tt.want {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+
+	result := runGoSymbols(t, map[string]any{"name": "NewServer", "path": dir, "mode": "references"})
+	if len(result.References) == 0 || result.References[0].Text != "s := server.NewServer(server.DefaultAddr)" {
+		t.Errorf("expected the source line as text, got %+v", result.References)
+	}
+}
+
+func TestGoSymbolsTool_Exported(t *testing.T) {
+	dir := writeGoSymbolsTree(t)
+
+	result := runGoSymbols(t, map[string]any{"mode": "exported", "path": filepath.Join(dir, "server")})
+	want := "server.go:6 type Server: type Server struct{...}\n" +
+		"server.go:7 field Server.Addr: Addr string\n" +
+		"server.go:12 type Handler: type Handler interface{...}\n" +
+		"server.go:13 method Handler.Handle: Handle(name string) error\n" +
+		`server.go:16 const DefaultAddr: const DefaultAddr = ":8080"` + "\n" +
+		"server.go:21 func NewServer: func NewServer(addr string) *Server\n" +
+		"server.go:26 method Server.Start: func (s *Server) Start() error"
+	if got := symbolLines(result.Symbols); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	result = runGoSymbols(t, map[string]any{"mode": "exported", "name": "Server", "path": filepath.Join(dir, "server")})
+	want = "server.go:6 type Server: type Server struct{...}\n" +
+		"server.go:7 field Server.Addr: Addr string\n" +
+		"server.go:26 method Server.Start: func (s *Server) Start() error"
+	if got := symbolLines(result.Symbols); got != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestGoSymbolsTool_MaxResults(t *testing.T) {
+	dir := writeGoSymbolsTree(t)
+
+	result := runGoSymbols(t, map[string]any{"mode": "exported", "path": dir, "max_results": 2})
+	if len(result.Symbols) != 2 || !result.Truncated || result.Omitted != 7 || result.Notice == "" {
+		t.Errorf("expected 2 symbols with 7 omitted, got %d, truncated %v, omitted %d", len(result.Symbols), result.Truncated, result.Omitted)
+	}
+}
+
+func TestGoSymbolsTool_Errors(t *testing.T) {
+	dir := writeGoSymbolsTree(t)
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  string
+	}{
+		{"missing name", map[string]any{"path": dir}, "name is required for definitions"},
+		{"malformed name", map[string]any{"name": "a.b.c", "path": dir}, "invalid name"},
+		{"empty qualifier", map[string]any{"name": ".Start", "path": dir}, "invalid name"},
+		{"unknown mode", map[string]any{"name": "Start", "mode": "callers", "path": dir}, `invalid mode "callers"`},
+		{"missing path", map[string]any{"name": "Start", "path": filepath.Join(dir, "nope")}, "path not found"},
+		{"not a Go file", map[string]any{"name": "Start", "path": filepath.Join(dir, "notes.txt")}, "not a Go file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runGoSymbols(t, tt.input)
+			if !strings.Contains(result.Error, tt.want) {
+				t.Errorf("expected error containing %q, got %q", tt.want, result.Error)
+			}
+		})
+	}
+}
+
+func TestGoSymbolsTool_EmptyResults(t *testing.T) {
+	dir := writeGoSymbolsTree(t)
+
+	data, _ := json.Marshal(map[string]any{"name": "Missing", "path": dir})
+	output, err := NewGoSymbolsTool().Execute(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, `"symbols":[]`) {
+		t.Errorf("expected an empty symbols array, got %s", output)
+	}
+}
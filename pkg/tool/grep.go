@@ -1,28 +1,87 @@
 package tool
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// defaultGrepMaxMatches is the number of matches returned unless the
+	// input asks for another limit.
+	defaultGrepMaxMatches = 200
+	// maxGrepMaxMatches caps the requested limit.
+	maxGrepMaxMatches = 2000
+	// maxGrepContext caps the number of context lines around a match.
+	maxGrepContext = 10
+	// maxGrepLineLength is the longest line text returned; longer lines,
+	// typically minified code, are cut.
+	maxGrepLineLength = 500
+	// maxGrepScanLine is the longest line scanned; files with longer lines
+	// are searched up to that line.
+	maxGrepScanLine = 1024 * 1024
 )
 
 // GrepTool implements the Tool interface for searching patterns in files.
-// It uses the system's grep command with Basic Regular Expressions (BRE).
+// Patterns use Go's RE2 syntax, so behavior does not depend on the
+// platform's grep.
 type GrepTool struct{}
 
 // grepInput defines the expected input parameters for the grep tool.
 type grepInput struct {
-	Pattern   string `json:"pattern"`
-	Path      string `json:"path"`
-	Recursive *bool  `json:"recursive,omitempty"`
+	Pattern    string   `json:"pattern"`
+	Path       string   `json:"path"`
+	Recursive  *bool    `json:"recursive,omitempty"`
+	IgnoreCase bool     `json:"ignore_case"`
+	Include    []string `json:"include,omitempty"`
+	Exclude    []string `json:"exclude,omitempty"`
+	MaxMatches int      `json:"max_matches,omitempty"`
+	Context    int      `json:"context,omitempty"`
+	Before     *int     `json:"before,omitempty"`
+	After      *int     `json:"after,omitempty"`
+	FilesOnly  bool     `json:"files_only"`
+	NoIgnore   bool     `json:"no_ignore"`
+}
+
+// grepMatch is one matching line.
+type grepMatch struct {
+	File   string   `json:"file"`
+	Line   int      `json:"line"`
+	Column int      `json:"column"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
 }
 
 // grepOutput defines the success response format.
 type grepOutput struct {
-	Matches string `json:"matches"`
+	Matches []*grepMatch `json:"matches"`
+	grepLimit
+}
+
+// grepFilesOutput defines the success response format with files_only.
+type grepFilesOutput struct {
+	Files []string `json:"files"`
+	grepLimit
+}
+
+// grepLimit reports the results cut by max_matches.
+type grepLimit struct {
+	Truncated bool `json:"truncated,omitempty"`
+	// Omitted counts the matches (or files) past the limit; Notice
+	// describes them for the model.
+	Omitted int    `json:"omitted,omitempty"`
+	Notice  string `json:"notice,omitempty"`
 }
 
 // grepError defines the error response format.
@@ -42,7 +101,12 @@ func (t *GrepTool) Name() string {
 
 // Description returns a human-readable description of the tool.
 func (t *GrepTool) Description() string {
-	return "Search for patterns in files or directories"
+	return "Search for a regular expression (RE2 syntax) in files or directories, returning matching lines with file, line, and column"
+}
+
+// ReadOnly reports that the tool has no side effects.
+func (t *GrepTool) ReadOnly() bool {
+	return true
 }
 
 // InputSchema returns the JSON Schema for the tool's input parameters.
@@ -50,9 +114,18 @@ func (t *GrepTool) InputSchema() json.RawMessage {
 	return json.RawMessage(`{
 		"type": "object",
 		"properties": {
-			"pattern": {"type": "string", "description": "Search pattern (BRE regex)"},
+			"pattern": {"type": "string", "description": "Regular expression (Go RE2 syntax)"},
 			"path": {"type": "string", "description": "File or directory path"},
-			"recursive": {"type": "boolean", "description": "Search recursively (default: false)"}
+			"recursive": {"type": "boolean", "description": "Search subdirectories too (default: false, which searches only the files directly in a directory)"},
+			"ignore_case": {"type": "boolean", "description": "Match case-insensitively (default: false)"},
+			"include": {"type": "array", "items": {"type": "string"}, "description": "Only search files matching these globs, e.g. [\"*.go\"]. Globs without / match the file name; others match the path relative to path"},
+			"exclude": {"type": "array", "items": {"type": "string"}, "description": "Skip files matching these globs"},
+			"max_matches": {"type": "integer", "description": "Maximum matches to return (default 200, max 2000); the number omitted beyond it is reported"},
+			"context": {"type": "integer", "description": "Lines of context to include before and after each match (default 0, max 10)"},
+			"before": {"type": "integer", "description": "Lines of context before each match, overriding context (max 10)"},
+			"after": {"type": "integer", "description": "Lines of context after each match, overriding context (max 10)"},
+			"files_only": {"type": "boolean", "description": "Return only the paths of files with a match; max_matches then limits files (default: false)"},
+			"no_ignore": {"type": "boolean", "description": "Also search paths excluded by .gitignore, .ignore, and the global ignore list (default false)"}
 		},
 		"required": ["pattern", "path"]
 	}`)
@@ -79,9 +152,51 @@ func (t *GrepTool) Execute(ctx context.Context, input json.RawMessage) (string,
 	if params.Path == "" {
 		return formatGrepError("path is required"), nil
 	}
+	expr := params.Pattern
+	if params.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return formatGrepError("invalid pattern: " + err.Error()), nil
+	}
+	include, err := compileGrepGlobs(params.Include)
+	if err != nil {
+		return formatGrepError(err.Error()), nil
+	}
+	exclude, err := compileGrepGlobs(params.Exclude)
+	if err != nil {
+		return formatGrepError(err.Error()), nil
+	}
+
+	context := clampInt(params.Context, 0, maxGrepContext)
+	s := &grepSearch{
+		re:         re,
+		include:    include,
+		exclude:    exclude,
+		maxMatches: clampInt(params.MaxMatches, defaultGrepMaxMatches, maxGrepMaxMatches),
+		before:     context,
+		after:      context,
+		filesOnly:  params.FilesOnly,
+	}
+	if params.Before != nil {
+		s.before = clampInt(*params.Before, 0, maxGrepContext)
+	}
+	if params.After != nil {
+		s.after = clampInt(*params.After, 0, maxGrepContext)
+	}
+
+	// Resolve path within the workspace, if any
+	root, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatGrepError(err.Error()), nil
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		return formatGrepError("invalid path: " + err.Error()), nil
+	}
 
 	// Check if path exists
-	_, err := os.Stat(params.Path)
+	info, err := os.Stat(root)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return formatGrepError("path not found"), nil
@@ -92,63 +207,250 @@ func (t *GrepTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		return formatGrepError(err.Error()), nil
 	}
 
-	// Build grep command arguments
-	// -n: show line numbers
-	args := []string{"-n"}
+	if !info.IsDir() {
+		// An explicitly named file is searched regardless of filters
+		if err := s.searchFile(ctx, root, filepath.ToSlash(params.Path)); err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			if errors.Is(err, os.ErrPermission) {
+				return formatGrepError("permission denied"), nil
+			}
+			return formatGrepError(err.Error()), nil
+		}
+		return formatGrepSuccess(s.output()), nil
+	}
 
-	// Add recursive flag if requested
-	if params.Recursive != nil && *params.Recursive {
-		args = append(args, "-r")
+	recursive := params.Recursive != nil && *params.Recursive
+	var ignore *ignoreMatcher
+	if !params.NoIgnore {
+		ignore = newIgnoreMatcher(ctx, root)
+		ignore.enter(root)
+	}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// Skip unreadable entries rather than failing the whole search
+			if d != nil && d.IsDir() && p != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if p == root {
+			return nil
+		}
+		if d.IsDir() {
+			if !recursive || d.Name() == ".git" || (ignore != nil && ignore.ignored(p, true)) {
+				return fs.SkipDir
+			}
+			if ignore != nil {
+				ignore.enter(p)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || (ignore != nil && ignore.ignored(p, false)) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if !s.selected(rel) {
+			return nil
+		}
+		// Unreadable files are skipped, like directories
+		if err := s.searchFile(ctx, p, rel); ctx.Err() != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return formatGrepError(err.Error()), nil
 	}
 
-	// Add pattern and path
-	args = append(args, params.Pattern, params.Path)
+	return formatGrepSuccess(s.output()), nil
+}
+
+// grepSearch holds the state of one grep call.
+type grepSearch struct {
+	re         *regexp.Regexp
+	include    [][]string
+	exclude    [][]string
+	maxMatches int
+	before     int
+	after      int
+	filesOnly  bool
+
+	matches []*grepMatch
+	files   []string
+	omitted int
+}
 
-	// Execute grep command
-	cmd := exec.CommandContext(ctx, "/usr/bin/grep", args...)
-	output, err := cmd.Output()
+// selected reports whether the file at the slash-separated path rel passes
+// the include and exclude filters.
+func (s *grepSearch) selected(rel string) bool {
+	if len(s.include) > 0 && !matchGrepGlobs(s.include, rel) {
+		return false
+	}
+	return !matchGrepGlobs(s.exclude, rel)
+}
 
-	// Handle errors
+// searchFile appends the matches in the file at path, reported as name, or
+// with filesOnly the name if anything matches. Binary files are skipped.
+// Matches beyond the limit are counted as omitted.
+func (s *grepSearch) searchFile(ctx context.Context, path, name string) error {
+	f, err := ReadCacheFromContext(ctx).open(path)
 	if err != nil {
-		// Check for context cancellation
-		if ctx.Err() != nil {
-			return "", ctx.Err()
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	head, _ := r.Peek(statBinarySniffSize)
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxGrepScanLine)
+	var before []string      // the last s.before lines
+	var pending []*grepMatch // matches still collecting after-context
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if lineNo%1000 == 0 && ctx.Err() != nil {
+			return ctx.Err()
 		}
+		text := truncateGrepLine(strings.TrimSuffix(scanner.Text(), "\r"))
 
-		// grep returns exit code 1 when no matches are found
-		// This is not an error condition for us
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			// Exit code 1 means no matches - return success with empty string
-			if exitErr.ExitCode() == 1 {
-				return formatGrepSuccess(""), nil
+		// Fill the after-context of earlier matches
+		open := pending[:0]
+		for _, m := range pending {
+			m.After = append(m.After, text)
+			if len(m.After) < s.after {
+				open = append(open, m)
 			}
+		}
+		pending = open
 
-			// Exit code 2 typically means error (invalid regex, etc.)
-			stderr := strings.TrimSpace(string(exitErr.Stderr))
-			if strings.Contains(stderr, "Invalid regular expression") ||
-				strings.Contains(stderr, "invalid") ||
-				strings.Contains(stderr, "illegal") {
-				return formatGrepError("invalid pattern: " + stderr), nil
+		if loc := s.re.FindStringIndex(text); loc != nil {
+			switch {
+			case s.filesOnly:
+				if len(s.files) == s.maxMatches {
+					s.omitted++
+				} else {
+					s.files = append(s.files, name)
+				}
+				return nil
+			case len(s.matches) == s.maxMatches:
+				s.omitted++
+			default:
+				m := &grepMatch{File: name, Line: lineNo, Column: loc[0] + 1, Text: text}
+				if len(before) > 0 {
+					m.Before = append([]string(nil), before...)
+				}
+				s.matches = append(s.matches, m)
+				if s.after > 0 {
+					pending = append(pending, m)
+				}
 			}
-			if strings.Contains(stderr, "Permission denied") {
-				return formatGrepError("permission denied"), nil
+		}
+
+		if s.before > 0 {
+			before = append(before, text)
+			if len(before) > s.before {
+				before = before[1:]
 			}
-			if stderr != "" {
-				return formatGrepError(stderr), nil
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return err
+	}
+	return nil
+}
+
+// output returns the search result.
+func (s *grepSearch) output() any {
+	limit := grepLimit{Truncated: s.omitted > 0, Omitted: s.omitted}
+	unit := "matches"
+	if s.filesOnly {
+		unit = "files"
+	}
+	if s.omitted > 0 {
+		limit.Notice = fmt.Sprintf("%d more %s omitted; narrow the pattern or path, or raise max_matches", s.omitted, unit)
+	}
+	if s.filesOnly {
+		return grepFilesOutput{Files: append([]string{}, s.files...), grepLimit: limit}
+	}
+	return grepOutput{Matches: append([]*grepMatch{}, s.matches...), grepLimit: limit}
+}
+
+// compileGrepGlobs splits file globs into segments for matchSegments,
+// expanding braces. Globs without a slash match the file name at any depth.
+func compileGrepGlobs(globs []string) ([][]string, error) {
+	var compiled [][]string
+	for _, g := range globs {
+		alternatives, err := expandBraces(filepath.ToSlash(g))
+		if err != nil {
+			return nil, err
+		}
+		for _, alt := range alternatives {
+			segs := strings.Split(path.Clean(strings.TrimPrefix(alt, "/")), "/")
+			for _, seg := range segs {
+				if _, err := path.Match(seg, ""); err != nil {
+					return nil, errors.New("invalid glob: " + g)
+				}
+			}
+			if !strings.Contains(alt, "/") {
+				segs = append([]string{"**"}, segs...)
 			}
-			return formatGrepError("grep failed with exit code " + string(rune('0'+exitErr.ExitCode()))), nil
+			compiled = append(compiled, segs)
 		}
-		return formatGrepError("failed to execute grep: " + err.Error()), nil
 	}
+	return compiled, nil
+}
+
+// matchGrepGlobs reports whether the slash-separated path rel matches any
+// compiled glob.
+func matchGrepGlobs(globs [][]string, rel string) bool {
+	parts := strings.Split(rel, "/")
+	for _, segs := range globs {
+		if matchSegments(segs, parts) {
+			return true
+		}
+	}
+	return false
+}
 
-	// Return successful matches
-	return formatGrepSuccess(strings.TrimSuffix(string(output), "\n")), nil
+// truncateGrepLine cuts text to maxGrepLineLength bytes on a rune boundary.
+func truncateGrepLine(text string) string {
+	if len(text) <= maxGrepLineLength {
+		return text
+	}
+	cut := maxGrepLineLength
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…"
+}
+
+// clampInt returns v, or def if v is not positive, capped at max.
+func clampInt(v, def, max int) int {
+	if v <= 0 {
+		v = def
+	}
+	if v > max {
+		v = max
+	}
+	return v
 }
 
 // formatGrepSuccess formats a successful grep response.
-func formatGrepSuccess(matches string) string {
-	output := grepOutput{Matches: matches}
+func formatGrepSuccess(output any) string {
 	data, _ := json.Marshal(output)
 	return string(data)
 }
@@ -3,6 +3,7 @@ package tool
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,13 +11,25 @@ import (
 )
 
 // Helper to parse grep tool output
-func parseGrepOutput(t *testing.T, output string) (matches string, errMsg string) {
+func parseGrepOutput(t *testing.T, output string) (matches []*grepMatch, errMsg string) {
 	t.Helper()
-	var result map[string]string
+	var result struct {
+		grepOutput
+		Error string `json:"error"`
+	}
 	if err := json.Unmarshal([]byte(output), &result); err != nil {
 		t.Fatalf("failed to parse output JSON: %v", err)
 	}
-	return result["matches"], result["error"]
+	return result.Matches, result.Error
+}
+
+// grepLines formats matches as file:line:column:text, one per line.
+func grepLines(matches []*grepMatch) string {
+	var lines []string
+	for _, m := range matches {
+		lines = append(lines, fmt.Sprintf("%s:%d:%d:%s", m.File, m.Line, m.Column, m.Text))
+	}
+	return strings.Join(lines, "\n")
 }
 
 func TestGrepTool_Name(t *testing.T) {
@@ -88,12 +101,9 @@ func TestGrepTool_PatternFoundSingleFile(t *testing.T) {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
 
-	// Single file should show line_number:content format
-	if !strings.Contains(matches, "1:line1 foo bar") {
-		t.Errorf("expected match on line 1, got %q", matches)
-	}
-	if !strings.Contains(matches, "3:line3 foo again") {
-		t.Errorf("expected match on line 3, got %q", matches)
+	want := testFile + ":1:7:line1 foo bar\n" + testFile + ":3:7:line3 foo again"
+	if got := grepLines(matches); got != want {
+		t.Errorf("expected %q, got %q", want, got)
 	}
 }
 
@@ -122,12 +132,9 @@ func TestGrepTool_PatternFoundDirectory(t *testing.T) {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
 
-	// Recursive search should show filename:line_number:content format
-	if !strings.Contains(matches, "file1.txt") {
-		t.Errorf("expected file1.txt in matches, got %q", matches)
-	}
-	if !strings.Contains(matches, "file2.txt") {
-		t.Errorf("expected file2.txt in matches, got %q", matches)
+	// Files are reported relative to the searched directory
+	if got, want := grepLines(matches), "file1.txt:1:7:hello foo world\nfile2.txt:2:1:foo here"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
 	}
 }
 
@@ -153,8 +160,8 @@ func TestGrepTool_NoMatches(t *testing.T) {
 	if gotErr != "" {
 		t.Fatalf("no matches should not be an error, got: %s", gotErr)
 	}
-	if matches != "" {
-		t.Errorf("expected empty matches, got %q", matches)
+	if matches == nil || len(matches) != 0 {
+		t.Errorf("expected empty matches, got %v", matches)
 	}
 }
 
@@ -185,11 +192,8 @@ func TestGrepTool_RecursiveSearch(t *testing.T) {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
 
-	if !strings.Contains(matches, "root.txt") {
-		t.Error("should find matches in root file")
-	}
-	if !strings.Contains(matches, "nested.txt") {
-		t.Error("should find matches in nested file")
+	if got, want := grepLines(matches), "root.txt:1:1:foo in root\nsubdir/nested.txt:1:1:foo in nested"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
 	}
 }
 
@@ -203,17 +207,23 @@ func TestGrepTool_NonRecursiveOnDirectory(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("foo content"), 0644)
+	os.Mkdir(filepath.Join(tmpDir, "sub"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "sub", "nested.txt"), []byte("foo nested"), 0644)
 
-	// Non-recursive search on directory - grep behavior varies
-	// Just verify it doesn't crash
+	// Non-recursive search covers only the files directly in the directory
 	input, _ := json.Marshal(map[string]string{"pattern": "foo", "path": tmpDir})
 	output, err := tool.Execute(context.Background(), input)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	_, _ = parseGrepOutput(t, output)
-	// The behavior here varies by grep version, so we just verify it completes
+	matches, gotErr := parseGrepOutput(t, output)
+	if gotErr != "" {
+		t.Fatalf("unexpected error in output: %s", gotErr)
+	}
+	if got := grepLines(matches); got != "test.txt:1:1:foo content" {
+		t.Errorf("expected only test.txt, got %q", got)
+	}
 }
 
 func TestGrepTool_InvalidRegex(t *testing.T) {
@@ -337,9 +347,9 @@ func TestGrepTool_CaseSensitive(t *testing.T) {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
 
-	// Should find line with "foo" (the line contains all variations)
-	if matches == "" {
-		t.Error("should find match for lowercase foo")
+	// The match column points at the lowercase occurrence
+	if len(matches) != 1 || matches[0].Column != 9 {
+		t.Errorf("expected one match at column 9, got %q", grepLines(matches))
 	}
 }
 
@@ -355,7 +365,6 @@ func TestGrepTool_RegexPattern(t *testing.T) {
 	testFile := filepath.Join(tmpDir, "test.txt")
 	os.WriteFile(testFile, []byte("foo123bar\nfoo456bar\nnoMatch"), 0644)
 
-	// Use BRE regex pattern
 	input, _ := json.Marshal(map[string]string{"pattern": "foo[0-9]*bar", "path": testFile})
 	output, err := tool.Execute(context.Background(), input)
 	if err != nil {
@@ -367,13 +376,181 @@ func TestGrepTool_RegexPattern(t *testing.T) {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
 
-	if !strings.Contains(matches, "foo123bar") {
+	got := grepLines(matches)
+	if !strings.Contains(got, "foo123bar") {
 		t.Error("should match foo123bar")
 	}
-	if !strings.Contains(matches, "foo456bar") {
+	if !strings.Contains(got, "foo456bar") {
 		t.Error("should match foo456bar")
 	}
-	if strings.Contains(matches, "noMatch") {
+	if strings.Contains(got, "noMatch") {
 		t.Error("should not match noMatch")
 	}
 }
+
+func TestGrepTool_IgnoreCase(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	os.WriteFile(testFile, []byte("Foo\nbar\nFOO"), 0644)
+
+	input, _ := json.Marshal(map[string]any{"pattern": "foo", "path": testFile, "ignore_case": true})
+	output, _ := NewGrepTool().Execute(context.Background(), input)
+	matches, gotErr := parseGrepOutput(t, output)
+	if gotErr != "" {
+		t.Fatalf("unexpected error in output: %s", gotErr)
+	}
+	if len(matches) != 2 || matches[0].Line != 1 || matches[1].Line != 3 {
+		t.Errorf("expected lines 1 and 3, got %q", grepLines(matches))
+	}
+}
+
+func TestGrepTool_IncludeExclude(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.go", "a_test.go", "b.ts", "pkg/c.go", "pkg/gen/d.go", ".git/e.go"} {
+		p := filepath.Join(tmpDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		os.WriteFile(p, []byte("needle"), 0644)
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{"all", nil, nil, []string{"a.go", "a_test.go", "b.ts", "pkg/c.go", "pkg/gen/d.go"}},
+		{"include name", []string{"*.go"}, nil, []string{"a.go", "a_test.go", "pkg/c.go", "pkg/gen/d.go"}},
+		{"include braces", []string{"*.{ts,md}"}, nil, []string{"b.ts"}},
+		{"exclude name", []string{"*.go"}, []string{"*_test.go"}, []string{"a.go", "pkg/c.go", "pkg/gen/d.go"}},
+		{"exclude path", nil, []string{"pkg/gen/**"}, []string{"a.go", "a_test.go", "b.ts", "pkg/c.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, _ := json.Marshal(map[string]any{"pattern": "needle", "path": tmpDir, "recursive": true, "include": tt.include, "exclude": tt.exclude})
+			output, _ := NewGrepTool().Execute(context.Background(), input)
+			matches, gotErr := parseGrepOutput(t, output)
+			if gotErr != "" {
+				t.Fatalf("unexpected error in output: %s", gotErr)
+			}
+			var files []string
+			for _, m := range matches {
+				files = append(files, m.File)
+			}
+			if strings.Join(files, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, files)
+			}
+		})
+	}
+}
+
+func TestGrepTool_MaxMatches(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	os.WriteFile(testFile, []byte(strings.Repeat("hit\n", 10)), 0644)
+
+	input, _ := json.Marshal(map[string]any{"pattern": "hit", "path": testFile, "max_matches": 3})
+	output, _ := NewGrepTool().Execute(context.Background(), input)
+	var result grepOutput
+	json.Unmarshal([]byte(output), &result)
+	if len(result.Matches) != 3 || !result.Truncated || result.Omitted != 7 {
+		t.Errorf("expected 3 matches and 7 omitted, got %s", output)
+	}
+	if !strings.HasPrefix(result.Notice, "7 more matches omitted") {
+		t.Errorf("expected omitted notice, got %q", result.Notice)
+	}
+
+	input, _ = json.Marshal(map[string]any{"pattern": "hit", "path": testFile, "max_matches": 10})
+	output, _ = NewGrepTool().Execute(context.Background(), input)
+	result = grepOutput{}
+	json.Unmarshal([]byte(output), &result)
+	if len(result.Matches) != 10 || result.Truncated || result.Notice != "" {
+		t.Errorf("expected all 10 matches untruncated, got %s", output)
+	}
+}
+
+func TestGrepTool_Context(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	os.WriteFile(testFile, []byte("one\ntwo\nmatch a\nthree\nmatch b\nfour\nfive"), 0644)
+
+	input, _ := json.Marshal(map[string]any{"pattern": "match", "path": testFile, "context": 1})
+	output, _ := NewGrepTool().Execute(context.Background(), input)
+	matches, gotErr := parseGrepOutput(t, output)
+	if gotErr != "" {
+		t.Fatalf("unexpected error in output: %s", gotErr)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %q", grepLines(matches))
+	}
+	if got := strings.Join(matches[0].Before, "|") + "/" + strings.Join(matches[0].After, "|"); got != "two/three" {
+		t.Errorf("unexpected context for first match: %s", got)
+	}
+	if got := strings.Join(matches[1].Before, "|") + "/" + strings.Join(matches[1].After, "|"); got != "three/four" {
+		t.Errorf("unexpected context for second match: %s", got)
+	}
+}
+
+func TestGrepTool_SkipsBinaryFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "bin"), []byte("needle\x00\x01"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "text"), []byte("needle"), 0644)
+
+	input, _ := json.Marshal(map[string]any{"pattern": "needle", "path": tmpDir})
+	output, _ := NewGrepTool().Execute(context.Background(), input)
+	matches, _ := parseGrepOutput(t, output)
+	if got := grepLines(matches); got != "text:1:1:needle" {
+		t.Errorf("expected only the text file, got %q", got)
+	}
+}
+
+func TestGrepTool_BeforeAfter(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	os.WriteFile(testFile, []byte("a\nb\nc\nmatch\nd\ne\nf"), 0644)
+
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  string
+	}{
+		{"before only", map[string]any{"before": 2}, "b|c/"},
+		{"after only", map[string]any{"after": 3}, "/d|e|f"},
+		{"override context", map[string]any{"context": 2, "after": 0}, "b|c/"},
+		{"after past end of file", map[string]any{"after": 5}, "/d|e|f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input["pattern"] = "match"
+			tt.input["path"] = testFile
+			input, _ := json.Marshal(tt.input)
+			output, _ := NewGrepTool().Execute(context.Background(), input)
+			matches, gotErr := parseGrepOutput(t, output)
+			if gotErr != "" || len(matches) != 1 {
+				t.Fatalf("unexpected result %s", output)
+			}
+			if got := strings.Join(matches[0].Before, "|") + "/" + strings.Join(matches[0].After, "|"); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGrepTool_FilesOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		os.WriteFile(filepath.Join(tmpDir, name), []byte("hit\nhit\n"), 0644)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "miss.txt"), []byte("nothing"), 0644)
+
+	input, _ := json.Marshal(map[string]any{"pattern": "hit", "path": tmpDir, "files_only": true, "max_matches": 3})
+	output, _ := NewGrepTool().Execute(context.Background(), input)
+	var result grepFilesOutput
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if strings.Join(result.Files, ",") != "a.txt,b.txt,c.txt" || result.Omitted != 1 {
+		t.Errorf("expected 3 files and 1 omitted, got %s", output)
+	}
+	if !strings.HasPrefix(result.Notice, "1 more files omitted") {
+		t.Errorf("expected omitted notice, got %q", result.Notice)
+	}
+	if strings.Contains(output, `"matches"`) {
+		t.Errorf("files_only should not return matches, got %s", output)
+	}
+}
@@ -0,0 +1,223 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// defaultHTTPTimeout is the request timeout unless told otherwise.
+	defaultHTTPTimeout = 30
+	// maxHTTPTimeout caps the requested timeout, in seconds.
+	maxHTTPTimeout = 300
+	// maxHTTPBodyBytes caps the response body returned to the model.
+	maxHTTPBodyBytes = 256 * 1024
+)
+
+// httpMethods are the accepted request methods.
+var httpMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// HTTPRequestTool implements the Tool interface for sending HTTP requests.
+type HTTPRequestTool struct {
+	client *http.Client
+}
+
+// httpRequestInput defines the expected input parameters for the
+// http_request tool.
+type httpRequestInput struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	// Timeout is in seconds.
+	Timeout int `json:"timeout"`
+}
+
+// httpRequestOutput defines the success response format. Any response,
+// whatever its status, is a success.
+type httpRequestOutput struct {
+	Status     int    `json:"status"`
+	StatusText string `json:"status_text"`
+	// URL is the final URL after redirects.
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	// Body is empty for binary responses.
+	Body string `json:"body"`
+	// BodyBytes is the full body length. A truncated body is not read past
+	// the cap, so its length comes from Content-Length, or is -1 if the
+	// server sent none.
+	BodyBytes int64 `json:"body_bytes"`
+	Truncated bool  `json:"truncated,omitempty"`
+	Binary    bool  `json:"binary,omitempty"`
+	// DurationMs is the time until the body was read.
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// httpRequestError defines the error response format.
+type httpRequestError struct {
+	Error string `json:"error"`
+}
+
+// NewHTTPRequestTool creates a new HTTPRequestTool instance.
+func NewHTTPRequestTool() *HTTPRequestTool {
+	return &HTTPRequestTool{client: &http.Client{}}
+}
+
+// Name returns the tool identifier.
+func (t *HTTPRequestTool) Name() string {
+	return "http_request"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *HTTPRequestTool) Description() string {
+	return "Send an HTTP request and return the response status, headers, and body as JSON. Use this to exercise HTTP APIs instead of curl. Redirects are followed; bodies over 256KB are truncated"
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *HTTPRequestTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"url": {"type": "string", "description": "Absolute http or https URL"},
+			"method": {"type": "string", "enum": ["GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"], "description": "Request method (default GET)"},
+			"headers": {"type": "object", "additionalProperties": {"type": "string"}, "description": "Request headers, e.g. {\"Content-Type\": \"application/json\"}"},
+			"body": {"type": "string", "description": "Request body"},
+			"timeout": {"type": "integer", "description": "Timeout in seconds for the whole request, 1-300 (default 30)"}
+		},
+		"required": ["url"]
+	}`)
+}
+
+// Execute sends the request and returns the response.
+func (t *HTTPRequestTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params httpRequestInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatHTTPRequestError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	// Validate parameters
+	if params.URL == "" {
+		return formatHTTPRequestError("url is required"), nil
+	}
+	u, err := url.Parse(params.URL)
+	if err != nil {
+		return formatHTTPRequestError("invalid url: " + err.Error()), nil
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return formatHTTPRequestError("invalid url: must be an absolute http or https URL"), nil
+	}
+	method := strings.ToUpper(params.Method)
+	if method == "" {
+		method = "GET"
+	}
+	if !slices.Contains(httpMethods, method) {
+		return formatHTTPRequestError(fmt.Sprintf("invalid method %q: must be one of %s", params.Method, strings.Join(httpMethods, ", "))), nil
+	}
+	timeout := clampInt(params.Timeout, defaultHTTPTimeout, maxHTTPTimeout)
+
+	reqCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	var body io.Reader
+	if params.Body != "" {
+		body = strings.NewReader(params.Body)
+	}
+	req, err := http.NewRequestWithContext(reqCtx, method, u.String(), body)
+	if err != nil {
+		return formatHTTPRequestError("invalid request: " + err.Error()), nil
+	}
+	for name, value := range params.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+
+	start := time.Now()
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return httpRequestFailure(ctx, reqCtx, timeout, err)
+	}
+	defer resp.Body.Close()
+
+	// Read one byte past the cap to detect truncation, and no further:
+	// streaming responses may never end
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBodyBytes+1))
+	if err != nil {
+		return httpRequestFailure(ctx, reqCtx, timeout, err)
+	}
+
+	output := httpRequestOutput{
+		Status:     resp.StatusCode,
+		StatusText: http.StatusText(resp.StatusCode),
+		URL:        resp.Request.URL.String(),
+		Headers:    make(map[string]string, len(resp.Header)),
+		BodyBytes:  int64(len(data)),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	for name, values := range resp.Header {
+		output.Headers[name] = strings.Join(values, ", ")
+	}
+	if len(data) > maxHTTPBodyBytes {
+		data = trimPartialRune(data[:maxHTTPBodyBytes])
+		output.Truncated = true
+		output.BodyBytes = resp.ContentLength
+	}
+	if !utf8.Valid(data) || slices.Contains(data, 0) {
+		output.Binary = true
+	} else {
+		output.Body = string(data)
+	}
+
+	result, _ := json.Marshal(output)
+	return string(result), nil
+}
+
+// httpRequestFailure turns a transport error into an error response, or
+// returns the context's error once ctx is done.
+func httpRequestFailure(ctx, reqCtx context.Context, timeout int, err error) (string, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", ctxErr
+	}
+	if errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+		return formatHTTPRequestError(fmt.Sprintf("request timed out after %d seconds", timeout)), nil
+	}
+	return formatHTTPRequestError("request failed: " + err.Error()), nil
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence from the end of data.
+func trimPartialRune(data []byte) []byte {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+// formatHTTPRequestError formats an error response.
+func formatHTTPRequestError(msg string) string {
+	output := httpRequestError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
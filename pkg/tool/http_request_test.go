@@ -0,0 +1,140 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// executeHTTPRequest runs the http_request tool and decodes its result.
+func executeHTTPRequest(t *testing.T, input map[string]any) (httpRequestOutput, string) {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	result, err := NewHTTPRequestTool().Execute(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out struct {
+		httpRequestOutput
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(result), &out); err != nil {
+		t.Fatalf("failed to parse %s: %v", result, err)
+	}
+	return out.httpRequestOutput, out.Error
+}
+
+func TestHTTPRequestTool(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Add("X-Multi", "a")
+			w.Header().Add("X-Multi", "b")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{
+				"method": r.Method,
+				"token":  r.Header.Get("Authorization"),
+				"body":   string(body),
+			})
+		case "/redirect":
+			http.Redirect(w, r, "/echo", http.StatusFound)
+		case "/missing":
+			http.Error(w, "not here", http.StatusNotFound)
+		case "/large":
+			w.Header().Set("Content-Length", strconv.Itoa(maxHTTPBodyBytes+10))
+			w.Write([]byte(strings.Repeat("x", maxHTTPBodyBytes+10)))
+		case "/stream":
+			// Never finishes: the tool must stop reading at the cap
+			w.Write([]byte(strings.Repeat("x", maxHTTPBodyBytes+10)))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/binary":
+			w.Write([]byte{0x89, 'P', 'N', 'G', 0, 0xff})
+		}
+	}))
+	defer srv.Close()
+
+	out, errMsg := executeHTTPRequest(t, map[string]any{
+		"url":     srv.URL + "/echo",
+		"method":  "post",
+		"headers": map[string]string{"Authorization": "Bearer abc"},
+		"body":    `{"name":"x"}`,
+	})
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if out.Status != 201 || out.StatusText != "Created" || out.Headers["X-Multi"] != "a, b" {
+		t.Errorf("unexpected response: %+v", out)
+	}
+	var echo map[string]string
+	json.Unmarshal([]byte(out.Body), &echo)
+	if echo["method"] != "POST" || echo["token"] != "Bearer abc" || echo["body"] != `{"name":"x"}` {
+		t.Errorf("unexpected echo: %s", out.Body)
+	}
+
+	// Redirects are followed
+	out, _ = executeHTTPRequest(t, map[string]any{"url": srv.URL + "/redirect"})
+	if out.Status != 201 || out.URL != srv.URL+"/echo" {
+		t.Errorf("expected redirect to /echo, got %d %s", out.Status, out.URL)
+	}
+
+	// Error statuses are responses, not tool errors
+	out, errMsg = executeHTTPRequest(t, map[string]any{"url": srv.URL + "/missing"})
+	if errMsg != "" || out.Status != 404 || out.Body != "not here\n" {
+		t.Errorf("unexpected 404 response: %+v (%s)", out, errMsg)
+	}
+
+	out, _ = executeHTTPRequest(t, map[string]any{"url": srv.URL + "/large"})
+	if !out.Truncated || len(out.Body) != maxHTTPBodyBytes || out.BodyBytes != maxHTTPBodyBytes+10 {
+		t.Errorf("expected truncated body, got truncated=%v len=%d bytes=%d", out.Truncated, len(out.Body), out.BodyBytes)
+	}
+
+	out, errMsg = executeHTTPRequest(t, map[string]any{"url": srv.URL + "/stream", "timeout": 5})
+	if errMsg != "" || !out.Truncated || len(out.Body) != maxHTTPBodyBytes || out.BodyBytes != -1 {
+		t.Errorf("expected truncated stream of unknown size, got truncated=%v len=%d bytes=%d (%s)", out.Truncated, len(out.Body), out.BodyBytes, errMsg)
+	}
+
+	out, _ = executeHTTPRequest(t, map[string]any{"url": srv.URL + "/binary"})
+	if !out.Binary || out.Body != "" || out.BodyBytes != 6 {
+		t.Errorf("expected binary body, got %+v", out)
+	}
+}
+
+func TestHTTPRequestTool_Errors(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name   string
+		input  map[string]any
+		errMsg string
+	}{
+		{"missing url", map[string]any{}, "url is required"},
+		{"relative url", map[string]any{"url": "/api"}, "absolute http or https URL"},
+		{"other scheme", map[string]any{"url": "file:///etc/passwd"}, "absolute http or https URL"},
+		{"invalid method", map[string]any{"url": slow.URL, "method": "TRACE"}, "invalid method"},
+		{"timeout", map[string]any{"url": slow.URL, "timeout": 1}, "request timed out after 1 seconds"},
+		{"connection refused", map[string]any{"url": "http://127.0.0.1:1"}, "request failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errMsg := executeHTTPRequest(t, tt.input)
+			if !strings.Contains(errMsg, tt.errMsg) {
+				t.Errorf("expected error containing %q, got %q", tt.errMsg, errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFiles are the per-directory ignore files honored by recursive
+// tools, in the order they are applied. .ignore follows the ripgrep
+// convention for excluding files from searches but not from git.
+var ignoreFiles = []string{".gitignore", ".ignore"}
+
+// ignoreMatcher decides which paths recursive tools skip. It combines the
+// global patterns in the context with the ignore files of the directories
+// being walked and of their ancestors up to the repository root. Rules from
+// a directory's files apply only beneath that directory, and later rules
+// override earlier ones, so deeper files take precedence.
+type ignoreMatcher struct {
+	rules []ignoreRule
+	// cache is the run's read cache, if any, for the ignore files.
+	cache *ReadCache
+}
+
+// ignoreRule is one parsed ignore pattern.
+type ignoreRule struct {
+	base    []string // absolute directory the rule applies beneath, split on /
+	segs    []string // pattern segments, matched with matchSegments
+	negate  bool     // pattern started with !
+	dirOnly bool     // pattern ended with /
+}
+
+// newIgnoreMatcher returns a matcher for a walk of root. The walk must call
+// enter for each directory it descends into, root included.
+func newIgnoreMatcher(ctx context.Context, root string) *ignoreMatcher {
+	m := &ignoreMatcher{cache: ReadCacheFromContext(ctx)}
+	for _, p := range IgnorePatterns(ctx) {
+		// Global patterns match at any depth, even those with a slash
+		for _, rule := range parseIgnoreRules(p, nil) {
+			if rule.segs[0] != "**" {
+				rule.segs = append([]string{"**"}, rule.segs...)
+			}
+			m.rules = append(m.rules, rule)
+		}
+	}
+	for _, dir := range ignoreAncestors(ctx, root) {
+		m.enter(dir)
+	}
+	return m
+}
+
+// ignoreAncestors returns the directories above root, outermost first, whose
+// ignore files apply to it: those up to the enclosing repository root or the
+// workspace root, whichever is nearer. Outside both there are none.
+func ignoreAncestors(ctx context.Context, root string) []string {
+	limit := Workspace(ctx)
+	var dirs []string
+	for dir := root; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || dir == limit {
+			return dirs
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+		dirs = append([]string{dir}, dirs...)
+	}
+}
+
+// enter loads the ignore files in dir. Missing files are not an error.
+func (m *ignoreMatcher) enter(dir string) {
+	base := splitIgnorePath(dir)
+	for _, name := range ignoreFiles {
+		data, err := m.cache.readFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		m.rules = append(m.rules, parseIgnoreRules(string(data), base)...)
+	}
+}
+
+// ignored reports whether the absolute path p is excluded by the rules
+// loaded so far.
+func (m *ignoreMatcher) ignored(p string, isDir bool) bool {
+	parts := splitIgnorePath(p)
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if len(parts) <= len(rule.base) || !hasPrefixParts(parts, rule.base) {
+			continue
+		}
+		if matchSegments(rule.segs, parts[len(rule.base):]) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// splitIgnorePath splits an absolute path into its components.
+func splitIgnorePath(p string) []string {
+	p = strings.Trim(filepath.ToSlash(p), "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// parseIgnoreRules parses ignore file content in .gitignore syntax.
+// Patterns without a slash match at any depth below base; patterns with one
+// are anchored to base.
+func parseIgnoreRules(data string, base []string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		rule.base = base
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		anchored := strings.Contains(line, "/")
+		line = path.Clean(strings.TrimPrefix(line, "/"))
+		rule.segs = strings.Split(line, "/")
+		if !anchored {
+			rule.segs = append([]string{"**"}, rule.segs...)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// hasPrefixParts reports whether parts begins with prefix.
+func hasPrefixParts(parts, prefix []string) bool {
+	for i, p := range prefix {
+		if parts[i] != p {
+			return false
+		}
+	}
+	return true
+}
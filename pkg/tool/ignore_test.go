@@ -0,0 +1,155 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{
+		".git/HEAD":          "",
+		".gitignore":         "*.log\n/dist\n",
+		"pkg/.ignore":        "fixtures/\n!keep.log\n",
+		"pkg/.gitignore":     "*.tmp\n",
+		"pkg/sub/.gitignore": "",
+	})
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"main.go", false, false},
+		{"app.log", false, true},
+		{"dist", true, true},
+		{"pkg/dist", true, false}, // anchored to the root
+		{"node_modules", true, true},
+		{"pkg/node_modules", true, true},
+		{"vendor", false, false}, // directory-only pattern
+		{"bin/tool.exe", false, true},
+		{"pkg/fixtures", true, true},
+		{"pkg/sub/fixtures", true, true},
+		{"fixtures", true, false}, // pkg/.ignore applies only beneath pkg
+		{"pkg/a.tmp", false, true},
+		{"pkg/keep.log", false, false}, // re-included by a deeper file
+		{"pkg/other.log", false, true},
+	}
+
+	// Walk from a subdirectory: the repository root's rules still apply
+	m := newIgnoreMatcher(context.Background(), filepath.Join(root, "pkg"))
+	m.enter(filepath.Join(root, "pkg"))
+	m.enter(filepath.Join(root, "pkg", "sub"))
+	for _, tt := range tests {
+		if !strings.HasPrefix(tt.path, "pkg/") {
+			continue
+		}
+		if got := m.ignored(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir); got != tt.ignored {
+			t.Errorf("from pkg: ignored(%s) = %v, want %v", tt.path, got, tt.ignored)
+		}
+	}
+
+	m = newIgnoreMatcher(context.Background(), root)
+	m.enter(root)
+	m.enter(filepath.Join(root, "pkg"))
+	m.enter(filepath.Join(root, "pkg", "sub"))
+	for _, tt := range tests {
+		if got := m.ignored(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir); got != tt.ignored {
+			t.Errorf("ignored(%s) = %v, want %v", tt.path, got, tt.ignored)
+		}
+	}
+}
+
+func TestIgnoreMatcher_Ancestors(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{
+		".gitignore":     "*.log\n",
+		"repo/.git/HEAD": "",
+		"repo/a/b/x":     "",
+	})
+	// Ignore files above the repository root do not apply
+	m := newIgnoreMatcher(context.Background(), filepath.Join(root, "repo", "a"))
+	if m.ignored(filepath.Join(root, "repo", "a", "x.log"), false) {
+		t.Error("rules above the repository root should not apply")
+	}
+
+	// Nor do those above the workspace
+	ctx := WithWorkspace(context.Background(), filepath.Join(root, "repo", "a"))
+	os.WriteFile(filepath.Join(root, "repo", ".gitignore"), []byte("*.tmp\n"), 0644)
+	m = newIgnoreMatcher(ctx, filepath.Join(root, "repo", "a", "b"))
+	if m.ignored(filepath.Join(root, "repo", "a", "b", "x.tmp"), false) {
+		t.Error("rules above the workspace should not apply")
+	}
+}
+
+func TestIgnoreMatcher_GlobalPatterns(t *testing.T) {
+	root := t.TempDir()
+	ctx := WithIgnorePatterns(context.Background(), []string{"build/", "gen/*.go"})
+	m := newIgnoreMatcher(ctx, root)
+	for path, want := range map[string]bool{
+		"build":        true,
+		"a/b/build":    true,
+		"a/gen/x.go":   true,
+		"node_modules": false, // defaults replaced
+	} {
+		if got := m.ignored(filepath.Join(root, filepath.FromSlash(path)), true); got != want {
+			t.Errorf("ignored(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	m = newIgnoreMatcher(WithIgnorePatterns(context.Background(), []string{}), root)
+	if m.ignored(filepath.Join(root, "node_modules"), true) {
+		t.Error("an empty list should disable global patterns")
+	}
+}
+
+func TestRecursiveTools_Ignore(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{
+		".gitignore":            "*.gen.go\n",
+		"main.go":               "needle",
+		"api.gen.go":            "needle",
+		"node_modules/m/i.go":   "needle",
+		"pkg/vendor/v/v.go":     "needle",
+		"pkg/util.go":           "needle",
+		"pkg/.ignore":           "testdata/\n",
+		"pkg/testdata/input.go": "needle",
+	})
+	want := "main.go,pkg/util.go"
+	wantAll := "api.gen.go,main.go,node_modules/m/i.go,pkg/testdata/input.go,pkg/util.go,pkg/vendor/v/v.go"
+
+	for _, noIgnore := range []bool{false, true} {
+		expected := want
+		if noIgnore {
+			expected = wantAll
+		}
+
+		input, _ := json.Marshal(map[string]any{"pattern": "needle", "path": root, "recursive": true, "no_ignore": noIgnore})
+		result, _ := NewGrepTool().Execute(context.Background(), input)
+		matches, errMsg := parseGrepOutput(t, result)
+		if errMsg != "" {
+			t.Fatalf("grep: %s", errMsg)
+		}
+		var files []string
+		for _, m := range matches {
+			files = append(files, m.File)
+		}
+		if got := strings.Join(files, ","); got != expected {
+			t.Errorf("grep no_ignore=%v: expected %s, got %s", noIgnore, expected, got)
+		}
+
+		input, _ = json.Marshal(map[string]any{"pattern": "**/*.go", "path": root, "no_ignore": noIgnore})
+		result, _ = NewGlobTool().Execute(context.Background(), input)
+		var globResult globOutput
+		json.Unmarshal([]byte(result), &globResult)
+		sort.Strings(globResult.Files)
+		if got := strings.Join(globResult.Files, ","); got != expected {
+			t.Errorf("glob no_ignore=%v: expected %s, got %s", noIgnore, expected, got)
+		}
+	}
+}
@@ -4,23 +4,59 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
-	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 )
 
+const (
+	// maxListDirDepth caps the requested recursion depth.
+	maxListDirDepth = 5
+	// maxListDirEntries caps the number of entries in one result.
+	maxListDirEntries = 1000
+)
+
+// listDirSorts are the accepted sort orders.
+var listDirSorts = []string{"name", "type", "size", "mtime"}
+
 // ListDirTool implements the Tool interface for listing directory contents.
-// It uses the system's ls command to provide detailed file information.
+// It reads directories natively and returns one structured entry per file.
 type ListDirTool struct{}
 
 // listDirInput defines the expected input parameters for the list_dir tool.
 type listDirInput struct {
-	Path string `json:"path"`
+	Path  string `json:"path"`
+	Depth int    `json:"depth"`
+	Sort  string `json:"sort"`
+	// IncludeHidden defaults to true.
+	IncludeHidden *bool `json:"include_hidden"`
+}
+
+// listDirEntry describes one file or directory.
+type listDirEntry struct {
+	// Name is the path relative to the listed directory, using forward
+	// slashes.
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Size  int64  `json:"size"`
+	Mode  string `json:"mode"`
+	Mtime string `json:"mtime"`
+	// Target is set for symlinks.
+	Target string `json:"target,omitempty"`
+
+	mtime time.Time
 }
 
 // listDirOutput defines the success response format.
 type listDirOutput struct {
-	Entries string `json:"entries"`
+	Path      string          `json:"path"`
+	Entries   []*listDirEntry `json:"entries"`
+	Truncated bool            `json:"truncated,omitempty"`
 }
 
 // listDirError defines the error response format.
@@ -40,7 +76,12 @@ func (t *ListDirTool) Name() string {
 
 // Description returns a human-readable description of the tool.
 func (t *ListDirTool) Description() string {
-	return "List directory contents with detailed metadata"
+	return "List directory contents with detailed metadata: name, type, size, mode, and modification time of each entry, optionally recursing into subdirectories"
+}
+
+// ReadOnly reports that the tool has no side effects.
+func (t *ListDirTool) ReadOnly() bool {
+	return true
 }
 
 // InputSchema returns the JSON Schema for the tool's input parameters.
@@ -48,13 +89,16 @@ func (t *ListDirTool) InputSchema() json.RawMessage {
 	return json.RawMessage(`{
 		"type": "object",
 		"properties": {
-			"path": {"type": "string", "description": "Directory path to list"}
+			"path": {"type": "string", "description": "Directory path to list"},
+			"depth": {"type": "integer", "description": "Levels to list, 1-5; 1 lists only the directory itself (default 1)"},
+			"sort": {"type": "string", "enum": ["name", "type", "size", "mtime"], "description": "Sort order: name (default), type (directories first), size (largest first), or mtime (newest first)"},
+			"include_hidden": {"type": "boolean", "description": "Include entries whose names start with a dot (default true)"}
 		},
 		"required": ["path"]
 	}`)
 }
 
-// Execute lists the contents of the specified directory using ls -al.
+// Execute lists the contents of the specified directory.
 func (t *ListDirTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
 	var params listDirInput
 	if err := json.Unmarshal(input, &params); err != nil {
@@ -68,13 +112,35 @@ func (t *ListDirTool) Execute(ctx context.Context, input json.RawMessage) (strin
 	default:
 	}
 
-	// Validate path
+	// Validate parameters
 	if params.Path == "" {
 		return formatListDirError("path is required"), nil
 	}
+	if params.Depth <= 0 {
+		params.Depth = 1
+	}
+	if params.Depth > maxListDirDepth {
+		params.Depth = maxListDirDepth
+	}
+	if params.Sort == "" {
+		params.Sort = "name"
+	}
+	if !slices.Contains(listDirSorts, params.Sort) {
+		return formatListDirError(fmt.Sprintf("invalid sort %q: must be one of %s", params.Sort, strings.Join(listDirSorts, ", "))), nil
+	}
+
+	// Resolve path within the workspace, if any
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatListDirError(err.Error()), nil
+	}
+	root, err := filepath.Abs(resolved)
+	if err != nil {
+		return formatListDirError("invalid path: " + err.Error()), nil
+	}
 
 	// Check if path exists and get file info
-	info, err := os.Stat(params.Path)
+	info, err := os.Stat(root)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return formatListDirError("path not found"), nil
@@ -90,36 +156,113 @@ func (t *ListDirTool) Execute(ctx context.Context, input json.RawMessage) (strin
 		return formatListDirError("not a directory"), nil
 	}
 
-	// Execute ls -al command
-	cmd := exec.CommandContext(ctx, "ls", "-al", params.Path)
-	output, err := cmd.Output()
+	l := &dirLister{
+		depth:  params.Depth,
+		hidden: params.IncludeHidden == nil || *params.IncludeHidden,
+	}
+	if err := l.list(ctx, root, "", 1); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return formatListDirError("permission denied"), nil
+		}
+		return formatListDirError("failed to list directory: " + err.Error()), nil
+	}
+	sortListDirEntries(l.entries, params.Sort)
+
+	return formatListDirSuccess(listDirOutput{Path: root, Entries: l.entries, Truncated: l.truncated}), nil
+}
+
+// dirLister collects the entries of a directory tree down to a depth.
+type dirLister struct {
+	depth     int
+	hidden    bool
+	entries   []*listDirEntry
+	truncated bool
+}
+
+// list adds the entries of dir, named rel relative to the root, at the given
+// depth. Only a failure to read the root is an error; unreadable
+// subdirectories are listed without their contents.
+func (l *dirLister) list(ctx context.Context, dir, rel string, depth int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		// Check for context cancellation
-		if ctx.Err() != nil {
-			return "", ctx.Err()
+		if depth == 1 {
+			return err
+		}
+		return nil
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !l.hidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		if len(l.entries) == maxListDirEntries {
+			l.truncated = true
+			return nil
 		}
+		info, err := entry.Info()
+		if err != nil {
+			// Removed since the directory was read
+			continue
+		}
+		childPath := filepath.Join(dir, name)
+		e := &listDirEntry{
+			Name:  path.Join(rel, name),
+			Type:  statType(info.Mode()),
+			Size:  info.Size(),
+			Mode:  fmt.Sprintf("%04o", info.Mode().Perm()),
+			Mtime: info.ModTime().UTC().Format(time.RFC3339),
+			mtime: info.ModTime(),
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			e.Target, _ = os.Readlink(childPath)
+		}
+		l.entries = append(l.entries, e)
 
-		// Check if it's an exit error with stderr
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			stderr := strings.TrimSpace(string(exitErr.Stderr))
-			if strings.Contains(stderr, "Permission denied") {
-				return formatListDirError("permission denied"), nil
-			}
-			if stderr != "" {
-				return formatListDirError(stderr), nil
+		// Symlinks are not followed, and .git is listed but not entered
+		if entry.IsDir() && depth < l.depth && name != ".git" {
+			if err := l.list(ctx, childPath, e.Name, depth+1); err != nil {
+				return err
 			}
 		}
-		return formatListDirError("failed to list directory: " + err.Error()), nil
 	}
+	return nil
+}
 
-	// Return the raw ls output
-	return formatListDirSuccess(strings.TrimSuffix(string(output), "\n")), nil
+// sortListDirEntries orders entries by the given key, breaking ties by name.
+// Names compare component by component, so a directory's entries follow it
+// directly.
+func sortListDirEntries(entries []*listDirEntry, by string) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch by {
+		case "type":
+			if (a.Type == "dir") != (b.Type == "dir") {
+				return a.Type == "dir"
+			}
+		case "size":
+			if a.Size != b.Size {
+				return a.Size > b.Size
+			}
+		case "mtime":
+			if !a.mtime.Equal(b.mtime) {
+				return a.mtime.After(b.mtime)
+			}
+		}
+		return strings.ReplaceAll(a.Name, "/", "\x00") < strings.ReplaceAll(b.Name, "/", "\x00")
+	})
 }
 
 // formatListDirSuccess formats a successful list_dir response.
-func formatListDirSuccess(entries string) string {
-	output := listDirOutput{Entries: entries}
+func formatListDirSuccess(output listDirOutput) string {
+	if output.Entries == nil {
+		output.Entries = []*listDirEntry{}
+	}
 	data, _ := json.Marshal(output)
 	return string(data)
 }
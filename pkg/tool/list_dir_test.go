@@ -5,18 +5,42 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Helper to parse list_dir tool output
-func parseListDirOutput(t *testing.T, output string) (entries string, errMsg string) {
+func parseListDirOutput(t *testing.T, output string) (entries []*listDirEntry, errMsg string) {
 	t.Helper()
-	var result map[string]string
+	var result struct {
+		listDirOutput
+		Error string `json:"error"`
+	}
 	if err := json.Unmarshal([]byte(output), &result); err != nil {
 		t.Fatalf("failed to parse output JSON: %v", err)
 	}
-	return result["entries"], result["error"]
+	return result.Entries, result.Error
+}
+
+// Helper to list entry names in order
+func listDirNames(entries []*listDirEntry) string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// Helper to find an entry by name
+func findListDirEntry(entries []*listDirEntry, name string) *listDirEntry {
+	for _, e := range entries {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
 }
 
 func TestListDirTool_Name(t *testing.T) {
@@ -86,15 +110,13 @@ func TestListDirTool_ValidDirectory(t *testing.T) {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
 
-	// Check that output contains expected elements
-	if !strings.Contains(entries, "total") {
-		t.Error("output should contain 'total' line")
+	// Entries are sorted by name, without . and ..
+	if names := listDirNames(entries); names != ".hidden,file1.txt,file2.txt" {
+		t.Errorf("expected .hidden,file1.txt,file2.txt, got %s", names)
 	}
-	if !strings.Contains(entries, "file1.txt") {
-		t.Error("output should contain 'file1.txt'")
-	}
-	if !strings.Contains(entries, "file2.txt") {
-		t.Error("output should contain 'file2.txt'")
+	e := findListDirEntry(entries, "file1.txt")
+	if e == nil || e.Type != "file" || e.Size != 8 || e.Mtime == "" {
+		t.Errorf("unexpected entry for file1.txt: %+v", e)
 	}
 }
 
@@ -121,9 +143,15 @@ func TestListDirTool_IncludesHiddenFiles(t *testing.T) {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
 
-	if !strings.Contains(entries, ".hidden_file") {
+	if findListDirEntry(entries, ".hidden_file") == nil {
 		t.Error("output should include hidden files")
 	}
+
+	input, _ = json.Marshal(map[string]any{"path": dir, "include_hidden": false})
+	output, _ = tool.Execute(context.Background(), input)
+	if entries, _ = parseListDirOutput(t, output); len(entries) != 0 {
+		t.Errorf("expected hidden files to be filtered, got %s", listDirNames(entries))
+	}
 }
 
 func TestListDirTool_ShowsPermissions(t *testing.T) {
@@ -136,6 +164,7 @@ func TestListDirTool_ShowsPermissions(t *testing.T) {
 	defer os.RemoveAll(dir)
 
 	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("content"), 0644)
+	os.Chmod(filepath.Join(dir, "test.txt"), 0640)
 
 	input, _ := json.Marshal(map[string]string{"path": dir})
 	output, err := tool.Execute(context.Background(), input)
@@ -145,9 +174,8 @@ func TestListDirTool_ShowsPermissions(t *testing.T) {
 
 	entries, _ := parseListDirOutput(t, output)
 
-	// ls -al output should show permission strings like "drwx" or "-rw-"
-	if !strings.Contains(entries, "rw") {
-		t.Error("output should show file permissions")
+	if e := findListDirEntry(entries, "test.txt"); e == nil || e.Mode != "0640" {
+		t.Errorf("expected mode 0640, got %+v", e)
 	}
 }
 
@@ -256,9 +284,8 @@ func TestListDirTool_EmptyDirectory(t *testing.T) {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
 
-	// Empty directory still shows . and .. entries with ls -al
-	if !strings.Contains(entries, "total") {
-		t.Error("output should contain 'total' line even for empty directory")
+	if entries == nil || len(entries) != 0 {
+		t.Errorf("expected an empty entry list, got %v", entries)
 	}
 }
 
@@ -293,8 +320,8 @@ func TestListDirTool_RelativePath(t *testing.T) {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
 
-	if !strings.Contains(entries, "file.txt") {
-		t.Error("output should contain 'file.txt'")
+	if names := listDirNames(entries); names != "file.txt" {
+		t.Errorf("expected file.txt, got %s", names)
 	}
 }
 
@@ -313,7 +340,94 @@ func TestListDirTool_CurrentDirectory(t *testing.T) {
 	}
 
 	// Current directory should show something
-	if entries == "" {
+	if len(entries) == 0 {
 		t.Error("output should not be empty for current directory")
 	}
 }
+
+func TestListDirTool_Depth(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{
+		"a.txt":         "a",
+		"sub/b.txt":     "bb",
+		"sub/deep/c.go": "ccc",
+		".git/HEAD":     "ref",
+	})
+	os.Symlink("sub", filepath.Join(root, "link"))
+
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{0, ".git,a.txt,link,sub"},
+		{2, ".git,a.txt,link,sub,sub/b.txt,sub/deep"},
+		{9, ".git,a.txt,link,sub,sub/b.txt,sub/deep,sub/deep/c.go"},
+	}
+	for _, tt := range tests {
+		input, _ := json.Marshal(map[string]any{"path": root, "depth": tt.depth})
+		output, _ := NewListDirTool().Execute(context.Background(), input)
+		entries, errMsg := parseListDirOutput(t, output)
+		if errMsg != "" {
+			t.Fatalf("depth %d: %s", tt.depth, errMsg)
+		}
+		if got := listDirNames(entries); got != tt.want {
+			t.Errorf("depth %d: expected %s, got %s", tt.depth, tt.want, got)
+		}
+	}
+
+	input, _ := json.Marshal(map[string]any{"path": root})
+	output, _ := NewListDirTool().Execute(context.Background(), input)
+	entries, _ := parseListDirOutput(t, output)
+	if e := findListDirEntry(entries, "link"); e == nil || e.Type != "symlink" || e.Target != "sub" {
+		t.Errorf("expected a symlink to sub, got %+v", e)
+	}
+	if e := findListDirEntry(entries, "sub"); e == nil || e.Type != "dir" {
+		t.Errorf("expected a dir, got %+v", e)
+	}
+}
+
+func TestListDirTool_Sort(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{
+		"big.txt":   "0123456789",
+		"small.txt": "0",
+		"mid.txt":   "01234",
+		"zdir/x":    "",
+	})
+	now := time.Now()
+	for i, name := range []string{"small.txt", "big.txt", "mid.txt", "zdir"} {
+		mtime := now.Add(-time.Duration(i) * time.Hour)
+		os.Chtimes(filepath.Join(root, name), mtime, mtime)
+	}
+
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{"", "big.txt,mid.txt,small.txt,zdir"},
+		{"type", "zdir,big.txt,mid.txt,small.txt"},
+		{"size", "big.txt,mid.txt,small.txt"},
+		{"mtime", "small.txt,big.txt,mid.txt,zdir"},
+	}
+	for _, tt := range tests {
+		input, _ := json.Marshal(map[string]any{"path": root, "sort": tt.sort})
+		output, _ := NewListDirTool().Execute(context.Background(), input)
+		entries, errMsg := parseListDirOutput(t, output)
+		if errMsg != "" {
+			t.Fatalf("sort %q: %s", tt.sort, errMsg)
+		}
+		if tt.sort == "size" {
+			// Directory sizes vary by filesystem
+			entries = slices.DeleteFunc(entries, func(e *listDirEntry) bool { return e.Type == "dir" })
+		}
+		if got := listDirNames(entries); got != tt.want {
+			t.Errorf("sort %q: expected %s, got %s", tt.sort, tt.want, got)
+		}
+	}
+
+	input, _ := json.Marshal(map[string]any{"path": root, "sort": "color"})
+	output, _ := NewListDirTool().Execute(context.Background(), input)
+	if _, errMsg := parseListDirOutput(t, output); !strings.Contains(errMsg, "invalid sort") {
+		t.Errorf("expected invalid sort error, got %q", errMsg)
+	}
+}
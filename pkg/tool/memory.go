@@ -0,0 +1,353 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MemoryFile is where memories are stored, relative to the workspace
+	// root (or the working directory when tools are not jailed).
+	MemoryFile = ".harness/memory.json"
+	// MemoryQuota caps the total size of stored names and contents, in bytes.
+	MemoryQuota = 64 * 1024
+)
+
+// ErrMemoryNotFound is returned for a memory name that is not stored.
+var ErrMemoryNotFound = errors.New("memory not found")
+
+// validMemoryName matches names that are safe to show and type.
+var validMemoryName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// memoryActions are the accepted memory tool actions.
+var memoryActions = []string{"list", "get", "save", "delete"}
+
+// memoryMu serializes updates of memory files within the process.
+var memoryMu sync.Mutex
+
+// Memory is a named note kept across prompts and sessions.
+type Memory struct {
+	Name      string    `json:"name"`
+	Content   string    `json:"content"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// size is the memory's share of the quota.
+func (m Memory) size() int {
+	return len(m.Name) + len(m.Content)
+}
+
+// MemoriesSize returns the quota used by memories, in bytes.
+func MemoriesSize(memories []Memory) int {
+	total := 0
+	for _, m := range memories {
+		total += m.size()
+	}
+	return total
+}
+
+// MemoryStore reads and updates the memory file of a directory.
+type MemoryStore struct {
+	path string
+}
+
+// OpenMemoryStore returns the store of the workspace at root. The file is
+// created by the first Save.
+func OpenMemoryStore(root string) *MemoryStore {
+	return &MemoryStore{path: filepath.Join(root, MemoryFile)}
+}
+
+// CheckMemoryName validates a memory name.
+func CheckMemoryName(name string) error {
+	if !validMemoryName.MatchString(name) {
+		return fmt.Errorf("invalid memory name %q: use up to 64 letters, digits, '.', '-' and '_', starting with a letter or digit", name)
+	}
+	return nil
+}
+
+// List returns the stored memories sorted by name.
+func (s *MemoryStore) List() ([]Memory, error) {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	return s.load()
+}
+
+// Get returns the memory called name, or ErrMemoryNotFound.
+func (s *MemoryStore) Get(name string) (Memory, error) {
+	memories, err := s.List()
+	if err != nil {
+		return Memory{}, err
+	}
+	idx := slices.IndexFunc(memories, func(m Memory) bool { return m.Name == name })
+	if idx < 0 {
+		return Memory{}, ErrMemoryNotFound
+	}
+	return memories[idx], nil
+}
+
+// Save stores content under name, replacing any memory of that name. It
+// reports whether the memory is new and fails if the memories would exceed
+// MemoryQuota.
+func (s *MemoryStore) Save(name, content string) (created bool, err error) {
+	if err := CheckMemoryName(name); err != nil {
+		return false, err
+	}
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	memories, err := s.load()
+	if err != nil {
+		return false, err
+	}
+
+	memory := Memory{Name: name, Content: content, UpdatedAt: time.Now().UTC()}
+	idx := slices.IndexFunc(memories, func(m Memory) bool { return m.Name == name })
+	if idx < 0 {
+		memories = append(memories, memory)
+		slices.SortFunc(memories, func(a, b Memory) int { return strings.Compare(a.Name, b.Name) })
+	} else {
+		memories[idx] = memory
+	}
+	if size := MemoriesSize(memories); size > MemoryQuota {
+		return false, fmt.Errorf("memory quota exceeded: saving %q would use %d of %d bytes; delete or shorten other memories first", name, size, MemoryQuota)
+	}
+	return idx < 0, s.store(memories)
+}
+
+// Delete removes the memory called name, or returns ErrMemoryNotFound.
+func (s *MemoryStore) Delete(name string) error {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	memories, err := s.load()
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(memories, func(m Memory) bool { return m.Name == name })
+	if idx < 0 {
+		return ErrMemoryNotFound
+	}
+	return s.store(slices.Delete(memories, idx, idx+1))
+}
+
+// load reads the memory file; a missing file holds no memories.
+func (s *MemoryStore) load() ([]Memory, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Memory{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	memories := []Memory{}
+	if err := json.Unmarshal(data, &memories); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", s.path, err)
+	}
+	return memories, nil
+}
+
+// store writes the memory file atomically via a temp file and rename.
+func (s *MemoryStore) store(memories []Memory) error {
+	data, err := json.MarshalIndent(memories, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".memory-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// MemoryTool implements the Tool interface for keeping notes across
+// prompts and sessions.
+type MemoryTool struct{}
+
+// memoryInput defines the expected input parameters for the memory tool.
+type memoryInput struct {
+	Action  string  `json:"action"`
+	Name    string  `json:"name"`
+	Content *string `json:"content"`
+}
+
+// memorySummary describes a stored memory without its content.
+type memorySummary struct {
+	Name      string    `json:"name"`
+	Bytes     int       `json:"bytes"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// memoryListOutput is the response of the list action.
+type memoryListOutput struct {
+	Memories []memorySummary `json:"memories"`
+	Bytes    int             `json:"bytes"`
+	Quota    int             `json:"quota"`
+}
+
+// memoryChangeOutput is the response of the save and delete actions.
+type memoryChangeOutput struct {
+	Name    string `json:"name"`
+	Created bool   `json:"created,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+	Bytes   int    `json:"bytes"`
+	Quota   int    `json:"quota"`
+}
+
+// memoryError defines the error response format.
+type memoryError struct {
+	Error string `json:"error"`
+}
+
+// NewMemoryTool creates a new MemoryTool instance.
+func NewMemoryTool() *MemoryTool {
+	return &MemoryTool{}
+}
+
+// Name returns the tool identifier.
+func (t *MemoryTool) Name() string {
+	return "memory"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *MemoryTool) Description() string {
+	return "Save and recall named notes that persist across prompts and sessions in this workspace, such as build commands, conventions, or decisions (e.g. name \"build\", content \"make build-all builds every binary\"). List the memories at the start of a task and get the relevant ones; save what a later session would need to rediscover. Memories share a 64KB quota"
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *MemoryTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"action": {"type": "string", "enum": ["list", "get", "save", "delete"], "description": "list memory names (default), get one memory's content, save content under a name, or delete a memory"},
+			"name": {"type": "string", "description": "Memory name: up to 64 letters, digits, '.', '-' and '_'; required for get, save, and delete"},
+			"content": {"type": "string", "description": "Note to store under name, replacing any previous content; required for save"}
+		}
+	}`)
+}
+
+// Execute performs the requested memory action.
+func (t *MemoryTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params memoryInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatMemoryError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	// Validate parameters
+	action := params.Action
+	if action == "" {
+		action = "list"
+	}
+	if !slices.Contains(memoryActions, action) {
+		return formatMemoryError(fmt.Sprintf("invalid action %q: must be one of %s", params.Action, strings.Join(memoryActions, ", "))), nil
+	}
+	if action != "list" && params.Name == "" {
+		return formatMemoryError("name is required for " + action), nil
+	}
+	if action == "save" && (params.Content == nil || strings.TrimSpace(*params.Content) == "") {
+		return formatMemoryError("content is required for save"), nil
+	}
+	if action != "save" && params.Content != nil {
+		return formatMemoryError("content is only valid for save"), nil
+	}
+
+	root := Workspace(ctx)
+	if root == "" {
+		root = "."
+	}
+	store := OpenMemoryStore(root)
+
+	var output any
+	switch action {
+	case "list":
+		memories, err := store.List()
+		if err != nil {
+			return formatMemoryError("failed to read memories: " + err.Error()), nil
+		}
+		list := memoryListOutput{Memories: make([]memorySummary, len(memories)), Bytes: MemoriesSize(memories), Quota: MemoryQuota}
+		for i, m := range memories {
+			list.Memories[i] = memorySummary{Name: m.Name, Bytes: len(m.Content), UpdatedAt: m.UpdatedAt}
+		}
+		output = list
+	case "get":
+		memory, err := store.Get(params.Name)
+		if err != nil {
+			return formatMemoryFailure(params.Name, err), nil
+		}
+		output = memory
+	case "save":
+		created, err := store.Save(params.Name, *params.Content)
+		if err != nil {
+			return formatMemoryFailure(params.Name, err), nil
+		}
+		change, err := memoryChange(store, params.Name)
+		if err != nil {
+			return formatMemoryFailure(params.Name, err), nil
+		}
+		change.Created = created
+		output = change
+	case "delete":
+		if err := store.Delete(params.Name); err != nil {
+			return formatMemoryFailure(params.Name, err), nil
+		}
+		change, err := memoryChange(store, params.Name)
+		if err != nil {
+			return formatMemoryFailure(params.Name, err), nil
+		}
+		change.Deleted = true
+		output = change
+	}
+
+	data, _ := json.Marshal(output)
+	return string(data), nil
+}
+
+// memoryChange reports the quota used after a change to the memory name.
+func memoryChange(store *MemoryStore, name string) (memoryChangeOutput, error) {
+	memories, err := store.List()
+	if err != nil {
+		return memoryChangeOutput{}, err
+	}
+	return memoryChangeOutput{Name: name, Bytes: MemoriesSize(memories), Quota: MemoryQuota}, nil
+}
+
+// formatMemoryFailure formats a store error for the memory name.
+func formatMemoryFailure(name string, err error) string {
+	if errors.Is(err, ErrMemoryNotFound) {
+		return formatMemoryError(fmt.Sprintf("no memory named %q", name))
+	}
+	return formatMemoryError(err.Error())
+}
+
+// formatMemoryError formats an error response.
+func formatMemoryError(msg string) string {
+	output := memoryError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
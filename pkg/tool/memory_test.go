@@ -0,0 +1,98 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// executeMemory runs the memory tool and decodes its result into out,
+// returning the error message of an error result.
+func executeMemory(t *testing.T, ctx context.Context, input map[string]any, out any) string {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	result, err := NewMemoryTool().Execute(ctx, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if IsErrorResult(result) {
+		var e memoryError
+		json.Unmarshal([]byte(result), &e)
+		return e.Error
+	}
+	if err := json.Unmarshal([]byte(result), out); err != nil {
+		t.Fatalf("failed to parse %s: %v", result, err)
+	}
+	return ""
+}
+
+func TestMemoryTool(t *testing.T) {
+	root := t.TempDir()
+	ctx := WithWorkspace(context.Background(), root)
+
+	var change memoryChangeOutput
+	if msg := executeMemory(t, ctx, map[string]any{"action": "save", "name": "build", "content": "make build-all"}, &change); msg != "" {
+		t.Fatalf("save failed: %s", msg)
+	}
+	if !change.Created || change.Bytes != len("build")+len("make build-all") || change.Quota != MemoryQuota {
+		t.Errorf("unexpected save result: %+v", change)
+	}
+	executeMemory(t, ctx, map[string]any{"action": "save", "name": "style", "content": "tabs"}, &change)
+	change = memoryChangeOutput{}
+	executeMemory(t, ctx, map[string]any{"action": "save", "name": "build", "content": "make all"}, &change)
+	if change.Created {
+		t.Error("replacing a memory should not report it as created")
+	}
+
+	var list memoryListOutput
+	executeMemory(t, ctx, map[string]any{}, &list)
+	if len(list.Memories) != 2 || list.Memories[0].Name != "build" || list.Memories[1].Name != "style" || list.Bytes != 22 {
+		t.Errorf("unexpected list: %+v", list)
+	}
+	var memory Memory
+	executeMemory(t, ctx, map[string]any{"action": "get", "name": "build"}, &memory)
+	if memory.Content != "make all" || memory.UpdatedAt.IsZero() {
+		t.Errorf("unexpected memory: %+v", memory)
+	}
+	if _, err := os.Stat(filepath.Join(root, MemoryFile)); err != nil {
+		t.Errorf("expected memory file: %v", err)
+	}
+
+	executeMemory(t, ctx, map[string]any{"action": "delete", "name": "style"}, &change)
+	if !change.Deleted || change.Bytes != len("build")+len("make all") {
+		t.Errorf("unexpected delete result: %+v", change)
+	}
+	if msg := executeMemory(t, ctx, map[string]any{"action": "get", "name": "style"}, &memory); msg != `no memory named "style"` {
+		t.Errorf("expected not found after delete, got %q", msg)
+	}
+}
+
+func TestMemoryTool_Errors(t *testing.T) {
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+	big := strings.Repeat("x", MemoryQuota)
+
+	tests := []struct {
+		name   string
+		input  map[string]any
+		errMsg string
+	}{
+		{"invalid action", map[string]any{"action": "search"}, "invalid action"},
+		{"missing name", map[string]any{"action": "get"}, "name is required for get"},
+		{"missing content", map[string]any{"action": "save", "name": "a"}, "content is required for save"},
+		{"content without save", map[string]any{"action": "get", "name": "a", "content": "x"}, "content is only valid for save"},
+		{"invalid name", map[string]any{"action": "save", "name": "../a", "content": "x"}, "invalid memory name"},
+		{"over quota", map[string]any{"action": "save", "name": "big", "content": big}, "memory quota exceeded"},
+		{"delete unknown", map[string]any{"action": "delete", "name": "nope"}, `no memory named "nope"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if msg := executeMemory(t, ctx, tt.input, nil); !strings.Contains(msg, tt.errMsg) {
+				t.Errorf("expected error containing %q, got %q", tt.errMsg, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// MkdirTool implements the Tool interface for creating directories.
+type MkdirTool struct{}
+
+// mkdirInput defines the expected input parameters for the mkdir tool.
+type mkdirInput struct {
+	Path    string `json:"path"`
+	Parents bool   `json:"parents"`
+	Mode    string `json:"mode,omitempty"`
+}
+
+// mkdirOutput defines the success response format.
+type mkdirOutput struct {
+	Path    string `json:"path"`
+	Created bool   `json:"created"`
+}
+
+// mkdirError defines the error response format.
+type mkdirError struct {
+	Error string `json:"error"`
+}
+
+// NewMkdirTool creates a new MkdirTool instance.
+func NewMkdirTool() *MkdirTool {
+	return &MkdirTool{}
+}
+
+// Name returns the tool identifier.
+func (t *MkdirTool) Name() string {
+	return "mkdir"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *MkdirTool) Description() string {
+	return "Create a directory, optionally with its missing parents"
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *MkdirTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "description": "Path of the directory to create"},
+			"parents": {"type": "boolean", "description": "Create missing parent directories, and succeed if the directory already exists (default false)"},
+			"mode": {"type": "string", "description": "Octal permissions for the new directory, e.g. \"0700\" (default \"0755\")"}
+		},
+		"required": ["path"]
+	}`)
+}
+
+// Execute creates the specified directory.
+func (t *MkdirTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params mkdirInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatMkdirError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	if params.Path == "" {
+		return formatMkdirError("path is required"), nil
+	}
+
+	mode := os.FileMode(defaultDirPermissions)
+	if params.Mode != "" {
+		m, err := parseFileMode(params.Mode)
+		if err != nil {
+			return formatMkdirError(err.Error()), nil
+		}
+		mode = m
+	}
+
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatMkdirError(err.Error()), nil
+	}
+	absPath, err := filepath.Abs(resolved)
+	if err != nil {
+		return formatMkdirError("invalid path: " + err.Error()), nil
+	}
+
+	if info, err := os.Stat(absPath); err == nil {
+		if !info.IsDir() {
+			return formatMkdirError(fmt.Sprintf("path exists and is not a directory: %s", params.Path)), nil
+		}
+		if params.Parents {
+			return formatMkdirSuccess(absPath, false), nil
+		}
+		return formatMkdirError(fmt.Sprintf("directory already exists: %s", params.Path)), nil
+	}
+
+	if params.Parents {
+		if err := os.MkdirAll(filepath.Dir(absPath), defaultDirPermissions); err != nil {
+			return formatMkdirError(mkdirErrorMessage(err, params.Path)), nil
+		}
+	}
+	if err := os.Mkdir(absPath, mode); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return formatMkdirError(fmt.Sprintf("parent directory not found: %s (set parents: true to create it)", filepath.Dir(params.Path))), nil
+		}
+		return formatMkdirError(mkdirErrorMessage(err, params.Path)), nil
+	}
+	// Mkdir is subject to the umask; apply an explicit mode exactly
+	if params.Mode != "" {
+		if err := os.Chmod(absPath, mode); err != nil {
+			return formatMkdirError("failed to set mode: " + err.Error()), nil
+		}
+	}
+
+	return formatMkdirSuccess(absPath, true), nil
+}
+
+// parseFileMode parses octal permission bits such as "0644" or "755".
+func parseFileMode(s string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || m > 0777 {
+		return 0, fmt.Errorf("invalid mode %q: expected octal permissions such as \"0755\"", s)
+	}
+	return os.FileMode(m), nil
+}
+
+// mkdirErrorMessage describes a failure to create path.
+func mkdirErrorMessage(err error, path string) string {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Sprintf("permission denied: cannot create %s", path)
+	}
+	return "cannot create directory: " + err.Error()
+}
+
+// formatMkdirSuccess formats a successful mkdir response.
+func formatMkdirSuccess(path string, created bool) string {
+	data, _ := json.Marshal(mkdirOutput{Path: path, Created: created})
+	return string(data)
+}
+
+// formatMkdirError formats an error response.
+func formatMkdirError(msg string) string {
+	output := mkdirError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
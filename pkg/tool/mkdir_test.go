@@ -0,0 +1,85 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runMkdir(t *testing.T, input map[string]any) (mkdirOutput, string) {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	result, err := NewMkdirTool().Execute(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var errResp mkdirError
+	json.Unmarshal([]byte(result), &errResp)
+	var output mkdirOutput
+	json.Unmarshal([]byte(result), &output)
+	return output, errResp.Error
+}
+
+func TestMkdirTool_Create(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pkg")
+	output, errMsg := runMkdir(t, map[string]any{"path": dir})
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if !output.Created || output.Path != dir {
+		t.Errorf("unexpected output %+v", output)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatal("directory should exist")
+	}
+
+	if _, errMsg := runMkdir(t, map[string]any{"path": dir}); !strings.Contains(errMsg, "already exists") {
+		t.Errorf("expected already exists error, got %q", errMsg)
+	}
+}
+
+func TestMkdirTool_Parents(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "a", "b", "c")
+
+	if _, errMsg := runMkdir(t, map[string]any{"path": dir}); !strings.Contains(errMsg, "parent directory not found") {
+		t.Errorf("expected missing parent error, got %q", errMsg)
+	}
+
+	output, errMsg := runMkdir(t, map[string]any{"path": dir, "parents": true})
+	if errMsg != "" || !output.Created {
+		t.Fatalf("unexpected result %+v %q", output, errMsg)
+	}
+
+	// Like mkdir -p, an existing directory is not an error
+	output, errMsg = runMkdir(t, map[string]any{"path": dir, "parents": true})
+	if errMsg != "" || output.Created {
+		t.Errorf("expected existing directory to succeed uncreated, got %+v %q", output, errMsg)
+	}
+}
+
+func TestMkdirTool_Mode(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "private")
+	if _, errMsg := runMkdir(t, map[string]any{"path": dir, "mode": "0700"}); errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	info, _ := os.Stat(dir)
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("expected mode 0700, got %v", info.Mode().Perm())
+	}
+
+	if _, errMsg := runMkdir(t, map[string]any{"path": dir + "2", "mode": "rwx"}); !strings.Contains(errMsg, "invalid mode") {
+		t.Errorf("expected invalid mode error, got %q", errMsg)
+	}
+}
+
+func TestMkdirTool_ExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(path, nil, 0644)
+	if _, errMsg := runMkdir(t, map[string]any{"path": path, "parents": true}); !strings.Contains(errMsg, "not a directory") {
+		t.Errorf("expected not a directory error, got %q", errMsg)
+	}
+}
@@ -58,6 +58,30 @@ func (t *MoveTool) InputSchema() json.RawMessage {
 	}`)
 }
 
+// ChangedPaths returns the source and destination of a file move. Moves of
+// directories are not reported.
+func (t *MoveTool) ChangedPaths(ctx context.Context, input json.RawMessage) []string {
+	var params moveInput
+	if json.Unmarshal(input, &params) != nil || params.Source == "" || params.Destination == "" {
+		return nil
+	}
+	src, err := ResolvePath(ctx, params.Source)
+	if err != nil {
+		return nil
+	}
+	dst, err := ResolvePath(ctx, params.Destination)
+	if err != nil {
+		return nil
+	}
+	if info, err := os.Stat(src); err != nil || info.IsDir() {
+		return nil
+	}
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+	return []string{src, dst}
+}
+
 // Execute moves or renames the specified file or directory.
 func (t *MoveTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
 	var params moveInput
@@ -80,12 +104,22 @@ func (t *MoveTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		return formatMoveError("destination is required"), nil
 	}
 
+	// Resolve paths within the workspace, if any
+	srcResolved, err := ResolvePath(ctx, params.Source)
+	if err != nil {
+		return formatMoveError(err.Error()), nil
+	}
+	dstResolved, err := ResolvePath(ctx, params.Destination)
+	if err != nil {
+		return formatMoveError(err.Error()), nil
+	}
+
 	// Resolve to absolute paths
-	srcAbs, err := filepath.Abs(params.Source)
+	srcAbs, err := filepath.Abs(srcResolved)
 	if err != nil {
 		return formatMoveError("invalid source path: " + err.Error()), nil
 	}
-	dstAbs, err := filepath.Abs(params.Destination)
+	dstAbs, err := filepath.Abs(dstResolved)
 	if err != nil {
 		return formatMoveError("invalid destination path: " + err.Error()), nil
 	}
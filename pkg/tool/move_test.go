@@ -457,3 +457,31 @@ func TestMoveTool_InvalidInput(t *testing.T) {
 		t.Error("expected error for invalid input")
 	}
 }
+
+func TestMoveTool_ChangedPaths(t *testing.T) {
+	tool := NewMoveTool()
+	ctx := context.Background()
+
+	tmpDir := t.TempDir()
+	srcPath := filepath.Join(tmpDir, "file.txt")
+	dstDir := filepath.Join(tmpDir, "subdir")
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		t.Fatalf("failed to create destination dir: %v", err)
+	}
+	if err := os.WriteFile(srcPath, []byte("content"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	// A move into a directory changes the file inside it
+	input := `{"source": "` + srcPath + `", "destination": "` + dstDir + `"}`
+	paths := tool.ChangedPaths(ctx, json.RawMessage(input))
+	if len(paths) != 2 || paths[0] != srcPath || paths[1] != filepath.Join(dstDir, "file.txt") {
+		t.Errorf("unexpected paths: %v", paths)
+	}
+
+	// Directory moves are not reported
+	input = `{"source": "` + dstDir + `", "destination": "` + filepath.Join(tmpDir, "other") + `"}`
+	if paths := tool.ChangedPaths(ctx, json.RawMessage(input)); paths != nil {
+		t.Errorf("expected no paths for a directory move, got %v", paths)
+	}
+}
@@ -0,0 +1,311 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Per-file statuses reported by multi_edit.
+const (
+	multiEditApplied    = "applied"
+	multiEditValidated  = "validated"
+	multiEditFailed     = "failed"
+	multiEditRolledBack = "rolled_back"
+	multiEditNotApplied = "not_applied"
+)
+
+// MultiEditTool implements the Tool interface for editing several files as
+// one atomic change.
+type MultiEditTool struct{}
+
+// multiEditInput defines the expected input parameters for the multi_edit tool.
+type multiEditInput struct {
+	Files  []multiEditFile `json:"files"`
+	DryRun bool            `json:"dry_run,omitempty"`
+}
+
+// multiEditFile is the edit of one file: the same path, operations, eol
+// and base_hash options the edit tool takes.
+type multiEditFile struct {
+	Path       string      `json:"path"`
+	Operations []Operation `json:"operations"`
+	EOL        string      `json:"eol,omitempty"`
+	BaseHash   string      `json:"base_hash,omitempty"`
+}
+
+// multiEditOutput defines the success response format.
+type multiEditOutput struct {
+	Files  []multiEditResult `json:"files"`
+	DryRun bool              `json:"dry_run,omitempty"`
+}
+
+// multiEditResult reports the outcome for one file.
+type multiEditResult struct {
+	Path         string `json:"path"`
+	Status       string `json:"status"`
+	LinesChanged int    `json:"linesChanged,omitempty"`
+	NewLineCount int    `json:"newLineCount,omitempty"`
+	Diff         string `json:"diff,omitempty"`
+	EOL          string `json:"eol,omitempty"`
+	Error        string `json:"error,omitempty"`
+	// Current is the file's current content where the edit was to go, set
+	// when its base_hash no longer matches.
+	Current *conflictRegion `json:"current,omitempty"`
+}
+
+// multiEditError defines the error response format. Files is set once the
+// request has been parsed, so the model can see which file failed.
+type multiEditError struct {
+	Error string            `json:"error"`
+	Files []multiEditResult `json:"files,omitempty"`
+}
+
+// NewMultiEditTool creates a new MultiEditTool instance.
+func NewMultiEditTool() *MultiEditTool {
+	return &MultiEditTool{}
+}
+
+// Name returns the tool identifier.
+func (t *MultiEditTool) Name() string {
+	return "multi_edit"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *MultiEditTool) Description() string {
+	return "Edit several files as one atomic change, using the same operations as edit. Every file is validated before any is written; if one fails, none are changed. Returns a status and unified diff per file"
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *MultiEditTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"files": {
+				"type": "array",
+				"description": "Files to edit, each path at most once",
+				"items": {
+					"type": "object",
+					"properties": {
+						"path": {"type": "string", "description": "File path to edit"},
+						"operations": {
+							"type": "array",
+							"description": "Edit operations for this file, as for the edit tool",
+							"items": {
+								"type": "object",
+								"properties": {
+									"op": {"type": "string", "enum": ["replace", "insert", "delete", "replace_string"]},
+									"startLine": {"type": "integer", "description": "First line (1-indexed) for replace/delete"},
+									"endLine": {"type": "integer", "description": "Last line (inclusive) for replace/delete"},
+									"afterLine": {"type": "integer", "description": "Insert after this line (0 = beginning)"},
+									"content": {"type": "array", "items": {"type": "string"}, "description": "Lines to insert/replace with"},
+									"oldString": {"type": "string", "description": "Exact text to find for replace_string, may span lines"},
+									"newString": {"type": "string", "description": "Replacement text for replace_string"},
+									"expectedOccurrences": {"type": "integer", "description": "Number of times oldString must occur for replace_string (default: 1); every occurrence is replaced"}
+								},
+								"required": ["op"]
+							}
+						},
+						"eol": {"type": "string", "enum": ["lf", "crlf"], "description": "Line ending to write this file with (default: keep the file's own)"},
+						"base_hash": {"type": "string", "description": "Edit this file only if its current SHA-256 matches this hex digest, as returned by read"}
+					},
+					"required": ["path", "operations"]
+				}
+			},
+			"dry_run": {"type": "boolean", "description": "Validate every file and return the diffs without writing (default false)"}
+		},
+		"required": ["files"]
+	}`)
+}
+
+// ChangedPaths returns the edited files; a dry run changes nothing.
+func (t *MultiEditTool) ChangedPaths(ctx context.Context, input json.RawMessage) []string {
+	var params multiEditInput
+	if json.Unmarshal(input, &params) != nil || params.DryRun {
+		return nil
+	}
+	var paths []string
+	for _, file := range params.Files {
+		if file.Path == "" {
+			continue
+		}
+		if resolved, err := ResolvePath(ctx, file.Path); err == nil {
+			paths = append(paths, resolved)
+		}
+	}
+	return paths
+}
+
+// Execute validates the edits of every file, then writes them all or none.
+func (t *MultiEditTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params multiEditInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatMultiEditError("invalid input: "+err.Error(), nil), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	if len(params.Files) == 0 {
+		return formatMultiEditError("no files provided", nil), nil
+	}
+
+	// Validate every file before writing any, so all problems are reported
+	// together
+	results := make([]multiEditResult, len(params.Files))
+	plans := make([]*editPlan, len(params.Files))
+	seen := make(map[string]string)
+	failed := 0
+	for i, f := range params.Files {
+		results[i] = multiEditResult{Path: f.Path, Status: multiEditNotApplied}
+		plan, err := planEdit(ctx, f.Path, f.Operations, f.EOL, f.BaseHash)
+		if err == nil {
+			if first, ok := seen[plan.absPath]; ok {
+				err = fmt.Errorf("file is listed more than once (also as %s); combine its operations into one entry", first)
+			} else {
+				seen[plan.absPath] = f.Path
+			}
+		}
+		if err != nil {
+			results[i].Status = multiEditFailed
+			results[i].Error = err.Error()
+			var conflict *conflictError
+			if errors.As(err, &conflict) {
+				results[i].Current = &conflict.current
+			}
+			failed++
+			continue
+		}
+		plans[i] = plan
+		results[i].LinesChanged = plan.output.LinesChanged
+		results[i].NewLineCount = plan.output.NewLineCount
+		results[i].Diff = plan.output.Diff
+		results[i].EOL = plan.output.EOL
+	}
+	if failed > 0 {
+		return formatMultiEditError(fmt.Sprintf("%d of %d files failed validation; no files were changed", failed, len(plans)), results), nil
+	}
+
+	if params.DryRun {
+		for i := range results {
+			results[i].Status = multiEditValidated
+		}
+		return formatMultiEditSuccess(multiEditOutput{Files: results, DryRun: true}), nil
+	}
+
+	// Check for context cancellation before writing
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	if err := commitEdits(plans, results); err != nil {
+		return formatMultiEditError(err.Error()+"; no files were changed", results), nil
+	}
+	return formatMultiEditSuccess(multiEditOutput{Files: results}), nil
+}
+
+// commitEdits writes every plan to a temporary file, then swaps each into
+// place, keeping the original as a backup until all have been swapped. On
+// the first failure the files already swapped are restored from their
+// backups. results is updated with the status of each file.
+func commitEdits(plans []*editPlan, results []multiEditResult) error {
+	temps := make([]string, len(plans))
+	backups := make([]string, len(plans))
+	cleanup := func(paths []string) {
+		for _, p := range paths {
+			if p != "" {
+				os.Remove(p)
+			}
+		}
+	}
+
+	// Stage the new contents
+	for i, plan := range plans {
+		tmpPath, err := writeEditTemp(plan.absPath, plan.perm, plan.write)
+		if err != nil {
+			cleanup(temps)
+			results[i].Status = multiEditFailed
+			results[i].Error = editWriteError(results[i].Path, err)
+			return fmt.Errorf("failed to write %s", results[i].Path)
+		}
+		temps[i] = tmpPath
+	}
+
+	// Swap each file for its new contents, keeping the original aside
+	for i, plan := range plans {
+		backup, err := swapEdit(plan.absPath, temps[i])
+		if err != nil {
+			results[i].Status = multiEditFailed
+			results[i].Error = editWriteError(results[i].Path, err)
+			for j := i - 1; j >= 0; j-- {
+				if err := os.Rename(backups[j], plans[j].absPath); err != nil {
+					results[j].Error = "rollback failed, original kept at " + backups[j] + ": " + err.Error()
+					continue
+				}
+				results[j].Status = multiEditRolledBack
+			}
+			cleanup(temps[i:])
+			return fmt.Errorf("failed to write %s", results[i].Path)
+		}
+		backups[i] = backup
+	}
+
+	cleanup(backups)
+	for i := range results {
+		results[i].Status = multiEditApplied
+	}
+	return nil
+}
+
+// swapEdit moves the file at path to a new backup beside it and renames
+// tmpPath into its place. It returns the backup's path. On error, path is
+// left as it was.
+func swapEdit(path, tmpPath string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), ".multi_edit-*.bak")
+	if err != nil {
+		return "", err
+	}
+	backup := f.Name()
+	f.Close()
+
+	if err := os.Rename(path, backup); err != nil {
+		os.Remove(backup)
+		return "", err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Rename(backup, path)
+		return "", err
+	}
+	return backup, nil
+}
+
+// editWriteError describes a failure to write path for the model.
+func editWriteError(path string, err error) string {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Sprintf("permission denied: %s", path)
+	}
+	return "failed to write file: " + err.Error()
+}
+
+// formatMultiEditSuccess formats a successful multi_edit response.
+func formatMultiEditSuccess(output multiEditOutput) string {
+	data, _ := json.Marshal(output)
+	return string(data)
+}
+
+// formatMultiEditError formats an error response with the per-file results,
+// if any.
+func formatMultiEditError(msg string, files []multiEditResult) string {
+	output := multiEditError{Error: msg, Files: files}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
@@ -0,0 +1,206 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// parseMultiEditResult decodes a multi_edit response, success or error.
+func parseMultiEditResult(t *testing.T, out string) multiEditError {
+	t.Helper()
+	var result multiEditError
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("failed to parse output %q: %v", out, err)
+	}
+	return result
+}
+
+func TestMultiEditTool_Apply(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{
+		"a.go":     "package a\n\nfunc Fetch() {}",
+		"cmd/b.go": "package main\n\nfunc main() { a.Fetch() }",
+	})
+
+	input, _ := json.Marshal(map[string]any{"files": []map[string]any{
+		{"path": filepath.Join(root, "a.go"), "operations": []Operation{{Op: "replace_string", OldString: "Fetch(", NewString: "Get("}}},
+		{"path": filepath.Join(root, "cmd/b.go"), "operations": []Operation{{Op: "replace_string", OldString: "a.Fetch(", NewString: "a.Get("}}},
+	}})
+	out, err := NewMultiEditTool().Execute(context.Background(), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result := parseMultiEditResult(t, out)
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	for _, f := range result.Files {
+		if f.Status != multiEditApplied || f.LinesChanged != 2 || !strings.Contains(f.Diff, "+") {
+			t.Errorf("unexpected result for %s: %+v", f.Path, f)
+		}
+	}
+
+	a, _ := os.ReadFile(filepath.Join(root, "a.go"))
+	b, _ := os.ReadFile(filepath.Join(root, "cmd/b.go"))
+	if string(a) != "package a\n\nfunc Get() {}" || string(b) != "package main\n\nfunc main() { a.Get() }" {
+		t.Errorf("unexpected content: %q, %q", a, b)
+	}
+
+	// No temporary files or backups are left behind
+	for _, dir := range []string{root, filepath.Join(root, "cmd")} {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".") {
+				t.Errorf("leftover file %s in %s", e.Name(), dir)
+			}
+		}
+	}
+}
+
+func TestMultiEditTool_ValidationFailure(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{
+		"a.txt": "one\n",
+		"b.txt": "two\n",
+	})
+	a, b := filepath.Join(root, "a.txt"), filepath.Join(root, "b.txt")
+
+	tests := []struct {
+		name   string
+		files  []map[string]any
+		status []string
+		errMsg string
+	}{
+		{
+			name: "operation fails",
+			files: []map[string]any{
+				{"path": a, "operations": []Operation{{Op: "replace_string", OldString: "one", NewString: "1"}}},
+				{"path": b, "operations": []Operation{{Op: "replace_string", OldString: "missing", NewString: "x"}}},
+			},
+			status: []string{multiEditNotApplied, multiEditFailed},
+			errMsg: "oldString not found",
+		},
+		{
+			name: "file not found",
+			files: []map[string]any{
+				{"path": filepath.Join(root, "missing.txt"), "operations": []Operation{{Op: "delete", StartLine: 1, EndLine: 1}}},
+				{"path": a, "operations": []Operation{{Op: "delete", StartLine: 1, EndLine: 1}}},
+			},
+			status: []string{multiEditFailed, multiEditNotApplied},
+			errMsg: "file not found",
+		},
+		{
+			name: "duplicate path",
+			files: []map[string]any{
+				{"path": a, "operations": []Operation{{Op: "delete", StartLine: 1, EndLine: 1}}},
+				{"path": filepath.Join(root, ".", "a.txt"), "operations": []Operation{{Op: "insert", AfterLine: 0, Content: []string{"x"}}}},
+			},
+			status: []string{multiEditNotApplied, multiEditFailed},
+			errMsg: "listed more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, _ := json.Marshal(map[string]any{"files": tt.files})
+			out, _ := NewMultiEditTool().Execute(context.Background(), input)
+			result := parseMultiEditResult(t, out)
+			if !strings.Contains(result.Error, "no files were changed") {
+				t.Errorf("expected validation error, got %q", result.Error)
+			}
+			if len(result.Files) != len(tt.status) {
+				t.Fatalf("expected %d file results, got %+v", len(tt.status), result.Files)
+			}
+			for i, f := range result.Files {
+				if f.Status != tt.status[i] {
+					t.Errorf("file %d: expected status %s, got %s", i, tt.status[i], f.Status)
+				}
+				if f.Status == multiEditFailed && !strings.Contains(f.Error, tt.errMsg) {
+					t.Errorf("file %d: expected error containing %q, got %q", i, tt.errMsg, f.Error)
+				}
+			}
+
+			gotA, _ := os.ReadFile(a)
+			gotB, _ := os.ReadFile(b)
+			if string(gotA) != "one\n" || string(gotB) != "two\n" {
+				t.Errorf("files changed: %q, %q", gotA, gotB)
+			}
+		})
+	}
+}
+
+func TestMultiEditTool_DryRun(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{"a.txt": "one\n", "b.txt": "two\n"})
+
+	input, _ := json.Marshal(map[string]any{
+		"files": []map[string]any{
+			{"path": filepath.Join(root, "a.txt"), "operations": []Operation{{Op: "replace", StartLine: 1, EndLine: 1, Content: []string{"1"}}}},
+			{"path": filepath.Join(root, "b.txt"), "operations": []Operation{{Op: "replace", StartLine: 1, EndLine: 1, Content: []string{"2"}}}},
+		},
+		"dry_run": true,
+	})
+	out, _ := NewMultiEditTool().Execute(context.Background(), input)
+	var result multiEditOutput
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if !result.DryRun || len(result.Files) != 2 {
+		t.Fatalf("unexpected result: %s", out)
+	}
+	for _, f := range result.Files {
+		if f.Status != multiEditValidated || f.Diff == "" {
+			t.Errorf("unexpected result for %s: %+v", f.Path, f)
+		}
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(got) != "one\n" {
+		t.Errorf("dry run wrote the file: %q", got)
+	}
+}
+
+func TestCommitEdits_RollBack(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{"a.txt": "one\n", "b.txt": "two\n", "c.txt": "three\n"})
+
+	var plans []*editPlan
+	var results []multiEditResult
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		plan, err := planEdit(context.Background(), filepath.Join(root, name), []Operation{{Op: "insert", AfterLine: 0, Content: []string{"new"}}}, "", "")
+		if err != nil {
+			t.Fatalf("planEdit(%s): %v", name, err)
+		}
+		plans = append(plans, plan)
+		results = append(results, multiEditResult{Path: name, Status: multiEditNotApplied})
+	}
+
+	// Replace b.txt with a directory after validation, so it cannot be
+	// moved aside and swapped
+	os.Remove(filepath.Join(root, "b.txt"))
+	writeTreeFiles(t, root, map[string]string{"b.txt/x": ""})
+
+	if err := commitEdits(plans, results); err == nil {
+		t.Fatal("expected an error")
+	}
+	want := []string{multiEditRolledBack, multiEditFailed, multiEditNotApplied}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("%s: expected status %s, got %s (%s)", r.Path, want[i], r.Status, r.Error)
+		}
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(got) != "one\n" {
+		t.Errorf("a.txt not restored: %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(root, "c.txt")); string(got) != "three\n" {
+		t.Errorf("c.txt changed: %q", got)
+	}
+	entries, _ := os.ReadDir(root)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("leftover file %s", e.Name())
+		}
+	}
+}
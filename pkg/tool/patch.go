@@ -0,0 +1,510 @@
+package tool
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxPatchFuzz is the maximum number of leading and trailing context lines
+// a hunk may ignore when it does not match exactly.
+const maxPatchFuzz = 2
+
+// hunkHeader matches "@@ -start[,count] +start[,count] @@".
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// PatchTool implements the Tool interface for applying unified diffs.
+type PatchTool struct{}
+
+// patchInput defines the expected input parameters for the patch tool.
+type patchInput struct {
+	Patch  string `json:"patch"`
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+// patchOutput defines the success response format.
+type patchOutput struct {
+	Files   []patchFileResult `json:"files"`
+	Applied int               `json:"applied"`
+	Failed  int               `json:"failed"`
+	DryRun  bool              `json:"dry_run,omitempty"`
+}
+
+// patchFileResult reports the outcome for one file of the patch.
+type patchFileResult struct {
+	Path    string            `json:"path"`
+	Created bool              `json:"created,omitempty"`
+	Deleted bool              `json:"deleted,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	Hunks   []patchHunkResult `json:"hunks"`
+}
+
+// patchHunkResult reports the outcome of one hunk.
+type patchHunkResult struct {
+	Hunk    int    `json:"hunk"`
+	Applied bool   `json:"applied"`
+	Line    int    `json:"line,omitempty"`
+	Offset  int    `json:"offset,omitempty"`
+	Fuzz    int    `json:"fuzz,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// patchError defines the error response format.
+type patchError struct {
+	Error string `json:"error"`
+}
+
+// filePatch is the parsed diff of one file.
+type filePatch struct {
+	oldPath string // "" for /dev/null
+	newPath string // "" for /dev/null
+	hunks   []hunk
+}
+
+// hunk is one parsed @@ section.
+type hunk struct {
+	oldStart int
+	lines    []hunkLine
+
+	// "\ No newline at end of file" after the last old or new line
+	oldNoEOL bool
+	newNoEOL bool
+}
+
+// hunkLine is one line of a hunk: ' ' context, '-' removed, or '+' added.
+type hunkLine struct {
+	kind byte
+	text string
+}
+
+// NewPatchTool creates a new PatchTool instance.
+func NewPatchTool() *PatchTool {
+	return &PatchTool{}
+}
+
+// Name returns the tool identifier.
+func (t *PatchTool) Name() string {
+	return "patch"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *PatchTool) Description() string {
+	return "Apply a unified diff to one or more files; hunks are located by their context, so line numbers may be off"
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *PatchTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"patch": {"type": "string", "description": "Unified diff with ---/+++ file headers and @@ hunks"},
+			"dry_run": {"type": "boolean", "description": "Report which hunks would apply without writing (default: false)"}
+		},
+		"required": ["patch"]
+	}`)
+}
+
+// ExecuteWithDisplay applies the patch and attaches it as a DisplayDiff
+// hint, when any hunk applied.
+func (t *PatchTool) ExecuteWithDisplay(ctx context.Context, input json.RawMessage) (string, *Display, error) {
+	result, err := t.Execute(ctx, input)
+	var output patchOutput
+	if err != nil || !decodeResult(result, &output) || output.Applied == 0 {
+		return result, nil, err
+	}
+	var params patchInput
+	json.Unmarshal(input, &params)
+	return result, diffDisplay("", params.Patch, output.DryRun), nil
+}
+
+// Execute applies the patch and reports the outcome of every hunk.
+func (t *PatchTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params patchInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatPatchError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	if strings.TrimSpace(params.Patch) == "" {
+		return formatPatchError("patch is required"), nil
+	}
+	files, err := parsePatch(params.Patch)
+	if err != nil {
+		return formatPatchError(err.Error()), nil
+	}
+
+	output := patchOutput{Files: []patchFileResult{}, DryRun: params.DryRun}
+	for _, fp := range files {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		result := applyFilePatch(ctx, fp, params.DryRun)
+		for _, h := range result.Hunks {
+			if h.Applied && result.Error == "" {
+				output.Applied++
+			} else {
+				output.Failed++
+			}
+		}
+		if result.Error != "" && len(result.Hunks) == 0 {
+			output.Failed += len(fp.hunks)
+		}
+		output.Files = append(output.Files, result)
+	}
+
+	data, _ := json.Marshal(output)
+	return string(data), nil
+}
+
+// parsePatch splits a unified diff into per-file patches.
+func parsePatch(patch string) ([]filePatch, error) {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	var files []filePatch
+	var current *filePatch
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			files = append(files, filePatch{
+				oldPath: patchPath(line[4:]),
+				newPath: patchPath(lines[i+1][4:]),
+			})
+			current = &files[len(files)-1]
+			i++
+
+		case strings.HasPrefix(line, "@@"):
+			if current == nil {
+				return nil, errors.New("hunk before ---/+++ file header")
+			}
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("invalid hunk header: %s", line)
+			}
+			h := hunk{}
+			h.oldStart, _ = strconv.Atoi(m[1])
+			i = parseHunkLines(lines, i+1, &h) - 1
+			current.hunks = append(current.hunks, h)
+		}
+		// Anything else (diff --git, index, commentary) is ignored
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("no ---/+++ file headers found in patch")
+	}
+	for _, fp := range files {
+		if fp.oldPath == "" && fp.newPath == "" {
+			return nil, errors.New("file header has /dev/null on both sides")
+		}
+		if len(fp.hunks) == 0 {
+			return nil, fmt.Errorf("no hunks for %s", cmp.Or(fp.newPath, fp.oldPath))
+		}
+	}
+	stripGitPrefixes(files)
+	return files, nil
+}
+
+// parseHunkLines reads hunk body lines starting at i into h and returns the
+// index of the first line after the hunk. Hunk line counts are not trusted;
+// the body ends at the next header. Blank lines count as empty context.
+func parseHunkLines(lines []string, i int, h *hunk) int {
+body:
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "diff ") ||
+			(strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")) {
+			break body
+		}
+		if line == "" {
+			h.lines = append(h.lines, hunkLine{kind: ' '})
+			continue
+		}
+		switch line[0] {
+		case ' ', '-', '+':
+			h.lines = append(h.lines, hunkLine{kind: line[0], text: line[1:]})
+		case '\\':
+			// "\ No newline at end of file" applies to the preceding line
+			if n := len(h.lines); n > 0 {
+				switch h.lines[n-1].kind {
+				case '-':
+					h.oldNoEOL = true
+				case '+':
+					h.newNoEOL = true
+				default:
+					h.oldNoEOL, h.newNoEOL = true, true
+				}
+			}
+		default:
+			break body
+		}
+	}
+
+	// Trailing blank lines are the end of the patch, not context
+	for len(h.lines) > 0 && h.lines[len(h.lines)-1] == (hunkLine{kind: ' '}) {
+		h.lines = h.lines[:len(h.lines)-1]
+	}
+	return i
+}
+
+// patchPath extracts the file path from a ---/+++ header value, dropping
+// any trailing timestamp. Returns "" for /dev/null.
+func patchPath(value string) string {
+	if tab := strings.IndexByte(value, '\t'); tab >= 0 {
+		value = value[:tab]
+	}
+	value = strings.TrimSpace(value)
+	if value == "/dev/null" {
+		return ""
+	}
+	return value
+}
+
+// stripGitPrefixes removes the a/ and b/ prefixes of git-style diffs when
+// every header uses them.
+func stripGitPrefixes(files []filePatch) {
+	for _, fp := range files {
+		if (fp.oldPath != "" && !strings.HasPrefix(fp.oldPath, "a/")) ||
+			(fp.newPath != "" && !strings.HasPrefix(fp.newPath, "b/")) {
+			return
+		}
+	}
+	for i := range files {
+		files[i].oldPath = strings.TrimPrefix(files[i].oldPath, "a/")
+		files[i].newPath = strings.TrimPrefix(files[i].newPath, "b/")
+	}
+}
+
+// applyFilePatch applies the hunks of one file and writes the result unless
+// dryRun is set. Hunks that apply are kept even if others fail.
+func applyFilePatch(ctx context.Context, fp filePatch, dryRun bool) patchFileResult {
+	target := fp.newPath
+	if target == "" {
+		target = fp.oldPath
+	}
+	result := patchFileResult{Path: target, Hunks: []patchHunkResult{}}
+
+	resolved, err := ResolvePath(ctx, target)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	absPath, err := filepath.Abs(resolved)
+	if err != nil {
+		result.Error = "invalid path: " + err.Error()
+		return result
+	}
+
+	// Load the current content; a created file starts empty
+	var lines []string
+	eol := true
+	perm := os.FileMode(defaultFilePermissions)
+	info, err := os.Stat(absPath)
+	switch {
+	case fp.oldPath == "":
+		if err == nil {
+			result.Error = "file already exists"
+			return result
+		}
+		result.Created = true
+	case errors.Is(err, os.ErrNotExist):
+		result.Error = "file not found"
+		return result
+	case err != nil:
+		result.Error = err.Error()
+		return result
+	case info.IsDir():
+		result.Error = "path is a directory"
+		return result
+	default:
+		perm = info.Mode()
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			result.Error = "failed to read file: " + err.Error()
+			return result
+		}
+		content := string(data)
+		eol = content == "" || strings.HasSuffix(content, "\n")
+		content = strings.TrimSuffix(content, "\n")
+		if content != "" {
+			lines = strings.Split(content, "\n")
+		}
+	}
+
+	// Apply hunks in order; each must land after the previous one
+	offset, minPos := 0, 0
+	for i, h := range fp.hunks {
+		hr := patchHunkResult{Hunk: i + 1}
+		pos, fuzz, ok := locateHunk(lines, h, offset, minPos)
+		if !ok {
+			hr.Error = "hunk context not found"
+			result.Hunks = append(result.Hunks, hr)
+			continue
+		}
+		old, updated, lead := hunkSides(h, fuzz)
+		lines = splice(lines, pos, len(old), updated)
+		if pos+len(updated) == len(lines) {
+			// The hunk reached the end of the file
+			if h.newNoEOL {
+				eol = false
+			} else if h.oldNoEOL {
+				eol = true
+			}
+		}
+
+		expected := max(h.oldStart-1, 0) + lead
+		hr.Applied = true
+		hr.Line = pos + 1
+		hr.Offset = pos - expected
+		hr.Fuzz = fuzz
+		result.Hunks = append(result.Hunks, hr)
+		offset = pos - expected + len(updated) - len(old)
+		minPos = pos + len(updated)
+	}
+
+	if dryRun {
+		return result
+	}
+	applied := false
+	for _, hr := range result.Hunks {
+		applied = applied || hr.Applied
+	}
+	if !applied {
+		result.Created = false
+		return result
+	}
+
+	// A deletion removes the file once its content has been patched away
+	if fp.newPath == "" {
+		if len(lines) > 0 {
+			result.Error = "file is not empty after removing its lines; not deleted"
+			return result
+		}
+		if err := os.Remove(absPath); err != nil {
+			result.Error = "failed to delete file: " + err.Error()
+			return result
+		}
+		result.Deleted = true
+		return result
+	}
+
+	content := strings.Join(lines, "\n")
+	if eol && len(lines) > 0 {
+		content += "\n"
+	}
+	if result.Created {
+		if err := os.MkdirAll(filepath.Dir(absPath), defaultDirPermissions); err != nil {
+			result.Error = "failed to create directories: " + err.Error()
+			return result
+		}
+	}
+	if err := atomicWriteEdit(absPath, content, perm); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			result.Error = "permission denied"
+		} else {
+			result.Error = "failed to write file: " + err.Error()
+		}
+	}
+	return result
+}
+
+// hunkSides returns the old and new lines of h, ignoring up to fuzz context
+// lines at each end, and how many leading lines were ignored.
+func hunkSides(h hunk, fuzz int) (old, updated []string, lead int) {
+	lines := h.lines
+	for ; lead < fuzz && len(lines) > 0 && lines[0].kind == ' '; lead++ {
+		lines = lines[1:]
+	}
+	for f := 0; f < fuzz && len(lines) > 0 && lines[len(lines)-1].kind == ' '; f++ {
+		lines = lines[:len(lines)-1]
+	}
+	for _, l := range lines {
+		if l.kind != '+' {
+			old = append(old, l.text)
+		}
+		if l.kind != '-' {
+			updated = append(updated, l.text)
+		}
+	}
+	return old, updated, lead
+}
+
+// locateHunk finds where the old side of h occurs in lines at or after
+// minPos, nearest to the header position shifted by offset. It tries an
+// exact match, then ignores trailing whitespace, then drops up to
+// maxPatchFuzz context lines from each end.
+func locateHunk(lines []string, h hunk, offset, minPos int) (pos, fuzz int, ok bool) {
+	for fuzz = 0; fuzz <= maxPatchFuzz; fuzz++ {
+		old, _, lead := hunkSides(h, fuzz)
+		if fuzz > 0 {
+			if prev, _, _ := hunkSides(h, fuzz-1); len(prev) == len(old) {
+				break // no more context to drop
+			}
+		}
+		want := max(h.oldStart-1, 0) + lead + offset
+		if len(old) == 0 {
+			// Pure insertion: trust the header
+			if want < minPos || want > len(lines) {
+				return 0, 0, false
+			}
+			return want, fuzz, true
+		}
+		for _, equal := range []func(a, b string) bool{
+			func(a, b string) bool { return a == b },
+			func(a, b string) bool { return strings.TrimRight(a, " \t\r") == strings.TrimRight(b, " \t\r") },
+		} {
+			if pos, ok := nearestMatch(lines, old, want, minPos, equal); ok {
+				return pos, fuzz, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// nearestMatch returns the position of block in lines closest to want,
+// searching outward, at or after minPos.
+func nearestMatch(lines, block []string, want, minPos int, equal func(a, b string) bool) (int, bool) {
+	last := len(lines) - len(block)
+	if last < minPos {
+		return 0, false
+	}
+	want = min(max(want, minPos), last)
+	for d := 0; want-d >= minPos || want+d <= last; d++ {
+		if p := want - d; p >= minPos && blockAt(lines, block, p, equal) {
+			return p, true
+		}
+		if p := want + d; d > 0 && p <= last && blockAt(lines, block, p, equal) {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// blockAt reports whether block occurs in lines at position p.
+func blockAt(lines, block []string, p int, equal func(a, b string) bool) bool {
+	for i, b := range block {
+		if !equal(lines[p+i], b) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatPatchError formats an error response.
+func formatPatchError(msg string) string {
+	output := patchError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
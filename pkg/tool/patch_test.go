@@ -0,0 +1,181 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runPatch(t *testing.T, ctx context.Context, input map[string]any) patchOutput {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	result, err := NewPatchTool().Execute(ctx, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out patchOutput
+	if err := json.Unmarshal([]byte(result), &out); err != nil || out.Files == nil {
+		t.Fatalf("unexpected result %s", result)
+	}
+	return out
+}
+
+func readFileString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPatchTool_AppliesWithStaleLineNumbers(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	os.WriteFile(file, []byte("package main\n\n// added later\n// more\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"), 0644)
+
+	// The hunk claims line 3, but the function moved down by 3 lines
+	patch := "--- " + file + "\n+++ " + file + "\n" +
+		"@@ -3,3 +3,3 @@\n func main() {\n-\tprintln(\"hi\")\n+\tprintln(\"hello\")\n }\n"
+	out := runPatch(t, context.Background(), map[string]any{"patch": patch})
+
+	if out.Applied != 1 || out.Failed != 0 {
+		t.Fatalf("expected 1 applied hunk, got %+v", out)
+	}
+	hunk := out.Files[0].Hunks[0]
+	if hunk.Line != 6 || hunk.Offset != 3 || hunk.Fuzz != 0 {
+		t.Errorf("expected hunk at line 6 with offset 3, got %+v", hunk)
+	}
+	want := "package main\n\n// added later\n// more\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+	if got := readFileString(t, file); got != want {
+		t.Errorf("unexpected content:\n%s", got)
+	}
+}
+
+func TestPatchTool_Fuzz(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "list.txt")
+	os.WriteFile(file, []byte("one\ntwo\nthree\nfour\nfive\n"), 0644)
+
+	// The first context line is stale; fuzz 1 ignores it
+	patch := "--- list.txt\n+++ list.txt\n@@ -1,4 +1,4 @@\n zero\n two\n-three\n+THREE\n four\n"
+	out := runPatch(t, WithWorkspace(context.Background(), dir), map[string]any{"patch": patch})
+
+	if out.Applied != 1 || out.Files[0].Hunks[0].Fuzz != 1 {
+		t.Fatalf("expected the hunk to apply with fuzz 1, got %+v", out)
+	}
+	if got := readFileString(t, file); got != "one\ntwo\nTHREE\nfour\nfive\n" {
+		t.Errorf("unexpected content:\n%s", got)
+	}
+}
+
+func TestPatchTool_PerHunkResults(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	os.WriteFile(file, []byte("a\nb\nc\nd\ne\nf\ng\n"), 0644)
+
+	patch := "--- a/a.txt\n+++ b/a.txt\n" +
+		"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n" +
+		"@@ -4,3 +4,3 @@\n x\n-y\n+Y\n z\n" +
+		"@@ -5,3 +5,3 @@\n e\n-f\n+F\n g\n"
+	out := runPatch(t, WithWorkspace(context.Background(), dir), map[string]any{"patch": patch})
+
+	if out.Applied != 2 || out.Failed != 1 {
+		t.Fatalf("expected 2 applied and 1 failed hunk, got %+v", out)
+	}
+	hunks := out.Files[0].Hunks
+	if !hunks[0].Applied || hunks[1].Applied || hunks[1].Error == "" || !hunks[2].Applied {
+		t.Errorf("unexpected hunk results %+v", hunks)
+	}
+	if got := readFileString(t, file); got != "a\nB\nc\nd\ne\nF\ng\n" {
+		t.Errorf("unexpected content:\n%s", got)
+	}
+}
+
+func TestPatchTool_CreateAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.txt")
+	os.WriteFile(old, []byte("bye\n"), 0644)
+
+	patch := "diff --git a/new/hello.txt b/new/hello.txt\nnew file mode 100644\n" +
+		"--- /dev/null\n+++ b/new/hello.txt\n@@ -0,0 +1,2 @@\n+hello\n+world\n" +
+		"diff --git a/old.txt b/old.txt\ndeleted file mode 100644\n" +
+		"--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n"
+	out := runPatch(t, WithWorkspace(context.Background(), dir), map[string]any{"patch": patch})
+
+	if out.Applied != 2 || !out.Files[0].Created || !out.Files[1].Deleted {
+		t.Fatalf("expected a create and a delete, got %+v", out)
+	}
+	if got := readFileString(t, filepath.Join(dir, "new", "hello.txt")); got != "hello\nworld\n" {
+		t.Errorf("unexpected created content %q", got)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expected old.txt to be deleted, got %v", err)
+	}
+}
+
+func TestPatchTool_NoNewlineAtEOF(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "v.txt")
+	os.WriteFile(file, []byte("v1\n"), 0644)
+
+	patch := "--- v.txt\n+++ v.txt\n@@ -1 +1 @@\n-v1\n+v2\n\\ No newline at end of file\n"
+	runPatch(t, WithWorkspace(context.Background(), dir), map[string]any{"patch": patch})
+	if got := readFileString(t, file); got != "v2" {
+		t.Errorf("expected no trailing newline, got %q", got)
+	}
+}
+
+func TestPatchTool_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.txt")
+	os.WriteFile(file, []byte("a\n"), 0644)
+
+	patch := "--- a.txt\n+++ a.txt\n@@ -1 +1 @@\n-a\n+b\n"
+	out := runPatch(t, WithWorkspace(context.Background(), dir), map[string]any{"patch": patch, "dry_run": true})
+	if out.Applied != 1 || !out.DryRun {
+		t.Fatalf("expected a dry-run success, got %+v", out)
+	}
+	if got := readFileString(t, file); got != "a\n" {
+		t.Errorf("dry run modified the file: %q", got)
+	}
+}
+
+func TestPatchTool_Errors(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithWorkspace(context.Background(), dir)
+
+	tests := []struct {
+		patch string
+		want  string
+	}{
+		{"", "patch is required"},
+		{"just some text", "no ---/+++ file headers"},
+		{"@@ -1 +1 @@\n-a\n+b\n", "hunk before"},
+		{"--- a.txt\n+++ a.txt\n@@ bogus @@\n", "invalid hunk header"},
+		{"--- a.txt\n+++ a.txt\n", "no hunks"},
+	}
+	for _, tt := range tests {
+		data, _ := json.Marshal(map[string]any{"patch": tt.patch})
+		result, err := NewPatchTool().Execute(ctx, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result, tt.want) {
+			t.Errorf("patch %q: expected error containing %q, got %s", tt.patch, tt.want, result)
+		}
+	}
+
+	// File-level failures are reported per file
+	out := runPatch(t, ctx, map[string]any{"patch": "--- missing.txt\n+++ missing.txt\n@@ -1 +1 @@\n-a\n+b\n"})
+	if out.Failed != 1 || out.Files[0].Error != "file not found" {
+		t.Errorf("expected file not found, got %+v", out)
+	}
+	out = runPatch(t, ctx, map[string]any{"patch": "--- /etc/hosts\n+++ /etc/hosts\n@@ -1 +1 @@\n-a\n+b\n"})
+	if !strings.Contains(out.Files[0].Error, "outside the workspace") {
+		t.Errorf("expected the workspace jail to reject /etc/hosts, got %+v", out)
+	}
+}
@@ -2,16 +2,37 @@ package tool
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"strings"
 )
 
+const (
+	// defaultReadLimit is how many lines one read returns unless a limit or
+	// end_line is given.
+	defaultReadLimit = 2000
+	// maxReadBytes caps the content returned by one read.
+	maxReadBytes = 256 * 1024
+	// maxReadImageBytes caps the size of image files attached to a result,
+	// keeping their base64 encoding within the API's 5MB image limit.
+	maxReadImageBytes = 5 * 1024 * 1024 / 4 * 3
+)
+
+// readImageTypes are the image formats attached to results when the
+// harness accepts images.
+var readImageTypes = []string{"image/png", "image/jpeg"}
+
 // ReadTool implements the Tool interface for reading file contents.
-// It supports optional line range specification for partial file reads.
+// It supports optional line range specification for partial file reads,
+// and returns large files a page at a time.
 type ReadTool struct{}
 
 // readInput defines the expected input parameters for the read tool.
@@ -19,11 +40,35 @@ type readInput struct {
 	Path      string `json:"path"`
 	StartLine *int   `json:"start_line,omitempty"`
 	EndLine   *int   `json:"end_line,omitempty"`
+	Offset    *int   `json:"offset,omitempty"`
+	Limit     *int   `json:"limit,omitempty"`
+	// ShowLineNumbers defaults to true.
+	ShowLineNumbers *bool `json:"show_line_numbers,omitempty"`
 }
 
 // readOutput defines the success response format.
 type readOutput struct {
-	Content string `json:"content"`
+	Content    string `json:"content"`
+	TotalLines int    `json:"total_lines"`
+	// Truncated is set when the line or byte cap cut the requested range
+	// short.
+	Truncated bool `json:"truncated,omitempty"`
+	// NextOffset is the offset of the first line after the content, set
+	// when the file has more lines.
+	NextOffset int `json:"next_offset,omitempty"`
+	// SHA256 is the hex digest of the whole file, for the base_hash of the
+	// write and edit tools.
+	SHA256 string `json:"sha256"`
+}
+
+// readBinaryOutput is the success response for binary files, whose content
+// is not returned as text.
+type readBinaryOutput struct {
+	Type string `json:"type"`
+	Size int64  `json:"size"`
+	MIME string `json:"mime"`
+	// Attached is set when the file is attached to the result as an image.
+	Attached bool `json:"attached,omitempty"`
 }
 
 // readError defines the error response format.
@@ -43,7 +88,12 @@ func (t *ReadTool) Name() string {
 
 // Description returns a human-readable description of the tool.
 func (t *ReadTool) Description() string {
-	return "Read file contents, optionally specifying a line range"
+	return fmt.Sprintf("Read file contents, optionally specifying a line range. Lines are numbered cat -n style by default; the number and tab before each line are not part of the file, so leave them out of edits. Returns at most %d lines or %dKB at a time, with the file's total_lines; page through larger files with offset and limit. Binary files are described by size and MIME type instead, and PNG and JPEG images may be attached for viewing", defaultReadLimit, maxReadBytes/1024)
+}
+
+// ReadOnly reports that the tool has no side effects.
+func (t *ReadTool) ReadOnly() bool {
+	return true
 }
 
 // InputSchema returns the JSON Schema for the tool's input parameters.
@@ -53,7 +103,10 @@ func (t *ReadTool) InputSchema() json.RawMessage {
 		"properties": {
 			"path": {"type": "string", "description": "Absolute or relative file path"},
 			"start_line": {"type": "integer", "description": "First line to read (1-indexed)"},
-			"end_line": {"type": "integer", "description": "Last line to read (inclusive)"}
+			"end_line": {"type": "integer", "description": "Last line to read (inclusive)"},
+			"offset": {"type": "integer", "description": "Number of lines to skip before reading; pass next_offset from a truncated result to continue"},
+			"limit": {"type": "integer", "description": "Maximum number of lines to read (default 2000)"},
+			"show_line_numbers": {"type": "boolean", "description": "Prefix each line with its 1-indexed line number and a tab, as cat -n does (default true). The prefix is not part of the file"}
 		},
 		"required": ["path"]
 	}`)
@@ -79,6 +132,13 @@ func (t *ReadTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		return formatReadError("path is required"), nil
 	}
 
+	// Resolve path within the workspace, if any
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatReadError(err.Error()), nil
+	}
+	params.Path = resolved
+
 	// Check if path exists and get file info
 	info, err := os.Stat(params.Path)
 	if err != nil {
@@ -97,6 +157,9 @@ func (t *ReadTool) Execute(ctx context.Context, input json.RawMessage) (string,
 	}
 
 	// Validate line range parameters
+	if (params.Offset != nil || params.Limit != nil) && (params.StartLine != nil || params.EndLine != nil) {
+		return formatReadError("use either offset and limit or start_line and end_line, not both"), nil
+	}
 	startLine := 1
 	if params.StartLine != nil {
 		startLine = *params.StartLine
@@ -114,8 +177,32 @@ func (t *ReadTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		}
 	}
 
-	// Read the file
-	file, err := os.Open(params.Path)
+	// Translate offset and limit into the equivalent line range
+	endLine := 0
+	if params.EndLine != nil {
+		endLine = *params.EndLine
+	}
+	if params.Offset != nil {
+		if *params.Offset < 0 {
+			return formatReadError("offset must be at least 0"), nil
+		}
+		startLine = *params.Offset + 1
+	}
+	if params.Limit != nil {
+		if *params.Limit < 1 {
+			return formatReadError("limit must be at least 1"), nil
+		}
+		endLine = startLine + *params.Limit - 1
+	}
+
+	// Without an explicit end, return at most defaultReadLimit lines
+	truncateAt := 0
+	if endLine == 0 {
+		truncateAt = startLine + defaultReadLimit - 1
+	}
+
+	// Read the file, through the run's read cache if any
+	file, err := ReadCacheFromContext(ctx).open(params.Path)
 	if err != nil {
 		if errors.Is(err, os.ErrPermission) {
 			return formatReadError("permission denied"), nil
@@ -124,13 +211,28 @@ func (t *ReadTool) Execute(ctx context.Context, input json.RawMessage) (string,
 	}
 	defer file.Close()
 
-	// Read lines with optional range
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
+	// Describe binary files rather than returning their bytes
+	hash := sha256.New()
+	reader := bufio.NewReader(io.TeeReader(file, hash))
+	head, _ := reader.Peek(statBinarySniffSize)
+	if mime := http.DetectContentType(head); bytes.IndexByte(head, 0) >= 0 || isReadImage(mime) {
+		output := readBinaryOutput{Type: "binary", Size: info.Size(), MIME: mime}
+		if isReadImage(mime) && info.Size() <= maxReadImageBytes {
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return formatReadError("error reading file: " + err.Error()), nil
+			}
+			output.Attached = AttachImage(ctx, Image{MediaType: mime, Data: data})
+		}
+		return formatReadSuccess(output), nil
+	}
 
+	// Read every line to count them, keeping those in range while they fit
+	var content bytes.Buffer
+	numbered := params.ShowLineNumbers == nil || *params.ShowLineNumbers
+	lineNum, lastLine := 0, 0
+	truncated := false
+	for {
 		// Check context periodically
 		if lineNum%1000 == 0 {
 			select {
@@ -140,21 +242,47 @@ func (t *ReadTool) Execute(ctx context.Context, input json.RawMessage) (string,
 			}
 		}
 
-		// Skip lines before start_line
-		if lineNum < startLine {
-			continue
+		wanted := !truncated && lineNum+1 >= startLine && (endLine == 0 || lineNum+1 <= endLine)
+		keep := 0
+		prefix := ""
+		if wanted {
+			if numbered {
+				prefix = fmt.Sprintf("%6d\t", lineNum+1)
+			}
+			keep = max(maxReadBytes-content.Len()-len(prefix), 0)
 		}
-
-		// Stop after end_line
-		if params.EndLine != nil && lineNum > *params.EndLine {
+		line, size, err := readLine(reader, keep)
+		if err == io.EOF {
 			break
 		}
+		if err != nil {
+			return formatReadError("error reading file: " + err.Error()), nil
+		}
+		lineNum++
+		if !wanted {
+			continue
+		}
 
-		lines = append(lines, scanner.Text())
-	}
-
-	if err := scanner.Err(); err != nil {
-		return formatReadError("error reading file: " + err.Error()), nil
+		sep := 0
+		if lastLine > 0 {
+			sep = 1
+		}
+		if truncateAt > 0 && lineNum > truncateAt || content.Len()+sep+len(prefix)+size > maxReadBytes {
+			truncated = true
+			// A first line too long for the byte cap is returned in part
+			if lastLine == 0 {
+				content.WriteString(prefix)
+				content.Write(bytes.ToValidUTF8(line, nil))
+				lastLine = lineNum
+			}
+			continue
+		}
+		if sep > 0 {
+			content.WriteByte('\n')
+		}
+		content.WriteString(prefix)
+		content.Write(line)
+		lastLine = lineNum
 	}
 
 	// Check if start_line exceeds file length
@@ -162,15 +290,98 @@ func (t *ReadTool) Execute(ctx context.Context, input json.RawMessage) (string,
 	if params.StartLine != nil && lineNum < startLine {
 		return formatReadError(fmt.Sprintf("start_line %d exceeds file length of %d lines", startLine, lineNum)), nil
 	}
+	if params.Offset != nil && *params.Offset > 0 && lineNum < startLine {
+		return formatReadError(fmt.Sprintf("offset %d exceeds file length of %d lines", *params.Offset, lineNum)), nil
+	}
+
+	output := readOutput{
+		Content:    content.String(),
+		TotalLines: lineNum,
+		SHA256:     hex.EncodeToString(hash.Sum(nil)),
+		Truncated:  truncated,
+	}
+	if lastLine > 0 && lastLine < lineNum {
+		output.NextOffset = lastLine
+	}
+	return formatReadSuccess(output), nil
+}
+
+// ExecuteWithDisplay reads the file and attaches a DisplayFile hint with
+// the file's language and the range of lines returned. Binary files have
+// none.
+func (t *ReadTool) ExecuteWithDisplay(ctx context.Context, input json.RawMessage) (string, *Display, error) {
+	result, err := t.Execute(ctx, input)
+	var output struct {
+		readOutput
+		Type string `json:"type"`
+	}
+	if err != nil || !decodeResult(result, &output) || output.Type != "" {
+		return result, nil, err
+	}
+	var params readInput
+	json.Unmarshal(input, &params)
+	display := FileDisplay{Path: params.Path, Language: fileLanguage(params.Path), TotalLines: output.TotalLines}
+	if output.Content != "" {
+		display.StartLine = 1
+		if params.StartLine != nil {
+			display.StartLine = *params.StartLine
+		} else if params.Offset != nil {
+			display.StartLine = *params.Offset + 1
+		}
+		display.EndLine = output.TotalLines
+		if output.NextOffset > 0 {
+			display.EndLine = output.NextOffset
+		}
+	}
+	return result, &Display{Kind: DisplayFile, Payload: display}, nil
+}
+
+// readLine reads the next line from r without its line terminator, keeping
+// at most keep bytes of it so that long lines can be skipped cheaply. It
+// returns the kept bytes, the full length of the line, and io.EOF once no
+// lines remain.
+func readLine(r *bufio.Reader, keep int) ([]byte, int, error) {
+	var line []byte
+	size := 0
+	prevCR := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		size += len(chunk)
+		if room := keep - len(line); room > 0 {
+			line = append(line, chunk[:min(room, len(chunk))]...)
+		}
+		if err == bufio.ErrBufferFull {
+			prevCR = chunk[len(chunk)-1] == '\r'
+			continue
+		}
+		if err == io.EOF && size > 0 {
+			return line, size, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// Drop the terminator, \n or \r\n, as bufio.ScanLines does
+		size--
+		if bytes.HasSuffix(chunk, []byte("\r\n")) || len(chunk) == 1 && prevCR {
+			size--
+		}
+		return line[:min(len(line), size)], size, nil
+	}
+}
 
-	// Join lines and return
-	content := strings.Join(lines, "\n")
-	return formatReadSuccess(content), nil
+// isReadImage reports whether mime is an image format the read tool attaches.
+func isReadImage(mime string) bool {
+	for _, t := range readImageTypes {
+		if strings.HasPrefix(mime, t) {
+			return true
+		}
+	}
+	return false
 }
 
 // formatReadSuccess formats a successful read response.
-func formatReadSuccess(content string) string {
-	output := readOutput{Content: content}
+func formatReadSuccess(output any) string {
 	data, _ := json.Marshal(output)
 	return string(data)
 }
@@ -1,10 +1,15 @@
 package tool
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -27,11 +32,20 @@ func createTestFile(t *testing.T, content string) string {
 // Helper to parse tool output
 func parseReadOutput(t *testing.T, output string) (content string, errMsg string) {
 	t.Helper()
-	var result map[string]string
+	result := parseReadResult(t, output)
+	return result.Content, result.Error
+}
+
+// Helper to parse the full tool output, paging fields included
+func parseReadResult(t *testing.T, output string) (result struct {
+	readOutput
+	Error string `json:"error"`
+}) {
+	t.Helper()
 	if err := json.Unmarshal([]byte(output), &result); err != nil {
 		t.Fatalf("failed to parse output JSON: %v", err)
 	}
-	return result["content"], result["error"]
+	return result
 }
 
 func TestReadTool_Name(t *testing.T) {
@@ -81,6 +95,16 @@ func TestReadTool_InputSchema(t *testing.T) {
 	}
 }
 
+// numberLines formats content as the read tool numbers it by default,
+// starting at line first
+func numberLines(first int, content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = fmt.Sprintf("%6d\t%s", first+i, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
 func TestReadTool_ReadEntireFile(t *testing.T) {
 	tool := NewReadTool()
 	content := "line1\nline2\nline3"
@@ -97,8 +121,8 @@ func TestReadTool_ReadEntireFile(t *testing.T) {
 	if gotErr != "" {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
-	if gotContent != content {
-		t.Errorf("expected content %q, got %q", content, gotContent)
+	if expected := numberLines(1, content); gotContent != expected {
+		t.Errorf("expected content %q, got %q", expected, gotContent)
 	}
 }
 
@@ -119,7 +143,7 @@ func TestReadTool_ReadWithStartLineOnly(t *testing.T) {
 	if gotErr != "" {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
-	expected := "line2\nline3"
+	expected := numberLines(2, "line2\nline3")
 	if gotContent != expected {
 		t.Errorf("expected content %q, got %q", expected, gotContent)
 	}
@@ -142,7 +166,7 @@ func TestReadTool_ReadWithEndLineOnly(t *testing.T) {
 	if gotErr != "" {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
-	expected := "line1\nline2"
+	expected := numberLines(1, "line1\nline2")
 	if gotContent != expected {
 		t.Errorf("expected content %q, got %q", expected, gotContent)
 	}
@@ -166,7 +190,7 @@ func TestReadTool_ReadSpecificRange(t *testing.T) {
 	if gotErr != "" {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
-	expected := "line2\nline3\nline4"
+	expected := numberLines(2, "line2\nline3\nline4")
 	if gotContent != expected {
 		t.Errorf("expected content %q, got %q", expected, gotContent)
 	}
@@ -308,8 +332,8 @@ func TestReadTool_SingleLine(t *testing.T) {
 	if gotErr != "" {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
-	if gotContent != content {
-		t.Errorf("expected content %q, got %q", content, gotContent)
+	if expected := numberLines(1, content); gotContent != expected {
+		t.Errorf("expected content %q, got %q", expected, gotContent)
 	}
 }
 
@@ -386,8 +410,8 @@ func TestReadTool_RelativePath(t *testing.T) {
 	if gotErr != "" {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
-	if gotContent != content {
-		t.Errorf("expected content %q, got %q", content, gotContent)
+	if expected := numberLines(1, content); gotContent != expected {
+		t.Errorf("expected content %q, got %q", expected, gotContent)
 	}
 }
 
@@ -409,7 +433,232 @@ func TestReadTool_EndLineBeyondFileLength(t *testing.T) {
 	if gotErr != "" {
 		t.Fatalf("unexpected error in output: %s", gotErr)
 	}
-	if gotContent != content {
-		t.Errorf("expected content %q, got %q", content, gotContent)
+	if expected := numberLines(1, content); gotContent != expected {
+		t.Errorf("expected content %q, got %q", expected, gotContent)
+	}
+}
+
+func TestReadTool_Workspace(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "inside.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outside := createTestFile(t, "secret")
+	defer os.Remove(outside)
+
+	tool := NewReadTool()
+	ctx := WithWorkspace(context.Background(), root)
+
+	result, _ := tool.Execute(ctx, json.RawMessage(`{"path":"inside.txt","show_line_numbers":false}`))
+	content, errMsg := parseReadOutput(t, result)
+	if errMsg != "" || content != "hello" {
+		t.Errorf("expected relative path to resolve in workspace, got content=%q error=%q", content, errMsg)
+	}
+
+	input, _ := json.Marshal(map[string]string{"path": outside})
+	result, _ = tool.Execute(ctx, input)
+	if _, errMsg := parseReadOutput(t, result); errMsg == "" {
+		t.Error("expected path outside the workspace to be rejected")
+	}
+}
+
+func TestReadTool_OffsetLimit(t *testing.T) {
+	tool := NewReadTool()
+	path := createTestFile(t, "line1\nline2\nline3\nline4\nline5\n")
+	defer os.Remove(path)
+
+	tests := []struct {
+		name       string
+		input      map[string]any
+		content    string
+		nextOffset int
+	}{
+		{"first page", map[string]any{"limit": 2}, numberLines(1, "line1\nline2"), 2},
+		{"middle page", map[string]any{"offset": 2, "limit": 2}, numberLines(3, "line3\nline4"), 4},
+		{"last page", map[string]any{"offset": 4, "limit": 2}, numberLines(5, "line5"), 0},
+		{"offset only", map[string]any{"offset": 3}, numberLines(4, "line4\nline5"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input["path"] = path
+			input, _ := json.Marshal(tt.input)
+			output, _ := tool.Execute(context.Background(), input)
+			result := parseReadResult(t, output)
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if result.Content != tt.content {
+				t.Errorf("expected content %q, got %q", tt.content, result.Content)
+			}
+			if result.TotalLines != 5 {
+				t.Errorf("expected total_lines 5, got %d", result.TotalLines)
+			}
+			if result.NextOffset != tt.nextOffset {
+				t.Errorf("expected next_offset %d, got %d", tt.nextOffset, result.NextOffset)
+			}
+			if result.Truncated {
+				t.Error("an explicit limit should not report truncation")
+			}
+		})
+	}
+
+	for _, input := range []string{
+		`{"offset": 5}`,
+		`{"offset": -1}`,
+		`{"limit": 0}`,
+		`{"offset": 1, "start_line": 2}`,
+	} {
+		var params map[string]any
+		json.Unmarshal([]byte(input), &params)
+		params["path"] = path
+		data, _ := json.Marshal(params)
+		output, _ := tool.Execute(context.Background(), data)
+		if _, errMsg := parseReadOutput(t, output); errMsg == "" {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+}
+
+func TestReadTool_DefaultLineCap(t *testing.T) {
+	tool := NewReadTool()
+	var b strings.Builder
+	for i := 1; i <= defaultReadLimit+500; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	path := createTestFile(t, b.String())
+	defer os.Remove(path)
+
+	input, _ := json.Marshal(map[string]any{"path": path, "show_line_numbers": false})
+	output, _ := tool.Execute(context.Background(), input)
+	result := parseReadResult(t, output)
+	if !result.Truncated || result.TotalLines != defaultReadLimit+500 || result.NextOffset != defaultReadLimit {
+		t.Fatalf("expected truncation at %d lines, got truncated=%v total_lines=%d next_offset=%d",
+			defaultReadLimit, result.Truncated, result.TotalLines, result.NextOffset)
+	}
+	if lines := strings.Split(result.Content, "\n"); len(lines) != defaultReadLimit || lines[len(lines)-1] != fmt.Sprintf("line %d", defaultReadLimit) {
+		t.Errorf("expected the first %d lines, got %d ending %q", defaultReadLimit, len(lines), lines[len(lines)-1])
+	}
+
+	// Continuing from next_offset returns the rest
+	input, _ = json.Marshal(map[string]any{"path": path, "offset": result.NextOffset, "show_line_numbers": false})
+	output, _ = tool.Execute(context.Background(), input)
+	result = parseReadResult(t, output)
+	if result.Truncated || result.NextOffset != 0 || !strings.HasPrefix(result.Content, fmt.Sprintf("line %d\n", defaultReadLimit+1)) {
+		t.Errorf("unexpected second page: truncated=%v next_offset=%d", result.Truncated, result.NextOffset)
+	}
+}
+
+func TestReadTool_ByteCap(t *testing.T) {
+	tool := NewReadTool()
+	long := strings.Repeat("x", maxReadBytes/2)
+	path := createTestFile(t, long+"\n"+long+"\n"+long+"\r\nend")
+	defer os.Remove(path)
+
+	input, _ := json.Marshal(map[string]any{"path": path, "show_line_numbers": false})
+	output, _ := tool.Execute(context.Background(), input)
+	result := parseReadResult(t, output)
+	if !result.Truncated || result.Content != long || result.NextOffset != 1 || result.TotalLines != 4 {
+		t.Errorf("expected only the first line, got %d bytes, truncated=%v next_offset=%d total_lines=%d",
+			len(result.Content), result.Truncated, result.NextOffset, result.TotalLines)
+	}
+
+	// CRLF endings are dropped even on lines longer than the read buffer
+	input, _ = json.Marshal(map[string]any{"path": path, "offset": 2, "show_line_numbers": false})
+	output, _ = tool.Execute(context.Background(), input)
+	if result = parseReadResult(t, output); result.Content != long+"\nend" {
+		t.Errorf("expected the last two lines, got %d bytes", len(result.Content))
+	}
+
+	// A single line over the cap is returned in part
+	path2 := createTestFile(t, strings.Repeat("y", maxReadBytes+10)+"\nnext")
+	defer os.Remove(path2)
+	input, _ = json.Marshal(map[string]any{"path": path2, "show_line_numbers": false})
+	output, _ = tool.Execute(context.Background(), input)
+	result = parseReadResult(t, output)
+	if !result.Truncated || len(result.Content) != maxReadBytes || result.NextOffset != 1 {
+		t.Errorf("expected a partial first line, got %d bytes, truncated=%v next_offset=%d",
+			len(result.Content), result.Truncated, result.NextOffset)
+	}
+}
+
+func TestReadTool_Binary(t *testing.T) {
+	tool := NewReadTool()
+	path := createTestFile(t, "ELF\x00\x01\x02binary data")
+	defer os.Remove(path)
+
+	input, _ := json.Marshal(map[string]string{"path": path})
+	output, _ := tool.Execute(context.Background(), input)
+	var result readBinaryOutput
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("failed to parse output JSON: %v", err)
+	}
+	if result.Type != "binary" || result.Size != 17 || result.MIME != "application/octet-stream" || result.Attached {
+		t.Errorf("unexpected binary result: %s", output)
+	}
+}
+
+func TestReadTool_Image(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2)))
+	path := filepath.Join(t.TempDir(), "shot.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	input, _ := json.Marshal(map[string]string{"path": path})
+
+	// Without an image sink the file is only described
+	output, _ := NewReadTool().Execute(context.Background(), input)
+	var result readBinaryOutput
+	json.Unmarshal([]byte(output), &result)
+	if result.Type != "binary" || result.MIME != "image/png" || result.Attached {
+		t.Errorf("unexpected result without a sink: %s", output)
+	}
+
+	var images []Image
+	ctx := WithImageSink(context.Background(), func(img Image) {
+		images = append(images, img)
+	})
+	output, _ = NewReadTool().Execute(ctx, input)
+	result = readBinaryOutput{}
+	json.Unmarshal([]byte(output), &result)
+	if !result.Attached || result.Size != int64(buf.Len()) {
+		t.Errorf("expected the image to be attached, got %s", output)
+	}
+	if len(images) != 1 || images[0].MediaType != "image/png" || !bytes.Equal(images[0].Data, buf.Bytes()) {
+		t.Errorf("unexpected attached images: %d", len(images))
+	}
+}
+
+func TestReadTool_LineNumbers(t *testing.T) {
+	tool := NewReadTool()
+	var b strings.Builder
+	for i := 1; i <= 12; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	path := createTestFile(t, b.String())
+	defer os.Remove(path)
+
+	input, _ := json.Marshal(map[string]any{"path": path, "start_line": 9, "end_line": 10})
+	output, _ := tool.Execute(context.Background(), input)
+	content, _ := parseReadOutput(t, output)
+	if expected := "     9\tline 9\n    10\tline 10"; content != expected {
+		t.Errorf("expected content %q, got %q", expected, content)
+	}
+
+	input, _ = json.Marshal(map[string]any{"path": path, "start_line": 9, "end_line": 10, "show_line_numbers": false})
+	output, _ = tool.Execute(context.Background(), input)
+	content, _ = parseReadOutput(t, output)
+	if expected := "line 9\nline 10"; content != expected {
+		t.Errorf("expected content %q, got %q", expected, content)
+	}
+
+	// The prefix counts toward the byte cap
+	long := strings.Repeat("x", maxReadBytes)
+	path2 := createTestFile(t, long)
+	defer os.Remove(path2)
+	input, _ = json.Marshal(map[string]string{"path": path2})
+	output, _ = tool.Execute(context.Background(), input)
+	if content, _ = parseReadOutput(t, output); content != "     1\t"+long[:maxReadBytes-7] {
+		t.Errorf("expected a numbered partial line of %d bytes, got %d", maxReadBytes, len(content))
 	}
 }
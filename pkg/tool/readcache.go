@@ -0,0 +1,168 @@
+package tool
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ReadCache holds the content of files read by the read, grep, and tree
+// tools, so a file read again within a run is not read from disk. An entry
+// is used only while the file's size and modification time are unchanged.
+// The least recently used entries are evicted to keep the content held
+// within the cache's size, and larger files are not cached.
+type ReadCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	entries  map[string]*list.Element
+	// lru holds the *readCacheEntry values, most recently used first.
+	lru   *list.List
+	stats ReadCacheStats
+}
+
+// ReadCacheStats counts a ReadCache's lookups and the content it holds.
+type ReadCacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Bytes     int64 `json:"bytes"`
+	Entries   int   `json:"entries"`
+}
+
+// HitRate returns the fraction of lookups served from the cache, or 0
+// before any.
+func (s ReadCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// readCacheEntry is the content of one file as of its size and
+// modification time.
+type readCacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+// NewReadCache returns an empty ReadCache holding at most maxBytes of file
+// content.
+func NewReadCache(maxBytes int64) *ReadCache {
+	return &ReadCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// readCacheKey is the context key for the ReadCache.
+type readCacheKey struct{}
+
+// WithReadCache returns a copy of ctx in which the read, grep, and tree
+// tools read files through c. The harness keeps one cache per run.
+func WithReadCache(ctx context.Context, c *ReadCache) context.Context {
+	return context.WithValue(ctx, readCacheKey{}, c)
+}
+
+// ReadCacheFromContext returns the ReadCache in ctx, or nil if there is
+// none.
+func ReadCacheFromContext(ctx context.Context) *ReadCache {
+	c, _ := ctx.Value(readCacheKey{}).(*ReadCache)
+	return c
+}
+
+// Stats returns the cache's counts so far.
+func (c *ReadCache) Stats() ReadCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// open opens the file at path for reading, from the cache if it holds the
+// file's current content. A nil cache opens the file directly.
+func (c *ReadCache) open(path string) (io.ReadCloser, error) {
+	if c == nil {
+		return os.Open(path)
+	}
+	path = filepath.Clean(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return os.Open(path)
+	}
+	if data, ok := c.lookup(path, info); ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	if !info.Mode().IsRegular() || info.Size() > c.maxBytes {
+		return os.Open(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the content only if the file did not change while it was read
+	if after, err := os.Stat(path); err == nil && after.Size() == int64(len(data)) && after.Size() == info.Size() && after.ModTime().Equal(info.ModTime()) {
+		c.store(&readCacheEntry{path: path, size: info.Size(), modTime: info.ModTime(), data: data})
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// readFile reads the file at path, as os.ReadFile does, through the cache.
+func (c *ReadCache) readFile(path string) ([]byte, error) {
+	r, err := c.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// lookup returns the cached content of the file at path if its entry
+// matches info, dropping a stale entry.
+func (c *ReadCache) lookup(path string, info os.FileInfo) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[path]; ok {
+		entry := elem.Value.(*readCacheEntry)
+		if entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			return entry.data, true
+		}
+		c.remove(elem)
+	}
+	c.stats.Misses++
+	return nil, false
+}
+
+// store adds entry, replacing any for the same path and evicting the least
+// recently used entries to make room.
+func (c *ReadCache) store(entry *readCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[entry.path]; ok {
+		c.remove(elem)
+	}
+	for c.stats.Bytes+int64(len(entry.data)) > c.maxBytes && c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+	c.entries[entry.path] = c.lru.PushFront(entry)
+	c.stats.Bytes += int64(len(entry.data))
+	c.stats.Entries++
+}
+
+// remove drops an entry. The caller holds c.mu.
+func (c *ReadCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*readCacheEntry)
+	delete(c.entries, entry.path)
+	c.stats.Bytes -= int64(len(entry.data))
+	c.stats.Entries--
+}
@@ -0,0 +1,91 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCache_HitsAndInvalidation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	os.WriteFile(path, []byte("one"), 0644)
+	c := NewReadCache(1024)
+
+	for range 2 {
+		if data, err := c.readFile(path); err != nil || string(data) != "one" {
+			t.Fatalf("unexpected read: %q, %v", data, err)
+		}
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Bytes != 3 || stats.Entries != 1 {
+		t.Errorf("expected one hit and one miss, got %+v", stats)
+	}
+
+	// A new modification time invalidates the entry
+	os.WriteFile(path, []byte("two"), 0644)
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Hour))
+	if data, _ := c.readFile(path); string(data) != "two" {
+		t.Errorf("expected the new content, got %q", data)
+	}
+	if stats := c.Stats(); stats.Misses != 2 || stats.Entries != 1 || stats.HitRate() != 1.0/3 {
+		t.Errorf("expected a miss for the changed file, got %+v", stats)
+	}
+
+	// Missing files are not cached
+	if _, err := c.readFile(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+}
+
+func TestReadCache_MemoryCap(t *testing.T) {
+	dir := t.TempDir()
+	c := NewReadCache(10)
+	for _, name := range []string{"a", "b", "c"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("1234"), 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "big"), []byte("12345678901"), 0644)
+
+	c.readFile(filepath.Join(dir, "a"))
+	c.readFile(filepath.Join(dir, "b"))
+	c.readFile(filepath.Join(dir, "a")) // a is now the most recently used
+	c.readFile(filepath.Join(dir, "c")) // evicts b
+	if data, _ := c.readFile(filepath.Join(dir, "big")); string(data) != "12345678901" {
+		t.Errorf("expected a file over the cap to be read, got %q", data)
+	}
+
+	stats := c.Stats()
+	if stats.Bytes != 8 || stats.Entries != 2 || stats.Evictions != 1 {
+		t.Errorf("expected a and c within the cap, got %+v", stats)
+	}
+	c.readFile(filepath.Join(dir, "a"))
+	if c.Stats().Hits != 2 {
+		t.Errorf("expected a to be kept, got %+v", c.Stats())
+	}
+}
+
+func TestReadCache_SharedByTools(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0644)
+	c := NewReadCache(1 << 20)
+	ctx := WithReadCache(context.Background(), c)
+
+	readInput, _ := json.Marshal(map[string]string{"path": path})
+	for range 2 {
+		result, _ := NewReadTool().Execute(ctx, readInput)
+		if !strings.Contains(result, "func main()") {
+			t.Fatalf("unexpected read result: %s", result)
+		}
+	}
+	grepInput, _ := json.Marshal(map[string]string{"pattern": "func main", "path": dir})
+	if result, _ := NewGrepTool().Execute(ctx, grepInput); !strings.Contains(result, "main.go") {
+		t.Fatalf("unexpected grep result: %s", result)
+	}
+	if stats := c.Stats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("expected the later reads to hit, got %+v", stats)
+	}
+}
@@ -0,0 +1,192 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds tools by name and tracks which of them are enabled.
+// Tools are enabled when registered; order of registration is preserved.
+// It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	tools    map[string]Tool
+	order    []string
+	disabled map[string]bool
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		tools:    make(map[string]Tool),
+		disabled: make(map[string]bool),
+	}
+}
+
+// NewBuiltinRegistry creates a Registry holding the built-in tools.
+func NewBuiltinRegistry() *Registry {
+	r := NewRegistry()
+	for _, t := range Builtins() {
+		r.Register(t)
+	}
+	return r
+}
+
+// Builtins returns new instances of the built-in tools in their default order.
+func Builtins() []Tool {
+	return []Tool{
+		NewReadTool(),
+		NewListDirTool(),
+		NewStatTool(),
+		NewTreeTool(),
+		NewGrepTool(),
+		NewGlobTool(),
+		NewGoSymbolsTool(),
+		NewBashTool(),
+		NewWriteTool(),
+		NewEditTool(),
+		NewMultiEditTool(),
+		NewPatchTool(),
+		NewMoveTool(),
+		NewMkdirTool(),
+		NewTouchTool(),
+		NewDeleteTool(),
+		NewArchiveTool(),
+		NewGitStatusTool(),
+		NewGitDiffTool(),
+		NewGitLogTool(),
+		NewGitCommitTool(),
+		NewGitBranchTool(),
+		NewHTTPRequestTool(),
+		NewTodoTool(),
+		NewMemoryTool(),
+	}
+}
+
+// Register adds an enabled tool. It returns an error if the name is empty or
+// already registered.
+func (r *Registry) Register(t Tool) error {
+	name := t.Name()
+	if name == "" {
+		return errors.New("tool name is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[name]; ok {
+		return fmt.Errorf("tool %q already registered", name)
+	}
+	r.tools[name] = t
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Get returns the registered tool with the given name, enabled or not.
+func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// Enable enables the named tools.
+func (r *Registry) Enable(names ...string) error {
+	return r.setDisabled(names, false)
+}
+
+// Disable disables the named tools.
+func (r *Registry) Disable(names ...string) error {
+	return r.setDisabled(names, true)
+}
+
+// SetEnabled enables exactly the named tools and disables the rest. An empty
+// list enables every tool. Unknown names are an error and change nothing.
+func (r *Registry) SetEnabled(names []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkNames(names); err != nil {
+		return err
+	}
+	r.disabled = make(map[string]bool)
+	if len(names) == 0 {
+		return nil
+	}
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		enabled[name] = true
+	}
+	for _, name := range r.order {
+		if !enabled[name] {
+			r.disabled[name] = true
+		}
+	}
+	return nil
+}
+
+// IsEnabled reports whether the named tool is registered and enabled.
+func (r *Registry) IsEnabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.tools[name]
+	return ok && !r.disabled[name]
+}
+
+// Tools returns the enabled tools in registration order.
+func (r *Registry) Tools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var tools []Tool
+	for _, name := range r.order {
+		if !r.disabled[name] {
+			tools = append(tools, r.tools[name])
+		}
+	}
+	return tools
+}
+
+// All returns every registered tool in registration order.
+func (r *Registry) All() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tools := make([]Tool, len(r.order))
+	for i, name := range r.order {
+		tools[i] = r.tools[name]
+	}
+	return tools
+}
+
+// setDisabled marks the named tools as disabled or enabled.
+func (r *Registry) setDisabled(names []string, disabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkNames(names); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if disabled {
+			r.disabled[name] = true
+		} else {
+			delete(r.disabled, name)
+		}
+	}
+	return nil
+}
+
+// checkNames returns an error listing any names that are not registered.
+// The caller must hold r.mu.
+func (r *Registry) checkNames(names []string) error {
+	var unknown []string
+	for _, name := range names {
+		if _, ok := r.tools[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown tools: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
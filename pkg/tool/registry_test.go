@@ -0,0 +1,84 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+)
+
+// registryNames returns the names of tools.
+func registryNames(tools []Tool) string {
+	names := make([]string, len(tools))
+	for i, t := range tools {
+		names[i] = t.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRegistry_RegisterAndGet(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(NewReadTool()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Register(NewGrepTool()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Register(NewReadTool()); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("expected duplicate registration to fail, got %v", err)
+	}
+
+	if got, ok := r.Get("grep"); !ok || got.Name() != "grep" {
+		t.Errorf("expected to get grep, got %v %v", got, ok)
+	}
+	if _, ok := r.Get("missing"); ok {
+		t.Error("expected missing tool to be absent")
+	}
+	if got := registryNames(r.Tools()); got != "read,grep" {
+		t.Errorf("expected registration order read,grep, got %s", got)
+	}
+}
+
+func TestRegistry_EnableDisable(t *testing.T) {
+	r := NewBuiltinRegistry()
+	if got := registryNames(r.Tools()); got != registryNames(Builtins()) {
+		t.Fatalf("expected all builtins enabled, got %s", got)
+	}
+
+	if err := r.SetEnabled([]string{"bash", "read", "grep"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := registryNames(r.Tools()); got != "read,grep,bash" {
+		t.Errorf("expected read,grep,bash in registration order, got %s", got)
+	}
+	if len(r.All()) != len(Builtins()) || r.IsEnabled("write") {
+		t.Error("disabled tools should stay registered but not enabled")
+	}
+
+	if err := r.Disable("grep"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Enable("write"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := registryNames(r.Tools()); got != "read,bash,write" {
+		t.Errorf("expected read,bash,write, got %s", got)
+	}
+
+	// Unknown names are rejected without changing anything
+	err := r.SetEnabled([]string{"read", "nope", "bogus"})
+	if err == nil || err.Error() != "unknown tools: bogus, nope" {
+		t.Errorf("expected unknown tools error, got %v", err)
+	}
+	if got := registryNames(r.Tools()); got != "read,bash,write" {
+		t.Errorf("failed SetEnabled changed the enabled tools: %s", got)
+	}
+	if err := r.Disable("nope"); err == nil {
+		t.Error("expected disabling an unknown tool to fail")
+	}
+
+	if err := r.SetEnabled(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.Tools()) != len(Builtins()) {
+		t.Errorf("expected an empty list to enable every tool, got %s", registryNames(r.Tools()))
+	}
+}
@@ -0,0 +1,221 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// statMaxLineCountSize is the largest file whose lines stat will count.
+const statMaxLineCountSize = 64 * 1024 * 1024
+
+// statBinarySniffSize is how much of a file is checked for NUL bytes to
+// decide whether it is binary.
+const statBinarySniffSize = 8000
+
+// StatTool implements the Tool interface for inspecting file metadata.
+type StatTool struct{}
+
+// statInput defines the expected input parameters for the stat tool.
+type statInput struct {
+	Path string `json:"path"`
+}
+
+// statOutput defines the success response format.
+type statOutput struct {
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+	Type   string `json:"type,omitempty"`
+	Size   int64  `json:"size"`
+	Mode   string `json:"mode,omitempty"`
+	Mtime  string `json:"mtime,omitempty"`
+	// Lines is set for text files no larger than statMaxLineCountSize.
+	Lines  *int   `json:"lines,omitempty"`
+	Binary bool   `json:"binary,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+// statError defines the error response format.
+type statError struct {
+	Error string `json:"error"`
+}
+
+// NewStatTool creates a new StatTool instance.
+func NewStatTool() *StatTool {
+	return &StatTool{}
+}
+
+// Name returns the tool identifier.
+func (t *StatTool) Name() string {
+	return "stat"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *StatTool) Description() string {
+	return "Get a path's type, size, mode, modification time, and line count without reading it"
+}
+
+// ReadOnly reports that the tool has no side effects.
+func (t *StatTool) ReadOnly() bool {
+	return true
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *StatTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "description": "Path to the file or directory to inspect"}
+		},
+		"required": ["path"]
+	}`)
+}
+
+// Execute returns metadata for the specified path.
+func (t *StatTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params statInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatStatError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	if params.Path == "" {
+		return formatStatError("path is required"), nil
+	}
+
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatStatError(err.Error()), nil
+	}
+	absPath, err := filepath.Abs(resolved)
+	if err != nil {
+		return formatStatError("invalid path: " + err.Error()), nil
+	}
+
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		// A missing path is an answer, not a failure
+		if errors.Is(err, os.ErrNotExist) {
+			return formatStatSuccess(statOutput{Path: absPath}), nil
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return formatStatError(fmt.Sprintf("permission denied: cannot read %s", params.Path)), nil
+		}
+		return formatStatError(err.Error()), nil
+	}
+
+	output := statOutput{
+		Path:   absPath,
+		Exists: true,
+		Type:   statType(info.Mode()),
+		Size:   info.Size(),
+		Mode:   fmt.Sprintf("%04o", info.Mode().Perm()),
+		Mtime:  info.ModTime().UTC().Format(time.RFC3339),
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		output.Target, _ = os.Readlink(absPath)
+	case info.Mode().IsRegular() && info.Size() <= statMaxLineCountSize:
+		lines, binary, err := countFileLines(ctx, absPath)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return "", ctxErr
+			}
+			if errors.Is(err, os.ErrPermission) {
+				return formatStatError(fmt.Sprintf("permission denied: cannot read %s", params.Path)), nil
+			}
+			return formatStatError("failed to read file: " + err.Error()), nil
+		}
+		output.Binary = binary
+		if !binary {
+			output.Lines = &lines
+		}
+	}
+
+	return formatStatSuccess(output), nil
+}
+
+// statType names the type of a file mode.
+func statType(mode os.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return "file"
+	case mode.IsDir():
+		return "dir"
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	default:
+		return "other"
+	}
+}
+
+// countFileLines counts the lines in a file, counting a final line without a
+// trailing newline. It reports binary instead if the start of the file
+// contains a NUL byte.
+func countFileLines(ctx context.Context, path string) (lines int, binary bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 32*1024)
+	var read int64
+	var last byte
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, false, err
+		}
+		n, err := f.Read(buf)
+		if n > 0 {
+			chunk := buf[:n]
+			if read < statBinarySniffSize {
+				sniff := chunk
+				if int64(len(sniff)) > statBinarySniffSize-read {
+					sniff = sniff[:statBinarySniffSize-read]
+				}
+				if bytes.IndexByte(sniff, 0) >= 0 {
+					return 0, true, nil
+				}
+			}
+			lines += bytes.Count(chunk, []byte{'\n'})
+			last = chunk[n-1]
+			read += int64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	if read > 0 && last != '\n' {
+		lines++
+	}
+	return lines, false, nil
+}
+
+// formatStatSuccess formats a successful stat response.
+func formatStatSuccess(output statOutput) string {
+	data, _ := json.Marshal(output)
+	return string(data)
+}
+
+// formatStatError formats an error response.
+func formatStatError(msg string) string {
+	output := statError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
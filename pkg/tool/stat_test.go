@@ -0,0 +1,86 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func runStat(t *testing.T, path string) statOutput {
+	t.Helper()
+	data, _ := json.Marshal(map[string]string{"path": path})
+	result, err := NewStatTool().Execute(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if IsErrorResult(result) {
+		t.Fatalf("unexpected error result: %s", result)
+	}
+	var output statOutput
+	if err := json.Unmarshal([]byte(result), &output); err != nil {
+		t.Fatalf("failed to parse result: %v", err)
+	}
+	return output
+}
+
+func TestStatTool_File(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		lines   int
+	}{
+		{"empty", "", 0},
+		{"trailing newline", "a\nb\n", 2},
+		{"no trailing newline", "a\nb\nc", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			os.WriteFile(path, []byte(tt.content), 0640)
+			output := runStat(t, path)
+			if !output.Exists || output.Type != "file" || output.Size != int64(len(tt.content)) || output.Mode != "0640" {
+				t.Errorf("unexpected output %+v", output)
+			}
+			if output.Lines == nil || *output.Lines != tt.lines {
+				t.Errorf("expected %d lines, got %v", tt.lines, output.Lines)
+			}
+			if output.Mtime == "" {
+				t.Error("expected mtime")
+			}
+		})
+	}
+}
+
+func TestStatTool_Binary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blob")
+	os.WriteFile(path, []byte{'a', 0, '\n', 'b'}, 0644)
+	output := runStat(t, path)
+	if !output.Binary || output.Lines != nil {
+		t.Errorf("expected binary without line count, got %+v", output)
+	}
+}
+
+func TestStatTool_DirAndSymlink(t *testing.T) {
+	dir := t.TempDir()
+	if output := runStat(t, dir); output.Type != "dir" || output.Lines != nil {
+		t.Errorf("unexpected dir output %+v", output)
+	}
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink("target.txt", link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if output := runStat(t, link); output.Type != "symlink" || output.Target != "target.txt" {
+		t.Errorf("unexpected symlink output %+v", output)
+	}
+}
+
+func TestStatTool_Missing(t *testing.T) {
+	output := runStat(t, filepath.Join(t.TempDir(), "missing"))
+	if output.Exists || output.Type != "" {
+		t.Errorf("expected exists false, got %+v", output)
+	}
+}
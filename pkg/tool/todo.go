@@ -0,0 +1,288 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PlanEventType is the reserved event type the todo tool emits with the
+// updated task list. The harness delivers these events to plan handlers
+// rather than as custom tool events, so they are not subject to event type
+// filtering.
+const PlanEventType = "plan"
+
+// maxTodoItems caps the length of the task list.
+const maxTodoItems = 100
+
+// todoStatuses are the accepted item statuses.
+var todoStatuses = []string{"pending", "in_progress", "done"}
+
+// TodoItem is one task in the plan.
+type TodoItem struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	// Status is "pending", "in_progress", or "done".
+	Status string `json:"status"`
+}
+
+// TodoList is the task plan kept by the todo tool. The zero value is an
+// empty list. It is safe for concurrent use.
+type TodoList struct {
+	mu    sync.Mutex
+	items []TodoItem
+}
+
+// Items returns a copy of the tasks, never nil.
+func (l *TodoList) Items() []TodoItem {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]TodoItem{}, l.items...)
+}
+
+// Clear empties the list.
+func (l *TodoList) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items = nil
+}
+
+// update replaces the tasks with the result of fn, unless it fails, and
+// returns a copy of them.
+func (l *TodoList) update(fn func(items []TodoItem) ([]TodoItem, error)) ([]TodoItem, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	items, err := fn(append([]TodoItem{}, l.items...))
+	if err != nil {
+		return nil, err
+	}
+	l.items = items
+	return append([]TodoItem{}, items...), nil
+}
+
+// todoListKey is the context key for the TodoList.
+type todoListKey struct{}
+
+// WithTodoList returns a copy of ctx whose todo tool calls read and update
+// list. The harness keeps one list per conversation.
+func WithTodoList(ctx context.Context, list *TodoList) context.Context {
+	return context.WithValue(ctx, todoListKey{}, list)
+}
+
+// TodoTool implements the Tool interface for keeping a task plan.
+type TodoTool struct {
+	// list is used when the context carries none
+	list TodoList
+}
+
+// todoInput defines the expected input parameters for the todo tool.
+type todoInput struct {
+	Todos   []TodoItem   `json:"todos"`
+	Updates []todoUpdate `json:"updates"`
+}
+
+// todoUpdate changes one existing item; empty fields are left as they are.
+type todoUpdate struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+// todoOutput defines the success response format.
+type todoOutput struct {
+	Todos  []TodoItem     `json:"todos"`
+	Counts map[string]int `json:"counts"`
+}
+
+// todoError defines the error response format.
+type todoError struct {
+	Error string `json:"error"`
+}
+
+// NewTodoTool creates a new TodoTool instance.
+func NewTodoTool() *TodoTool {
+	return &TodoTool{}
+}
+
+// Name returns the tool identifier.
+func (t *TodoTool) Name() string {
+	return "todo"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *TodoTool) Description() string {
+	return "Keep a task plan for multi-step work, shown to the user as your progress. Pass todos to write the whole list, or updates to change the status or text of items by id; pass neither to read it. Statuses: pending, in_progress, done. Mark a task in_progress before starting it and done as soon as it is finished"
+}
+
+// ReadOnly reports that the tool has no side effects: it changes only the
+// plan, not files.
+func (t *TodoTool) ReadOnly() bool {
+	return true
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *TodoTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"todos": {
+				"type": "array",
+				"description": "The complete task list, replacing the current one",
+				"items": {
+					"type": "object",
+					"properties": {
+						"id": {"type": "string", "description": "Stable identifier (default: the item's position, from 1)"},
+						"content": {"type": "string", "description": "What the task is"},
+						"status": {"type": "string", "enum": ["pending", "in_progress", "done"], "description": "Task status (default pending)"}
+					},
+					"required": ["content"]
+				}
+			},
+			"updates": {
+				"type": "array",
+				"description": "Changes to existing tasks",
+				"items": {
+					"type": "object",
+					"properties": {
+						"id": {"type": "string", "description": "Task to change"},
+						"content": {"type": "string", "description": "New text (default: unchanged)"},
+						"status": {"type": "string", "enum": ["pending", "in_progress", "done"], "description": "New status (default: unchanged)"}
+					},
+					"required": ["id"]
+				}
+			}
+		}
+	}`)
+}
+
+// Execute replaces or updates the task list and returns it.
+func (t *TodoTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params todoInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatTodoError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	if params.Todos != nil && params.Updates != nil {
+		return formatTodoError("todos and updates cannot be combined"), nil
+	}
+	list, ok := ctx.Value(todoListKey{}).(*TodoList)
+	if !ok || list == nil {
+		list = &t.list
+	}
+
+	var items []TodoItem
+	var err error
+	switch {
+	case params.Todos != nil:
+		items, err = list.update(func([]TodoItem) ([]TodoItem, error) {
+			return newTodos(params.Todos)
+		})
+	case params.Updates != nil:
+		items, err = list.update(func(items []TodoItem) ([]TodoItem, error) {
+			return applyTodoUpdates(items, params.Updates)
+		})
+	default:
+		return formatTodoSuccess(list.Items()), nil
+	}
+	if err != nil {
+		return formatTodoError(err.Error()), nil
+	}
+
+	Emit(ctx, PlanEventType, items)
+	return formatTodoSuccess(items), nil
+}
+
+// newTodos validates a complete task list, filling in default IDs and
+// statuses.
+func newTodos(todos []TodoItem) ([]TodoItem, error) {
+	if len(todos) > maxTodoItems {
+		return nil, fmt.Errorf("too many todos: %d (maximum %d)", len(todos), maxTodoItems)
+	}
+	items := make([]TodoItem, len(todos))
+	seen := make(map[string]bool, len(todos))
+	for i, item := range todos {
+		item.Content = strings.TrimSpace(item.Content)
+		if item.Content == "" {
+			return nil, fmt.Errorf("todo %d: content is required", i+1)
+		}
+		if item.ID == "" {
+			item.ID = strconv.Itoa(i + 1)
+		}
+		if seen[item.ID] {
+			return nil, fmt.Errorf("todo %d: duplicate id %q", i+1, item.ID)
+		}
+		seen[item.ID] = true
+		if item.Status == "" {
+			item.Status = "pending"
+		}
+		if err := checkTodoStatus(item.Status); err != nil {
+			return nil, fmt.Errorf("todo %d: %w", i+1, err)
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// applyTodoUpdates applies updates to items, failing without changes if any
+// update is invalid.
+func applyTodoUpdates(items []TodoItem, updates []todoUpdate) ([]TodoItem, error) {
+	if len(updates) == 0 {
+		return nil, errors.New("no updates provided")
+	}
+	for i, u := range updates {
+		idx := slices.IndexFunc(items, func(item TodoItem) bool { return item.ID == u.ID })
+		if idx < 0 {
+			return nil, fmt.Errorf("update %d: no todo with id %q", i+1, u.ID)
+		}
+		if u.Status != "" {
+			if err := checkTodoStatus(u.Status); err != nil {
+				return nil, fmt.Errorf("update %d: %w", i+1, err)
+			}
+			items[idx].Status = u.Status
+		}
+		if content := strings.TrimSpace(u.Content); content != "" {
+			items[idx].Content = content
+		}
+	}
+	return items, nil
+}
+
+// checkTodoStatus returns an error unless status is a known status.
+func checkTodoStatus(status string) error {
+	if !slices.Contains(todoStatuses, status) {
+		return fmt.Errorf("invalid status %q: must be one of %s", status, strings.Join(todoStatuses, ", "))
+	}
+	return nil
+}
+
+// formatTodoSuccess formats a successful todo response.
+func formatTodoSuccess(items []TodoItem) string {
+	output := todoOutput{Todos: items, Counts: make(map[string]int, len(todoStatuses))}
+	for _, status := range todoStatuses {
+		output.Counts[status] = 0
+	}
+	for _, item := range items {
+		output.Counts[item.Status]++
+	}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
+
+// formatTodoError formats an error response.
+func formatTodoError(msg string) string {
+	output := todoError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
@@ -0,0 +1,110 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTodoTool(t *testing.T) {
+	var list TodoList
+	var emitted []any
+	ctx := WithTodoList(context.Background(), &list)
+	ctx = WithEmitter(ctx, EmitterFunc(func(eventType string, payload any) {
+		if eventType == PlanEventType {
+			emitted = append(emitted, payload)
+		}
+	}))
+	tl := NewTodoTool()
+
+	execute := func(input string) todoOutput {
+		t.Helper()
+		result, err := tl.Execute(ctx, json.RawMessage(input))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if IsErrorResult(result) {
+			t.Fatalf("unexpected error result: %s", result)
+		}
+		var out todoOutput
+		json.Unmarshal([]byte(result), &out)
+		return out
+	}
+
+	out := execute(`{"todos": [{"content": "Read the code"}, {"id": "tests", "content": " Add tests ", "status": "in_progress"}]}`)
+	want := []TodoItem{
+		{ID: "1", Content: "Read the code", Status: "pending"},
+		{ID: "tests", Content: "Add tests", Status: "in_progress"},
+	}
+	if !reflect.DeepEqual(out.Todos, want) || !reflect.DeepEqual(list.Items(), want) {
+		t.Errorf("unexpected todos: %+v", out.Todos)
+	}
+	if out.Counts["pending"] != 1 || out.Counts["in_progress"] != 1 || out.Counts["done"] != 0 {
+		t.Errorf("unexpected counts: %v", out.Counts)
+	}
+
+	out = execute(`{"updates": [{"id": "tests", "status": "done"}, {"id": "1", "content": "Read all the code"}]}`)
+	want[0].Content = "Read all the code"
+	want[1].Status = "done"
+	if !reflect.DeepEqual(out.Todos, want) {
+		t.Errorf("unexpected todos after update: %+v", out.Todos)
+	}
+
+	// Reading does not emit a plan
+	if out = execute(`{}`); !reflect.DeepEqual(out.Todos, want) {
+		t.Errorf("unexpected todos on read: %+v", out.Todos)
+	}
+	if len(emitted) != 2 || !reflect.DeepEqual(emitted[1], want) {
+		t.Errorf("expected 2 plan events, got %+v", emitted)
+	}
+
+	// Without a list in the context, the tool keeps its own
+	fallback := NewTodoTool()
+	fallback.Execute(context.Background(), json.RawMessage(`{"todos": [{"content": "x"}]}`))
+	if result, _ := fallback.Execute(context.Background(), json.RawMessage(`{}`)); !strings.Contains(result, `"content":"x"`) {
+		t.Errorf("expected the fallback list, got %s", result)
+	}
+}
+
+func TestTodoTool_Errors(t *testing.T) {
+	var list TodoList
+	list.update(func([]TodoItem) ([]TodoItem, error) {
+		return []TodoItem{{ID: "1", Content: "a", Status: "pending"}}, nil
+	})
+	ctx := WithTodoList(context.Background(), &list)
+
+	tests := []struct {
+		name   string
+		input  string
+		errMsg string
+	}{
+		{"combined", `{"todos": [], "updates": []}`, "todos and updates cannot be combined"},
+		{"missing content", `{"todos": [{"content": " "}]}`, "todo 1: content is required"},
+		{"duplicate id", `{"todos": [{"content": "a"}, {"id": "1", "content": "b"}]}`, `todo 2: duplicate id "1"`},
+		{"invalid status", `{"todos": [{"content": "a", "status": "blocked"}]}`, `invalid status "blocked"`},
+		{"too many", `{"todos": [` + strings.Repeat(`{"content": "a"},`, maxTodoItems) + `{"content": "a"}]}`, "too many todos"},
+		{"no updates", `{"updates": []}`, "no updates provided"},
+		{"unknown id", `{"updates": [{"id": "1", "status": "done"}, {"id": "9", "status": "done"}]}`, `update 2: no todo with id "9"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewTodoTool().Execute(ctx, json.RawMessage(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var out todoError
+			json.Unmarshal([]byte(result), &out)
+			if !strings.Contains(out.Error, tt.errMsg) {
+				t.Errorf("expected error containing %q, got %s", tt.errMsg, result)
+			}
+		})
+	}
+
+	// Failed calls leave the list unchanged
+	if items := list.Items(); len(items) != 1 || items[0].Status != "pending" {
+		t.Errorf("list changed by failed calls: %+v", items)
+	}
+}
@@ -1,32 +1,119 @@
 // Package tool defines the interface and utilities for implementing tools
 // that can be used by the Harness AI agent.
+//
+// The interface types are aliases of those in package toolapi, the
+// dependency-free contract intended for third-party tool authors. Tools
+// written against either package are interchangeable.
 package tool
 
 import (
 	"context"
-	"encoding/json"
+
+	"github.com/user/harness/pkg/toolapi"
 )
 
 // Tool defines the interface that all tools must implement to be usable
-// by the Harness agent. Tools are independent units that can be called
-// by the AI to perform specific operations like reading files, listing
-// directories, or searching with grep.
-type Tool interface {
-	// Name returns the unique identifier for this tool.
-	// This name is used by the AI to invoke the tool.
-	Name() string
-
-	// Description returns a human-readable description of what the tool does.
-	// This helps the AI understand when to use the tool.
-	Description() string
-
-	// InputSchema returns the JSON Schema defining the expected input parameters.
-	// The schema is used for validation and to inform the AI of expected inputs.
-	InputSchema() json.RawMessage
-
-	// Execute runs the tool with the given input and returns the result.
-	// The input is JSON that conforms to InputSchema.
-	// Returns the tool output as a string (JSON formatted for structured output).
-	// Returns an error if the tool execution fails.
-	Execute(ctx context.Context, input json.RawMessage) (string, error)
+// by the Harness agent. See toolapi.Tool.
+type Tool = toolapi.Tool
+
+// ReadOnlyTool is an optional interface for tools that never modify the
+// filesystem or environment. See toolapi.ReadOnlyTool.
+type ReadOnlyTool = toolapi.ReadOnlyTool
+
+// Display is a client-facing rendering hint attached to a tool result.
+// See toolapi.Display.
+type Display = toolapi.Display
+
+// DisplayTool is an optional interface for tools that attach a Display to
+// their result. See toolapi.DisplayTool.
+type DisplayTool = toolapi.DisplayTool
+
+// Releaser is an optional interface for tools that hold heavyweight resources.
+// See toolapi.Releaser.
+type Releaser = toolapi.Releaser
+
+// TimeoutProvider is an optional interface for tools that override the
+// harness's execution time limit. See toolapi.TimeoutProvider.
+type TimeoutProvider = toolapi.TimeoutProvider
+
+// PathReporter is an optional interface for tools that change files.
+// See toolapi.PathReporter.
+type PathReporter = toolapi.PathReporter
+
+// IsReadOnly reports whether t declares itself read-only.
+func IsReadOnly(t Tool) bool {
+	return toolapi.IsReadOnly(t)
+}
+
+// IsErrorResult reports whether a tool result string is a structured error
+// response, i.e. a JSON object with a top-level "error" field.
+func IsErrorResult(result string) bool {
+	return toolapi.IsErrorResult(result)
+}
+
+// WithWorkspace returns a copy of ctx that jails tool paths to root.
+func WithWorkspace(ctx context.Context, root string) context.Context {
+	return toolapi.WithWorkspace(ctx, root)
+}
+
+// Workspace returns the workspace root in ctx, or "" if tools are not jailed.
+func Workspace(ctx context.Context) string {
+	return toolapi.Workspace(ctx)
+}
+
+// ResolvePath maps a tool path argument into the workspace in ctx.
+// See toolapi.ResolvePath.
+func ResolvePath(ctx context.Context, path string) (string, error) {
+	return toolapi.ResolvePath(ctx, path)
+}
+
+// WithTempDir returns a copy of ctx carrying dir as the run's temporary directory.
+// See toolapi.WithTempDir.
+func WithTempDir(ctx context.Context, dir string) context.Context {
+	return toolapi.WithTempDir(ctx, dir)
+}
+
+// TempDir returns the run's temporary directory in ctx, or "" if none.
+func TempDir(ctx context.Context) string {
+	return toolapi.TempDir(ctx)
+}
+
+// KeepTempDir marks the run's temporary directory in ctx to survive the run.
+func KeepTempDir(ctx context.Context) {
+	toolapi.KeepTempDir(ctx)
+}
+
+// TempDirKept reports whether KeepTempDir was called for the run in ctx.
+func TempDirKept(ctx context.Context) bool {
+	return toolapi.TempDirKept(ctx)
+}
+
+// DefaultIgnorePatterns are the global ignore patterns used by recursive
+// tools unless the context overrides them. See toolapi.DefaultIgnorePatterns.
+var DefaultIgnorePatterns = toolapi.DefaultIgnorePatterns
+
+// WithIgnorePatterns returns a copy of ctx carrying the global ignore patterns.
+// See toolapi.WithIgnorePatterns.
+func WithIgnorePatterns(ctx context.Context, patterns []string) context.Context {
+	return toolapi.WithIgnorePatterns(ctx, patterns)
+}
+
+// IgnorePatterns returns the global ignore patterns in ctx.
+func IgnorePatterns(ctx context.Context) []string {
+	return toolapi.IgnorePatterns(ctx)
+}
+
+// Image is an image a tool attaches to its result. See toolapi.Image.
+type Image = toolapi.Image
+
+// WithImageSink returns a copy of ctx through which tools can attach images.
+// See toolapi.WithImageSink.
+func WithImageSink(ctx context.Context, sink func(Image)) context.Context {
+	return toolapi.WithImageSink(ctx, sink)
+}
+
+// AttachImage attaches img to the running tool call's result, reporting
+// whether ctx accepts images. See toolapi.AttachImage.
+func AttachImage(ctx context.Context, img Image) bool {
+	return toolapi.AttachImage(ctx, img)
 }
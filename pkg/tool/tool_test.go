@@ -0,0 +1,36 @@
+package tool
+
+import "testing"
+
+func TestIsErrorResult(t *testing.T) {
+	tests := []struct {
+		result string
+		want   bool
+	}{
+		{`{"error":"file not found"}`, true},
+		{`{"content":"hello"}`, false},
+		{`{"stdout":"","stderr":"error: x","exitCode":1}`, false},
+		{`not json`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := IsErrorResult(tt.result); got != tt.want {
+			t.Errorf("IsErrorResult(%q) = %v, want %v", tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestIsReadOnly(t *testing.T) {
+	readOnly := []Tool{NewReadTool(), NewListDirTool(), NewStatTool(), NewTreeTool(), NewGrepTool(), NewGitStatusTool(), NewGitDiffTool(), NewGitLogTool(), NewTodoTool()}
+	for _, tl := range readOnly {
+		if !IsReadOnly(tl) {
+			t.Errorf("%s should be read-only", tl.Name())
+		}
+	}
+	mutating := []Tool{NewBashTool(), NewWriteTool(), NewEditTool(), NewMultiEditTool(), NewMoveTool(), NewMkdirTool(), NewTouchTool(), NewDeleteTool(), NewGitCommitTool(), NewGitBranchTool(), NewHTTPRequestTool(), NewMemoryTool()}
+	for _, tl := range mutating {
+		if IsReadOnly(tl) {
+			t.Errorf("%s should not be read-only", tl.Name())
+		}
+	}
+}
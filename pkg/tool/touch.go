@@ -0,0 +1,150 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TouchTool implements the Tool interface for creating empty files and
+// updating modification times.
+type TouchTool struct{}
+
+// touchInput defines the expected input parameters for the touch tool.
+type touchInput struct {
+	Path    string `json:"path"`
+	Parents bool   `json:"parents"`
+	Mode    string `json:"mode,omitempty"`
+}
+
+// touchOutput defines the success response format.
+type touchOutput struct {
+	Path    string `json:"path"`
+	Created bool   `json:"created"`
+}
+
+// touchError defines the error response format.
+type touchError struct {
+	Error string `json:"error"`
+}
+
+// NewTouchTool creates a new TouchTool instance.
+func NewTouchTool() *TouchTool {
+	return &TouchTool{}
+}
+
+// Name returns the tool identifier.
+func (t *TouchTool) Name() string {
+	return "touch"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *TouchTool) Description() string {
+	return "Create an empty file, or update the modification time of an existing one"
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *TouchTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "description": "Path of the file to create or touch"},
+			"parents": {"type": "boolean", "description": "Create missing parent directories (default false)"},
+			"mode": {"type": "string", "description": "Octal permissions for a new file, e.g. \"0755\" (default \"0644\")"}
+		},
+		"required": ["path"]
+	}`)
+}
+
+// Execute creates the specified file if missing, or updates its times.
+func (t *TouchTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params touchInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatTouchError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	if params.Path == "" {
+		return formatTouchError("path is required"), nil
+	}
+
+	mode := os.FileMode(defaultFilePermissions)
+	if params.Mode != "" {
+		m, err := parseFileMode(params.Mode)
+		if err != nil {
+			return formatTouchError(err.Error()), nil
+		}
+		mode = m
+	}
+
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatTouchError(err.Error()), nil
+	}
+	absPath, err := filepath.Abs(resolved)
+	if err != nil {
+		return formatTouchError("invalid path: " + err.Error()), nil
+	}
+
+	// Existing files keep their content and mode
+	if _, err := os.Stat(absPath); err == nil {
+		now := time.Now()
+		if err := os.Chtimes(absPath, now, now); err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				return formatTouchError(fmt.Sprintf("permission denied: cannot write to %s", params.Path)), nil
+			}
+			return formatTouchError("failed to update times: " + err.Error()), nil
+		}
+		return formatTouchSuccess(absPath, false), nil
+	}
+
+	if params.Parents {
+		if err := os.MkdirAll(filepath.Dir(absPath), defaultDirPermissions); err != nil {
+			return formatTouchError(mkdirErrorMessage(err, filepath.Dir(params.Path))), nil
+		}
+	}
+	f, err := os.OpenFile(absPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return formatTouchError(fmt.Sprintf("parent directory not found: %s (set parents: true to create it)", filepath.Dir(params.Path))), nil
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return formatTouchError(fmt.Sprintf("permission denied: cannot write to %s", params.Path)), nil
+		}
+		return formatTouchError("failed to create file: " + err.Error()), nil
+	}
+	if err := f.Close(); err != nil {
+		return formatTouchError("failed to create file: " + err.Error()), nil
+	}
+	// OpenFile is subject to the umask; apply an explicit mode exactly
+	if params.Mode != "" {
+		if err := os.Chmod(absPath, mode); err != nil {
+			return formatTouchError("failed to set mode: " + err.Error()), nil
+		}
+	}
+
+	return formatTouchSuccess(absPath, true), nil
+}
+
+// formatTouchSuccess formats a successful touch response.
+func formatTouchSuccess(path string, created bool) string {
+	data, _ := json.Marshal(touchOutput{Path: path, Created: created})
+	return string(data)
+}
+
+// formatTouchError formats an error response.
+func formatTouchError(msg string) string {
+	output := touchError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
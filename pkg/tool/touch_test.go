@@ -0,0 +1,82 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runTouch(t *testing.T, input map[string]any) (touchOutput, string) {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	result, err := NewTouchTool().Execute(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var errResp touchError
+	json.Unmarshal([]byte(result), &errResp)
+	var output touchOutput
+	json.Unmarshal([]byte(result), &output)
+	return output, errResp.Error
+}
+
+func TestTouchTool_CreatesEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	output, errMsg := runTouch(t, map[string]any{"path": path})
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if !output.Created || output.Path != path {
+		t.Errorf("unexpected output %+v", output)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != 0 {
+		t.Fatalf("expected empty file, got %v %v", info, err)
+	}
+}
+
+func TestTouchTool_ExistingFileKeepsContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(path, []byte("keep"), 0644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(path, old, old)
+
+	output, errMsg := runTouch(t, map[string]any{"path": path})
+	if errMsg != "" || output.Created {
+		t.Fatalf("unexpected result %+v %q", output, errMsg)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != "keep" {
+		t.Errorf("content changed to %q", content)
+	}
+	if info, _ := os.Stat(path); !info.ModTime().After(old) {
+		t.Error("modification time should be updated")
+	}
+}
+
+func TestTouchTool_Parents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cmd", "app", "main.go")
+	if _, errMsg := runTouch(t, map[string]any{"path": path}); !strings.Contains(errMsg, "parent directory not found") {
+		t.Errorf("expected missing parent error, got %q", errMsg)
+	}
+	if _, errMsg := runTouch(t, map[string]any{"path": path, "parents": true}); errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error("file should exist")
+	}
+}
+
+func TestTouchTool_Mode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.sh")
+	if _, errMsg := runTouch(t, map[string]any{"path": path, "mode": "0755"}); errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0755 {
+		t.Errorf("expected mode 0755, got %v", info.Mode().Perm())
+	}
+}
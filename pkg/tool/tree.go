@@ -0,0 +1,218 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// defaultTreeDepth is how many levels below the root tree descends
+	// unless told otherwise.
+	defaultTreeDepth = 3
+	// maxTreeDepth caps the requested depth.
+	maxTreeDepth = 10
+	// maxTreeEntries caps the number of nodes in one result.
+	maxTreeEntries = 1000
+)
+
+// TreeTool implements the Tool interface for listing a directory tree.
+type TreeTool struct{}
+
+// treeInput defines the expected input parameters for the tree tool.
+type treeInput struct {
+	Path     string `json:"path"`
+	Depth    int    `json:"depth"`
+	NoIgnore bool   `json:"no_ignore"`
+}
+
+// treeNode is one file or directory in the tree.
+type treeNode struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Size is set for files.
+	Size *int64 `json:"size,omitempty"`
+	// Children is set for directories above the depth limit.
+	Children []*treeNode `json:"children,omitempty"`
+}
+
+// treeOutput defines the success response format.
+type treeOutput struct {
+	Tree      *treeNode `json:"tree"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// treeError defines the error response format.
+type treeError struct {
+	Error string `json:"error"`
+}
+
+// NewTreeTool creates a new TreeTool instance.
+func NewTreeTool() *TreeTool {
+	return &TreeTool{}
+}
+
+// Name returns the tool identifier.
+func (t *TreeTool) Name() string {
+	return "tree"
+}
+
+// Description returns a human-readable description of the tool.
+func (t *TreeTool) Description() string {
+	return "Get a directory tree as JSON, skipping .git, dependency directories, and ignored paths. Use this to orient yourself in a repository"
+}
+
+// ReadOnly reports that the tool has no side effects.
+func (t *TreeTool) ReadOnly() bool {
+	return true
+}
+
+// InputSchema returns the JSON Schema for the tool's input parameters.
+func (t *TreeTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"path": {"type": "string", "description": "Directory to list (default: current directory)"},
+			"depth": {"type": "integer", "description": "Levels to descend below path, 1-10 (default 3)"},
+			"no_ignore": {"type": "boolean", "description": "Include paths excluded by .gitignore, .ignore, and the global ignore list (default false)"}
+		}
+	}`)
+}
+
+// Execute lists the directory tree rooted at the specified path.
+func (t *TreeTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
+	var params treeInput
+	if err := json.Unmarshal(input, &params); err != nil {
+		return formatTreeError("invalid input: " + err.Error()), nil
+	}
+
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	if params.Path == "" {
+		params.Path = "."
+	}
+	if params.Depth <= 0 {
+		params.Depth = defaultTreeDepth
+	}
+	if params.Depth > maxTreeDepth {
+		params.Depth = maxTreeDepth
+	}
+
+	// Resolve path within the workspace, if any
+	root, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatTreeError(err.Error()), nil
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return formatTreeError("invalid path: " + err.Error()), nil
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return formatTreeError("path not found"), nil
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return formatTreeError("permission denied"), nil
+		}
+		return formatTreeError(err.Error()), nil
+	}
+	if !info.IsDir() {
+		return formatTreeError("not a directory"), nil
+	}
+
+	w := &treeWalker{maxDepth: params.Depth}
+	if !params.NoIgnore {
+		w.ignore = newIgnoreMatcher(ctx, root)
+	}
+	tree := &treeNode{Name: filepath.Base(root), Type: "dir"}
+	if err := w.walk(ctx, tree, root, 1); err != nil {
+		return "", err
+	}
+
+	data, _ := json.Marshal(treeOutput{Tree: tree, Truncated: w.truncated})
+	return string(data), nil
+}
+
+// treeWalker builds a treeNode hierarchy breadth-first within each
+// directory, stopping at maxDepth levels or maxTreeEntries nodes.
+type treeWalker struct {
+	maxDepth  int
+	ignore    *ignoreMatcher // nil to include ignored paths
+	count     int
+	truncated bool
+}
+
+// walk fills in the children of dir, found at path, at the given depth. It
+// returns only context errors; unreadable directories are left empty.
+func (w *treeWalker) walk(ctx context.Context, dir *treeNode, path string, depth int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if w.ignore != nil {
+		w.ignore.enter(path)
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	// Directories first, then files, each by name
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].IsDir() && !entries[j].IsDir()
+	})
+
+	var subdirs []*treeNode
+	var subdirPaths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		childPath := filepath.Join(path, name)
+		if entry.IsDir() && name == ".git" {
+			continue
+		}
+		if w.ignore != nil && w.ignore.ignored(childPath, entry.IsDir()) {
+			continue
+		}
+		if w.count == maxTreeEntries {
+			w.truncated = true
+			break
+		}
+		w.count++
+
+		node := &treeNode{Name: name, Type: statType(entry.Type())}
+		switch {
+		case entry.IsDir():
+			if depth < w.maxDepth {
+				subdirs = append(subdirs, node)
+				subdirPaths = append(subdirPaths, childPath)
+			}
+		case entry.Type().IsRegular():
+			if fi, err := entry.Info(); err == nil {
+				size := fi.Size()
+				node.Size = &size
+			}
+		}
+		dir.Children = append(dir.Children, node)
+	}
+
+	for i, sub := range subdirs {
+		if err := w.walk(ctx, sub, subdirPaths[i], depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatTreeError formats an error response.
+func formatTreeError(msg string) string {
+	output := treeError{Error: msg}
+	data, _ := json.Marshal(output)
+	return string(data)
+}
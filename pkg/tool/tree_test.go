@@ -0,0 +1,133 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runTree(t *testing.T, input map[string]any) treeOutput {
+	t.Helper()
+	data, _ := json.Marshal(input)
+	result, err := NewTreeTool().Execute(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if IsErrorResult(result) {
+		t.Fatalf("unexpected error result: %s", result)
+	}
+	var output treeOutput
+	if err := json.Unmarshal([]byte(result), &output); err != nil {
+		t.Fatalf("failed to parse result: %v", err)
+	}
+	return output
+}
+
+// flattenTree returns the paths in a tree, with directories suffixed by /.
+func flattenTree(node *treeNode, prefix string) []string {
+	var paths []string
+	for _, child := range node.Children {
+		p := prefix + child.Name
+		if child.Type == "dir" {
+			p += "/"
+		}
+		paths = append(paths, p)
+		paths = append(paths, flattenTree(child, p)...)
+	}
+	return paths
+}
+
+func writeTreeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTreeTool_Structure(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{
+		"main.go":         "package main",
+		"pkg/a/a.go":      "package a",
+		"pkg/a/deep/x.go": "package deep",
+		".git/HEAD":       "ref",
+	})
+
+	output := runTree(t, map[string]any{"path": root, "depth": 2})
+	got := strings.Join(flattenTree(output.Tree, ""), ",")
+	if want := "pkg/,pkg/a/,main.go"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if output.Tree.Type != "dir" || output.Tree.Name != filepath.Base(root) {
+		t.Errorf("unexpected root %+v", output.Tree)
+	}
+	main := output.Tree.Children[1]
+	if main.Type != "file" || main.Size == nil || *main.Size != int64(len("package main")) {
+		t.Errorf("unexpected file node %+v", main)
+	}
+}
+
+func TestTreeTool_Gitignore(t *testing.T) {
+	root := t.TempDir()
+	writeTreeFiles(t, root, map[string]string{
+		".gitignore":          "*.log\nbuild/\n/top.txt\n!keep.log\n",
+		"app.log":             "",
+		"keep.log":            "",
+		"top.txt":             "",
+		"build/out":           "",
+		"src/top.txt":         "",
+		"src/debug.log":       "",
+		"src/.gitignore":      "gen/\n",
+		"src/gen/code.go":     "",
+		"other/gen/code.go":   "",
+		"src/build.go":        "",
+		"src/nested/build/x":  "",
+		"src/nested/notes.md": "",
+	})
+
+	output := runTree(t, map[string]any{"path": root, "depth": 5})
+	got := flattenTree(output.Tree, "")
+	want := []string{
+		"other/", "other/gen/", "other/gen/code.go",
+		"src/", "src/nested/", "src/nested/notes.md",
+		"src/.gitignore", "src/build.go", "src/top.txt",
+		".gitignore", "keep.log",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	all := runTree(t, map[string]any{"path": root, "depth": 5, "no_ignore": true})
+	if n := len(flattenTree(all.Tree, "")); n <= len(want) {
+		t.Errorf("no_ignore should include ignored paths, got %d entries", n)
+	}
+}
+
+func TestTreeTool_Truncated(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < maxTreeEntries+5; i++ {
+		os.WriteFile(filepath.Join(root, fmt.Sprintf("f%04d", i)), nil, 0644)
+	}
+	output := runTree(t, map[string]any{"path": root})
+	if !output.Truncated || len(output.Tree.Children) != maxTreeEntries {
+		t.Errorf("expected %d entries and truncated, got %d truncated=%v", maxTreeEntries, len(output.Tree.Children), output.Truncated)
+	}
+}
+
+func TestTreeTool_NotADirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(path, nil, 0644)
+	data, _ := json.Marshal(map[string]any{"path": path})
+	result, _ := NewTreeTool().Execute(context.Background(), data)
+	if !strings.Contains(result, "not a directory") {
+		t.Errorf("expected not a directory error, got %s", result)
+	}
+}
@@ -2,10 +2,15 @@ package tool
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const (
@@ -13,6 +18,8 @@ const (
 	defaultFilePermissions = 0644
 	// defaultDirPermissions is the permission mode for new directories.
 	defaultDirPermissions = 0755
+	// maxWriteDiffFileSize is the largest file whose overwrite is diffed.
+	maxWriteDiffFileSize = 4 << 20
 )
 
 // WriteTool implements the Tool interface for writing file contents.
@@ -23,12 +30,31 @@ type writeInput struct {
 	Path    string `json:"path"`
 	Content string `json:"content"`
 	Mode    string `json:"mode,omitempty"`
+	// BaseHash guards overwrites: the write fails with a conflict unless
+	// the file's current content has this hex SHA-256 digest.
+	BaseHash string `json:"base_hash,omitempty"`
+	// ExpectedSHA256 is the former name of BaseHash, still accepted.
+	ExpectedSHA256 string `json:"expected_sha256,omitempty"`
+	// Diff adds a unified diff of an overwrite to its changes.
+	Diff bool `json:"diff,omitempty"`
 }
 
 // writeOutput defines the success response format.
 type writeOutput struct {
 	BytesWritten int    `json:"bytesWritten"`
 	Path         string `json:"path"`
+	// SHA256 is the hex digest of the file's content after the write.
+	SHA256 string `json:"sha256"`
+	// Changes summarizes what an overwrite of an existing file changed.
+	Changes *writeChanges `json:"changes,omitempty"`
+}
+
+// writeChanges summarizes the lines an overwrite changed.
+type writeChanges struct {
+	LinesAdded   int `json:"linesAdded"`
+	LinesRemoved int `json:"linesRemoved"`
+	// Diff is a unified diff of the change, when requested and not empty.
+	Diff string `json:"diff,omitempty"`
 }
 
 // writeError defines the error response format.
@@ -48,7 +74,7 @@ func (t *WriteTool) Name() string {
 
 // Description returns a human-readable description of the tool.
 func (t *WriteTool) Description() string {
-	return "Write content to a file, creating or overwriting as needed"
+	return "Write content to a file, creating or overwriting as needed. Use mode create for new files so an existing file is never replaced, and pass base_hash (the sha256 from read) when overwriting so a file changed since you read it is not clobbered"
 }
 
 // InputSchema returns the JSON Schema for the tool's input parameters.
@@ -58,12 +84,27 @@ func (t *WriteTool) InputSchema() json.RawMessage {
 		"properties": {
 			"path": {"type": "string", "description": "Absolute or relative file path"},
 			"content": {"type": "string", "description": "Content to write to the file"},
-			"mode": {"type": "string", "enum": ["overwrite", "append"], "description": "Write mode: overwrite (default) or append"}
+			"mode": {"type": "string", "enum": ["overwrite", "append", "create"], "description": "Write mode: overwrite (default), append, or create (fail if the file exists)"},
+			"base_hash": {"type": "string", "description": "Overwrite only if the file's current SHA-256 matches this hex digest, as returned by read"},
+			"diff": {"type": "boolean", "description": "When overwriting a file, include a unified diff of the change in the result"}
 		},
 		"required": ["path", "content"]
 	}`)
 }
 
+// ChangedPaths returns the file the write creates or replaces.
+func (t *WriteTool) ChangedPaths(ctx context.Context, input json.RawMessage) []string {
+	var params writeInput
+	if json.Unmarshal(input, &params) != nil || params.Path == "" {
+		return nil
+	}
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return nil
+	}
+	return []string{resolved}
+}
+
 // Execute writes content to the specified file.
 func (t *WriteTool) Execute(ctx context.Context, input json.RawMessage) (string, error) {
 	var params writeInput
@@ -83,8 +124,14 @@ func (t *WriteTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		return formatWriteError("path is required"), nil
 	}
 
+	// Resolve path within the workspace, if any
+	resolved, err := ResolvePath(ctx, params.Path)
+	if err != nil {
+		return formatWriteError(err.Error()), nil
+	}
+
 	// Resolve to absolute path
-	absPath, err := filepath.Abs(params.Path)
+	absPath, err := filepath.Abs(resolved)
 	if err != nil {
 		return formatWriteError("invalid path: " + err.Error()), nil
 	}
@@ -100,8 +147,39 @@ func (t *WriteTool) Execute(ctx context.Context, input json.RawMessage) (string,
 	if mode == "" {
 		mode = "overwrite"
 	}
-	if mode != "overwrite" && mode != "append" {
-		return formatWriteError("mode must be 'overwrite' or 'append'"), nil
+	if mode != "overwrite" && mode != "append" && mode != "create" {
+		return formatWriteError("mode must be 'overwrite', 'append', or 'create'"), nil
+	}
+	baseHash := params.BaseHash
+	if baseHash == "" {
+		baseHash = params.ExpectedSHA256
+	}
+	if baseHash != "" && mode != "overwrite" {
+		return formatWriteError("base_hash is only supported in overwrite mode"), nil
+	}
+
+	// Refuse to replace existing files when asked to
+	if mode == "create" && info != nil {
+		return formatWriteError(fmt.Sprintf("file already exists: %s (use mode overwrite to replace it)", params.Path)), nil
+	}
+	if baseHash != "" {
+		if info == nil {
+			return formatWriteError(fmt.Sprintf("file not found: %s (base_hash requires an existing file)", params.Path)), nil
+		}
+		current, err := fileSHA256(absPath)
+		if err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				return formatWriteError("permission denied"), nil
+			}
+			return formatWriteError("failed to read file: " + err.Error()), nil
+		}
+		if !matchesBase(baseHash, current) {
+			conflict, err := fileConflict(absPath, baseHash, current)
+			if err != nil {
+				return formatWriteError(fmt.Sprintf("file has changed since it was read: expected sha256 %s, found %s; read it again before overwriting", baseHash, current)), nil
+			}
+			return formatConflict(conflict), nil
+		}
 	}
 
 	// Create parent directories if needed
@@ -119,9 +197,31 @@ func (t *WriteTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		fileMode = info.Mode()
 	}
 
+	// Keep the content being replaced, to report what the overwrite changed
+	var previous *string
+	if mode == "overwrite" && info != nil && info.Mode().IsRegular() && info.Size() <= maxWriteDiffFileSize {
+		if data, err := os.ReadFile(absPath); err == nil {
+			content := string(data)
+			previous = &content
+		}
+	}
+
 	var bytesWritten int
 
-	if mode == "append" {
+	switch mode {
+	case "create":
+		// Exclusive create, so a file appearing since the check is not replaced
+		bytesWritten, err = createWrite(absPath, params.Content, defaultFilePermissions)
+		if err != nil {
+			if errors.Is(err, os.ErrExist) {
+				return formatWriteError(fmt.Sprintf("file already exists: %s (use mode overwrite to replace it)", params.Path)), nil
+			}
+			if errors.Is(err, os.ErrPermission) {
+				return formatWriteError("permission denied"), nil
+			}
+			return formatWriteError(err.Error()), nil
+		}
+	case "append":
 		// Append mode: open existing file or create new one
 		f, err := os.OpenFile(absPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, defaultFilePermissions)
 		if err != nil {
@@ -137,7 +237,7 @@ func (t *WriteTool) Execute(ctx context.Context, input json.RawMessage) (string,
 			return formatWriteError("failed to write: " + err.Error()), nil
 		}
 		bytesWritten = n
-	} else {
+	default:
 		// Overwrite mode: atomic write using temp file + rename
 		bytesWritten, err = atomicWrite(absPath, params.Content, fileMode)
 		if err != nil {
@@ -148,7 +248,75 @@ func (t *WriteTool) Execute(ctx context.Context, input json.RawMessage) (string,
 		}
 	}
 
-	return formatWriteSuccess(bytesWritten, absPath), nil
+	sum, err := fileSHA256(absPath)
+	if err != nil {
+		return formatWriteError("failed to read file after writing: " + err.Error()), nil
+	}
+	output := writeOutput{BytesWritten: bytesWritten, Path: absPath, SHA256: sum}
+	if previous != nil {
+		output.Changes = diffWrite(params.Path, *previous, params.Content, params.Diff)
+	}
+	return formatWriteSuccess(output), nil
+}
+
+// diffWrite summarizes the change from before to after, with a unified diff
+// if withDiff is set.
+func diffWrite(name, before, after string, withDiff bool) *writeChanges {
+	a, b := contentLines(before), contentLines(after)
+	changes := &writeChanges{}
+	for _, l := range diffLines(a, b) {
+		switch l.kind {
+		case '+':
+			changes.LinesAdded++
+		case '-':
+			changes.LinesRemoved++
+		}
+	}
+	if withDiff {
+		changes.Diff = unifiedDiff(name, a, b)
+	}
+	return changes
+}
+
+// contentLines splits content into lines, without an empty line after a
+// final newline.
+func contentLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// createWrite writes content to a new file, failing with os.ErrExist if the
+// file already exists. A partially written file is removed.
+func createWrite(path, content string, perm os.FileMode) (int, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return 0, err
+	}
+	n, err := f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+	return n, nil
+}
+
+// fileSHA256 returns the hex SHA-256 digest of a file's content.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 // atomicWrite writes content to a temporary file and renames it to the target path.
@@ -197,11 +365,7 @@ func atomicWrite(path, content string, perm os.FileMode) (int, error) {
 }
 
 // formatWriteSuccess formats a successful write response.
-func formatWriteSuccess(bytesWritten int, path string) string {
-	output := writeOutput{
-		BytesWritten: bytesWritten,
-		Path:         path,
-	}
+func formatWriteSuccess(output writeOutput) string {
 	data, _ := json.Marshal(output)
 	return string(data)
 }
@@ -396,3 +396,129 @@ func TestWriteTool_AbsolutePath(t *testing.T) {
 		t.Errorf("expected absolute path, got '%s'", output.Path)
 	}
 }
+
+func TestWriteTool_CreateMode(t *testing.T) {
+	tool := NewWriteTool()
+	ctx := context.Background()
+
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "new.txt")
+
+	input, _ := json.Marshal(map[string]string{"path": filePath, "content": "first", "mode": "create"})
+	result, _ := tool.Execute(ctx, input)
+	var output writeOutput
+	json.Unmarshal([]byte(result), &output)
+	if output.BytesWritten != 5 {
+		t.Fatalf("expected create to succeed, got %s", result)
+	}
+
+	// A second create must not replace the file
+	input, _ = json.Marshal(map[string]string{"path": filePath, "content": "second", "mode": "create"})
+	result, _ = tool.Execute(ctx, input)
+	var errOutput writeError
+	json.Unmarshal([]byte(result), &errOutput)
+	if !strings.Contains(errOutput.Error, "already exists") {
+		t.Errorf("expected already exists error, got %s", result)
+	}
+	if content, _ := os.ReadFile(filePath); string(content) != "first" {
+		t.Errorf("expected file to be unchanged, got %q", content)
+	}
+}
+
+func TestWriteTool_ExpectedSHA256(t *testing.T) {
+	tool := NewWriteTool()
+	ctx := context.Background()
+
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "guarded.txt")
+	os.WriteFile(filePath, []byte("original"), 0644)
+
+	// The digest comes from read
+	readInput, _ := json.Marshal(map[string]string{"path": filePath})
+	readResult, _ := NewReadTool().Execute(ctx, readInput)
+	sum := parseReadResult(t, readResult).SHA256
+	if sum != "0682c5f2076f099c34cfdd15a9e063849ed437a49677e6fcc5b4198c76575be5" {
+		t.Fatalf("unexpected sha256 from read: %q", sum)
+	}
+
+	// The file changes behind the model's back
+	os.WriteFile(filePath, []byte("changed"), 0644)
+	input, _ := json.Marshal(map[string]string{"path": filePath, "content": "mine", "expected_sha256": sum})
+	result, _ := tool.Execute(ctx, input)
+	var errOutput writeError
+	json.Unmarshal([]byte(result), &errOutput)
+	if !strings.Contains(errOutput.Error, "changed since it was read") {
+		t.Errorf("expected a changed file error, got %s", result)
+	}
+	if content, _ := os.ReadFile(filePath); string(content) != "changed" {
+		t.Errorf("expected file to be unchanged, got %q", content)
+	}
+
+	// With the current digest the overwrite succeeds and reports the new one
+	input, _ = json.Marshal(map[string]string{"path": filePath, "content": "mine", "expected_sha256": strings.ToUpper(fileDigest(t, filePath))})
+	result, _ = tool.Execute(ctx, input)
+	var output writeOutput
+	json.Unmarshal([]byte(result), &output)
+	if output.BytesWritten != 4 || output.SHA256 != fileDigest(t, filePath) {
+		t.Errorf("expected overwrite to succeed, got %s", result)
+	}
+
+	for _, params := range []map[string]string{
+		{"path": filepath.Join(tmpDir, "missing.txt"), "content": "x", "expected_sha256": sum},
+		{"path": filePath, "content": "x", "expected_sha256": sum, "mode": "append"},
+	} {
+		input, _ = json.Marshal(params)
+		result, _ = tool.Execute(ctx, input)
+		if !IsErrorResult(result) {
+			t.Errorf("expected an error for %v, got %s", params, result)
+		}
+	}
+}
+
+// fileDigest returns the hex SHA-256 of a file's content.
+func fileDigest(t *testing.T, path string) string {
+	t.Helper()
+	sum, err := fileSHA256(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sum
+}
+
+func TestWriteTool_OverwriteChanges(t *testing.T) {
+	tool := NewWriteTool()
+	filePath := filepath.Join(t.TempDir(), "notes.txt")
+	write := func(input string) writeOutput {
+		t.Helper()
+		result, err := tool.Execute(context.Background(), json.RawMessage(input))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var output writeOutput
+		if err := json.Unmarshal([]byte(result), &output); err != nil || output.Path == "" {
+			t.Fatalf("unexpected result %s", result)
+		}
+		return output
+	}
+
+	// A new file has no changes to report
+	if output := write(`{"path": "` + filePath + `", "content": "one\ntwo\nthree\n"}`); output.Changes != nil {
+		t.Errorf("expected no changes for a new file, got %+v", output.Changes)
+	}
+
+	output := write(`{"path": "` + filePath + `", "content": "one\n2\nthree\nfour\n"}`)
+	if output.Changes == nil || output.Changes.LinesAdded != 2 || output.Changes.LinesRemoved != 1 || output.Changes.Diff != "" {
+		t.Fatalf("expected 2 lines added and 1 removed without a diff, got %+v", output.Changes)
+	}
+
+	output = write(`{"path": "` + filePath + `", "content": "one\n2\nthree\n", "diff": true}`)
+	want := "--- " + filePath + "\n+++ " + filePath + "\n@@ -1,4 +1,3 @@\n one\n 2\n three\n-four\n"
+	if output.Changes == nil || output.Changes.LinesRemoved != 1 || output.Changes.Diff != want {
+		t.Errorf("expected the diff\n%s\ngot %+v", want, output.Changes)
+	}
+
+	// Appending reports no changes
+	if output := write(`{"path": "` + filePath + `", "content": "five\n", "mode": "append"}`); output.Changes != nil {
+		t.Errorf("expected no changes for an append, got %+v", output.Changes)
+	}
+}
@@ -0,0 +1,54 @@
+package toolapi
+
+import "context"
+
+// Emitter receives custom events emitted by a tool while it executes.
+// The harness installs an Emitter in the context passed to Execute so tools
+// can report progress (e.g., per-test results) before returning.
+type Emitter interface {
+	// Emit publishes an event of the given type. payload must be JSON-marshalable.
+	Emit(eventType string, payload any)
+}
+
+// EmitterFunc adapts an ordinary function to the Emitter interface.
+type EmitterFunc func(eventType string, payload any)
+
+// Emit calls f(eventType, payload).
+func (f EmitterFunc) Emit(eventType string, payload any) {
+	f(eventType, payload)
+}
+
+// emitterKey is the context key for the Emitter.
+type emitterKey struct{}
+
+// WithEmitter returns a copy of ctx carrying the given Emitter.
+func WithEmitter(ctx context.Context, e Emitter) context.Context {
+	return context.WithValue(ctx, emitterKey{}, e)
+}
+
+// Emit publishes a custom event through the Emitter in ctx.
+// It is a no-op when no Emitter is present, so tools can call it unconditionally.
+func Emit(ctx context.Context, eventType string, payload any) {
+	if e, ok := ctx.Value(emitterKey{}).(Emitter); ok && e != nil {
+		e.Emit(eventType, payload)
+	}
+}
+
+// OutputEventType is the reserved event type for incremental process output.
+// The harness delivers these events to output handlers rather than as custom
+// tool events, so they are not subject to event type filtering.
+const OutputEventType = "tool_output"
+
+// Output is the payload of an OutputEventType event.
+type Output struct {
+	// Stream is "stdout" or "stderr".
+	Stream string `json:"stream"`
+	// Text is the output produced since the previous event on the stream.
+	Text string `json:"text"`
+}
+
+// EmitOutput publishes a chunk of a tool's stdout or stderr as it is produced.
+// Like Emit, it is a no-op when no Emitter is present.
+func EmitOutput(ctx context.Context, stream, text string) {
+	Emit(ctx, OutputEventType, Output{Stream: stream, Text: text})
+}
@@ -0,0 +1,3 @@
+module github.com/user/harness/pkg/toolapi
+
+go 1.23.0
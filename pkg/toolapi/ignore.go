@@ -0,0 +1,30 @@
+package toolapi
+
+import "context"
+
+// ignoreKey is the context key for the global ignore patterns.
+type ignoreKey struct{}
+
+// DefaultIgnorePatterns are the global ignore patterns used when ctx carries
+// none: version control metadata, dependency trees, and compiled binaries.
+var DefaultIgnorePatterns = []string{
+	".git/", ".hg/", ".svn/",
+	"node_modules/", "vendor/",
+	"*.exe", "*.dll", "*.so", "*.dylib", "*.o", "*.a", "*.class", "*.pyc", "*.wasm",
+}
+
+// WithIgnorePatterns returns a copy of ctx whose recursive tools skip paths
+// matching patterns, in .gitignore syntax, at any depth, in addition to
+// .gitignore and .ignore files. An empty list disables global patterns.
+func WithIgnorePatterns(ctx context.Context, patterns []string) context.Context {
+	return context.WithValue(ctx, ignoreKey{}, patterns)
+}
+
+// IgnorePatterns returns the global ignore patterns in ctx, or
+// DefaultIgnorePatterns if ctx has none.
+func IgnorePatterns(ctx context.Context) []string {
+	if patterns, ok := ctx.Value(ignoreKey{}).([]string); ok {
+		return patterns
+	}
+	return DefaultIgnorePatterns
+}
@@ -0,0 +1,32 @@
+package toolapi
+
+import "context"
+
+// Image is an image a tool attaches to its result for the model to view.
+type Image struct {
+	// MediaType is the image's MIME type, e.g. "image/png" or "image/jpeg".
+	MediaType string
+	// Data is the raw image data.
+	Data []byte
+}
+
+// imageSinkKey is the context key for the image sink.
+type imageSinkKey struct{}
+
+// WithImageSink returns a copy of ctx whose tools can attach images to their
+// result with AttachImage. The harness installs a sink only when the model
+// accepts images in tool results.
+func WithImageSink(ctx context.Context, sink func(Image)) context.Context {
+	return context.WithValue(ctx, imageSinkKey{}, sink)
+}
+
+// AttachImage attaches img to the result of the tool call running with ctx.
+// It reports false, attaching nothing, when ctx has no image sink.
+func AttachImage(ctx context.Context, img Image) bool {
+	sink, ok := ctx.Value(imageSinkKey{}).(func(Image))
+	if !ok || sink == nil {
+		return false
+	}
+	sink(img)
+	return true
+}
@@ -0,0 +1,59 @@
+package toolapi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Error is a structured tool error. Tools report expected failures (missing
+// files, invalid input) as an Error result the model can read and react to,
+// rather than as a Go error, which is reserved for cancellation and other
+// conditions the harness itself must handle.
+type Error struct {
+	// Message is the human-readable error description shown to the model.
+	Message string `json:"error"`
+	// Code is an optional machine-readable error code (e.g., "not_found").
+	Code string `json:"code,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errorf creates an Error with a formatted message.
+func Errorf(format string, args ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// String returns the JSON result string for the error.
+func (e *Error) String() string {
+	data, _ := json.Marshal(e)
+	return string(data)
+}
+
+// Success formats v as a JSON result string.
+// v should marshal to a JSON object.
+func Success(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Failure("failed to encode result: " + err.Error())
+	}
+	return string(data)
+}
+
+// Failure formats msg as a JSON error result: {"error": msg}.
+func Failure(msg string) string {
+	return (&Error{Message: msg}).String()
+}
+
+// IsErrorResult reports whether a tool result string is a structured error
+// response, i.e. a JSON object with a top-level "error" field.
+func IsErrorResult(result string) bool {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(result), &obj); err != nil {
+		return false
+	}
+	_, ok := obj["error"]
+	return ok
+}
@@ -0,0 +1,80 @@
+package toolapi
+
+import "encoding/json"
+
+// Property describes one input parameter in a tool's JSON Schema.
+type Property struct {
+	name     string
+	required bool
+	schema   map[string]any
+}
+
+// newProperty creates a property of the given JSON type.
+func newProperty(name, typ, description string) Property {
+	schema := map[string]any{"type": typ}
+	if description != "" {
+		schema["description"] = description
+	}
+	return Property{name: name, schema: schema}
+}
+
+// String declares a string parameter.
+func String(name, description string) Property {
+	return newProperty(name, "string", description)
+}
+
+// Integer declares an integer parameter.
+func Integer(name, description string) Property {
+	return newProperty(name, "integer", description)
+}
+
+// Number declares a numeric parameter.
+func Number(name, description string) Property {
+	return newProperty(name, "number", description)
+}
+
+// Boolean declares a boolean parameter.
+func Boolean(name, description string) Property {
+	return newProperty(name, "boolean", description)
+}
+
+// Array declares an array parameter whose items have the given JSON type.
+func Array(name, itemType, description string) Property {
+	p := newProperty(name, "array", description)
+	p.schema["items"] = map[string]any{"type": itemType}
+	return p
+}
+
+// Required marks the property as required.
+func (p Property) Required() Property {
+	p.required = true
+	return p
+}
+
+// Enum restricts the property to the given values.
+func (p Property) Enum(values ...string) Property {
+	p.schema["enum"] = values
+	return p
+}
+
+// Schema builds an object JSON Schema from the given properties, suitable
+// for returning from Tool.InputSchema.
+func Schema(props ...Property) json.RawMessage {
+	properties := make(map[string]any, len(props))
+	required := []string{}
+	for _, p := range props {
+		properties[p.name] = p.schema
+		if p.required {
+			required = append(required, p.name)
+		}
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
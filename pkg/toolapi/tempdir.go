@@ -0,0 +1,46 @@
+package toolapi
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// tempDirKey is the context key for the run's temporary directory.
+type tempDirKey struct{}
+
+// runTempDir is a run-scoped scratch directory shared by all tool calls in
+// the run.
+type runTempDir struct {
+	path string
+	keep atomic.Bool
+}
+
+// WithTempDir returns a copy of ctx carrying dir as the run's temporary
+// directory. The harness creates the directory and removes it when the run
+// ends unless a tool calls KeepTempDir.
+func WithTempDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, tempDirKey{}, &runTempDir{path: dir})
+}
+
+// TempDir returns the run's temporary directory in ctx, or "" if none.
+func TempDir(ctx context.Context) string {
+	if td, ok := ctx.Value(tempDirKey{}).(*runTempDir); ok {
+		return td.path
+	}
+	return ""
+}
+
+// KeepTempDir marks the run's temporary directory in ctx as holding
+// artifacts worth keeping, so it survives the end of the run.
+// It is a no-op without a temporary directory.
+func KeepTempDir(ctx context.Context) {
+	if td, ok := ctx.Value(tempDirKey{}).(*runTempDir); ok {
+		td.keep.Store(true)
+	}
+}
+
+// TempDirKept reports whether KeepTempDir was called for the run in ctx.
+func TempDirKept(ctx context.Context) bool {
+	td, ok := ctx.Value(tempDirKey{}).(*runTempDir)
+	return ok && td.keep.Load()
+}
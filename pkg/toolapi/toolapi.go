@@ -0,0 +1,96 @@
+// Package toolapi is the stable, minimal contract for writing tools that can
+// be used by the Harness agent. It depends only on the standard library so
+// third-party tools can implement it without importing the harness, the
+// server, or the Anthropic SDK.
+//
+// A tool implements Tool and may additionally implement any of the optional
+// interfaces (ReadOnlyTool, DisplayTool, Releaser, TimeoutProvider,
+// PathReporter) to opt into harness features. Results follow a simple
+// contract: a JSON object on success and {"error": "..."} on failure; see
+// Success and Failure.
+package toolapi
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Tool defines the interface that all tools must implement to be usable
+// by the Harness agent. Tools are independent units that can be called
+// by the AI to perform specific operations like reading files, listing
+// directories, or searching with grep.
+type Tool interface {
+	// Name returns the unique identifier for this tool.
+	// This name is used by the AI to invoke the tool.
+	Name() string
+
+	// Description returns a human-readable description of what the tool does.
+	// This helps the AI understand when to use the tool.
+	Description() string
+
+	// InputSchema returns the JSON Schema defining the expected input parameters.
+	// The schema is used for validation and to inform the AI of expected inputs.
+	InputSchema() json.RawMessage
+
+	// Execute runs the tool with the given input and returns the result.
+	// The input is JSON that conforms to InputSchema.
+	// Returns the tool output as a string (JSON formatted for structured output).
+	// Returns an error if the tool execution fails.
+	Execute(ctx context.Context, input json.RawMessage) (string, error)
+}
+
+// ReadOnlyTool is an optional interface for tools that never modify the
+// filesystem or environment. Tools that do not implement it are treated as
+// mutating by policies such as the harness fail-safe mode.
+type ReadOnlyTool interface {
+	// ReadOnly reports whether the tool is free of side effects.
+	ReadOnly() bool
+}
+
+// IsReadOnly reports whether t declares itself read-only.
+func IsReadOnly(t Tool) bool {
+	ro, ok := t.(ReadOnlyTool)
+	return ok && ro.ReadOnly()
+}
+
+// Display is a client-facing rendering hint attached to a tool result.
+// It is forwarded to UIs only and never sent to the model.
+type Display struct {
+	// Kind identifies how to render the payload (e.g., "diff", "table", "filetree").
+	Kind string `json:"kind"`
+	// Payload is the kind-specific data. Must be JSON-marshalable.
+	Payload any `json:"payload"`
+}
+
+// DisplayTool is an optional interface for tools that attach a Display to
+// their result. When implemented, the harness calls ExecuteWithDisplay
+// instead of Execute. The returned Display may be nil.
+type DisplayTool interface {
+	ExecuteWithDisplay(ctx context.Context, input json.RawMessage) (string, *Display, error)
+}
+
+// Releaser is an optional interface for tools that hold heavyweight resources
+// (persistent shells, indexes, caches). Release frees them; the tool must
+// recreate them lazily if it is used again.
+type Releaser interface {
+	Release()
+}
+
+// TimeoutProvider is an optional interface for tools that need a different
+// execution time limit than the harness default.
+type TimeoutProvider interface {
+	// Timeout returns the tool's time limit. Zero uses the harness default;
+	// a negative value disables the limit.
+	Timeout() time.Duration
+}
+
+// PathReporter is an optional interface for tools that change files. The
+// harness calls ChangedPaths before executing the tool, to snapshot the
+// files a rollback restores.
+type PathReporter interface {
+	// ChangedPaths returns the resolved paths of the files the call with
+	// input may create, modify, or remove. Paths it cannot determine are
+	// omitted.
+	ChangedPaths(ctx context.Context, input json.RawMessage) []string
+}
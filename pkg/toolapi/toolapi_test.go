@@ -0,0 +1,116 @@
+package toolapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestSuccessAndFailure(t *testing.T) {
+	ok := Success(map[string]int{"count": 2})
+	if ok != `{"count":2}` {
+		t.Errorf("unexpected success result: %s", ok)
+	}
+	if IsErrorResult(ok) {
+		t.Error("success result should not be an error result")
+	}
+
+	fail := Failure("file not found")
+	if fail != `{"error":"file not found"}` {
+		t.Errorf("unexpected failure result: %s", fail)
+	}
+	if !IsErrorResult(fail) {
+		t.Error("failure result should be an error result")
+	}
+}
+
+func TestError_String(t *testing.T) {
+	e := &Error{Message: "denied", Code: "permission_denied"}
+	if e.Error() != "denied" {
+		t.Errorf("unexpected Error(): %q", e.Error())
+	}
+	if e.String() != `{"error":"denied","code":"permission_denied"}` {
+		t.Errorf("unexpected String(): %s", e.String())
+	}
+	if !IsErrorResult(Errorf("bad %s", "input").String()) {
+		t.Error("Errorf result should be an error result")
+	}
+}
+
+func TestIsErrorResult(t *testing.T) {
+	cases := map[string]bool{
+		`{"error":"boom"}`: true,
+		`{"content":"ok"}`: false,
+		`not json`:         false,
+		`["error"]`:        false,
+	}
+	for input, want := range cases {
+		if got := IsErrorResult(input); got != want {
+			t.Errorf("IsErrorResult(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	raw := Schema(
+		String("path", "File path").Required(),
+		Integer("limit", ""),
+		Array("names", "string", "Names").Required(),
+		String("mode", "").Enum("a", "b"),
+	)
+
+	var schema struct {
+		Type       string                    `json:"type"`
+		Properties map[string]map[string]any `json:"properties"`
+		Required   []string                  `json:"required"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatalf("invalid schema JSON: %v", err)
+	}
+	if schema.Type != "object" {
+		t.Errorf("expected object type, got %q", schema.Type)
+	}
+	if len(schema.Properties) != 4 {
+		t.Errorf("expected 4 properties, got %d", len(schema.Properties))
+	}
+	if schema.Properties["path"]["description"] != "File path" {
+		t.Errorf("missing description on path: %v", schema.Properties["path"])
+	}
+	if _, ok := schema.Properties["limit"]["description"]; ok {
+		t.Error("empty description should be omitted")
+	}
+	if len(schema.Required) != 2 || schema.Required[0] != "path" || schema.Required[1] != "names" {
+		t.Errorf("unexpected required list: %v", schema.Required)
+	}
+}
+
+func TestEmit(t *testing.T) {
+	// No emitter installed: must not panic.
+	Emit(context.Background(), "noop", nil)
+
+	var got []string
+	ctx := WithEmitter(context.Background(), EmitterFunc(func(eventType string, payload any) {
+		got = append(got, eventType)
+	}))
+	Emit(ctx, "progress", map[string]int{"done": 1})
+	if len(got) != 1 || got[0] != "progress" {
+		t.Errorf("unexpected events: %v", got)
+	}
+}
+
+func TestAttachImage(t *testing.T) {
+	if AttachImage(context.Background(), Image{MediaType: "image/png"}) {
+		t.Error("expected AttachImage to report false without a sink")
+	}
+
+	var got []Image
+	ctx := WithImageSink(context.Background(), func(img Image) {
+		got = append(got, img)
+	})
+	if !AttachImage(ctx, Image{MediaType: "image/png", Data: []byte("png")}) {
+		t.Error("expected AttachImage to report true with a sink")
+	}
+	if len(got) != 1 || got[0].MediaType != "image/png" || string(got[0].Data) != "png" {
+		t.Errorf("unexpected images: %v", got)
+	}
+}
@@ -0,0 +1,111 @@
+package toolapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// workspaceKey is the context key for the workspace root.
+type workspaceKey struct{}
+
+// WithWorkspace returns a copy of ctx that jails tool paths to root.
+// root is made absolute; an empty root removes the restriction.
+func WithWorkspace(ctx context.Context, root string) context.Context {
+	if root != "" {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+	}
+	return context.WithValue(ctx, workspaceKey{}, root)
+}
+
+// Workspace returns the workspace root in ctx, or "" if tools are not jailed.
+func Workspace(ctx context.Context) string {
+	root, _ := ctx.Value(workspaceKey{}).(string)
+	return root
+}
+
+// ResolvePath maps a tool path argument into the workspace in ctx.
+// Relative paths are resolved against the workspace root. Paths that leave
+// the workspace, directly or through a symlink, are rejected, except paths
+// inside the run's temporary directory (see TempDir).
+// Without a workspace the path is returned unchanged.
+func ResolvePath(ctx context.Context, path string) (string, error) {
+	root := Workspace(ctx)
+	if root == "" {
+		return path, nil
+	}
+
+	resolved := path
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(root, resolved)
+	}
+	resolved = filepath.Clean(resolved)
+
+	// Follow symlinks in the existing part of the path.
+	real, err := evalExisting(resolved)
+	if err != nil {
+		return "", err
+	}
+	if ok, err := jailed(root, resolved, real); err != nil {
+		return "", fmt.Errorf("workspace unavailable: %w", err)
+	} else if ok {
+		return resolved, nil
+	}
+	if tmp := TempDir(ctx); tmp != "" {
+		if ok, _ := jailed(tmp, resolved, real); ok {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("path %q is outside the workspace", path)
+}
+
+// jailed reports whether resolved, and real (resolved with symlinks
+// followed), both stay inside root.
+func jailed(root, resolved, real string) (bool, error) {
+	if !within(root, resolved) {
+		return false, nil
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false, err
+	}
+	return within(realRoot, real), nil
+}
+
+// within reports whether path is root or inside it. Both must be clean.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// evalExisting resolves symlinks in the longest existing prefix of path and
+// appends the remaining, not yet existing, components.
+func evalExisting(path string) (string, error) {
+	rest := ""
+	current := path
+	for {
+		if _, err := os.Lstat(current); err == nil {
+			real, err := filepath.EvalSymlinks(current)
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(real, rest), nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return path, nil
+		}
+		rest = filepath.Join(filepath.Base(current), rest)
+		current = parent
+	}
+}
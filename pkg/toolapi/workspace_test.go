@@ -0,0 +1,84 @@
+package toolapi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePath_NoWorkspace(t *testing.T) {
+	got, err := ResolvePath(context.Background(), "../anything")
+	if err != nil || got != "../anything" {
+		t.Errorf("expected path unchanged, got %q, %v", got, err)
+	}
+}
+
+func TestResolvePath_Jail(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithWorkspace(context.Background(), root)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"src/main.go", filepath.Join(root, "src", "main.go"), false},
+		{".", root, false},
+		{filepath.Join(root, "new", "file.txt"), filepath.Join(root, "new", "file.txt"), false},
+		{"../secret", "", true},
+		{"src/../../secret", "", true},
+		{filepath.Join(outside, "file"), "", true},
+		{"escape/file", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ResolvePath(ctx, tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ResolvePath(%q): expected error, got %q", tt.path, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ResolvePath(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolvePath_TempDirAllowed(t *testing.T) {
+	root := t.TempDir()
+	tmp := t.TempDir()
+	ctx := WithTempDir(WithWorkspace(context.Background(), root), tmp)
+
+	path := filepath.Join(tmp, "scratch", "out.txt")
+	if got, err := ResolvePath(ctx, path); err != nil || got != path {
+		t.Errorf("expected temp dir path allowed, got %q, %v", got, err)
+	}
+	if _, err := ResolvePath(ctx, filepath.Join(filepath.Dir(tmp), "other")); err == nil {
+		t.Error("expected path beside the temp dir to be rejected")
+	}
+}
+
+func TestKeepTempDir(t *testing.T) {
+	ctx := context.Background()
+	KeepTempDir(ctx) // no-op without a temp dir
+	if TempDirKept(ctx) || TempDir(ctx) != "" {
+		t.Error("expected no temp dir")
+	}
+
+	ctx = WithTempDir(ctx, "/tmp/run")
+	if TempDir(ctx) != "/tmp/run" || TempDirKept(ctx) {
+		t.Error("expected unkept temp dir")
+	}
+	KeepTempDir(ctx)
+	if !TempDirKept(ctx) {
+		t.Error("expected temp dir kept")
+	}
+}